@@ -0,0 +1,55 @@
+-- Migration: 004_qualify_soft_delete_functions
+-- Description: Accept schema-qualified table names in the soft delete functions and stamp deleted_at in UTC
+-- Created: 2026-10-18
+
+-- UP Migration
+
+-- format('%I') quoted 'my_service.my_table' as one identifier, so the functions
+-- only worked for tables on the search_path. Casting to regclass resolves both
+-- qualified and unqualified names and quotes each part.
+
+-- Function to soft delete a record
+CREATE OR REPLACE FUNCTION soft_delete_record(table_name TEXT, record_id INTEGER)
+RETURNS BOOLEAN AS $$
+DECLARE
+    query TEXT;
+    affected INTEGER;
+BEGIN
+    query := format('UPDATE %s SET deleted_at = get_utc_timestamp() WHERE id = $1 AND deleted_at IS NULL', table_name::regclass);
+    EXECUTE query USING record_id;
+
+    GET DIAGNOSTICS affected = ROW_COUNT;
+    RETURN affected > 0;
+END;
+$$ language 'plpgsql';
+
+-- Function to restore a soft deleted record
+CREATE OR REPLACE FUNCTION restore_deleted_record(table_name TEXT, record_id INTEGER)
+RETURNS BOOLEAN AS $$
+DECLARE
+    query TEXT;
+    affected INTEGER;
+BEGIN
+    query := format('UPDATE %s SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL', table_name::regclass);
+    EXECUTE query USING record_id;
+
+    GET DIAGNOSTICS affected = ROW_COUNT;
+    RETURN affected > 0;
+END;
+$$ language 'plpgsql';
+
+-- Function to check if a record exists and is not soft deleted
+CREATE OR REPLACE FUNCTION record_exists_and_active(table_name TEXT, record_id INTEGER)
+RETURNS BOOLEAN AS $$
+DECLARE
+    query TEXT;
+    result BOOLEAN;
+BEGIN
+    query := format('SELECT EXISTS(SELECT 1 FROM %s WHERE id = $1 AND deleted_at IS NULL)', table_name::regclass);
+    EXECUTE query INTO result USING record_id;
+    RETURN result;
+END;
+$$ language 'plpgsql';
+
+-- DOWN Migration
+-- Re-run 002_create_utility_functions.sql to restore the previous definitions
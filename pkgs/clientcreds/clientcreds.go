@@ -0,0 +1,165 @@
+// Package clientcreds authenticates service-to-service gRPC calls with access
+// tokens from the OAuth 2.0 client credentials grant. Tokens are cached and
+// fetched again shortly before they expire, so callers never handle them.
+package clientcreds
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/your-project/pkgs/oauth2"
+)
+
+const (
+	// DefaultRefreshBefore is how long before expiry a cached token is replaced
+	DefaultRefreshBefore = time.Minute
+	// defaultLifetime applies to tokens returned without an expiry
+	defaultLifetime = 5 * time.Minute
+	// retryInterval spaces out fetches while a still-valid token is served after a failure
+	retryInterval = 5 * time.Second
+)
+
+// ErrNoToken is returned when a fetch succeeds without an access token
+var ErrNoToken = errors.New("clientcreds: token source returned no access token")
+
+// Token is an access token and the time it stops being accepted
+type Token struct {
+	AccessToken string
+	// Expiry is zero when the issuer did not say; the token is then kept for five minutes
+	Expiry time.Time
+}
+
+// FetchFunc obtains a new token, typically by calling the auth service's
+// IssueServiceToken RPC or an OAuth token endpoint
+type FetchFunc func(ctx context.Context) (*Token, error)
+
+// Credentials caches a token and attaches it to outgoing calls. It implements
+// grpc's credentials.PerRPCCredentials and is safe for concurrent use:
+//
+//	conn, err := grpc.Dial(target, grpc.WithPerRPCCredentials(creds), ...)
+type Credentials struct {
+	fetch         FetchFunc
+	refreshBefore time.Duration
+	allowInsecure bool
+	now           func() time.Time
+
+	mu        sync.Mutex
+	token     *Token
+	expiresAt time.Time
+	refreshAt time.Time
+}
+
+// Option configures Credentials
+type Option func(*Credentials)
+
+// WithRefreshBefore sets how long before expiry a token is replaced. Tokens
+// living less than twice this long are replaced at half their lifetime.
+func WithRefreshBefore(d time.Duration) Option {
+	return func(c *Credentials) {
+		c.refreshBefore = d
+	}
+}
+
+// WithInsecure allows sending tokens over connections without TLS, for local development
+func WithInsecure() Option {
+	return func(c *Credentials) {
+		c.allowInsecure = true
+	}
+}
+
+// WithClock overrides the time source, for tests
+func WithClock(now func() time.Time) Option {
+	return func(c *Credentials) {
+		c.now = now
+	}
+}
+
+// New creates credentials that obtain tokens from fetch
+func New(fetch FetchFunc, opts ...Option) *Credentials {
+	c := &Credentials{
+		fetch:         fetch,
+		refreshBefore: DefaultRefreshBefore,
+		now:           time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// FromTokenEndpoint fetches tokens from an OAuth token endpoint with the
+// client credentials grant, requesting cfg.Scopes
+func FromTokenEndpoint(cfg oauth2.Config, client *http.Client) FetchFunc {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context) (*Token, error) {
+		token, err := cfg.ClientCredentials(ctx, client)
+		if err != nil {
+			return nil, err
+		}
+		return &Token{AccessToken: token.AccessToken, Expiry: token.Expiry}, nil
+	}
+}
+
+// Token returns a valid access token, fetching a new one when the cached token
+// is due for refresh. If the fetch fails while the cached token has not yet
+// expired, the cached token is returned and the fetch retried a little later.
+func (c *Credentials) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if c.token != nil && now.Before(c.refreshAt) {
+		return c.token.AccessToken, nil
+	}
+
+	token, err := c.fetch(ctx)
+	if err == nil && (token == nil || token.AccessToken == "") {
+		err = ErrNoToken
+	}
+	if err != nil {
+		if c.token != nil && now.Before(c.expiresAt) {
+			c.refreshAt = now.Add(retryInterval)
+			return c.token.AccessToken, nil
+		}
+		return "", err
+	}
+
+	c.token = token
+	c.expiresAt = token.Expiry
+	if c.expiresAt.IsZero() {
+		c.expiresAt = now.Add(defaultLifetime)
+	}
+	lead := c.refreshBefore
+	if lifetime := c.expiresAt.Sub(now); lifetime < 2*lead {
+		lead = lifetime / 2
+	}
+	c.refreshAt = c.expiresAt.Add(-lead)
+	return token.AccessToken, nil
+}
+
+// Invalidate drops the cached token, for example after a call failed with
+// Unauthenticated because the issuer rotated its keys
+func (c *Credentials) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = nil
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials
+func (c *Credentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token, err := c.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"authorization": "Bearer " + token}, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials
+func (c *Credentials) RequireTransportSecurity() bool {
+	return !c.allowInsecure
+}
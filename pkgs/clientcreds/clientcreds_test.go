@@ -0,0 +1,159 @@
+package clientcreds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/your-project/pkgs/oauth2"
+	"github.com/your-project/pkgs/oauth2/oauth2test"
+)
+
+type fakeSource struct {
+	mu       sync.Mutex
+	calls    int
+	lifetime time.Duration
+	now      func() time.Time
+	err      error
+}
+
+func (s *fakeSource) fetch(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &Token{AccessToken: fmt.Sprintf("token-%d", s.calls), Expiry: s.now().Add(s.lifetime)}, nil
+}
+
+func newClock() (*time.Time, func() time.Time) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	return &now, func() time.Time { return now }
+}
+
+func TestTokenIsCachedAndRefreshed(t *testing.T) {
+	now, clock := newClock()
+	source := &fakeSource{lifetime: 5 * time.Minute, now: clock}
+	creds := New(source.fetch, WithClock(clock))
+	ctx := context.Background()
+
+	first, err := creds.Token(ctx)
+	if err != nil {
+		t.Fatalf("Token returned error: %v", err)
+	}
+	*now = now.Add(3 * time.Minute)
+	if token, _ := creds.Token(ctx); token != first || source.calls != 1 {
+		t.Errorf("expected cached token, got %q after %d fetches", token, source.calls)
+	}
+
+	// Within DefaultRefreshBefore of expiry
+	*now = now.Add(90 * time.Second)
+	if token, _ := creds.Token(ctx); token == first || source.calls != 2 {
+		t.Errorf("expected refreshed token, got %q after %d fetches", token, source.calls)
+	}
+}
+
+func TestShortLivedTokensRefreshAtHalfLife(t *testing.T) {
+	now, clock := newClock()
+	source := &fakeSource{lifetime: time.Minute, now: clock}
+	creds := New(source.fetch, WithClock(clock))
+	ctx := context.Background()
+
+	creds.Token(ctx)
+	*now = now.Add(29 * time.Second)
+	creds.Token(ctx)
+	if source.calls != 1 {
+		t.Fatalf("expected cached token before half life, got %d fetches", source.calls)
+	}
+	*now = now.Add(2 * time.Second)
+	creds.Token(ctx)
+	if source.calls != 2 {
+		t.Errorf("expected refresh after half life, got %d fetches", source.calls)
+	}
+}
+
+func TestFailedRefreshServesValidToken(t *testing.T) {
+	now, clock := newClock()
+	source := &fakeSource{lifetime: 5 * time.Minute, now: clock}
+	creds := New(source.fetch, WithClock(clock))
+	ctx := context.Background()
+
+	first, _ := creds.Token(ctx)
+	source.err = errors.New("auth service unavailable")
+
+	*now = now.Add(4*time.Minute + 30*time.Second)
+	if token, err := creds.Token(ctx); err != nil || token != first {
+		t.Fatalf("expected cached token while still valid, got %q, %v", token, err)
+	}
+	// Retries are spaced out instead of hitting the issuer on every call
+	creds.Token(ctx)
+	if source.calls != 2 {
+		t.Errorf("expected one failed refresh, got %d fetches", source.calls)
+	}
+
+	*now = now.Add(time.Minute)
+	if _, err := creds.Token(ctx); err == nil {
+		t.Error("expected error once the cached token expired")
+	}
+}
+
+func TestInvalidate(t *testing.T) {
+	_, clock := newClock()
+	source := &fakeSource{lifetime: time.Hour, now: clock}
+	creds := New(source.fetch, WithClock(clock))
+	ctx := context.Background()
+
+	first, _ := creds.Token(ctx)
+	creds.Invalidate()
+	if token, _ := creds.Token(ctx); token == first {
+		t.Error("expected a new token after Invalidate")
+	}
+}
+
+func TestConcurrentCallsShareOneFetch(t *testing.T) {
+	_, clock := newClock()
+	source := &fakeSource{lifetime: time.Hour, now: clock}
+	creds := New(source.fetch, WithClock(clock))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			creds.GetRequestMetadata(context.Background())
+		}()
+	}
+	wg.Wait()
+	if source.calls != 1 {
+		t.Errorf("expected a single fetch, got %d", source.calls)
+	}
+}
+
+func TestRequestMetadata(t *testing.T) {
+	provider := oauth2test.NewProvider("billing", "secret")
+	defer provider.Close()
+
+	creds := New(FromTokenEndpoint(oauth2.Config{
+		ClientID:     provider.ClientID,
+		ClientSecret: provider.ClientSecret,
+		TokenURL:     provider.TokenURL(),
+		Scopes:       []string{"users.read"},
+	}, http.DefaultClient))
+
+	md, err := creds.GetRequestMetadata(context.Background(), "https://users.internal")
+	if err != nil {
+		t.Fatalf("GetRequestMetadata returned error: %v", err)
+	}
+	token, _ := creds.Token(context.Background())
+	if md["authorization"] != "Bearer "+token {
+		t.Errorf("unexpected metadata %v", md)
+	}
+	if !creds.RequireTransportSecurity() || New(nil, WithInsecure()).RequireTransportSecurity() {
+		t.Error("transport security should be required unless WithInsecure is set")
+	}
+}
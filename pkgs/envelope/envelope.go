@@ -0,0 +1,223 @@
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Prefix marks values produced by Encrypt. The full format is
+// "enc:v1:<key id>:<wrapped data key>:<nonce || ciphertext>" with unpadded
+// base64url fields, so values fit in TEXT columns.
+const Prefix = "enc:v1:"
+
+// DataKeySize is the size of the per-value AES-256 data key
+const DataKeySize = 32
+
+// Envelope errors
+var (
+	// ErrNotEncrypted is returned by Decrypt for values without the envelope prefix
+	ErrNotEncrypted = errors.New("envelope: value is not encrypted")
+	// ErrMalformed is returned for values with the prefix that cannot be parsed
+	ErrMalformed = errors.New("envelope: malformed value")
+	// ErrUnknownKey is returned when a value was wrapped with a master key the provider does not have
+	ErrUnknownKey = errors.New("envelope: unknown master key")
+	// ErrDecrypt is returned when authentication fails, e.g. the value was
+	// tampered with or is decrypted with different associated data
+	ErrDecrypt = errors.New("envelope: decryption failed")
+)
+
+// encoding is used for every binary field of an envelope
+var encoding = base64.RawURLEncoding
+
+// KeyProvider wraps and unwraps data keys with versioned master keys. A KMS
+// implementation performs both operations remotely and never exposes the master key.
+type KeyProvider interface {
+	// CurrentKeyID is the master key new data keys are wrapped with
+	CurrentKeyID() string
+	// WrapKey encrypts a data key with the master key keyID
+	WrapKey(ctx context.Context, keyID string, dataKey []byte) ([]byte, error)
+	// UnwrapKey decrypts a data key wrapped by WrapKey; unknown key IDs return ErrUnknownKey
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// Encryptor seals values with a fresh AES-256-GCM data key each, wrapped by
+// the provider's current master key
+type Encryptor struct {
+	keys KeyProvider
+}
+
+// New creates an encryptor backed by the key provider
+func New(keys KeyProvider) *Encryptor {
+	return &Encryptor{keys: keys}
+}
+
+// IsEncrypted reports whether value carries the envelope prefix
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// KeyID returns the master key a value's data key is wrapped with
+func KeyID(value string) (string, error) {
+	parts, err := split(value)
+	if err != nil {
+		return "", err
+	}
+	return parts.keyID, nil
+}
+
+// Encrypt seals plaintext. aad binds the value to its context (for example the
+// table and column it is stored in) and must be passed to Decrypt unchanged.
+func (e *Encryptor) Encrypt(ctx context.Context, plaintext, aad []byte) (string, error) {
+	dataKey := make([]byte, DataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", fmt.Errorf("envelope: failed to generate data key: %w", err)
+	}
+
+	sealed, err := seal(dataKey, plaintext, aad)
+	if err != nil {
+		return "", err
+	}
+
+	keyID := e.keys.CurrentKeyID()
+	wrapped, err := e.keys.WrapKey(ctx, keyID, dataKey)
+	if err != nil {
+		return "", fmt.Errorf("envelope: failed to wrap data key: %w", err)
+	}
+
+	return join(envelope{keyID: keyID, wrapped: wrapped, sealed: sealed}), nil
+}
+
+// EncryptString is Encrypt for string values
+func (e *Encryptor) EncryptString(ctx context.Context, plaintext, aad string) (string, error) {
+	return e.Encrypt(ctx, []byte(plaintext), []byte(aad))
+}
+
+// Decrypt opens a value produced by Encrypt with the same aad
+func (e *Encryptor) Decrypt(ctx context.Context, value string, aad []byte) ([]byte, error) {
+	parts, err := split(value)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := e.keys.UnwrapKey(ctx, parts.keyID, parts.wrapped)
+	if err != nil {
+		return nil, err
+	}
+	return open(dataKey, parts.sealed, aad)
+}
+
+// DecryptString is Decrypt for string values
+func (e *Encryptor) DecryptString(ctx context.Context, value, aad string) (string, error) {
+	plaintext, err := e.Decrypt(ctx, value, []byte(aad))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// NeedsRewrap reports whether value is wrapped with a master key other than the current one
+func (e *Encryptor) NeedsRewrap(value string) bool {
+	keyID, err := KeyID(value)
+	return err == nil && keyID != e.keys.CurrentKeyID()
+}
+
+// Rewrap re-encrypts a value's data key with the current master key. The
+// ciphertext itself is unchanged, so rotation does not need the aad.
+func (e *Encryptor) Rewrap(ctx context.Context, value string) (string, error) {
+	parts, err := split(value)
+	if err != nil {
+		return "", err
+	}
+	current := e.keys.CurrentKeyID()
+	if parts.keyID == current {
+		return value, nil
+	}
+
+	dataKey, err := e.keys.UnwrapKey(ctx, parts.keyID, parts.wrapped)
+	if err != nil {
+		return "", err
+	}
+	wrapped, err := e.keys.WrapKey(ctx, current, dataKey)
+	if err != nil {
+		return "", fmt.Errorf("envelope: failed to wrap data key: %w", err)
+	}
+
+	parts.keyID, parts.wrapped = current, wrapped
+	return join(parts), nil
+}
+
+// envelope is a parsed encrypted value
+type envelope struct {
+	keyID   string
+	wrapped []byte
+	sealed  []byte
+}
+
+func join(e envelope) string {
+	return Prefix + e.keyID + ":" + encoding.EncodeToString(e.wrapped) + ":" + encoding.EncodeToString(e.sealed)
+}
+
+func split(value string) (envelope, error) {
+	if !IsEncrypted(value) {
+		return envelope{}, ErrNotEncrypted
+	}
+	fields := strings.Split(strings.TrimPrefix(value, Prefix), ":")
+	if len(fields) != 3 || fields[0] == "" {
+		return envelope{}, ErrMalformed
+	}
+	wrapped, err := encoding.DecodeString(fields[1])
+	if err != nil {
+		return envelope{}, ErrMalformed
+	}
+	sealed, err := encoding.DecodeString(fields[2])
+	if err != nil {
+		return envelope{}, ErrMalformed
+	}
+	return envelope{keyID: fields[0], wrapped: wrapped, sealed: sealed}, nil
+}
+
+// seal encrypts with AES-256-GCM and returns nonce || ciphertext
+func seal(key, plaintext, aad []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("envelope: failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// open reverses seal
+func open(key, sealed, aad []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrMalformed
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], aad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != DataKeySize {
+		return nil, fmt.Errorf("envelope: key must be %d bytes, got %d", DataKeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
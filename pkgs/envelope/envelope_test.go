@@ -0,0 +1,157 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, DataKeySize)
+}
+
+func newTestEncryptor(t *testing.T, current string, keys ...MasterKey) *Encryptor {
+	t.Helper()
+	provider, err := NewLocalKeyProvider(current, keys)
+	if err != nil {
+		t.Fatalf("Failed to create key provider: %v", err)
+	}
+	return New(provider)
+}
+
+func TestEncrypt_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	enc := newTestEncryptor(t, "", MasterKey{ID: "v1", Key: testKey(1)})
+
+	value, err := enc.EncryptString(ctx, "ya29.secret-token", "user_providers.access_token")
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	if !IsEncrypted(value) || strings.Contains(value, "secret-token") {
+		t.Fatalf("Expected an opaque envelope, got %q", value)
+	}
+	if keyID, _ := KeyID(value); keyID != "v1" {
+		t.Errorf("Expected key id v1, got %q", keyID)
+	}
+
+	plaintext, err := enc.DecryptString(ctx, value, "user_providers.access_token")
+	if err != nil {
+		t.Fatalf("Failed to decrypt: %v", err)
+	}
+	if plaintext != "ya29.secret-token" {
+		t.Errorf("Expected original plaintext, got %q", plaintext)
+	}
+
+	again, _ := enc.EncryptString(ctx, "ya29.secret-token", "user_providers.access_token")
+	if again == value {
+		t.Error("Expected a fresh data key and nonce for every value")
+	}
+}
+
+func TestDecrypt_RejectsWrongContextAndTampering(t *testing.T) {
+	ctx := context.Background()
+	enc := newTestEncryptor(t, "", MasterKey{ID: "v1", Key: testKey(1)})
+	value, _ := enc.EncryptString(ctx, "secret", "providers.client_secret")
+
+	if _, err := enc.DecryptString(ctx, value, "user_providers.refresh_token"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Expected ErrDecrypt for different aad, got %v", err)
+	}
+
+	parts, _ := split(value)
+	parts.sealed[len(parts.sealed)-1] ^= 1
+	if _, err := enc.DecryptString(ctx, join(parts), "providers.client_secret"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Expected ErrDecrypt for modified ciphertext, got %v", err)
+	}
+
+	if _, err := enc.DecryptString(ctx, "plain", "providers.client_secret"); !errors.Is(err, ErrNotEncrypted) {
+		t.Errorf("Expected ErrNotEncrypted, got %v", err)
+	}
+	if _, err := enc.DecryptString(ctx, Prefix+"v1:!!:??", "providers.client_secret"); !errors.Is(err, ErrMalformed) {
+		t.Errorf("Expected ErrMalformed, got %v", err)
+	}
+
+	other := newTestEncryptor(t, "", MasterKey{ID: "v1", Key: testKey(2)})
+	if _, err := other.DecryptString(ctx, value, "providers.client_secret"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Expected ErrDecrypt with a different master key, got %v", err)
+	}
+}
+
+func TestRewrap_RotatesMasterKey(t *testing.T) {
+	ctx := context.Background()
+	v1 := MasterKey{ID: "v1", Key: testKey(1)}
+	v2 := MasterKey{ID: "v2", Key: testKey(2)}
+
+	old := newTestEncryptor(t, "", v1)
+	value, _ := old.EncryptString(ctx, "secret", "aad")
+
+	rotated := newTestEncryptor(t, "", v1, v2)
+	if !rotated.NeedsRewrap(value) {
+		t.Fatal("Expected a value wrapped with v1 to need rewrapping")
+	}
+	rewrapped, err := rotated.Rewrap(ctx, value)
+	if err != nil {
+		t.Fatalf("Failed to rewrap: %v", err)
+	}
+	if keyID, _ := KeyID(rewrapped); keyID != "v2" || rotated.NeedsRewrap(rewrapped) {
+		t.Errorf("Expected value wrapped with v2, got %q", keyID)
+	}
+
+	// Once everything is rewrapped the old master key can be retired
+	retired := newTestEncryptor(t, "", v2)
+	if plaintext, err := retired.DecryptString(ctx, rewrapped, "aad"); err != nil || plaintext != "secret" {
+		t.Errorf("Expected rewrapped value to open with v2 only, got %q (%v)", plaintext, err)
+	}
+	if _, err := retired.DecryptString(ctx, value, "aad"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Expected ErrUnknownKey for a retired master key, got %v", err)
+	}
+}
+
+func TestNewLocalKeyProvider_Validation(t *testing.T) {
+	cases := []struct {
+		name    string
+		current string
+		keys    []MasterKey
+	}{
+		{"no keys", "", nil},
+		{"short key", "", []MasterKey{{ID: "v1", Key: []byte("short")}}},
+		{"separator in id", "", []MasterKey{{ID: "v:1", Key: testKey(1)}}},
+		{"duplicate id", "", []MasterKey{{ID: "v1", Key: testKey(1)}, {ID: "v1", Key: testKey(2)}}},
+		{"unknown current", "v2", []MasterKey{{ID: "v1", Key: testKey(1)}}},
+	}
+	for _, c := range cases {
+		if _, err := NewLocalKeyProvider(c.current, c.keys); err == nil {
+			t.Errorf("%s: expected an error", c.name)
+		}
+	}
+
+	provider, err := NewLocalKeyProvider("v1", []MasterKey{{ID: "v1", Key: testKey(1)}, {ID: "v2", Key: testKey(2)}})
+	if err != nil || provider.CurrentKeyID() != "v1" {
+		t.Errorf("Expected explicit current key v1, got %v", err)
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	k1 := base64.StdEncoding.EncodeToString(testKey(1))
+	k2 := base64.RawURLEncoding.EncodeToString(testKey(2))
+
+	keys, err := ParseKeys("# master keys\nv1:" + k1 + "\n\n v2:" + k2 + " \n")
+	if err != nil {
+		t.Fatalf("Failed to parse key file: %v", err)
+	}
+	if len(keys) != 2 || keys[0].ID != "v1" || keys[1].ID != "v2" || !bytes.Equal(keys[1].Key, testKey(2)) {
+		t.Errorf("Unexpected keys: %+v", keys)
+	}
+
+	if keys, err = ParseKeys("v1:" + k1 + ",v2:" + k2); err != nil || len(keys) != 2 {
+		t.Errorf("Expected two comma separated keys, got %d (%v)", len(keys), err)
+	}
+	if _, err := ParseKeys("v1"); err == nil {
+		t.Error("Expected error for entry without key")
+	}
+	if _, err := ParseKeys("v1:not base64!"); err == nil {
+		t.Error("Expected error for invalid base64")
+	}
+}
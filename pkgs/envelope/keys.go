@@ -0,0 +1,139 @@
+package envelope
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// MasterKey is one version of a local master key
+type MasterKey struct {
+	ID  string
+	Key []byte
+}
+
+// LocalKeyProvider keeps AES-256 master keys in memory, loaded from the
+// environment or a file. Data keys are wrapped with AES-256-GCM using the key
+// ID as associated data.
+type LocalKeyProvider struct {
+	current string
+	keys    map[string][]byte
+}
+
+// NewLocalKeyProvider creates a provider from master keys. current selects
+// the key used for new values; empty means the last key in the list, so a
+// rotation is appending a key.
+func NewLocalKeyProvider(current string, keys []MasterKey) (*LocalKeyProvider, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("envelope: no master keys configured")
+	}
+
+	provider := &LocalKeyProvider{keys: make(map[string][]byte, len(keys))}
+	for _, key := range keys {
+		if !validKeyID(key.ID) {
+			return nil, fmt.Errorf("envelope: invalid master key id %q", key.ID)
+		}
+		if len(key.Key) != DataKeySize {
+			return nil, fmt.Errorf("envelope: master key %q must be %d bytes, got %d", key.ID, DataKeySize, len(key.Key))
+		}
+		if _, ok := provider.keys[key.ID]; ok {
+			return nil, fmt.Errorf("envelope: duplicate master key id %q", key.ID)
+		}
+		provider.keys[key.ID] = key.Key
+	}
+
+	if current == "" {
+		current = keys[len(keys)-1].ID
+	}
+	if _, ok := provider.keys[current]; !ok {
+		return nil, fmt.Errorf("envelope: current master key %q is not configured", current)
+	}
+	provider.current = current
+	return provider, nil
+}
+
+// ParseKeys reads "id:base64key" entries separated by commas or newlines.
+// Blank lines and lines starting with # are ignored, so the same format works
+// for an environment variable and a key file.
+func ParseKeys(text string) ([]MasterKey, error) {
+	var keys []MasterKey
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, entry := range strings.Split(line, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			id, encoded, ok := strings.Cut(entry, ":")
+			if !ok {
+				return nil, fmt.Errorf("envelope: master key entry must be id:base64key")
+			}
+			key, err := decodeKey(strings.TrimSpace(encoded))
+			if err != nil {
+				return nil, fmt.Errorf("envelope: master key %q: %w", id, err)
+			}
+			keys = append(keys, MasterKey{ID: strings.TrimSpace(id), Key: key})
+		}
+	}
+	return keys, nil
+}
+
+// LoadKeyFile reads master keys from a file in the ParseKeys format
+func LoadKeyFile(path string) ([]MasterKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: failed to read key file: %w", err)
+	}
+	return ParseKeys(string(data))
+}
+
+// CurrentKeyID implements KeyProvider
+func (p *LocalKeyProvider) CurrentKeyID() string {
+	return p.current
+}
+
+// WrapKey implements KeyProvider
+func (p *LocalKeyProvider) WrapKey(ctx context.Context, keyID string, dataKey []byte) ([]byte, error) {
+	master, ok := p.keys[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return seal(master, dataKey, []byte(keyID))
+}
+
+// UnwrapKey implements KeyProvider
+func (p *LocalKeyProvider) UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	master, ok := p.keys[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return open(master, wrapped, []byte(keyID))
+}
+
+// decodeKey accepts standard or URL-safe base64, padded or not
+func decodeKey(encoded string) ([]byte, error) {
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if key, err := enc.DecodeString(encoded); err == nil {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("key is not valid base64")
+}
+
+// validKeyID keeps key IDs free of the envelope separator
+func validKeyID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
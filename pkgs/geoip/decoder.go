@@ -0,0 +1,242 @@
+package geoip
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Data section field types
+const (
+	typeExtended  = 0
+	typePointer   = 1
+	typeString    = 2
+	typeDouble    = 3
+	typeBytes     = 4
+	typeUint16    = 5
+	typeUint32    = 6
+	typeMap       = 7
+	typeInt32     = 8
+	typeUint64    = 9
+	typeUint128   = 10
+	typeArray     = 11
+	typeContainer = 12
+	typeEndMarker = 13
+	typeBool      = 14
+	typeFloat     = 15
+)
+
+// maxDecodeDepth bounds nesting so a corrupt file cannot exhaust the stack
+const maxDecodeDepth = 64
+
+// decoder reads values from a MaxMind DB data section. Offsets, including
+// pointer targets, are relative to the start of buf.
+type decoder struct {
+	buf []byte
+}
+
+// decode returns the value at offset and the offset just past it.
+// Maps decode to map[string]interface{}, arrays to []interface{}, unsigned
+// integers to uint64 (uint128 to []byte), int32 to int64 and floats to float64.
+func (d decoder) decode(offset uint, depth int) (interface{}, uint, error) {
+	if depth > maxDecodeDepth {
+		return nil, 0, fmt.Errorf("%w: data nested too deeply", ErrInvalidDatabase)
+	}
+
+	typeNum, size, offset, err := d.decodeControl(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if typeNum == typePointer {
+		target, next, err := d.decodePointer(size, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, _, err := d.decode(target, depth+1)
+		return value, next, err
+	}
+
+	return d.decodeValue(typeNum, size, offset, depth)
+}
+
+// decodeControl reads a control byte and any extended type and size bytes
+func (d decoder) decodeControl(offset uint) (int, uint, uint, error) {
+	ctrl, err := d.byteAt(offset)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	offset++
+
+	typeNum := int(ctrl >> 5)
+	if typeNum == typeExtended {
+		next, err := d.byteAt(offset)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		typeNum = int(next) + 7
+		offset++
+		if typeNum < typeInt32 {
+			return 0, 0, 0, fmt.Errorf("%w: invalid extended type %d", ErrInvalidDatabase, typeNum)
+		}
+	}
+
+	size := uint(ctrl & 0x1f)
+	if typeNum == typePointer || size < 29 {
+		return typeNum, size, offset, nil
+	}
+
+	extra := size - 28
+	bytes, err := d.slice(offset, extra)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	offset += extra
+
+	switch size {
+	case 29:
+		size = 29 + uint(bytes[0])
+	case 30:
+		size = 285 + uint(binary.BigEndian.Uint16(bytes))
+	default:
+		size = 65821 + uint(uintFromBytes(bytes))
+	}
+	return typeNum, size, offset, nil
+}
+
+// decodePointer resolves a pointer whose control byte carried size bits
+func (d decoder) decodePointer(size, offset uint) (uint, uint, error) {
+	pointerSize := ((size >> 3) & 0x3) + 1
+	bytes, err := d.slice(offset, pointerSize)
+	if err != nil {
+		return 0, 0, err
+	}
+	next := offset + pointerSize
+
+	prefix := size & 0x7
+	var target uint
+	switch pointerSize {
+	case 1:
+		target = prefix<<8 | uint(uintFromBytes(bytes))
+	case 2:
+		target = (prefix<<16 | uint(uintFromBytes(bytes))) + 2048
+	case 3:
+		target = (prefix<<24 | uint(uintFromBytes(bytes))) + 526336
+	default:
+		target = uint(uintFromBytes(bytes))
+	}
+	return target, next, nil
+}
+
+func (d decoder) decodeValue(typeNum int, size, offset uint, depth int) (interface{}, uint, error) {
+	switch typeNum {
+	case typeMap:
+		return d.decodeMap(size, offset, depth)
+	case typeArray:
+		return d.decodeArray(size, offset, depth)
+	case typeBool:
+		if size > 1 {
+			return nil, 0, fmt.Errorf("%w: invalid boolean size %d", ErrInvalidDatabase, size)
+		}
+		return size == 1, offset, nil
+	}
+
+	bytes, err := d.slice(offset, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	next := offset + size
+
+	switch typeNum {
+	case typeString:
+		return string(bytes), next, nil
+	case typeBytes:
+		return append([]byte(nil), bytes...), next, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, fmt.Errorf("%w: invalid double size %d", ErrInvalidDatabase, size)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(bytes)), next, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, fmt.Errorf("%w: invalid float size %d", ErrInvalidDatabase, size)
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(bytes))), next, nil
+	case typeUint16, typeUint32, typeUint64:
+		if size > map[int]uint{typeUint16: 2, typeUint32: 4, typeUint64: 8}[typeNum] {
+			return nil, 0, fmt.Errorf("%w: invalid integer size %d", ErrInvalidDatabase, size)
+		}
+		return uintFromBytes(bytes), next, nil
+	case typeInt32:
+		if size > 4 {
+			return nil, 0, fmt.Errorf("%w: invalid int32 size %d", ErrInvalidDatabase, size)
+		}
+		var padded [4]byte
+		copy(padded[4-size:], bytes)
+		return int64(int32(binary.BigEndian.Uint32(padded[:]))), next, nil
+	case typeUint128:
+		if size > 16 {
+			return nil, 0, fmt.Errorf("%w: invalid uint128 size %d", ErrInvalidDatabase, size)
+		}
+		return append([]byte(nil), bytes...), next, nil
+	default:
+		return nil, 0, fmt.Errorf("%w: unexpected type %d", ErrInvalidDatabase, typeNum)
+	}
+}
+
+func (d decoder) decodeMap(size, offset uint, depth int) (interface{}, uint, error) {
+	result := make(map[string]interface{}, size)
+	for i := uint(0); i < size; i++ {
+		key, next, err := d.decode(offset, depth+1)
+		if err != nil {
+			return nil, 0, err
+		}
+		name, ok := key.(string)
+		if !ok {
+			return nil, 0, fmt.Errorf("%w: map key is not a string", ErrInvalidDatabase)
+		}
+
+		value, next, err := d.decode(next, depth+1)
+		if err != nil {
+			return nil, 0, err
+		}
+		result[name] = value
+		offset = next
+	}
+	return result, offset, nil
+}
+
+func (d decoder) decodeArray(size, offset uint, depth int) (interface{}, uint, error) {
+	result := make([]interface{}, 0, size)
+	for i := uint(0); i < size; i++ {
+		value, next, err := d.decode(offset, depth+1)
+		if err != nil {
+			return nil, 0, err
+		}
+		result = append(result, value)
+		offset = next
+	}
+	return result, offset, nil
+}
+
+func (d decoder) byteAt(offset uint) (byte, error) {
+	if offset >= uint(len(d.buf)) {
+		return 0, fmt.Errorf("%w: unexpected end of data", ErrInvalidDatabase)
+	}
+	return d.buf[offset], nil
+}
+
+func (d decoder) slice(offset, size uint) ([]byte, error) {
+	if offset > uint(len(d.buf)) || size > uint(len(d.buf))-offset {
+		return nil, fmt.Errorf("%w: unexpected end of data", ErrInvalidDatabase)
+	}
+	return d.buf[offset : offset+size], nil
+}
+
+func uintFromBytes(bytes []byte) uint64 {
+	var value uint64
+	for _, b := range bytes {
+		value = value<<8 | uint64(b)
+	}
+	return value
+}
@@ -0,0 +1,82 @@
+// Package geoip reads MaxMind DB (.mmdb) files such as GeoLite2-City and
+// GeoLite2-ASN offline and computes distances between locations.
+package geoip
+
+import (
+	"math"
+	"net"
+)
+
+// earthRadiusKm is the mean Earth radius used for great-circle distances
+const earthRadiusKm = 6371.0
+
+// Location is the geographic part of a City or Country database record
+type Location struct {
+	CountryCode string
+	City        string
+	Latitude    float64
+	Longitude   float64
+	// AccuracyRadius is MaxMind's confidence radius around the coordinates, in km
+	AccuracyRadius uint
+	HasCoordinates bool
+}
+
+// ASN is an autonomous system record from an ASN database
+type ASN struct {
+	Number       uint
+	Organization string
+}
+
+// Location looks up ip in a City or Country database
+func (r *Reader) Location(ip net.IP) (Location, bool, error) {
+	record, found, err := r.Lookup(ip)
+	if err != nil || !found {
+		return Location{}, found, err
+	}
+
+	var location Location
+	if country, ok := record["country"].(map[string]interface{}); ok {
+		location.CountryCode = stringField(country, "iso_code")
+	}
+	if city, ok := record["city"].(map[string]interface{}); ok {
+		if names, ok := city["names"].(map[string]interface{}); ok {
+			location.City = stringField(names, "en")
+		}
+	}
+	if coordinates, ok := record["location"].(map[string]interface{}); ok {
+		latitude, latOK := coordinates["latitude"].(float64)
+		longitude, lonOK := coordinates["longitude"].(float64)
+		if latOK && lonOK {
+			location.Latitude, location.Longitude = latitude, longitude
+			location.HasCoordinates = true
+		}
+		location.AccuracyRadius = uint(uintField(coordinates, "accuracy_radius"))
+	}
+	return location, true, nil
+}
+
+// ASN looks up ip in an ASN database
+func (r *Reader) ASN(ip net.IP) (ASN, bool, error) {
+	record, found, err := r.Lookup(ip)
+	if err != nil || !found {
+		return ASN{}, found, err
+	}
+	return ASN{
+		Number:       uint(uintField(record, "autonomous_system_number")),
+		Organization: stringField(record, "autonomous_system_organization"),
+	}, true, nil
+}
+
+// DistanceKm returns the great-circle distance between two locations in kilometres
+func DistanceKm(a, b Location) float64 {
+	lat1, lat2 := radians(a.Latitude), radians(b.Latitude)
+	dLat := lat2 - lat1
+	dLon := radians(b.Longitude - a.Longitude)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
@@ -0,0 +1,141 @@
+package geoip
+
+import (
+	"errors"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/your-project/pkgs/geoip/geoiptest"
+)
+
+func buildReader(t *testing.T, opts geoiptest.Options, networks ...geoiptest.Network) *Reader {
+	t.Helper()
+	db, err := geoiptest.Build(opts, networks...)
+	if err != nil {
+		t.Fatalf("Build returned error: %v", err)
+	}
+	reader, err := FromBytes(db)
+	if err != nil {
+		t.Fatalf("FromBytes returned error: %v", err)
+	}
+	return reader
+}
+
+func TestLocationLookup(t *testing.T) {
+	networks := []geoiptest.Network{
+		{CIDR: "81.2.69.0/24", Record: geoiptest.CityRecord("GB", "London", 51.5142, -0.0931)},
+		{CIDR: "81.2.69.128/25", Record: geoiptest.CityRecord("GB", "Manchester", 53.4808, -2.2426)},
+		{CIDR: "2001:db8::/32", Record: geoiptest.CityRecord("DE", "Berlin", 52.52, 13.405)},
+	}
+
+	for _, recordSize := range []uint{24, 28, 32} {
+		reader := buildReader(t, geoiptest.Options{DatabaseType: "GeoLite2-City", RecordSize: recordSize}, networks...)
+
+		tests := []struct {
+			ip   string
+			city string
+		}{
+			{"81.2.69.1", "London"},
+			{"81.2.69.200", "Manchester"},
+			{"2001:db8::1", "Berlin"},
+			{"::ffff:81.2.69.1", "London"},
+		}
+		for _, tt := range tests {
+			location, found, err := reader.Location(net.ParseIP(tt.ip))
+			if err != nil || !found {
+				t.Fatalf("record size %d: Location(%s) = found %v, err %v", recordSize, tt.ip, found, err)
+			}
+			if location.City != tt.city || !location.HasCoordinates || location.AccuracyRadius != 20 {
+				t.Errorf("record size %d: Location(%s) = %+v, want city %s", recordSize, tt.ip, location, tt.city)
+			}
+		}
+
+		if _, found, err := reader.Location(net.ParseIP("8.8.8.8")); err != nil || found {
+			t.Errorf("record size %d: expected no record for 8.8.8.8, got found %v, err %v", recordSize, found, err)
+		}
+	}
+}
+
+func TestASNLookup(t *testing.T) {
+	reader := buildReader(t, geoiptest.Options{DatabaseType: "GeoLite2-ASN", IPVersion: 4},
+		geoiptest.Network{CIDR: "1.0.0.0/24", Record: geoiptest.ASNRecord(13335, "CLOUDFLARENET")},
+		geoiptest.Network{CIDR: "1.1.1.0/24", Record: geoiptest.ASNRecord(13335, "CLOUDFLARENET")},
+	)
+
+	asn, found, err := reader.ASN(net.ParseIP("1.1.1.1"))
+	if err != nil || !found {
+		t.Fatalf("ASN returned found %v, err %v", found, err)
+	}
+	if asn.Number != 13335 || asn.Organization != "CLOUDFLARENET" {
+		t.Errorf("unexpected ASN %+v", asn)
+	}
+
+	if _, _, err := reader.ASN(net.ParseIP("2001:db8::1")); !errors.Is(err, ErrIPv6Lookup) {
+		t.Errorf("expected ErrIPv6Lookup, got %v", err)
+	}
+
+	metadata := reader.Metadata()
+	if metadata.DatabaseType != "GeoLite2-ASN" || metadata.IPVersion != 4 || metadata.Languages[0] != "en" {
+		t.Errorf("unexpected metadata %+v", metadata)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	db, err := geoiptest.Build(geoiptest.Options{},
+		geoiptest.Network{CIDR: "10.0.0.0/8", Record: map[string]interface{}{
+			"flag": true, "count": uint64(1 << 40), "ratio": float32(0.5), "offset": int32(-7),
+			"tags": []interface{}{"a", "b"},
+		}},
+	)
+	if err != nil {
+		t.Fatalf("Build returned error: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "test.mmdb")
+	if err := os.WriteFile(path, db, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	record, found, err := reader.Lookup(net.ParseIP("10.1.2.3"))
+	if err != nil || !found {
+		t.Fatalf("Lookup returned found %v, err %v", found, err)
+	}
+	if record["flag"] != true || record["count"] != uint64(1<<40) || record["ratio"] != 0.5 || record["offset"] != int64(-7) {
+		t.Errorf("unexpected record %+v", record)
+	}
+	if tags, _ := record["tags"].([]interface{}); len(tags) != 2 || tags[1] != "b" {
+		t.Errorf("unexpected tags %+v", record["tags"])
+	}
+}
+
+func TestFromBytesRejectsInvalidData(t *testing.T) {
+	if _, err := FromBytes([]byte("not a database")); !errors.Is(err, ErrInvalidDatabase) {
+		t.Errorf("expected ErrInvalidDatabase, got %v", err)
+	}
+
+	db, err := geoiptest.Build(geoiptest.Options{}, geoiptest.Network{CIDR: "10.0.0.0/8", Record: map[string]interface{}{"a": "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FromBytes(db[len(db)-40:]); !errors.Is(err, ErrInvalidDatabase) {
+		t.Errorf("expected ErrInvalidDatabase for truncated file, got %v", err)
+	}
+}
+
+func TestDistanceKm(t *testing.T) {
+	london := Location{Latitude: 51.5074, Longitude: -0.1278}
+	newYork := Location{Latitude: 40.7128, Longitude: -74.0060}
+
+	if d := DistanceKm(london, newYork); math.Abs(d-5570) > 10 {
+		t.Errorf("DistanceKm(London, New York) = %.0f, want about 5570", d)
+	}
+	if d := DistanceKm(london, london); d != 0 {
+		t.Errorf("DistanceKm to itself = %f, want 0", d)
+	}
+}
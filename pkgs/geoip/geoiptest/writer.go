@@ -0,0 +1,320 @@
+// Package geoiptest builds small MaxMind DB files for exercising geoip lookups in tests.
+package geoiptest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+)
+
+// Network maps a CIDR block to the record returned for addresses inside it
+type Network struct {
+	CIDR   string
+	Record map[string]interface{}
+}
+
+// Options controls the layout of the generated database
+type Options struct {
+	DatabaseType string
+	// RecordSize is 24, 28 or 32 bits (default 28)
+	RecordSize uint
+	// IPVersion is 4 or 6 (default 6). IPv4 networks in an IPv6 database are stored under ::/96.
+	IPVersion uint
+}
+
+// CityRecord returns a record shaped like a GeoLite2-City entry
+func CityRecord(countryCode, city string, latitude, longitude float64) map[string]interface{} {
+	return map[string]interface{}{
+		"country": map[string]interface{}{"iso_code": countryCode},
+		"city":    map[string]interface{}{"names": map[string]interface{}{"en": city}},
+		"location": map[string]interface{}{
+			"latitude":        latitude,
+			"longitude":       longitude,
+			"accuracy_radius": uint16(20),
+		},
+	}
+}
+
+// ASNRecord returns a record shaped like a GeoLite2-ASN entry
+func ASNRecord(number uint32, organization string) map[string]interface{} {
+	return map[string]interface{}{
+		"autonomous_system_number":       number,
+		"autonomous_system_organization": organization,
+	}
+}
+
+// Build encodes networks as a MaxMind DB file. More specific networks may be
+// nested inside broader ones in any order.
+func Build(opts Options, networks ...Network) ([]byte, error) {
+	if opts.RecordSize == 0 {
+		opts.RecordSize = 28
+	}
+	if opts.IPVersion == 0 {
+		opts.IPVersion = 6
+	}
+	if opts.DatabaseType == "" {
+		opts.DatabaseType = "Test-DB"
+	}
+	if opts.RecordSize != 24 && opts.RecordSize != 28 && opts.RecordSize != 32 {
+		return nil, fmt.Errorf("unsupported record size %d", opts.RecordSize)
+	}
+
+	// Insert broader networks first so nested ones split them
+	type prefix struct {
+		bits   []byte
+		length int
+		record map[string]interface{}
+	}
+	prefixes := make([]prefix, 0, len(networks))
+	for _, network := range networks {
+		_, ipNet, err := net.ParseCIDR(network.CIDR)
+		if err != nil {
+			return nil, err
+		}
+		ones, _ := ipNet.Mask.Size()
+		bits := []byte(ipNet.IP)
+		if ip4 := ipNet.IP.To4(); ip4 != nil {
+			bits = ip4
+			if opts.IPVersion == 6 {
+				bits = append(make([]byte, 12), ip4...)
+				ones += 96
+			}
+		} else if opts.IPVersion == 4 {
+			return nil, fmt.Errorf("IPv6 network %s in IPv4 database", network.CIDR)
+		}
+		prefixes = append(prefixes, prefix{bits: bits, length: ones, record: network.Record})
+	}
+	sort.SliceStable(prefixes, func(i, j int) bool { return prefixes[i].length < prefixes[j].length })
+
+	data := newDataWriter()
+	t := &tree{nodes: []node{{}}}
+	for _, p := range prefixes {
+		offset := data.writeRecord(p.record)
+		t.insert(p.bits, p.length, offset)
+	}
+
+	var out bytes.Buffer
+	nodeCount := uint64(len(t.nodes))
+	for _, n := range t.nodes {
+		left, right := n.children[0].value(nodeCount), n.children[1].value(nodeCount)
+		writeNode(&out, opts.RecordSize, left, right)
+	}
+	out.Write(make([]byte, 16))
+	out.Write(data.buf.Bytes())
+
+	out.WriteString("\xab\xcd\xefMaxMind.com")
+	metadata := newDataWriter()
+	metadata.write(map[string]interface{}{
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(1700000000),
+		"database_type":               opts.DatabaseType,
+		"description":                 map[string]interface{}{"en": "geoiptest database"},
+		"ip_version":                  uint16(opts.IPVersion),
+		"languages":                   []interface{}{"en"},
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(opts.RecordSize),
+	})
+	out.Write(metadata.buf.Bytes())
+
+	return out.Bytes(), nil
+}
+
+type childKind int
+
+const (
+	childEmpty childKind = iota
+	childNode
+	childData
+)
+
+type child struct {
+	kind  childKind
+	index uint64
+}
+
+// value is the search tree record for the child
+func (c child) value(nodeCount uint64) uint64 {
+	switch c.kind {
+	case childNode:
+		return c.index
+	case childData:
+		return nodeCount + 16 + c.index
+	default:
+		return nodeCount
+	}
+}
+
+type node struct {
+	children [2]child
+}
+
+type tree struct {
+	nodes []node
+}
+
+func (t *tree) insert(bits []byte, length int, dataOffset uint64) {
+	current := 0
+	for i := 0; i < length; i++ {
+		bit := (bits[i>>3] >> (7 - uint(i&7))) & 1
+		if i == length-1 {
+			t.nodes[current].children[bit] = child{kind: childData, index: dataOffset}
+			return
+		}
+
+		next := t.nodes[current].children[bit]
+		if next.kind != childNode {
+			// Split an empty or broader branch, keeping its record on both sides
+			t.nodes = append(t.nodes, node{children: [2]child{next, next}})
+			next = child{kind: childNode, index: uint64(len(t.nodes) - 1)}
+			t.nodes[current].children[bit] = next
+		}
+		current = int(next.index)
+	}
+}
+
+func writeNode(out *bytes.Buffer, recordSize uint, left, right uint64) {
+	switch recordSize {
+	case 24:
+		out.Write([]byte{byte(left >> 16), byte(left >> 8), byte(left), byte(right >> 16), byte(right >> 8), byte(right)})
+	case 28:
+		out.Write([]byte{
+			byte(left >> 16), byte(left >> 8), byte(left),
+			byte((left>>24)&0x0f)<<4 | byte((right>>24)&0x0f),
+			byte(right >> 16), byte(right >> 8), byte(right),
+		})
+	default:
+		var b [8]byte
+		binary.BigEndian.PutUint32(b[0:4], uint32(left))
+		binary.BigEndian.PutUint32(b[4:8], uint32(right))
+		out.Write(b[:])
+	}
+}
+
+// dataWriter encodes data section values, emitting pointers for repeated map
+// keys the way MaxMind's own writer deduplicates them
+type dataWriter struct {
+	buf     bytes.Buffer
+	strings map[string]uint64
+}
+
+func newDataWriter() *dataWriter {
+	return &dataWriter{strings: map[string]uint64{}}
+}
+
+func (w *dataWriter) writeRecord(record map[string]interface{}) uint64 {
+	offset := uint64(w.buf.Len())
+	w.write(record)
+	return offset
+}
+
+func (w *dataWriter) write(value interface{}) {
+	switch v := value.(type) {
+	case string:
+		w.control(2, uint64(len(v)))
+		w.buf.WriteString(v)
+	case float64:
+		w.control(3, 8)
+		binary.Write(&w.buf, binary.BigEndian, math.Float64bits(v))
+	case []byte:
+		w.control(4, uint64(len(v)))
+		w.buf.Write(v)
+	case uint16:
+		w.unsigned(5, uint64(v))
+	case uint32:
+		w.unsigned(6, uint64(v))
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		w.control(7, uint64(len(v)))
+		for _, key := range keys {
+			w.key(key)
+			w.write(v[key])
+		}
+	case int32:
+		w.control(8, 4)
+		binary.Write(&w.buf, binary.BigEndian, v)
+	case uint64:
+		w.unsigned(9, v)
+	case []interface{}:
+		w.control(11, uint64(len(v)))
+		for _, item := range v {
+			w.write(item)
+		}
+	case bool:
+		size := uint64(0)
+		if v {
+			size = 1
+		}
+		w.control(14, size)
+	case float32:
+		w.control(15, 4)
+		binary.Write(&w.buf, binary.BigEndian, math.Float32bits(v))
+	default:
+		panic(fmt.Sprintf("geoiptest: unsupported value type %T", value))
+	}
+}
+
+// key writes a map key, pointing back to an earlier copy when there is one
+func (w *dataWriter) key(key string) {
+	if offset, ok := w.strings[key]; ok {
+		w.pointer(offset)
+		return
+	}
+	w.strings[key] = uint64(w.buf.Len())
+	w.write(key)
+}
+
+func (w *dataWriter) pointer(offset uint64) {
+	switch {
+	case offset < 2048:
+		w.buf.Write([]byte{0x20 | byte(offset>>8)&0x7, byte(offset)})
+	case offset < 526336:
+		offset -= 2048
+		w.buf.Write([]byte{0x28 | byte(offset>>16)&0x7, byte(offset >> 8), byte(offset)})
+	case offset < 134744064:
+		offset -= 526336
+		w.buf.Write([]byte{0x30 | byte(offset>>24)&0x7, byte(offset >> 16), byte(offset >> 8), byte(offset)})
+	default:
+		w.buf.Write([]byte{0x38, byte(offset >> 24), byte(offset >> 16), byte(offset >> 8), byte(offset)})
+	}
+}
+
+func (w *dataWriter) unsigned(typeNum byte, value uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], value)
+	trimmed := bytes.TrimLeft(b[:], "\x00")
+	w.control(typeNum, uint64(len(trimmed)))
+	w.buf.Write(trimmed)
+}
+
+// control writes a control byte, extended type byte and size extension
+func (w *dataWriter) control(typeNum byte, size uint64) {
+	var sizeBits byte
+	var extra []byte
+	switch {
+	case size < 29:
+		sizeBits = byte(size)
+	case size < 285:
+		sizeBits, extra = 29, []byte{byte(size - 29)}
+	case size < 65821:
+		sizeBits, extra = 30, []byte{byte((size - 285) >> 8), byte(size - 285)}
+	default:
+		size -= 65821
+		sizeBits, extra = 31, []byte{byte(size >> 16), byte(size >> 8), byte(size)}
+	}
+
+	if typeNum <= 7 {
+		w.buf.WriteByte(typeNum<<5 | sizeBits)
+	} else {
+		w.buf.WriteByte(sizeBits)
+		w.buf.WriteByte(typeNum - 7)
+	}
+	w.buf.Write(extra)
+}
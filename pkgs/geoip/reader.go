@@ -0,0 +1,243 @@
+package geoip
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+)
+
+// metadataMarker precedes the metadata map at the end of every MaxMind DB file
+var metadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// maxMetadataSize is how far from the end of the file the marker is searched for
+const maxMetadataSize = 128 * 1024
+
+// dataSectionSeparator is the run of zero bytes between the search tree and data section
+const dataSectionSeparator = 16
+
+var (
+	// ErrInvalidDatabase is returned for files that are not valid MaxMind DBs
+	ErrInvalidDatabase = errors.New("invalid MaxMind database")
+	// ErrIPv6Lookup is returned when looking up an IPv6 address in an IPv4-only database
+	ErrIPv6Lookup = errors.New("IPv6 address lookup in IPv4-only database")
+)
+
+// Metadata describes a MaxMind DB file
+type Metadata struct {
+	DatabaseType string
+	Description  map[string]string
+	Languages    []string
+	IPVersion    uint
+	NodeCount    uint
+	RecordSize   uint
+	BuildEpoch   uint64
+	MajorVersion uint
+	MinorVersion uint
+}
+
+// Reader looks up records in a MaxMind DB (.mmdb) file held in memory.
+// It is safe for concurrent use.
+type Reader struct {
+	buf      []byte
+	metadata Metadata
+	data     decoder
+
+	nodeSize  uint
+	treeSize  uint
+	ipv4Start uint
+}
+
+// Open reads the database at path into memory
+func Open(path string) (*Reader, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GeoIP database: %w", err)
+	}
+	return FromBytes(buf)
+}
+
+// FromBytes parses a database already in memory. The reader keeps buf.
+func FromBytes(buf []byte) (*Reader, error) {
+	searchFrom := 0
+	if len(buf) > maxMetadataSize {
+		searchFrom = len(buf) - maxMetadataSize
+	}
+	markerAt := bytes.LastIndex(buf[searchFrom:], metadataMarker)
+	if markerAt < 0 {
+		return nil, fmt.Errorf("%w: metadata marker not found", ErrInvalidDatabase)
+	}
+	metadataStart := searchFrom + markerAt + len(metadataMarker)
+
+	raw, _, err := decoder{buf: buf[metadataStart:]}.decode(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	fields, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: metadata is not a map", ErrInvalidDatabase)
+	}
+	metadata, err := parseMetadata(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Reader{buf: buf, metadata: metadata}
+	r.nodeSize = metadata.RecordSize / 4
+	r.treeSize = metadata.NodeCount * r.nodeSize
+	dataStart := r.treeSize + dataSectionSeparator
+	if dataStart > uint(searchFrom+markerAt) {
+		return nil, fmt.Errorf("%w: search tree exceeds file size", ErrInvalidDatabase)
+	}
+	r.data = decoder{buf: buf[dataStart : searchFrom+markerAt]}
+
+	// IPv4 addresses live under ::/96 in an IPv6 tree
+	if metadata.IPVersion == 6 {
+		node := uint(0)
+		for i := 0; i < 96 && node < metadata.NodeCount; i++ {
+			if node, err = r.readNode(node, 0); err != nil {
+				return nil, err
+			}
+		}
+		r.ipv4Start = node
+	}
+
+	return r, nil
+}
+
+// Metadata returns the database's metadata
+func (r *Reader) Metadata() Metadata {
+	return r.metadata
+}
+
+// Lookup returns the raw record for ip. found is false when the database holds
+// no record for the address.
+func (r *Reader) Lookup(ip net.IP) (record map[string]interface{}, found bool, err error) {
+	value, found, err := r.lookup(ip)
+	if err != nil || !found {
+		return nil, found, err
+	}
+	record, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, false, fmt.Errorf("%w: record is not a map", ErrInvalidDatabase)
+	}
+	return record, true, nil
+}
+
+func (r *Reader) lookup(ip net.IP) (interface{}, bool, error) {
+	node, bits := uint(0), net.IP(nil)
+	if ip4 := ip.To4(); ip4 != nil {
+		bits = ip4
+		if r.metadata.IPVersion == 6 {
+			node = r.ipv4Start
+		}
+	} else if ip16 := ip.To16(); ip16 != nil {
+		if r.metadata.IPVersion == 4 {
+			return nil, false, ErrIPv6Lookup
+		}
+		bits = ip16
+	} else {
+		return nil, false, fmt.Errorf("invalid IP address %q", ip)
+	}
+
+	var err error
+	for i := 0; i < len(bits)*8 && node < r.metadata.NodeCount; i++ {
+		bit := uint(bits[i>>3]>>(7-uint(i&7))) & 1
+		if node, err = r.readNode(node, bit); err != nil {
+			return nil, false, err
+		}
+	}
+
+	switch {
+	case node == r.metadata.NodeCount:
+		return nil, false, nil
+	case node < r.metadata.NodeCount:
+		return nil, false, fmt.Errorf("%w: search tree deeper than address", ErrInvalidDatabase)
+	}
+
+	offset := node - r.metadata.NodeCount - dataSectionSeparator
+	if node < r.metadata.NodeCount+dataSectionSeparator || offset >= uint(len(r.data.buf)) {
+		return nil, false, fmt.Errorf("%w: record pointer out of range", ErrInvalidDatabase)
+	}
+	value, _, err := r.data.decode(offset, 0)
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// readNode returns the left (bit 0) or right (bit 1) record of a search tree node
+func (r *Reader) readNode(node, bit uint) (uint, error) {
+	start := node * r.nodeSize
+	if start+r.nodeSize > r.treeSize {
+		return 0, fmt.Errorf("%w: node %d out of range", ErrInvalidDatabase, node)
+	}
+	b := r.buf[start : start+r.nodeSize]
+
+	switch r.metadata.RecordSize {
+	case 24:
+		if bit == 0 {
+			return uint(uintFromBytes(b[0:3])), nil
+		}
+		return uint(uintFromBytes(b[3:6])), nil
+	case 28:
+		if bit == 0 {
+			return uint(b[3]&0xf0)<<20 | uint(uintFromBytes(b[0:3])), nil
+		}
+		return uint(b[3]&0x0f)<<24 | uint(uintFromBytes(b[4:7])), nil
+	default:
+		if bit == 0 {
+			return uint(uintFromBytes(b[0:4])), nil
+		}
+		return uint(uintFromBytes(b[4:8])), nil
+	}
+}
+
+func parseMetadata(fields map[string]interface{}) (Metadata, error) {
+	metadata := Metadata{
+		DatabaseType: stringField(fields, "database_type"),
+		IPVersion:    uint(uintField(fields, "ip_version")),
+		NodeCount:    uint(uintField(fields, "node_count")),
+		RecordSize:   uint(uintField(fields, "record_size")),
+		BuildEpoch:   uintField(fields, "build_epoch"),
+		MajorVersion: uint(uintField(fields, "binary_format_major_version")),
+		MinorVersion: uint(uintField(fields, "binary_format_minor_version")),
+		Description:  map[string]string{},
+	}
+	if languages, ok := fields["languages"].([]interface{}); ok {
+		for _, language := range languages {
+			if s, ok := language.(string); ok {
+				metadata.Languages = append(metadata.Languages, s)
+			}
+		}
+	}
+	if description, ok := fields["description"].(map[string]interface{}); ok {
+		for language, text := range description {
+			if s, ok := text.(string); ok {
+				metadata.Description[language] = s
+			}
+		}
+	}
+
+	if metadata.MajorVersion != 2 {
+		return metadata, fmt.Errorf("%w: unsupported format version %d", ErrInvalidDatabase, metadata.MajorVersion)
+	}
+	if metadata.IPVersion != 4 && metadata.IPVersion != 6 {
+		return metadata, fmt.Errorf("%w: invalid ip_version %d", ErrInvalidDatabase, metadata.IPVersion)
+	}
+	if metadata.RecordSize != 24 && metadata.RecordSize != 28 && metadata.RecordSize != 32 {
+		return metadata, fmt.Errorf("%w: unsupported record_size %d", ErrInvalidDatabase, metadata.RecordSize)
+	}
+	return metadata, nil
+}
+
+func stringField(fields map[string]interface{}, key string) string {
+	s, _ := fields[key].(string)
+	return s
+}
+
+func uintField(fields map[string]interface{}, key string) uint64 {
+	n, _ := fields[key].(uint64)
+	return n
+}
@@ -0,0 +1,237 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrUnknownKey is returned when a token names a key ID the KeyManager does not hold
+var ErrUnknownKey = errors.New("jwt: unknown signing key")
+
+// SigningKey is an asymmetric private key published under a key ID, so that
+// anyone holding the public half (usually fetched from a JWKS endpoint) can
+// verify tokens without sharing a secret
+type SigningKey struct {
+	// ID is sent in the "kid" header; KeyID derives a stable one from the key
+	ID string
+	// Algorithm is the JWS algorithm (RS256, ES256, EdDSA, ...)
+	Algorithm string
+	Key       crypto.Signer
+}
+
+// PublicKey is the verification half of a SigningKey
+type PublicKey struct {
+	ID        string
+	Algorithm string
+	Key       crypto.PublicKey
+}
+
+// KeyManager signs tokens with its current key and verifies tokens signed by any
+// key it holds. Keeping the previous keys after a rotation lets tokens issued
+// before it stay valid until they expire.
+type KeyManager struct {
+	current SigningKey
+	keys    map[string]SigningKey
+	order   []string
+}
+
+// NewKeyManager creates a key manager that signs with current and also accepts
+// tokens signed by previous. Keys without an ID or algorithm get derived ones.
+func NewKeyManager(current SigningKey, previous ...SigningKey) (*KeyManager, error) {
+	m := &KeyManager{keys: map[string]SigningKey{}}
+	for _, key := range append([]SigningKey{current}, previous...) {
+		key, err := completeSigningKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := m.keys[key.ID]; exists {
+			return nil, fmt.Errorf("jwt: duplicate key ID %q", key.ID)
+		}
+		m.keys[key.ID] = key
+		m.order = append(m.order, key.ID)
+	}
+	m.current = m.keys[m.order[0]]
+	return m, nil
+}
+
+// CurrentKeyID returns the ID of the key new tokens are signed with
+func (m *KeyManager) CurrentKeyID() string {
+	return m.current.ID
+}
+
+// GenerateToken signs claims with the current key and sets the "kid" header
+func (m *KeyManager) GenerateToken(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(m.current.Algorithm), claims)
+	token.Header["kid"] = m.current.ID
+	return token.SignedString(m.current.Key)
+}
+
+// GenerateTokenWithExpiry signs claims with the current key and a custom expiry
+func (m *KeyManager) GenerateTokenWithExpiry(claims jwt.Claims, expiry time.Duration) (string, error) {
+	if customClaims, ok := claims.(CustomClaims); ok {
+		customClaims.SetExpiry(expiry)
+	}
+	return m.GenerateToken(claims)
+}
+
+// ParseToken verifies a token against the key named by its "kid" header and fills
+// claims. The header's algorithm must match the key's, so a token cannot pick a
+// weaker one. Parser options such as jwt.WithAudience add claim checks.
+func (m *KeyManager) ParseToken(tokenString string, claims jwt.Claims, opts ...jwt.ParserOption) error {
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := m.keys[kid]
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+		if token.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.Key.Public(), nil
+	}, opts...)
+
+	if err != nil {
+		return fmt.Errorf("failed to parse token: %w", err)
+	}
+
+	if !token.Valid {
+		return fmt.Errorf("invalid token")
+	}
+
+	return nil
+}
+
+// PublicKeys returns the verification keys, current key first, for publishing as a JWKS
+func (m *KeyManager) PublicKeys() []PublicKey {
+	keys := make([]PublicKey, 0, len(m.order))
+	for _, id := range m.order {
+		key := m.keys[id]
+		keys = append(keys, PublicKey{ID: key.ID, Algorithm: key.Algorithm, Key: key.Key.Public()})
+	}
+	return keys
+}
+
+// GenerateSigningKey creates a random ECDSA P-256 (ES256) signing key. It suits
+// tests and development; production keys should be loaded with ParsePrivateKey
+// so that tokens survive restarts.
+func GenerateSigningKey() (SigningKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return SigningKey{}, fmt.Errorf("jwt: failed to generate key: %w", err)
+	}
+	return completeSigningKey(SigningKey{Key: key})
+}
+
+// ParsePrivateKey reads a PEM encoded PKCS #8, PKCS #1 (RSA) or SEC 1 (EC) private key
+func ParsePrivateKey(pemData []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("jwt: no PEM block found")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("jwt: unsupported private key type %T", key)
+		}
+		return signer, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("jwt: failed to parse private key")
+}
+
+// AlgorithmForKey returns the JWS algorithm used for a key: RS256 for RSA,
+// ES256/ES384/ES512 for the matching NIST curve and EdDSA for Ed25519
+func AlgorithmForKey(key crypto.PublicKey) (string, error) {
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if key.N.BitLen() < 2048 {
+			return "", fmt.Errorf("jwt: RSA keys must be at least 2048 bits")
+		}
+		return jwt.SigningMethodRS256.Alg(), nil
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256():
+			return jwt.SigningMethodES256.Alg(), nil
+		case elliptic.P384():
+			return jwt.SigningMethodES384.Alg(), nil
+		case elliptic.P521():
+			return jwt.SigningMethodES512.Alg(), nil
+		}
+		return "", fmt.Errorf("jwt: unsupported curve %s", key.Curve.Params().Name)
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA.Alg(), nil
+	default:
+		return "", fmt.Errorf("jwt: unsupported public key type %T", key)
+	}
+}
+
+// KeyID derives a stable key ID from the SHA-256 of the public key's PKIX encoding,
+// so the same key file always publishes under the same ID
+func KeyID(key crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", fmt.Errorf("jwt: failed to encode public key: %w", err)
+	}
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:16]), nil
+}
+
+// completeSigningKey fills in a missing ID and algorithm and checks the algorithm fits the key
+func completeSigningKey(key SigningKey) (SigningKey, error) {
+	if key.Key == nil {
+		return SigningKey{}, errors.New("jwt: signing key is required")
+	}
+
+	algorithm, err := AlgorithmForKey(key.Key.Public())
+	if err != nil {
+		return SigningKey{}, err
+	}
+	if key.Algorithm == "" {
+		key.Algorithm = algorithm
+	}
+	if !algorithmFitsKey(key.Algorithm, algorithm) {
+		return SigningKey{}, fmt.Errorf("jwt: algorithm %s does not match the %s key", key.Algorithm, algorithm)
+	}
+
+	if key.ID == "" {
+		if key.ID, err = KeyID(key.Key.Public()); err != nil {
+			return SigningKey{}, err
+		}
+	}
+	return key, nil
+}
+
+// algorithmFitsKey allows the other RSA hash sizes and RSA-PSS for RSA keys;
+// EC and Ed25519 keys only have one algorithm each
+func algorithmFitsKey(algorithm, keyAlgorithm string) bool {
+	if algorithm == keyAlgorithm {
+		return true
+	}
+	if keyAlgorithm != jwt.SigningMethodRS256.Alg() {
+		return false
+	}
+	switch algorithm {
+	case "RS384", "RS512", "PS256", "PS384", "PS512":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestKeyManager_SignAndParse(t *testing.T) {
+	key, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	manager, err := NewKeyManager(key)
+	if err != nil {
+		t.Fatalf("Failed to create key manager: %v", err)
+	}
+
+	claims := &TestClaims{UserID: "user123", Type: "access"}
+	claims.SetIssuer("https://auth.example.com")
+	claims.SetAudience([]string{"web-app"})
+	token, err := manager.GenerateTokenWithExpiry(claims, 15*time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	parsed := &TestClaims{}
+	if err := manager.ParseToken(token, parsed, jwt.WithAudience("web-app")); err != nil {
+		t.Fatalf("Failed to parse token: %v", err)
+	}
+	if parsed.UserID != "user123" {
+		t.Errorf("Expected user ID %s, got %s", "user123", parsed.UserID)
+	}
+
+	if err := manager.ParseToken(token, &TestClaims{}, jwt.WithAudience("other-app")); err == nil {
+		t.Error("Expected audience mismatch to be rejected")
+	}
+}
+
+func TestKeyManager_Rotation(t *testing.T) {
+	oldKey, _ := GenerateSigningKey()
+	newKey, _ := GenerateSigningKey()
+
+	before, err := NewKeyManager(oldKey)
+	if err != nil {
+		t.Fatalf("Failed to create key manager: %v", err)
+	}
+	claims := &TestClaims{UserID: "user123"}
+	token, err := before.GenerateTokenWithExpiry(claims, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	after, err := NewKeyManager(newKey, oldKey)
+	if err != nil {
+		t.Fatalf("Failed to create key manager: %v", err)
+	}
+	if after.CurrentKeyID() != newKey.ID {
+		t.Errorf("Expected current key %s, got %s", newKey.ID, after.CurrentKeyID())
+	}
+	if err := after.ParseToken(token, &TestClaims{}); err != nil {
+		t.Errorf("Expected token signed by the previous key to verify: %v", err)
+	}
+	if keys := after.PublicKeys(); len(keys) != 2 || keys[0].ID != newKey.ID {
+		t.Errorf("Expected the current key to be published first, got %+v", keys)
+	}
+
+	dropped, _ := NewKeyManager(newKey)
+	if err := dropped.ParseToken(token, &TestClaims{}); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Expected ErrUnknownKey once the old key is removed, got %v", err)
+	}
+}
+
+func TestKeyManager_RejectsHMACTokens(t *testing.T) {
+	key, _ := GenerateSigningKey()
+	manager, _ := NewKeyManager(key)
+
+	// A token "signed" with the public key as an HMAC secret must not verify
+	claims := &TestClaims{UserID: "user123"}
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	forged.Header["kid"] = key.ID
+	der, _ := x509.MarshalPKIXPublicKey(key.Key.Public())
+	token, err := forged.SignedString(der)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+
+	if err := manager.ParseToken(token, &TestClaims{}); err == nil {
+		t.Error("Expected HS256 token to be rejected")
+	}
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate EC key: %v", err)
+	}
+	ecDER, _ := x509.MarshalECPrivateKey(ecKey)
+	pkcs8DER, _ := x509.MarshalPKCS8PrivateKey(rsaKey)
+
+	tests := []struct {
+		name      string
+		block     *pem.Block
+		algorithm string
+	}{
+		{"PKCS1", &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}, "RS256"},
+		{"PKCS8", &pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8DER}, "RS256"},
+		{"SEC1", &pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER}, "ES384"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := ParsePrivateKey(pem.EncodeToMemory(tt.block))
+			if err != nil {
+				t.Fatalf("Failed to parse key: %v", err)
+			}
+			manager, err := NewKeyManager(SigningKey{Key: signer})
+			if err != nil {
+				t.Fatalf("Failed to create key manager: %v", err)
+			}
+			if alg := manager.PublicKeys()[0].Algorithm; alg != tt.algorithm {
+				t.Errorf("Expected algorithm %s, got %s", tt.algorithm, alg)
+			}
+
+			token, err := manager.GenerateToken(&TestClaims{UserID: "user123"})
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			if err := manager.ParseToken(token, &TestClaims{}); err != nil {
+				t.Errorf("Failed to parse token: %v", err)
+			}
+		})
+	}
+
+	if _, err := ParsePrivateKey([]byte("not a key")); err == nil {
+		t.Error("Expected an error for input without a PEM block")
+	}
+}
+
+func TestNewKeyManager_AlgorithmMismatch(t *testing.T) {
+	key, _ := GenerateSigningKey()
+	key.Algorithm = "RS256"
+	if _, err := NewKeyManager(key); err == nil {
+		t.Error("Expected RS256 with an EC key to be rejected")
+	}
+}
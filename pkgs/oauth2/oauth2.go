@@ -0,0 +1,288 @@
+// Package oauth2 implements the client side of the OAuth 2.0 authorization code
+// grant with PKCE (RFC 6749, RFC 7636) and of the client credentials grant,
+// independent of any provider.
+package oauth2
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxResponseSize bounds token and userinfo response bodies
+const maxResponseSize = 1 << 20
+
+// Client authentication methods at the token endpoint
+const (
+	AuthStyleClientSecretPost  = "client_secret_post"
+	AuthStyleClientSecretBasic = "client_secret_basic"
+)
+
+// Config describes an OAuth client registered with one provider
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	AuthURL      string
+	TokenURL     string
+	RedirectURL  string
+	Scopes       []string
+	// AuthStyle selects how the client authenticates at the token endpoint
+	// (default client_secret_post)
+	AuthStyle string
+}
+
+// Token is a token endpoint response
+type Token struct {
+	AccessToken  string
+	TokenType    string
+	RefreshToken string
+	// Expiry is zero when the provider did not return expires_in
+	Expiry  time.Time
+	IDToken string
+	Scope   string
+	// Raw holds every field of the response
+	Raw map[string]interface{}
+}
+
+// Error is an error response from a token or userinfo endpoint
+type Error struct {
+	StatusCode  int
+	Code        string
+	Description string
+}
+
+func (e *Error) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("oauth2: %s: %s (HTTP %d)", e.Code, e.Description, e.StatusCode)
+	}
+	if e.Code != "" {
+		return fmt.Sprintf("oauth2: %s (HTTP %d)", e.Code, e.StatusCode)
+	}
+	return fmt.Sprintf("oauth2: unexpected HTTP %d", e.StatusCode)
+}
+
+// ErrInvalidResponse is returned when an endpoint's response cannot be parsed
+var ErrInvalidResponse = errors.New("oauth2: invalid response")
+
+// AuthCodeURL builds the authorization request URL with an S256 PKCE challenge.
+// Extra parameters such as nonce or prompt are added from params.
+func (c Config) AuthCodeURL(state, codeChallenge string, params url.Values) (string, error) {
+	u, err := url.Parse(c.AuthURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorization URL: %w", err)
+	}
+
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	query.Set("response_type", "code")
+	query.Set("client_id", c.ClientID)
+	query.Set("state", state)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", ChallengeMethodS256)
+	if c.RedirectURL != "" {
+		query.Set("redirect_uri", c.RedirectURL)
+	}
+	if len(c.Scopes) > 0 {
+		query.Set("scope", strings.Join(c.Scopes, " "))
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// Exchange redeems an authorization code and its PKCE verifier for tokens
+func (c Config) Exchange(ctx context.Context, client *http.Client, code, verifier string) (*Token, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"code_verifier": {verifier},
+	}
+	if c.RedirectURL != "" {
+		form.Set("redirect_uri", c.RedirectURL)
+	}
+	return c.requestToken(ctx, client, form)
+}
+
+// Refresh obtains new tokens with a refresh token. Providers that do not rotate
+// refresh tokens omit it from the response; the caller keeps the old one then.
+func (c Config) Refresh(ctx context.Context, client *http.Client, refreshToken string) (*Token, error) {
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	}
+	return c.requestToken(ctx, client, form)
+}
+
+// ClientCredentials obtains a token for the client itself (RFC 6749 section
+// 4.4), requesting Config.Scopes. No refresh token is issued; call it again
+// once the token expires.
+func (c Config) ClientCredentials(ctx context.Context, client *http.Client) (*Token, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(c.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.Scopes, " "))
+	}
+	return c.requestToken(ctx, client, form)
+}
+
+func (c Config) requestToken(ctx context.Context, client *http.Client, form url.Values) (*Token, error) {
+	if c.AuthStyle != AuthStyleClientSecretBasic {
+		form.Set("client_id", c.ClientID)
+		if c.ClientSecret != "" {
+			form.Set("client_secret", c.ClientSecret)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.AuthStyle == AuthStyleClientSecretBasic {
+		req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+	}
+
+	fields, status, err := doRequest(client, req)
+	if err != nil {
+		return nil, err
+	}
+	// Some providers (GitHub) report errors with HTTP 200
+	if status != http.StatusOK || fields["error"] != nil {
+		return nil, newError(status, fields)
+	}
+
+	token := &Token{
+		AccessToken:  stringValue(fields["access_token"]),
+		TokenType:    stringValue(fields["token_type"]),
+		RefreshToken: stringValue(fields["refresh_token"]),
+		IDToken:      stringValue(fields["id_token"]),
+		Scope:        stringValue(fields["scope"]),
+		Raw:          fields,
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("%w: missing access_token", ErrInvalidResponse)
+	}
+	if seconds, ok := numberValue(fields["expires_in"]); ok && seconds > 0 {
+		token.Expiry = time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	return token, nil
+}
+
+// FetchJSON performs an authenticated GET against a resource such as a userinfo
+// endpoint and decodes the JSON response. The result is either a
+// map[string]interface{} or a []interface{}.
+func FetchJSON(ctx context.Context, client *http.Client, endpoint, accessToken string, params url.Values) (interface{}, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource URL: %w", err)
+	}
+	if len(params) > 0 {
+		query := u.Query()
+		for key, values := range params {
+			query[key] = values
+		}
+		u.RawQuery = query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build resource request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("resource request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resource response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		fields := map[string]interface{}{}
+		_ = json.Unmarshal(body, &fields)
+		return nil, newError(resp.StatusCode, fields)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	return value, nil
+}
+
+// doRequest sends req and decodes a JSON or form-encoded body
+func doRequest(client *http.Client, req *http.Request) (map[string]interface{}, int, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	fields := map[string]interface{}{}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/x-www-form-urlencoded", "text/plain":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, resp.StatusCode, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+		}
+		for key := range values {
+			fields[key] = values.Get(key)
+		}
+	default:
+		if err := json.Unmarshal(body, &fields); err != nil && resp.StatusCode == http.StatusOK {
+			return nil, resp.StatusCode, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+		}
+	}
+	return fields, resp.StatusCode, nil
+}
+
+func newError(status int, fields map[string]interface{}) *Error {
+	return &Error{
+		StatusCode:  status,
+		Code:        stringValue(fields["error"]),
+		Description: stringValue(fields["error_description"]),
+	}
+}
+
+func stringValue(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
+
+// numberValue accepts JSON numbers and the numeric strings some providers send
+func numberValue(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return int64(v), true
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func constantTimeEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
@@ -0,0 +1,183 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/your-project/pkgs/oauth2/oauth2test"
+)
+
+func newConfig(provider *oauth2test.Provider) Config {
+	return Config{
+		ClientID:     provider.ClientID,
+		ClientSecret: provider.ClientSecret,
+		AuthURL:      provider.AuthURL(),
+		TokenURL:     provider.TokenURL(),
+		RedirectURL:  "https://app.example.com/callback",
+		Scopes:       []string{"openid", "email"},
+	}
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	provider := oauth2test.NewProvider("client", "secret")
+	defer provider.Close()
+
+	for _, style := range []string{AuthStyleClientSecretPost, AuthStyleClientSecretBasic} {
+		cfg := newConfig(provider)
+		cfg.AuthStyle = style
+
+		verifier, err := GenerateVerifier()
+		if err != nil {
+			t.Fatal(err)
+		}
+		authURL, err := cfg.AuthCodeURL("state-123", S256Challenge(verifier), url.Values{"nonce": {"n-1"}})
+		if err != nil {
+			t.Fatalf("AuthCodeURL returned error: %v", err)
+		}
+		query, _ := url.Parse(authURL)
+		if query.Query().Get("scope") != "openid email" || query.Query().Get("nonce") != "n-1" {
+			t.Errorf("unexpected authorization URL %s", authURL)
+		}
+
+		code, state, err := provider.Authorize(authURL, map[string]interface{}{"sub": "user-1", "email": "a@example.com"})
+		if err != nil {
+			t.Fatalf("Authorize returned error: %v", err)
+		}
+		if state != "state-123" {
+			t.Errorf("state = %q", state)
+		}
+
+		token, err := cfg.Exchange(context.Background(), http.DefaultClient, code, verifier)
+		if err != nil {
+			t.Fatalf("%s: Exchange returned error: %v", style, err)
+		}
+		if token.AccessToken == "" || token.RefreshToken == "" || token.Expiry.Before(time.Now().Add(50*time.Minute)) {
+			t.Errorf("unexpected token %+v", token)
+		}
+
+		profile, err := FetchJSON(context.Background(), http.DefaultClient, provider.UserinfoURL(), token.AccessToken, nil)
+		if err != nil {
+			t.Fatalf("FetchJSON returned error: %v", err)
+		}
+		if profile.(map[string]interface{})["sub"] != "user-1" {
+			t.Errorf("unexpected profile %+v", profile)
+		}
+
+		refreshed, err := cfg.Refresh(context.Background(), http.DefaultClient, token.RefreshToken)
+		if err != nil {
+			t.Fatalf("Refresh returned error: %v", err)
+		}
+		if refreshed.AccessToken == token.AccessToken {
+			t.Error("expected a new access token")
+		}
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	provider := oauth2test.NewProvider("client", "secret")
+	defer provider.Close()
+	cfg := newConfig(provider)
+
+	verifier, _ := GenerateVerifier()
+	authURL, _ := cfg.AuthCodeURL("state", S256Challenge(verifier), nil)
+	code, _, err := provider.Authorize(authURL, map[string]interface{}{"sub": "user-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, _ := GenerateVerifier()
+	_, err = cfg.Exchange(context.Background(), http.DefaultClient, code, other)
+	var oauthErr *Error
+	if !errors.As(err, &oauthErr) || oauthErr.Code != "invalid_grant" {
+		t.Fatalf("expected invalid_grant, got %v", err)
+	}
+
+	// The code is single use even after a failed attempt
+	if _, err := cfg.Exchange(context.Background(), http.DefaultClient, code, verifier); err == nil {
+		t.Error("expected reused code to fail")
+	}
+}
+
+func TestExchangeParsesFormEncodedResponse(t *testing.T) {
+	provider := oauth2test.NewProvider("client", "secret")
+	provider.FormEncodedTokens = true
+	defer provider.Close()
+	cfg := newConfig(provider)
+
+	verifier, _ := GenerateVerifier()
+	authURL, _ := cfg.AuthCodeURL("state", S256Challenge(verifier), nil)
+	code, _, _ := provider.Authorize(authURL, map[string]interface{}{"id": float64(42)})
+
+	token, err := cfg.Exchange(context.Background(), http.DefaultClient, code, verifier)
+	if err != nil {
+		t.Fatalf("Exchange returned error: %v", err)
+	}
+	if token.TokenType != "Bearer" || token.Expiry.IsZero() {
+		t.Errorf("unexpected token %+v", token)
+	}
+}
+
+func TestExchangeRejectsBadClient(t *testing.T) {
+	provider := oauth2test.NewProvider("client", "secret")
+	defer provider.Close()
+	cfg := newConfig(provider)
+	cfg.ClientSecret = "wrong"
+
+	_, err := cfg.Exchange(context.Background(), http.DefaultClient, "code", strings.Repeat("a", 43))
+	var oauthErr *Error
+	if !errors.As(err, &oauthErr) || oauthErr.Code != "invalid_client" || oauthErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected invalid_client, got %v", err)
+	}
+}
+
+func TestClientCredentials(t *testing.T) {
+	provider := oauth2test.NewProvider("service", "secret")
+	defer provider.Close()
+
+	cfg := Config{
+		ClientID:     provider.ClientID,
+		ClientSecret: provider.ClientSecret,
+		TokenURL:     provider.TokenURL(),
+		Scopes:       []string{"users.read"},
+		AuthStyle:    AuthStyleClientSecretBasic,
+	}
+	token, err := cfg.ClientCredentials(context.Background(), http.DefaultClient)
+	if err != nil {
+		t.Fatalf("ClientCredentials returned error: %v", err)
+	}
+	if token.RefreshToken != "" || token.Scope != "users.read" || token.Expiry.IsZero() {
+		t.Errorf("unexpected token %+v", token)
+	}
+
+	profile, err := FetchJSON(context.Background(), http.DefaultClient, provider.UserinfoURL(), token.AccessToken, nil)
+	if err != nil {
+		t.Fatalf("FetchJSON returned error: %v", err)
+	}
+	if profile.(map[string]interface{})["sub"] != "client:service" {
+		t.Errorf("unexpected subject in %v", profile)
+	}
+}
+
+func TestVerifyChallenge(t *testing.T) {
+	// RFC 7636 appendix B
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	if S256Challenge(verifier) != challenge {
+		t.Fatalf("S256Challenge = %s", S256Challenge(verifier))
+	}
+	if !VerifyChallenge(verifier, challenge, ChallengeMethodS256, false) {
+		t.Error("expected S256 verifier to match")
+	}
+	if VerifyChallenge(verifier, verifier, ChallengeMethodPlain, false) {
+		t.Error("expected plain to be refused when not allowed")
+	}
+	if VerifyChallenge("short", S256Challenge("short"), ChallengeMethodS256, false) {
+		t.Error("expected short verifier to be refused")
+	}
+}
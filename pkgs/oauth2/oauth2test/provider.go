@@ -0,0 +1,251 @@
+// Package oauth2test runs a fake OAuth 2.0 provider on httptest for exercising
+// authorization code + PKCE clients end to end.
+package oauth2test
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+)
+
+// Provider is an in-process OAuth 2.0 provider with authorize, token and userinfo endpoints
+type Provider struct {
+	Server       *httptest.Server
+	ClientID     string
+	ClientSecret string
+	// FormEncodedTokens makes the token endpoint answer like GitHub does without an Accept header
+	FormEncodedTokens bool
+	// IDToken, when set, adds an id_token to authorization code responses. It is
+	// called with the approved profile and the nonce of the authorization request.
+	IDToken func(profile map[string]interface{}, nonce string) (string, error)
+
+	mux           *http.ServeMux
+	mu            sync.Mutex
+	codes         map[string]grant
+	accessTokens  map[string]map[string]interface{}
+	refreshTokens map[string]map[string]interface{}
+}
+
+type grant struct {
+	challenge   string
+	redirectURI string
+	nonce       string
+	profile     map[string]interface{}
+}
+
+// NewProvider starts a provider accepting the given client credentials
+func NewProvider(clientID, clientSecret string) *Provider {
+	p := &Provider{
+		ClientID:      clientID,
+		ClientSecret:  clientSecret,
+		codes:         map[string]grant{},
+		accessTokens:  map[string]map[string]interface{}{},
+		refreshTokens: map[string]map[string]interface{}{},
+	}
+
+	p.mux = http.NewServeMux()
+	p.mux.HandleFunc("/authorize", p.handleAuthorize)
+	p.mux.HandleFunc("/token", p.handleToken)
+	p.mux.HandleFunc("/userinfo", p.handleUserinfo)
+	p.Server = httptest.NewServer(p.mux)
+	return p
+}
+
+// Handle registers an additional endpoint, such as discovery, on the provider's server
+func (p *Provider) Handle(pattern string, handler http.Handler) {
+	p.mux.Handle(pattern, handler)
+}
+
+// Close shuts the provider down
+func (p *Provider) Close() {
+	p.Server.Close()
+}
+
+// AuthURL returns the authorization endpoint
+func (p *Provider) AuthURL() string { return p.Server.URL + "/authorize" }
+
+// TokenURL returns the token endpoint
+func (p *Provider) TokenURL() string { return p.Server.URL + "/token" }
+
+// UserinfoURL returns the userinfo endpoint
+func (p *Provider) UserinfoURL() string { return p.Server.URL + "/userinfo" }
+
+// Authorize plays the user approving an authorization request for profile. It
+// returns the code and state the provider redirects back with.
+func (p *Provider) Authorize(authURL string, profile map[string]interface{}) (code, state string, err error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	query := u.Query()
+
+	switch {
+	case query.Get("response_type") != "code":
+		return "", "", fmt.Errorf("unsupported response_type %q", query.Get("response_type"))
+	case query.Get("client_id") != p.ClientID:
+		return "", "", fmt.Errorf("unknown client_id %q", query.Get("client_id"))
+	case query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "":
+		return "", "", fmt.Errorf("missing S256 code challenge")
+	}
+
+	code = randomString()
+	p.mu.Lock()
+	p.codes[code] = grant{
+		challenge:   query.Get("code_challenge"),
+		redirectURI: query.Get("redirect_uri"),
+		nonce:       query.Get("nonce"),
+		profile:     profile,
+	}
+	p.mu.Unlock()
+
+	return code, query.Get("state"), nil
+}
+
+func (p *Provider) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	code, state, err := p.Authorize(r.URL.String(), map[string]interface{}{"sub": "anonymous"})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	redirect, err := url.Parse(r.URL.Query().Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	query := redirect.Query()
+	query.Set("code", code)
+	query.Set("state", state)
+	redirect.RawQuery = query.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (p *Provider) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil {
+		p.writeError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != p.ClientID || clientSecret != p.ClientSecret {
+		p.writeError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+
+	var (
+		profile map[string]interface{}
+		idToken string
+	)
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		p.mu.Lock()
+		g, found := p.codes[r.PostForm.Get("code")]
+		delete(p.codes, r.PostForm.Get("code"))
+		p.mu.Unlock()
+
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if !found || g.redirectURI != r.PostForm.Get("redirect_uri") ||
+			base64.RawURLEncoding.EncodeToString(sum[:]) != g.challenge {
+			p.writeError(w, http.StatusBadRequest, "invalid_grant")
+			return
+		}
+		profile = g.profile
+		if p.IDToken != nil {
+			token, err := p.IDToken(profile, g.nonce)
+			if err != nil {
+				p.writeError(w, http.StatusInternalServerError, "server_error")
+				return
+			}
+			idToken = token
+		}
+	case "refresh_token":
+		p.mu.Lock()
+		profile = p.refreshTokens[r.PostForm.Get("refresh_token")]
+		delete(p.refreshTokens, r.PostForm.Get("refresh_token"))
+		p.mu.Unlock()
+		if profile == nil {
+			p.writeError(w, http.StatusBadRequest, "invalid_grant")
+			return
+		}
+	case "client_credentials":
+		profile = map[string]interface{}{"sub": "client:" + clientID}
+	default:
+		p.writeError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	accessToken := randomString()
+	response := map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+	}
+	if scope := r.PostForm.Get("scope"); scope != "" {
+		response["scope"] = scope
+	}
+	p.mu.Lock()
+	p.accessTokens[accessToken] = profile
+	if r.PostForm.Get("grant_type") != "client_credentials" {
+		refreshToken := randomString()
+		p.refreshTokens[refreshToken] = profile
+		response["refresh_token"] = refreshToken
+	}
+	p.mu.Unlock()
+	if idToken != "" {
+		response["id_token"] = idToken
+	}
+	if p.FormEncodedTokens {
+		values := url.Values{}
+		for key, value := range response {
+			values.Set(key, fmt.Sprint(value))
+		}
+		w.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+		w.Write([]byte(values.Encode()))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (p *Provider) handleUserinfo(w http.ResponseWriter, r *http.Request) {
+	const prefix = "Bearer "
+	header := r.Header.Get("Authorization")
+	if len(header) <= len(prefix) || header[:len(prefix)] != prefix {
+		p.writeError(w, http.StatusUnauthorized, "invalid_token")
+		return
+	}
+
+	p.mu.Lock()
+	profile, ok := p.accessTokens[header[len(prefix):]]
+	p.mu.Unlock()
+	if !ok {
+		p.writeError(w, http.StatusUnauthorized, "invalid_token")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile)
+}
+
+func (p *Provider) writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": code})
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,69 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ClientSecretJWT produces the short-lived signed client secret some providers
+// require instead of a static one. Apple expects an ES256 token with the team ID
+// as iss, the Services ID as sub and "https://appleid.apple.com" as aud.
+type ClientSecretJWT struct {
+	Issuer   string
+	ClientID string
+	KeyID    string
+	Audience string
+	Key      *ecdsa.PrivateKey
+	// TTL defaults to five minutes
+	TTL time.Duration
+}
+
+// Sign returns the client secret valid from now
+func (c ClientSecretJWT) Sign(now time.Time) (string, error) {
+	if c.Key == nil || c.Issuer == "" || c.ClientID == "" || c.Audience == "" {
+		return "", errors.New("oidc: incomplete client secret JWT configuration")
+	}
+	ttl := c.TTL
+	if ttl == 0 {
+		ttl = 5 * time.Minute
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.RegisteredClaims{
+		Issuer:    c.Issuer,
+		Subject:   c.ClientID,
+		Audience:  jwt.ClaimStrings{c.Audience},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+	})
+	if c.KeyID != "" {
+		token.Header["kid"] = c.KeyID
+	}
+	return token.SignedString(c.Key)
+}
+
+// ParseECPrivateKey reads a PEM encoded PKCS #8 or SEC 1 EC private key, such
+// as the .p8 file Apple issues for Sign in with Apple
+func ParseECPrivateKey(pemData []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("oidc: no PEM block found")
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: failed to parse private key: %w", err)
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("oidc: private key is %T, not ECDSA", parsed)
+	}
+	return key, nil
+}
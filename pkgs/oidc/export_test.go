@@ -0,0 +1,8 @@
+package oidc
+
+import "time"
+
+// SetKeySetClock lets external tests move a key set's clock
+func SetKeySetClock(s *KeySet, now func() time.Time) {
+	s.now = now
+}
@@ -0,0 +1,241 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// minRefreshInterval limits refetches triggered by unknown key IDs, so tokens
+// with made-up kids cannot be used to hammer the provider
+const minRefreshInterval = time.Minute
+
+// ErrKeyNotFound is returned when no key in the set matches a token
+var ErrKeyNotFound = errors.New("oidc: signing key not found")
+
+// JSONWebKey is a public key in JWK form (RFC 7517)
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC and OKP
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// PublicKey decodes the key material
+func (k JSONWebKey) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("oidc: invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("oidc: unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("oidc: EC point is not on curve %s", k.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("oidc: unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("oidc: invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("oidc: unsupported key type %q", k.Kty)
+	}
+}
+
+// NewJSONWebKey encodes an RSA, ECDSA or Ed25519 public key as a JWK
+func NewJSONWebKey(key crypto.PublicKey, kid, alg string) (JSONWebKey, error) {
+	jwk := JSONWebKey{Kid: kid, Use: "sig", Alg: alg}
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = key.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size)))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(key)
+	default:
+		return JSONWebKey{}, fmt.Errorf("oidc: unsupported public key type %T", key)
+	}
+	return jwk, nil
+}
+
+// JSONWebKeySet is the document served at a jwks_uri
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// KeySet fetches and caches a provider's signing keys. Keys are refetched after
+// the TTL, or early when a token names a key ID that is not in the cached set
+// (providers rotate keys by publishing the new one first).
+type KeySet struct {
+	client *http.Client
+	url    string
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	keys      []parsedKey
+	fetchedAt time.Time
+}
+
+type parsedKey struct {
+	kid string
+	kty string
+	alg string
+	key crypto.PublicKey
+}
+
+// NewKeySet creates a key set for jwksURL
+func NewKeySet(client *http.Client, jwksURL string, ttl time.Duration) *KeySet {
+	return &KeySet{client: client, url: jwksURL, ttl: ttl, now: time.Now}
+}
+
+// Key returns the signing key for a token header. A token without kid matches
+// the only key of a compatible type.
+func (s *KeySet) Key(ctx context.Context, kid, alg string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.fetchedAt.IsZero() || s.now().Sub(s.fetchedAt) >= s.ttl {
+		if err := s.refresh(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if key := s.find(kid, alg); key != nil {
+		return key, nil
+	}
+
+	if s.now().Sub(s.fetchedAt) < minRefreshInterval {
+		return nil, ErrKeyNotFound
+	}
+	if err := s.refresh(ctx); err != nil {
+		return nil, err
+	}
+	if key := s.find(kid, alg); key != nil {
+		return key, nil
+	}
+	return nil, ErrKeyNotFound
+}
+
+func (s *KeySet) find(kid, alg string) crypto.PublicKey {
+	var candidates []parsedKey
+	for _, key := range s.keys {
+		if key.alg != "" && key.alg != alg {
+			continue
+		}
+		if key.kty != keyTypeForAlg(alg) {
+			continue
+		}
+		if kid != "" && key.kid == kid {
+			return key.key
+		}
+		candidates = append(candidates, key)
+	}
+	if kid == "" && len(candidates) == 1 {
+		return candidates[0].key
+	}
+	return nil
+}
+
+func (s *KeySet) refresh(ctx context.Context) error {
+	var set JSONWebKeySet
+	if err := getJSON(ctx, s.client, s.url, &set); err != nil {
+		return err
+	}
+
+	keys := make([]parsedKey, 0, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			// Skip keys this package cannot use instead of failing the whole set
+			continue
+		}
+		keys = append(keys, parsedKey{kid: jwk.Kid, kty: jwk.Kty, alg: jwk.Alg, key: key})
+	}
+
+	s.keys = keys
+	s.fetchedAt = s.now()
+	return nil
+}
+
+// keyTypeForAlg maps a JWS algorithm onto the JWK key type it needs
+func keyTypeForAlg(alg string) string {
+	switch alg {
+	case "RS256", "RS384", "RS512", "PS256", "PS384", "PS512":
+		return "RSA"
+	case "ES256", "ES384", "ES512":
+		return "EC"
+	case "EdDSA":
+		return "OKP"
+	default:
+		return ""
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("oidc: invalid key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,161 @@
+// Package oidc validates OpenID Connect ID tokens from upstream providers: it
+// reads provider metadata through discovery, caches the provider's JSON Web Key
+// Set and checks signatures and the issuer, audience, azp, nonce and time claims.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxResponseSize bounds discovery and JWKS response bodies
+const maxResponseSize = 1 << 20
+
+// DiscoveryPath is appended to an issuer to locate its metadata
+const DiscoveryPath = "/.well-known/openid-configuration"
+
+var (
+	// ErrInvalidMetadata is returned for unusable discovery documents
+	ErrInvalidMetadata = errors.New("oidc: invalid provider metadata")
+	// ErrInvalidIDToken wraps every ID token validation failure
+	ErrInvalidIDToken = errors.New("oidc: invalid id_token")
+)
+
+// Metadata is the subset of an OpenID Provider Configuration the package uses
+type Metadata struct {
+	Issuer                           string   `json:"issuer"`
+	AuthorizationEndpoint            string   `json:"authorization_endpoint"`
+	TokenEndpoint                    string   `json:"token_endpoint"`
+	UserinfoEndpoint                 string   `json:"userinfo_endpoint"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+}
+
+// DiscoveryURL returns the metadata location for an issuer
+func DiscoveryURL(issuer string) string {
+	return strings.TrimSuffix(issuer, "/") + DiscoveryPath
+}
+
+// Discover fetches provider metadata. The document's issuer must equal the
+// expected issuer exactly; multi-tenant templates such as Microsoft's
+// "{tenantid}" are compared verbatim.
+func Discover(ctx context.Context, client *http.Client, discoveryURL, issuer string) (*Metadata, error) {
+	var metadata Metadata
+	if err := getJSON(ctx, client, discoveryURL, &metadata); err != nil {
+		return nil, err
+	}
+	if metadata.Issuer != issuer {
+		return nil, fmt.Errorf("%w: issuer %q does not match %q", ErrInvalidMetadata, metadata.Issuer, issuer)
+	}
+	if metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: missing jwks_uri", ErrInvalidMetadata)
+	}
+	return &metadata, nil
+}
+
+// Provider is a discovered provider together with its key set
+type Provider struct {
+	Metadata *Metadata
+	Keys     *KeySet
+}
+
+// Verifier returns an ID token verifier for a client registered with the provider.
+// Extra issuers are accepted in addition to the discovered one (Google also
+// issues tokens as "accounts.google.com").
+func (p *Provider) Verifier(clientID string, extraIssuers ...string) *Verifier {
+	return &Verifier{
+		Issuers:    append([]string{p.Metadata.Issuer}, extraIssuers...),
+		ClientID:   clientID,
+		Keys:       p.Keys,
+		Algorithms: p.Metadata.IDTokenSigningAlgValuesSupported,
+	}
+}
+
+// Cache keeps discovered providers and their key sets for a while so that
+// callbacks do not fetch metadata on every sign-in
+type Cache struct {
+	client *http.Client
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	providers map[string]*cachedProvider
+}
+
+type cachedProvider struct {
+	provider  *Provider
+	fetchedAt time.Time
+}
+
+// NewCache creates a cache whose metadata and keys are refreshed after ttl
+func NewCache(client *http.Client, ttl time.Duration) *Cache {
+	return &Cache{
+		client:    client,
+		ttl:       ttl,
+		now:       time.Now,
+		providers: map[string]*cachedProvider{},
+	}
+}
+
+// Provider returns the cached provider for discoveryURL, discovering it when
+// missing or older than the cache TTL. The key set survives metadata refreshes
+// as long as jwks_uri is unchanged.
+func (c *Cache) Provider(ctx context.Context, discoveryURL, issuer string) (*Provider, error) {
+	c.mu.Lock()
+	cached := c.providers[discoveryURL]
+	c.mu.Unlock()
+	if cached != nil && c.now().Sub(cached.fetchedAt) < c.ttl && cached.provider.Metadata.Issuer == issuer {
+		return cached.provider, nil
+	}
+
+	metadata, err := Discover(ctx, c.client, discoveryURL, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := &Provider{Metadata: metadata}
+	if cached != nil && cached.provider.Metadata.JWKSURI == metadata.JWKSURI {
+		provider.Keys = cached.provider.Keys
+	} else {
+		provider.Keys = NewKeySet(c.client, metadata.JWKSURI, c.ttl)
+	}
+
+	c.mu.Lock()
+	c.providers[discoveryURL] = &cachedProvider{provider: provider, fetchedAt: c.now()}
+	c.mu.Unlock()
+
+	return provider, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, endpoint string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", endpoint, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s returned HTTP %d", ErrInvalidMetadata, endpoint, resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMetadata, err)
+	}
+	return nil
+}
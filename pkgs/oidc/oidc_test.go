@@ -0,0 +1,253 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/your-project/pkgs/oauth2"
+	"github.com/your-project/pkgs/oidc"
+	"github.com/your-project/pkgs/oidc/oidctest"
+)
+
+func newProvider(t *testing.T) *oidctest.Provider {
+	t.Helper()
+	provider, err := oidctest.NewProvider("client-1", "secret-1")
+	if err != nil {
+		t.Fatalf("Failed to start provider: %v", err)
+	}
+	t.Cleanup(provider.Close)
+	return provider
+}
+
+func newVerifier(t *testing.T, provider *oidctest.Provider) *oidc.Verifier {
+	t.Helper()
+	discovered, err := oidc.NewCache(http.DefaultClient, time.Hour).Provider(context.Background(), provider.DiscoveryURL(), provider.Issuer())
+	if err != nil {
+		t.Fatalf("Discovery failed: %v", err)
+	}
+	return discovered.Verifier(provider.ClientID)
+}
+
+func TestAuthorizationCodeFlowWithIDToken(t *testing.T) {
+	provider := newProvider(t)
+	ctx := context.Background()
+
+	discovered, err := oidc.NewCache(http.DefaultClient, time.Hour).Provider(ctx, provider.DiscoveryURL(), provider.Issuer())
+	if err != nil {
+		t.Fatalf("Discovery failed: %v", err)
+	}
+	if discovered.Metadata.TokenEndpoint != provider.TokenURL() {
+		t.Errorf("Expected token endpoint %s, got %s", provider.TokenURL(), discovered.Metadata.TokenEndpoint)
+	}
+
+	cfg := oauth2.Config{
+		ClientID:     provider.ClientID,
+		ClientSecret: provider.ClientSecret,
+		AuthURL:      discovered.Metadata.AuthorizationEndpoint,
+		TokenURL:     discovered.Metadata.TokenEndpoint,
+		RedirectURL:  "https://app.example.com/callback",
+		Scopes:       []string{"openid", "email"},
+	}
+	verifier, _ := oauth2.GenerateVerifier()
+	authURL, err := cfg.AuthCodeURL("state-1", oauth2.S256Challenge(verifier), map[string][]string{"nonce": {"nonce-1"}})
+	if err != nil {
+		t.Fatalf("AuthCodeURL failed: %v", err)
+	}
+	code, _, err := provider.Authorize(authURL, map[string]interface{}{
+		"sub": "user-1", "email": "user@example.com", "email_verified": true,
+	})
+	if err != nil {
+		t.Fatalf("Authorize failed: %v", err)
+	}
+	token, err := cfg.Exchange(ctx, http.DefaultClient, code, verifier)
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+
+	idToken, err := discovered.Verifier(provider.ClientID).Verify(ctx, token.IDToken, "nonce-1")
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if idToken.Subject != "user-1" || idToken.Claims["email"] != "user@example.com" {
+		t.Errorf("Unexpected claims: %+v", idToken.Claims)
+	}
+
+	if _, err := discovered.Verifier(provider.ClientID).Verify(ctx, token.IDToken, "other-nonce"); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Errorf("Expected nonce mismatch to fail, got %v", err)
+	}
+}
+
+func TestVerifyRejectsInvalidClaims(t *testing.T) {
+	provider := newProvider(t)
+	verifier := newVerifier(t, provider)
+
+	tests := []struct {
+		name   string
+		claims map[string]interface{}
+	}{
+		{"wrong issuer", map[string]interface{}{"iss": "https://evil.example.com"}},
+		{"wrong audience", map[string]interface{}{"aud": "client-2"}},
+		{"multiple audiences without azp", map[string]interface{}{"aud": []string{"client-1", "client-2"}}},
+		{"foreign azp", map[string]interface{}{"azp": "client-2"}},
+		{"expired", map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()}},
+		{"malformed exp", map[string]interface{}{"exp": "never"}},
+		{"issued in the future", map[string]interface{}{"iat": time.Now().Add(time.Hour).Unix()}},
+		{"missing subject", map[string]interface{}{"sub": ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := map[string]interface{}{"sub": "user-1"}
+			for key, value := range tt.claims {
+				claims[key] = value
+			}
+			raw, err := provider.SignIDToken(claims)
+			if err != nil {
+				t.Fatalf("Failed to sign token: %v", err)
+			}
+			if _, err := verifier.Verify(context.Background(), raw, ""); !errors.Is(err, oidc.ErrInvalidIDToken) {
+				t.Errorf("Expected ErrInvalidIDToken, got %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyAcceptsMultipleAudiencesWithAzp(t *testing.T) {
+	provider := newProvider(t)
+	verifier := newVerifier(t, provider)
+
+	raw, _ := provider.SignIDToken(map[string]interface{}{
+		"sub": "user-1", "aud": []string{"client-1", "client-2"}, "azp": "client-1",
+	})
+	if _, err := verifier.Verify(context.Background(), raw, ""); err != nil {
+		t.Errorf("Expected token to verify, got %v", err)
+	}
+}
+
+func TestVerifyRejectsForgedSignatureAndAlgorithms(t *testing.T) {
+	provider := newProvider(t)
+	verifier := newVerifier(t, provider)
+
+	raw, _ := provider.SignIDToken(map[string]interface{}{"sub": "user-1"})
+	parts := strings.Split(raw, ".")
+	forged := parts[0] + "." + parts[1] + "." + strings.Repeat("A", len(parts[2]))
+	if _, err := verifier.Verify(context.Background(), forged, ""); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Errorf("Expected forged signature to fail, got %v", err)
+	}
+
+	hmac := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss": provider.Issuer(), "aud": provider.ClientID, "sub": "user-1", "exp": time.Now().Add(time.Hour).Unix(),
+	})
+	hmac.Header["kid"] = "key-1"
+	hmacRaw, _ := hmac.SignedString([]byte("secret-1"))
+	if _, err := verifier.Verify(context.Background(), hmacRaw, ""); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Errorf("Expected HS256 token to fail, got %v", err)
+	}
+}
+
+func TestKeySetRefetchesOnUnknownKeyID(t *testing.T) {
+	provider := newProvider(t)
+	verifier := newVerifier(t, provider)
+	now := time.Now()
+	oidc.SetKeySetClock(verifier.Keys, func() time.Time { return now })
+	ctx := context.Background()
+
+	raw, _ := provider.SignIDToken(map[string]interface{}{"sub": "user-1"})
+	if _, err := verifier.Verify(ctx, raw, ""); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+
+	if err := provider.RotateKey(false); err != nil {
+		t.Fatalf("RotateKey failed: %v", err)
+	}
+	rotated, _ := provider.SignIDToken(map[string]interface{}{"sub": "user-1"})
+	if _, err := verifier.Verify(ctx, rotated, ""); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Errorf("Expected unknown kid to fail within the refresh interval, got %v", err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := verifier.Verify(ctx, rotated, ""); err != nil {
+		t.Errorf("Expected rotated key to be fetched, got %v", err)
+	}
+}
+
+func TestVerifyTenantIssuerTemplate(t *testing.T) {
+	provider := newProvider(t)
+	keys := oidc.NewKeySet(http.DefaultClient, provider.JWKSURL(), time.Hour)
+	verifier := &oidc.Verifier{
+		Issuers:  []string{"https://login.example.com/{tenantid}/v2.0"},
+		ClientID: provider.ClientID,
+		Keys:     keys,
+	}
+
+	raw, _ := provider.SignIDToken(map[string]interface{}{
+		"sub": "user-1", "tid": "tenant-1", "iss": "https://login.example.com/tenant-1/v2.0",
+	})
+	if _, err := verifier.Verify(context.Background(), raw, ""); err != nil {
+		t.Errorf("Expected tenant issuer to verify, got %v", err)
+	}
+
+	raw, _ = provider.SignIDToken(map[string]interface{}{
+		"sub": "user-1", "tid": "tenant-2", "iss": "https://login.example.com/tenant-1/v2.0",
+	})
+	if _, err := verifier.Verify(context.Background(), raw, ""); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Errorf("Expected issuer of another tenant to fail, got %v", err)
+	}
+}
+
+func TestDiscoverRejectsIssuerMismatch(t *testing.T) {
+	provider := newProvider(t)
+	_, err := oidc.Discover(context.Background(), http.DefaultClient, provider.DiscoveryURL(), "https://other.example.com")
+	if !errors.Is(err, oidc.ErrInvalidMetadata) {
+		t.Errorf("Expected ErrInvalidMetadata, got %v", err)
+	}
+}
+
+func TestClientSecretJWT(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	parsed, err := oidc.ParseECPrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		t.Fatalf("ParseECPrivateKey failed: %v", err)
+	}
+
+	now := time.Now()
+	secret, err := oidc.ClientSecretJWT{
+		Issuer:   "TEAM123",
+		ClientID: "com.example.app",
+		KeyID:    "KEY123",
+		Audience: "https://appleid.apple.com",
+		Key:      parsed,
+	}.Sign(now)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	claims := jwt.RegisteredClaims{}
+	token, err := jwt.ParseWithClaims(secret, &claims, func(*jwt.Token) (interface{}, error) {
+		return &key.PublicKey, nil
+	}, jwt.WithValidMethods([]string{"ES256"}))
+	if err != nil {
+		t.Fatalf("Failed to parse client secret: %v", err)
+	}
+	if token.Header["kid"] != "KEY123" || claims.Issuer != "TEAM123" || claims.Subject != "com.example.app" {
+		t.Errorf("Unexpected client secret: header %v claims %+v", token.Header, claims)
+	}
+	if claims.ExpiresAt.Time.Sub(now) > 6*time.Minute {
+		t.Errorf("Expected a short-lived secret, expires at %v", claims.ExpiresAt)
+	}
+}
@@ -0,0 +1,135 @@
+// Package oidctest runs a fake OpenID Connect provider on httptest: the
+// oauth2test endpoints plus discovery, a JWKS document and signed ID tokens.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/your-project/pkgs/oauth2/oauth2test"
+	"github.com/your-project/pkgs/oidc"
+)
+
+// Provider is an in-process OpenID provider whose issuer is the server URL
+type Provider struct {
+	*oauth2test.Provider
+
+	// Claims are merged into every ID token after the defaults, so tests can
+	// override iss, aud, azp, exp or nonce
+	Claims map[string]interface{}
+
+	mu    sync.Mutex
+	keyID string
+	key   *rsa.PrivateKey
+	// retired keys stay published so tokens signed before a rotation still verify
+	published []oidc.JSONWebKey
+	keyCount  int
+}
+
+// NewProvider starts a provider accepting the given client credentials
+func NewProvider(clientID, clientSecret string) (*Provider, error) {
+	p := &Provider{Provider: oauth2test.NewProvider(clientID, clientSecret)}
+	if err := p.RotateKey(false); err != nil {
+		p.Close()
+		return nil, err
+	}
+
+	p.Handle(oidc.DiscoveryPath, http.HandlerFunc(p.handleDiscovery))
+	p.Handle("/jwks.json", http.HandlerFunc(p.handleJWKS))
+	p.Provider.IDToken = func(profile map[string]interface{}, nonce string) (string, error) {
+		claims := map[string]interface{}{}
+		for key, value := range profile {
+			claims[key] = value
+		}
+		if nonce != "" {
+			claims["nonce"] = nonce
+		}
+		return p.SignIDToken(claims)
+	}
+	return p, nil
+}
+
+// Issuer returns the provider's issuer identifier
+func (p *Provider) Issuer() string { return p.Server.URL }
+
+// DiscoveryURL returns the metadata endpoint
+func (p *Provider) DiscoveryURL() string { return oidc.DiscoveryURL(p.Issuer()) }
+
+// JWKSURL returns the key set endpoint
+func (p *Provider) JWKSURL() string { return p.Server.URL + "/jwks.json" }
+
+// RotateKey switches to a new signing key. With keepOld the previous key stays
+// in the key set; without it only the new key is published.
+func (p *Provider) RotateKey(keepOld bool) error {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keyCount++
+	p.key = key
+	p.keyID = fmt.Sprintf("key-%d", p.keyCount)
+	jwk, err := oidc.NewJSONWebKey(&key.PublicKey, p.keyID, "RS256")
+	if err != nil {
+		return err
+	}
+	if !keepOld {
+		p.published = nil
+	}
+	p.published = append(p.published, jwk)
+	return nil
+}
+
+// SignIDToken signs claims with the current key after adding iss, aud, iat and
+// exp defaults and applying Claims
+func (p *Provider) SignIDToken(claims map[string]interface{}) (string, error) {
+	now := time.Now()
+	mapClaims := jwt.MapClaims{
+		"iss": p.Issuer(),
+		"aud": p.ClientID,
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+	for key, value := range claims {
+		mapClaims[key] = value
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, value := range p.Claims {
+		mapClaims[key] = value
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, mapClaims)
+	token.Header["kid"] = p.keyID
+	return token.SignedString(p.key)
+}
+
+func (p *Provider) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(oidc.Metadata{
+		Issuer:                           p.Issuer(),
+		AuthorizationEndpoint:            p.AuthURL(),
+		TokenEndpoint:                    p.TokenURL(),
+		UserinfoEndpoint:                 p.UserinfoURL(),
+		JWKSURI:                          p.JWKSURL(),
+		IDTokenSigningAlgValuesSupported: []string{"RS256"},
+	})
+}
+
+func (p *Provider) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	set := oidc.JSONWebKeySet{Keys: append([]oidc.JSONWebKey(nil), p.published...)}
+	p.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(set)
+}
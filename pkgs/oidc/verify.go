@@ -0,0 +1,182 @@
+package oidc
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultClockSkew is tolerated on exp, iat and nbf
+const DefaultClockSkew = time.Minute
+
+// TenantPlaceholder in an issuer is replaced by the token's tid claim, as used by
+// Microsoft's multi-tenant endpoints
+const TenantPlaceholder = "{tenantid}"
+
+// supportedAlgorithms are the asymmetric algorithms accepted for ID tokens;
+// "none" and HMAC algorithms are never accepted
+var supportedAlgorithms = []string{
+	"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA",
+}
+
+// Verifier validates ID tokens issued to one client
+type Verifier struct {
+	// Issuers lists the accepted iss values; the first is the canonical one
+	Issuers  []string
+	ClientID string
+	Keys     *KeySet
+	// Algorithms restricts the signing algorithms (default RS256)
+	Algorithms []string
+	// ClockSkew defaults to DefaultClockSkew
+	ClockSkew time.Duration
+	// Now defaults to time.Now
+	Now func() time.Time
+}
+
+// IDToken is a validated ID token
+type IDToken struct {
+	Issuer          string
+	Subject         string
+	Audience        []string
+	AuthorizedParty string
+	Nonce           string
+	Expiry          time.Time
+	IssuedAt        time.Time
+	// Claims holds every claim of the token
+	Claims map[string]interface{}
+}
+
+// Verify checks the token's signature and its iss, aud, azp, exp, iat, nbf and
+// nonce claims. The nonce is compared whenever expectedNonce is not empty.
+func (v *Verifier) Verify(ctx context.Context, rawIDToken, expectedNonce string) (*IDToken, error) {
+	if rawIDToken == "" {
+		return nil, fmt.Errorf("%w: missing", ErrInvalidIDToken)
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return v.Keys.Key(ctx, kid, token.Method.Alg())
+	}, jwt.WithValidMethods(v.algorithms()), jwt.WithoutClaimsValidation())
+	if err != nil {
+		if errors.Is(err, ErrInvalidMetadata) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	token := &IDToken{Claims: claims}
+	token.Issuer, _ = claims["iss"].(string)
+	token.Subject, _ = claims["sub"].(string)
+	token.AuthorizedParty, _ = claims["azp"].(string)
+	token.Nonce, _ = claims["nonce"].(string)
+	if aud, err := claims.GetAudience(); err == nil {
+		token.Audience = aud
+	}
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		token.Expiry = exp.Time
+	}
+	if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		token.IssuedAt = iat.Time
+	}
+
+	if err := v.checkClaims(token, claims, expectedNonce); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (v *Verifier) checkClaims(token *IDToken, claims jwt.MapClaims, expectedNonce string) error {
+	tenant, _ := claims["tid"].(string)
+	if !v.issuerAllowed(token.Issuer, tenant) {
+		return fmt.Errorf("%w: unexpected issuer %q", ErrInvalidIDToken, token.Issuer)
+	}
+	if token.Subject == "" {
+		return fmt.Errorf("%w: missing sub", ErrInvalidIDToken)
+	}
+
+	audienceOK := false
+	for _, aud := range token.Audience {
+		if aud == v.ClientID {
+			audienceOK = true
+		}
+	}
+	if !audienceOK {
+		return fmt.Errorf("%w: audience does not include the client", ErrInvalidIDToken)
+	}
+	if len(token.Audience) > 1 && token.AuthorizedParty == "" {
+		return fmt.Errorf("%w: azp required with multiple audiences", ErrInvalidIDToken)
+	}
+	if token.AuthorizedParty != "" && token.AuthorizedParty != v.ClientID {
+		return fmt.Errorf("%w: azp %q is not the client", ErrInvalidIDToken, token.AuthorizedParty)
+	}
+
+	now := v.now()
+	skew := v.ClockSkew
+	if skew == 0 {
+		skew = DefaultClockSkew
+	}
+	if token.Expiry.IsZero() {
+		return fmt.Errorf("%w: missing exp", ErrInvalidIDToken)
+	}
+	if now.After(token.Expiry.Add(skew)) {
+		return fmt.Errorf("%w: expired at %s", ErrInvalidIDToken, token.Expiry.UTC().Format(time.RFC3339))
+	}
+	if !token.IssuedAt.IsZero() && token.IssuedAt.After(now.Add(skew)) {
+		return fmt.Errorf("%w: issued in the future", ErrInvalidIDToken)
+	}
+	if nbf, err := claims.GetNotBefore(); err == nil && nbf != nil && nbf.Time.After(now.Add(skew)) {
+		return fmt.Errorf("%w: not valid yet", ErrInvalidIDToken)
+	}
+
+	if expectedNonce != "" && subtle.ConstantTimeCompare([]byte(token.Nonce), []byte(expectedNonce)) != 1 {
+		return fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	return nil
+}
+
+func (v *Verifier) issuerAllowed(issuer, tenant string) bool {
+	for _, allowed := range v.Issuers {
+		if strings.Contains(allowed, TenantPlaceholder) {
+			if tenant == "" {
+				continue
+			}
+			allowed = strings.ReplaceAll(allowed, TenantPlaceholder, tenant)
+		}
+		if issuer == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// algorithms returns the configured algorithms that are safe to accept
+func (v *Verifier) algorithms() []string {
+	if len(v.Algorithms) == 0 {
+		return []string{"RS256"}
+	}
+	var algs []string
+	for _, alg := range v.Algorithms {
+		for _, supported := range supportedAlgorithms {
+			if alg == supported {
+				algs = append(algs, alg)
+			}
+		}
+	}
+	if len(algs) == 0 {
+		return []string{"RS256"}
+	}
+	return algs
+}
+
+func (v *Verifier) now() time.Time {
+	if v.Now != nil {
+		return v.Now()
+	}
+	return time.Now()
+}
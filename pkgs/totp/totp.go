@@ -0,0 +1,185 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Default parameters understood by all common authenticator apps
+const (
+	DefaultDigits     = 6
+	DefaultPeriod     = 30 * time.Second
+	DefaultSkew       = 1
+	DefaultSecretSize = 20
+)
+
+// Supported range of Options; normalize clamps values outside it
+const (
+	minDigits = 6
+	maxDigits = 8
+	minPeriod = time.Second
+)
+
+// secretEncoding is the unpadded base32 alphabet used in otpauth:// URIs
+var secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Options controls how codes are generated and validated
+type Options struct {
+	// Digits is the number of digits in a code, from 6 to 8
+	Digits int
+	// Period is the lifetime of a single time step, at least a second
+	Period time.Duration
+	// Skew is the number of steps accepted either side of the current one
+	Skew int
+}
+
+// DefaultOptions returns the RFC 6238 defaults (6 digits, 30 second steps, ±1 step)
+func DefaultOptions() Options {
+	return Options{
+		Digits: DefaultDigits,
+		Period: DefaultPeriod,
+		Skew:   DefaultSkew,
+	}
+}
+
+// normalize fills in zero values with defaults and clamps the rest into the
+// supported range. A sub-second period would divide by zero in Step, and more
+// than 8 digits overflow the modulus in hotp.
+func (o Options) normalize() Options {
+	switch {
+	case o.Digits == 0:
+		o.Digits = DefaultDigits
+	case o.Digits < minDigits:
+		o.Digits = minDigits
+	case o.Digits > maxDigits:
+		o.Digits = maxDigits
+	}
+	switch {
+	case o.Period == 0:
+		o.Period = DefaultPeriod
+	case o.Period < minPeriod:
+		o.Period = minPeriod
+	}
+	if o.Skew < 0 {
+		o.Skew = 0
+	}
+	return o
+}
+
+// GenerateSecret creates a new random base32 encoded shared secret
+func GenerateSecret() (string, error) {
+	buf := make([]byte, DefaultSecretSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return secretEncoding.EncodeToString(buf), nil
+}
+
+// DecodeSecret decodes a base32 secret, tolerating lowercase, spaces and padding
+func DecodeSecret(secret string) ([]byte, error) {
+	cleaned := strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	cleaned = strings.TrimRight(cleaned, "=")
+	key, err := secretEncoding.DecodeString(cleaned)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret encoding: %w", err)
+	}
+	return key, nil
+}
+
+// Step returns the time step counter for t
+func Step(t time.Time, opts Options) int64 {
+	opts = opts.normalize()
+	return t.Unix() / int64(opts.Period/time.Second)
+}
+
+// GenerateCode returns the code for the time step containing t
+func GenerateCode(secret string, t time.Time, opts Options) (string, error) {
+	key, err := DecodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	opts = opts.normalize()
+	return hotp(key, Step(t, opts), opts.Digits), nil
+}
+
+// Validate checks code against the steps around t and returns the matching step.
+// Callers should persist the returned step and reject any later code whose step
+// is not greater than it to prevent replay.
+func Validate(secret, code string, t time.Time, opts Options) (int64, bool, error) {
+	key, err := DecodeSecret(secret)
+	if err != nil {
+		return 0, false, err
+	}
+	opts = opts.normalize()
+
+	code = strings.TrimSpace(code)
+	if len(code) != opts.Digits {
+		return 0, false, nil
+	}
+
+	current := Step(t, opts)
+	for offset := -opts.Skew; offset <= opts.Skew; offset++ {
+		step := current + int64(offset)
+		expected := hotp(key, step, opts.Digits)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+// KeyURI builds the otpauth:// URI rendered as a QR code by authenticator apps
+func KeyURI(issuer, accountName, secret string, opts Options) string {
+	opts = opts.normalize()
+
+	label := accountName
+	if issuer != "" {
+		label = issuer + ":" + accountName
+	}
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	if issuer != "" {
+		query.Set("issuer", issuer)
+	}
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprintf("%d", opts.Digits))
+	query.Set("period", fmt.Sprintf("%d", int64(opts.Period/time.Second)))
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + label,
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
+
+// hotp implements RFC 4226 with HMAC-SHA1 and dynamic truncation
+func hotp(key []byte, counter int64, digits int) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
@@ -0,0 +1,151 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the base32 form of the RFC 6238 SHA1 test key "12345678901234567890"
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateCode_RFC6238Vectors(t *testing.T) {
+	opts := Options{Digits: 8, Period: 30 * time.Second}
+
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, v := range vectors {
+		code, err := GenerateCode(rfcSecret, time.Unix(v.unix, 0), opts)
+		if err != nil {
+			t.Fatalf("Failed to generate code: %v", err)
+		}
+		if code != v.code {
+			t.Errorf("At %d expected code %s, got %s", v.unix, v.code, code)
+		}
+	}
+}
+
+func TestValidate_DriftWindow(t *testing.T) {
+	opts := DefaultOptions()
+	now := time.Unix(1700000000, 0)
+
+	previous, _ := GenerateCode(rfcSecret, now.Add(-30*time.Second), opts)
+	step, ok, err := Validate(rfcSecret, previous, now, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !ok {
+		t.Fatal("Expected code from previous step to be accepted")
+	}
+	if step != Step(now, opts)-1 {
+		t.Errorf("Expected matched step %d, got %d", Step(now, opts)-1, step)
+	}
+
+	stale, _ := GenerateCode(rfcSecret, now.Add(-90*time.Second), opts)
+	if _, ok, _ := Validate(rfcSecret, stale, now, opts); ok {
+		t.Error("Expected code outside the drift window to be rejected")
+	}
+
+	if _, ok, _ := Validate(rfcSecret, "12345", now, opts); ok {
+		t.Error("Expected code with wrong length to be rejected")
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("Failed to generate secret: %v", err)
+	}
+
+	key, err := DecodeSecret(strings.ToLower(secret))
+	if err != nil {
+		t.Fatalf("Failed to decode generated secret: %v", err)
+	}
+	if len(key) != DefaultSecretSize {
+		t.Errorf("Expected %d byte secret, got %d", DefaultSecretSize, len(key))
+	}
+}
+
+func TestKeyURI(t *testing.T) {
+	uri := KeyURI("Example", "user@example.com", rfcSecret, DefaultOptions())
+
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		t.Fatalf("Failed to parse URI: %v", err)
+	}
+	if parsed.Scheme != "otpauth" || parsed.Host != "totp" {
+		t.Errorf("Unexpected URI prefix: %s", uri)
+	}
+	if parsed.Path != "/Example:user@example.com" {
+		t.Errorf("Unexpected label: %s", parsed.Path)
+	}
+	if parsed.Query().Get("secret") != rfcSecret {
+		t.Errorf("Expected secret %s, got %s", rfcSecret, parsed.Query().Get("secret"))
+	}
+	if parsed.Query().Get("issuer") != "Example" {
+		t.Errorf("Expected issuer Example, got %s", parsed.Query().Get("issuer"))
+	}
+}
+
+func TestOptions_ClampsPeriod(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	for _, period := range []time.Duration{time.Nanosecond, 500 * time.Millisecond, -time.Minute} {
+		opts := Options{Period: period}
+		if step := Step(now, opts); step != now.Unix() {
+			t.Errorf("Period %v: expected one-second steps, got step %d", period, step)
+		}
+		code, err := GenerateCode(rfcSecret, now, opts)
+		if err != nil {
+			t.Fatalf("Period %v: failed to generate code: %v", period, err)
+		}
+		if _, ok, _ := Validate(rfcSecret, code, now, opts); !ok {
+			t.Errorf("Period %v: expected the generated code to validate", period)
+		}
+		if got := parseQuery(t, KeyURI("Example", "user@example.com", rfcSecret, opts)).Get("period"); got != "1" {
+			t.Errorf("Period %v: expected period 1 in the URI, got %s", period, got)
+		}
+	}
+}
+
+func TestOptions_ClampsDigits(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	for digits, want := range map[int]int{-1: 6, 4: 6, 9: 8, 10: 8, 64: 8} {
+		opts := Options{Digits: digits}
+		code, err := GenerateCode(rfcSecret, now, opts)
+		if err != nil {
+			t.Fatalf("Digits %d: failed to generate code: %v", digits, err)
+		}
+		if len(code) != want {
+			t.Errorf("Digits %d: expected a %d digit code, got %q", digits, want, code)
+		}
+		if _, ok, _ := Validate(rfcSecret, code, now, opts); !ok {
+			t.Errorf("Digits %d: expected the generated code to validate", digits)
+		}
+	}
+
+	// Clamped to 8 digits, the code matches the RFC 6238 vector
+	code, _ := GenerateCode(rfcSecret, now, Options{Digits: 10})
+	if code != "89005924" {
+		t.Errorf("Expected the 8 digit RFC code, got %s", code)
+	}
+}
+
+func parseQuery(t *testing.T, uri string) url.Values {
+	t.Helper()
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		t.Fatalf("Failed to parse URI: %v", err)
+	}
+	return parsed.Query()
+}
@@ -0,0 +1,128 @@
+// Package useragent extracts browser, operating system and device class from
+// User-Agent strings. It recognises the mainstream browsers and platforms only;
+// anything else is reported with empty names and DeviceTypeUnknown.
+package useragent
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Device classes, matching the values stored in sr_auth.devices.device_type
+const (
+	DeviceTypeDesktop = "desktop"
+	DeviceTypeMobile  = "mobile"
+	DeviceTypeTablet  = "tablet"
+	DeviceTypeUnknown = "unknown"
+)
+
+// Info is the parsed form of a User-Agent string
+type Info struct {
+	Browser        string
+	BrowserVersion string
+	OS             string
+	OSVersion      string
+	DeviceType     string
+}
+
+// Name returns a short human readable label such as "Chrome on macOS"
+func (i Info) Name() string {
+	switch {
+	case i.Browser != "" && i.OS != "":
+		return i.Browser + " on " + i.OS
+	case i.Browser != "":
+		return i.Browser
+	case i.OS != "":
+		return i.OS
+	default:
+		return "Unknown device"
+	}
+}
+
+type browserRule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// Order matters: most Chromium derivatives also advertise Chrome and Safari
+var browserRules = []browserRule{
+	{"Edge", regexp.MustCompile(`(?:Edg|Edge|EdgA|EdgiOS)/([\d.]+)`)},
+	{"Opera", regexp.MustCompile(`(?:OPR|Opera)/([\d.]+)`)},
+	{"Samsung Internet", regexp.MustCompile(`SamsungBrowser/([\d.]+)`)},
+	{"Firefox", regexp.MustCompile(`(?:Firefox|FxiOS)/([\d.]+)`)},
+	{"Chrome", regexp.MustCompile(`(?:Chrome|CriOS)/([\d.]+)`)},
+	{"Safari", regexp.MustCompile(`Version/([\d.]+).*Safari/`)},
+}
+
+var (
+	windowsPattern = regexp.MustCompile(`Windows NT ([\d.]+)`)
+	iosPattern     = regexp.MustCompile(`(?:iPhone OS|CPU OS) ([\d_]+)`)
+	androidPattern = regexp.MustCompile(`Android ([\d.]+)`)
+	macPattern     = regexp.MustCompile(`Mac OS X ([\d_.]+)`)
+)
+
+// Windows NT kernel versions to marketing names
+var windowsVersions = map[string]string{
+	"10.0": "10",
+	"6.3":  "8.1",
+	"6.2":  "8",
+	"6.1":  "7",
+}
+
+// Parse extracts what it can from a User-Agent string
+func Parse(ua string) Info {
+	info := Info{DeviceType: DeviceTypeUnknown}
+	if strings.TrimSpace(ua) == "" {
+		return info
+	}
+
+	for _, rule := range browserRules {
+		if match := rule.pattern.FindStringSubmatch(ua); match != nil {
+			info.Browser = rule.name
+			info.BrowserVersion = match[1]
+			break
+		}
+	}
+
+	isIPad := strings.Contains(ua, "iPad")
+	isIPhone := strings.Contains(ua, "iPhone") || strings.Contains(ua, "iPod")
+
+	switch {
+	case isIPad || isIPhone:
+		info.OS = "iOS"
+		if match := iosPattern.FindStringSubmatch(ua); match != nil {
+			info.OSVersion = strings.ReplaceAll(match[1], "_", ".")
+		}
+	case strings.Contains(ua, "Android"):
+		info.OS = "Android"
+		if match := androidPattern.FindStringSubmatch(ua); match != nil {
+			info.OSVersion = match[1]
+		}
+	case strings.Contains(ua, "Windows"):
+		info.OS = "Windows"
+		if match := windowsPattern.FindStringSubmatch(ua); match != nil {
+			info.OSVersion = windowsVersions[match[1]]
+		}
+	case strings.Contains(ua, "CrOS"):
+		info.OS = "ChromeOS"
+	case strings.Contains(ua, "Mac OS X"):
+		info.OS = "macOS"
+		if match := macPattern.FindStringSubmatch(ua); match != nil {
+			info.OSVersion = strings.ReplaceAll(match[1], "_", ".")
+		}
+	case strings.Contains(ua, "Linux"):
+		info.OS = "Linux"
+	}
+
+	switch {
+	case isIPad, strings.Contains(ua, "Tablet"),
+		info.OS == "Android" && !strings.Contains(ua, "Mobile"):
+		info.DeviceType = DeviceTypeTablet
+	case isIPhone, strings.Contains(ua, "Mobi"):
+		info.DeviceType = DeviceTypeMobile
+	case info.OS == "Windows", info.OS == "macOS", info.OS == "Linux", info.OS == "ChromeOS":
+		info.DeviceType = DeviceTypeDesktop
+	}
+
+	return info
+}
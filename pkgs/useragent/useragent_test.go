@@ -0,0 +1,69 @@
+package useragent
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name string
+		ua   string
+		want Info
+	}{
+		{
+			name: "chrome on macos",
+			ua:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			want: Info{Browser: "Chrome", BrowserVersion: "120.0.0.0", OS: "macOS", OSVersion: "10.15.7", DeviceType: DeviceTypeDesktop},
+		},
+		{
+			name: "edge on windows",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91",
+			want: Info{Browser: "Edge", BrowserVersion: "120.0.2210.91", OS: "Windows", OSVersion: "10", DeviceType: DeviceTypeDesktop},
+		},
+		{
+			name: "firefox on linux",
+			ua:   "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			want: Info{Browser: "Firefox", BrowserVersion: "121.0", OS: "Linux", DeviceType: DeviceTypeDesktop},
+		},
+		{
+			name: "safari on iphone",
+			ua:   "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			want: Info{Browser: "Safari", BrowserVersion: "17.2", OS: "iOS", OSVersion: "17.2", DeviceType: DeviceTypeMobile},
+		},
+		{
+			name: "safari on ipad",
+			ua:   "Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1",
+			want: Info{Browser: "Safari", BrowserVersion: "16.6", OS: "iOS", OSVersion: "16.6", DeviceType: DeviceTypeTablet},
+		},
+		{
+			name: "chrome on android phone",
+			ua:   "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36",
+			want: Info{Browser: "Chrome", BrowserVersion: "120.0.6099.144", OS: "Android", OSVersion: "14", DeviceType: DeviceTypeMobile},
+		},
+		{
+			name: "samsung browser on android tablet",
+			ua:   "Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Safari/537.36",
+			want: Info{Browser: "Samsung Internet", BrowserVersion: "23.0", OS: "Android", OSVersion: "13", DeviceType: DeviceTypeTablet},
+		},
+		{
+			name: "unrecognised",
+			ua:   "curl/8.4.0",
+			want: Info{DeviceType: DeviceTypeUnknown},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Parse(tc.ua); got != tc.want {
+				t.Errorf("Parse() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestInfoName(t *testing.T) {
+	if got := (Info{Browser: "Chrome", OS: "macOS"}).Name(); got != "Chrome on macOS" {
+		t.Errorf("Expected \"Chrome on macOS\", got %q", got)
+	}
+	if got := (Info{}).Name(); got != "Unknown device" {
+		t.Errorf("Expected \"Unknown device\", got %q", got)
+	}
+}
@@ -0,0 +1,338 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Client data types defined by the WebAuthn specification
+const (
+	ClientDataTypeCreate = "webauthn.create"
+	ClientDataTypeGet    = "webauthn.get"
+)
+
+// Authenticator data flags
+const (
+	FlagUserPresent    byte = 0x01
+	FlagUserVerified   byte = 0x04
+	FlagBackupEligible byte = 0x08
+	FlagBackupState    byte = 0x10
+	FlagAttestedData   byte = 0x40
+	FlagExtensionData  byte = 0x80
+)
+
+// ChallengeSize is the number of random bytes in a generated challenge
+const ChallengeSize = 32
+
+// Verification errors
+var (
+	ErrInvalidSignature     = errors.New("webauthn: signature verification failed")
+	ErrChallengeMismatch    = errors.New("webauthn: challenge mismatch")
+	ErrOriginMismatch       = errors.New("webauthn: origin not allowed")
+	ErrTypeMismatch         = errors.New("webauthn: unexpected client data type")
+	ErrRPIDMismatch         = errors.New("webauthn: relying party ID hash mismatch")
+	ErrUserNotPresent       = errors.New("webauthn: user presence flag not set")
+	ErrUserNotVerified      = errors.New("webauthn: user verification required")
+	ErrMissingCredential    = errors.New("webauthn: attested credential data missing")
+	ErrUnsupportedFormat    = errors.New("webauthn: unsupported attestation format")
+	ErrInvalidAttestation   = errors.New("webauthn: invalid attestation statement")
+	ErrMalformedAuthData    = errors.New("webauthn: malformed authenticator data")
+	ErrMalformedAttestation = errors.New("webauthn: malformed attestation object")
+)
+
+// base64url is the encoding used for challenges and credential IDs on the wire
+var base64url = base64.RawURLEncoding
+
+// Config identifies the relying party
+type Config struct {
+	// RPID is the relying party identifier, normally the registrable domain
+	RPID string
+	// RPName is shown to the user by the authenticator
+	RPName string
+	// Origins lists the exact origins (scheme://host[:port]) allowed in client data
+	Origins []string
+}
+
+// RelyingParty verifies registration and assertion ceremonies
+type RelyingParty struct {
+	cfg      Config
+	rpIDHash [32]byte
+}
+
+// NewRelyingParty creates a relying party verifier
+func NewRelyingParty(cfg Config) (*RelyingParty, error) {
+	if cfg.RPID == "" {
+		return nil, errors.New("webauthn: RPID is required")
+	}
+	if len(cfg.Origins) == 0 {
+		return nil, errors.New("webauthn: at least one origin is required")
+	}
+	return &RelyingParty{cfg: cfg, rpIDHash: sha256.Sum256([]byte(cfg.RPID))}, nil
+}
+
+// ID returns the relying party identifier
+func (rp *RelyingParty) ID() string {
+	return rp.cfg.RPID
+}
+
+// Name returns the relying party display name
+func (rp *RelyingParty) Name() string {
+	return rp.cfg.RPName
+}
+
+// GenerateChallenge returns a new random challenge
+func GenerateChallenge() ([]byte, error) {
+	challenge := make([]byte, ChallengeSize)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, fmt.Errorf("webauthn: failed to generate challenge: %w", err)
+	}
+	return challenge, nil
+}
+
+// EncodeBase64URL encodes binary values the way browsers expect them
+func EncodeBase64URL(value []byte) string {
+	return base64url.EncodeToString(value)
+}
+
+// DecodeBase64URL decodes an unpadded base64url value
+func DecodeBase64URL(value string) ([]byte, error) {
+	return base64url.DecodeString(value)
+}
+
+// CollectedClientData is the parsed clientDataJSON
+type CollectedClientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin,omitempty"`
+}
+
+// AuthenticatorData is the parsed authenticatorData structure
+type AuthenticatorData struct {
+	RPIDHash            []byte
+	Flags               byte
+	SignCount           uint32
+	AAGUID              []byte
+	CredentialID        []byte
+	CredentialPublicKey []byte
+}
+
+// HasFlag reports whether flag is set
+func (a *AuthenticatorData) HasFlag(flag byte) bool {
+	return a.Flags&flag != 0
+}
+
+// RegistrationResponse is the AuthenticatorAttestationResponse sent by the client
+type RegistrationResponse struct {
+	ClientDataJSON    []byte
+	AttestationObject []byte
+}
+
+// Credential is a verified new credential ready to be stored
+type Credential struct {
+	ID                []byte
+	PublicKey         []byte
+	Algorithm         int64
+	SignCount         uint32
+	AAGUID            []byte
+	AttestationFormat string
+	AttestationType   string
+	UserVerified      bool
+	BackupEligible    bool
+	BackupState       bool
+}
+
+// AssertionResponse is the AuthenticatorAssertionResponse sent by the client
+type AssertionResponse struct {
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+	UserHandle        []byte
+}
+
+// AssertionResult is the outcome of a verified assertion
+type AssertionResult struct {
+	SignCount    uint32
+	UserVerified bool
+	BackupState  bool
+	// CloneWarning is set when the authenticator's signature counter did not
+	// increase, which may indicate a cloned authenticator
+	CloneWarning bool
+}
+
+// VerifyRegistration validates a registration ceremony against the expected challenge
+func (rp *RelyingParty) VerifyRegistration(challenge []byte, resp RegistrationResponse, requireUserVerification bool) (*Credential, error) {
+	if err := rp.verifyClientData(resp.ClientDataJSON, ClientDataTypeCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	attestation, err := parseAttestationObject(resp.AttestationObject)
+	if err != nil {
+		return nil, err
+	}
+
+	authData, err := ParseAuthenticatorData(attestation.authData)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.verifyAuthenticatorData(authData, requireUserVerification); err != nil {
+		return nil, err
+	}
+	if !authData.HasFlag(FlagAttestedData) || len(authData.CredentialID) == 0 {
+		return nil, ErrMissingCredential
+	}
+
+	publicKey, err := ParsePublicKey(authData.CredentialPublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(resp.ClientDataJSON)
+	attestationType, err := verifyAttestationStatement(attestation, authData, publicKey, clientDataHash[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		ID:                authData.CredentialID,
+		PublicKey:         authData.CredentialPublicKey,
+		Algorithm:         publicKey.Algorithm,
+		SignCount:         authData.SignCount,
+		AAGUID:            authData.AAGUID,
+		AttestationFormat: attestation.format,
+		AttestationType:   attestationType,
+		UserVerified:      authData.HasFlag(FlagUserVerified),
+		BackupEligible:    authData.HasFlag(FlagBackupEligible),
+		BackupState:       authData.HasFlag(FlagBackupState),
+	}, nil
+}
+
+// VerifyAssertion validates an authentication ceremony for a stored credential
+func (rp *RelyingParty) VerifyAssertion(challenge []byte, resp AssertionResponse, storedPublicKey []byte, storedSignCount uint32, requireUserVerification bool) (*AssertionResult, error) {
+	if err := rp.verifyClientData(resp.ClientDataJSON, ClientDataTypeGet, challenge); err != nil {
+		return nil, err
+	}
+
+	authData, err := ParseAuthenticatorData(resp.AuthenticatorData)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.verifyAuthenticatorData(authData, requireUserVerification); err != nil {
+		return nil, err
+	}
+
+	publicKey, err := ParsePublicKey(storedPublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(resp.ClientDataJSON)
+	signed := append(append([]byte{}, resp.AuthenticatorData...), clientDataHash[:]...)
+	if err := publicKey.Verify(signed, resp.Signature); err != nil {
+		return nil, err
+	}
+
+	result := &AssertionResult{
+		SignCount:    authData.SignCount,
+		UserVerified: authData.HasFlag(FlagUserVerified),
+		BackupState:  authData.HasFlag(FlagBackupState),
+	}
+	if (authData.SignCount != 0 || storedSignCount != 0) && authData.SignCount <= storedSignCount {
+		result.CloneWarning = true
+	}
+
+	return result, nil
+}
+
+// ParseAuthenticatorData decodes the binary authenticatorData structure
+func ParseAuthenticatorData(data []byte) (*AuthenticatorData, error) {
+	if len(data) < 37 {
+		return nil, ErrMalformedAuthData
+	}
+
+	authData := &AuthenticatorData{
+		RPIDHash:  data[:32],
+		Flags:     data[32],
+		SignCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	rest := data[37:]
+
+	if authData.HasFlag(FlagAttestedData) {
+		if len(rest) < 18 {
+			return nil, ErrMalformedAuthData
+		}
+		authData.AAGUID = rest[:16]
+		idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if idLength == 0 || idLength > 1023 || len(rest) < idLength {
+			return nil, ErrMalformedAuthData
+		}
+		authData.CredentialID = rest[:idLength]
+		rest = rest[idLength:]
+
+		_, n, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformedAuthData, err)
+		}
+		authData.CredentialPublicKey = rest[:n]
+		rest = rest[n:]
+	}
+
+	if authData.HasFlag(FlagExtensionData) {
+		_, n, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformedAuthData, err)
+		}
+		rest = rest[n:]
+	}
+
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%w: trailing bytes", ErrMalformedAuthData)
+	}
+
+	return authData, nil
+}
+
+func (rp *RelyingParty) verifyClientData(clientDataJSON []byte, expectedType string, challenge []byte) error {
+	var clientData CollectedClientData
+	if err := json.Unmarshal(clientDataJSON, &clientData); err != nil {
+		return fmt.Errorf("webauthn: invalid client data: %w", err)
+	}
+
+	if clientData.Type != expectedType {
+		return ErrTypeMismatch
+	}
+
+	received, err := DecodeBase64URL(clientData.Challenge)
+	if err != nil || len(challenge) == 0 || subtle.ConstantTimeCompare(received, challenge) != 1 {
+		return ErrChallengeMismatch
+	}
+
+	for _, origin := range rp.cfg.Origins {
+		if clientData.Origin == origin {
+			return nil
+		}
+	}
+	return ErrOriginMismatch
+}
+
+func (rp *RelyingParty) verifyAuthenticatorData(authData *AuthenticatorData, requireUserVerification bool) error {
+	if !bytes.Equal(authData.RPIDHash, rp.rpIDHash[:]) {
+		return ErrRPIDMismatch
+	}
+	if !authData.HasFlag(FlagUserPresent) {
+		return ErrUserNotPresent
+	}
+	if requireUserVerification && !authData.HasFlag(FlagUserVerified) {
+		return ErrUserNotVerified
+	}
+	return nil
+}
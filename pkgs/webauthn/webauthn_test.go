@@ -0,0 +1,165 @@
+package webauthn
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/your-project/pkgs/webauthn/webauthntest"
+)
+
+const (
+	testRPID   = "example.com"
+	testOrigin = "https://example.com"
+)
+
+func newTestRelyingParty(t *testing.T) *RelyingParty {
+	rp, err := NewRelyingParty(Config{RPID: testRPID, RPName: "Example", Origins: []string{testOrigin}})
+	if err != nil {
+		t.Fatalf("Failed to create relying party: %v", err)
+	}
+	return rp
+}
+
+func registerCredential(t *testing.T, rp *RelyingParty, authenticator *webauthntest.Authenticator, format string) *Credential {
+	challenge, err := GenerateChallenge()
+	if err != nil {
+		t.Fatalf("Failed to generate challenge: %v", err)
+	}
+
+	reg, err := authenticator.Register(testRPID, testOrigin, challenge, format)
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	credential, err := rp.VerifyRegistration(challenge, RegistrationResponse{
+		ClientDataJSON:    reg.ClientDataJSON,
+		AttestationObject: reg.AttestationObject,
+	}, true)
+	if err != nil {
+		t.Fatalf("Failed to verify %s registration: %v", format, err)
+	}
+	return credential
+}
+
+func TestVerifyRegistration_Formats(t *testing.T) {
+	rp := newTestRelyingParty(t)
+
+	cases := map[string]string{
+		webauthntest.FormatNone:       AttestationTypeNone,
+		webauthntest.FormatPackedSelf: AttestationTypeSelf,
+		webauthntest.FormatPackedX5C:  AttestationTypeBasic,
+	}
+
+	for format, expectedType := range cases {
+		authenticator, err := webauthntest.New()
+		if err != nil {
+			t.Fatalf("Failed to create authenticator: %v", err)
+		}
+
+		credential := registerCredential(t, rp, authenticator, format)
+
+		if credential.AttestationType != expectedType {
+			t.Errorf("Expected attestation type %s for %s, got %s", expectedType, format, credential.AttestationType)
+		}
+		if !bytes.Equal(credential.ID, authenticator.CredentialID) {
+			t.Errorf("Credential ID mismatch for %s", format)
+		}
+		if !bytes.Equal(credential.PublicKey, authenticator.PublicKeyCOSE()) {
+			t.Errorf("Public key mismatch for %s", format)
+		}
+		if credential.Algorithm != AlgES256 {
+			t.Errorf("Expected ES256, got %d", credential.Algorithm)
+		}
+	}
+}
+
+func TestVerifyRegistration_RejectsWrongChallengeAndOrigin(t *testing.T) {
+	rp := newTestRelyingParty(t)
+	authenticator, _ := webauthntest.New()
+
+	challenge, _ := GenerateChallenge()
+	other, _ := GenerateChallenge()
+
+	reg, _ := authenticator.Register(testRPID, testOrigin, challenge, webauthntest.FormatNone)
+	_, err := rp.VerifyRegistration(other, RegistrationResponse{ClientDataJSON: reg.ClientDataJSON, AttestationObject: reg.AttestationObject}, true)
+	if !errors.Is(err, ErrChallengeMismatch) {
+		t.Errorf("Expected ErrChallengeMismatch, got %v", err)
+	}
+
+	reg, _ = authenticator.Register(testRPID, "https://evil.example", challenge, webauthntest.FormatNone)
+	_, err = rp.VerifyRegistration(challenge, RegistrationResponse{ClientDataJSON: reg.ClientDataJSON, AttestationObject: reg.AttestationObject}, true)
+	if !errors.Is(err, ErrOriginMismatch) {
+		t.Errorf("Expected ErrOriginMismatch, got %v", err)
+	}
+
+	reg, _ = authenticator.Register("evil.example", testOrigin, challenge, webauthntest.FormatNone)
+	_, err = rp.VerifyRegistration(challenge, RegistrationResponse{ClientDataJSON: reg.ClientDataJSON, AttestationObject: reg.AttestationObject}, true)
+	if !errors.Is(err, ErrRPIDMismatch) {
+		t.Errorf("Expected ErrRPIDMismatch, got %v", err)
+	}
+}
+
+func TestVerifyRegistration_RequiresUserVerification(t *testing.T) {
+	rp := newTestRelyingParty(t)
+	authenticator, _ := webauthntest.New()
+	authenticator.UserVerified = false
+
+	challenge, _ := GenerateChallenge()
+	reg, _ := authenticator.Register(testRPID, testOrigin, challenge, webauthntest.FormatPackedSelf)
+
+	_, err := rp.VerifyRegistration(challenge, RegistrationResponse{ClientDataJSON: reg.ClientDataJSON, AttestationObject: reg.AttestationObject}, true)
+	if !errors.Is(err, ErrUserNotVerified) {
+		t.Errorf("Expected ErrUserNotVerified, got %v", err)
+	}
+}
+
+func TestVerifyAssertion_SignCountAndCloneDetection(t *testing.T) {
+	rp := newTestRelyingParty(t)
+	authenticator, _ := webauthntest.New()
+	credential := registerCredential(t, rp, authenticator, webauthntest.FormatNone)
+
+	challenge, _ := GenerateChallenge()
+	assertion, err := authenticator.Assert(testRPID, testOrigin, challenge, []byte("user-handle"))
+	if err != nil {
+		t.Fatalf("Failed to assert: %v", err)
+	}
+
+	response := AssertionResponse{
+		CredentialID:      assertion.CredentialID,
+		ClientDataJSON:    assertion.ClientDataJSON,
+		AuthenticatorData: assertion.AuthenticatorData,
+		Signature:         assertion.Signature,
+		UserHandle:        assertion.UserHandle,
+	}
+
+	result, err := rp.VerifyAssertion(challenge, response, credential.PublicKey, credential.SignCount, true)
+	if err != nil {
+		t.Fatalf("Failed to verify assertion: %v", err)
+	}
+	if result.SignCount != 1 || result.CloneWarning {
+		t.Errorf("Expected sign count 1 without clone warning, got %d/%v", result.SignCount, result.CloneWarning)
+	}
+
+	// Replaying the same counter value against the updated stored count signals a clone
+	result, err = rp.VerifyAssertion(challenge, response, credential.PublicKey, result.SignCount, true)
+	if err != nil {
+		t.Fatalf("Failed to verify assertion: %v", err)
+	}
+	if !result.CloneWarning {
+		t.Error("Expected clone warning when sign count does not increase")
+	}
+
+	response.Signature[len(response.Signature)-1] ^= 0xff
+	if _, err := rp.VerifyAssertion(challenge, response, credential.PublicKey, 0, true); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected ErrInvalidSignature, got %v", err)
+	}
+}
+
+func TestDecodeCBOR_RejectsTruncatedInput(t *testing.T) {
+	// Map of one entry whose byte string claims 16 bytes but has 1
+	data := []byte{0xa1, 0x01, 0x50, 0x00}
+	if _, _, err := decodeCBOR(data); err == nil {
+		t.Error("Expected error for truncated CBOR")
+	}
+}
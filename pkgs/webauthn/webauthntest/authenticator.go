@@ -0,0 +1,237 @@
+// Package webauthntest provides a software authenticator for exercising
+// WebAuthn registration and assertion ceremonies in tests.
+package webauthntest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Attestation formats the software authenticator can produce
+const (
+	FormatNone       = "none"
+	FormatPackedSelf = "packed-self"
+	FormatPackedX5C  = "packed-x5c"
+)
+
+// Authenticator is an in-memory ES256 authenticator holding a single credential
+type Authenticator struct {
+	AAGUID       []byte
+	CredentialID []byte
+	SignCount    uint32
+	// UserVerified controls whether the UV flag is set on responses
+	UserVerified bool
+
+	key *ecdsa.PrivateKey
+}
+
+// Registration is the client output of a registration ceremony
+type Registration struct {
+	ClientDataJSON    []byte
+	AttestationObject []byte
+}
+
+// Assertion is the client output of an authentication ceremony
+type Assertion struct {
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+	UserHandle        []byte
+}
+
+// New creates an authenticator with a fresh P-256 key and random credential ID
+func New() (*Authenticator, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	credentialID := make([]byte, 32)
+	aaguid := make([]byte, 16)
+	if _, err := rand.Read(credentialID); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(aaguid); err != nil {
+		return nil, err
+	}
+
+	return &Authenticator{
+		AAGUID:       aaguid,
+		CredentialID: credentialID,
+		UserVerified: true,
+		key:          key,
+	}, nil
+}
+
+// Register produces a registration response for rpID/origin bound to challenge
+func (a *Authenticator) Register(rpID, origin string, challenge []byte, format string) (*Registration, error) {
+	clientData := clientDataJSON("webauthn.create", origin, challenge)
+
+	authData := a.authenticatorData(rpID, true)
+	clientDataHash := sha256.Sum256(clientData)
+	signed := append(append([]byte{}, authData...), clientDataHash[:]...)
+
+	var (
+		fmtName string
+		attStmt = map[interface{}]interface{}{}
+	)
+
+	switch format {
+	case FormatNone:
+		fmtName = "none"
+	case FormatPackedSelf:
+		fmtName = "packed"
+		sig, err := a.sign(signed)
+		if err != nil {
+			return nil, err
+		}
+		attStmt["alg"] = int64(-7)
+		attStmt["sig"] = sig
+	case FormatPackedX5C:
+		fmtName = "packed"
+		certKey, certDER, err := a.attestationCertificate()
+		if err != nil {
+			return nil, err
+		}
+		digest := sha256.Sum256(signed)
+		sig, err := ecdsa.SignASN1(rand.Reader, certKey, digest[:])
+		if err != nil {
+			return nil, err
+		}
+		attStmt["alg"] = int64(-7)
+		attStmt["sig"] = sig
+		attStmt["x5c"] = []interface{}{certDER}
+	default:
+		return nil, fmt.Errorf("webauthntest: unknown format %q", format)
+	}
+
+	attestation := encodeCBOR(map[interface{}]interface{}{
+		"fmt":      fmtName,
+		"attStmt":  attStmt,
+		"authData": authData,
+	})
+
+	return &Registration{ClientDataJSON: clientData, AttestationObject: attestation}, nil
+}
+
+// Assert produces an assertion for rpID/origin bound to challenge and increments the counter
+func (a *Authenticator) Assert(rpID, origin string, challenge, userHandle []byte) (*Assertion, error) {
+	a.SignCount++
+
+	clientData := clientDataJSON("webauthn.get", origin, challenge)
+	authData := a.authenticatorData(rpID, false)
+	clientDataHash := sha256.Sum256(clientData)
+
+	sig, err := a.sign(append(append([]byte{}, authData...), clientDataHash[:]...))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Assertion{
+		CredentialID:      a.CredentialID,
+		ClientDataJSON:    clientData,
+		AuthenticatorData: authData,
+		Signature:         sig,
+		UserHandle:        userHandle,
+	}, nil
+}
+
+// PublicKeyCOSE returns the credential public key as a COSE_Key
+func (a *Authenticator) PublicKeyCOSE() []byte {
+	x := a.key.PublicKey.X.FillBytes(make([]byte, 32))
+	y := a.key.PublicKey.Y.FillBytes(make([]byte, 32))
+	return encodeCBOR(map[interface{}]interface{}{
+		int64(1):  int64(2),
+		int64(3):  int64(-7),
+		int64(-1): int64(1),
+		int64(-2): x,
+		int64(-3): y,
+	})
+}
+
+func (a *Authenticator) authenticatorData(rpID string, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+
+	flags := byte(0x01)
+	if a.UserVerified {
+		flags |= 0x04
+	}
+	if attested {
+		flags |= 0x40
+	}
+
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.SignCount)
+
+	if attested {
+		data = append(data, a.AAGUID...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.CredentialID)))
+		data = append(data, a.CredentialID...)
+		data = append(data, a.PublicKeyCOSE()...)
+	}
+
+	return data
+}
+
+func (a *Authenticator) sign(data []byte) ([]byte, error) {
+	digest := sha256.Sum256(data)
+	return ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+}
+
+// attestationCertificate creates a self-signed packed attestation certificate carrying the AAGUID
+func (a *Authenticator) attestationCertificate() (*ecdsa.PrivateKey, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	aaguidExt, err := asn1.Marshal(a.AAGUID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			Country:            []string{"US"},
+			Organization:       []string{"Software Authenticator"},
+			OrganizationalUnit: []string{"Authenticator Attestation"},
+			CommonName:         "webauthntest",
+		},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  false,
+		ExtraExtensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 45724, 1, 1, 4}, Value: aaguidExt},
+		},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, der, nil
+}
+
+func clientDataJSON(ceremony, origin string, challenge []byte) []byte {
+	data, _ := json.Marshal(map[string]interface{}{
+		"type":      ceremony,
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    origin,
+	})
+	return data
+}
@@ -0,0 +1,86 @@
+package webauthntest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+)
+
+// encodeCBOR encodes the value types produced by the webauthn decoder using
+// CTAP2 canonical ordering for map keys
+func encodeCBOR(value interface{}) []byte {
+	var buf bytes.Buffer
+	writeCBOR(&buf, value)
+	return buf.Bytes()
+}
+
+func writeCBOR(buf *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case int64:
+		if v >= 0 {
+			writeHeader(buf, 0, uint64(v))
+		} else {
+			writeHeader(buf, 1, uint64(-1-v))
+		}
+	case []byte:
+		writeHeader(buf, 2, uint64(len(v)))
+		buf.Write(v)
+	case string:
+		writeHeader(buf, 3, uint64(len(v)))
+		buf.WriteString(v)
+	case []interface{}:
+		writeHeader(buf, 4, uint64(len(v)))
+		for _, item := range v {
+			writeCBOR(buf, item)
+		}
+	case map[interface{}]interface{}:
+		type entry struct {
+			key   []byte
+			value interface{}
+		}
+		entries := make([]entry, 0, len(v))
+		for key, item := range v {
+			entries = append(entries, entry{key: encodeCBOR(key), value: item})
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			if len(entries[i].key) != len(entries[j].key) {
+				return len(entries[i].key) < len(entries[j].key)
+			}
+			return bytes.Compare(entries[i].key, entries[j].key) < 0
+		})
+		writeHeader(buf, 5, uint64(len(entries)))
+		for _, e := range entries {
+			buf.Write(e.key)
+			writeCBOR(buf, e.value)
+		}
+	case bool:
+		if v {
+			buf.WriteByte(0xf5)
+		} else {
+			buf.WriteByte(0xf4)
+		}
+	case nil:
+		buf.WriteByte(0xf6)
+	default:
+		panic("webauthntest: unsupported CBOR value")
+	}
+}
+
+func writeHeader(buf *bytes.Buffer, major byte, arg uint64) {
+	switch {
+	case arg < 24:
+		buf.WriteByte(major<<5 | byte(arg))
+	case arg <= 0xff:
+		buf.WriteByte(major<<5 | 24)
+		buf.WriteByte(byte(arg))
+	case arg <= 0xffff:
+		buf.WriteByte(major<<5 | 25)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(arg)))
+	case arg <= 0xffffffff:
+		buf.WriteByte(major<<5 | 26)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(arg)))
+	default:
+		buf.WriteByte(major<<5 | 27)
+		buf.Write(binary.BigEndian.AppendUint64(nil, arg))
+	}
+}
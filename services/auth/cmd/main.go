@@ -2,9 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
+	"expvar"
 	"fmt"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -13,6 +16,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 
+	"github.com/your-project/pkgs/envelope"
 	"github.com/your-project/services/auth/internal/business"
 	"github.com/your-project/services/auth/internal/config"
 	"github.com/your-project/services/auth/internal/handlers"
@@ -26,6 +30,10 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	if cfg.JWTSecret == "" {
+		log.Fatalf("JWT_SECRET environment variable is required")
+	}
+
 	// Initialize database connection
 	db, err := config.NewDatabaseConnection(cfg)
 	if err != nil {
@@ -33,11 +41,35 @@ func main() {
 	}
 	defer db.Close()
 
+	// Initialize encryption at rest for provider secrets and OAuth tokens
+	keys, err := config.NewKeyProvider(cfg)
+	if err != nil {
+		log.Fatalf("Failed to load encryption keys: %v", err)
+	}
+
 	// Initialize repository layer
-	authRepo := repository.NewAuthRepository(db)
+	authRepo := repository.NewAuthRepository(db, repository.WithEncryptor(envelope.New(keys)))
+
+	// Initialize token signing keys for the OAuth authorization server
+	signingKeys, err := config.NewSigningKeys(cfg)
+	if err != nil {
+		log.Fatalf("Failed to load OAuth signing keys: %v", err)
+	}
+	if len(cfg.OAuthSigningKeyFiles) == 0 {
+		log.Println("OAUTH_SIGNING_KEY_FILES not set; using a throwaway signing key")
+	}
 
 	// Initialize business logic layer
-	authBusiness := business.NewAuthBusiness(authRepo)
+	authBusiness := business.NewAuthBusiness(authRepo, cfg, business.WithSigningKeys(signingKeys))
+
+	// Start background jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go runLoginLogArchiver(jobsCtx, authBusiness, cfg.LoginLogArchiveInterval)
+	go runProviderCacheRefresher(jobsCtx, authBusiness, cfg.ProviderCacheRefreshInterval)
+	go runProviderTokenRefresher(jobsCtx, authBusiness, cfg.OAuthTokenRefreshInterval)
+	go runAccountPurger(jobsCtx, authBusiness, cfg.AccountPurgeInterval)
+	go runMaintenance(jobsCtx, authBusiness, cfg.MaintenanceInterval)
 
 	// Initialize gRPC handlers
 	_ = handlers.NewAuthHandler(authBusiness)
@@ -66,18 +98,185 @@ func main() {
 		}
 	}()
 
+	// Start the OAuth / OpenID Connect HTTP server
+	httpServer := &http.Server{
+		Addr:              fmt.Sprintf(":%s", cfg.HTTPPort),
+		Handler:           handlers.NewOAuthServer(authBusiness),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		log.Printf("Starting OAuth server on port %s", cfg.HTTPPort)
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to serve HTTP: %v", err)
+		}
+	}()
+
+	// Start the metrics server (expvar counters, including maintenance runs)
+	var metricsServer *http.Server
+	if cfg.MetricsPort != "" {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/debug/vars", expvar.Handler())
+		metricsServer = &http.Server{
+			Addr:              fmt.Sprintf(":%s", cfg.MetricsPort),
+			Handler:           metricsMux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			log.Printf("Starting metrics server on port %s", cfg.MetricsPort)
+			if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatalf("Failed to serve metrics: %v", err)
+			}
+		}()
+	}
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	log.Println("Shutting down auth service...")
+	stopJobs()
 
 	// Graceful shutdown
-	_, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("HTTP server shutdown: %v", err)
+	}
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Metrics server shutdown: %v", err)
+		}
+	}
 	grpcServer.GracefulStop()
 
 	log.Println("Auth service stopped gracefully")
 }
+
+// runLoginLogArchiver periodically moves audit entries past retention into the archive
+func runLoginLogArchiver(ctx context.Context, authBusiness *business.AuthBusiness, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			moved, err := authBusiness.ArchiveLoginEvents(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("Login log archiving failed after %d rows: %v", moved, err)
+			} else if moved > 0 {
+				log.Printf("Archived %d login log entries", moved)
+			}
+		}
+	}
+}
+
+// runProviderCacheRefresher reloads the provider cache when another replica changes sr_auth.providers
+func runProviderCacheRefresher(ctx context.Context, authBusiness *business.AuthBusiness, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := authBusiness.RefreshProviders(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("Provider cache refresh failed: %v", err)
+			} else if reloaded {
+				log.Printf("Reloaded sign-in provider configuration")
+			}
+		}
+	}
+}
+
+// runProviderTokenRefresher keeps upstream access tokens on provider links fresh
+func runProviderTokenRefresher(ctx context.Context, authBusiness *business.AuthBusiness, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := authBusiness.RefreshProviderTokens(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("Provider token refresh failed: %v", err)
+			} else if result != nil && result.Refreshed+result.Revoked+result.Failed > 0 {
+				log.Printf("Provider tokens: %d refreshed, %d revoked, %d failed, %d skipped",
+					result.Refreshed, result.Revoked, result.Failed, result.Skipped)
+			}
+		}
+	}
+}
+
+// runAccountPurger removes the data of deleted accounts once their grace period is over
+func runAccountPurger(ctx context.Context, authBusiness *business.AuthBusiness, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := authBusiness.PurgeDeletedAccounts(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("Account purge failed after %d accounts: %v", purged, err)
+			} else if purged > 0 {
+				log.Printf("Purged %d deleted accounts", purged)
+			}
+		}
+	}
+}
+
+// runMaintenance deletes expired, used and revoked rows once their retention is over
+func runMaintenance(ctx context.Context, authBusiness *business.AuthBusiness, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := authBusiness.RunMaintenance(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("Maintenance failed: %v", err)
+			} else if result != nil && !result.Skipped {
+				var removed int64
+				for _, count := range result.Removed {
+					removed += count
+				}
+				if removed > 0 {
+					log.Printf("Maintenance removed %d rows: %v", removed, result.Removed)
+				}
+			}
+		}
+	}
+}
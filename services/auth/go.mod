@@ -4,14 +4,19 @@ go 1.21
 
 require (
 	github.com/lib/pq v1.10.9
+	github.com/your-project/pkgs v0.0.0
+	golang.org/x/crypto v0.17.0
 	google.golang.org/grpc v1.59.0
 )
 
 require (
+	github.com/golang-jwt/jwt/v5 v5.2.0 // indirect
 	github.com/golang/protobuf v1.5.3 // indirect
 	golang.org/x/net v0.14.0 // indirect
-	golang.org/x/sys v0.11.0 // indirect
-	golang.org/x/text v0.12.0 // indirect
+	golang.org/x/sys v0.15.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20230822172742-b8732ec3820d // indirect
 	google.golang.org/protobuf v1.31.0 // indirect
 )
+
+replace github.com/your-project/pkgs => ../../pkgs
@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"context"
+	"log"
+
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// Event is one entry in the authentication audit trail
+type Event struct {
+	Type       string
+	UserID     *int
+	SessionID  *int
+	IPAddress  string
+	UserAgent  string
+	DeviceInfo map[string]interface{}
+	// FailureReason marks the event as unsuccessful when set
+	FailureReason string
+	Meta          map[string]interface{}
+}
+
+// Writer records audit events to sr_auth.login_logs. Every flow writes through it
+// so that event types and the success flag are applied consistently.
+type Writer struct {
+	repo repository.LoginLogs
+}
+
+// NewWriter creates an audit writer
+func NewWriter(repo repository.LoginLogs) *Writer {
+	return &Writer{repo: repo}
+}
+
+// Record writes an event. Failures are logged rather than returned so that
+// auditing problems never block authentication. Unknown event types are refused.
+func (w *Writer) Record(ctx context.Context, event Event) {
+	if !models.IsLoginEventType(event.Type) {
+		log.Printf("Refusing to record unknown audit event type %q", event.Type)
+		return
+	}
+
+	entry := &models.LoginLog{
+		UserID:        event.UserID,
+		SessionID:     event.SessionID,
+		EventType:     event.Type,
+		IPAddress:     optionalString(event.IPAddress),
+		UserAgent:     optionalString(event.UserAgent),
+		DeviceInfo:    event.DeviceInfo,
+		Success:       event.FailureReason == "",
+		FailureReason: optionalString(event.FailureReason),
+		Meta:          event.Meta,
+	}
+
+	if err := w.repo.CreateLoginLog(ctx, entry); err != nil {
+		log.Printf("Failed to record %s audit event: %v", event.Type, err)
+	}
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
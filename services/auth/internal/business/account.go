@@ -0,0 +1,260 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/your-project/services/auth/internal/config"
+	"github.com/your-project/services/auth/internal/events"
+	"github.com/your-project/services/auth/internal/mailer"
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// Revoke reasons recorded on sessions ended by the account lifecycle
+const (
+	revokeReasonAccountDeactivated = "account_deactivated"
+	revokeReasonAccountDeleted     = "account_deleted"
+)
+
+// maxAccountReasonLength bounds the free-text reason kept in the audit log
+const maxAccountReasonLength = 500
+
+// DeactivateAccountInput suspends an account
+type DeactivateAccountInput struct {
+	UserID string
+	// SessionID is the caller's session; impersonation sessions are refused
+	SessionID string
+	// Reason is kept in the audit log
+	Reason string
+	Client ClientInfo
+}
+
+// DeleteAccountInput deletes an account after a grace period
+type DeleteAccountInput struct {
+	UserID string
+	// SessionID is the caller's session; impersonation sessions are refused
+	SessionID string
+	// CurrentPassword is required for accounts that have a password
+	CurrentPassword string
+	Reason          string
+	Client          ClientInfo
+}
+
+// AccountDeletion describes a deleted account waiting to be purged
+type AccountDeletion struct {
+	// PurgeAfter is the end of the grace period; until then the account can be restored
+	PurgeAfter      time.Time
+	SessionsRevoked int
+}
+
+// DeactivateAccount disables sign-in for the account and signs out every
+// session. Nothing is removed; ReactivateAccount reverses it. It returns how
+// many sessions were revoked.
+func (b *AuthBusiness) DeactivateAccount(ctx context.Context, input DeactivateAccountInput) (int, error) {
+	reason, err := accountReason(input.Reason)
+	if err != nil {
+		return 0, err
+	}
+	user, err := b.getUser(ctx, input.UserID)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := b.requireOwnSession(ctx, user, input.SessionID); err != nil {
+		return 0, err
+	}
+
+	var (
+		updated *models.User
+		revoked int64
+	)
+	err = b.authRepo.WithTx(ctx, func(ctx context.Context, tx repository.Store) error {
+		if updated, err = tx.SetUserActive(ctx, user.ID, false); err != nil {
+			return err
+		}
+		revoked, err = tx.RevokeUserSessions(ctx, user.ID, 0, revokeReasonAccountDeactivated)
+		return err
+	})
+	if errors.Is(err, repository.ErrNotFound) {
+		return 0, ErrUserNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	b.recordLoginEvent(ctx, updated, nil, models.EventAccountDeactivated, input.Client, "",
+		map[string]interface{}{"reason": reason, "sessions_revoked": revoked})
+	b.publishEvent(ctx, events.TypeAccountDeactivated, updated, map[string]interface{}{"reason": reason})
+	return int(revoked), nil
+}
+
+// ReactivateAccount lets a deactivated account sign in again
+func (b *AuthBusiness) ReactivateAccount(ctx context.Context, userID string, client ClientInfo) (*models.User, error) {
+	user, err := b.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := b.authRepo.SetUserActive(ctx, user.ID, true)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	b.recordLoginEvent(ctx, updated, nil, models.EventAccountReactivated, client, "", nil)
+	b.publishEvent(ctx, events.TypeAccountReactivated, updated, nil)
+	return updated, nil
+}
+
+// DeleteAccount soft-deletes the account, signs out every session and emails a
+// restore link valid for AccountDeletionGrace. The account disappears from
+// sign-in immediately; PurgeDeletedAccounts removes its data once the grace
+// period is over.
+func (b *AuthBusiness) DeleteAccount(ctx context.Context, input DeleteAccountInput) (*AccountDeletion, error) {
+	reason, err := accountReason(input.Reason)
+	if err != nil {
+		return nil, err
+	}
+	user, err := b.getUser(ctx, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := b.requireOwnSession(ctx, user, input.SessionID); err != nil {
+		return nil, err
+	}
+	if user.PasswordHash != "" {
+		if input.CurrentPassword == "" {
+			return nil, fmt.Errorf("%w: current password is required", ErrInvalidArgument)
+		}
+		if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.CurrentPassword)) != nil {
+			b.recordLoginEvent(ctx, user, nil, models.EventAccountDeleted, input.Client, "invalid_password", nil)
+			return nil, ErrInvalidCredentials
+		}
+	}
+
+	purgeAfter := b.now().Add(b.cfg.AccountDeletionGrace)
+	var (
+		deleted *models.User
+		revoked int64
+	)
+	err = b.authRepo.WithTx(ctx, func(ctx context.Context, tx repository.Store) error {
+		if deleted, err = tx.SoftDeleteUser(ctx, user.ID, purgeAfter); err != nil {
+			return err
+		}
+		revoked, err = tx.RevokeUserSessions(ctx, user.ID, 0, revokeReasonAccountDeleted)
+		return err
+	})
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.sendRestoreLink(ctx, deleted, purgeAfter, input.Client); err != nil {
+		// The deletion has been committed; support can still restore the account
+		log.Printf("Failed to send account restore link: %v", err)
+	}
+
+	b.recordLoginEvent(ctx, deleted, nil, models.EventAccountDeleted, input.Client, "",
+		map[string]interface{}{"reason": reason, "purge_after": purgeAfter, "sessions_revoked": revoked})
+	b.publishEvent(ctx, events.TypeAccountDeleted, deleted, map[string]interface{}{"reason": reason, "purge_after": purgeAfter})
+	return &AccountDeletion{PurgeAfter: purgeAfter, SessionsRevoked: int(revoked)}, nil
+}
+
+// RestoreAccount undoes DeleteAccount with the token from the restore link. The
+// user signs in again afterwards; sessions revoked by the deletion stay revoked.
+func (b *AuthBusiness) RestoreAccount(ctx context.Context, token string, client ClientInfo) (*models.User, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, fmt.Errorf("%w: token is required", ErrInvalidArgument)
+	}
+
+	actionToken, err := b.authRepo.ConsumeActionToken(ctx, hashToken(token), models.TokenPurposeAccountRestore)
+	if errors.Is(err, repository.ErrNotFound) {
+		b.recordLoginEvent(ctx, nil, nil, models.EventAccountRestored, client, "invalid_token", nil)
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := b.authRepo.RestoreUser(ctx, actionToken.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	b.recordLoginEvent(ctx, user, nil, models.EventAccountRestored, client, "", nil)
+	b.publishEvent(ctx, events.TypeAccountRestored, user, nil)
+	return user, nil
+}
+
+// PurgeDeletedAccounts removes or anonymizes (AccountPurgeMode) deleted accounts
+// whose grace period is over, in batches of AccountPurgeBatchSize, and returns
+// how many were purged
+func (b *AuthBusiness) PurgeDeletedAccounts(ctx context.Context) (int64, error) {
+	anonymize := b.cfg.AccountPurgeMode != config.AccountPurgeDelete
+	now := b.now()
+	var total int64
+	for {
+		purged, err := b.authRepo.PurgeDeletedUsers(ctx, now, b.cfg.AccountPurgeBatchSize, anonymize)
+		if err != nil {
+			return total, err
+		}
+		total += int64(len(purged))
+		for _, uuid := range purged {
+			b.publishEvent(ctx, events.TypeAccountPurged, &models.User{UUID: uuid},
+				map[string]interface{}{"mode": b.cfg.AccountPurgeMode})
+		}
+		if len(purged) < b.cfg.AccountPurgeBatchSize {
+			return total, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+	}
+}
+
+func (b *AuthBusiness) sendRestoreLink(ctx context.Context, user *models.User, purgeAfter time.Time, client ClientInfo) error {
+	token, err := generateOpaqueToken(32)
+	if err != nil {
+		return err
+	}
+	if err := b.authRepo.CreateActionToken(ctx, &models.ActionToken{
+		UserID:    user.ID,
+		Purpose:   models.TokenPurposeAccountRestore,
+		TokenHash: hashToken(token),
+		IPAddress: optionalString(client.IPAddress),
+		UserAgent: optionalString(client.UserAgent),
+		ExpiresAt: purgeAfter,
+	}); err != nil {
+		return err
+	}
+
+	return b.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your account has been deleted",
+		Body: fmt.Sprintf("Your account has been deleted and you have been signed out everywhere. Its data will be removed permanently on %s.\n\nChanged your mind? Use the link below before then to restore it:\n\n%s",
+			purgeAfter.UTC().Format("2 January 2006"), tokenLinkURL(b.cfg.AccountRestoreURL, token)),
+	})
+}
+
+// accountReason trims a free-text reason and bounds its length
+func accountReason(reason string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	if len(reason) > maxAccountReasonLength {
+		return "", fmt.Errorf("%w: reason must be at most %d bytes", ErrInvalidArgument, maxAccountReasonLength)
+	}
+	return reason, nil
+}
@@ -0,0 +1,476 @@
+package business
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/your-project/services/auth/internal/audit"
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+const (
+	// apiKeyPrefix starts every key so leaked keys are easy to recognise in code and logs
+	apiKeyPrefix = "sr"
+	// apiKeyLookupBytes is the random public part used to find a key (12 hex characters)
+	apiKeyLookupBytes = 6
+	// apiKeySecretBytes is the random secret part (256 bits)
+	apiKeySecretBytes = 32
+	// apiKeyTouchInterval limits last-used writes for busy keys
+	apiKeyTouchInterval = time.Minute
+	maxAPIKeyAllowedIPs = 50
+
+	revokeReasonAPIKeyRevoked = "revoked"
+)
+
+// APIKeyOwner selects whose keys are managed: a user's personal keys or a
+// service account's machine keys. Exactly one field must be set.
+type APIKeyOwner struct {
+	UserID           string
+	ServiceAccountID string
+}
+
+// APIKeyInput creates an API key
+type APIKeyInput struct {
+	Owner APIKeyOwner
+	// SessionID is the caller's session, required for personal keys;
+	// impersonation sessions are refused
+	SessionID string
+	Name      string
+	// Scopes the key grants; personal keys are limited to API_KEY_USER_SCOPES
+	// (and VERIFICATION_UNVERIFIED_SCOPES while unverified), machine keys to
+	// the service account's scopes
+	Scopes []string
+	// ExpiresIn defaults to API_KEY_DEFAULT_EXPIRY and may not exceed API_KEY_MAX_EXPIRY
+	ExpiresIn time.Duration
+	// AllowedIPs are addresses or CIDR ranges; empty allows any address
+	AllowedIPs []string
+	Client     ClientInfo
+}
+
+// APIKeyToken is the short-lived JWT a gateway gets in exchange for an API key
+type APIKeyToken struct {
+	AccessToken string
+	ExpiresIn   int64
+	Scope       string
+	// Subject is the user UUID, or "client:<client_id>" for machine keys
+	Subject  string
+	APIKeyID string
+}
+
+// apiKeyOwner is an APIKeyOwner resolved to database IDs
+type apiKeyOwner struct {
+	userID   *int
+	clientID *int
+	// user is set for personal keys
+	user *models.User
+	// scopes limits machine keys to the service account's scopes
+	scopes []string
+}
+
+// CreateAPIKey issues a key and returns it with the plaintext, which is only
+// stored as a hash and cannot be shown again
+func (b *AuthBusiness) CreateAPIKey(ctx context.Context, input APIKeyInput) (*models.APIKey, string, error) {
+	if err := validateAPIKeyOwner(input.Owner); err != nil {
+		return nil, "", err
+	}
+	key := &models.APIKey{Name: strings.TrimSpace(input.Name)}
+	if key.Name == "" || len(key.Name) > 255 {
+		return nil, "", fmt.Errorf("%w: name is required and at most 255 characters", ErrInvalidArgument)
+	}
+	scope, err := normalizeScopes(input.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	if key.Scopes = strings.Fields(scope); len(key.Scopes) == 0 {
+		return nil, "", fmt.Errorf("%w: at least one scope is required", ErrInvalidArgument)
+	}
+	if key.AllowedIPs, err = normalizeAllowedIPs(input.AllowedIPs); err != nil {
+		return nil, "", err
+	}
+
+	lifetime := input.ExpiresIn
+	if lifetime == 0 {
+		lifetime = b.cfg.APIKeyDefaultExpiry
+	}
+	if lifetime < 0 || lifetime > b.cfg.APIKeyMaxExpiry {
+		return nil, "", fmt.Errorf("%w: expiry must be positive and at most %s", ErrInvalidArgument, b.cfg.APIKeyMaxExpiry)
+	}
+	key.ExpiresAt = b.now().Add(lifetime)
+
+	owner, err := b.resolveAPIKeyOwner(ctx, input.Owner)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := b.requireKeyOwnerSession(ctx, owner, input.SessionID); err != nil {
+		return nil, "", err
+	}
+	if owner.clientID != nil {
+		for _, s := range key.Scopes {
+			if !hasScope(owner.scopes, s) {
+				return nil, "", fmt.Errorf("%w: service account is not allowed scope %q", ErrScopeNotAllowed, s)
+			}
+		}
+	}
+	if owner.user != nil {
+		for _, s := range key.Scopes {
+			if !hasScope(b.cfg.APIKeyUserScopes, s) {
+				return nil, "", fmt.Errorf("%w: personal keys are not allowed scope %q", ErrScopeNotAllowed, s)
+			}
+			if b.verificationScope(owner.user, s) == "" {
+				return nil, "", fmt.Errorf("%w: scope %q requires a verified account", ErrScopeNotAllowed, s)
+			}
+		}
+	}
+	key.UserID, key.OAuthClientID = owner.userID, owner.clientID
+
+	plaintext, err := newAPIKeySecret(key)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := b.authRepo.CreateAPIKey(ctx, key); err != nil {
+		return nil, "", err
+	}
+
+	b.recordAPIKeyEvent(ctx, models.EventAPIKeyCreated, key, input.Client, "", nil)
+	return key, plaintext, nil
+}
+
+// ListAPIKeys returns the owner's keys that are neither revoked nor expired
+func (b *AuthBusiness) ListAPIKeys(ctx context.Context, owner APIKeyOwner) ([]*models.APIKey, error) {
+	resolved, err := b.resolveAPIKeyOwner(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	return b.authRepo.ListAPIKeys(ctx, resolved.userID, resolved.clientID, b.now())
+}
+
+// RevokeAPIKey stops a key from being used; tokens already obtained with it
+// remain valid until they expire
+func (b *AuthBusiness) RevokeAPIKey(ctx context.Context, owner APIKeyOwner, keyUUID string, client ClientInfo) (*models.APIKey, error) {
+	key, _, err := b.getOwnedAPIKey(ctx, owner, keyUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err = b.authRepo.RevokeAPIKey(ctx, key.ID, revokeReasonAPIKeyRevoked)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	b.recordAPIKeyEvent(ctx, models.EventAPIKeyRevoked, key, client, "", nil)
+	return key, nil
+}
+
+// RotateAPIKey replaces a key with a new one of the same name, scopes, allow
+// list and lifetime. The old key keeps working for API_KEY_ROTATION_GRACE so
+// scripts can be updated without downtime. Personal keys are rotated from the
+// owner's own session, like CreateAPIKey.
+func (b *AuthBusiness) RotateAPIKey(ctx context.Context, owner APIKeyOwner, sessionUUID, keyUUID string, client ClientInfo) (*models.APIKey, string, error) {
+	old, resolved, err := b.getOwnedAPIKey(ctx, owner, keyUUID)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := b.requireKeyOwnerSession(ctx, resolved, sessionUUID); err != nil {
+		return nil, "", err
+	}
+	now := b.now()
+	if !old.IsUsable(now) || old.RotatedToID != nil {
+		return nil, "", ErrAPIKeyNotFound
+	}
+
+	lifetime := old.ExpiresAt.Sub(old.CreatedAt)
+	if lifetime > b.cfg.APIKeyMaxExpiry {
+		lifetime = b.cfg.APIKeyMaxExpiry
+	}
+	replacement := &models.APIKey{
+		UserID:        old.UserID,
+		OAuthClientID: old.OAuthClientID,
+		Name:          old.Name,
+		Scopes:        old.Scopes,
+		AllowedIPs:    old.AllowedIPs,
+		ExpiresAt:     now.Add(lifetime),
+	}
+	plaintext, err := newAPIKeySecret(replacement)
+	if err != nil {
+		return nil, "", err
+	}
+
+	old, err = b.authRepo.RotateAPIKey(ctx, old.ID, replacement, now.Add(b.cfg.APIKeyRotationGrace))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, "", ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	b.recordAPIKeyEvent(ctx, models.EventAPIKeyRotated, replacement, client, "",
+		map[string]interface{}{"rotated_from": old.UUID, "old_key_expires_at": old.ExpiresAt})
+	return replacement, plaintext, nil
+}
+
+// ValidateAPIKey checks a key presented by an integration and exchanges it for
+// a short-lived access token signed with the authorization server keys, so
+// services behind the gateway only ever see JWTs. client.IPAddress must be the
+// caller's address as seen by the gateway.
+func (b *AuthBusiness) ValidateAPIKey(ctx context.Context, plaintext string, client ClientInfo) (*APIKeyToken, error) {
+	plaintext = strings.TrimSpace(plaintext)
+	prefix, ok := parseAPIKeyPrefix(plaintext)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+	if b.signingKeys == nil {
+		return nil, errors.New("token signing is not configured")
+	}
+	key, err := b.authRepo.GetAPIKeyByPrefix(ctx, prefix)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := b.now()
+	if subtle.ConstantTimeCompare([]byte(hashToken(plaintext)), []byte(key.KeyHash)) != 1 || !key.IsUsable(now) {
+		return nil, ErrInvalidAPIKey
+	}
+	if !key.AllowsIP(net.ParseIP(client.IPAddress)) {
+		b.recordAPIKeyEvent(ctx, models.EventAPIKeyRejected, key, client, "ip_not_allowed", nil)
+		return nil, ErrAPIKeyIPNotAllowed
+	}
+
+	subject, clientID, scopes, err := b.apiKeySubject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	// A token without scopes would only prove the key exists
+	if len(scopes) == 0 {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if err := b.authRepo.TouchAPIKey(ctx, key.ID, client.IPAddress, now, now.Add(-apiKeyTouchInterval)); err != nil {
+		log.Printf("Failed to record api key use: %v", err)
+	}
+
+	scope := strings.Join(scopes, " ")
+	accessToken, err := b.signAccessToken(subject, &TokenClaims{
+		Scope:    scope,
+		ClientID: clientID,
+		APIKeyID: key.UUID,
+	}, b.cfg.APIKeyTokenExpiry)
+	if err != nil {
+		return nil, err
+	}
+	return &APIKeyToken{
+		AccessToken: accessToken,
+		ExpiresIn:   int64(b.cfg.APIKeyTokenExpiry / time.Second),
+		Scope:       scope,
+		Subject:     subject,
+		APIKeyID:    key.UUID,
+	}, nil
+}
+
+// apiKeySubject checks that the key's owner may still use it and returns the
+// token subject, client_id claim and the scopes still granted
+func (b *AuthBusiness) apiKeySubject(ctx context.Context, key *models.APIKey) (string, string, []string, error) {
+	if key.UserID != nil {
+		user, err := b.authRepo.GetUserByID(ctx, *key.UserID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return "", "", nil, ErrInvalidAPIKey
+		}
+		if err != nil {
+			return "", "", nil, err
+		}
+		if !user.IsActive {
+			return "", "", nil, ErrAccountDisabled
+		}
+		// Scopes since removed from API_KEY_USER_SCOPES, or beyond what an
+		// account that is no longer verified may use, are dropped
+		var scopes []string
+		for _, scope := range key.Scopes {
+			if hasScope(b.cfg.APIKeyUserScopes, scope) && b.verificationScope(user, scope) != "" {
+				scopes = append(scopes, scope)
+			}
+		}
+		return user.UUID, "", scopes, nil
+	}
+
+	if key.OAuthClientID == nil {
+		return "", "", nil, ErrInvalidAPIKey
+	}
+	client, err := b.authRepo.GetOAuthClientByID(ctx, *key.OAuthClientID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && !client.IsActive) {
+		return "", "", nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	// Scopes removed from the service account since the key was created are dropped
+	var scopes []string
+	for _, scope := range key.Scopes {
+		if hasScope(client.Scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return clientSubjectPrefix + client.ClientID, client.ClientID, scopes, nil
+}
+
+// validateAPIKeyOwner checks that exactly one owner is given
+func validateAPIKeyOwner(owner APIKeyOwner) error {
+	if (strings.TrimSpace(owner.UserID) == "") == (strings.TrimSpace(owner.ServiceAccountID) == "") {
+		return fmt.Errorf("%w: exactly one of user_id and service_account_id is required", ErrInvalidArgument)
+	}
+	return nil
+}
+
+// resolveAPIKeyOwner looks up the user or service account owning keys
+func (b *AuthBusiness) resolveAPIKeyOwner(ctx context.Context, owner APIKeyOwner) (*apiKeyOwner, error) {
+	if err := validateAPIKeyOwner(owner); err != nil {
+		return nil, err
+	}
+	userID, serviceAccountID := strings.TrimSpace(owner.UserID), strings.TrimSpace(owner.ServiceAccountID)
+
+	if userID != "" {
+		user, err := b.getUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		return &apiKeyOwner{userID: &user.ID, user: user}, nil
+	}
+
+	client, err := b.authRepo.GetOAuthClientByUUID(ctx, serviceAccountID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && !client.AllowsGrant(models.GrantTypeClientCredentials)) {
+		return nil, ErrOAuthClientNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &apiKeyOwner{clientID: &client.ID, scopes: client.Scopes}, nil
+}
+
+// requireKeyOwnerSession checks that personal keys are minted from the owner's
+// own session; machine keys are managed by admins and need none
+func (b *AuthBusiness) requireKeyOwnerSession(ctx context.Context, owner *apiKeyOwner, sessionUUID string) error {
+	if owner.user == nil {
+		return nil
+	}
+	_, err := b.requireOwnSession(ctx, owner.user, sessionUUID)
+	return err
+}
+
+func (b *AuthBusiness) getOwnedAPIKey(ctx context.Context, owner APIKeyOwner, keyUUID string) (*models.APIKey, *apiKeyOwner, error) {
+	if err := validateAPIKeyOwner(owner); err != nil {
+		return nil, nil, err
+	}
+	if strings.TrimSpace(keyUUID) == "" {
+		return nil, nil, fmt.Errorf("%w: api key id is required", ErrInvalidArgument)
+	}
+	resolved, err := b.resolveAPIKeyOwner(ctx, owner)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := b.authRepo.GetOwnedAPIKey(ctx, keyUUID, resolved.userID, resolved.clientID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, resolved, nil
+}
+
+func (b *AuthBusiness) recordAPIKeyEvent(ctx context.Context, eventType string, key *models.APIKey, client ClientInfo, failureReason string, meta map[string]interface{}) {
+	if meta == nil {
+		meta = map[string]interface{}{}
+	}
+	meta["api_key_id"] = key.UUID
+	meta["key_prefix"] = key.KeyPrefix
+	if key.OAuthClientID != nil {
+		meta["oauth_client_id"] = *key.OAuthClientID
+	}
+
+	b.audit.Record(ctx, audit.Event{
+		Type:          eventType,
+		UserID:        key.UserID,
+		IPAddress:     client.IPAddress,
+		UserAgent:     client.UserAgent,
+		DeviceInfo:    client.DeviceInfo,
+		FailureReason: failureReason,
+		Meta:          meta,
+	})
+}
+
+// normalizeAllowedIPs accepts addresses and CIDR ranges and stores them as CIDRs
+func normalizeAllowedIPs(values []string) ([]string, error) {
+	if len(values) > maxAPIKeyAllowedIPs {
+		return nil, fmt.Errorf("%w: at most %d allowed IP ranges", ErrInvalidArgument, maxAPIKeyAllowedIPs)
+	}
+
+	var result []string
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("%w: invalid IP address %q", ErrInvalidArgument, value)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				bits = 32
+			}
+			value = fmt.Sprintf("%s/%d", ip, bits)
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid IP range %q", ErrInvalidArgument, value)
+		}
+		if cidr := network.String(); !hasScope(result, cidr) {
+			result = append(result, cidr)
+		}
+	}
+	return result, nil
+}
+
+// newAPIKeySecret generates "sr_<lookup>_<secret>", setting the key's prefix and hash
+func newAPIKeySecret(key *models.APIKey) (string, error) {
+	lookup := make([]byte, apiKeyLookupBytes)
+	if _, err := rand.Read(lookup); err != nil {
+		return "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	secret, err := generateOpaqueToken(apiKeySecretBytes)
+	if err != nil {
+		return "", err
+	}
+
+	key.KeyPrefix = apiKeyPrefix + "_" + hex.EncodeToString(lookup)
+	plaintext := key.KeyPrefix + "_" + secret
+	key.KeyHash = hashToken(plaintext)
+	return plaintext, nil
+}
+
+// parseAPIKeyPrefix returns the lookup prefix of a well-formed key
+func parseAPIKeyPrefix(plaintext string) (string, bool) {
+	parts := strings.SplitN(plaintext, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyPrefix || len(parts[1]) != 2*apiKeyLookupBytes || parts[2] == "" {
+		return "", false
+	}
+	if _, err := hex.DecodeString(parts[1]); err != nil {
+		return "", false
+	}
+	return parts[0] + "_" + parts[1], true
+}
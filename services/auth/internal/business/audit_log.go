@@ -0,0 +1,336 @@
+package business
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// Login event listing limits
+const (
+	DefaultLoginEventPageSize = 50
+	MaxLoginEventPageSize     = 500
+
+	// MaxLoginEventExportRows caps a single export; narrow the filter for more
+	MaxLoginEventExportRows = 100000
+	exportPageSize          = 1000
+)
+
+// Export formats accepted by ExportLoginEvents
+const (
+	ExportFormatCSV   = "csv"
+	ExportFormatJSONL = "jsonl"
+)
+
+// LoginEventFilter selects audit entries. Zero values leave a field unfiltered.
+type LoginEventFilter struct {
+	UserUUID   string
+	EventTypes []string
+	Success    *bool
+	// IP is a single address or a CIDR block
+	IP        string
+	From      time.Time
+	To        time.Time
+	PageSize  int
+	PageToken string
+}
+
+// LoginEventPage is one page of audit entries, newest first
+type LoginEventPage struct {
+	Events        []*models.LoginLog
+	NextPageToken string
+}
+
+// ExportResult summarises a completed export
+type ExportResult struct {
+	Rows      int
+	Truncated bool
+}
+
+// ListLoginEvents returns audit entries matching filter, using keyset pagination
+func (b *AuthBusiness) ListLoginEvents(ctx context.Context, filter LoginEventFilter) (*LoginEventPage, error) {
+	repoFilter, err := b.loginLogFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	pageSize := filter.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultLoginEventPageSize
+	}
+	if pageSize > MaxLoginEventPageSize {
+		pageSize = MaxLoginEventPageSize
+	}
+	// Fetch one extra row to learn whether another page exists
+	repoFilter.Limit = pageSize + 1
+
+	entries, err := b.authRepo.ListLoginLogs(ctx, repoFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &LoginEventPage{Events: entries}
+	if len(entries) > pageSize {
+		page.Events = entries[:pageSize]
+		last := page.Events[pageSize-1]
+		page.NextPageToken = encodeLoginLogCursor(repository.LoginLogCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	return page, nil
+}
+
+// ExportLoginEvents writes every entry matching filter to w as CSV or JSON Lines,
+// stopping after MaxLoginEventExportRows. PageSize and PageToken are ignored.
+func (b *AuthBusiness) ExportLoginEvents(ctx context.Context, filter LoginEventFilter, format string, w io.Writer) (*ExportResult, error) {
+	if format != ExportFormatCSV && format != ExportFormatJSONL {
+		return nil, ErrInvalidArgument
+	}
+
+	filter.PageToken = ""
+	repoFilter, err := b.loginLogFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	repoFilter.Limit = exportPageSize
+
+	var (
+		csvWriter *csv.Writer
+		encoder   *json.Encoder
+	)
+	if format == ExportFormatCSV {
+		csvWriter = csv.NewWriter(w)
+		if err := csvWriter.Write(loginEventCSVHeader); err != nil {
+			return nil, fmt.Errorf("failed to write export: %w", err)
+		}
+	} else {
+		encoder = json.NewEncoder(w)
+	}
+
+	result := &ExportResult{}
+	for {
+		entries, err := b.authRepo.ListLoginLogs(ctx, repoFilter)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if result.Rows == MaxLoginEventExportRows {
+				result.Truncated = true
+				break
+			}
+			record := newLoginEventRecord(entry)
+			if csvWriter != nil {
+				err = csvWriter.Write(record.csvRow())
+			} else {
+				err = encoder.Encode(record)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to write export: %w", err)
+			}
+			result.Rows++
+		}
+
+		if result.Truncated || len(entries) < repoFilter.Limit {
+			break
+		}
+		last := entries[len(entries)-1]
+		repoFilter.After = &repository.LoginLogCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	if csvWriter != nil {
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			return nil, fmt.Errorf("failed to write export: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// ArchiveLoginEvents moves audit entries older than LoginLogRetention into the
+// archive table in batches and returns how many were moved. A short batch may
+// only mean rows were locked by another archiver, so it stops once a batch
+// moves nothing.
+func (b *AuthBusiness) ArchiveLoginEvents(ctx context.Context) (int64, error) {
+	if b.cfg.LoginLogRetention <= 0 {
+		return 0, nil
+	}
+
+	cutoff := b.now().Add(-b.cfg.LoginLogRetention)
+	var total int64
+	for {
+		moved, err := b.authRepo.ArchiveLoginLogs(ctx, cutoff, b.cfg.LoginLogArchiveBatchSize)
+		total += moved
+		if err != nil {
+			return total, err
+		}
+		if moved == 0 {
+			return total, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+	}
+}
+
+// loginLogFilter validates a business filter and converts it for the repository
+func (b *AuthBusiness) loginLogFilter(ctx context.Context, filter LoginEventFilter) (repository.LoginLogFilter, error) {
+	var repoFilter repository.LoginLogFilter
+
+	if filter.UserUUID != "" {
+		user, err := b.authRepo.GetUserByUUID(ctx, filter.UserUUID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return repoFilter, ErrUserNotFound
+		}
+		if err != nil {
+			return repoFilter, err
+		}
+		repoFilter.UserID = &user.ID
+	}
+
+	for _, eventType := range filter.EventTypes {
+		if !models.IsLoginEventType(eventType) {
+			return repoFilter, fmt.Errorf("%w: unknown event type %q", ErrInvalidArgument, eventType)
+		}
+	}
+	repoFilter.EventTypes = filter.EventTypes
+	repoFilter.Success = filter.Success
+
+	if filter.IP != "" {
+		network, err := parseNetwork(filter.IP)
+		if err != nil {
+			return repoFilter, err
+		}
+		repoFilter.Network = network
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return repoFilter, fmt.Errorf("%w: from must be before to", ErrInvalidArgument)
+	}
+	repoFilter.From = filter.From
+	repoFilter.To = filter.To
+
+	if filter.PageToken != "" {
+		cursor, err := decodeLoginLogCursor(filter.PageToken)
+		if err != nil {
+			return repoFilter, err
+		}
+		repoFilter.After = cursor
+	}
+
+	return repoFilter, nil
+}
+
+// parseNetwork accepts an IP address or CIDR block and returns it in CIDR form
+func parseNetwork(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if _, network, err := net.ParseCIDR(value); err == nil {
+		return network.String(), nil
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return "", fmt.Errorf("%w: invalid IP address or CIDR %q", ErrInvalidArgument, value)
+	}
+	if ip.To4() != nil {
+		return ip.String() + "/32", nil
+	}
+	return ip.String() + "/128", nil
+}
+
+// Page tokens are opaque to clients: base64url("<created_at unix nanos>:<id>")
+func encodeLoginLogCursor(cursor repository.LoginLogCursor) string {
+	raw := strconv.FormatInt(cursor.CreatedAt.UnixNano(), 10) + ":" + strconv.Itoa(cursor.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeLoginLogCursor(token string) (*repository.LoginLogCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid page token", ErrInvalidArgument)
+	}
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid page token", ErrInvalidArgument)
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid page token", ErrInvalidArgument)
+	}
+	i, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid page token", ErrInvalidArgument)
+	}
+	return &repository.LoginLogCursor{CreatedAt: time.Unix(0, n).UTC(), ID: i}, nil
+}
+
+var loginEventCSVHeader = []string{
+	"id", "created_at", "user_id", "event_type", "success", "failure_reason", "ip_address", "user_agent", "meta",
+}
+
+// loginEventRecord is the exported form of an audit entry
+type loginEventRecord struct {
+	ID            string                 `json:"id"`
+	CreatedAt     time.Time              `json:"created_at"`
+	UserID        string                 `json:"user_id,omitempty"`
+	EventType     string                 `json:"event_type"`
+	Success       bool                   `json:"success"`
+	FailureReason string                 `json:"failure_reason,omitempty"`
+	IPAddress     string                 `json:"ip_address,omitempty"`
+	UserAgent     string                 `json:"user_agent,omitempty"`
+	DeviceInfo    map[string]interface{} `json:"device_info,omitempty"`
+	Meta          map[string]interface{} `json:"meta,omitempty"`
+}
+
+func newLoginEventRecord(entry *models.LoginLog) loginEventRecord {
+	record := loginEventRecord{
+		ID:         entry.UUID,
+		CreatedAt:  entry.CreatedAt.UTC(),
+		EventType:  entry.EventType,
+		Success:    entry.Success,
+		DeviceInfo: entry.DeviceInfo,
+		Meta:       entry.Meta,
+	}
+	if entry.UserUUID != nil {
+		record.UserID = *entry.UserUUID
+	}
+	if entry.FailureReason != nil {
+		record.FailureReason = *entry.FailureReason
+	}
+	if entry.IPAddress != nil {
+		record.IPAddress = *entry.IPAddress
+	}
+	if entry.UserAgent != nil {
+		record.UserAgent = *entry.UserAgent
+	}
+	return record
+}
+
+func (r loginEventRecord) csvRow() []string {
+	meta := ""
+	if len(r.Meta) > 0 {
+		encoded, _ := json.Marshal(r.Meta)
+		meta = string(encoded)
+	}
+	return []string{
+		r.ID, r.CreatedAt.Format(time.RFC3339Nano), r.UserID, r.EventType, strconv.FormatBool(r.Success),
+		csvSafe(r.FailureReason), r.IPAddress, csvSafe(r.UserAgent), csvSafe(meta),
+	}
+}
+
+// csvSafe neutralises values a spreadsheet would evaluate as a formula.
+// User agents in particular are attacker controlled.
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -1,25 +1,174 @@
 package business
 
 import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/your-project/pkgs/geoip"
+	"github.com/your-project/pkgs/jwt"
+	"github.com/your-project/pkgs/oidc"
+	"github.com/your-project/pkgs/webauthn"
+	"github.com/your-project/services/auth/internal/audit"
+	"github.com/your-project/services/auth/internal/config"
+	"github.com/your-project/services/auth/internal/events"
+	"github.com/your-project/services/auth/internal/mailer"
 	"github.com/your-project/services/auth/internal/repository"
+	"github.com/your-project/services/auth/internal/sms"
 )
 
 // AuthBusiness handles business logic for authentication
 type AuthBusiness struct {
-	authRepo *repository.AuthRepository
+	authRepo repository.Store
+	cfg      *config.Config
+	tokens   *jwt.JWTManager
+	// signingKeys sign the ID and access tokens of the OAuth authorization server
+	signingKeys *jwt.KeyManager
+	passkeys    *webauthn.RelyingParty
+	mailer      mailer.Mailer
+	sms         sms.Sender
+	events      events.Publisher
+	audit       *audit.Writer
+	// cityDB and asnDB feed risk scoring; nil disables the location signals
+	cityDB *geoip.Reader
+	asnDB  *geoip.Reader
+	// httpClient talks to external identity providers
+	httpClient *http.Client
+	// oidc caches upstream discovery documents and signing keys
+	oidc *oidc.Cache
+	// providers caches sr_auth.providers (see RefreshProviders)
+	providers providerCache
+	now       func() time.Time
+}
+
+// Option customizes an AuthBusiness at construction time
+type Option func(*AuthBusiness)
+
+// WithMailer overrides the mail sink selected by configuration
+func WithMailer(m mailer.Mailer) Option {
+	return func(b *AuthBusiness) {
+		b.mailer = m
+	}
+}
+
+// WithSMSSender overrides the text message sink selected by configuration
+func WithSMSSender(s sms.Sender) Option {
+	return func(b *AuthBusiness) {
+		b.sms = s
+	}
+}
+
+// WithEventPublisher overrides the event sink selected by configuration
+func WithEventPublisher(p events.Publisher) Option {
+	return func(b *AuthBusiness) {
+		b.events = p
+	}
+}
+
+// WithGeoIP overrides the GeoIP databases opened from configuration
+func WithGeoIP(cityDB, asnDB *geoip.Reader) Option {
+	return func(b *AuthBusiness) {
+		b.cityDB = cityDB
+		b.asnDB = asnDB
+	}
+}
+
+// WithHTTPClient overrides the client used to call identity providers
+func WithHTTPClient(client *http.Client) Option {
+	return func(b *AuthBusiness) {
+		b.httpClient = client
+	}
+}
+
+// WithSigningKeys sets the keys for ID and access tokens issued to OAuth clients.
+// Without it a throwaway key is generated.
+func WithSigningKeys(keys *jwt.KeyManager) Option {
+	return func(b *AuthBusiness) {
+		b.signingKeys = keys
+	}
 }
 
-// NewAuthBusiness creates a new auth business instance
-func NewAuthBusiness(authRepo *repository.AuthRepository) *AuthBusiness {
-	return &AuthBusiness{
-		authRepo: authRepo,
+// WithClock overrides the time source, so tests can move past expiries
+func WithClock(now func() time.Time) Option {
+	return func(b *AuthBusiness) {
+		b.now = now
 	}
 }
 
-// TODO: Implement business logic methods for authentication operations
-// Examples:
-// - RegisterUser
-// - LoginUser
-// - ValidateToken
-// - RefreshToken
-// - LogoutUser
+// NewAuthBusiness creates a new auth business instance on top of any repository.Store
+func NewAuthBusiness(authRepo repository.Store, cfg *config.Config, opts ...Option) *AuthBusiness {
+	passkeys, err := webauthn.NewRelyingParty(webauthn.Config{
+		RPID:    cfg.WebAuthnRPID,
+		RPName:  cfg.WebAuthnRPName,
+		Origins: cfg.WebAuthnOrigins,
+	})
+	if err != nil {
+		log.Printf("Passkeys disabled: %v", err)
+	}
+
+	mail, err := mailer.New(cfg.MailSink, cfg.MailDir)
+	if err != nil {
+		log.Printf("Falling back to log mail sink: %v", err)
+		mail = mailer.NewLogMailer()
+	}
+
+	sender, err := sms.New(cfg.SMSSink, cfg.SMSDir)
+	if err != nil {
+		log.Printf("Falling back to log sms sink: %v", err)
+		sender = sms.NewLogSender()
+	}
+
+	publisher, err := events.New(cfg.EventSink)
+	if err != nil {
+		log.Printf("Falling back to log event sink: %v", err)
+		publisher = events.NewLogPublisher()
+	}
+
+	b := &AuthBusiness{
+		authRepo:   authRepo,
+		cfg:        cfg,
+		tokens:     jwt.NewJWTManager(cfg.JWTSecret),
+		passkeys:   passkeys,
+		mailer:     mail,
+		sms:        sender,
+		events:     publisher,
+		audit:      audit.NewWriter(authRepo),
+		cityDB:     openGeoIP(cfg.GeoIPCityDB, "city"),
+		asnDB:      openGeoIP(cfg.GeoIPASNDB, "ASN"),
+		httpClient: &http.Client{Timeout: cfg.OAuthHTTPTimeout},
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	b.oidc = oidc.NewCache(b.httpClient, cfg.OIDCCacheTTL)
+	if b.signingKeys == nil {
+		if b.signingKeys, err = generateSigningKeys(); err != nil {
+			log.Printf("OAuth authorization server disabled: %v", err)
+		}
+	}
+
+	return b
+}
+
+// generateSigningKeys creates a key manager with a single throwaway key
+func generateSigningKeys() (*jwt.KeyManager, error) {
+	key, err := jwt.GenerateSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	return jwt.NewKeyManager(key)
+}
+
+// openGeoIP loads an optional GeoIP database, returning nil if none is configured
+func openGeoIP(path, kind string) *geoip.Reader {
+	if path == "" {
+		return nil
+	}
+	reader, err := geoip.Open(path)
+	if err != nil {
+		log.Printf("GeoIP %s signals disabled: %v", kind, err)
+		return nil
+	}
+	return reader
+}
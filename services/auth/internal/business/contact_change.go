@@ -0,0 +1,309 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/your-project/services/auth/internal/events"
+	"github.com/your-project/services/auth/internal/mailer"
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+	"github.com/your-project/services/auth/internal/sms"
+)
+
+// revokeReasonContactChangeUndone is recorded on sessions ended by undoing a change
+const revokeReasonContactChangeUndone = "contact_change_undone"
+
+// phoneNumberPattern accepts E.164 numbers, which fit users.phone
+var phoneNumberPattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// RequestContactChangeInput starts replacing the user's email address or phone number
+type RequestContactChangeInput struct {
+	UserID string
+	// SessionID is the caller's session; impersonation sessions are refused
+	SessionID string
+	// Channel is models.VerificationChannelEmail or models.VerificationChannelPhone
+	Channel  string
+	NewValue string
+	// CurrentPassword is required for accounts that have a password
+	CurrentPassword string
+	Client          ClientInfo
+}
+
+// ContactChangeChallenge describes a confirmation link that was just sent
+type ContactChangeChallenge struct {
+	Channel string
+	// Destination is the new address, masked for display
+	Destination string
+	ExpiresAt   time.Time
+}
+
+// RequestContactChange sends a confirmation link to the new address. Nothing
+// changes until it is opened; a newer request replaces an unconfirmed one.
+func (b *AuthBusiness) RequestContactChange(ctx context.Context, input RequestContactChangeInput) (*ContactChangeChallenge, error) {
+	channel, _, err := verificationUseCase(input.Channel)
+	if err != nil {
+		return nil, err
+	}
+	newValue, err := normalizeContact(channel, input.NewValue)
+	if err != nil {
+		return nil, err
+	}
+	user, err := b.getUser(ctx, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrAccountDisabled
+	}
+	if _, err := b.requireOwnSession(ctx, user, input.SessionID); err != nil {
+		return nil, err
+	}
+	meta := map[string]interface{}{"channel": channel}
+
+	oldValue := contactValue(user, channel)
+	if oldValue != nil && strings.EqualFold(*oldValue, newValue) {
+		return nil, fmt.Errorf("%w: new %s is the same as the current one", ErrInvalidArgument, channel)
+	}
+	if user.PasswordHash != "" {
+		if input.CurrentPassword == "" {
+			return nil, fmt.Errorf("%w: current password is required", ErrInvalidArgument)
+		}
+		if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.CurrentPassword)) != nil {
+			b.recordLoginEvent(ctx, user, nil, models.EventContactChangeRequested, input.Client, "invalid_password", meta)
+			return nil, ErrInvalidCredentials
+		}
+	}
+
+	inUse, err := b.authRepo.IsContactInUse(ctx, channel, newValue, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if inUse {
+		b.recordLoginEvent(ctx, user, nil, models.EventContactChangeRequested, input.Client, "address_in_use", meta)
+		return nil, ErrContactInUse
+	}
+
+	token, err := generateOpaqueToken(32)
+	if err != nil {
+		return nil, err
+	}
+	change := &models.ContactChange{
+		UserID:           user.ID,
+		Channel:          channel,
+		OldValue:         oldValue,
+		NewValue:         newValue,
+		ConfirmTokenHash: hashToken(token),
+		ExpiresAt:        b.now().Add(b.cfg.ContactChangeExpiry),
+		IPAddress:        optionalString(input.Client.IPAddress),
+		UserAgent:        optionalString(input.Client.UserAgent),
+	}
+	err = b.authRepo.WithTx(ctx, func(ctx context.Context, _ repository.Store) error {
+		if err := b.authRepo.CreateContactChange(ctx, change); err != nil {
+			return err
+		}
+		b.recordLoginEvent(ctx, user, nil, models.EventContactChangeRequested, input.Client, "", meta)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Sent once the request is stored, so a retried transaction sends one link
+	link := tokenLinkURL(b.cfg.ContactChangeConfirmURL, token)
+	if channel == models.VerificationChannelPhone {
+		err = b.sms.Send(ctx, sms.Message{
+			To:   newValue,
+			Body: fmt.Sprintf("Confirm this number for your account: %s (expires in %s)", link, b.cfg.ContactChangeExpiry),
+		})
+	} else {
+		err = b.mailer.Send(ctx, mailer.Message{
+			To:      newValue,
+			Subject: "Confirm your new email address",
+			Body: fmt.Sprintf("Use the link below to make this the email address of your account. It expires in %s and can only be used once.\n\n%s\n\nIf you did not request this, you can ignore this email.",
+				b.cfg.ContactChangeExpiry, link),
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to send contact change confirmation: %w", err)
+	}
+
+	return &ContactChangeChallenge{
+		Channel:     channel,
+		Destination: maskDestination(channel, newValue),
+		ExpiresAt:   change.ExpiresAt,
+	}, nil
+}
+
+// ConfirmContactChange applies a change with the token sent to the new address,
+// which becomes verified. Uniqueness is checked again, since the address may
+// have been taken after the request. The old address, if any, is told about
+// the change and given an undo link valid for ContactChangeUndoWindow.
+func (b *AuthBusiness) ConfirmContactChange(ctx context.Context, token string, client ClientInfo) (*models.User, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, fmt.Errorf("%w: token is required", ErrInvalidArgument)
+	}
+
+	undoToken, err := generateOpaqueToken(32)
+	if err != nil {
+		return nil, err
+	}
+	undoHash := hashToken(undoToken)
+	undoExpiresAt := b.now().Add(b.cfg.ContactChangeUndoWindow)
+
+	var (
+		change *models.ContactChange
+		user   *models.User
+	)
+	err = b.authRepo.WithTx(ctx, func(ctx context.Context, _ repository.Store) error {
+		var err error
+		change, user, err = b.authRepo.ConfirmContactChange(ctx, hashToken(token), &undoHash, undoExpiresAt)
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrInvalidToken
+		case errors.Is(err, repository.ErrDuplicate):
+			return ErrContactInUse
+		case err != nil:
+			return err
+		}
+
+		b.recordLoginEvent(ctx, user, nil, models.EventContactChanged, client, "", map[string]interface{}{"channel": change.Channel})
+		return nil
+	})
+	if errors.Is(err, ErrInvalidToken) {
+		b.recordLoginEvent(ctx, nil, nil, models.EventContactChanged, client, "invalid_token", nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if change.OldValue != nil {
+		if err := b.sendContactUndoNotice(ctx, change, undoToken); err != nil {
+			// The change has been committed; failing now would only hide that
+			log.Printf("Failed to send contact change undo notice: %v", err)
+		}
+	}
+
+	b.publishContactChange(ctx, user, change, false)
+	return user, nil
+}
+
+// UndoContactChange reverts a confirmed change with the token sent to the old
+// address and signs the user out everywhere, since the change may have been
+// made by someone else. It returns how many sessions were revoked.
+func (b *AuthBusiness) UndoContactChange(ctx context.Context, token string, client ClientInfo) (int, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return 0, fmt.Errorf("%w: token is required", ErrInvalidArgument)
+	}
+
+	// The address is only restored together with signing out every session
+	var (
+		change  *models.ContactChange
+		user    *models.User
+		revoked int64
+	)
+	err := b.authRepo.WithTx(ctx, func(ctx context.Context, _ repository.Store) error {
+		var err error
+		change, user, err = b.authRepo.UndoContactChange(ctx, hashToken(token))
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrInvalidToken
+		case errors.Is(err, repository.ErrDuplicate):
+			return ErrContactInUse
+		case err != nil:
+			return err
+		}
+
+		if revoked, err = b.authRepo.RevokeUserSessions(ctx, user.ID, 0, revokeReasonContactChangeUndone); err != nil {
+			return err
+		}
+
+		b.recordLoginEvent(ctx, user, nil, models.EventContactChangeUndone, client, "",
+			map[string]interface{}{"channel": change.Channel, "sessions_revoked": revoked})
+		return nil
+	})
+	if errors.Is(err, ErrInvalidToken) {
+		b.recordLoginEvent(ctx, nil, nil, models.EventContactChangeUndone, client, "invalid_token", nil)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	b.publishContactChange(ctx, user, change, true)
+	return int(revoked), nil
+}
+
+func (b *AuthBusiness) sendContactUndoNotice(ctx context.Context, change *models.ContactChange, undoToken string) error {
+	link := tokenLinkURL(b.cfg.ContactChangeUndoURL, undoToken)
+	days := int(b.cfg.ContactChangeUndoWindow.Hours() / 24)
+	if change.Channel == models.VerificationChannelPhone {
+		return b.sms.Send(ctx, sms.Message{
+			To: *change.OldValue,
+			Body: fmt.Sprintf("The phone number on your account was changed to %s. Not you? Undo it within %d days: %s",
+				maskDestination(change.Channel, change.NewValue), days, link),
+		})
+	}
+	return b.mailer.Send(ctx, mailer.Message{
+		To:      *change.OldValue,
+		Subject: "Your email address was changed",
+		Body: fmt.Sprintf("The email address on your account was changed to %s.\n\nIf you did not make this change, use the link below within %d days to restore this address and sign out every session:\n\n%s",
+			maskDestination(change.Channel, change.NewValue), days, link),
+	})
+}
+
+// publishContactChange announces the user's new address on the change's channel
+func (b *AuthBusiness) publishContactChange(ctx context.Context, user *models.User, change *models.ContactChange, undone bool) {
+	eventType := events.TypeEmailChanged
+	if change.Channel == models.VerificationChannelPhone {
+		eventType = events.TypePhoneChanged
+	}
+	previous, current := change.OldValue, &change.NewValue
+	if undone {
+		previous, current = current, previous
+	}
+	b.publishEvent(ctx, eventType, user, map[string]interface{}{
+		"previous": stringValue(previous),
+		"current":  stringValue(current),
+		"undone":   undone,
+	})
+}
+
+// normalizeContact trims value and checks it is an email address or E.164 phone number
+func normalizeContact(channel, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", fmt.Errorf("%w: new %s is required", ErrInvalidArgument, channel)
+	}
+	if channel == models.VerificationChannelPhone {
+		if !phoneNumberPattern.MatchString(value) {
+			return "", fmt.Errorf("%w: phone number must be in E.164 format", ErrInvalidArgument)
+		}
+		return value, nil
+	}
+	addr, err := mail.ParseAddress(value)
+	if err != nil || addr.Address != value || len(value) > 255 {
+		return "", fmt.Errorf("%w: invalid email address", ErrInvalidArgument)
+	}
+	return value, nil
+}
+
+// contactValue returns the user's current address on channel, nil if unset
+func contactValue(user *models.User, channel string) *string {
+	if channel == models.VerificationChannelPhone {
+		if user.Phone == nil || *user.Phone == "" {
+			return nil
+		}
+		return user.Phone
+	}
+	return &user.Email
+}
@@ -0,0 +1,223 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/your-project/pkgs/useragent"
+	"github.com/your-project/services/auth/internal/mailer"
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// derivedDeviceIDPrefix marks device IDs derived from the user agent because the
+// client did not send one. Such devices are tracked but can never be trusted.
+const derivedDeviceIDPrefix = "ua:"
+
+// ListDevices returns the devices the user has signed in from
+func (b *AuthBusiness) ListDevices(ctx context.Context, userUUID string) ([]*models.Device, error) {
+	user, err := b.getUser(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	return b.authRepo.ListDevices(ctx, user.ID)
+}
+
+// TrustDevice lets the device skip the second factor for DeviceTrustDuration
+func (b *AuthBusiness) TrustDevice(ctx context.Context, userUUID, deviceUUID string) (*models.Device, error) {
+	user, device, err := b.getUserDevice(ctx, userUUID, deviceUUID)
+	if err != nil {
+		return nil, err
+	}
+	if device.DeviceID == nil || strings.HasPrefix(*device.DeviceID, derivedDeviceIDPrefix) {
+		return nil, ErrDeviceNotTrustable
+	}
+
+	now := b.now()
+	device, err = b.authRepo.SetDeviceTrust(ctx, user.ID, device.UUID, &now)
+	if err != nil {
+		return nil, err
+	}
+
+	b.recordLoginEvent(ctx, user, nil, models.EventDeviceTrusted, ClientInfo{}, "", map[string]interface{}{"device_id": device.UUID})
+	return device, nil
+}
+
+// UntrustDevice requires the second factor on the device again
+func (b *AuthBusiness) UntrustDevice(ctx context.Context, userUUID, deviceUUID string) (*models.Device, error) {
+	user, device, err := b.getUserDevice(ctx, userUUID, deviceUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	device, err = b.authRepo.SetDeviceTrust(ctx, user.ID, device.UUID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	b.recordLoginEvent(ctx, user, nil, models.EventDeviceUntrusted, ClientInfo{}, "", map[string]interface{}{"device_id": device.UUID})
+	return device, nil
+}
+
+// RemoveDevice forgets a device. The next sign-in from it counts as a new device.
+func (b *AuthBusiness) RemoveDevice(ctx context.Context, userUUID, deviceUUID string) error {
+	user, err := b.getUser(ctx, userUUID)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(deviceUUID) == "" {
+		return ErrInvalidArgument
+	}
+
+	if err := b.authRepo.DeleteDevice(ctx, user.ID, deviceUUID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrDeviceNotFound
+		}
+		return err
+	}
+
+	b.recordLoginEvent(ctx, user, nil, models.EventDeviceRemoved, ClientInfo{}, "", map[string]interface{}{"device_id": deviceUUID})
+	return nil
+}
+
+func (b *AuthBusiness) getUserDevice(ctx context.Context, userUUID, deviceUUID string) (*models.User, *models.Device, error) {
+	user, err := b.getUser(ctx, userUUID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if strings.TrimSpace(deviceUUID) == "" {
+		return nil, nil, ErrInvalidArgument
+	}
+
+	device, err := b.authRepo.GetDeviceByUUID(ctx, user.ID, deviceUUID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, ErrDeviceNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, device, nil
+}
+
+// trackDevice upserts the device behind a successful sign-in. It returns the device
+// when it is new to an account that already had others, so the caller can raise a
+// new-device alert once the sign-in is committed. Failures are logged so they never
+// block sign-in.
+func (b *AuthBusiness) trackDevice(ctx context.Context, user *models.User, client ClientInfo) *models.Device {
+	deviceID := resolveDeviceID(client)
+	if deviceID == "" {
+		return nil
+	}
+
+	info := useragent.Parse(client.UserAgent)
+	device := &models.Device{
+		UserID:     user.ID,
+		DeviceID:   &deviceID,
+		DeviceName: optionalString(info.Name()),
+		DeviceType: optionalString(info.DeviceType),
+		Browser:    optionalString(info.Browser),
+		OS:         optionalString(info.OS),
+		IPAddress:  optionalString(client.IPAddress),
+		LastUsedAt: b.now(),
+	}
+
+	isNew, err := b.authRepo.UpsertDevice(ctx, device)
+	if err != nil {
+		log.Printf("Failed to record device: %v", err)
+		return nil
+	}
+	if !isNew {
+		return nil
+	}
+
+	// A brand new account's first device is not worth an alert
+	devices, err := b.authRepo.ListDevices(ctx, user.ID)
+	if err != nil {
+		log.Printf("Failed to list devices: %v", err)
+		return nil
+	}
+	if len(devices) > 1 {
+		return device
+	}
+	return nil
+}
+
+// notifyNewDevice records the new-device event and emails the account owner
+func (b *AuthBusiness) notifyNewDevice(ctx context.Context, user *models.User, device *models.Device, client ClientInfo) {
+	name := "Unknown device"
+	if device.DeviceName != nil {
+		name = *device.DeviceName
+	}
+
+	b.recordLoginEvent(ctx, user, nil, models.EventNewDeviceSignIn, client, "",
+		map[string]interface{}{"device_id": device.UUID, "device_name": name})
+
+	ip := client.IPAddress
+	if ip == "" {
+		ip = "an unknown address"
+	}
+	err := b.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "New sign-in to your account",
+		Body: fmt.Sprintf("Your account was just signed in to from %s (%s) at %s.\n\nIf this was you, no action is needed. If not, change your password and sign out of your other sessions.",
+			name, ip, b.now().UTC().Format("2006-01-02 15:04 MST")),
+	})
+	if err != nil {
+		log.Printf("Failed to send new device alert: %v", err)
+	}
+}
+
+// isTrustedDevice reports whether the signing-in device may skip the second factor
+func (b *AuthBusiness) isTrustedDevice(ctx context.Context, user *models.User, client ClientInfo) bool {
+	deviceID := strings.TrimSpace(client.DeviceID)
+	if deviceID == "" || b.cfg.DeviceTrustDuration <= 0 {
+		return false
+	}
+
+	device, err := b.authRepo.GetDeviceByDeviceID(ctx, user.ID, deviceID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			log.Printf("Failed to look up device: %v", err)
+		}
+		return false
+	}
+
+	return device.IsTrusted && device.TrustedAt != nil &&
+		b.now().Before(device.TrustedAt.Add(b.cfg.DeviceTrustDuration))
+}
+
+// trustCurrentDevice trusts the device a sign-in just came from, if the client identified it
+func (b *AuthBusiness) trustCurrentDevice(ctx context.Context, user *models.User, client ClientInfo) {
+	deviceID := strings.TrimSpace(client.DeviceID)
+	if deviceID == "" {
+		return
+	}
+
+	device, err := b.authRepo.GetDeviceByDeviceID(ctx, user.ID, deviceID)
+	if err != nil {
+		log.Printf("Failed to look up device to trust: %v", err)
+		return
+	}
+	if _, err := b.TrustDevice(ctx, user.UUID, device.UUID); err != nil {
+		log.Printf("Failed to trust device: %v", err)
+	}
+}
+
+// resolveDeviceID returns the client's device identifier, falling back to one
+// derived from the user agent so that clients without IDs are still listed
+func resolveDeviceID(client ClientInfo) string {
+	if deviceID := strings.TrimSpace(client.DeviceID); deviceID != "" {
+		if strings.HasPrefix(deviceID, derivedDeviceIDPrefix) {
+			// Reserved prefix; keep client IDs from colliding with derived ones
+			return ""
+		}
+		return deviceID
+	}
+	if client.UserAgent == "" {
+		return ""
+	}
+	return derivedDeviceIDPrefix + hashToken(client.UserAgent)[:32]
+}
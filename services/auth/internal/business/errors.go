@@ -0,0 +1,69 @@
+package business
+
+import (
+	"errors"
+)
+
+// Business errors returned to the handler layer
+var (
+	ErrInvalidArgument    = errors.New("invalid argument")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrAccountDisabled    = errors.New("account is disabled")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrInvalidMFACode     = errors.New("invalid two-factor code")
+	ErrMFAAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnabled      = errors.New("two-factor authentication is not enabled")
+	ErrMFANotEnrolled     = errors.New("no pending two-factor enrollment")
+	ErrMFALocked          = errors.New("too many wrong two-factor codes; try again later")
+
+	ErrPasskeysDisabled     = errors.New("passkeys are not configured")
+	ErrInvalidChallenge     = errors.New("challenge is invalid, expired or already used")
+	ErrPasskeyNotFound      = errors.New("passkey not found")
+	ErrPasskeyExists        = errors.New("passkey is already registered")
+	ErrPasskeyVerification  = errors.New("passkey verification failed")
+	ErrPasskeyCloneDetected = errors.New("passkey signature counter regressed; credential disabled")
+	ErrLastLoginMethod      = errors.New("cannot remove the last remaining sign-in method")
+
+	ErrSessionNotFound = errors.New("session not found")
+	ErrRefreshConflict = errors.New("refresh token was just rotated by a concurrent request")
+
+	ErrDeviceNotFound     = errors.New("device not found")
+	ErrDeviceNotTrustable = errors.New("device has no client-provided identifier and cannot be trusted")
+
+	ErrLoginBlocked = errors.New("sign-in blocked as high risk")
+
+	ErrMagicLinkDeviceMismatch = errors.New("magic link must be opened on the device that requested it")
+
+	ErrProviderNotFound     = errors.New("sign-in provider not found or disabled")
+	ErrProviderExists       = errors.New("a provider with this name already exists")
+	ErrOAuthExchange        = errors.New("sign-in with the provider failed")
+	ErrOAuthEmailUnverified = errors.New("provider did not return a verified email address")
+	ErrOAuthAccountExists   = errors.New("an account with this email already exists; sign in and link the provider instead")
+
+	ErrProviderLinkNotFound  = errors.New("linked provider not found")
+	ErrProviderAlreadyLinked = errors.New("provider is already linked")
+	ErrReauthRequired        = errors.New("sign in again to confirm this change")
+
+	ErrOAuthClientNotFound          = errors.New("oauth client not found")
+	ErrOAuthClientExists            = errors.New("an oauth client with this client_id already exists")
+	ErrAuthorizationRequestNotFound = errors.New("authorization request not found, expired or already answered")
+	ErrClientNotAuthorized          = errors.New("client is not allowed to request service tokens")
+	ErrScopeNotAllowed              = errors.New("requested scope is not allowed for this client")
+
+	ErrAPIKeyNotFound     = errors.New("api key not found")
+	ErrInvalidAPIKey      = errors.New("invalid, expired or revoked api key")
+	ErrAPIKeyIPNotAllowed = errors.New("api key may not be used from this address")
+
+	ErrAlreadyVerified         = errors.New("this address is already verified")
+	ErrNoPhoneNumber           = errors.New("user has no phone number")
+	ErrVerificationCooldown    = errors.New("too many verification codes requested; try again later")
+	ErrInvalidVerificationCode = errors.New("invalid or expired verification code")
+
+	ErrNoPassword = errors.New("account has no password; request a password reset to set one")
+
+	ErrContactInUse = errors.New("this address is already used by another account")
+
+	ErrImpersonationNotAllowed = errors.New("not allowed to impersonate this user")
+	ErrImpersonatedSession     = errors.New("not available while impersonating a user")
+)
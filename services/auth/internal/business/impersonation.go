@@ -0,0 +1,234 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/your-project/services/auth/internal/events"
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// Keys in sessions.meta and login_logs.meta for impersonation sessions
+const (
+	sessionMetaImpersonatedBy      = "impersonated_by"
+	sessionMetaImpersonationReason = "impersonation_reason"
+)
+
+// maxImpersonationHistory bounds ListImpersonations
+const maxImpersonationHistory = 100
+
+// ImpersonateInput signs an admin in as another user
+type ImpersonateInput struct {
+	// AdminToken is the admin's own access token
+	AdminToken   string
+	TargetUserID string
+	// Reason is required and recorded on the target's login log
+	Reason string
+	Client ClientInfo
+}
+
+// ImpersonationResult is a short-lived access token for the target user. There
+// is no refresh token; once it expires the admin impersonates again.
+type ImpersonationResult struct {
+	AccessToken string
+	ExpiresAt   time.Time
+	SessionUUID string
+	Scope       string
+}
+
+// Impersonation is one past impersonation of a user, as shown to that user
+type Impersonation struct {
+	AdminID   string
+	Reason    string
+	SessionID string
+	StartedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Impersonate issues an access token for the target user to an admin listed in
+// ImpersonationAdmins. The token carries an act claim naming the admin and only
+// ImpersonationScopes, lasts ImpersonationTokenExpiry and cannot be refreshed.
+// Admins cannot impersonate each other, and impersonation cannot be chained.
+func (b *AuthBusiness) Impersonate(ctx context.Context, input ImpersonateInput) (*ImpersonationResult, error) {
+	reason, err := accountReason(input.Reason)
+	if err != nil {
+		return nil, err
+	}
+	if reason == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrInvalidArgument)
+	}
+	if strings.TrimSpace(input.TargetUserID) == "" {
+		return nil, fmt.Errorf("%w: target user is required", ErrInvalidArgument)
+	}
+
+	adminClaims, err := b.ValidateAccessToken(ctx, strings.TrimSpace(input.AdminToken))
+	if err != nil {
+		return nil, err
+	}
+	if adminClaims.Act != nil || adminClaims.ClientID != "" || adminClaims.APIKeyID != "" ||
+		!b.isImpersonationAdmin(adminClaims.Subject) {
+		return nil, ErrImpersonationNotAllowed
+	}
+	admin, err := b.getUser(ctx, adminClaims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if !admin.IsActive {
+		return nil, ErrAccountDisabled
+	}
+
+	target, err := b.getUser(ctx, input.TargetUserID)
+	if err != nil {
+		return nil, err
+	}
+	if target.ID == admin.ID {
+		return nil, fmt.Errorf("%w: cannot impersonate yourself", ErrInvalidArgument)
+	}
+	if b.isImpersonationAdmin(target.UUID) {
+		return nil, ErrImpersonationNotAllowed
+	}
+	if !target.IsActive {
+		return nil, ErrAccountDisabled
+	}
+
+	// The refresh token is never handed out, so the session ends with the token
+	discarded, err := generateOpaqueToken(32)
+	if err != nil {
+		return nil, err
+	}
+	now := b.now()
+	expiresAt := now.Add(b.cfg.ImpersonationTokenExpiry)
+	session := &models.Session{
+		UserID:           target.ID,
+		RefreshTokenHash: hashToken(discarded),
+		DeviceInfo:       input.Client.DeviceInfo,
+		IPAddress:        optionalString(input.Client.IPAddress),
+		UserAgent:        optionalString(input.Client.UserAgent),
+		ExpiresAt:        expiresAt,
+		Meta: map[string]interface{}{
+			sessionMetaImpersonatedBy:      admin.UUID,
+			sessionMetaImpersonationReason: reason,
+		},
+	}
+	if err := b.authRepo.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	scope := strings.Join(b.cfg.ImpersonationScopes, " ")
+	jti, err := generateOpaqueToken(16)
+	if err != nil {
+		return nil, err
+	}
+	claims := &TokenClaims{
+		Email:     target.Email,
+		Type:      TokenTypeAccess,
+		SessionID: session.UUID,
+		Scope:     scope,
+		Act:       &ActorClaim{Subject: admin.UUID},
+	}
+	claims.SetIssuer(b.cfg.JWTIssuer)
+	claims.SetSubject(target.UUID)
+	claims.SetIssuedAt(now)
+	claims.SetID(jti)
+	token, err := b.tokens.GenerateTokenWithExpiry(claims, b.cfg.ImpersonationTokenExpiry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign impersonation token: %w", err)
+	}
+
+	b.recordLoginEvent(ctx, target, &session.ID, models.EventImpersonationStarted, input.Client, "", map[string]interface{}{
+		sessionMetaImpersonatedBy:      admin.UUID,
+		sessionMetaImpersonationReason: reason,
+		"admin_email":                  admin.Email,
+		"session_id":                   session.UUID,
+		"expires_at":                   expiresAt.UTC().Format(time.RFC3339),
+	})
+	b.publishEvent(ctx, events.TypeUserImpersonated, target, map[string]interface{}{
+		"reason":     reason,
+		"expires_at": expiresAt.UTC(),
+	})
+
+	return &ImpersonationResult{
+		AccessToken: token,
+		ExpiresAt:   expiresAt,
+		SessionUUID: session.UUID,
+		Scope:       scope,
+	}, nil
+}
+
+// ListImpersonations returns the most recent times the user was impersonated,
+// newest first, so they can see who signed in as them and why
+func (b *AuthBusiness) ListImpersonations(ctx context.Context, userUUID string) ([]*Impersonation, error) {
+	user, err := b.getUser(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	success := true
+	entries, err := b.authRepo.ListLoginLogs(ctx, repository.LoginLogFilter{
+		UserID:     &user.ID,
+		EventTypes: []string{models.EventImpersonationStarted},
+		Success:    &success,
+		Limit:      maxImpersonationHistory,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	impersonations := make([]*Impersonation, 0, len(entries))
+	for _, entry := range entries {
+		impersonation := &Impersonation{StartedAt: entry.CreatedAt}
+		impersonation.AdminID, _ = entry.Meta[sessionMetaImpersonatedBy].(string)
+		impersonation.Reason, _ = entry.Meta[sessionMetaImpersonationReason].(string)
+		if expiresAt, ok := entry.Meta["expires_at"].(string); ok {
+			impersonation.ExpiresAt, _ = time.Parse(time.RFC3339, expiresAt)
+		}
+		impersonation.SessionID, _ = entry.Meta["session_id"].(string)
+		impersonations = append(impersonations, impersonation)
+	}
+	return impersonations, nil
+}
+
+func (b *AuthBusiness) isImpersonationAdmin(userUUID string) bool {
+	for _, admin := range b.cfg.ImpersonationAdmins {
+		if admin == userUUID {
+			return true
+		}
+	}
+	return false
+}
+
+// sessionImpersonator returns the admin UUID of an impersonation session, or ""
+func sessionImpersonator(session *models.Session) string {
+	admin, _ := session.Meta[sessionMetaImpersonatedBy].(string)
+	return admin
+}
+
+// requireOwnSession loads the caller's session for a sensitive account change:
+// changing how the user signs in, minting credentials for them, or deactivating
+// or deleting the account. The session must be the user's own and live, and
+// must not have been started by Impersonate.
+func (b *AuthBusiness) requireOwnSession(ctx context.Context, user *models.User, sessionUUID string) (*models.Session, error) {
+	sessionUUID = strings.TrimSpace(sessionUUID)
+	if sessionUUID == "" {
+		return nil, fmt.Errorf("%w: session is required", ErrInvalidArgument)
+	}
+	session, err := b.authRepo.GetSessionByUUID(ctx, sessionUUID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != user.ID || !session.IsActive || session.RevokedAt != nil ||
+		b.sessionTimeoutReason(session, b.now()) != "" {
+		return nil, ErrSessionNotFound
+	}
+	if sessionImpersonator(session) != "" {
+		return nil, ErrImpersonatedSession
+	}
+	return session, nil
+}
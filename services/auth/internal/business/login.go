@@ -0,0 +1,289 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/your-project/services/auth/internal/audit"
+	"github.com/your-project/services/auth/internal/config"
+	"github.com/your-project/services/auth/internal/events"
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// ClientInfo describes the client making an authentication request
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+	// DeviceID is a stable identifier the client generated and keeps for this device
+	DeviceID   string
+	DeviceInfo map[string]interface{}
+}
+
+// LoginInput is the first step of a password sign-in
+type LoginInput struct {
+	Email    string
+	Password string
+	Client   ClientInfo
+}
+
+// LoginResult is returned by Login and VerifyMFA.
+// When MFARequired is set, Tokens is nil and MFAToken must be exchanged via VerifyMFA.
+// When LinkRequired is set, Tokens is nil and the user has to sign in to the
+// existing account and redeem LinkToken with LinkProvider.
+// When VerificationRequired is set, Tokens is nil and the user has to verify
+// UnverifiedChannels with StartVerification before signing in again.
+type LoginResult struct {
+	User         *models.User
+	MFARequired  bool
+	MFAToken     string
+	MFAExpiresAt time.Time
+	Tokens       *TokenPair
+
+	LinkRequired  bool
+	LinkToken     string
+	LinkExpiresAt time.Time
+
+	VerificationRequired bool
+	UnverifiedChannels   []string
+}
+
+// VerifyMFAInput is the second step of a sign-in for users with two-factor enabled
+type VerifyMFAInput struct {
+	MFAToken string
+	Code     string
+	// TrustDevice skips the second factor on this device for DeviceTrustDuration
+	TrustDevice bool
+	Client      ClientInfo
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// compareDummyPassword spends the same time as a real bcrypt comparison so that
+// unknown emails cannot be distinguished by response time
+func compareDummyPassword(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
+// Login verifies a user's password. Users with a confirmed authenticator receive a
+// short-lived mfa_pending token instead of a session.
+func (b *AuthBusiness) Login(ctx context.Context, input LoginInput) (*LoginResult, error) {
+	email := strings.TrimSpace(input.Email)
+	if email == "" || input.Password == "" {
+		return nil, ErrInvalidArgument
+	}
+
+	user, err := b.authRepo.GetUserByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		compareDummyPassword(input.Password)
+		b.recordLoginEvent(ctx, nil, nil, models.EventLoginFailed, input.Client, "unknown_email", nil)
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if user.PasswordHash == "" {
+		// Passkey-only or OAuth-only account
+		compareDummyPassword(input.Password)
+		b.recordLoginEvent(ctx, user, nil, models.EventLoginFailed, input.Client, "no_password", nil)
+		return nil, ErrInvalidCredentials
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.Password)) != nil {
+		b.recordLoginEvent(ctx, user, nil, models.EventLoginFailed, input.Client, "invalid_password", nil)
+		return nil, ErrInvalidCredentials
+	}
+
+	if !user.IsActive {
+		b.recordLoginEvent(ctx, user, nil, models.EventLoginFailed, input.Client, "account_disabled", nil)
+		return nil, ErrAccountDisabled
+	}
+
+	return b.completeFirstFactor(ctx, user, input.Client, map[string]interface{}{"method": "password"})
+}
+
+// VerifyMFA exchanges an mfa_pending token plus a TOTP or recovery code for a
+// session. A token can be tried MFAMaxAttempts times and is burned once it
+// succeeds; a newer sign-in replaces it.
+func (b *AuthBusiness) VerifyMFA(ctx context.Context, input VerifyMFAInput) (*LoginResult, error) {
+	if input.MFAToken == "" || strings.TrimSpace(input.Code) == "" {
+		return nil, ErrInvalidArgument
+	}
+
+	claims, err := b.parseToken(input.MFAToken, TokenTypeMFAPending)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := b.authRepo.GetUserByUUID(ctx, claims.Subject)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrAccountDisabled
+	}
+
+	// Every code tried counts against the token, which is burned on success
+	pending, err := b.authRepo.TakeOTPAttempt(ctx, user.ID, models.OTPUseCaseTwoFactor, hashToken(claims.ID), b.cfg.MFAMaxAttempts)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	factor, err := b.verifySecondFactor(ctx, user, input.Code, input.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	used, err := b.authRepo.MarkOTPUsed(ctx, pending.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, ErrInvalidToken
+	}
+
+	result, err := b.completeLogin(ctx, user, input.Client, map[string]interface{}{"method": "password", "mfa": factor})
+	if err != nil {
+		return nil, err
+	}
+
+	if input.TrustDevice {
+		b.trustCurrentDevice(ctx, user, input.Client)
+	}
+
+	return result, nil
+}
+
+// completeFirstFactor finishes a single-factor sign-in. Users with a confirmed
+// authenticator receive an mfa_pending token; everyone else gets a session.
+// High-risk sign-ins are blocked, and step-up risk overrides device trust.
+func (b *AuthBusiness) completeFirstFactor(ctx context.Context, user *models.User, client ClientInfo, meta map[string]interface{}) (*LoginResult, error) {
+	risk, err := b.checkLoginRisk(ctx, user, client, meta)
+	if err != nil {
+		return nil, err
+	}
+	stepUp := risk.Decision == RiskDecisionStepUp
+
+	mfaEnabled, err := b.isMFAEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !mfaEnabled {
+		if stepUp {
+			// Nothing to step up to; the flag lets reviewers find these sign-ins
+			risk.Details["step_up"] = "unavailable"
+			meta["risk"] = risk.meta()
+		}
+		return b.completeLogin(ctx, user, client, meta)
+	}
+
+	if !stepUp && b.isTrustedDevice(ctx, user, client) {
+		meta["mfa"] = FactorTrustedDevice
+		return b.completeLogin(ctx, user, client, meta)
+	}
+
+	token, expiresAt, err := b.issueMFAToken(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	b.recordLoginEvent(ctx, user, nil, models.EventMFAChallenge, client, "", meta)
+
+	return &LoginResult{
+		User:         user,
+		MFARequired:  true,
+		MFAToken:     token,
+		MFAExpiresAt: expiresAt,
+	}, nil
+}
+
+// completeLogin starts a session and records the successful sign-in and the
+// device in one transaction. Under the block_login verification policy
+// unverified users get no session.
+func (b *AuthBusiness) completeLogin(ctx context.Context, user *models.User, client ClientInfo, meta map[string]interface{}) (*LoginResult, error) {
+	if b.cfg.VerificationPolicy == config.VerificationPolicyBlockLogin {
+		if missing := b.unverifiedChannels(user); len(missing) > 0 {
+			b.recordLoginEvent(ctx, user, nil, models.EventLoginFailed, client, "unverified", meta)
+			return &LoginResult{User: user, VerificationRequired: true, UnverifiedChannels: missing}, nil
+		}
+	}
+
+	var (
+		tokens    *TokenPair
+		newDevice *models.Device
+	)
+	err := b.authRepo.WithTx(ctx, func(ctx context.Context, _ repository.Store) error {
+		session, pair, err := b.startSession(ctx, user, client, meta)
+		if err != nil {
+			return err
+		}
+		tokens = pair
+
+		b.recordLoginEvent(ctx, user, &session.ID, models.EventLoginSuccess, client, "", meta)
+		newDevice = b.trackDevice(ctx, user, client)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if newDevice != nil {
+		b.notifyNewDevice(ctx, user, newDevice, client)
+	}
+
+	return &LoginResult{
+		User:   user,
+		Tokens: tokens,
+	}, nil
+}
+
+// recordLoginEvent writes a user-facing flow event to the audit trail
+func (b *AuthBusiness) recordLoginEvent(ctx context.Context, user *models.User, sessionID *int, eventType string, client ClientInfo, failureReason string, meta map[string]interface{}) {
+	event := audit.Event{
+		Type:          eventType,
+		SessionID:     sessionID,
+		IPAddress:     client.IPAddress,
+		UserAgent:     client.UserAgent,
+		DeviceInfo:    client.DeviceInfo,
+		FailureReason: failureReason,
+		Meta:          meta,
+	}
+	if user != nil {
+		event.UserID = &user.ID
+	}
+	b.audit.Record(ctx, event)
+}
+
+// publishEvent tells other services about an account change. Failures are
+// logged rather than returned so that the change itself is never undone.
+func (b *AuthBusiness) publishEvent(ctx context.Context, eventType string, user *models.User, data map[string]interface{}) {
+	err := b.events.Publish(ctx, events.Event{
+		Type:       eventType,
+		UserID:     user.UUID,
+		Email:      user.Email,
+		OccurredAt: b.now().UTC(),
+		Data:       data,
+	})
+	if err != nil {
+		log.Printf("Failed to publish %s event: %v", eventType, err)
+	}
+}
@@ -0,0 +1,187 @@
+package business
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/your-project/services/auth/internal/mailer"
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// RequestMagicLinkInput starts a passwordless sign-in
+type RequestMagicLinkInput struct {
+	Email string
+	// DeviceID is the requesting client's stable device identifier, if it has one
+	DeviceID string
+	Client   ClientInfo
+}
+
+// RequestMagicLinkResult is returned whether or not the email belongs to an account,
+// so the response cannot be used to enumerate users.
+type RequestMagicLinkResult struct {
+	// BindingToken is kept by the requesting client and presented together with
+	// the emailed token to prove the link is opened on the same device
+	BindingToken string
+	ExpiresAt    time.Time
+}
+
+// ConsumeMagicLinkInput redeems the token from an emailed link
+type ConsumeMagicLinkInput struct {
+	Token        string
+	BindingToken string
+	DeviceID     string
+	Client       ClientInfo
+}
+
+// RequestMagicLink emails a single-use sign-in link to the address if it belongs
+// to an active account. The token is generated for every request and the email
+// is sent in the background, so the response time does not reveal whether the
+// account exists.
+func (b *AuthBusiness) RequestMagicLink(ctx context.Context, input RequestMagicLinkInput) (*RequestMagicLinkResult, error) {
+	email := strings.TrimSpace(input.Email)
+	if email == "" {
+		return nil, ErrInvalidArgument
+	}
+
+	bindingToken, err := generateOpaqueToken(32)
+	if err != nil {
+		return nil, err
+	}
+	token, err := generateOpaqueToken(32)
+	if err != nil {
+		return nil, err
+	}
+	bindingHash := hashToken(bindingToken)
+	tokenHash := hashToken(token)
+
+	result := &RequestMagicLinkResult{
+		BindingToken: bindingToken,
+		ExpiresAt:    b.now().Add(b.cfg.MagicLinkExpiry),
+	}
+	meta := map[string]interface{}{"method": "magic_link"}
+
+	user, err := b.authRepo.GetUserByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		b.recordLoginEvent(ctx, nil, nil, models.EventMagicLinkRequested, input.Client, "unknown_email", meta)
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		b.recordLoginEvent(ctx, user, nil, models.EventMagicLinkRequested, input.Client, "account_disabled", meta)
+		return result, nil
+	}
+
+	actionToken := &models.ActionToken{
+		UserID:      user.ID,
+		Purpose:     models.TokenPurposeMagicLink,
+		TokenHash:   tokenHash,
+		BindingHash: &bindingHash,
+		DeviceID:    optionalString(strings.TrimSpace(input.DeviceID)),
+		IPAddress:   optionalString(input.Client.IPAddress),
+		UserAgent:   optionalString(input.Client.UserAgent),
+		ExpiresAt:   result.ExpiresAt,
+	}
+	if err := b.authRepo.CreateActionToken(ctx, actionToken); err != nil {
+		return nil, err
+	}
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Your sign-in link",
+		Body: fmt.Sprintf("Use the link below to sign in. It expires in %s and can only be used once.\n\n%s\n\nIf you did not request this, you can ignore this email.",
+			b.cfg.MagicLinkExpiry, tokenLinkURL(b.cfg.MagicLinkURL, token)),
+	}
+	go func(ctx context.Context) {
+		// The link is already stored; the user can ask for another if this fails
+		if err := b.mailer.Send(ctx, msg); err != nil {
+			log.Printf("Failed to send magic link: %v", err)
+		}
+	}(context.WithoutCancel(ctx))
+
+	b.recordLoginEvent(ctx, user, nil, models.EventMagicLinkRequested, input.Client, "", meta)
+
+	return result, nil
+}
+
+// ConsumeMagicLink redeems a magic link token. The token is burned before any
+// other check so that a failed attempt cannot be retried. Users with two-factor
+// enabled receive an mfa_pending token as with a password sign-in.
+func (b *AuthBusiness) ConsumeMagicLink(ctx context.Context, input ConsumeMagicLinkInput) (*LoginResult, error) {
+	if strings.TrimSpace(input.Token) == "" {
+		return nil, ErrInvalidArgument
+	}
+
+	token, err := b.authRepo.ConsumeActionToken(ctx, hashToken(strings.TrimSpace(input.Token)), models.TokenPurposeMagicLink)
+	if errors.Is(err, repository.ErrNotFound) {
+		b.recordLoginEvent(ctx, nil, nil, models.EventLoginFailed, input.Client, "invalid_magic_link", map[string]interface{}{"method": "magic_link"})
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := b.authRepo.GetUserByID(ctx, token.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	deviceBound, err := b.checkMagicLinkBinding(token, input)
+	meta := map[string]interface{}{"method": "magic_link", "device_bound": deviceBound}
+	if err != nil {
+		b.recordLoginEvent(ctx, user, nil, models.EventLoginFailed, input.Client, "device_mismatch", meta)
+		return nil, err
+	}
+
+	if !user.IsActive {
+		b.recordLoginEvent(ctx, user, nil, models.EventLoginFailed, input.Client, "account_disabled", meta)
+		return nil, ErrAccountDisabled
+	}
+
+	return b.completeFirstFactor(ctx, user, input.Client, meta)
+}
+
+// checkMagicLinkBinding reports whether the link is being redeemed by the device
+// that requested it. A binding token or device ID that is present but different
+// always fails; a missing one only fails when same-device redemption is required.
+func (b *AuthBusiness) checkMagicLinkBinding(token *models.ActionToken, input ConsumeMagicLinkInput) (bool, error) {
+	deviceID := strings.TrimSpace(input.DeviceID)
+	if token.DeviceID != nil && deviceID != "" && *token.DeviceID != deviceID {
+		return false, ErrMagicLinkDeviceMismatch
+	}
+
+	if token.BindingHash != nil && input.BindingToken != "" {
+		if subtle.ConstantTimeCompare([]byte(hashToken(input.BindingToken)), []byte(*token.BindingHash)) != 1 {
+			return false, ErrMagicLinkDeviceMismatch
+		}
+		return true, nil
+	}
+
+	if b.cfg.MagicLinkRequireSameDevice {
+		return false, ErrMagicLinkDeviceMismatch
+	}
+	return false, nil
+}
+
+// tokenLinkURL appends the token to a configured base URL as ?token=
+func tokenLinkURL(base, token string) string {
+	u, err := url.Parse(base)
+	if err != nil {
+		return base + "?token=" + url.QueryEscape(token)
+	}
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
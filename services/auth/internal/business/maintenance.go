@@ -0,0 +1,103 @@
+package business
+
+import (
+	"context"
+	"expvar"
+	"time"
+
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// Maintenance metrics, published through expvar as "auth_maintenance". runs
+// counts the runs that held the lock, skipped_runs those that found it taken
+// and failed_runs those that returned an error. rows_removed_total and
+// last_run_rows_removed hold rows deleted per table, and
+// last_run_duration_seconds times the latest run that held the lock.
+var (
+	maintenanceMetrics     = expvar.NewMap("auth_maintenance")
+	maintenanceRowsRemoved = new(expvar.Map).Init()
+)
+
+func init() {
+	maintenanceMetrics.Set("rows_removed_total", maintenanceRowsRemoved)
+}
+
+// MaintenanceResult summarises one RunMaintenance run
+type MaintenanceResult struct {
+	// Skipped is set when another replica held the maintenance lock
+	Skipped bool
+	// Removed counts the rows deleted per table
+	Removed map[string]int64
+}
+
+// RunMaintenance deletes rows that expired, or were revoked or used, longer ago
+// than their table's MAINTENANCE_RETENTION. Rows go in
+// batches of MAINTENANCE_BATCH_SIZE so no statement holds locks for long. Only
+// one replica runs it at a time; the others return a skipped result.
+func (b *AuthBusiness) RunMaintenance(ctx context.Context) (*MaintenanceResult, error) {
+	unlock, ok, err := b.authRepo.TryLockMaintenance(ctx)
+	if err != nil {
+		maintenanceMetrics.Add("failed_runs", 1)
+		return nil, err
+	}
+	if !ok {
+		maintenanceMetrics.Add("skipped_runs", 1)
+		return &MaintenanceResult{Skipped: true}, nil
+	}
+	defer unlock()
+
+	started := time.Now()
+	now := b.now()
+	result := &MaintenanceResult{Removed: map[string]int64{}}
+	for _, table := range repository.MaintenanceTables {
+		retention := b.cfg.MaintenanceRetention[table]
+		if retention <= 0 {
+			continue
+		}
+		removed, err := b.pruneTable(ctx, table, now.Add(-retention))
+		result.Removed[table] = removed
+		if err != nil {
+			recordMaintenanceRun(result, time.Since(started), err)
+			return result, err
+		}
+	}
+
+	recordMaintenanceRun(result, time.Since(started), nil)
+	return result, nil
+}
+
+// pruneTable deletes a table's rows that died before cutoff, one batch at a time
+func (b *AuthBusiness) pruneTable(ctx context.Context, table string, cutoff time.Time) (int64, error) {
+	var total int64
+	for {
+		removed, err := b.authRepo.PruneExpiredRows(ctx, table, cutoff, b.cfg.MaintenanceBatchSize)
+		total += removed
+		if err != nil {
+			return total, err
+		}
+		if removed < int64(b.cfg.MaintenanceBatchSize) {
+			return total, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+	}
+}
+
+// recordMaintenanceRun publishes the outcome of a run that held the lock
+func recordMaintenanceRun(result *MaintenanceResult, duration time.Duration, err error) {
+	lastRun := new(expvar.Map).Init()
+	for table, removed := range result.Removed {
+		lastRun.Add(table, removed)
+		maintenanceRowsRemoved.Add(table, removed)
+	}
+
+	maintenanceMetrics.Add("runs", 1)
+	if err != nil {
+		maintenanceMetrics.Add("failed_runs", 1)
+	}
+	maintenanceMetrics.Set("last_run_rows_removed", lastRun)
+	seconds := new(expvar.Float)
+	seconds.Set(duration.Seconds())
+	maintenanceMetrics.Set("last_run_duration_seconds", seconds)
+}
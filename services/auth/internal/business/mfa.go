@@ -0,0 +1,375 @@
+package business
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/your-project/pkgs/totp"
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// Second factor identifiers recorded in session and login log metadata
+const (
+	FactorTOTP         = "totp"
+	FactorRecoveryCode = "recovery_code"
+	// FactorTrustedDevice marks sign-ins where a trusted device stood in for the second factor
+	FactorTrustedDevice = "trusted_device"
+)
+
+// recoveryCodeCount is the number of recovery codes issued per enrollment
+const recoveryCodeCount = 10
+
+// recoveryCodeAlphabet is Crockford's base32 alphabet, which omits easily confused
+// letters (i, l, o, u); 32 symbols keep byte-to-symbol mapping unbiased
+const recoveryCodeAlphabet = "0123456789abcdefghjkmnpqrstvwxyz"
+
+// TOTPEnrollment is returned when a user starts authenticator enrollment
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// EnrollTOTP generates a new pending authenticator secret for the user signed
+// in with sessionUUID. The enrollment only takes effect after ConfirmTOTP succeeds.
+func (b *AuthBusiness) EnrollTOTP(ctx context.Context, userUUID, sessionUUID string) (*TOTPEnrollment, error) {
+	user, err := b.getUser(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := b.requireOwnSession(ctx, user, sessionUUID); err != nil {
+		return nil, err
+	}
+
+	enabled, err := b.isMFAEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := b.authRepo.CreatePendingTOTPCredential(ctx, user.ID, secret); err != nil {
+		return nil, err
+	}
+
+	return &TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.KeyURI(b.cfg.TOTPIssuer, user.Email, secret, totp.DefaultOptions()),
+	}, nil
+}
+
+// ConfirmTOTP activates a pending enrollment using the first code from the
+// authenticator and returns the plaintext recovery codes, which are shown only once
+func (b *AuthBusiness) ConfirmTOTP(ctx context.Context, userUUID, code string) ([]string, error) {
+	user, err := b.getUser(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := b.authRepo.GetTOTPCredential(ctx, user.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrMFANotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if credential.IsConfirmed() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	step, ok, err := totp.Validate(credential.Secret, code, b.now(), totp.DefaultOptions())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.authRepo.ConfirmTOTPCredential(ctx, credential.ID, step, hashes); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrMFANotEnrolled
+		}
+		return nil, err
+	}
+
+	b.recordLoginEvent(ctx, user, nil, models.EventMFAEnabled, ClientInfo{}, "", map[string]interface{}{"factor": FactorTOTP})
+
+	return codes, nil
+}
+
+// DisableTOTP removes the authenticator and recovery codes after verifying a
+// current second factor. Impersonation sessions are refused.
+func (b *AuthBusiness) DisableTOTP(ctx context.Context, userUUID, sessionUUID, code string) error {
+	user, err := b.getUser(ctx, userUUID)
+	if err != nil {
+		return err
+	}
+	if _, err := b.requireOwnSession(ctx, user, sessionUUID); err != nil {
+		return err
+	}
+
+	factor, err := b.verifySecondFactor(ctx, user, code, ClientInfo{})
+	if err != nil {
+		return err
+	}
+
+	if err := b.authRepo.DeleteTOTPCredential(ctx, user.ID); err != nil {
+		return err
+	}
+
+	b.recordLoginEvent(ctx, user, nil, models.EventMFADisabled, ClientInfo{}, "", map[string]interface{}{"factor": FactorTOTP, "verified_with": factor})
+
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after verifying a TOTP code
+func (b *AuthBusiness) RegenerateRecoveryCodes(ctx context.Context, userUUID, code string) ([]string, error) {
+	user, err := b.getUser(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := b.confirmedTOTPCredential(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.checkMFALockout(ctx, user); err != nil {
+		return nil, err
+	}
+	if err := b.verifyTOTP(ctx, credential, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			b.recordLoginEvent(ctx, user, nil, models.EventMFAFailed, ClientInfo{}, "invalid_code", nil)
+		}
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.authRepo.ReplaceRecoveryCodes(ctx, user.ID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// GetMFAStatus reports whether the user has an active authenticator and how many recovery codes remain
+func (b *AuthBusiness) GetMFAStatus(ctx context.Context, userUUID string) (bool, int, error) {
+	user, err := b.getUser(ctx, userUUID)
+	if err != nil {
+		return false, 0, err
+	}
+
+	enabled, err := b.isMFAEnabled(ctx, user.ID)
+	if err != nil || !enabled {
+		return false, 0, err
+	}
+
+	remaining, err := b.authRepo.CountUnusedRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return false, 0, err
+	}
+
+	return true, remaining, nil
+}
+
+// verifySecondFactor accepts either a TOTP code or a recovery code and returns
+// which one matched. Wrong codes are logged as mfa_failed, and every code is
+// refused while checkMFALockout does.
+func (b *AuthBusiness) verifySecondFactor(ctx context.Context, user *models.User, code string, client ClientInfo) (string, error) {
+	if err := b.checkMFALockout(ctx, user); err != nil {
+		return "", err
+	}
+
+	factor, err := b.matchSecondFactor(ctx, user, code)
+	if errors.Is(err, ErrInvalidMFACode) {
+		b.recordLoginEvent(ctx, user, nil, models.EventMFAFailed, client, "invalid_code", nil)
+	}
+	return factor, err
+}
+
+// checkMFALockout refuses second-factor checks for a user with MFAMaxFailures
+// wrong codes within MFAFailureWindow. It counts across mfa_pending tokens, so
+// signing in again does not buy more guesses.
+func (b *AuthBusiness) checkMFALockout(ctx context.Context, user *models.User) error {
+	failures, err := b.authRepo.CountLoginFailures(ctx, user.ID, []string{models.EventMFAFailed},
+		b.now().Add(-b.cfg.MFAFailureWindow))
+	if err != nil {
+		return err
+	}
+	if failures >= b.cfg.MFAMaxFailures {
+		return ErrMFALocked
+	}
+	return nil
+}
+
+// issueMFAToken signs an mfa_pending token and stores its jti as a two_factor
+// code, so VerifyMFA can count the attempts made with it and burn it once used
+func (b *AuthBusiness) issueMFAToken(ctx context.Context, user *models.User, client ClientInfo) (string, time.Time, error) {
+	jti, err := generateOpaqueToken(16)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	token, expiresAt, err := b.signToken(user, TokenTypeMFAPending, jti, "", b.cfg.MFATokenExpiry)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if err := b.authRepo.CreateOTP(ctx, &models.OTPToken{
+		UserID:    user.ID,
+		CodeHash:  hashToken(jti),
+		UseCase:   models.OTPUseCaseTwoFactor,
+		IPAddress: optionalString(client.IPAddress),
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// matchSecondFactor checks a TOTP or recovery code without counting failures
+func (b *AuthBusiness) matchSecondFactor(ctx context.Context, user *models.User, code string) (string, error) {
+	credential, err := b.confirmedTOTPCredential(ctx, user.ID)
+	if err != nil {
+		return "", err
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == totp.DefaultDigits && isNumeric(code) {
+		if err := b.verifyTOTP(ctx, credential, code); err != nil {
+			return "", err
+		}
+		return FactorTOTP, nil
+	}
+
+	consumed, err := b.authRepo.ConsumeRecoveryCode(ctx, user.ID, hashToken(NormalizeRecoveryCode(code)))
+	if err != nil {
+		return "", err
+	}
+	if !consumed {
+		return "", ErrInvalidMFACode
+	}
+
+	return FactorRecoveryCode, nil
+}
+
+// verifyTOTP validates a code within the drift window and rejects codes whose
+// time step has already been used
+func (b *AuthBusiness) verifyTOTP(ctx context.Context, credential *models.TOTPCredential, code string) error {
+	step, ok, err := totp.Validate(credential.Secret, code, b.now(), totp.DefaultOptions())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	advanced, err := b.authRepo.AdvanceTOTPStep(ctx, credential.ID, step)
+	if err != nil {
+		return err
+	}
+	if !advanced {
+		return ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+func (b *AuthBusiness) confirmedTOTPCredential(ctx context.Context, userID int) (*models.TOTPCredential, error) {
+	credential, err := b.authRepo.GetTOTPCredential(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrMFANotEnabled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !credential.IsConfirmed() {
+		return nil, ErrMFANotEnabled
+	}
+	return credential, nil
+}
+
+func (b *AuthBusiness) isMFAEnabled(ctx context.Context, userID int) (bool, error) {
+	_, err := b.confirmedTOTPCredential(ctx, userID)
+	if errors.Is(err, ErrMFANotEnabled) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// getUser loads an active user by UUID
+func (b *AuthBusiness) getUser(ctx context.Context, userUUID string) (*models.User, error) {
+	if userUUID == "" {
+		return nil, ErrInvalidArgument
+	}
+	user, err := b.authRepo.GetUserByUUID(ctx, userUUID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+// generateRecoveryCodes returns plaintext codes formatted as "xxxxx-xxxxx" and their hashes
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		buf := make([]byte, 10)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		for j := range buf {
+			buf[j] = recoveryCodeAlphabet[int(buf[j])%len(recoveryCodeAlphabet)]
+		}
+
+		code := string(buf[:5]) + "-" + string(buf[5:])
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(NormalizeRecoveryCode(code)))
+	}
+
+	return codes, hashes, nil
+}
+
+// NormalizeRecoveryCode lowercases a recovery code and strips separators and whitespace
+func NormalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '-' || r == ' ':
+			return -1
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		default:
+			return r
+		}
+	}, code)
+}
+
+func isNumeric(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,472 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/your-project/pkgs/oauth2"
+	"github.com/your-project/pkgs/oidc"
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// BeginOAuthInput starts a sign-in with an external provider. With UserID set
+// the flow links the provider account to that user instead (see LinkProvider).
+type BeginOAuthInput struct {
+	Provider string
+	UserID   string
+	Client   ClientInfo
+}
+
+// BeginOAuthResult carries the URL the client is redirected to. State is echoed
+// back by the provider and must be passed unchanged to CompleteOAuth.
+type BeginOAuthResult struct {
+	AuthorizationURL string
+	State            string
+	ExpiresAt        time.Time
+}
+
+// CompleteOAuthInput carries the parameters the provider redirected back with
+type CompleteOAuthInput struct {
+	Provider string
+	Code     string
+	State    string
+	// User is the JSON "user" field Apple posts with the first form_post callback;
+	// it is the only place Apple sends the user's name
+	User   string
+	Client ClientInfo
+}
+
+// oauthProfile is a provider's userinfo response normalized through the
+// field mappings in providers.config
+type oauthProfile struct {
+	ID            string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Raw           map[string]interface{}
+}
+
+// BeginOAuth builds the provider's authorization URL. The state, nonce and PKCE
+// verifier are stored server side; only the state and S256 challenge leave the service.
+func (b *AuthBusiness) BeginOAuth(ctx context.Context, input BeginOAuthInput) (*BeginOAuthResult, error) {
+	name := strings.ToLower(strings.TrimSpace(input.Provider))
+	if name == "" {
+		return nil, ErrInvalidArgument
+	}
+
+	var userID *int
+	if input.UserID != "" {
+		user, err := b.getUser(ctx, input.UserID)
+		if err != nil {
+			return nil, err
+		}
+		userID = &user.ID
+	}
+
+	provider, err := b.getEnabledProvider(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := oauth2.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := oauth2.RandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := oauth2.GenerateVerifier()
+	if err != nil {
+		return nil, err
+	}
+
+	conf := b.oauthConfig(provider)
+	params := url.Values{}
+	if hasScope(conf.Scopes, "openid") || isOIDCProvider(provider) {
+		params.Set("nonce", nonce)
+	}
+	for key, value := range providerStringMap(provider.Config, "auth_params") {
+		params.Set(key, value)
+	}
+	authURL, err := conf.AuthCodeURL(state, oauth2.S256Challenge(verifier), params)
+	if err != nil {
+		return nil, fmt.Errorf("provider %s: %w", provider.Name, err)
+	}
+
+	record := &models.OAuthState{
+		ProviderID:   provider.ID,
+		UserID:       userID,
+		StateHash:    hashToken(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		RedirectURI:  conf.RedirectURL,
+		DeviceID:     optionalString(strings.TrimSpace(input.Client.DeviceID)),
+		IPAddress:    optionalString(input.Client.IPAddress),
+		UserAgent:    optionalString(input.Client.UserAgent),
+		ExpiresAt:    b.now().Add(b.cfg.OAuthStateExpiry),
+	}
+	if err := b.authRepo.CreateOAuthState(ctx, record); err != nil {
+		return nil, err
+	}
+
+	return &BeginOAuthResult{
+		AuthorizationURL: authURL,
+		State:            state,
+		ExpiresAt:        record.ExpiresAt,
+	}, nil
+}
+
+// CompleteOAuth redeems the authorization code, signs in the user linked to the
+// provider account, or creates a new user when the provider vouches for an email
+// that is not registered yet. An email that already belongs to an account is
+// never linked implicitly: the result asks for LinkRequired and the owner has to
+// sign in again and confirm the link with LinkProvider.
+func (b *AuthBusiness) CompleteOAuth(ctx context.Context, input CompleteOAuthInput) (*LoginResult, error) {
+	name := strings.ToLower(strings.TrimSpace(input.Provider))
+	if name == "" || strings.TrimSpace(input.Code) == "" || strings.TrimSpace(input.State) == "" {
+		return nil, ErrInvalidArgument
+	}
+	meta := map[string]interface{}{"method": "oauth", "provider": name}
+
+	callback, err := b.redeemOAuthCallback(ctx, name, input.Code, input.State, input.User, input.Client, meta)
+	if err != nil {
+		return nil, err
+	}
+	if callback.state.UserID != nil {
+		// States created for linking cannot be used to sign in
+		b.recordLoginEvent(ctx, nil, nil, models.EventLoginFailed, input.Client, "invalid_oauth_state", meta)
+		return nil, ErrInvalidChallenge
+	}
+	provider, token, profile := callback.provider, callback.token, callback.profile
+
+	link, err := b.authRepo.GetUserProviderByIdentity(ctx, provider.ID, profile.ID)
+	if err == nil {
+		return b.completeLinkedOAuth(ctx, link, token, profile, input.Client, meta)
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	if profile.Email == "" || !profile.EmailVerified {
+		b.recordLoginEvent(ctx, nil, nil, models.EventLoginFailed, input.Client, "oauth_email_unverified", meta)
+		return nil, ErrOAuthEmailUnverified
+	}
+
+	existing, err := b.authRepo.GetUserByEmail(ctx, profile.Email)
+	if err == nil {
+		return b.requireLinkConfirmation(ctx, existing, provider, profile, input.Client, meta)
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	user := &models.User{
+		Email:      profile.Email,
+		IsVerified: true,
+	}
+	link = newUserProvider(provider.ID, token, profile)
+	if err := b.authRepo.CreateOAuthUser(ctx, user, link); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			// A concurrent callback registered the same email or provider account
+			return nil, ErrOAuthAccountExists
+		}
+		return nil, err
+	}
+
+	meta["new_user"] = true
+	return b.completeFirstFactor(ctx, user, input.Client, meta)
+}
+
+// oauthCallback is a redeemed authorization response
+type oauthCallback struct {
+	provider *models.Provider
+	state    *models.OAuthState
+	token    *oauth2.Token
+	profile  *oauthProfile
+}
+
+// redeemOAuthCallback burns the state, exchanges the code with the stored PKCE
+// verifier and resolves the provider account. Failures are logged as login_failed.
+func (b *AuthBusiness) redeemOAuthCallback(ctx context.Context, name, code, stateValue, user string, client ClientInfo, meta map[string]interface{}) (*oauthCallback, error) {
+	// The state is burned before any other check so that it cannot be replayed
+	state, err := b.authRepo.ConsumeOAuthState(ctx, hashToken(strings.TrimSpace(stateValue)))
+	if errors.Is(err, repository.ErrNotFound) {
+		b.recordLoginEvent(ctx, nil, nil, models.EventLoginFailed, client, "invalid_oauth_state", meta)
+		return nil, ErrInvalidChallenge
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := b.getEnabledProvider(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if provider.ID != state.ProviderID {
+		b.recordLoginEvent(ctx, nil, nil, models.EventLoginFailed, client, "invalid_oauth_state", meta)
+		return nil, ErrInvalidChallenge
+	}
+	deviceID := strings.TrimSpace(client.DeviceID)
+	if state.DeviceID != nil && deviceID != "" && *state.DeviceID != deviceID {
+		b.recordLoginEvent(ctx, nil, nil, models.EventLoginFailed, client, "device_mismatch", meta)
+		return nil, ErrInvalidChallenge
+	}
+
+	conf := b.oauthConfig(provider)
+	conf.RedirectURL = state.RedirectURI
+	if conf.ClientSecret, err = b.clientSecret(provider); err != nil {
+		return nil, err
+	}
+	token, err := conf.Exchange(ctx, b.httpClient, strings.TrimSpace(code), state.CodeVerifier)
+	if err != nil {
+		log.Printf("OAuth code exchange with %s failed: %v", provider.Name, err)
+		b.recordLoginEvent(ctx, nil, nil, models.EventLoginFailed, client, "oauth_exchange_failed", meta)
+		return nil, ErrOAuthExchange
+	}
+
+	var profile *oauthProfile
+	if isOIDCProvider(provider) {
+		profile, err = b.oidcProfile(ctx, provider, token, state.Nonce)
+		if errors.Is(err, oidc.ErrInvalidIDToken) {
+			log.Printf("ID token from %s rejected: %v", provider.Name, err)
+			b.recordLoginEvent(ctx, nil, nil, models.EventLoginFailed, client, "invalid_id_token", meta)
+			return nil, ErrOAuthExchange
+		}
+	} else {
+		profile, err = b.fetchOAuthProfile(ctx, provider, token)
+	}
+	if err != nil {
+		log.Printf("OAuth profile from %s unavailable: %v", provider.Name, err)
+		b.recordLoginEvent(ctx, nil, nil, models.EventLoginFailed, client, "oauth_profile_failed", meta)
+		return nil, ErrOAuthExchange
+	}
+	applyFormPostUser(profile, user)
+
+	return &oauthCallback{provider: provider, state: state, token: token, profile: profile}, nil
+}
+
+// completeLinkedOAuth signs in the owner of an existing provider link
+func (b *AuthBusiness) completeLinkedOAuth(ctx context.Context, link *models.UserProvider, token *oauth2.Token, profile *oauthProfile, client ClientInfo, meta map[string]interface{}) (*LoginResult, error) {
+	user, err := b.authRepo.GetUserByID(ctx, link.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		b.recordLoginEvent(ctx, user, nil, models.EventLoginFailed, client, "account_disabled", meta)
+		return nil, ErrAccountDisabled
+	}
+
+	updated := newUserProvider(link.ProviderID, token, profile)
+	updated.ID = link.ID
+	if err := b.authRepo.RecordUserProviderLogin(ctx, updated, b.now()); err != nil {
+		return nil, err
+	}
+
+	return b.completeFirstFactor(ctx, user, client, meta)
+}
+
+// getEnabledProvider loads a provider that is enabled and has client credentials
+func (b *AuthBusiness) getEnabledProvider(ctx context.Context, name string) (*models.Provider, error) {
+	provider, err := b.providerByName(ctx, name)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrProviderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !provider.IsActive || !provider.IsEnabled || provider.ClientID == "" {
+		return nil, ErrProviderNotFound
+	}
+	return provider, nil
+}
+
+// oauthConfig builds the client configuration for a provider. The provider's own
+// redirect_uri takes precedence over the service-wide OAUTH_REDIRECT_URL.
+func (b *AuthBusiness) oauthConfig(provider *models.Provider) oauth2.Config {
+	redirectURL := strings.ReplaceAll(b.cfg.OAuthRedirectURL, "{provider}", provider.Name)
+	if provider.RedirectURI != nil && *provider.RedirectURI != "" {
+		redirectURL = *provider.RedirectURI
+	}
+	var scopes []string
+	if provider.Scopes != nil {
+		scopes = strings.Fields(*provider.Scopes)
+	}
+
+	return oauth2.Config{
+		ClientID:     provider.ClientID,
+		ClientSecret: provider.ClientSecret,
+		AuthURL:      provider.AuthorizationURL,
+		TokenURL:     provider.TokenURL,
+		RedirectURL:  redirectURL,
+		Scopes:       scopes,
+		AuthStyle:    providerString(provider.Config, "token_auth_style", oauth2.AuthStyleClientSecretPost),
+	}
+}
+
+// fetchOAuthProfile calls the provider's userinfo endpoint and maps the response
+// onto an oauthProfile. Field names default to OpenID Connect claims and can be
+// overridden per provider through providers.config.
+func (b *AuthBusiness) fetchOAuthProfile(ctx context.Context, provider *models.Provider, token *oauth2.Token) (*oauthProfile, error) {
+	if provider.UserinfoURL == nil || *provider.UserinfoURL == "" {
+		return nil, fmt.Errorf("provider %s has no userinfo endpoint", provider.Name)
+	}
+
+	params := url.Values{}
+	for key, value := range providerStringMap(provider.Config, "userinfo_params") {
+		params.Set(key, value)
+	}
+	value, err := oauth2.FetchJSON(ctx, b.httpClient, *provider.UserinfoURL, token.AccessToken, params)
+	if err != nil {
+		return nil, err
+	}
+	raw, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("userinfo response is not an object")
+	}
+
+	profile := mapOAuthProfile(provider.Config, raw,
+		providerString(provider.Config, "id_field", "sub"),
+		providerString(provider.Config, "email_field", "email"),
+		providerString(provider.Config, "email_verified_field", "email_verified"),
+		providerString(provider.Config, "name_field", "name"))
+	if profile.ID == "" {
+		return nil, fmt.Errorf("userinfo response has no account identifier")
+	}
+
+	// Providers such as GitHub only expose the verified primary address separately
+	if emailsURL := providerString(provider.Config, "emails_url", ""); emailsURL != "" && !profile.EmailVerified {
+		email, err := b.fetchPrimaryEmail(ctx, emailsURL, token.AccessToken)
+		if err != nil {
+			return nil, err
+		}
+		if email != "" {
+			profile.Email = email
+			profile.EmailVerified = true
+		}
+	}
+
+	return profile, nil
+}
+
+// mapOAuthProfile reads the identity fields of a userinfo response or ID token
+func mapOAuthProfile(config, raw map[string]interface{}, idField, emailField, verifiedField, nameField string) *oauthProfile {
+	profile := &oauthProfile{
+		ID:    claimString(raw, idField),
+		Email: strings.TrimSpace(claimString(raw, emailField)),
+		Name:  claimString(raw, nameField),
+		Raw:   raw,
+	}
+	// Some providers only return addresses they have verified but do not say so
+	if verified, ok := config["email_verified"].(bool); ok && verified {
+		profile.EmailVerified = profile.Email != ""
+	} else {
+		profile.EmailVerified = claimBool(raw, verifiedField)
+	}
+	return profile
+}
+
+// fetchPrimaryEmail returns the verified primary address from a list of
+// {email, primary, verified} objects
+func (b *AuthBusiness) fetchPrimaryEmail(ctx context.Context, endpoint, accessToken string) (string, error) {
+	value, err := oauth2.FetchJSON(ctx, b.httpClient, endpoint, accessToken, nil)
+	if err != nil {
+		return "", err
+	}
+	entries, ok := value.([]interface{})
+	if !ok {
+		return "", fmt.Errorf("email list response is not an array")
+	}
+	for _, entry := range entries {
+		fields, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if claimBool(fields, "primary") && claimBool(fields, "verified") {
+			return strings.TrimSpace(claimString(fields, "email")), nil
+		}
+	}
+	return "", nil
+}
+
+// newUserProvider builds a provider link from a token response and profile
+func newUserProvider(providerID int, token *oauth2.Token, profile *oauthProfile) *models.UserProvider {
+	link := &models.UserProvider{
+		ProviderID:     providerID,
+		ProviderUserID: profile.ID,
+		AccessToken:    optionalString(token.AccessToken),
+		RefreshToken:   optionalString(token.RefreshToken),
+		TokenType:      optionalString(token.TokenType),
+		ProfileData:    profile.Raw,
+		Meta:           linkMeta(profile),
+	}
+	if !token.Expiry.IsZero() {
+		expiry := token.Expiry
+		link.ExpiresAt = &expiry
+	}
+	return link
+}
+
+// hasScope reports whether scope is among scopes
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// providerString reads a string setting from providers.config
+func providerString(config map[string]interface{}, key, fallback string) string {
+	if value, ok := config[key].(string); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// providerStringMap reads an object of string settings from providers.config
+func providerStringMap(config map[string]interface{}, key string) map[string]string {
+	values := map[string]string{}
+	fields, _ := config[key].(map[string]interface{})
+	for name, value := range fields {
+		if s, ok := value.(string); ok {
+			values[name] = s
+		}
+	}
+	return values
+}
+
+// claimString reads a profile field as a string; numeric IDs are formatted without exponent
+func claimString(fields map[string]interface{}, key string) string {
+	switch value := fields[key].(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+// claimBool reads a profile field as a boolean, accepting "true" strings as some providers send them
+func claimBool(fields map[string]interface{}, key string) bool {
+	switch value := fields[key].(type) {
+	case bool:
+		return value
+	case string:
+		return value == "true"
+	default:
+		return false
+	}
+}
@@ -0,0 +1,169 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// clientIDPattern matches client identifiers; they travel in URLs and Basic auth
+var clientIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{2,99}$`)
+
+// privateSchemePattern matches reverse-domain URI schemes used by native apps
+// (RFC 8252 section 7.1), such as com.example.app
+var privateSchemePattern = regexp.MustCompile(`^[a-z][a-z0-9+-]*(\.[a-z0-9+-]+)+$`)
+
+// OAuthClientInput registers an application with the authorization server
+type OAuthClientInput struct {
+	// ClientID is generated when empty
+	ClientID     string
+	Name         string
+	RedirectURIs []string
+	// GrantTypes default to authorization_code and refresh_token
+	GrantTypes []string
+	// Scopes default to openid and email
+	Scopes []string
+	// Confidential clients get a secret; public ones (mobile apps, SPAs) rely on PKCE
+	Confidential bool
+	// FirstParty clients are trusted to skip the consent page
+	FirstParty bool
+}
+
+// ListOAuthClients returns every registered client for administration
+func (b *AuthBusiness) ListOAuthClients(ctx context.Context) ([]*models.OAuthClient, error) {
+	return b.authRepo.ListOAuthClients(ctx)
+}
+
+// CreateOAuthClient registers a client. For confidential clients it also
+// returns the client secret, which is only stored as a hash and cannot be
+// shown again.
+func (b *AuthBusiness) CreateOAuthClient(ctx context.Context, input OAuthClientInput) (*models.OAuthClient, string, error) {
+	client := &models.OAuthClient{
+		ClientID:     strings.TrimSpace(input.ClientID),
+		Name:         strings.TrimSpace(input.Name),
+		IsFirstParty: input.FirstParty,
+	}
+	if client.Name == "" || len(client.Name) > 255 {
+		return nil, "", fmt.Errorf("%w: name is required and at most 255 characters", ErrInvalidArgument)
+	}
+	if client.ClientID == "" {
+		generated, err := generateOpaqueToken(16)
+		if err != nil {
+			return nil, "", err
+		}
+		client.ClientID = generated
+	} else if !clientIDPattern.MatchString(client.ClientID) {
+		return nil, "", fmt.Errorf("%w: client_id must be 3-100 letters, digits, '.', '-' or '_'", ErrInvalidArgument)
+	}
+
+	var err error
+	if client.GrantTypes, err = normalizeGrantTypes(input.GrantTypes); err != nil {
+		return nil, "", err
+	}
+	if hasScope(client.GrantTypes, models.GrantTypeClientCredentials) && !input.Confidential {
+		return nil, "", fmt.Errorf("%w: client_credentials requires a confidential client", ErrInvalidArgument)
+	}
+
+	for _, uri := range input.RedirectURIs {
+		uri, err := validateClientRedirectURI(uri)
+		if err != nil {
+			return nil, "", err
+		}
+		if !hasScope(client.RedirectURIs, uri) {
+			client.RedirectURIs = append(client.RedirectURIs, uri)
+		}
+	}
+	if hasScope(client.GrantTypes, models.GrantTypeAuthorizationCode) && len(client.RedirectURIs) == 0 {
+		return nil, "", fmt.Errorf("%w: authorization_code requires at least one redirect_uri", ErrInvalidArgument)
+	}
+
+	scopes, err := normalizeScopes(input.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	client.Scopes = strings.Fields(scopes)
+	if len(client.Scopes) == 0 {
+		client.Scopes = []string{scopeOpenID, scopeEmail}
+	}
+
+	var secret string
+	if input.Confidential {
+		if secret, err = generateOpaqueToken(32); err != nil {
+			return nil, "", err
+		}
+		client.ClientSecretHash = hashToken(secret)
+	}
+
+	if err := b.authRepo.CreateOAuthClient(ctx, client); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, "", ErrOAuthClientExists
+		}
+		return nil, "", err
+	}
+	return client, secret, nil
+}
+
+// EnableOAuthClient lets a client use the authorization server again
+func (b *AuthBusiness) EnableOAuthClient(ctx context.Context, clientUUID string) (*models.OAuthClient, error) {
+	return b.setOAuthClientActive(ctx, clientUUID, true)
+}
+
+// DisableOAuthClient stops a client from starting new authorizations or
+// redeeming codes and refresh tokens. Access tokens already issued stay valid
+// until they expire.
+func (b *AuthBusiness) DisableOAuthClient(ctx context.Context, clientUUID string) (*models.OAuthClient, error) {
+	return b.setOAuthClientActive(ctx, clientUUID, false)
+}
+
+func (b *AuthBusiness) setOAuthClientActive(ctx context.Context, clientUUID string, active bool) (*models.OAuthClient, error) {
+	if strings.TrimSpace(clientUUID) == "" {
+		return nil, ErrInvalidArgument
+	}
+	client, err := b.authRepo.SetOAuthClientActive(ctx, clientUUID, active)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrOAuthClientNotFound
+	}
+	return client, err
+}
+
+// normalizeGrantTypes validates grant types, defaulting to the authorization code flow
+func normalizeGrantTypes(grantTypes []string) ([]string, error) {
+	if len(grantTypes) == 0 {
+		return []string{models.GrantTypeAuthorizationCode, models.GrantTypeRefreshToken}, nil
+	}
+
+	var result []string
+	for _, grantType := range grantTypes {
+		grantType = strings.TrimSpace(grantType)
+		switch grantType {
+		case models.GrantTypeAuthorizationCode, models.GrantTypeRefreshToken, models.GrantTypeClientCredentials:
+		default:
+			return nil, fmt.Errorf("%w: unsupported grant type %q", ErrInvalidArgument, grantType)
+		}
+		if !hasScope(result, grantType) {
+			result = append(result, grantType)
+		}
+	}
+	return result, nil
+}
+
+// validateClientRedirectURI accepts https URLs, http on loopback hosts, and
+// reverse-domain private schemes for native apps. Redirect URIs are compared
+// exactly, so no normalization is done beyond trimming.
+func validateClientRedirectURI(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	parsed, err := url.Parse(value)
+	if err == nil && parsed.Scheme != "http" && parsed.Scheme != "https" {
+		if !privateSchemePattern.MatchString(parsed.Scheme) || parsed.Fragment != "" {
+			return "", fmt.Errorf("%w: redirect_uri %q must use https or a reverse-domain scheme", ErrInvalidArgument, value)
+		}
+		return value, nil
+	}
+	return validateProviderURL("redirect_uri", value, true)
+}
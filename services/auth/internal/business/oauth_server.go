@@ -0,0 +1,356 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// Endpoint paths of the authorization server, relative to OAUTH_ISSUER
+const (
+	OAuthAuthorizePath = "/authorize"
+	OAuthTokenPath     = "/token"
+	OAuthUserinfoPath  = "/userinfo"
+	OAuthJWKSPath      = "/jwks.json"
+	OAuthDiscoveryPath = "/.well-known/openid-configuration"
+)
+
+// OAuth error codes (RFC 6749 sections 4.1.2.1 and 5.2, RFC 6750 section 3.1,
+// OpenID Connect Core section 3.1.2.6)
+const (
+	OAuthErrInvalidRequest          = "invalid_request"
+	OAuthErrInvalidClient           = "invalid_client"
+	OAuthErrInvalidGrant            = "invalid_grant"
+	OAuthErrUnauthorizedClient      = "unauthorized_client"
+	OAuthErrUnsupportedGrantType    = "unsupported_grant_type"
+	OAuthErrUnsupportedResponseType = "unsupported_response_type"
+	OAuthErrInvalidScope            = "invalid_scope"
+	OAuthErrAccessDenied            = "access_denied"
+	OAuthErrLoginRequired           = "login_required"
+	OAuthErrInvalidToken            = "invalid_token"
+	OAuthErrInsufficientScope       = "insufficient_scope"
+	OAuthErrServerError             = "server_error"
+)
+
+// Scopes defined by OpenID Connect. Clients may also be registered for their own API scopes.
+const (
+	scopeOpenID        = "openid"
+	scopeEmail         = "email"
+	scopePhone         = "phone"
+	scopeOfflineAccess = "offline_access"
+)
+
+// userScopes describe a signed-in user and make no sense for client credentials tokens
+var userScopes = map[string]bool{scopeOpenID: true, scopeEmail: true, scopePhone: true, scopeOfflineAccess: true}
+
+// codeChallengeMethodS256 is the only PKCE method accepted; OAuth 2.1 drops "plain"
+const codeChallengeMethodS256 = "S256"
+
+// codeChallengePattern matches a base64url SHA-256 digest (RFC 7636 section 4.2)
+var codeChallengePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{43}$`)
+
+// Keys in sessions.meta for sessions created through the authorization server
+const (
+	sessionMetaOAuthClientID = "oauth_client_id"
+	sessionMetaOAuthScope    = "oauth_scope"
+	sessionMetaAuthTime      = "auth_time"
+)
+
+// revokeReasonCodeReuse is recorded when an authorization code is redeemed twice
+const revokeReasonCodeReuse = "authorization_code_reuse"
+
+// OAuthError is an error response defined by OAuth 2.1 / OpenID Connect. The
+// HTTP endpoints send Code and Description to the client as they are, so the
+// description must not leak internal details.
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+func newOAuthError(code, description string) *OAuthError {
+	return &OAuthError{Code: code, Description: description}
+}
+
+// AuthorizeInput holds the /authorize query parameters
+type AuthorizeInput struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	Nonce               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Prompt              string
+}
+
+// AuthorizationRequest is a pending /authorize request as shown on the sign-in page
+type AuthorizationRequest struct {
+	ID        string
+	Client    *models.OAuthClient
+	Scopes    []string
+	ExpiresAt time.Time
+}
+
+// Authorize validates an /authorize request and returns where to send the
+// browser. A valid request is stored and the browser goes to the sign-in page
+// (OAUTH_LOGIN_URL) with its request_id; once the user has signed in there, the
+// page calls ApproveAuthorization or DenyAuthorization. Invalid requests for a
+// known client and redirect URI go back to the client with an error. An
+// *OAuthError is returned when the redirect URI cannot be trusted, in which
+// case the error must be shown to the user instead.
+func (b *AuthBusiness) Authorize(ctx context.Context, input AuthorizeInput) (string, error) {
+	client, err := b.getActiveOAuthClient(ctx, strings.TrimSpace(input.ClientID))
+	if errors.Is(err, ErrOAuthClientNotFound) {
+		return "", newOAuthError(OAuthErrInvalidRequest, "unknown or disabled client_id")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	redirectURI := input.RedirectURI
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+	if !client.HasRedirectURI(redirectURI) {
+		return "", newOAuthError(OAuthErrInvalidRequest, "redirect_uri is not registered for this client")
+	}
+
+	// From here on errors are reported to the client through the redirect URI
+	reject := func(code, description string) (string, error) {
+		return b.authorizationResponse(redirectURI, url.Values{
+			"error":             {code},
+			"error_description": {description},
+		}, input.State), nil
+	}
+
+	if !client.AllowsGrant(models.GrantTypeAuthorizationCode) {
+		return reject(OAuthErrUnauthorizedClient, "client is not allowed to use the authorization code flow")
+	}
+	if input.ResponseType != "code" {
+		return reject(OAuthErrUnsupportedResponseType, "response_type must be code")
+	}
+	if input.CodeChallengeMethod != codeChallengeMethodS256 || !codeChallengePattern.MatchString(input.CodeChallenge) {
+		return reject(OAuthErrInvalidRequest, "a PKCE code_challenge with code_challenge_method S256 is required")
+	}
+	if len(input.State) > 500 || len(input.Nonce) > 255 {
+		return reject(OAuthErrInvalidRequest, "state or nonce is too long")
+	}
+	scope, oauthErr := grantedScopes(client, input.Scope)
+	if oauthErr != nil {
+		return reject(oauthErr.Code, oauthErr.Description)
+	}
+	// Sign-in happens on a separate page, so there is never a session to use silently
+	if hasScope(strings.Fields(input.Prompt), "none") {
+		return reject(OAuthErrLoginRequired, "user must sign in")
+	}
+
+	authz := &models.OAuthAuthorization{
+		ClientID:            client.ID,
+		RedirectURI:         redirectURI,
+		Scope:               scope,
+		State:               optionalString(input.State),
+		Nonce:               optionalString(input.Nonce),
+		CodeChallenge:       input.CodeChallenge,
+		CodeChallengeMethod: codeChallengeMethodS256,
+		ExpiresAt:           b.now().Add(b.cfg.OAuthAuthorizationRequestExpiry),
+	}
+	if err := b.authRepo.CreateOAuthAuthorization(ctx, authz); err != nil {
+		return "", err
+	}
+
+	loginURL, err := url.Parse(b.cfg.OAuthLoginURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid OAUTH_LOGIN_URL: %w", err)
+	}
+	query := loginURL.Query()
+	query.Set("request_id", authz.UUID)
+	loginURL.RawQuery = query.Encode()
+	return loginURL.String(), nil
+}
+
+// GetAuthorizationRequest returns a pending request so the sign-in page can name
+// the client and, for third-party clients, ask for consent to the scopes
+func (b *AuthBusiness) GetAuthorizationRequest(ctx context.Context, requestID string) (*AuthorizationRequest, error) {
+	authz, client, err := b.getPendingAuthorization(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	return &AuthorizationRequest{
+		ID:        authz.UUID,
+		Client:    client,
+		Scopes:    strings.Fields(authz.Scope),
+		ExpiresAt: authz.ExpiresAt,
+	}, nil
+}
+
+// ApproveAuthorization completes a pending request for the user signed in with
+// accessToken and returns the client's redirect URI carrying a single-use
+// authorization code. Impersonation tokens cannot approve: the code would be
+// exchanged for a refreshable session with the client's full scopes.
+func (b *AuthBusiness) ApproveAuthorization(ctx context.Context, requestID, accessToken string) (string, error) {
+	claims, err := b.ValidateAccessToken(ctx, accessToken)
+	if err != nil {
+		return "", err
+	}
+	if claims.Act != nil {
+		return "", ErrImpersonatedSession
+	}
+	authz, _, err := b.getPendingAuthorization(ctx, requestID)
+	if err != nil {
+		return "", err
+	}
+
+	user, err := b.getUser(ctx, claims.Subject)
+	if err != nil {
+		return "", err
+	}
+	if !user.IsActive {
+		return "", ErrAccountDisabled
+	}
+	session, err := b.authRepo.GetSessionByUUID(ctx, claims.SessionID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return "", ErrInvalidToken
+	}
+	if err != nil {
+		return "", err
+	}
+	if sessionImpersonator(session) != "" {
+		return "", ErrImpersonatedSession
+	}
+
+	code, err := generateOpaqueToken(32)
+	if err != nil {
+		return "", err
+	}
+	err = b.authRepo.ApproveOAuthAuthorization(ctx, authz.ID, user.ID, session.ID, hashToken(code),
+		b.now().Add(b.cfg.OAuthAuthorizationCodeExpiry))
+	if errors.Is(err, repository.ErrNotFound) {
+		return "", ErrAuthorizationRequestNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return b.authorizationResponse(authz.RedirectURI, url.Values{"code": {code}}, stringValue(authz.State)), nil
+}
+
+// DenyAuthorization ends a pending request the user declined and returns the
+// client's redirect URI carrying an access_denied error
+func (b *AuthBusiness) DenyAuthorization(ctx context.Context, requestID string) (string, error) {
+	authz, _, err := b.getPendingAuthorization(ctx, requestID)
+	if err != nil {
+		return "", err
+	}
+
+	err = b.authRepo.DenyOAuthAuthorization(ctx, authz.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return "", ErrAuthorizationRequestNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return b.authorizationResponse(authz.RedirectURI, url.Values{
+		"error":             {OAuthErrAccessDenied},
+		"error_description": {"the user declined the request"},
+	}, stringValue(authz.State)), nil
+}
+
+// getPendingAuthorization resolves a pending request and its still active client
+func (b *AuthBusiness) getPendingAuthorization(ctx context.Context, requestID string) (*models.OAuthAuthorization, *models.OAuthClient, error) {
+	if strings.TrimSpace(requestID) == "" {
+		return nil, nil, ErrInvalidArgument
+	}
+	authz, err := b.authRepo.GetPendingOAuthAuthorization(ctx, requestID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, ErrAuthorizationRequestNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client, err := b.authRepo.GetOAuthClientByID(ctx, authz.ClientID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && !client.IsActive) {
+		return nil, nil, ErrAuthorizationRequestNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return authz, client, nil
+}
+
+// getActiveOAuthClient resolves an enabled client by its public client_id
+func (b *AuthBusiness) getActiveOAuthClient(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	if clientID == "" {
+		return nil, ErrOAuthClientNotFound
+	}
+	client, err := b.authRepo.GetOAuthClientByClientID(ctx, clientID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && !client.IsActive) {
+		return nil, ErrOAuthClientNotFound
+	}
+	return client, err
+}
+
+// authorizationResponse adds params, state and the issuer (RFC 9207) to the
+// client's redirect URI, keeping any query it was registered with
+func (b *AuthBusiness) authorizationResponse(redirectURI string, params url.Values, state string) string {
+	target, err := url.Parse(redirectURI)
+	if err != nil {
+		// Registered redirect URIs are validated, so this cannot happen for stored ones
+		return redirectURI
+	}
+	query := target.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	if state != "" {
+		query.Set("state", state)
+	}
+	query.Set("iss", b.cfg.OAuthIssuer)
+	target.RawQuery = query.Encode()
+	return target.String()
+}
+
+// grantedScopes checks requested scopes against the client's registration. An
+// empty request grants every registered scope.
+func grantedScopes(client *models.OAuthClient, requested string) (string, *OAuthError) {
+	if strings.TrimSpace(requested) == "" {
+		return strings.Join(client.Scopes, " "), nil
+	}
+	scope, err := normalizeScopes([]string{requested})
+	if err != nil {
+		return "", newOAuthError(OAuthErrInvalidScope, "scope is malformed")
+	}
+	for _, s := range strings.Fields(scope) {
+		if !hasScope(client.Scopes, s) {
+			return "", newOAuthError(OAuthErrInvalidScope, fmt.Sprintf("client is not allowed to request scope %q", s))
+		}
+	}
+	return scope, nil
+}
+
+// sessionOAuthClientID returns the OAuth client a session was issued to, or ""
+// for first-party sessions
+func sessionOAuthClientID(session *models.Session) string {
+	clientID, _ := session.Meta[sessionMetaOAuthClientID].(string)
+	return clientID
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
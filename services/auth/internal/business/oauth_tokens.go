@@ -0,0 +1,537 @@
+package business
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/your-project/pkgs/jwt"
+	"github.com/your-project/pkgs/oidc"
+	"github.com/your-project/services/auth/internal/audit"
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// clientSubjectPrefix marks the subject of tokens issued to a client rather than a user
+const clientSubjectPrefix = "client:"
+
+// TokenRequest holds the /token form parameters. ClientID and ClientSecret come
+// from HTTP Basic authentication or the form.
+type TokenRequest struct {
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+	Scope        string
+	ClientID     string
+	ClientSecret string
+	Client       ClientInfo
+}
+
+// OAuthTokens is a successful /token response (RFC 6749 section 5.1)
+type OAuthTokens struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// ProfileClaims are the user claims released for the email and phone scopes
+type ProfileClaims struct {
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+	PhoneNumber   string `json:"phone_number,omitempty"`
+}
+
+// IDTokenClaims are the claims of an OpenID Connect ID token
+type IDTokenClaims struct {
+	jwt.BaseClaims
+	ProfileClaims
+	Nonce           string `json:"nonce,omitempty"`
+	AuthTime        int64  `json:"auth_time,omitempty"`
+	SessionID       string `json:"sid,omitempty"`
+	AuthorizedParty string `json:"azp,omitempty"`
+}
+
+// SetExpiry sets the token expiration time
+func (c *IDTokenClaims) SetExpiry(expiry time.Duration) {
+	c.BaseClaims.SetExpiry(expiry)
+}
+
+// UserInfo is the /userinfo response
+type UserInfo struct {
+	Subject string `json:"sub"`
+	ProfileClaims
+}
+
+// DiscoveryDocument is the OpenID provider metadata served at /.well-known/openid-configuration
+type DiscoveryDocument struct {
+	Issuer                                     string   `json:"issuer"`
+	AuthorizationEndpoint                      string   `json:"authorization_endpoint"`
+	TokenEndpoint                              string   `json:"token_endpoint"`
+	UserinfoEndpoint                           string   `json:"userinfo_endpoint"`
+	JWKSURI                                    string   `json:"jwks_uri"`
+	ScopesSupported                            []string `json:"scopes_supported"`
+	ResponseTypesSupported                     []string `json:"response_types_supported"`
+	GrantTypesSupported                        []string `json:"grant_types_supported"`
+	SubjectTypesSupported                      []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported           []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported          []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported              []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                            []string `json:"claims_supported"`
+	AuthorizationResponseIssParameterSupported bool     `json:"authorization_response_iss_parameter_supported"`
+}
+
+// ExchangeToken implements the /token endpoint for the authorization_code,
+// refresh_token and client_credentials grants. Protocol failures are returned
+// as *OAuthError.
+func (b *AuthBusiness) ExchangeToken(ctx context.Context, req TokenRequest) (*OAuthTokens, error) {
+	if b.signingKeys == nil {
+		return nil, newOAuthError(OAuthErrServerError, "token signing is not configured")
+	}
+
+	client, err := b.authenticateOAuthClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.GrantType {
+	case models.GrantTypeAuthorizationCode, models.GrantTypeRefreshToken, models.GrantTypeClientCredentials:
+	case "":
+		return nil, newOAuthError(OAuthErrInvalidRequest, "grant_type is required")
+	default:
+		return nil, newOAuthError(OAuthErrUnsupportedGrantType, "grant_type is not supported")
+	}
+	if !client.AllowsGrant(req.GrantType) {
+		return nil, newOAuthError(OAuthErrUnauthorizedClient, "client is not allowed to use this grant_type")
+	}
+
+	switch req.GrantType {
+	case models.GrantTypeAuthorizationCode:
+		return b.exchangeAuthorizationCode(ctx, client, req)
+	case models.GrantTypeRefreshToken:
+		return b.exchangeRefreshToken(ctx, client, req)
+	default:
+		return b.exchangeClientCredentials(ctx, client, req)
+	}
+}
+
+// UserInfo implements the /userinfo endpoint for an access token issued to a client
+func (b *AuthBusiness) UserInfo(ctx context.Context, accessToken string) (*UserInfo, error) {
+	claims, err := b.ValidateOAuthAccessToken(ctx, accessToken)
+	if errors.Is(err, ErrInvalidToken) {
+		return nil, newOAuthError(OAuthErrInvalidToken, "access token is invalid or expired")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(claims.Subject, clientSubjectPrefix) {
+		return nil, newOAuthError(OAuthErrInvalidToken, "access token was not issued for a user")
+	}
+	if !hasScope(strings.Fields(claims.Scope), scopeOpenID) {
+		return nil, newOAuthError(OAuthErrInsufficientScope, "the openid scope is required")
+	}
+
+	user, err := b.authRepo.GetUserByUUID(ctx, claims.Subject)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && !user.IsActive) {
+		return nil, newOAuthError(OAuthErrInvalidToken, "access token is invalid or expired")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserInfo{Subject: user.UUID, ProfileClaims: profileClaims(user, claims.Scope)}, nil
+}
+
+// ValidateOAuthAccessToken verifies an access token issued by the authorization
+// server. Tokens issued to users are tied to a session and stop validating when it ends.
+func (b *AuthBusiness) ValidateOAuthAccessToken(ctx context.Context, token string) (*TokenClaims, error) {
+	if b.signingKeys == nil {
+		return nil, ErrInvalidToken
+	}
+	claims := &TokenClaims{}
+	if err := b.signingKeys.ParseToken(token, claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.Issuer != b.cfg.OAuthIssuer || claims.Type != TokenTypeAccess || claims.Subject == "" || claims.ClientID == "" {
+		return nil, ErrInvalidToken
+	}
+	if !strings.HasPrefix(claims.Subject, clientSubjectPrefix) {
+		if err := b.checkSession(ctx, claims.SessionID); err != nil {
+			return nil, err
+		}
+	}
+	return claims, nil
+}
+
+// Discovery returns the OpenID provider metadata
+func (b *AuthBusiness) Discovery() *DiscoveryDocument {
+	issuer := b.cfg.OAuthIssuer
+	var algorithms []string
+	if b.signingKeys != nil {
+		seen := map[string]bool{}
+		for _, key := range b.signingKeys.PublicKeys() {
+			if !seen[key.Algorithm] {
+				seen[key.Algorithm] = true
+				algorithms = append(algorithms, key.Algorithm)
+			}
+		}
+	}
+
+	return &DiscoveryDocument{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + OAuthAuthorizePath,
+		TokenEndpoint:                     issuer + OAuthTokenPath,
+		UserinfoEndpoint:                  issuer + OAuthUserinfoPath,
+		JWKSURI:                           issuer + OAuthJWKSPath,
+		ScopesSupported:                   []string{scopeOpenID, scopeEmail, scopePhone, scopeOfflineAccess},
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{models.GrantTypeAuthorizationCode, models.GrantTypeRefreshToken, models.GrantTypeClientCredentials},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  algorithms,
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{codeChallengeMethodS256},
+		ClaimsSupported: []string{"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "sid", "azp",
+			"email", "email_verified", "phone_number"},
+		AuthorizationResponseIssParameterSupported: true,
+	}
+}
+
+// JWKS returns the public keys that verify ID and access tokens
+func (b *AuthBusiness) JWKS() (*oidc.JSONWebKeySet, error) {
+	set := &oidc.JSONWebKeySet{Keys: []oidc.JSONWebKey{}}
+	if b.signingKeys == nil {
+		return set, nil
+	}
+	for _, key := range b.signingKeys.PublicKeys() {
+		jwk, err := oidc.NewJSONWebKey(key.Key, key.ID, key.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set, nil
+}
+
+// exchangeAuthorizationCode redeems a code from ApproveAuthorization. Each
+// authorization gets its own session, so the client's refresh token rotates
+// independently of the sign-in that approved it.
+func (b *AuthBusiness) exchangeAuthorizationCode(ctx context.Context, client *models.OAuthClient, req TokenRequest) (*OAuthTokens, error) {
+	if req.Code == "" {
+		return nil, newOAuthError(OAuthErrInvalidRequest, "code is required")
+	}
+	codeHash := hashToken(req.Code)
+
+	authz, err := b.authRepo.ConsumeOAuthAuthorizationCode(ctx, codeHash)
+	if errors.Is(err, repository.ErrNotFound) {
+		if err := b.handleReusedAuthorizationCode(ctx, codeHash, req.Client); err != nil {
+			return nil, err
+		}
+		return nil, newOAuthError(OAuthErrInvalidGrant, "code is invalid, expired or already used")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if authz.ClientID != client.ID || authz.RedirectURI != req.RedirectURI {
+		return nil, newOAuthError(OAuthErrInvalidGrant, "code was issued to another client or redirect_uri")
+	}
+	if !verifyCodeChallenge(authz.CodeChallenge, req.CodeVerifier) {
+		return nil, newOAuthError(OAuthErrInvalidGrant, "code_verifier does not match the code_challenge")
+	}
+	if authz.UserID == nil || authz.SessionID == nil {
+		return nil, newOAuthError(OAuthErrInvalidGrant, "code is invalid, expired or already used")
+	}
+
+	user, err := b.authRepo.GetUserByID(ctx, *authz.UserID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && !user.IsActive) {
+		return nil, newOAuthError(OAuthErrInvalidGrant, "user is no longer active")
+	}
+	if err != nil {
+		return nil, err
+	}
+	signIn, err := b.authRepo.GetSessionByID(ctx, *authz.SessionID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && (!signIn.IsActive || signIn.RevokedAt != nil)) {
+		return nil, newOAuthError(OAuthErrInvalidGrant, "the sign-in session has ended")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := generateOpaqueToken(32)
+	if err != nil {
+		return nil, err
+	}
+	session := &models.Session{
+		UserID:           user.ID,
+		RefreshTokenHash: hashToken(refreshToken),
+		DeviceInfo:       req.Client.DeviceInfo,
+		IPAddress:        optionalString(req.Client.IPAddress),
+		UserAgent:        optionalString(req.Client.UserAgent),
+		ExpiresAt:        b.now().Add(b.cfg.RefreshTokenExpiry),
+		Meta: map[string]interface{}{
+			sessionMetaOAuthClientID: client.ClientID,
+			sessionMetaOAuthScope:    authz.Scope,
+			sessionMetaAuthTime:      signIn.CreatedAt.Unix(),
+		},
+	}
+	if err := b.authRepo.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+	if err := b.authRepo.SetOAuthAuthorizationTokenSession(ctx, authz.ID, session.ID); err != nil {
+		return nil, err
+	}
+
+	tokens, err := b.issueOAuthTokens(user, client.ClientID, session.UUID, authz.Scope, stringValue(authz.Nonce), signIn.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if client.AllowsGrant(models.GrantTypeRefreshToken) {
+		tokens.RefreshToken = refreshToken
+	}
+
+	b.recordLoginEvent(ctx, user, &session.ID, models.EventOAuthAuthorized, req.Client, "",
+		map[string]interface{}{"client_id": client.ClientID, "scope": authz.Scope})
+	return tokens, nil
+}
+
+// exchangeRefreshToken rotates the refresh token of a session created by
+// exchangeAuthorizationCode. A narrower scope may be requested for the new
+// access token; scopes outside the original grant are dropped.
+func (b *AuthBusiness) exchangeRefreshToken(ctx context.Context, client *models.OAuthClient, req TokenRequest) (*OAuthTokens, error) {
+	if req.RefreshToken == "" {
+		return nil, newOAuthError(OAuthErrInvalidRequest, "refresh_token is required")
+	}
+	if _, oauthErr := grantedScopes(client, req.Scope); oauthErr != nil {
+		return nil, oauthErr
+	}
+
+	session, user, refreshToken, err := b.rotateRefreshToken(ctx, req.RefreshToken, client.ClientID, req.Client)
+	switch {
+	case errors.Is(err, ErrInvalidToken), errors.Is(err, ErrRefreshConflict), errors.Is(err, ErrAccountDisabled):
+		return nil, newOAuthError(OAuthErrInvalidGrant, "refresh_token is invalid, expired or revoked")
+	case err != nil:
+		return nil, err
+	}
+
+	scope, _ := session.Meta[sessionMetaOAuthScope].(string)
+	if req.Scope != "" {
+		var narrowed []string
+		for _, s := range strings.Fields(req.Scope) {
+			if hasScope(strings.Fields(scope), s) {
+				narrowed = append(narrowed, s)
+			}
+		}
+		scope = strings.Join(narrowed, " ")
+	}
+	authTime := session.CreatedAt
+	if value, ok := session.Meta[sessionMetaAuthTime].(float64); ok {
+		authTime = time.Unix(int64(value), 0)
+	}
+
+	tokens, err := b.issueOAuthTokens(user, client.ClientID, session.UUID, scope, "", authTime)
+	if err != nil {
+		return nil, err
+	}
+	tokens.RefreshToken = refreshToken
+
+	b.recordLoginEvent(ctx, user, &session.ID, models.EventTokenRefreshed, req.Client, "",
+		map[string]interface{}{"client_id": client.ClientID})
+	return tokens, nil
+}
+
+// exchangeClientCredentials issues an access token to a confidential client
+// acting on its own behalf. Its subject is "client:<client_id>".
+func (b *AuthBusiness) exchangeClientCredentials(ctx context.Context, client *models.OAuthClient, req TokenRequest) (*OAuthTokens, error) {
+	if !client.IsConfidential() {
+		return nil, newOAuthError(OAuthErrUnauthorizedClient, "public clients cannot use client_credentials")
+	}
+
+	var scope string
+	if strings.TrimSpace(req.Scope) == "" {
+		var scopes []string
+		for _, s := range client.Scopes {
+			if !userScopes[s] {
+				scopes = append(scopes, s)
+			}
+		}
+		scope = strings.Join(scopes, " ")
+	} else {
+		var oauthErr *OAuthError
+		if scope, oauthErr = grantedScopes(client, req.Scope); oauthErr != nil {
+			return nil, oauthErr
+		}
+		for _, s := range strings.Fields(scope) {
+			if userScopes[s] {
+				return nil, newOAuthError(OAuthErrInvalidScope, fmt.Sprintf("scope %q requires a user", s))
+			}
+		}
+	}
+
+	accessToken, err := b.issueOAuthAccessToken(clientSubjectPrefix+client.ClientID, client.ClientID, "", scope, b.cfg.ServiceTokenExpiry)
+	if err != nil {
+		return nil, err
+	}
+	return &OAuthTokens{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(b.cfg.ServiceTokenExpiry / time.Second),
+		Scope:       scope,
+	}, nil
+}
+
+// issueOAuthTokens signs the access token and, for the openid scope, the ID
+// token. The access token scope is cut down for unverified users under the
+// restrict_scopes verification policy; the ID token is issued regardless.
+func (b *AuthBusiness) issueOAuthTokens(user *models.User, clientID, sessionUUID, scope, nonce string, authTime time.Time) (*OAuthTokens, error) {
+	accessScope := b.verificationScope(user, scope)
+	accessToken, err := b.issueOAuthAccessToken(user.UUID, clientID, sessionUUID, accessScope, b.cfg.AccessTokenExpiry)
+	if err != nil {
+		return nil, err
+	}
+	tokens := &OAuthTokens{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(b.cfg.AccessTokenExpiry / time.Second),
+		Scope:       accessScope,
+	}
+
+	if hasScope(strings.Fields(scope), scopeOpenID) {
+		claims := &IDTokenClaims{
+			ProfileClaims:   profileClaims(user, scope),
+			Nonce:           nonce,
+			AuthTime:        authTime.Unix(),
+			SessionID:       sessionUUID,
+			AuthorizedParty: clientID,
+		}
+		claims.SetIssuer(b.cfg.OAuthIssuer)
+		claims.SetSubject(user.UUID)
+		claims.SetAudience([]string{clientID})
+		claims.SetIssuedAt(b.now())
+		if tokens.IDToken, err = b.signingKeys.GenerateTokenWithExpiry(claims, b.cfg.AccessTokenExpiry); err != nil {
+			return nil, fmt.Errorf("failed to sign id token: %w", err)
+		}
+	}
+	return tokens, nil
+}
+
+// issueOAuthAccessToken signs an access token for subject, verifiable through the JWKS
+func (b *AuthBusiness) issueOAuthAccessToken(subject, clientID, sessionUUID, scope string, expiry time.Duration) (string, error) {
+	return b.signAccessToken(subject, &TokenClaims{
+		SessionID: sessionUUID,
+		Scope:     scope,
+		ClientID:  clientID,
+	}, expiry)
+}
+
+// signAccessToken completes claims as an access token for subject and signs it
+// with the authorization server keys
+func (b *AuthBusiness) signAccessToken(subject string, claims *TokenClaims, expiry time.Duration) (string, error) {
+	jti, err := generateOpaqueToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	claims.Type = TokenTypeAccess
+	claims.SetIssuer(b.cfg.OAuthIssuer)
+	claims.SetSubject(subject)
+	claims.SetIssuedAt(b.now())
+	claims.SetID(jti)
+
+	token, err := b.signingKeys.GenerateTokenWithExpiry(claims, expiry)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign access token: %w", err)
+	}
+	return token, nil
+}
+
+// authenticateOAuthClient checks the client's secret, or that a public client sent none
+func (b *AuthBusiness) authenticateOAuthClient(ctx context.Context, clientID, clientSecret string) (*models.OAuthClient, error) {
+	client, err := b.getActiveOAuthClient(ctx, clientID)
+	if errors.Is(err, ErrOAuthClientNotFound) {
+		return nil, newOAuthError(OAuthErrInvalidClient, "client authentication failed")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if client.IsConfidential() {
+		if clientSecret == "" || subtle.ConstantTimeCompare([]byte(hashToken(clientSecret)), []byte(client.ClientSecretHash)) != 1 {
+			return nil, newOAuthError(OAuthErrInvalidClient, "client authentication failed")
+		}
+	} else if clientSecret != "" {
+		return nil, newOAuthError(OAuthErrInvalidClient, "client authentication failed")
+	}
+	return client, nil
+}
+
+// handleReusedAuthorizationCode revokes the tokens issued for a code that is
+// presented again, since one of the two callers must have stolen it (RFC 6749 section 4.1.2)
+func (b *AuthBusiness) handleReusedAuthorizationCode(ctx context.Context, codeHash string, client ClientInfo) error {
+	authz, err := b.authRepo.GetOAuthAuthorizationByCodeHash(ctx, codeHash)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if authz.UsedAt == nil || authz.TokenSessionID == nil {
+		return nil
+	}
+
+	session, err := b.authRepo.GetSessionByID(ctx, *authz.TokenSessionID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := b.authRepo.RevokeSession(ctx, session.UserID, session.UUID, revokeReasonCodeReuse); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	b.audit.Record(ctx, audit.Event{
+		Type:          models.EventOAuthCodeReused,
+		UserID:        &session.UserID,
+		SessionID:     &session.ID,
+		IPAddress:     client.IPAddress,
+		UserAgent:     client.UserAgent,
+		DeviceInfo:    client.DeviceInfo,
+		FailureReason: revokeReasonCodeReuse,
+		Meta:          map[string]interface{}{"authorization_id": authz.UUID},
+	})
+	return nil
+}
+
+// verifyCodeChallenge checks a PKCE code_verifier against its S256 challenge
+func verifyCodeChallenge(challenge, verifier string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
+
+// profileClaims releases the user's email and phone for the scopes that cover them
+func profileClaims(user *models.User, scope string) ProfileClaims {
+	var claims ProfileClaims
+	scopes := strings.Fields(scope)
+	if hasScope(scopes, scopeEmail) {
+		verified := user.EmailVerifiedAt != nil
+		claims.Email = user.Email
+		claims.EmailVerified = &verified
+	}
+	if hasScope(scopes, scopePhone) && user.Phone != nil {
+		claims.PhoneNumber = *user.Phone
+	}
+	return claims
+}
@@ -0,0 +1,136 @@
+package business
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/your-project/pkgs/oauth2"
+	"github.com/your-project/pkgs/oidc"
+	"github.com/your-project/services/auth/internal/models"
+)
+
+// appleAudience is the aud of Sign in with Apple client secrets
+const appleAudience = "https://appleid.apple.com"
+
+// isOIDCProvider reports whether identity is taken from a validated ID token.
+// Providers opt in by setting "issuer" in providers.config.
+func isOIDCProvider(provider *models.Provider) bool {
+	return providerString(provider.Config, "issuer", "") != ""
+}
+
+// oidcProfile validates the token response's id_token against the provider's
+// discovered keys and builds the profile from its claims. The userinfo endpoint,
+// when configured, only fills in an email or name the ID token lacks, and must
+// describe the same account.
+func (b *AuthBusiness) oidcProfile(ctx context.Context, provider *models.Provider, token *oauth2.Token, nonce string) (*oauthProfile, error) {
+	issuer := providerString(provider.Config, "issuer", "")
+	discoveryURL := providerString(provider.Config, "discovery_url", oidc.DiscoveryURL(issuer))
+
+	discovered, err := b.oidc.Provider(ctx, discoveryURL, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("provider %s discovery: %w", provider.Name, err)
+	}
+	verifier := discovered.Verifier(provider.ClientID, providerStringList(provider.Config, "issuer_aliases")...)
+	verifier.Now = b.now
+
+	idToken, err := verifier.Verify(ctx, token.IDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := mapOAuthProfile(provider.Config, idToken.Claims,
+		providerString(provider.Config, "id_claim", "sub"), "email", "email_verified", "name")
+	if profile.ID == "" {
+		return nil, fmt.Errorf("%w: missing %s claim", oidc.ErrInvalidIDToken, providerString(provider.Config, "id_claim", "sub"))
+	}
+
+	hasUserinfo := provider.UserinfoURL != nil && *provider.UserinfoURL != ""
+	if hasUserinfo && token.AccessToken != "" && (profile.Email == "" || profile.Name == "") {
+		userinfo, err := b.fetchOAuthProfile(ctx, provider, token)
+		if err != nil {
+			return nil, err
+		}
+		if userinfo.ID != profile.ID {
+			return nil, fmt.Errorf("%w: userinfo describes another account", oidc.ErrInvalidIDToken)
+		}
+		if profile.Email == "" {
+			profile.Email = userinfo.Email
+			profile.EmailVerified = userinfo.EmailVerified
+		}
+		if profile.Name == "" {
+			profile.Name = userinfo.Name
+		}
+	}
+
+	return profile, nil
+}
+
+// clientSecret returns the secret presented at the token endpoint. Providers with
+// "client_secret_jwt" in providers.config keep an EC private key (PEM) in
+// client_secret and get a freshly signed ES256 client secret per exchange.
+func (b *AuthBusiness) clientSecret(provider *models.Provider) (string, error) {
+	settings, ok := provider.Config["client_secret_jwt"].(map[string]interface{})
+	if !ok {
+		return provider.ClientSecret, nil
+	}
+
+	key, err := oidc.ParseECPrivateKey([]byte(provider.ClientSecret))
+	if err != nil {
+		return "", fmt.Errorf("provider %s client secret key: %w", provider.Name, err)
+	}
+	secret, err := oidc.ClientSecretJWT{
+		Issuer:   providerString(settings, "team_id", ""),
+		ClientID: provider.ClientID,
+		KeyID:    providerString(settings, "key_id", ""),
+		Audience: providerString(settings, "audience", appleAudience),
+		Key:      key,
+	}.Sign(b.now())
+	if err != nil {
+		return "", fmt.Errorf("provider %s client secret: %w", provider.Name, err)
+	}
+	return secret, nil
+}
+
+// applyFormPostUser takes the name from Apple's first-authorization "user" field
+// when the ID token has none. The field is unsigned, so its email is ignored.
+func applyFormPostUser(profile *oauthProfile, user string) {
+	if profile.Name != "" || strings.TrimSpace(user) == "" {
+		return
+	}
+
+	var posted struct {
+		Name struct {
+			FirstName string `json:"firstName"`
+			LastName  string `json:"lastName"`
+		} `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(user), &posted); err != nil {
+		return
+	}
+	name := strings.TrimSpace(posted.Name.FirstName + " " + posted.Name.LastName)
+	if name == "" {
+		return
+	}
+
+	profile.Name = name
+	raw := make(map[string]interface{}, len(profile.Raw)+1)
+	for key, value := range profile.Raw {
+		raw[key] = value
+	}
+	raw["name"] = name
+	profile.Raw = raw
+}
+
+// providerStringList reads a list of strings from providers.config
+func providerStringList(config map[string]interface{}, key string) []string {
+	var values []string
+	items, _ := config[key].([]interface{})
+	for _, item := range items {
+		if s, ok := item.(string); ok && s != "" {
+			values = append(values, s)
+		}
+	}
+	return values
+}
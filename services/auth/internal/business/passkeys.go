@@ -0,0 +1,389 @@
+package business
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/your-project/pkgs/webauthn"
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// PasskeyRegistrationOptions are the PublicKeyCredentialCreationOptions for the client
+type PasskeyRegistrationOptions struct {
+	ChallengeID          string
+	Challenge            []byte
+	RPID                 string
+	RPName               string
+	UserHandle           []byte
+	UserName             string
+	Algorithms           []int64
+	ExcludeCredentialIDs [][]byte
+	Timeout              time.Duration
+}
+
+// FinishPasskeyRegistrationInput carries the client's attestation response
+type FinishPasskeyRegistrationInput struct {
+	UserUUID          string
+	ChallengeID       string
+	Name              string
+	DeviceUUID        string
+	ClientDataJSON    []byte
+	AttestationObject []byte
+}
+
+// PasskeyLoginOptions are the PublicKeyCredentialRequestOptions for the client
+type PasskeyLoginOptions struct {
+	ChallengeID        string
+	Challenge          []byte
+	RPID               string
+	AllowCredentialIDs [][]byte
+	Timeout            time.Duration
+}
+
+// FinishPasskeyLoginInput carries the client's assertion response
+type FinishPasskeyLoginInput struct {
+	ChallengeID       string
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+	UserHandle        []byte
+	Client            ClientInfo
+}
+
+// BeginPasskeyRegistration creates a registration challenge for the user signed
+// in with sessionUUID. Impersonation sessions are refused.
+func (b *AuthBusiness) BeginPasskeyRegistration(ctx context.Context, userUUID, sessionUUID string) (*PasskeyRegistrationOptions, error) {
+	if b.passkeys == nil {
+		return nil, ErrPasskeysDisabled
+	}
+
+	user, err := b.getUser(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := b.requireOwnSession(ctx, user, sessionUUID); err != nil {
+		return nil, err
+	}
+
+	existing, err := b.authRepo.ListWebAuthnCredentials(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := b.createWebAuthnChallenge(ctx, &user.ID, models.CeremonyRegistration)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &PasskeyRegistrationOptions{
+		ChallengeID: challenge.UUID,
+		Challenge:   challenge.Challenge,
+		RPID:        b.passkeys.ID(),
+		RPName:      b.passkeys.Name(),
+		UserHandle:  []byte(user.UUID),
+		UserName:    user.Email,
+		Algorithms:  webauthn.SupportedAlgorithms,
+		Timeout:     b.cfg.WebAuthnChallengeExpiry,
+	}
+	for _, credential := range existing {
+		options.ExcludeCredentialIDs = append(options.ExcludeCredentialIDs, credential.CredentialID)
+	}
+
+	return options, nil
+}
+
+// FinishPasskeyRegistration verifies the attestation and stores the new credential
+func (b *AuthBusiness) FinishPasskeyRegistration(ctx context.Context, input FinishPasskeyRegistrationInput) (*models.WebAuthnCredential, error) {
+	if b.passkeys == nil {
+		return nil, ErrPasskeysDisabled
+	}
+	if input.ChallengeID == "" || len(input.ClientDataJSON) == 0 || len(input.AttestationObject) == 0 {
+		return nil, ErrInvalidArgument
+	}
+
+	user, err := b.getUser(ctx, input.UserUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := b.consumeWebAuthnChallenge(ctx, input.ChallengeID, models.CeremonyRegistration)
+	if err != nil {
+		return nil, err
+	}
+	if challenge.UserID == nil || *challenge.UserID != user.ID {
+		return nil, ErrInvalidChallenge
+	}
+
+	verified, err := b.passkeys.VerifyRegistration(challenge.Challenge, webauthn.RegistrationResponse{
+		ClientDataJSON:    input.ClientDataJSON,
+		AttestationObject: input.AttestationObject,
+	}, true)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPasskeyVerification, err)
+	}
+
+	credential := &models.WebAuthnCredential{
+		UserID:            user.ID,
+		CredentialID:      verified.ID,
+		PublicKey:         verified.PublicKey,
+		Algorithm:         verified.Algorithm,
+		SignCount:         verified.SignCount,
+		AAGUID:            formatAAGUID(verified.AAGUID),
+		AttestationFormat: verified.AttestationFormat,
+		AttestationType:   verified.AttestationType,
+		Name:              optionalString(strings.TrimSpace(input.Name)),
+		BackupEligible:    verified.BackupEligible,
+		BackupState:       verified.BackupState,
+	}
+
+	if input.DeviceUUID != "" {
+		deviceID, err := b.authRepo.GetDeviceIDByUUID(ctx, user.ID, input.DeviceUUID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidArgument
+		}
+		if err != nil {
+			return nil, err
+		}
+		credential.DeviceID = &deviceID
+	}
+
+	if err := b.authRepo.CreateWebAuthnCredential(ctx, credential); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, ErrPasskeyExists
+		}
+		return nil, err
+	}
+
+	return credential, nil
+}
+
+// BeginPasskeyLogin creates an authentication challenge. With an email the
+// user's credentials are listed in allowCredentials; without one the client
+// uses a discoverable credential. Emails of unknown accounts, and of accounts
+// without passkeys, get a fake credential ID derived from the email, so the
+// list is stable across requests and cannot be used to enumerate accounts.
+func (b *AuthBusiness) BeginPasskeyLogin(ctx context.Context, email string) (*PasskeyLoginOptions, error) {
+	if b.passkeys == nil {
+		return nil, ErrPasskeysDisabled
+	}
+
+	var (
+		userID  *int
+		allowed [][]byte
+	)
+
+	if email = strings.TrimSpace(email); email != "" {
+		user, err := b.authRepo.GetUserByEmail(ctx, email)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+		if user != nil {
+			credentials, err := b.authRepo.ListWebAuthnCredentials(ctx, user.ID)
+			if err != nil {
+				return nil, err
+			}
+			for _, credential := range credentials {
+				allowed = append(allowed, credential.CredentialID)
+			}
+			userID = &user.ID
+		}
+		if len(allowed) == 0 {
+			allowed = [][]byte{b.fakeCredentialID(email)}
+		}
+	}
+
+	challenge, err := b.createWebAuthnChallenge(ctx, userID, models.CeremonyAuthentication)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PasskeyLoginOptions{
+		ChallengeID:        challenge.UUID,
+		Challenge:          challenge.Challenge,
+		RPID:               b.passkeys.ID(),
+		AllowCredentialIDs: allowed,
+		Timeout:            b.cfg.WebAuthnChallengeExpiry,
+	}, nil
+}
+
+// fakeCredentialID returns the credential ID offered for an email that has no
+// passkeys. It is keyed with the server secret so it cannot be told apart from
+// a real one, and is the same on every request for the email.
+func (b *AuthBusiness) fakeCredentialID(email string) []byte {
+	mac := hmac.New(sha256.New, []byte(b.cfg.JWTSecret))
+	mac.Write([]byte("passkey-credential:" + strings.ToLower(email)))
+	return mac.Sum(nil)
+}
+
+// FinishPasskeyLogin verifies an assertion and starts a session. User
+// verification is required, so a passkey satisfies both factors on its own.
+func (b *AuthBusiness) FinishPasskeyLogin(ctx context.Context, input FinishPasskeyLoginInput) (*LoginResult, error) {
+	if b.passkeys == nil {
+		return nil, ErrPasskeysDisabled
+	}
+	if input.ChallengeID == "" || len(input.CredentialID) == 0 {
+		return nil, ErrInvalidArgument
+	}
+
+	challenge, err := b.consumeWebAuthnChallenge(ctx, input.ChallengeID, models.CeremonyAuthentication)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := b.authRepo.GetWebAuthnCredentialByCredentialID(ctx, input.CredentialID)
+	if errors.Is(err, repository.ErrNotFound) {
+		b.recordLoginEvent(ctx, nil, nil, models.EventLoginFailed, input.Client, "unknown_passkey", nil)
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := b.authRepo.GetUserByID(ctx, credential.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// A challenge issued for a specific user can only be answered by that user's credential,
+	// and a returned user handle must identify the credential's owner
+	if challenge.UserID != nil && *challenge.UserID != user.ID {
+		b.recordLoginEvent(ctx, user, nil, models.EventLoginFailed, input.Client, "passkey_user_mismatch", nil)
+		return nil, ErrInvalidCredentials
+	}
+	if len(input.UserHandle) > 0 && !bytes.Equal(input.UserHandle, []byte(user.UUID)) {
+		b.recordLoginEvent(ctx, user, nil, models.EventLoginFailed, input.Client, "passkey_user_mismatch", nil)
+		return nil, ErrInvalidCredentials
+	}
+
+	result, err := b.passkeys.VerifyAssertion(challenge.Challenge, webauthn.AssertionResponse{
+		CredentialID:      input.CredentialID,
+		ClientDataJSON:    input.ClientDataJSON,
+		AuthenticatorData: input.AuthenticatorData,
+		Signature:         input.Signature,
+		UserHandle:        input.UserHandle,
+	}, credential.PublicKey, credential.SignCount, true)
+	if err != nil {
+		b.recordLoginEvent(ctx, user, nil, models.EventLoginFailed, input.Client, "passkey_verification_failed", nil)
+		return nil, fmt.Errorf("%w: %v", ErrPasskeyVerification, err)
+	}
+
+	if result.CloneWarning {
+		if err := b.authRepo.MarkWebAuthnCredentialCloned(ctx, credential.ID); err != nil {
+			return nil, err
+		}
+		b.recordLoginEvent(ctx, user, nil, models.EventLoginFailed, input.Client, "passkey_clone_detected", map[string]interface{}{
+			"credential_uuid":   credential.UUID,
+			"stored_sign_count": credential.SignCount,
+			"sign_count":        result.SignCount,
+		})
+		return nil, ErrPasskeyCloneDetected
+	}
+
+	updated, err := b.authRepo.UpdateWebAuthnCredentialUsage(ctx, credential.ID, credential.SignCount, result.SignCount, result.BackupState)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		// Another assertion advanced the counter first; treat this one as a replay
+		return nil, ErrInvalidCredentials
+	}
+
+	if !user.IsActive {
+		b.recordLoginEvent(ctx, user, nil, models.EventLoginFailed, input.Client, "account_disabled", nil)
+		return nil, ErrAccountDisabled
+	}
+
+	// A user-verified passkey already counts as two factors, so only blocking applies
+	meta := map[string]interface{}{
+		"method":          "passkey",
+		"credential_uuid": credential.UUID,
+	}
+	if _, err := b.checkLoginRisk(ctx, user, input.Client, meta); err != nil {
+		return nil, err
+	}
+
+	return b.completeLogin(ctx, user, input.Client, meta)
+}
+
+// ListPasskeys returns the user's active passkeys
+func (b *AuthBusiness) ListPasskeys(ctx context.Context, userUUID string) ([]*models.WebAuthnCredential, error) {
+	user, err := b.getUser(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	return b.authRepo.ListWebAuthnCredentials(ctx, user.ID)
+}
+
+// DeletePasskey removes a passkey. Passkey-only accounts cannot remove their last one.
+func (b *AuthBusiness) DeletePasskey(ctx context.Context, userUUID, credentialUUID string) error {
+	user, err := b.getUser(ctx, userUUID)
+	if err != nil {
+		return err
+	}
+
+	if user.AuthMethod == models.AuthMethodPasskey {
+		credentials, err := b.authRepo.ListWebAuthnCredentials(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		if len(credentials) <= 1 {
+			return ErrLastLoginMethod
+		}
+	}
+
+	if err := b.authRepo.DeleteWebAuthnCredential(ctx, user.ID, credentialUUID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrPasskeyNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (b *AuthBusiness) createWebAuthnChallenge(ctx context.Context, userID *int, ceremony string) (*models.WebAuthnChallenge, error) {
+	value, err := webauthn.GenerateChallenge()
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := &models.WebAuthnChallenge{
+		UserID:    userID,
+		Ceremony:  ceremony,
+		Challenge: value,
+		ExpiresAt: b.now().Add(b.cfg.WebAuthnChallengeExpiry),
+	}
+	if err := b.authRepo.CreateWebAuthnChallenge(ctx, challenge); err != nil {
+		return nil, err
+	}
+
+	return challenge, nil
+}
+
+func (b *AuthBusiness) consumeWebAuthnChallenge(ctx context.Context, challengeUUID, ceremony string) (*models.WebAuthnChallenge, error) {
+	challenge, err := b.authRepo.ConsumeWebAuthnChallenge(ctx, challengeUUID, ceremony)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidChallenge
+	}
+	return challenge, err
+}
+
+// formatAAGUID renders a 16 byte AAGUID in UUID form; all-zero AAGUIDs are omitted
+func formatAAGUID(aaguid []byte) *string {
+	if len(aaguid) != 16 || bytes.Equal(aaguid, make([]byte, 16)) {
+		return nil
+	}
+	value := fmt.Sprintf("%x-%x-%x-%x-%x", aaguid[0:4], aaguid[4:6], aaguid[6:8], aaguid[8:10], aaguid[10:16])
+	return &value
+}
@@ -0,0 +1,243 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/your-project/services/auth/internal/events"
+	"github.com/your-project/services/auth/internal/mailer"
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+const (
+	// revokeReasonPasswordChanged is recorded on sessions ended by a password reset or change
+	revokeReasonPasswordChanged = "password_changed"
+	// maxPasswordBytes is the most bcrypt takes into account
+	maxPasswordBytes = 72
+)
+
+// ResetPasswordInput redeems the token from a password reset email
+type ResetPasswordInput struct {
+	Token       string
+	NewPassword string
+	Client      ClientInfo
+}
+
+// ChangePasswordInput changes the password of a signed-in user
+type ChangePasswordInput struct {
+	UserID string
+	// SessionID is the caller's session, which stays signed in; every other
+	// session is revoked. Impersonation sessions are refused.
+	SessionID       string
+	CurrentPassword string
+	NewPassword     string
+	Client          ClientInfo
+}
+
+// RequestPasswordReset emails a single-use reset link to the address if it
+// belongs to an active account. The result is the same either way, so the call
+// cannot be used to enumerate users. Accounts without a password (OAuth or
+// passkey only) can use the link to add one.
+func (b *AuthBusiness) RequestPasswordReset(ctx context.Context, email string, client ClientInfo) error {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return ErrInvalidArgument
+	}
+
+	user, err := b.authRepo.GetUserByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		b.recordLoginEvent(ctx, nil, nil, models.EventPasswordResetRequested, client, "unknown_email", nil)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !user.IsActive {
+		b.recordLoginEvent(ctx, user, nil, models.EventPasswordResetRequested, client, "account_disabled", nil)
+		return nil
+	}
+
+	token, err := generateOpaqueToken(32)
+	if err != nil {
+		return err
+	}
+	actionToken := &models.ActionToken{
+		UserID:    user.ID,
+		Purpose:   models.TokenPurposePasswordReset,
+		TokenHash: hashToken(token),
+		IPAddress: optionalString(client.IPAddress),
+		UserAgent: optionalString(client.UserAgent),
+		ExpiresAt: b.now().Add(b.cfg.PasswordResetExpiry),
+	}
+	if err := b.authRepo.CreateActionToken(ctx, actionToken); err != nil {
+		return err
+	}
+
+	subject, action := "Reset your password", "reset your password"
+	if user.PasswordHash == "" {
+		subject, action = "Set a password for your account", "set a password for your account"
+	}
+	if err := b.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: subject,
+		Body: fmt.Sprintf("Use the link below to %s. It expires in %s and can only be used once.\n\n%s\n\nIf you did not request this, you can ignore this email; your password has not been changed.",
+			action, b.cfg.PasswordResetExpiry, tokenLinkURL(b.cfg.PasswordResetURL, token)),
+	}); err != nil {
+		return fmt.Errorf("failed to send password reset: %w", err)
+	}
+
+	b.recordLoginEvent(ctx, user, nil, models.EventPasswordResetRequested, client, "", nil)
+	return nil
+}
+
+// ResetPassword sets a new password with a token from RequestPasswordReset and
+// signs the user out everywhere. It returns how many sessions were revoked.
+// The password is checked before the token is burned, so a rejected password
+// can be retried with the same link, and the token is burned in the same
+// transaction that stores the password, so a failed reset does not use it up.
+func (b *AuthBusiness) ResetPassword(ctx context.Context, input ResetPasswordInput) (int, error) {
+	token := strings.TrimSpace(input.Token)
+	if token == "" {
+		return 0, fmt.Errorf("%w: token is required", ErrInvalidArgument)
+	}
+	if err := b.validatePassword(input.NewPassword); err != nil {
+		return 0, err
+	}
+
+	var user *models.User
+	revoked, err := b.setPassword(ctx, input.NewPassword, 0, "reset", input.Client, func(ctx context.Context, tx repository.Store) (*models.User, error) {
+		actionToken, err := tx.ConsumeActionToken(ctx, hashToken(token), models.TokenPurposePasswordReset)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidToken
+		}
+		if err != nil {
+			return nil, err
+		}
+		user, err = tx.GetUserByID(ctx, actionToken.UserID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidToken
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !user.IsActive {
+			return nil, ErrAccountDisabled
+		}
+		return user, nil
+	})
+	// The transaction has been rolled back, so failures are recorded outside it
+	switch {
+	case errors.Is(err, ErrInvalidToken):
+		b.recordLoginEvent(ctx, nil, nil, models.EventPasswordChanged, input.Client, "invalid_reset_token", map[string]interface{}{"method": "reset"})
+	case errors.Is(err, ErrAccountDisabled):
+		b.recordLoginEvent(ctx, user, nil, models.EventPasswordChanged, input.Client, "account_disabled", map[string]interface{}{"method": "reset"})
+	}
+	return revoked, err
+}
+
+// ChangePassword replaces the password of a signed-in user after checking the
+// current one, and revokes every session other than input.SessionID. Users
+// without a password add one through RequestPasswordReset instead.
+func (b *AuthBusiness) ChangePassword(ctx context.Context, input ChangePasswordInput) (int, error) {
+	if input.CurrentPassword == "" {
+		return 0, fmt.Errorf("%w: current password is required", ErrInvalidArgument)
+	}
+	if err := b.validatePassword(input.NewPassword); err != nil {
+		return 0, err
+	}
+
+	user, err := b.getUser(ctx, input.UserID)
+	if err != nil {
+		return 0, err
+	}
+	if !user.IsActive {
+		return 0, ErrAccountDisabled
+	}
+	if user.PasswordHash == "" {
+		return 0, ErrNoPassword
+	}
+
+	session, err := b.requireOwnSession(ctx, user, input.SessionID)
+	if err != nil {
+		return 0, err
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.CurrentPassword)) != nil {
+		b.recordLoginEvent(ctx, user, nil, models.EventPasswordChanged, input.Client, "invalid_password", map[string]interface{}{"method": "change"})
+		return 0, ErrInvalidCredentials
+	}
+
+	return b.setPassword(ctx, input.NewPassword, session.ID, "change", input.Client,
+		func(ctx context.Context, tx repository.Store) (*models.User, error) { return user, nil })
+}
+
+// setPassword stores the new password for the user returned by load and revokes
+// every session except keepSessionID (0 for none) in one transaction, then
+// records and publishes the change. load runs in the transaction, so whatever
+// it consumes is kept if setting the password fails.
+func (b *AuthBusiness) setPassword(ctx context.Context, password string, keepSessionID int, method string, client ClientInfo,
+	load func(ctx context.Context, tx repository.Store) (*models.User, error)) (int, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return 0, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	var (
+		added   bool
+		updated *models.User
+		revoked int64
+	)
+	err = b.authRepo.WithTx(ctx, func(ctx context.Context, tx repository.Store) error {
+		user, err := load(ctx, tx)
+		if err != nil {
+			return err
+		}
+		added = user.PasswordHash == ""
+		if updated, err = tx.SetUserPassword(ctx, user.ID, string(hash)); err != nil {
+			return err
+		}
+		revoked, err = tx.RevokeUserSessions(ctx, user.ID, keepSessionID, revokeReasonPasswordChanged)
+		return err
+	})
+	if errors.Is(err, repository.ErrNotFound) {
+		return 0, ErrUserNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	meta := map[string]interface{}{"method": method, "password_added": added, "sessions_revoked": revoked}
+	var sessionID *int
+	if keepSessionID != 0 {
+		sessionID = &keepSessionID
+	}
+	b.recordLoginEvent(ctx, updated, sessionID, models.EventPasswordChanged, client, "", meta)
+	b.publishEvent(ctx, events.TypePasswordChanged, updated, map[string]interface{}{
+		"method":         method,
+		"password_added": added,
+		"auth_method":    string(updated.AuthMethod),
+		"ip_address":     client.IPAddress,
+	})
+
+	return int(revoked), nil
+}
+
+// validatePassword enforces PasswordMinLength characters and bcrypt's 72 byte limit
+func (b *AuthBusiness) validatePassword(password string) error {
+	if utf8.RuneCountInString(password) < b.cfg.PasswordMinLength {
+		return fmt.Errorf("%w: password must be at least %d characters", ErrInvalidArgument, b.cfg.PasswordMinLength)
+	}
+	if len(password) > maxPasswordBytes {
+		return fmt.Errorf("%w: password must be at most %d bytes", ErrInvalidArgument, maxPasswordBytes)
+	}
+	if strings.TrimSpace(password) == "" {
+		return fmt.Errorf("%w: password must not be blank", ErrInvalidArgument)
+	}
+	return nil
+}
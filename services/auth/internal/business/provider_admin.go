@@ -0,0 +1,234 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// providerNamePattern matches provider names; they appear in redirect URLs
+var providerNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,99}$`)
+
+// providerConfigURLs are the providers.config keys that hold URLs
+var providerConfigURLs = []string{"issuer", "discovery_url", "emails_url"}
+
+// ProviderInput is an admin's provider configuration. ClientSecret is
+// write-only: it is never returned, and on update an empty value keeps the
+// stored secret.
+type ProviderInput struct {
+	Name             string
+	DisplayName      string
+	ClientID         string
+	ClientSecret     string
+	AuthorizationURL string
+	TokenURL         string
+	UserinfoURL      string
+	Scopes           []string
+	RedirectURI      string
+	Config           map[string]interface{}
+	// Enabled is only used on create; use EnableProvider and DisableProvider afterwards
+	Enabled bool
+}
+
+// ListProviders returns every configured provider for administration
+func (b *AuthBusiness) ListProviders(ctx context.Context) ([]*models.Provider, error) {
+	return b.authRepo.ListProviders(ctx)
+}
+
+// CreateProvider adds a provider
+func (b *AuthBusiness) CreateProvider(ctx context.Context, input ProviderInput) (*models.Provider, error) {
+	name := strings.ToLower(strings.TrimSpace(input.Name))
+	if !providerNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("%w: name must be lowercase letters, digits, '-' or '_'", ErrInvalidArgument)
+	}
+
+	provider := &models.Provider{Name: name, ClientSecret: strings.TrimSpace(input.ClientSecret), IsEnabled: input.Enabled}
+	if err := applyProviderInput(provider, input); err != nil {
+		return nil, err
+	}
+	if provider.IsEnabled && provider.ClientID == "" {
+		return nil, fmt.Errorf("%w: client_id is required to enable a provider", ErrInvalidArgument)
+	}
+
+	if err := b.authRepo.CreateProvider(ctx, provider); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, ErrProviderExists
+		}
+		return nil, err
+	}
+	b.invalidateProviders()
+	return provider, nil
+}
+
+// UpdateProvider replaces a provider's settings. The name and enabled state
+// are not changed.
+func (b *AuthBusiness) UpdateProvider(ctx context.Context, providerUUID string, input ProviderInput) (*models.Provider, error) {
+	provider, err := b.getProvider(ctx, providerUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	provider.ClientSecret = strings.TrimSpace(input.ClientSecret)
+	if err := applyProviderInput(provider, input); err != nil {
+		return nil, err
+	}
+	if provider.IsEnabled && provider.ClientID == "" {
+		return nil, fmt.Errorf("%w: client_id is required while the provider is enabled", ErrInvalidArgument)
+	}
+
+	if err := b.authRepo.UpdateProvider(ctx, provider); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrProviderNotFound
+		}
+		return nil, err
+	}
+	b.invalidateProviders()
+	return provider, nil
+}
+
+// EnableProvider allows sign-in with a provider
+func (b *AuthBusiness) EnableProvider(ctx context.Context, providerUUID string) (*models.Provider, error) {
+	return b.setProviderEnabled(ctx, providerUUID, true)
+}
+
+// DisableProvider stops sign-in with a provider. Existing links are kept.
+func (b *AuthBusiness) DisableProvider(ctx context.Context, providerUUID string) (*models.Provider, error) {
+	return b.setProviderEnabled(ctx, providerUUID, false)
+}
+
+func (b *AuthBusiness) setProviderEnabled(ctx context.Context, providerUUID string, enabled bool) (*models.Provider, error) {
+	provider, err := b.getProvider(ctx, providerUUID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled && provider.ClientID == "" {
+		return nil, fmt.Errorf("%w: client_id is required to enable a provider", ErrInvalidArgument)
+	}
+
+	provider, err = b.authRepo.SetProviderEnabled(ctx, provider.ID, enabled)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrProviderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	b.invalidateProviders()
+	return provider, nil
+}
+
+// getProvider resolves a provider by public ID, enabled or not
+func (b *AuthBusiness) getProvider(ctx context.Context, providerUUID string) (*models.Provider, error) {
+	if strings.TrimSpace(providerUUID) == "" {
+		return nil, ErrInvalidArgument
+	}
+	provider, err := b.authRepo.GetProviderByUUID(ctx, providerUUID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrProviderNotFound
+	}
+	return provider, err
+}
+
+// applyProviderInput validates input and copies it onto provider, leaving the
+// name, secret and enabled state alone
+func applyProviderInput(provider *models.Provider, input ProviderInput) error {
+	provider.DisplayName = strings.TrimSpace(input.DisplayName)
+	if provider.DisplayName == "" || len(provider.DisplayName) > 255 {
+		return fmt.Errorf("%w: display_name is required and at most 255 characters", ErrInvalidArgument)
+	}
+	provider.ClientID = strings.TrimSpace(input.ClientID)
+
+	var err error
+	if provider.AuthorizationURL, err = validateProviderURL("authorization_url", input.AuthorizationURL, true); err != nil {
+		return err
+	}
+	if provider.TokenURL, err = validateProviderURL("token_url", input.TokenURL, true); err != nil {
+		return err
+	}
+	userinfoURL, err := validateProviderURL("userinfo_url", input.UserinfoURL, false)
+	if err != nil {
+		return err
+	}
+	redirectURI, err := validateProviderURL("redirect_uri", input.RedirectURI, false)
+	if err != nil {
+		return err
+	}
+	provider.UserinfoURL, provider.RedirectURI = optionalString(userinfoURL), optionalString(redirectURI)
+
+	scopes, err := normalizeScopes(input.Scopes)
+	if err != nil {
+		return err
+	}
+	provider.Scopes = optionalString(scopes)
+
+	provider.Config = input.Config
+	if provider.Config == nil {
+		provider.Config = map[string]interface{}{}
+	}
+	for _, key := range providerConfigURLs {
+		value, ok := provider.Config[key]
+		if !ok {
+			continue
+		}
+		text, _ := value.(string)
+		if _, err := validateProviderURL("config."+key, text, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateProviderURL requires an absolute https URL without a fragment; plain
+// http is only accepted for loopback hosts used in development
+func validateProviderURL(field, value string, required bool) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		if required {
+			return "", fmt.Errorf("%w: %s is required", ErrInvalidArgument, field)
+		}
+		return "", nil
+	}
+
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Host == "" || parsed.Fragment != "" || parsed.User != nil {
+		return "", fmt.Errorf("%w: %s must be an absolute URL without credentials or fragment", ErrInvalidArgument, field)
+	}
+	switch parsed.Scheme {
+	case "https":
+	case "http":
+		host := parsed.Hostname()
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return "", fmt.Errorf("%w: %s must use https", ErrInvalidArgument, field)
+		}
+	default:
+		return "", fmt.Errorf("%w: %s must use https", ErrInvalidArgument, field)
+	}
+	return value, nil
+}
+
+// normalizeScopes validates RFC 6749 scope tokens and joins them with spaces,
+// dropping duplicates
+func normalizeScopes(scopes []string) (string, error) {
+	seen := map[string]bool{}
+	var result []string
+	for _, entry := range scopes {
+		for _, scope := range strings.Fields(entry) {
+			for _, r := range scope {
+				if r < 0x21 || r > 0x7e || r == '"' || r == '\\' {
+					return "", fmt.Errorf("%w: invalid scope %q", ErrInvalidArgument, scope)
+				}
+			}
+			if !seen[scope] {
+				seen[scope] = true
+				result = append(result, scope)
+			}
+		}
+	}
+	return strings.Join(result, " "), nil
+}
@@ -0,0 +1,98 @@
+package business
+
+import (
+	"context"
+	"sync"
+
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// providerCache keeps every provider in memory so sign-ins do not read
+// sr_auth.providers. Changes made through this replica drop the cache at once;
+// other replicas pick them up when RefreshProviders sees a new table version.
+type providerCache struct {
+	mu      sync.Mutex
+	version string
+	byName  map[string]*models.Provider
+	byID    map[int]*models.Provider
+}
+
+// RefreshProviders reloads the provider cache if sr_auth.providers changed since
+// it was loaded. It reports whether a reload happened.
+func (b *AuthBusiness) RefreshProviders(ctx context.Context) (bool, error) {
+	version, err := b.authRepo.ProvidersVersion(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	b.providers.mu.Lock()
+	defer b.providers.mu.Unlock()
+	if b.providers.byName != nil && b.providers.version == version {
+		return false, nil
+	}
+	return true, b.loadProviders(ctx, version)
+}
+
+// providerByName returns a provider from the cache, or repository.ErrNotFound
+func (b *AuthBusiness) providerByName(ctx context.Context, name string) (*models.Provider, error) {
+	if b.cfg.ProviderCacheRefreshInterval <= 0 {
+		return b.authRepo.GetProviderByName(ctx, name)
+	}
+	return b.cachedProvider(ctx, func(c *providerCache) *models.Provider { return c.byName[name] })
+}
+
+// providerByID returns a provider from the cache, or repository.ErrNotFound
+func (b *AuthBusiness) providerByID(ctx context.Context, id int) (*models.Provider, error) {
+	if b.cfg.ProviderCacheRefreshInterval <= 0 {
+		return b.authRepo.GetProviderByID(ctx, id)
+	}
+	return b.cachedProvider(ctx, func(c *providerCache) *models.Provider { return c.byID[id] })
+}
+
+func (b *AuthBusiness) cachedProvider(ctx context.Context, lookup func(*providerCache) *models.Provider) (*models.Provider, error) {
+	b.providers.mu.Lock()
+	defer b.providers.mu.Unlock()
+
+	if b.providers.byName == nil {
+		version, err := b.authRepo.ProvidersVersion(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := b.loadProviders(ctx, version); err != nil {
+			return nil, err
+		}
+	}
+
+	provider := lookup(&b.providers)
+	if provider == nil {
+		return nil, repository.ErrNotFound
+	}
+	// Callers get their own copy; the cached value is shared between requests
+	copied := *provider
+	return &copied, nil
+}
+
+// loadProviders replaces the cache contents; b.providers.mu must be held
+func (b *AuthBusiness) loadProviders(ctx context.Context, version string) error {
+	providers, err := b.authRepo.ListProviders(ctx)
+	if err != nil {
+		return err
+	}
+
+	byName := make(map[string]*models.Provider, len(providers))
+	byID := make(map[int]*models.Provider, len(providers))
+	for _, provider := range providers {
+		byName[provider.Name] = provider
+		byID[provider.ID] = provider
+	}
+	b.providers.version, b.providers.byName, b.providers.byID = version, byName, byID
+	return nil
+}
+
+// invalidateProviders makes the next lookup reload the cache
+func (b *AuthBusiness) invalidateProviders() {
+	b.providers.mu.Lock()
+	defer b.providers.mu.Unlock()
+	b.providers.version, b.providers.byName, b.providers.byID = "", nil, nil
+}
@@ -0,0 +1,327 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// LinkedProvider is a user's provider link together with the provider it points to
+type LinkedProvider struct {
+	Link     *models.UserProvider
+	Provider *models.Provider
+}
+
+// LinkProviderInput adds a provider account to a signed-in user. Either Code and
+// State from a BeginOAuth flow started with UserID, or the LinkToken returned by
+// CompleteOAuth when the provider's email matched this account, must be set.
+type LinkProviderInput struct {
+	UserID string
+	// SessionID is the caller's session; impersonation sessions are refused. A
+	// LinkToken is only accepted from a session started after the token was
+	// issued, proving a fresh sign-in.
+	SessionID string
+	Provider  string
+	Code      string
+	State     string
+	User      string
+	LinkToken string
+	Client    ClientInfo
+}
+
+// ListLinkedProviders returns the user's provider links, primary first
+func (b *AuthBusiness) ListLinkedProviders(ctx context.Context, userUUID string) ([]*LinkedProvider, error) {
+	user, err := b.getUser(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := b.authRepo.ListUserProviders(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	providers := map[int]*models.Provider{}
+	result := make([]*LinkedProvider, 0, len(links))
+	for _, link := range links {
+		provider, ok := providers[link.ProviderID]
+		if !ok {
+			provider, err = b.providerByID(ctx, link.ProviderID)
+			if err != nil {
+				return nil, err
+			}
+			providers[link.ProviderID] = provider
+		}
+		result = append(result, &LinkedProvider{Link: link, Provider: provider})
+	}
+	return result, nil
+}
+
+// LinkProvider links a provider account to the user. Linking an account that
+// already belongs to the user refreshes its tokens; one linked to anyone else is refused.
+func (b *AuthBusiness) LinkProvider(ctx context.Context, input LinkProviderInput) (*LinkedProvider, error) {
+	user, err := b.getUser(ctx, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+	session, err := b.requireOwnSession(ctx, user, input.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(input.LinkToken) != "" {
+		return b.confirmPendingLink(ctx, user, session, input)
+	}
+
+	name := strings.ToLower(strings.TrimSpace(input.Provider))
+	if name == "" || strings.TrimSpace(input.Code) == "" || strings.TrimSpace(input.State) == "" {
+		return nil, ErrInvalidArgument
+	}
+	meta := map[string]interface{}{"method": "oauth", "provider": name, "action": "link"}
+
+	callback, err := b.redeemOAuthCallback(ctx, name, input.Code, input.State, input.User, input.Client, meta)
+	if err != nil {
+		return nil, err
+	}
+	if callback.state.UserID == nil || *callback.state.UserID != user.ID {
+		return nil, ErrInvalidChallenge
+	}
+
+	existing, err := b.authRepo.GetUserProviderByIdentity(ctx, callback.provider.ID, callback.profile.ID)
+	switch {
+	case err == nil && existing.UserID != user.ID:
+		return nil, ErrProviderAlreadyLinked
+	case err == nil:
+		updated := newUserProvider(existing.ProviderID, callback.token, callback.profile)
+		updated.ID = existing.ID
+		if err := b.authRepo.RecordUserProviderLogin(ctx, updated, b.now()); err != nil {
+			return nil, err
+		}
+		link, err := b.authRepo.GetUserProviderByUUID(ctx, user.ID, existing.UUID)
+		if err != nil {
+			return nil, err
+		}
+		return &LinkedProvider{Link: link, Provider: callback.provider}, nil
+	case !errors.Is(err, repository.ErrNotFound):
+		return nil, err
+	}
+
+	link := newUserProvider(callback.provider.ID, callback.token, callback.profile)
+	link.UserID = user.ID
+	return b.createLink(ctx, user, callback.provider, link, input.Client)
+}
+
+// requireLinkConfirmation parks a provider account whose verified email belongs
+// to an existing user. Only upstream identity and profile are kept; the provider
+// tokens are stored on the next sign-in through the link.
+func (b *AuthBusiness) requireLinkConfirmation(ctx context.Context, user *models.User, provider *models.Provider, profile *oauthProfile, client ClientInfo, meta map[string]interface{}) (*LoginResult, error) {
+	if !user.IsActive {
+		b.recordLoginEvent(ctx, user, nil, models.EventLoginFailed, client, "account_disabled", meta)
+		return nil, ErrAccountDisabled
+	}
+
+	token, err := generateOpaqueToken(32)
+	if err != nil {
+		return nil, err
+	}
+	pending := &models.ActionToken{
+		UserID:    user.ID,
+		Purpose:   models.TokenPurposeOAuthLink,
+		TokenHash: hashToken(token),
+		DeviceID:  optionalString(strings.TrimSpace(client.DeviceID)),
+		IPAddress: optionalString(client.IPAddress),
+		UserAgent: optionalString(client.UserAgent),
+		ExpiresAt: b.now().Add(b.cfg.OAuthLinkExpiry),
+		Meta: map[string]interface{}{
+			"provider_id":      provider.ID,
+			"provider_user_id": profile.ID,
+			"profile":          profile.Raw,
+			"link":             linkMeta(profile),
+		},
+	}
+	if err := b.authRepo.CreateActionToken(ctx, pending); err != nil {
+		return nil, err
+	}
+
+	b.recordLoginEvent(ctx, user, nil, models.EventLoginFailed, client, "oauth_link_required", meta)
+
+	return &LoginResult{
+		User:          user,
+		LinkRequired:  true,
+		LinkToken:     token,
+		LinkExpiresAt: pending.ExpiresAt,
+	}, nil
+}
+
+// confirmPendingLink redeems a link token from CompleteOAuth. The token is burned
+// and the link stored in one transaction. A caller that has not signed in again
+// since the token was issued burns it too and has to start over.
+func (b *AuthBusiness) confirmPendingLink(ctx context.Context, user *models.User, session *models.Session, input LinkProviderInput) (*LinkedProvider, error) {
+	var (
+		linked *LinkedProvider
+		stale  bool
+	)
+	err := b.authRepo.WithTx(ctx, func(ctx context.Context, _ repository.Store) error {
+		pending, err := b.authRepo.ConsumeActionToken(ctx, hashToken(strings.TrimSpace(input.LinkToken)), models.TokenPurposeOAuthLink)
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidToken
+		}
+		if err != nil {
+			return err
+		}
+		if pending.UserID != user.ID {
+			return ErrInvalidToken
+		}
+		// Committing with stale set keeps the token burned
+		if stale = checkReauthenticated(session, pending) != nil; stale {
+			return nil
+		}
+
+		providerID, _ := pending.Meta["provider_id"].(float64)
+		providerUserID, _ := pending.Meta["provider_user_id"].(string)
+		if providerID == 0 || providerUserID == "" {
+			return ErrInvalidToken
+		}
+		provider, err := b.providerByID(ctx, int(providerID))
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrProviderNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		link := &models.UserProvider{
+			UserID:         user.ID,
+			ProviderID:     provider.ID,
+			ProviderUserID: providerUserID,
+		}
+		link.ProfileData, _ = pending.Meta["profile"].(map[string]interface{})
+		link.Meta, _ = pending.Meta["link"].(map[string]interface{})
+		linked, err = b.createLink(ctx, user, provider, link, input.Client)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if stale {
+		b.recordLoginEvent(ctx, user, nil, models.EventLoginFailed, input.Client, "reauth_required",
+			map[string]interface{}{"action": "link"})
+		return nil, ErrReauthRequired
+	}
+	return linked, nil
+}
+
+// checkReauthenticated requires the caller's session to have been started after
+// the pending link was issued
+func checkReauthenticated(session *models.Session, pending *models.ActionToken) error {
+	if session.CreatedAt.Before(pending.CreatedAt) {
+		return ErrReauthRequired
+	}
+	return nil
+}
+
+// createLink stores a new link and logs provider_linked in one transaction
+func (b *AuthBusiness) createLink(ctx context.Context, user *models.User, provider *models.Provider, link *models.UserProvider, client ClientInfo) (*LinkedProvider, error) {
+	err := b.authRepo.WithTx(ctx, func(ctx context.Context, _ repository.Store) error {
+		if err := b.authRepo.LinkUserProvider(ctx, link); err != nil {
+			if errors.Is(err, repository.ErrDuplicate) {
+				return ErrProviderAlreadyLinked
+			}
+			return err
+		}
+
+		b.recordLoginEvent(ctx, user, nil, models.EventProviderLinked, client, "",
+			map[string]interface{}{"provider": provider.Name, "link_id": link.UUID})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &LinkedProvider{Link: link, Provider: provider}, nil
+}
+
+// UnlinkProvider removes a provider link for the user signed in with
+// sessionUUID. The last remaining way to sign in (password, passkey or
+// provider) cannot be removed.
+func (b *AuthBusiness) UnlinkProvider(ctx context.Context, userUUID, sessionUUID, linkUUID string, client ClientInfo) error {
+	user, err := b.getUser(ctx, userUUID)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(linkUUID) == "" {
+		return ErrInvalidArgument
+	}
+	if _, err := b.requireOwnSession(ctx, user, sessionUUID); err != nil {
+		return err
+	}
+
+	return b.authRepo.WithTx(ctx, func(ctx context.Context, _ repository.Store) error {
+		link, err := b.authRepo.UnlinkUserProvider(ctx, user.ID, linkUUID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrProviderLinkNotFound
+		}
+		if errors.Is(err, repository.ErrLastLoginMethod) {
+			return ErrLastLoginMethod
+		}
+		if err != nil {
+			return err
+		}
+
+		b.recordLoginEvent(ctx, user, nil, models.EventProviderUnlinked, client, "",
+			map[string]interface{}{"provider_id": link.ProviderID, "link_id": link.UUID})
+		return nil
+	})
+}
+
+// SetPrimaryProvider makes a link the primary provider of the user signed in
+// with sessionUUID
+func (b *AuthBusiness) SetPrimaryProvider(ctx context.Context, userUUID, sessionUUID, linkUUID string, client ClientInfo) (*LinkedProvider, error) {
+	user, err := b.getUser(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(linkUUID) == "" {
+		return nil, ErrInvalidArgument
+	}
+	if _, err := b.requireOwnSession(ctx, user, sessionUUID); err != nil {
+		return nil, err
+	}
+
+	var linked *LinkedProvider
+	err = b.authRepo.WithTx(ctx, func(ctx context.Context, _ repository.Store) error {
+		link, err := b.authRepo.SetPrimaryUserProvider(ctx, user.ID, linkUUID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrProviderLinkNotFound
+		}
+		if err != nil {
+			return err
+		}
+		provider, err := b.providerByID(ctx, link.ProviderID)
+		if err != nil {
+			return err
+		}
+
+		b.recordLoginEvent(ctx, user, nil, models.EventPrimaryProviderChanged, client, "",
+			map[string]interface{}{"provider": provider.Name, "link_id": link.UUID})
+		linked = &LinkedProvider{Link: link, Provider: provider}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return linked, nil
+}
+
+// linkMeta is the normalized identity kept in user_providers.meta for listing
+func linkMeta(profile *oauthProfile) map[string]interface{} {
+	meta := map[string]interface{}{}
+	if profile.Email != "" {
+		meta["email"] = profile.Email
+	}
+	if profile.Name != "" {
+		meta["name"] = profile.Name
+	}
+	return meta
+}
@@ -0,0 +1,146 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/your-project/services/auth/internal/audit"
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// revokeReasonRefreshReuse is recorded when a rotated-out refresh token is presented again
+const revokeReasonRefreshReuse = "refresh_token_reuse"
+
+// RefreshTokensInput exchanges a refresh token for a new token pair
+type RefreshTokensInput struct {
+	RefreshToken string
+	Client       ClientInfo
+}
+
+// RefreshTokens rotates the session's refresh token and issues a new access token.
+// Every refresh token can be used once. Presenting one that has already been rotated
+// out revokes the session, since only a copy held by someone else could still be in use;
+// inside RefreshTokenReuseGrace it is instead treated as a concurrent retry and
+// rejected with ErrRefreshConflict, leaving the session intact.
+func (b *AuthBusiness) RefreshTokens(ctx context.Context, input RefreshTokensInput) (*TokenPair, error) {
+	session, user, newRefreshToken, err := b.rotateRefreshToken(ctx, input.RefreshToken, "", input.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, accessExpiresAt, err := b.issueToken(user, TokenTypeAccess, session.UUID, b.cfg.AccessTokenExpiry)
+	if err != nil {
+		return nil, err
+	}
+
+	b.recordLoginEvent(ctx, user, &session.ID, models.EventTokenRefreshed, input.Client, "", nil)
+
+	return &TokenPair{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessExpiresAt,
+		RefreshToken:          newRefreshToken,
+		RefreshTokenExpiresAt: session.ExpiresAt,
+		SessionUUID:           session.UUID,
+	}, nil
+}
+
+// rotateRefreshToken replaces a session's refresh token and returns the session,
+// its user and the new token. oauthClientID must name the OAuth client the
+// session was issued to, or be empty for first-party sessions, so a refresh
+// token cannot be redeemed by a different client.
+func (b *AuthBusiness) rotateRefreshToken(ctx context.Context, refreshToken, oauthClientID string, client ClientInfo) (*models.Session, *models.User, string, error) {
+	refreshToken = strings.TrimSpace(refreshToken)
+	if refreshToken == "" {
+		return nil, nil, "", ErrInvalidArgument
+	}
+	tokenHash := hashToken(refreshToken)
+
+	session, err := b.authRepo.GetSessionByRefreshTokenHash(ctx, tokenHash)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, "", b.handleRetiredRefreshToken(ctx, tokenHash, client)
+	}
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if !session.IsActive || session.RevokedAt != nil || sessionOAuthClientID(session) != oauthClientID ||
+		sessionImpersonator(session) != "" {
+		return nil, nil, "", ErrInvalidToken
+	}
+
+	now := b.now()
+	if reason := b.sessionTimeoutReason(session, now); reason != "" {
+		b.expireSession(ctx, session, reason)
+		return nil, nil, "", ErrInvalidToken
+	}
+
+	user, err := b.authRepo.GetUserByID(ctx, session.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, "", ErrInvalidToken
+	}
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if !user.IsActive {
+		return nil, nil, "", ErrAccountDisabled
+	}
+
+	newRefreshToken, err := generateOpaqueToken(32)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	err = b.authRepo.RotateRefreshToken(ctx, session.ID, tokenHash, hashToken(newRefreshToken), now)
+	if errors.Is(err, repository.ErrNotFound) {
+		// Another request rotated or revoked the session after we read it
+		return nil, nil, "", b.handleRetiredRefreshToken(ctx, tokenHash, client)
+	}
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	return session, user, newRefreshToken, nil
+}
+
+// handleRetiredRefreshToken decides what an unrecognized refresh token means and
+// returns the error to report. Tokens rotated out within the grace period come from
+// a concurrent refresh; older ones are treated as stolen and end the session.
+func (b *AuthBusiness) handleRetiredRefreshToken(ctx context.Context, tokenHash string, client ClientInfo) error {
+	retired, err := b.authRepo.GetRefreshTokenHistory(ctx, tokenHash)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+
+	session, err := b.authRepo.GetSessionByID(ctx, retired.SessionID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+
+	if session.RevokedAt == nil && b.now().Sub(retired.RotatedAt) <= b.cfg.RefreshTokenReuseGrace {
+		return ErrRefreshConflict
+	}
+
+	if _, err := b.authRepo.RevokeSession(ctx, session.UserID, session.UUID, revokeReasonRefreshReuse); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	b.audit.Record(ctx, audit.Event{
+		Type:          models.EventRefreshTokenReused,
+		UserID:        &session.UserID,
+		SessionID:     &session.ID,
+		IPAddress:     client.IPAddress,
+		UserAgent:     client.UserAgent,
+		DeviceInfo:    client.DeviceInfo,
+		FailureReason: revokeReasonRefreshReuse,
+		Meta:          map[string]interface{}{"rotated_at": retired.RotatedAt},
+	})
+
+	return ErrInvalidToken
+}
@@ -0,0 +1,230 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"net"
+	"time"
+
+	"github.com/your-project/pkgs/geoip"
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// Risk decisions recorded in login_logs.meta.risk.decision
+const (
+	RiskDecisionAllow  = "allow"
+	RiskDecisionStepUp = "step_up"
+	RiskDecisionBlock  = "block"
+)
+
+// Risk signals recorded in login_logs.meta.risk.reasons, with their score weights
+const (
+	riskReasonImpossibleTravel = "impossible_travel"
+	riskReasonNewDevice        = "new_device"
+	riskReasonNewASN           = "new_asn"
+	riskReasonRecentFailures   = "recent_failures"
+
+	riskWeightImpossibleTravel = 60
+	riskWeightNewDevice        = 20
+	riskWeightNewASN           = 20
+	riskWeightPerFailure       = 10
+	riskMaxFailureWeight       = 30
+)
+
+const (
+	// riskMinTravelKm ignores short hops that GeoIP cannot resolve reliably
+	riskMinTravelKm = 100
+	// riskKnownIPLimit bounds how many earlier addresses are compared for a new ASN
+	riskKnownIPLimit = 50
+)
+
+// riskAssessment is the outcome of scoring a sign-in
+type riskAssessment struct {
+	Score    int
+	Decision string
+	Reasons  []string
+	Details  map[string]interface{}
+}
+
+func (r *riskAssessment) add(reason string, weight int) {
+	r.Score += weight
+	r.Reasons = append(r.Reasons, reason)
+}
+
+// meta is the form stored under login_logs.meta.risk
+func (r *riskAssessment) meta() map[string]interface{} {
+	meta := map[string]interface{}{
+		"score":    r.Score,
+		"decision": r.Decision,
+		"reasons":  r.Reasons,
+	}
+	for key, value := range r.Details {
+		meta[key] = value
+	}
+	return meta
+}
+
+// checkLoginRisk scores a sign-in that has passed its first factor, records the
+// assessment in meta and refuses the sign-in when the score reaches RiskBlockScore
+func (b *AuthBusiness) checkLoginRisk(ctx context.Context, user *models.User, client ClientInfo, meta map[string]interface{}) (*riskAssessment, error) {
+	risk := b.assessLoginRisk(ctx, user, client)
+	meta["risk"] = risk.meta()
+
+	if risk.Decision == RiskDecisionBlock {
+		b.recordLoginEvent(ctx, user, nil, models.EventLoginFailed, client, "risk_blocked", meta)
+		return nil, ErrLoginBlocked
+	}
+	return risk, nil
+}
+
+// assessLoginRisk scores a sign-in against the user's history. Every signal fails
+// open: a lookup error drops that signal rather than blocking the user.
+func (b *AuthBusiness) assessLoginRisk(ctx context.Context, user *models.User, client ClientInfo) *riskAssessment {
+	now := b.now()
+	risk := &riskAssessment{Reasons: []string{}, Details: map[string]interface{}{}}
+	ip := net.ParseIP(client.IPAddress)
+
+	var current *geoip.Location
+	if ip != nil && b.cityDB != nil {
+		if location, found, err := b.cityDB.Location(ip); err != nil {
+			log.Printf("GeoIP city lookup failed: %v", err)
+		} else if found {
+			current = &location
+			risk.Details["country"] = location.CountryCode
+		}
+	}
+
+	success := true
+	previous, err := b.authRepo.ListLoginLogs(ctx, repository.LoginLogFilter{
+		UserID:     &user.ID,
+		EventTypes: []string{models.EventLoginSuccess},
+		Success:    &success,
+		Limit:      1,
+	})
+	if err != nil {
+		log.Printf("Failed to load previous login: %v", err)
+	}
+	// New device and new network only mean something once the user has history
+	hasHistory := len(previous) > 0
+
+	if hasHistory && current != nil && current.HasCoordinates {
+		b.checkImpossibleTravel(risk, previous[0], *current, now)
+	}
+	if hasHistory && b.isNewDevice(ctx, user, client) {
+		risk.add(riskReasonNewDevice, riskWeightNewDevice)
+	}
+	if hasHistory && ip != nil && b.asnDB != nil {
+		b.checkNewASN(ctx, risk, user, ip, now)
+	}
+
+	if b.cfg.RiskFailureWindow > 0 {
+		failures, err := b.authRepo.CountLoginFailures(ctx, user.ID,
+			[]string{models.EventLoginFailed, models.EventMFAFailed}, now.Add(-b.cfg.RiskFailureWindow))
+		if err != nil {
+			log.Printf("Failed to count login failures: %v", err)
+		} else if failures > 0 {
+			risk.add(riskReasonRecentFailures, min(failures*riskWeightPerFailure, riskMaxFailureWeight))
+			risk.Details["recent_failures"] = failures
+		}
+	}
+
+	risk.Decision = RiskDecisionAllow
+	switch {
+	case b.cfg.RiskBlockScore > 0 && risk.Score >= b.cfg.RiskBlockScore:
+		risk.Decision = RiskDecisionBlock
+	case b.cfg.RiskStepUpScore > 0 && risk.Score >= b.cfg.RiskStepUpScore:
+		risk.Decision = RiskDecisionStepUp
+	}
+	return risk
+}
+
+// checkImpossibleTravel flags a sign-in further from the previous one than
+// RiskMaxTravelSpeedKmh allows in the time between them
+func (b *AuthBusiness) checkImpossibleTravel(risk *riskAssessment, previous *models.LoginLog, current geoip.Location, now time.Time) {
+	if previous.IPAddress == nil {
+		return
+	}
+	previousIP := net.ParseIP(*previous.IPAddress)
+	if previousIP == nil {
+		return
+	}
+
+	location, found, err := b.cityDB.Location(previousIP)
+	if err != nil {
+		log.Printf("GeoIP city lookup failed: %v", err)
+		return
+	}
+	if !found || !location.HasCoordinates {
+		return
+	}
+
+	// Give both locations the benefit of their accuracy radius
+	distance := geoip.DistanceKm(location, current) - float64(location.AccuracyRadius+current.AccuracyRadius)
+	if distance < riskMinTravelKm {
+		return
+	}
+
+	hours := math.Max(now.Sub(previous.CreatedAt).Hours(), 1.0/60)
+	speed := distance / hours
+	if speed > b.cfg.RiskMaxTravelSpeedKmh {
+		risk.add(riskReasonImpossibleTravel, riskWeightImpossibleTravel)
+		risk.Details["travel_km"] = math.Round(distance)
+		risk.Details["travel_kmh"] = math.Round(speed)
+	}
+}
+
+// isNewDevice reports whether the user has never signed in from the client's device
+func (b *AuthBusiness) isNewDevice(ctx context.Context, user *models.User, client ClientInfo) bool {
+	deviceID := resolveDeviceID(client)
+	if deviceID == "" {
+		return false
+	}
+
+	_, err := b.authRepo.GetDeviceByDeviceID(ctx, user.ID, deviceID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		log.Printf("Failed to look up device: %v", err)
+		return false
+	}
+	return err != nil
+}
+
+// checkNewASN flags a sign-in from a network the user has not used within RiskHistoryWindow
+func (b *AuthBusiness) checkNewASN(ctx context.Context, risk *riskAssessment, user *models.User, ip net.IP, now time.Time) {
+	current, found, err := b.asnDB.ASN(ip)
+	if err != nil {
+		log.Printf("GeoIP ASN lookup failed: %v", err)
+		return
+	}
+	if !found {
+		return
+	}
+	risk.Details["asn"] = current.Number
+
+	knownIPs, err := b.authRepo.ListKnownIPs(ctx, user.ID, now.Add(-b.cfg.RiskHistoryWindow), riskKnownIPLimit)
+	if err != nil {
+		log.Printf("Failed to list known IPs: %v", err)
+		return
+	}
+
+	compared := false
+	for _, known := range knownIPs {
+		knownIP := net.ParseIP(known)
+		if knownIP == nil {
+			continue
+		}
+		asn, found, err := b.asnDB.ASN(knownIP)
+		if err != nil || !found {
+			continue
+		}
+		if asn.Number == current.Number {
+			return
+		}
+		compared = true
+	}
+	if compared {
+		risk.add(riskReasonNewASN, riskWeightNewASN)
+	}
+}
@@ -0,0 +1,171 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/your-project/services/auth/internal/audit"
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// sessionTouchInterval limits how often last_used_at is written for a busy session
+const sessionTouchInterval = time.Minute
+
+// Reasons recorded in sessions.meta.revoked_reason
+const (
+	revokeReasonUserRequest     = "user_request"
+	revokeReasonIdleTimeout     = "idle_timeout"
+	revokeReasonAbsoluteTimeout = "absolute_timeout"
+)
+
+// ListSessions returns the user's live sessions, most recently used first
+func (b *AuthBusiness) ListSessions(ctx context.Context, userUUID string) ([]*models.Session, error) {
+	user, err := b.getUser(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := b.now()
+	var idleCutoff time.Time
+	if b.cfg.SessionIdleTimeout > 0 {
+		idleCutoff = now.Add(-b.cfg.SessionIdleTimeout)
+	}
+
+	return b.authRepo.ListActiveSessions(ctx, user.ID, now, idleCutoff)
+}
+
+// RevokeSession ends one of the user's sessions. Access tokens carrying its ID stop
+// validating immediately.
+func (b *AuthBusiness) RevokeSession(ctx context.Context, userUUID, sessionUUID string, client ClientInfo) error {
+	if strings.TrimSpace(sessionUUID) == "" {
+		return ErrInvalidArgument
+	}
+
+	user, err := b.getUser(ctx, userUUID)
+	if err != nil {
+		return err
+	}
+
+	session, err := b.authRepo.RevokeSession(ctx, user.ID, sessionUUID, revokeReasonUserRequest)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	b.recordLoginEvent(ctx, user, &session.ID, models.EventSessionRevoked, client, "", map[string]interface{}{"scope": "single"})
+	return nil
+}
+
+// RevokeOtherSessions ends every session of the user except currentSessionUUID
+// and returns how many were revoked
+func (b *AuthBusiness) RevokeOtherSessions(ctx context.Context, userUUID, currentSessionUUID string, client ClientInfo) (int, error) {
+	if strings.TrimSpace(currentSessionUUID) == "" {
+		return 0, ErrInvalidArgument
+	}
+
+	user, err := b.getUser(ctx, userUUID)
+	if err != nil {
+		return 0, err
+	}
+
+	current, err := b.authRepo.GetSessionByUUID(ctx, currentSessionUUID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return 0, ErrSessionNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	if current.UserID != user.ID || current.RevokedAt != nil {
+		return 0, ErrSessionNotFound
+	}
+
+	count, err := b.authRepo.RevokeUserSessions(ctx, user.ID, current.ID, revokeReasonUserRequest)
+	if err != nil {
+		return 0, err
+	}
+
+	b.recordLoginEvent(ctx, user, &current.ID, models.EventSessionRevoked, client, "",
+		map[string]interface{}{"scope": "others", "count": count})
+	return int(count), nil
+}
+
+// RevokeAllSessions ends every session of the user and returns how many were revoked
+func (b *AuthBusiness) RevokeAllSessions(ctx context.Context, userUUID string, client ClientInfo) (int, error) {
+	user, err := b.getUser(ctx, userUUID)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := b.authRepo.RevokeUserSessions(ctx, user.ID, 0, revokeReasonUserRequest)
+	if err != nil {
+		return 0, err
+	}
+
+	b.recordLoginEvent(ctx, user, nil, models.EventSessionRevoked, client, "",
+		map[string]interface{}{"scope": "all", "count": count})
+	return int(count), nil
+}
+
+// checkSession verifies that an access token's session is still live, expiring it
+// when the idle or absolute timeout has passed, and records the activity
+func (b *AuthBusiness) checkSession(ctx context.Context, sessionUUID string) error {
+	if sessionUUID == "" {
+		return ErrInvalidToken
+	}
+
+	session, err := b.authRepo.GetSessionByUUID(ctx, sessionUUID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+	if !session.IsActive || session.RevokedAt != nil {
+		return ErrInvalidToken
+	}
+
+	now := b.now()
+	if reason := b.sessionTimeoutReason(session, now); reason != "" {
+		b.expireSession(ctx, session, reason)
+		return ErrInvalidToken
+	}
+
+	if err := b.authRepo.TouchSession(ctx, session.ID, now, now.Add(-sessionTouchInterval)); err != nil {
+		log.Printf("Failed to record session activity: %v", err)
+	}
+	return nil
+}
+
+// sessionTimeoutReason returns the timeout a session has hit, or "" if it is still live
+func (b *AuthBusiness) sessionTimeoutReason(session *models.Session, now time.Time) string {
+	if !now.Before(session.ExpiresAt) {
+		return revokeReasonAbsoluteTimeout
+	}
+	if b.cfg.SessionIdleTimeout > 0 && now.Sub(session.LastUsedAt) >= b.cfg.SessionIdleTimeout {
+		return revokeReasonIdleTimeout
+	}
+	return ""
+}
+
+// expireSession deactivates a timed-out session and logs session_expired
+func (b *AuthBusiness) expireSession(ctx context.Context, session *models.Session, reason string) {
+	if _, err := b.authRepo.RevokeSession(ctx, session.UserID, session.UUID, reason); err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			log.Printf("Failed to expire session: %v", err)
+		}
+		return
+	}
+
+	b.audit.Record(ctx, audit.Event{
+		Type:      models.EventSessionExpired,
+		UserID:    &session.UserID,
+		SessionID: &session.ID,
+		Meta:      map[string]interface{}{"reason": reason},
+	})
+}
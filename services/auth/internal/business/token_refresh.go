@@ -0,0 +1,197 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/your-project/pkgs/oauth2"
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// TokenRefreshResult summarises one RefreshProviderTokens run
+type TokenRefreshResult struct {
+	Refreshed int
+	// Revoked links had their refresh token rejected and were marked inactive
+	Revoked int
+	// Failed links are retried after a backoff
+	Failed int
+	// Skipped links were locked by another replica or no longer due
+	Skipped int
+}
+
+// tokenRefreshOutcome is the result of refreshing a single link
+type tokenRefreshOutcome int
+
+const (
+	tokenRefreshSkipped tokenRefreshOutcome = iota
+	tokenRefreshed
+	tokenRefreshRevoked
+	tokenRefreshFailed
+)
+
+// RefreshProviderTokens refreshes upstream access tokens that expire within
+// OAUTH_TOKEN_REFRESH_WINDOW, at most OAUTH_TOKEN_REFRESH_CONCURRENCY at a time.
+// Each link is refreshed under an advisory lock, so replicas can run it concurrently.
+func (b *AuthBusiness) RefreshProviderTokens(ctx context.Context) (*TokenRefreshResult, error) {
+	now := b.now()
+	links, err := b.authRepo.ListUserProvidersDueForRefresh(ctx, now.Add(b.cfg.OAuthTokenRefreshWindow), now,
+		b.cfg.OAuthTokenRefreshBatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		result TokenRefreshResult
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+	)
+	concurrency := b.cfg.OAuthTokenRefreshConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	slots := make(chan struct{}, concurrency)
+
+dispatch:
+	for _, link := range links {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case slots <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(linkID int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			outcome := b.refreshLinkTokens(ctx, linkID)
+			mu.Lock()
+			defer mu.Unlock()
+			switch outcome {
+			case tokenRefreshed:
+				result.Refreshed++
+			case tokenRefreshRevoked:
+				result.Revoked++
+			case tokenRefreshFailed:
+				result.Failed++
+			default:
+				result.Skipped++
+			}
+		}(link.ID)
+	}
+	wg.Wait()
+
+	return &result, nil
+}
+
+// refreshLinkTokens refreshes one link. The link is read again under the lock
+// because another replica may have refreshed it since it was listed.
+func (b *AuthBusiness) refreshLinkTokens(ctx context.Context, linkID int) tokenRefreshOutcome {
+	unlock, locked, err := b.authRepo.TryLockUserProviderRefresh(ctx, linkID)
+	if err != nil {
+		log.Printf("Token refresh for provider link %d failed to lock: %v", linkID, err)
+		return tokenRefreshFailed
+	}
+	if !locked {
+		return tokenRefreshSkipped
+	}
+	defer unlock()
+
+	now := b.now()
+	link, err := b.authRepo.GetUserProviderByID(ctx, linkID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return tokenRefreshSkipped
+	}
+	if err != nil {
+		log.Printf("Token refresh for provider link %d failed to load the link: %v", linkID, err)
+		return tokenRefreshFailed
+	}
+	if !link.IsActive || link.RefreshToken == nil || link.ExpiresAt == nil ||
+		!link.ExpiresAt.Before(now.Add(b.cfg.OAuthTokenRefreshWindow)) {
+		return tokenRefreshSkipped
+	}
+
+	provider, err := b.providerByID(ctx, link.ProviderID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && (!provider.IsActive || !provider.IsEnabled)) {
+		return tokenRefreshSkipped
+	}
+	if err != nil {
+		log.Printf("Token refresh for provider link %d failed to load the provider: %v", linkID, err)
+		return tokenRefreshFailed
+	}
+
+	conf := b.oauthConfig(provider)
+	if conf.ClientSecret, err = b.clientSecret(provider); err != nil {
+		return b.recordRefreshFailure(ctx, link, now, "client_secret_unavailable", err)
+	}
+	token, err := conf.Refresh(ctx, b.httpClient, *link.RefreshToken)
+	if err != nil {
+		var oauthErr *oauth2.Error
+		if errors.As(err, &oauthErr) && oauthErr.Code == "invalid_grant" {
+			// The user revoked access or the refresh token expired; only a new sign-in can fix it
+			if err := b.authRepo.DeactivateUserProvider(ctx, link.ID, "refresh_token_revoked"); err != nil {
+				log.Printf("Token refresh for provider link %d failed to deactivate the link: %v", linkID, err)
+				return tokenRefreshFailed
+			}
+			return tokenRefreshRevoked
+		}
+		reason := "request_failed"
+		if oauthErr != nil && oauthErr.Code != "" {
+			reason = oauthErr.Code
+		}
+		return b.recordRefreshFailure(ctx, link, now, reason, err)
+	}
+
+	refreshed := &models.UserProvider{
+		ID:           link.ID,
+		AccessToken:  optionalString(token.AccessToken),
+		RefreshToken: optionalString(token.RefreshToken),
+		TokenType:    optionalString(token.TokenType),
+	}
+	if !token.Expiry.IsZero() {
+		expiry := token.Expiry
+		refreshed.ExpiresAt = &expiry
+	}
+	if err := b.authRepo.StoreRefreshedUserProviderTokens(ctx, refreshed); err != nil {
+		log.Printf("Token refresh for provider link %d failed to store the tokens: %v", linkID, err)
+		return tokenRefreshFailed
+	}
+	return tokenRefreshed
+}
+
+// recordRefreshFailure schedules the next attempt with exponential backoff
+func (b *AuthBusiness) recordRefreshFailure(ctx context.Context, link *models.UserProvider, now time.Time, reason string, cause error) tokenRefreshOutcome {
+	failures := 1
+	if previous, ok := link.Meta["refresh_failures"].(float64); ok {
+		failures = int(previous) + 1
+	}
+	retryAt := now.Add(refreshBackoff(b.cfg.OAuthTokenRefreshInterval, b.cfg.OAuthTokenRefreshMaxBackoff, failures))
+
+	log.Printf("Token refresh for provider link %d failed (attempt %d, retry at %s): %v",
+		link.ID, failures, retryAt.Format(time.RFC3339), cause)
+	if err := b.authRepo.RecordUserProviderRefreshFailure(ctx, link.ID, failures, retryAt, reason); err != nil {
+		log.Printf("Token refresh for provider link %d failed to record the failure: %v", link.ID, err)
+	}
+	return tokenRefreshFailed
+}
+
+// refreshBackoff doubles base for every consecutive failure up to max, with up
+// to 10% jitter so replicas do not retry a struggling provider in lockstep
+func refreshBackoff(base, max time.Duration, failures int) time.Duration {
+	if base <= 0 {
+		base = time.Minute
+	}
+	delay := base
+	for i := 1; i < failures && delay < max; i++ {
+		delay *= 2
+	}
+	if max > 0 && delay > max {
+		delay = max
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)/10+1))
+}
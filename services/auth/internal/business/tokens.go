@@ -0,0 +1,171 @@
+package business
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/your-project/pkgs/jwt"
+	"github.com/your-project/services/auth/internal/models"
+)
+
+// Token types carried in the "type" claim
+const (
+	TokenTypeAccess     = "access"
+	TokenTypeMFAPending = "mfa_pending"
+)
+
+// TokenClaims are the JWT claims issued by the auth service. Subject is the
+// user UUID, or "client:<client_id>" for client credentials tokens.
+type TokenClaims struct {
+	jwt.BaseClaims
+	Email     string `json:"email,omitempty"`
+	Type      string `json:"type"`
+	SessionID string `json:"sid,omitempty"`
+	// Scope and ClientID are set on tokens issued to OAuth clients
+	Scope    string `json:"scope,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	// APIKeyID is set on tokens obtained with ValidateAPIKey
+	APIKeyID string `json:"api_key_id,omitempty"`
+	// Act names the admin on tokens issued by Impersonate (RFC 8693)
+	Act *ActorClaim `json:"act,omitempty"`
+}
+
+// ActorClaim identifies who is acting on behalf of the token's subject
+type ActorClaim struct {
+	// Subject is the admin's user UUID
+	Subject string `json:"sub"`
+}
+
+// SetExpiry sets the token expiration time
+func (c *TokenClaims) SetExpiry(expiry time.Duration) {
+	c.BaseClaims.SetExpiry(expiry)
+}
+
+// TokenPair is the result of a completed sign-in
+type TokenPair struct {
+	AccessToken           string
+	AccessTokenExpiresAt  time.Time
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
+	SessionUUID           string
+}
+
+// ValidateAccessToken parses an access token, rejects any other token type and
+// checks that the session it was issued under is still live
+func (b *AuthBusiness) ValidateAccessToken(ctx context.Context, token string) (*TokenClaims, error) {
+	claims, err := b.parseToken(token, TokenTypeAccess)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.checkSession(ctx, claims.SessionID); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// issueToken signs a token of the given type for user
+func (b *AuthBusiness) issueToken(user *models.User, tokenType, sessionUUID string, expiry time.Duration) (string, time.Time, error) {
+	jti, err := generateOpaqueToken(16)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return b.signToken(user, tokenType, jti, sessionUUID, expiry)
+}
+
+// signToken signs a token of the given type for user with a caller-chosen jti
+func (b *AuthBusiness) signToken(user *models.User, tokenType, jti, sessionUUID string, expiry time.Duration) (string, time.Time, error) {
+	now := b.now()
+	claims := &TokenClaims{
+		Email:     user.Email,
+		Type:      tokenType,
+		SessionID: sessionUUID,
+	}
+	if tokenType == TokenTypeAccess {
+		claims.Scope = b.verificationScope(user, "")
+	}
+	claims.SetIssuer(b.cfg.JWTIssuer)
+	claims.SetSubject(user.UUID)
+	claims.SetIssuedAt(now)
+	claims.SetID(jti)
+
+	token, err := b.tokens.GenerateTokenWithExpiry(claims, expiry)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign %s token: %w", tokenType, err)
+	}
+
+	return token, now.Add(expiry), nil
+}
+
+// parseToken validates a token signature, expiry and type
+func (b *AuthBusiness) parseToken(token, expectedType string) (*TokenClaims, error) {
+	claims := &TokenClaims{}
+	if err := b.tokens.ParseToken(token, claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.Type != expectedType || claims.Subject == "" {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// startSession creates a session row and issues the access/refresh token pair for it
+func (b *AuthBusiness) startSession(ctx context.Context, user *models.User, client ClientInfo, meta map[string]interface{}) (*models.Session, *TokenPair, error) {
+	refreshToken, err := generateOpaqueToken(32)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session := &models.Session{
+		UserID:           user.ID,
+		RefreshTokenHash: hashToken(refreshToken),
+		DeviceInfo:       client.DeviceInfo,
+		IPAddress:        optionalString(client.IPAddress),
+		UserAgent:        optionalString(client.UserAgent),
+		ExpiresAt:        b.now().Add(b.cfg.RefreshTokenExpiry),
+		Meta:             meta,
+	}
+	if err := b.authRepo.CreateSession(ctx, session); err != nil {
+		return nil, nil, err
+	}
+
+	accessToken, accessExpiresAt, err := b.issueToken(user, TokenTypeAccess, session.UUID, b.cfg.AccessTokenExpiry)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return session, &TokenPair{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessExpiresAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: session.ExpiresAt,
+		SessionUUID:           session.UUID,
+	}, nil
+}
+
+// generateOpaqueToken returns a URL-safe random token with n bytes of entropy
+func generateOpaqueToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken returns the SHA-256 hex digest stored for high-entropy secrets
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// optionalString returns nil for empty strings
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
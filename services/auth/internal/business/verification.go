@@ -0,0 +1,294 @@
+package business
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/your-project/services/auth/internal/config"
+	"github.com/your-project/services/auth/internal/mailer"
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+	"github.com/your-project/services/auth/internal/sms"
+)
+
+// verificationCodeDigits is the length of the numeric codes sent for verification
+const verificationCodeDigits = 6
+
+// StartVerificationInput sends a code to one of the user's addresses
+type StartVerificationInput struct {
+	UserID string
+	// Channel is models.VerificationChannelEmail or models.VerificationChannelPhone
+	Channel string
+	Client  ClientInfo
+}
+
+// ConfirmVerificationInput redeems a code sent by StartVerification
+type ConfirmVerificationInput struct {
+	UserID  string
+	Channel string
+	Code    string
+	Client  ClientInfo
+}
+
+// VerificationChallenge describes a code that was just sent
+type VerificationChallenge struct {
+	Channel string
+	// Destination is the address the code went to, masked for display
+	Destination string
+	ExpiresAt   time.Time
+	// ResendAfter is the earliest time another code can be requested
+	ResendAfter time.Time
+}
+
+// StartVerification sends a one-time code to the user's email address or phone
+// number. Only the latest code can be confirmed. Requests inside
+// VerificationResendCooldown, or beyond VerificationMaxSendsPerHour, are
+// rejected with ErrVerificationCooldown.
+func (b *AuthBusiness) StartVerification(ctx context.Context, input StartVerificationInput) (*VerificationChallenge, error) {
+	channel, useCase, err := verificationUseCase(input.Channel)
+	if err != nil {
+		return nil, err
+	}
+	user, err := b.getUser(ctx, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrAccountDisabled
+	}
+	destination, verifiedAt, err := verificationDestination(user, channel)
+	if err != nil {
+		return nil, err
+	}
+	if verifiedAt != nil {
+		return nil, ErrAlreadyVerified
+	}
+
+	now := b.now()
+	sent, lastSentAt, err := b.authRepo.OTPSendStats(ctx, user.ID, useCase, now.Add(-time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	if retryAt := b.verificationRetryAt(sent, lastSentAt); retryAt.After(now) {
+		return nil, fmt.Errorf("%w (retry in %ds)", ErrVerificationCooldown, int(math.Ceil(retryAt.Sub(now).Seconds())))
+	}
+
+	code, err := generateVerificationCode()
+	if err != nil {
+		return nil, err
+	}
+	otp := &models.OTPToken{
+		UserID:      user.ID,
+		CodeHash:    hashVerificationCode(user.ID, useCase, code),
+		UseCase:     useCase,
+		Destination: destination,
+		IPAddress:   optionalString(input.Client.IPAddress),
+		ExpiresAt:   now.Add(b.cfg.VerificationCodeExpiry),
+	}
+	if err := b.authRepo.CreateOTP(ctx, otp); err != nil {
+		return nil, err
+	}
+
+	if err := b.sendVerificationCode(ctx, channel, destination, code); err != nil {
+		return nil, err
+	}
+	b.recordLoginEvent(ctx, user, nil, models.EventVerificationSent, input.Client, "", map[string]interface{}{"channel": channel})
+
+	return &VerificationChallenge{
+		Channel:     channel,
+		Destination: maskDestination(channel, destination),
+		ExpiresAt:   otp.ExpiresAt,
+		ResendAfter: b.verificationRetryAt(sent+1, now),
+	}, nil
+}
+
+// ConfirmVerification checks a code from StartVerification and marks the
+// channel verified. The code only counts for the address it was sent to, so
+// it is refused if the user changed that address meanwhile.
+func (b *AuthBusiness) ConfirmVerification(ctx context.Context, input ConfirmVerificationInput) (*models.User, error) {
+	channel, useCase, err := verificationUseCase(input.Channel)
+	if err != nil {
+		return nil, err
+	}
+	code := strings.TrimSpace(input.Code)
+	if code == "" {
+		return nil, fmt.Errorf("%w: code is required", ErrInvalidArgument)
+	}
+	if !isVerificationCode(code) {
+		return nil, ErrInvalidVerificationCode
+	}
+	user, err := b.getUser(ctx, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrAccountDisabled
+	}
+	meta := map[string]interface{}{"channel": channel}
+
+	otp, err := b.authRepo.ConsumeOTP(ctx, user.ID, useCase, hashVerificationCode(user.ID, useCase, code), b.cfg.VerificationMaxAttempts)
+	switch {
+	case errors.Is(err, repository.ErrCodeMismatch):
+		b.recordLoginEvent(ctx, user, nil, models.EventVerificationFailed, input.Client, "invalid_code", meta)
+		return nil, ErrInvalidVerificationCode
+	case errors.Is(err, repository.ErrNotFound):
+		b.recordLoginEvent(ctx, user, nil, models.EventVerificationFailed, input.Client, "no_active_code", meta)
+		return nil, ErrInvalidVerificationCode
+	case err != nil:
+		return nil, err
+	}
+
+	verified, err := b.authRepo.MarkUserVerified(ctx, user.ID, channel, otp.Destination)
+	if errors.Is(err, repository.ErrNotFound) {
+		b.recordLoginEvent(ctx, user, nil, models.EventVerificationFailed, input.Client, "destination_changed", meta)
+		return nil, ErrInvalidVerificationCode
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	b.recordLoginEvent(ctx, verified, nil, models.EventVerificationSucceeded, input.Client, "", meta)
+	return verified, nil
+}
+
+// unverifiedChannels returns the channels in VerificationRequiredChannels the
+// user has not verified
+func (b *AuthBusiness) unverifiedChannels(user *models.User) []string {
+	var missing []string
+	for _, channel := range b.cfg.VerificationRequiredChannels {
+		if _, verifiedAt, _ := verificationDestination(user, channel); verifiedAt == nil {
+			missing = append(missing, channel)
+		}
+	}
+	return missing
+}
+
+// verificationScope returns the scope for an access token issued to user. Under
+// the restrict_scopes policy an unverified user only keeps the scopes in
+// VerificationUnverifiedScopes; first-party tokens, which otherwise carry no
+// scope at all, get exactly that list.
+func (b *AuthBusiness) verificationScope(user *models.User, scope string) string {
+	if b.cfg.VerificationPolicy != config.VerificationPolicyRestrictScopes || len(b.unverifiedChannels(user)) == 0 {
+		return scope
+	}
+	if scope == "" {
+		return strings.Join(b.cfg.VerificationUnverifiedScopes, " ")
+	}
+
+	var kept []string
+	for _, s := range strings.Fields(scope) {
+		if hasScope(b.cfg.VerificationUnverifiedScopes, s) {
+			kept = append(kept, s)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+// verificationRetryAt returns when another code may be sent, given how many
+// were sent in the last hour and when the latest one went out
+func (b *AuthBusiness) verificationRetryAt(sentLastHour int, lastSentAt time.Time) time.Time {
+	if lastSentAt.IsZero() {
+		return time.Time{}
+	}
+	if b.cfg.VerificationMaxSendsPerHour > 0 && sentLastHour >= b.cfg.VerificationMaxSendsPerHour {
+		// The oldest send in the window is not known; the latest one bounds it
+		return lastSentAt.Add(time.Hour)
+	}
+	return lastSentAt.Add(b.cfg.VerificationResendCooldown)
+}
+
+func (b *AuthBusiness) sendVerificationCode(ctx context.Context, channel, destination, code string) error {
+	expiry := b.cfg.VerificationCodeExpiry
+	if channel == models.VerificationChannelPhone {
+		if err := b.sms.Send(ctx, sms.Message{
+			To:   destination,
+			Body: fmt.Sprintf("Your verification code is %s. It expires in %s.", code, expiry),
+		}); err != nil {
+			return fmt.Errorf("failed to send verification code: %w", err)
+		}
+		return nil
+	}
+
+	if err := b.mailer.Send(ctx, mailer.Message{
+		To:      destination,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Your verification code is %s.\n\nIt expires in %s. If you did not request this, you can ignore this email.",
+			code, expiry),
+	}); err != nil {
+		return fmt.Errorf("failed to send verification code: %w", err)
+	}
+	return nil
+}
+
+// verificationUseCase validates a channel name and returns it with its OTP use case
+func verificationUseCase(channel string) (string, string, error) {
+	switch channel = strings.ToLower(strings.TrimSpace(channel)); channel {
+	case models.VerificationChannelEmail:
+		return channel, models.OTPUseCaseEmailVerification, nil
+	case models.VerificationChannelPhone:
+		return channel, models.OTPUseCasePhoneVerification, nil
+	default:
+		return "", "", fmt.Errorf("%w: channel must be %q or %q", ErrInvalidArgument,
+			models.VerificationChannelEmail, models.VerificationChannelPhone)
+	}
+}
+
+// verificationDestination returns the user's address on channel and when it was verified
+func verificationDestination(user *models.User, channel string) (string, *time.Time, error) {
+	if channel == models.VerificationChannelPhone {
+		if user.Phone == nil || *user.Phone == "" {
+			return "", nil, ErrNoPhoneNumber
+		}
+		return *user.Phone, user.PhoneVerifiedAt, nil
+	}
+	return user.Email, user.EmailVerifiedAt, nil
+}
+
+// generateVerificationCode returns a uniformly random numeric code
+func generateVerificationCode() (string, error) {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(verificationCodeDigits), nil)
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate verification code: %w", err)
+	}
+	return fmt.Sprintf("%0*d", verificationCodeDigits, n.Int64()), nil
+}
+
+// hashVerificationCode binds the stored hash to the user and use case, so equal
+// codes never produce equal hashes
+func hashVerificationCode(userID int, useCase, code string) string {
+	return hashToken(fmt.Sprintf("%d:%s:%s", userID, useCase, code))
+}
+
+func isVerificationCode(code string) bool {
+	if len(code) != verificationCodeDigits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// maskDestination hides most of an address: "j***@example.com", "***4567"
+func maskDestination(channel, destination string) string {
+	if channel == models.VerificationChannelPhone {
+		if len(destination) <= 4 {
+			return "***"
+		}
+		return "***" + destination[len(destination)-4:]
+	}
+	local, domain, ok := strings.Cut(destination, "@")
+	if !ok || local == "" {
+		return "***"
+	}
+	return local[:1] + "***@" + domain
+}
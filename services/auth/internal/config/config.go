@@ -5,9 +5,13 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	_ "github.com/lib/pq"
+
+	"github.com/your-project/pkgs/envelope"
+	"github.com/your-project/pkgs/jwt"
 )
 
 // Config holds all configuration for the auth service
@@ -15,6 +19,207 @@ type Config struct {
 	Port              string
 	DBConnectionURL   string
 	MaxConnectionPool int
+
+	// Token settings
+	JWTSecret          string
+	JWTIssuer          string
+	AccessTokenExpiry  time.Duration
+	RefreshTokenExpiry time.Duration
+
+	// SessionIdleTimeout ends sessions unused for this long; RefreshTokenExpiry is the absolute limit
+	SessionIdleTimeout time.Duration
+	// RefreshTokenReuseGrace is how long a rotated refresh token is treated as a
+	// concurrent retry rather than theft
+	RefreshTokenReuseGrace time.Duration
+
+	// Two-factor authentication settings. An mfa_pending token is burned after
+	// MFAMaxAttempts codes; MFAMaxFailures wrong codes within MFAFailureWindow
+	// lock the user's second factor until the window has passed.
+	MFATokenExpiry   time.Duration
+	MFAMaxAttempts   int
+	MFAMaxFailures   int
+	MFAFailureWindow time.Duration
+	TOTPIssuer       string
+	// DeviceTrustDuration is how long a trusted device may skip the second factor (0 disables)
+	DeviceTrustDuration time.Duration
+
+	// WebAuthn (passkey) relying party settings
+	WebAuthnRPID            string
+	WebAuthnRPName          string
+	WebAuthnOrigins         []string
+	WebAuthnChallengeExpiry time.Duration
+
+	// Risk-based authentication. Scores at or above RiskStepUpScore require the
+	// second factor even on trusted devices; at or above RiskBlockScore the sign-in
+	// is refused. 0 disables either action.
+	GeoIPCityDB           string
+	GeoIPASNDB            string
+	RiskStepUpScore       int
+	RiskBlockScore        int
+	RiskMaxTravelSpeedKmh float64
+	RiskFailureWindow     time.Duration
+	RiskHistoryWindow     time.Duration
+
+	// Login audit log retention; rows older than LoginLogRetention are moved to the archive (0 disables)
+	LoginLogRetention        time.Duration
+	LoginLogArchiveInterval  time.Duration
+	LoginLogArchiveBatchSize int
+
+	// Maintenance job. Every MaintenanceInterval (0 disables) one replica deletes
+	// rows that expired, or were revoked or used, longer ago than their table's
+	// MaintenanceRetention (0 keeps them), MaintenanceBatchSize rows per
+	// statement. MetricsPort serves expvar metrics, including rows removed per
+	// run, at /debug/vars (empty disables).
+	MaintenanceInterval  time.Duration
+	MaintenanceBatchSize int
+	MaintenanceRetention map[string]time.Duration
+	MetricsPort          string
+
+	// Email delivery (development sinks)
+	MailSink string
+	MailDir  string
+
+	// SMS delivery (development sinks)
+	SMSSink string
+	SMSDir  string
+
+	// EventSink publishes account events for other services (development sink)
+	EventSink string
+
+	// Passwords. Reset links expire after PasswordResetExpiry.
+	PasswordMinLength   int
+	PasswordResetURL    string
+	PasswordResetExpiry time.Duration
+
+	// Email and phone changes. The new address confirms within ContactChangeExpiry;
+	// the old one is then sent an undo link valid for ContactChangeUndoWindow.
+	ContactChangeConfirmURL string
+	ContactChangeUndoURL    string
+	ContactChangeExpiry     time.Duration
+	ContactChangeUndoWindow time.Duration
+
+	// Account deletion. Deleted accounts can be restored through the link sent to
+	// AccountRestoreURL for AccountDeletionGrace; after that the purge job, run
+	// every AccountPurgeInterval, removes or anonymizes (AccountPurgeMode) up to
+	// AccountPurgeBatchSize accounts per transaction.
+	AccountDeletionGrace  time.Duration
+	AccountRestoreURL     string
+	AccountPurgeInterval  time.Duration
+	AccountPurgeBatchSize int
+	AccountPurgeMode      string
+
+	// Impersonation. Only users in ImpersonationAdmins (UUIDs) may impersonate;
+	// the token lasts ImpersonationTokenExpiry, cannot be refreshed and carries
+	// ImpersonationScopes only.
+	ImpersonationAdmins      []string
+	ImpersonationTokenExpiry time.Duration
+	ImpersonationScopes      []string
+
+	// Email and phone verification. Codes expire after VerificationCodeExpiry and
+	// are burned after VerificationMaxAttempts wrong guesses. A new code can be
+	// sent once VerificationResendCooldown has passed, at most
+	// VerificationMaxSendsPerHour times per channel.
+	VerificationCodeExpiry      time.Duration
+	VerificationMaxAttempts     int
+	VerificationResendCooldown  time.Duration
+	VerificationMaxSendsPerHour int
+	// VerificationPolicy applies to users who have not verified every channel in
+	// VerificationRequiredChannels: VerificationPolicyRestrictScopes limits their
+	// access tokens to VerificationUnverifiedScopes, VerificationPolicyBlockLogin
+	// refuses the sign-in until they verify.
+	VerificationPolicy           string
+	VerificationRequiredChannels []string
+	VerificationUnverifiedScopes []string
+
+	// Magic link sign-in settings
+	MagicLinkURL               string
+	MagicLinkExpiry            time.Duration
+	MagicLinkRequireSameDevice bool
+
+	// OAuth sign-in settings. OAuthRedirectURL may contain a {provider} placeholder;
+	// a provider's own redirect_uri takes precedence.
+	OAuthRedirectURL string
+	OAuthStateExpiry time.Duration
+	OAuthHTTPTimeout time.Duration
+	// OAuthLinkExpiry is how long a sign-in that matched an existing account's
+	// email can be confirmed as a link after re-authenticating
+	OAuthLinkExpiry time.Duration
+	// OIDCCacheTTL is how long provider discovery documents and signing keys are cached
+	OIDCCacheTTL time.Duration
+	// Background refresh of upstream access tokens stored on provider links.
+	// Tokens expiring within OAuthTokenRefreshWindow are refreshed every
+	// OAuthTokenRefreshInterval (0 disables); failures back off exponentially
+	// up to OAuthTokenRefreshMaxBackoff.
+	OAuthTokenRefreshInterval    time.Duration
+	OAuthTokenRefreshWindow      time.Duration
+	OAuthTokenRefreshConcurrency int
+	OAuthTokenRefreshBatchSize   int
+	OAuthTokenRefreshMaxBackoff  time.Duration
+	// ProviderCacheRefreshInterval is how often the provider cache checks the
+	// database for changes made by other replicas (0 disables the cache)
+	ProviderCacheRefreshInterval time.Duration
+
+	// OAuth 2.1 / OpenID Connect authorization server. HTTPPort serves /authorize,
+	// /token, /userinfo and discovery; OAuthIssuer is the public base URL of those
+	// endpoints and the "iss" of the ID and access tokens they issue.
+	HTTPPort    string
+	OAuthIssuer string
+	// OAuthLoginURL is the sign-in page /authorize sends the browser to with ?request_id=
+	OAuthLoginURL                   string
+	OAuthAuthorizationRequestExpiry time.Duration
+	OAuthAuthorizationCodeExpiry    time.Duration
+	// OAuthSigningKeyFiles are PEM private keys for ID and access tokens, current
+	// key first; the others only verify tokens issued before a rotation
+	OAuthSigningKeyFiles []string
+	// ServiceTokenExpiry is the lifetime of client_credentials access tokens
+	// issued to service accounts
+	ServiceTokenExpiry time.Duration
+
+	// API keys. New keys expire after APIKeyDefaultExpiry unless the caller asks
+	// for another lifetime, at most APIKeyMaxExpiry. A rotated key keeps working
+	// for APIKeyRotationGrace; ValidateAPIKey tokens live APIKeyTokenExpiry.
+	// Personal keys may only carry APIKeyUserScopes.
+	APIKeyDefaultExpiry time.Duration
+	APIKeyMaxExpiry     time.Duration
+	APIKeyRotationGrace time.Duration
+	APIKeyTokenExpiry   time.Duration
+	APIKeyUserScopes    []string
+
+	// Encryption at rest for provider secrets and OAuth tokens. Master keys are
+	// "id:base64key" entries from EncryptionKeys and the file EncryptionKeyFile;
+	// EncryptionKeyID selects the key for new values (default: the last one).
+	EncryptionKeys    string
+	EncryptionKeyFile string
+	EncryptionKeyID   string
+}
+
+// Verification policies accepted in VERIFICATION_POLICY
+const (
+	VerificationPolicyNone           = "none"
+	VerificationPolicyRestrictScopes = "restrict_scopes"
+	VerificationPolicyBlockLogin     = "block_login"
+)
+
+// Purge modes accepted in ACCOUNT_PURGE_MODE
+const (
+	AccountPurgeAnonymize = "anonymize"
+	AccountPurgeDelete    = "delete"
+)
+
+// defaultMaintenanceRetention is how long the maintenance job keeps dead rows
+// of each table it cleans up
+func defaultMaintenanceRetention() map[string]time.Duration {
+	return map[string]time.Duration{
+		"otp_tokens":           24 * time.Hour,
+		"action_tokens":        7 * 24 * time.Hour,
+		"webauthn_challenges":  24 * time.Hour,
+		"oauth_states":         24 * time.Hour,
+		"oauth_authorizations": 7 * 24 * time.Hour,
+		"contact_changes":      30 * 24 * time.Hour,
+		"mfa_recovery_codes":   30 * 24 * time.Hour,
+		"sessions":             30 * 24 * time.Hour,
+		"user_providers":       30 * 24 * time.Hour,
+	}
 }
 
 // Load loads configuration from environment variables
@@ -32,10 +237,401 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("DB_CONNECTION_URL environment variable is required")
 	}
 
+	accessTokenExpiry, err := ParseDuration(GetEnv("JWT_ACCESS_TOKEN_EXPIRY", "15m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_ACCESS_TOKEN_EXPIRY value: %v", err)
+	}
+
+	refreshTokenExpiry, err := ParseDuration(GetEnv("JWT_REFRESH_TOKEN_EXPIRY", "7d"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_REFRESH_TOKEN_EXPIRY value: %v", err)
+	}
+
+	sessionIdleTimeout, err := ParseDuration(GetEnv("SESSION_IDLE_TIMEOUT", "3d"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SESSION_IDLE_TIMEOUT value: %v", err)
+	}
+
+	refreshTokenReuseGrace, err := ParseDuration(GetEnv("REFRESH_TOKEN_REUSE_GRACE", "10s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid REFRESH_TOKEN_REUSE_GRACE value: %v", err)
+	}
+
+	mfaTokenExpiry, err := ParseDuration(GetEnv("MFA_TOKEN_EXPIRY", "5m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid MFA_TOKEN_EXPIRY value: %v", err)
+	}
+
+	mfaMaxAttempts, err := strconv.Atoi(GetEnv("MFA_MAX_ATTEMPTS", "5"))
+	if err != nil || mfaMaxAttempts < 1 {
+		return nil, fmt.Errorf("invalid MFA_MAX_ATTEMPTS value: %v", err)
+	}
+
+	mfaMaxFailures, err := strconv.Atoi(GetEnv("MFA_MAX_FAILURES", "10"))
+	if err != nil || mfaMaxFailures < 1 {
+		return nil, fmt.Errorf("invalid MFA_MAX_FAILURES value: %v", err)
+	}
+
+	mfaFailureWindow, err := ParseDuration(GetEnv("MFA_FAILURE_WINDOW", "1h"))
+	if err != nil || mfaFailureWindow <= 0 {
+		return nil, fmt.Errorf("invalid MFA_FAILURE_WINDOW value: %v", err)
+	}
+
+	deviceTrustDuration, err := ParseDuration(GetEnv("DEVICE_TRUST_DURATION", "30d"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DEVICE_TRUST_DURATION value: %v", err)
+	}
+
+	webAuthnChallengeExpiry, err := ParseDuration(GetEnv("WEBAUTHN_CHALLENGE_EXPIRY", "5m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid WEBAUTHN_CHALLENGE_EXPIRY value: %v", err)
+	}
+
+	riskStepUpScore, err := strconv.Atoi(GetEnv("RISK_STEP_UP_SCORE", "40"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RISK_STEP_UP_SCORE value: %v", err)
+	}
+
+	riskBlockScore, err := strconv.Atoi(GetEnv("RISK_BLOCK_SCORE", "80"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RISK_BLOCK_SCORE value: %v", err)
+	}
+
+	riskMaxTravelSpeedKmh, err := strconv.ParseFloat(GetEnv("RISK_MAX_TRAVEL_SPEED_KMH", "1000"), 64)
+	if err != nil || riskMaxTravelSpeedKmh <= 0 {
+		return nil, fmt.Errorf("invalid RISK_MAX_TRAVEL_SPEED_KMH value: %q", GetEnv("RISK_MAX_TRAVEL_SPEED_KMH", "1000"))
+	}
+
+	riskFailureWindow, err := ParseDuration(GetEnv("RISK_FAILURE_WINDOW", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RISK_FAILURE_WINDOW value: %v", err)
+	}
+
+	riskHistoryWindow, err := ParseDuration(GetEnv("RISK_HISTORY_WINDOW", "90d"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RISK_HISTORY_WINDOW value: %v", err)
+	}
+
+	loginLogRetention, err := ParseDuration(GetEnv("LOGIN_LOG_RETENTION", "90d"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LOGIN_LOG_RETENTION value: %v", err)
+	}
+
+	loginLogArchiveInterval, err := ParseDuration(GetEnv("LOGIN_LOG_ARCHIVE_INTERVAL", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LOGIN_LOG_ARCHIVE_INTERVAL value: %v", err)
+	}
+
+	loginLogArchiveBatchSize, err := strconv.Atoi(GetEnv("LOGIN_LOG_ARCHIVE_BATCH_SIZE", "1000"))
+	if err != nil || loginLogArchiveBatchSize <= 0 {
+		return nil, fmt.Errorf("invalid LOGIN_LOG_ARCHIVE_BATCH_SIZE value: %q", GetEnv("LOGIN_LOG_ARCHIVE_BATCH_SIZE", "1000"))
+	}
+
+	maintenanceInterval, err := ParseDuration(GetEnv("MAINTENANCE_INTERVAL", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid MAINTENANCE_INTERVAL value: %v", err)
+	}
+
+	maintenanceBatchSize, err := strconv.Atoi(GetEnv("MAINTENANCE_BATCH_SIZE", "1000"))
+	if err != nil || maintenanceBatchSize <= 0 {
+		return nil, fmt.Errorf("invalid MAINTENANCE_BATCH_SIZE value: %q", GetEnv("MAINTENANCE_BATCH_SIZE", "1000"))
+	}
+
+	maintenanceRetention, err := ParseRetention(GetEnv("MAINTENANCE_RETENTION", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid MAINTENANCE_RETENTION value: %v", err)
+	}
+
+	magicLinkExpiry, err := ParseDuration(GetEnv("MAGIC_LINK_EXPIRY", "15m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid MAGIC_LINK_EXPIRY value: %v", err)
+	}
+
+	magicLinkRequireSameDevice, err := strconv.ParseBool(GetEnv("MAGIC_LINK_REQUIRE_SAME_DEVICE", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid MAGIC_LINK_REQUIRE_SAME_DEVICE value: %v", err)
+	}
+
+	oauthStateExpiry, err := ParseDuration(GetEnv("OAUTH_STATE_EXPIRY", "10m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OAUTH_STATE_EXPIRY value: %v", err)
+	}
+
+	oauthHTTPTimeout, err := ParseDuration(GetEnv("OAUTH_HTTP_TIMEOUT", "10s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OAUTH_HTTP_TIMEOUT value: %v", err)
+	}
+
+	oauthLinkExpiry, err := ParseDuration(GetEnv("OAUTH_LINK_EXPIRY", "15m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OAUTH_LINK_EXPIRY value: %v", err)
+	}
+
+	oauthTokenRefreshInterval, err := ParseDuration(GetEnv("OAUTH_TOKEN_REFRESH_INTERVAL", "1m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OAUTH_TOKEN_REFRESH_INTERVAL value: %v", err)
+	}
+
+	oauthTokenRefreshWindow, err := ParseDuration(GetEnv("OAUTH_TOKEN_REFRESH_WINDOW", "5m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OAUTH_TOKEN_REFRESH_WINDOW value: %v", err)
+	}
+
+	oauthTokenRefreshConcurrency, err := strconv.Atoi(GetEnv("OAUTH_TOKEN_REFRESH_CONCURRENCY", "4"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OAUTH_TOKEN_REFRESH_CONCURRENCY value: %v", err)
+	}
+
+	oauthTokenRefreshBatchSize, err := strconv.Atoi(GetEnv("OAUTH_TOKEN_REFRESH_BATCH_SIZE", "100"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OAUTH_TOKEN_REFRESH_BATCH_SIZE value: %v", err)
+	}
+
+	oauthTokenRefreshMaxBackoff, err := ParseDuration(GetEnv("OAUTH_TOKEN_REFRESH_MAX_BACKOFF", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OAUTH_TOKEN_REFRESH_MAX_BACKOFF value: %v", err)
+	}
+
+	providerCacheRefreshInterval, err := ParseDuration(GetEnv("PROVIDER_CACHE_REFRESH_INTERVAL", "30s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PROVIDER_CACHE_REFRESH_INTERVAL value: %v", err)
+	}
+
+	oidcCacheTTL, err := ParseDuration(GetEnv("OIDC_CACHE_TTL", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OIDC_CACHE_TTL value: %v", err)
+	}
+
+	oauthAuthorizationRequestExpiry, err := ParseDuration(GetEnv("OAUTH_AUTHORIZATION_REQUEST_EXPIRY", "10m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OAUTH_AUTHORIZATION_REQUEST_EXPIRY value: %v", err)
+	}
+
+	oauthAuthorizationCodeExpiry, err := ParseDuration(GetEnv("OAUTH_AUTHORIZATION_CODE_EXPIRY", "1m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OAUTH_AUTHORIZATION_CODE_EXPIRY value: %v", err)
+	}
+
+	serviceTokenExpiry, err := ParseDuration(GetEnv("SERVICE_TOKEN_EXPIRY", "5m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SERVICE_TOKEN_EXPIRY value: %v", err)
+	}
+
+	apiKeyDefaultExpiry, err := ParseDuration(GetEnv("API_KEY_DEFAULT_EXPIRY", "90d"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid API_KEY_DEFAULT_EXPIRY value: %v", err)
+	}
+
+	apiKeyMaxExpiry, err := ParseDuration(GetEnv("API_KEY_MAX_EXPIRY", "365d"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid API_KEY_MAX_EXPIRY value: %v", err)
+	}
+	if apiKeyDefaultExpiry > apiKeyMaxExpiry {
+		return nil, fmt.Errorf("API_KEY_DEFAULT_EXPIRY must not exceed API_KEY_MAX_EXPIRY")
+	}
+
+	apiKeyRotationGrace, err := ParseDuration(GetEnv("API_KEY_ROTATION_GRACE", "24h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid API_KEY_ROTATION_GRACE value: %v", err)
+	}
+
+	apiKeyTokenExpiry, err := ParseDuration(GetEnv("API_KEY_TOKEN_EXPIRY", "5m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid API_KEY_TOKEN_EXPIRY value: %v", err)
+	}
+
+	passwordMinLength, err := strconv.Atoi(GetEnv("PASSWORD_MIN_LENGTH", "8"))
+	if err != nil || passwordMinLength < 1 || passwordMinLength > 72 {
+		return nil, fmt.Errorf("invalid PASSWORD_MIN_LENGTH value: must be between 1 and 72")
+	}
+
+	passwordResetExpiry, err := ParseDuration(GetEnv("PASSWORD_RESET_EXPIRY", "30m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PASSWORD_RESET_EXPIRY value: %v", err)
+	}
+
+	contactChangeExpiry, err := ParseDuration(GetEnv("CONTACT_CHANGE_EXPIRY", "24h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CONTACT_CHANGE_EXPIRY value: %v", err)
+	}
+
+	contactChangeUndoWindow, err := ParseDuration(GetEnv("CONTACT_CHANGE_UNDO_WINDOW", "7d"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CONTACT_CHANGE_UNDO_WINDOW value: %v", err)
+	}
+
+	accountDeletionGrace, err := ParseDuration(GetEnv("ACCOUNT_DELETION_GRACE", "30d"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ACCOUNT_DELETION_GRACE value: %v", err)
+	}
+
+	accountPurgeInterval, err := ParseDuration(GetEnv("ACCOUNT_PURGE_INTERVAL", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ACCOUNT_PURGE_INTERVAL value: %v", err)
+	}
+
+	accountPurgeBatchSize, err := strconv.Atoi(GetEnv("ACCOUNT_PURGE_BATCH_SIZE", "100"))
+	if err != nil || accountPurgeBatchSize <= 0 {
+		return nil, fmt.Errorf("invalid ACCOUNT_PURGE_BATCH_SIZE value: %q", GetEnv("ACCOUNT_PURGE_BATCH_SIZE", "100"))
+	}
+
+	accountPurgeMode := GetEnv("ACCOUNT_PURGE_MODE", AccountPurgeAnonymize)
+	if accountPurgeMode != AccountPurgeAnonymize && accountPurgeMode != AccountPurgeDelete {
+		return nil, fmt.Errorf("invalid ACCOUNT_PURGE_MODE value: %q", accountPurgeMode)
+	}
+
+	impersonationTokenExpiry, err := ParseDuration(GetEnv("IMPERSONATION_TOKEN_EXPIRY", "15m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid IMPERSONATION_TOKEN_EXPIRY value: %v", err)
+	}
+	if impersonationTokenExpiry <= 0 || impersonationTokenExpiry > time.Hour {
+		return nil, fmt.Errorf("invalid IMPERSONATION_TOKEN_EXPIRY value: must be between 1s and 1h")
+	}
+
+	verificationCodeExpiry, err := ParseDuration(GetEnv("VERIFICATION_CODE_EXPIRY", "10m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid VERIFICATION_CODE_EXPIRY value: %v", err)
+	}
+
+	verificationMaxAttempts, err := strconv.Atoi(GetEnv("VERIFICATION_MAX_ATTEMPTS", "5"))
+	if err != nil || verificationMaxAttempts < 1 {
+		return nil, fmt.Errorf("invalid VERIFICATION_MAX_ATTEMPTS value: %v", err)
+	}
+
+	verificationResendCooldown, err := ParseDuration(GetEnv("VERIFICATION_RESEND_COOLDOWN", "60s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid VERIFICATION_RESEND_COOLDOWN value: %v", err)
+	}
+
+	verificationMaxSendsPerHour, err := strconv.Atoi(GetEnv("VERIFICATION_MAX_SENDS_PER_HOUR", "5"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid VERIFICATION_MAX_SENDS_PER_HOUR value: %v", err)
+	}
+
+	verificationPolicy := GetEnv("VERIFICATION_POLICY", VerificationPolicyNone)
+	switch verificationPolicy {
+	case VerificationPolicyNone, VerificationPolicyRestrictScopes, VerificationPolicyBlockLogin:
+	default:
+		return nil, fmt.Errorf("invalid VERIFICATION_POLICY value: %q", verificationPolicy)
+	}
+
+	verificationRequiredChannels := SplitList(GetEnv("VERIFICATION_REQUIRED_CHANNELS", "email"))
+	for _, channel := range verificationRequiredChannels {
+		if channel != "email" && channel != "phone" {
+			return nil, fmt.Errorf("invalid VERIFICATION_REQUIRED_CHANNELS value: unknown channel %q", channel)
+		}
+	}
+
 	return &Config{
-		Port:              port,
-		DBConnectionURL:   dbConnectionURL,
-		MaxConnectionPool: maxConnectionPool,
+		Port:               port,
+		DBConnectionURL:    dbConnectionURL,
+		MaxConnectionPool:  maxConnectionPool,
+		JWTSecret:          GetEnv("JWT_SECRET", ""),
+		JWTIssuer:          GetEnv("JWT_ISSUER", "auth-service"),
+		AccessTokenExpiry:  accessTokenExpiry,
+		RefreshTokenExpiry: refreshTokenExpiry,
+
+		SessionIdleTimeout:     sessionIdleTimeout,
+		RefreshTokenReuseGrace: refreshTokenReuseGrace,
+
+		MFATokenExpiry:      mfaTokenExpiry,
+		MFAMaxAttempts:      mfaMaxAttempts,
+		MFAMaxFailures:      mfaMaxFailures,
+		MFAFailureWindow:    mfaFailureWindow,
+		TOTPIssuer:          GetEnv("TOTP_ISSUER", "Auth Service"),
+		DeviceTrustDuration: deviceTrustDuration,
+
+		WebAuthnRPID:            GetEnv("WEBAUTHN_RP_ID", "localhost"),
+		WebAuthnRPName:          GetEnv("WEBAUTHN_RP_NAME", "Auth Service"),
+		WebAuthnOrigins:         SplitList(GetEnv("WEBAUTHN_ORIGINS", "http://localhost:3000")),
+		WebAuthnChallengeExpiry: webAuthnChallengeExpiry,
+
+		GeoIPCityDB:           GetEnv("GEOIP_CITY_DB", ""),
+		GeoIPASNDB:            GetEnv("GEOIP_ASN_DB", ""),
+		RiskStepUpScore:       riskStepUpScore,
+		RiskBlockScore:        riskBlockScore,
+		RiskMaxTravelSpeedKmh: riskMaxTravelSpeedKmh,
+		RiskFailureWindow:     riskFailureWindow,
+		RiskHistoryWindow:     riskHistoryWindow,
+
+		LoginLogRetention:        loginLogRetention,
+		LoginLogArchiveInterval:  loginLogArchiveInterval,
+		LoginLogArchiveBatchSize: loginLogArchiveBatchSize,
+
+		MaintenanceInterval:  maintenanceInterval,
+		MaintenanceBatchSize: maintenanceBatchSize,
+		MaintenanceRetention: maintenanceRetention,
+		MetricsPort:          GetEnv("METRICS_PORT", ""),
+
+		MailSink: GetEnv("MAIL_SINK", "log"),
+		MailDir:  GetEnv("MAIL_DIR", "./tmp/mail"),
+
+		SMSSink: GetEnv("SMS_SINK", "log"),
+		SMSDir:  GetEnv("SMS_DIR", "./tmp/sms"),
+
+		EventSink: GetEnv("EVENT_SINK", "log"),
+
+		PasswordMinLength:   passwordMinLength,
+		PasswordResetURL:    GetEnv("PASSWORD_RESET_URL", "http://localhost:3000/auth/reset-password"),
+		PasswordResetExpiry: passwordResetExpiry,
+
+		ContactChangeConfirmURL: GetEnv("CONTACT_CHANGE_CONFIRM_URL", "http://localhost:3000/account/confirm-change"),
+		ContactChangeUndoURL:    GetEnv("CONTACT_CHANGE_UNDO_URL", "http://localhost:3000/account/undo-change"),
+		ContactChangeExpiry:     contactChangeExpiry,
+		ContactChangeUndoWindow: contactChangeUndoWindow,
+
+		AccountDeletionGrace:  accountDeletionGrace,
+		AccountRestoreURL:     GetEnv("ACCOUNT_RESTORE_URL", "http://localhost:3000/account/restore"),
+		AccountPurgeInterval:  accountPurgeInterval,
+		AccountPurgeBatchSize: accountPurgeBatchSize,
+		AccountPurgeMode:      accountPurgeMode,
+
+		ImpersonationAdmins:      SplitList(GetEnv("IMPERSONATION_ADMINS", "")),
+		ImpersonationTokenExpiry: impersonationTokenExpiry,
+		ImpersonationScopes:      SplitList(GetEnv("IMPERSONATION_SCOPES", "openid,profile,email")),
+
+		VerificationCodeExpiry:       verificationCodeExpiry,
+		VerificationMaxAttempts:      verificationMaxAttempts,
+		VerificationResendCooldown:   verificationResendCooldown,
+		VerificationMaxSendsPerHour:  verificationMaxSendsPerHour,
+		VerificationPolicy:           verificationPolicy,
+		VerificationRequiredChannels: verificationRequiredChannels,
+		VerificationUnverifiedScopes: SplitList(GetEnv("VERIFICATION_UNVERIFIED_SCOPES", "openid,account.verify")),
+
+		MagicLinkURL:               GetEnv("MAGIC_LINK_URL", "http://localhost:3000/auth/magic-link"),
+		MagicLinkExpiry:            magicLinkExpiry,
+		MagicLinkRequireSameDevice: magicLinkRequireSameDevice,
+
+		OAuthRedirectURL: GetEnv("OAUTH_REDIRECT_URL", "http://localhost:3000/auth/oauth/{provider}/callback"),
+		OAuthStateExpiry: oauthStateExpiry,
+		OAuthHTTPTimeout: oauthHTTPTimeout,
+		OAuthLinkExpiry:  oauthLinkExpiry,
+		OIDCCacheTTL:     oidcCacheTTL,
+
+		OAuthTokenRefreshInterval:    oauthTokenRefreshInterval,
+		OAuthTokenRefreshWindow:      oauthTokenRefreshWindow,
+		OAuthTokenRefreshConcurrency: oauthTokenRefreshConcurrency,
+		OAuthTokenRefreshBatchSize:   oauthTokenRefreshBatchSize,
+		OAuthTokenRefreshMaxBackoff:  oauthTokenRefreshMaxBackoff,
+		ProviderCacheRefreshInterval: providerCacheRefreshInterval,
+
+		HTTPPort:                        GetEnv("HTTP_PORT", "8080"),
+		OAuthIssuer:                     strings.TrimSuffix(GetEnv("OAUTH_ISSUER", "http://localhost:8080"), "/"),
+		OAuthLoginURL:                   GetEnv("OAUTH_LOGIN_URL", "http://localhost:3000/auth/authorize"),
+		OAuthAuthorizationRequestExpiry: oauthAuthorizationRequestExpiry,
+		OAuthAuthorizationCodeExpiry:    oauthAuthorizationCodeExpiry,
+		OAuthSigningKeyFiles:            SplitList(GetEnv("OAUTH_SIGNING_KEY_FILES", "")),
+		ServiceTokenExpiry:              serviceTokenExpiry,
+
+		APIKeyDefaultExpiry: apiKeyDefaultExpiry,
+		APIKeyMaxExpiry:     apiKeyMaxExpiry,
+		APIKeyRotationGrace: apiKeyRotationGrace,
+		APIKeyTokenExpiry:   apiKeyTokenExpiry,
+		APIKeyUserScopes:    SplitList(GetEnv("API_KEY_USER_SCOPES", "openid,profile,email")),
+
+		EncryptionKeys:    GetEnv("ENCRYPTION_KEYS", ""),
+		EncryptionKeyFile: GetEnv("ENCRYPTION_KEY_FILE", ""),
+		EncryptionKeyID:   GetEnv("ENCRYPTION_KEY_ID", ""),
 	}, nil
 }
 
@@ -59,6 +655,55 @@ func NewDatabaseConnection(cfg *Config) (*sql.DB, error) {
 	return db, nil
 }
 
+// NewKeyProvider creates the master key provider for encryption at rest from
+// ENCRYPTION_KEYS and ENCRYPTION_KEY_FILE
+func NewKeyProvider(cfg *Config) (envelope.KeyProvider, error) {
+	keys, err := envelope.ParseKeys(cfg.EncryptionKeys)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ENCRYPTION_KEYS value: %v", err)
+	}
+	if cfg.EncryptionKeyFile != "" {
+		fileKeys, err := envelope.LoadKeyFile(cfg.EncryptionKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ENCRYPTION_KEY_FILE: %v", err)
+		}
+		keys = append(keys, fileKeys...)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("ENCRYPTION_KEYS or ENCRYPTION_KEY_FILE is required")
+	}
+
+	return envelope.NewLocalKeyProvider(cfg.EncryptionKeyID, keys)
+}
+
+// NewSigningKeys loads the authorization server's token signing keys from
+// OAUTH_SIGNING_KEY_FILES. Without any configured keys it generates a throwaway
+// key, so tokens stop verifying when the process restarts.
+func NewSigningKeys(cfg *Config) (*jwt.KeyManager, error) {
+	if len(cfg.OAuthSigningKeyFiles) == 0 {
+		key, err := jwt.GenerateSigningKey()
+		if err != nil {
+			return nil, err
+		}
+		return jwt.NewKeyManager(key)
+	}
+
+	keys := make([]jwt.SigningKey, 0, len(cfg.OAuthSigningKeyFiles))
+	for _, path := range cfg.OAuthSigningKeyFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OAUTH_SIGNING_KEY_FILES: %v", err)
+		}
+		signer, err := jwt.ParsePrivateKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OAUTH_SIGNING_KEY_FILES entry %s: %v", path, err)
+		}
+		keys = append(keys, jwt.SigningKey{Key: signer})
+	}
+
+	return jwt.NewKeyManager(keys[0], keys[1:]...)
+}
+
 // GetEnv gets an environment variable with a fallback default value
 // Made public for testing purposes
 func GetEnv(key, defaultValue string) string {
@@ -67,3 +712,50 @@ func GetEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// ParseDuration parses a Go duration string, additionally accepting a day suffix ("7d")
+func ParseDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid day duration %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// SplitList splits a comma separated environment value, dropping empty entries
+func SplitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// ParseRetention reads comma separated "table=duration" pairs over the default
+// maintenance retention. A duration of 0 stops the table from being cleaned up.
+func ParseRetention(value string) (map[string]time.Duration, error) {
+	retention := defaultMaintenanceRetention()
+	for _, item := range SplitList(value) {
+		table, duration, ok := strings.Cut(item, "=")
+		table = strings.TrimSpace(table)
+		if _, known := retention[table]; !ok || !known {
+			return nil, fmt.Errorf("unknown table in %q", item)
+		}
+		d, err := ParseDuration(strings.TrimSpace(duration))
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid retention in %q", item)
+		}
+		retention[table] = d
+	}
+
+	// Resend limits count the codes sent in the last hour
+	if d := retention["otp_tokens"]; d > 0 && d < time.Hour {
+		return nil, fmt.Errorf("otp_tokens retention must be 0 or at least 1h")
+	}
+	return retention, nil
+}
@@ -0,0 +1,75 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Event types published for other services
+const (
+	TypePasswordChanged = "password_changed"
+	TypeEmailChanged    = "email_changed"
+	TypePhoneChanged    = "phone_changed"
+
+	TypeAccountDeactivated = "account_deactivated"
+	TypeAccountReactivated = "account_reactivated"
+	TypeAccountDeleted     = "account_deleted"
+	TypeAccountRestored    = "account_restored"
+	// TypeAccountPurged is published once a deleted account's data is gone
+	TypeAccountPurged = "account_purged"
+
+	// TypeUserImpersonated lets the notification service tell the user
+	TypeUserImpersonated = "user_impersonated"
+)
+
+// Event is an account change other services react to, such as the
+// notification service telling the user about it
+type Event struct {
+	Type       string                 `json:"event_type"`
+	UserID     string                 `json:"user_id"`
+	Email      string                 `json:"email,omitempty"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
+
+// Publisher delivers events to other services
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// Sink names accepted by New
+const (
+	SinkLog = "log"
+)
+
+// New returns the development sink selected by name. Production delivery goes
+// through the message queue and is wired in separately.
+func New(sink string) (Publisher, error) {
+	switch sink {
+	case "", SinkLog:
+		return NewLogPublisher(), nil
+	default:
+		return nil, fmt.Errorf("unknown event sink %q", sink)
+	}
+}
+
+// LogPublisher writes events to the service log as JSON
+type LogPublisher struct{}
+
+// NewLogPublisher creates a publisher that logs every event
+func NewLogPublisher() *LogPublisher {
+	return &LogPublisher{}
+}
+
+// Publish logs the event
+func (p *LogPublisher) Publish(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	log.Printf("Event %s", data)
+	return nil
+}
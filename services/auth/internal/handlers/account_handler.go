@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/your-project/services/auth/internal/business"
+	"github.com/your-project/services/auth/internal/models"
+)
+
+// DeactivateAccountRequest mirrors auth.DeactivateAccountRequest
+type DeactivateAccountRequest struct {
+	UserID    string
+	SessionID string
+	Reason    string
+	Client    ClientContext
+}
+
+// ReactivateAccountRequest mirrors auth.ReactivateAccountRequest
+type ReactivateAccountRequest struct {
+	UserID string
+	Client ClientContext
+}
+
+// DeleteAccountRequest mirrors auth.DeleteAccountRequest
+type DeleteAccountRequest struct {
+	UserID          string
+	SessionID       string
+	CurrentPassword string
+	Reason          string
+	Client          ClientContext
+}
+
+// DeleteAccountResponse mirrors auth.DeleteAccountResponse
+type DeleteAccountResponse struct {
+	PurgeAfter   time.Time
+	RevokedCount int32
+}
+
+// RestoreAccountRequest mirrors auth.RestoreAccountRequest
+type RestoreAccountRequest struct {
+	Token  string
+	Client ClientContext
+}
+
+// AccountStatus mirrors auth.AccountStatus
+type AccountStatus struct {
+	UserID        string
+	IsActive      bool
+	DeactivatedAt *time.Time
+}
+
+// DeactivateAccount disables sign-in and signs out every session
+func (h *AuthHandler) DeactivateAccount(ctx context.Context, req *DeactivateAccountRequest) (*RevokeSessionsResponse, error) {
+	count, err := h.authBusiness.DeactivateAccount(ctx, business.DeactivateAccountInput{
+		UserID:    req.UserID,
+		SessionID: req.SessionID,
+		Reason:    req.Reason,
+		Client:    req.Client.toBusiness(),
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &RevokeSessionsResponse{RevokedCount: int32(count)}, nil
+}
+
+// ReactivateAccount lets a deactivated account sign in again
+func (h *AuthHandler) ReactivateAccount(ctx context.Context, req *ReactivateAccountRequest) (*AccountStatus, error) {
+	user, err := h.authBusiness.ReactivateAccount(ctx, req.UserID, req.Client.toBusiness())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return newAccountStatus(user), nil
+}
+
+// DeleteAccount soft-deletes an account and emails a restore link
+func (h *AuthHandler) DeleteAccount(ctx context.Context, req *DeleteAccountRequest) (*DeleteAccountResponse, error) {
+	deletion, err := h.authBusiness.DeleteAccount(ctx, business.DeleteAccountInput{
+		UserID:          req.UserID,
+		SessionID:       req.SessionID,
+		CurrentPassword: req.CurrentPassword,
+		Reason:          req.Reason,
+		Client:          req.Client.toBusiness(),
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &DeleteAccountResponse{
+		PurgeAfter:   deletion.PurgeAfter,
+		RevokedCount: int32(deletion.SessionsRevoked),
+	}, nil
+}
+
+// RestoreAccount restores a deleted account with the token from the restore link
+func (h *AuthHandler) RestoreAccount(ctx context.Context, req *RestoreAccountRequest) (*AccountStatus, error) {
+	user, err := h.authBusiness.RestoreAccount(ctx, req.Token, req.Client.toBusiness())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return newAccountStatus(user), nil
+}
+
+func newAccountStatus(user *models.User) *AccountStatus {
+	return &AccountStatus{
+		UserID:        user.UUID,
+		IsActive:      user.IsActive,
+		DeactivatedAt: user.DeactivatedAt,
+	}
+}
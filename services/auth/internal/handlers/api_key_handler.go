@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/your-project/services/auth/internal/business"
+	"github.com/your-project/services/auth/internal/models"
+)
+
+// CreateAPIKeyRequest mirrors auth.CreateAPIKeyRequest. Exactly one of UserID
+// (personal key) and ServiceAccountID (machine key) is set; personal keys also
+// need the caller's SessionID.
+type CreateAPIKeyRequest struct {
+	UserID           string
+	ServiceAccountID string
+	SessionID        string
+	Name             string
+	Scopes           []string
+	// ExpiresInSeconds of 0 uses API_KEY_DEFAULT_EXPIRY
+	ExpiresInSeconds int64
+	AllowedIPs       []string
+	Client           ClientContext
+}
+
+// CreateAPIKeyResponse mirrors auth.CreateAPIKeyResponse. Secret is only returned here.
+type CreateAPIKeyResponse struct {
+	Key    *APIKey
+	Secret string
+}
+
+// ListAPIKeysRequest mirrors auth.ListAPIKeysRequest
+type ListAPIKeysRequest struct {
+	UserID           string
+	ServiceAccountID string
+}
+
+// ListAPIKeysResponse mirrors auth.ListAPIKeysResponse
+type ListAPIKeysResponse struct {
+	Keys []*APIKey
+}
+
+// APIKeyRequest mirrors auth.APIKeyRequest, used by the revoke and rotate RPCs
+type APIKeyRequest struct {
+	UserID           string
+	ServiceAccountID string
+	SessionID        string
+	KeyID            string
+	Client           ClientContext
+}
+
+// ValidateAPIKeyRequest mirrors auth.ValidateAPIKeyRequest
+type ValidateAPIKeyRequest struct {
+	APIKey string
+	// Client.IPAddress is the integration's address as seen by the gateway
+	Client ClientContext
+}
+
+// ValidateAPIKeyResponse mirrors auth.ValidateAPIKeyResponse
+type ValidateAPIKeyResponse struct {
+	AccessToken string
+	ExpiresIn   int64
+	Scope       string
+	Subject     string
+	KeyID       string
+}
+
+// APIKey mirrors auth.APIKey. The key itself is never returned after creation.
+type APIKey struct {
+	ID         string
+	Name       string
+	Prefix     string
+	Scopes     []string
+	AllowedIPs []string
+	ExpiresAt  time.Time
+	LastUsedAt *time.Time
+	LastUsedIP string
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+// CreateAPIKey issues a personal or machine API key
+func (h *AuthHandler) CreateAPIKey(ctx context.Context, req *CreateAPIKeyRequest) (*CreateAPIKeyResponse, error) {
+	key, secret, err := h.authBusiness.CreateAPIKey(ctx, business.APIKeyInput{
+		Owner:      business.APIKeyOwner{UserID: req.UserID, ServiceAccountID: req.ServiceAccountID},
+		SessionID:  req.SessionID,
+		Name:       req.Name,
+		Scopes:     req.Scopes,
+		ExpiresIn:  time.Duration(req.ExpiresInSeconds) * time.Second,
+		AllowedIPs: req.AllowedIPs,
+		Client:     req.Client.toBusiness(),
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &CreateAPIKeyResponse{Key: newAPIKey(key), Secret: secret}, nil
+}
+
+// ListAPIKeys returns the keys that are neither revoked nor expired
+func (h *AuthHandler) ListAPIKeys(ctx context.Context, req *ListAPIKeysRequest) (*ListAPIKeysResponse, error) {
+	keys, err := h.authBusiness.ListAPIKeys(ctx, business.APIKeyOwner{UserID: req.UserID, ServiceAccountID: req.ServiceAccountID})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	response := &ListAPIKeysResponse{Keys: make([]*APIKey, 0, len(keys))}
+	for _, key := range keys {
+		response.Keys = append(response.Keys, newAPIKey(key))
+	}
+	return response, nil
+}
+
+// RevokeAPIKey stops a key from being used
+func (h *AuthHandler) RevokeAPIKey(ctx context.Context, req *APIKeyRequest) (*APIKey, error) {
+	key, err := h.authBusiness.RevokeAPIKey(ctx,
+		business.APIKeyOwner{UserID: req.UserID, ServiceAccountID: req.ServiceAccountID}, req.KeyID, req.Client.toBusiness())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return newAPIKey(key), nil
+}
+
+// RotateAPIKey replaces a key; the old one keeps working for API_KEY_ROTATION_GRACE
+func (h *AuthHandler) RotateAPIKey(ctx context.Context, req *APIKeyRequest) (*CreateAPIKeyResponse, error) {
+	key, secret, err := h.authBusiness.RotateAPIKey(ctx,
+		business.APIKeyOwner{UserID: req.UserID, ServiceAccountID: req.ServiceAccountID}, req.SessionID, req.KeyID, req.Client.toBusiness())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &CreateAPIKeyResponse{Key: newAPIKey(key), Secret: secret}, nil
+}
+
+// ValidateAPIKey exchanges an API key for a short-lived access token
+func (h *AuthHandler) ValidateAPIKey(ctx context.Context, req *ValidateAPIKeyRequest) (*ValidateAPIKeyResponse, error) {
+	token, err := h.authBusiness.ValidateAPIKey(ctx, req.APIKey, req.Client.toBusiness())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &ValidateAPIKeyResponse{
+		AccessToken: token.AccessToken,
+		ExpiresIn:   token.ExpiresIn,
+		Scope:       token.Scope,
+		Subject:     token.Subject,
+		KeyID:       token.APIKeyID,
+	}, nil
+}
+
+func newAPIKey(key *models.APIKey) *APIKey {
+	response := &APIKey{
+		ID:         key.UUID,
+		Name:       key.Name,
+		Prefix:     key.KeyPrefix,
+		Scopes:     key.Scopes,
+		AllowedIPs: key.AllowedIPs,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+	if key.LastUsedIP != nil {
+		response.LastUsedIP = *key.LastUsedIP
+	}
+	return response
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"time"
+
 	"github.com/your-project/services/auth/internal/business"
 )
 
@@ -16,10 +18,41 @@ func NewAuthHandler(authBusiness *business.AuthBusiness) *AuthHandler {
 	}
 }
 
-// TODO: Implement gRPC service methods
-// Examples:
-// - Register
-// - Login
-// - ValidateToken
-// - RefreshToken
-// - Logout
+// ClientContext carries the caller's network and device details, forwarded by the application layer
+type ClientContext struct {
+	IPAddress  string
+	UserAgent  string
+	DeviceID   string
+	DeviceInfo map[string]interface{}
+}
+
+func (c ClientContext) toBusiness() business.ClientInfo {
+	return business.ClientInfo{
+		IPAddress:  c.IPAddress,
+		UserAgent:  c.UserAgent,
+		DeviceID:   c.DeviceID,
+		DeviceInfo: c.DeviceInfo,
+	}
+}
+
+// TokenPair is the token set returned after a completed sign-in
+type TokenPair struct {
+	AccessToken           string
+	AccessTokenExpiresAt  time.Time
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
+	SessionID             string
+}
+
+func newTokenPair(tokens *business.TokenPair) *TokenPair {
+	if tokens == nil {
+		return nil
+	}
+	return &TokenPair{
+		AccessToken:           tokens.AccessToken,
+		AccessTokenExpiresAt:  tokens.AccessTokenExpiresAt,
+		RefreshToken:          tokens.RefreshToken,
+		RefreshTokenExpiresAt: tokens.RefreshTokenExpiresAt,
+		SessionID:             tokens.SessionUUID,
+	}
+}
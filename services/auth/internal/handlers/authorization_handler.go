@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"time"
+)
+
+// AuthorizationRequestRequest mirrors auth.AuthorizationRequestRequest
+type AuthorizationRequestRequest struct {
+	RequestID string
+}
+
+// AuthorizationRequestResponse mirrors auth.AuthorizationRequestResponse
+type AuthorizationRequestResponse struct {
+	RequestID  string
+	ClientName string
+	FirstParty bool
+	Scopes     []string
+	ExpiresAt  time.Time
+}
+
+// ApproveAuthorizationRequest mirrors auth.ApproveAuthorizationRequest
+type ApproveAuthorizationRequest struct {
+	RequestID string
+	// AccessToken is the signed-in user's access token
+	AccessToken string
+}
+
+// AuthorizationRedirectResponse mirrors auth.AuthorizationRedirectResponse
+type AuthorizationRedirectResponse struct {
+	RedirectURI string
+}
+
+// GetAuthorizationRequest describes a pending /authorize request for the sign-in page
+func (h *AuthHandler) GetAuthorizationRequest(ctx context.Context, req *AuthorizationRequestRequest) (*AuthorizationRequestResponse, error) {
+	request, err := h.authBusiness.GetAuthorizationRequest(ctx, req.RequestID)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &AuthorizationRequestResponse{
+		RequestID:  request.ID,
+		ClientName: request.Client.Name,
+		FirstParty: request.Client.IsFirstParty,
+		Scopes:     request.Scopes,
+		ExpiresAt:  request.ExpiresAt,
+	}, nil
+}
+
+// ApproveAuthorization issues an authorization code once the user has signed in
+func (h *AuthHandler) ApproveAuthorization(ctx context.Context, req *ApproveAuthorizationRequest) (*AuthorizationRedirectResponse, error) {
+	redirectURI, err := h.authBusiness.ApproveAuthorization(ctx, req.RequestID, req.AccessToken)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &AuthorizationRedirectResponse{RedirectURI: redirectURI}, nil
+}
+
+// DenyAuthorization reports to the client that the user declined
+func (h *AuthHandler) DenyAuthorization(ctx context.Context, req *AuthorizationRequestRequest) (*AuthorizationRedirectResponse, error) {
+	redirectURI, err := h.authBusiness.DenyAuthorization(ctx, req.RequestID)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &AuthorizationRedirectResponse{RedirectURI: redirectURI}, nil
+}
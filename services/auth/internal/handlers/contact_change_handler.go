@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/your-project/services/auth/internal/business"
+)
+
+// RequestContactChangeRequest mirrors auth.RequestContactChangeRequest
+type RequestContactChangeRequest struct {
+	UserID    string
+	SessionID string
+	// Channel is "email" or "phone"
+	Channel         string
+	NewValue        string
+	CurrentPassword string
+	Client          ClientContext
+}
+
+// RequestContactChangeResponse mirrors auth.RequestContactChangeResponse
+type RequestContactChangeResponse struct {
+	Channel     string
+	Destination string
+	ExpiresAt   time.Time
+}
+
+// ContactChangeTokenRequest mirrors auth.ContactChangeTokenRequest
+type ContactChangeTokenRequest struct {
+	Token  string
+	Client ClientContext
+}
+
+// ContactDetails mirrors auth.ContactDetails
+type ContactDetails struct {
+	UserID          string
+	Email           string
+	Phone           string
+	EmailVerifiedAt *time.Time
+	PhoneVerifiedAt *time.Time
+}
+
+// RequestContactChange sends a confirmation link to the new email address or phone number
+func (h *AuthHandler) RequestContactChange(ctx context.Context, req *RequestContactChangeRequest) (*RequestContactChangeResponse, error) {
+	challenge, err := h.authBusiness.RequestContactChange(ctx, business.RequestContactChangeInput{
+		UserID:          req.UserID,
+		SessionID:       req.SessionID,
+		Channel:         req.Channel,
+		NewValue:        req.NewValue,
+		CurrentPassword: req.CurrentPassword,
+		Client:          req.Client.toBusiness(),
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &RequestContactChangeResponse{
+		Channel:     challenge.Channel,
+		Destination: challenge.Destination,
+		ExpiresAt:   challenge.ExpiresAt,
+	}, nil
+}
+
+// ConfirmContactChange applies a change with the token from the confirmation link
+func (h *AuthHandler) ConfirmContactChange(ctx context.Context, req *ContactChangeTokenRequest) (*ContactDetails, error) {
+	user, err := h.authBusiness.ConfirmContactChange(ctx, req.Token, req.Client.toBusiness())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	details := &ContactDetails{
+		UserID:          user.UUID,
+		Email:           user.Email,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		PhoneVerifiedAt: user.PhoneVerifiedAt,
+	}
+	if user.Phone != nil {
+		details.Phone = *user.Phone
+	}
+	return details, nil
+}
+
+// UndoContactChange reverts a change with the token from the undo link and signs out every session
+func (h *AuthHandler) UndoContactChange(ctx context.Context, req *ContactChangeTokenRequest) (*RevokeSessionsResponse, error) {
+	count, err := h.authBusiness.UndoContactChange(ctx, req.Token, req.Client.toBusiness())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &RevokeSessionsResponse{RevokedCount: int32(count)}, nil
+}
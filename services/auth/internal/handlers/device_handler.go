@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/your-project/services/auth/internal/models"
+)
+
+// ListDevicesRequest mirrors auth.ListDevicesRequest
+type ListDevicesRequest struct {
+	UserID string
+}
+
+// ListDevicesResponse mirrors auth.ListDevicesResponse
+type ListDevicesResponse struct {
+	Devices []*Device
+}
+
+// DeviceRequest mirrors auth.DeviceRequest, used by the trust, untrust and remove RPCs
+type DeviceRequest struct {
+	UserID   string
+	DeviceID string
+}
+
+// RemoveDeviceResponse mirrors auth.RemoveDeviceResponse
+type RemoveDeviceResponse struct {
+	Message string
+}
+
+// Device mirrors auth.Device
+type Device struct {
+	ID         string
+	Name       string
+	DeviceType string
+	Browser    string
+	OS         string
+	IPAddress  string
+	IsTrusted  bool
+	TrustedAt  *time.Time
+	LastUsedAt time.Time
+	CreatedAt  time.Time
+}
+
+// ListDevices returns the devices the user has signed in from
+func (h *AuthHandler) ListDevices(ctx context.Context, req *ListDevicesRequest) (*ListDevicesResponse, error) {
+	devices, err := h.authBusiness.ListDevices(ctx, req.UserID)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	response := &ListDevicesResponse{Devices: make([]*Device, 0, len(devices))}
+	for _, device := range devices {
+		response.Devices = append(response.Devices, newDevice(device))
+	}
+	return response, nil
+}
+
+// TrustDevice lets a device skip the second factor
+func (h *AuthHandler) TrustDevice(ctx context.Context, req *DeviceRequest) (*Device, error) {
+	device, err := h.authBusiness.TrustDevice(ctx, req.UserID, req.DeviceID)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return newDevice(device), nil
+}
+
+// UntrustDevice requires the second factor on a device again
+func (h *AuthHandler) UntrustDevice(ctx context.Context, req *DeviceRequest) (*Device, error) {
+	device, err := h.authBusiness.UntrustDevice(ctx, req.UserID, req.DeviceID)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return newDevice(device), nil
+}
+
+// RemoveDevice forgets a device
+func (h *AuthHandler) RemoveDevice(ctx context.Context, req *DeviceRequest) (*RemoveDeviceResponse, error) {
+	if err := h.authBusiness.RemoveDevice(ctx, req.UserID, req.DeviceID); err != nil {
+		return nil, toStatusError(err)
+	}
+	return &RemoveDeviceResponse{Message: "device removed"}, nil
+}
+
+func newDevice(device *models.Device) *Device {
+	result := &Device{
+		ID:         device.UUID,
+		IsTrusted:  device.IsTrusted,
+		TrustedAt:  device.TrustedAt,
+		LastUsedAt: device.LastUsedAt,
+		CreatedAt:  device.CreatedAt,
+	}
+	for _, field := range []struct {
+		src *string
+		dst *string
+	}{
+		{device.DeviceName, &result.Name}, {device.DeviceType, &result.DeviceType}, {device.Browser, &result.Browser},
+		{device.OS, &result.OS}, {device.IPAddress, &result.IPAddress},
+	} {
+		if field.src != nil {
+			*field.dst = *field.src
+		}
+	}
+	return result
+}
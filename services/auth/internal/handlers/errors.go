@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/your-project/services/auth/internal/business"
+)
+
+// toStatusError maps business errors onto gRPC status codes.
+// Unexpected errors are logged and returned as Internal without details.
+func toStatusError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, business.ErrInvalidArgument),
+		errors.Is(err, business.ErrInvalidChallenge),
+		errors.Is(err, business.ErrInvalidVerificationCode):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, business.ErrUserNotFound),
+		errors.Is(err, business.ErrPasskeyNotFound),
+		errors.Is(err, business.ErrSessionNotFound),
+		errors.Is(err, business.ErrDeviceNotFound),
+		errors.Is(err, business.ErrProviderNotFound),
+		errors.Is(err, business.ErrProviderLinkNotFound),
+		errors.Is(err, business.ErrOAuthClientNotFound),
+		errors.Is(err, business.ErrAuthorizationRequestNotFound),
+		errors.Is(err, business.ErrAPIKeyNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, business.ErrInvalidCredentials),
+		errors.Is(err, business.ErrInvalidToken),
+		errors.Is(err, business.ErrInvalidMFACode),
+		errors.Is(err, business.ErrPasskeyVerification),
+		errors.Is(err, business.ErrPasskeyCloneDetected),
+		errors.Is(err, business.ErrMagicLinkDeviceMismatch),
+		errors.Is(err, business.ErrOAuthExchange),
+		errors.Is(err, business.ErrReauthRequired),
+		errors.Is(err, business.ErrInvalidAPIKey):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, business.ErrAccountDisabled),
+		errors.Is(err, business.ErrLoginBlocked),
+		errors.Is(err, business.ErrClientNotAuthorized),
+		errors.Is(err, business.ErrScopeNotAllowed),
+		errors.Is(err, business.ErrAPIKeyIPNotAllowed),
+		errors.Is(err, business.ErrImpersonationNotAllowed),
+		errors.Is(err, business.ErrImpersonatedSession):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, business.ErrMFAAlreadyEnabled),
+		errors.Is(err, business.ErrPasskeyExists),
+		errors.Is(err, business.ErrProviderAlreadyLinked),
+		errors.Is(err, business.ErrProviderExists),
+		errors.Is(err, business.ErrOAuthClientExists),
+		errors.Is(err, business.ErrContactInUse):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, business.ErrMFANotEnabled),
+		errors.Is(err, business.ErrMFANotEnrolled),
+		errors.Is(err, business.ErrLastLoginMethod),
+		errors.Is(err, business.ErrDeviceNotTrustable),
+		errors.Is(err, business.ErrOAuthEmailUnverified),
+		errors.Is(err, business.ErrOAuthAccountExists),
+		errors.Is(err, business.ErrAlreadyVerified),
+		errors.Is(err, business.ErrNoPhoneNumber),
+		errors.Is(err, business.ErrNoPassword):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, business.ErrVerificationCooldown),
+		errors.Is(err, business.ErrMFALocked):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, business.ErrRefreshConflict):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, business.ErrPasskeysDisabled):
+		return status.Error(codes.Unimplemented, err.Error())
+	default:
+		log.Printf("Unhandled auth error: %v", err)
+		return status.Error(codes.Internal, "internal error")
+	}
+}
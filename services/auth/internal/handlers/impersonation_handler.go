@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/your-project/services/auth/internal/business"
+)
+
+// ImpersonateRequest mirrors auth.ImpersonateRequest
+type ImpersonateRequest struct {
+	AdminToken   string
+	TargetUserID string
+	Reason       string
+	Client       ClientContext
+}
+
+// ImpersonateResponse mirrors auth.ImpersonateResponse
+type ImpersonateResponse struct {
+	AccessToken string
+	ExpiresAt   time.Time
+	SessionID   string
+	Scope       string
+}
+
+// ListImpersonationsRequest mirrors auth.ListImpersonationsRequest
+type ListImpersonationsRequest struct {
+	UserID string
+}
+
+// ListImpersonationsResponse mirrors auth.ListImpersonationsResponse
+type ListImpersonationsResponse struct {
+	Impersonations []*Impersonation
+}
+
+// Impersonation mirrors auth.Impersonation
+type Impersonation struct {
+	AdminID   string
+	Reason    string
+	SessionID string
+	StartedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Impersonate signs an admin in as another user
+func (h *AuthHandler) Impersonate(ctx context.Context, req *ImpersonateRequest) (*ImpersonateResponse, error) {
+	result, err := h.authBusiness.Impersonate(ctx, business.ImpersonateInput{
+		AdminToken:   req.AdminToken,
+		TargetUserID: req.TargetUserID,
+		Reason:       req.Reason,
+		Client:       req.Client.toBusiness(),
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &ImpersonateResponse{
+		AccessToken: result.AccessToken,
+		ExpiresAt:   result.ExpiresAt,
+		SessionID:   result.SessionUUID,
+		Scope:       result.Scope,
+	}, nil
+}
+
+// ListImpersonations returns when the user was impersonated
+func (h *AuthHandler) ListImpersonations(ctx context.Context, req *ListImpersonationsRequest) (*ListImpersonationsResponse, error) {
+	impersonations, err := h.authBusiness.ListImpersonations(ctx, req.UserID)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	response := &ListImpersonationsResponse{Impersonations: make([]*Impersonation, 0, len(impersonations))}
+	for _, impersonation := range impersonations {
+		response.Impersonations = append(response.Impersonations, &Impersonation{
+			AdminID:   impersonation.AdminID,
+			Reason:    impersonation.Reason,
+			SessionID: impersonation.SessionID,
+			StartedAt: impersonation.StartedAt,
+			ExpiresAt: impersonation.ExpiresAt,
+		})
+	}
+	return response, nil
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/your-project/services/auth/internal/business"
+)
+
+// LoginRequest mirrors auth.LoginRequest
+type LoginRequest struct {
+	Email    string
+	Password string
+	Client   ClientContext
+}
+
+// VerifyMFARequest mirrors auth.VerifyMFARequest
+type VerifyMFARequest struct {
+	MFAToken    string
+	Code        string
+	TrustDevice bool
+	Client      ClientContext
+}
+
+// LoginResponse mirrors auth.LoginResponse.
+// Either MFARequired is set with an MFAToken, LinkRequired is set with a
+// LinkToken, VerificationRequired is set with the UnverifiedChannels, or Tokens
+// is populated.
+type LoginResponse struct {
+	UserID        string
+	MFARequired   bool
+	MFAToken      string
+	MFAExpiresAt  time.Time
+	Tokens        *TokenPair
+	LinkRequired  bool
+	LinkToken     string
+	LinkExpiresAt time.Time
+
+	VerificationRequired bool
+	UnverifiedChannels   []string
+}
+
+// Login handles the password step of a sign-in
+func (h *AuthHandler) Login(ctx context.Context, req *LoginRequest) (*LoginResponse, error) {
+	result, err := h.authBusiness.Login(ctx, business.LoginInput{
+		Email:    req.Email,
+		Password: req.Password,
+		Client:   req.Client.toBusiness(),
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return newLoginResponse(result), nil
+}
+
+// VerifyMFA handles the second-factor step of a sign-in
+func (h *AuthHandler) VerifyMFA(ctx context.Context, req *VerifyMFARequest) (*LoginResponse, error) {
+	result, err := h.authBusiness.VerifyMFA(ctx, business.VerifyMFAInput{
+		MFAToken:    req.MFAToken,
+		Code:        req.Code,
+		TrustDevice: req.TrustDevice,
+		Client:      req.Client.toBusiness(),
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return newLoginResponse(result), nil
+}
+
+func newLoginResponse(result *business.LoginResult) *LoginResponse {
+	return &LoginResponse{
+		UserID:        result.User.UUID,
+		MFARequired:   result.MFARequired,
+		MFAToken:      result.MFAToken,
+		MFAExpiresAt:  result.MFAExpiresAt,
+		Tokens:        newTokenPair(result.Tokens),
+		LinkRequired:  result.LinkRequired,
+		LinkToken:     result.LinkToken,
+		LinkExpiresAt: result.LinkExpiresAt,
+
+		VerificationRequired: result.VerificationRequired,
+		UnverifiedChannels:   result.UnverifiedChannels,
+	}
+}
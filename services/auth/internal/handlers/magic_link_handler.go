@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/your-project/services/auth/internal/business"
+)
+
+// RequestMagicLinkRequest mirrors auth.RequestMagicLinkRequest
+type RequestMagicLinkRequest struct {
+	Email    string
+	DeviceID string
+	Client   ClientContext
+}
+
+// RequestMagicLinkResponse mirrors auth.RequestMagicLinkResponse
+type RequestMagicLinkResponse struct {
+	BindingToken string
+	ExpiresAt    time.Time
+}
+
+// ConsumeMagicLinkRequest mirrors auth.ConsumeMagicLinkRequest
+type ConsumeMagicLinkRequest struct {
+	Token        string
+	BindingToken string
+	DeviceID     string
+	Client       ClientContext
+}
+
+// RequestMagicLink emails a sign-in link. The response is the same for unknown emails.
+func (h *AuthHandler) RequestMagicLink(ctx context.Context, req *RequestMagicLinkRequest) (*RequestMagicLinkResponse, error) {
+	result, err := h.authBusiness.RequestMagicLink(ctx, business.RequestMagicLinkInput{
+		Email:    req.Email,
+		DeviceID: req.DeviceID,
+		Client:   req.Client.toBusiness(),
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &RequestMagicLinkResponse{
+		BindingToken: result.BindingToken,
+		ExpiresAt:    result.ExpiresAt,
+	}, nil
+}
+
+// ConsumeMagicLink redeems an emailed sign-in link
+func (h *AuthHandler) ConsumeMagicLink(ctx context.Context, req *ConsumeMagicLinkRequest) (*LoginResponse, error) {
+	result, err := h.authBusiness.ConsumeMagicLink(ctx, business.ConsumeMagicLinkInput{
+		Token:        req.Token,
+		BindingToken: req.BindingToken,
+		DeviceID:     req.DeviceID,
+		Client:       req.Client.toBusiness(),
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return newLoginResponse(result), nil
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+)
+
+// EnrollTOTPRequest mirrors auth.EnrollTOTPRequest
+type EnrollTOTPRequest struct {
+	UserID    string
+	SessionID string
+}
+
+// EnrollTOTPResponse mirrors auth.EnrollTOTPResponse
+type EnrollTOTPResponse struct {
+	Secret string
+	URI    string
+}
+
+// TOTPCodeRequest mirrors auth.TOTPCodeRequest, used by confirm, disable and regenerate
+type TOTPCodeRequest struct {
+	UserID string
+	// SessionID is the caller's session, required to disable
+	SessionID string
+	Code      string
+}
+
+// RecoveryCodesResponse mirrors auth.RecoveryCodesResponse
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string
+}
+
+// GetMFAStatusRequest mirrors auth.GetMFAStatusRequest
+type GetMFAStatusRequest struct {
+	UserID string
+}
+
+// GetMFAStatusResponse mirrors auth.GetMFAStatusResponse
+type GetMFAStatusResponse struct {
+	Enabled                bool
+	RecoveryCodesRemaining int
+}
+
+// EnrollTOTP starts authenticator enrollment and returns the secret and otpauth URI
+func (h *AuthHandler) EnrollTOTP(ctx context.Context, req *EnrollTOTPRequest) (*EnrollTOTPResponse, error) {
+	enrollment, err := h.authBusiness.EnrollTOTP(ctx, req.UserID, req.SessionID)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &EnrollTOTPResponse{Secret: enrollment.Secret, URI: enrollment.URI}, nil
+}
+
+// ConfirmTOTP completes enrollment with the first authenticator code
+func (h *AuthHandler) ConfirmTOTP(ctx context.Context, req *TOTPCodeRequest) (*RecoveryCodesResponse, error) {
+	codes, err := h.authBusiness.ConfirmTOTP(ctx, req.UserID, req.Code)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTOTP removes the authenticator after verifying a TOTP or recovery code
+func (h *AuthHandler) DisableTOTP(ctx context.Context, req *TOTPCodeRequest) (*GetMFAStatusResponse, error) {
+	if err := h.authBusiness.DisableTOTP(ctx, req.UserID, req.SessionID, req.Code); err != nil {
+		return nil, toStatusError(err)
+	}
+	return &GetMFAStatusResponse{Enabled: false}, nil
+}
+
+// RegenerateRecoveryCodes issues a fresh set of recovery codes
+func (h *AuthHandler) RegenerateRecoveryCodes(ctx context.Context, req *TOTPCodeRequest) (*RecoveryCodesResponse, error) {
+	codes, err := h.authBusiness.RegenerateRecoveryCodes(ctx, req.UserID, req.Code)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// GetMFAStatus reports the user's two-factor state
+func (h *AuthHandler) GetMFAStatus(ctx context.Context, req *GetMFAStatusRequest) (*GetMFAStatusResponse, error) {
+	enabled, remaining, err := h.authBusiness.GetMFAStatus(ctx, req.UserID)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &GetMFAStatusResponse{Enabled: enabled, RecoveryCodesRemaining: remaining}, nil
+}
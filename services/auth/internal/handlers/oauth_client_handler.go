@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/your-project/services/auth/internal/business"
+	"github.com/your-project/services/auth/internal/models"
+)
+
+// ListOAuthClientsRequest mirrors auth.ListOAuthClientsRequest
+type ListOAuthClientsRequest struct{}
+
+// ListOAuthClientsResponse mirrors auth.ListOAuthClientsResponse
+type ListOAuthClientsResponse struct {
+	Clients []*OAuthClient
+}
+
+// CreateOAuthClientRequest mirrors auth.CreateOAuthClientRequest
+type CreateOAuthClientRequest struct {
+	ClientID     string
+	Name         string
+	RedirectURIs []string
+	GrantTypes   []string
+	Scopes       []string
+	Confidential bool
+	FirstParty   bool
+}
+
+// CreateOAuthClientResponse mirrors auth.CreateOAuthClientResponse.
+// ClientSecret is only returned here, and only for confidential clients.
+type CreateOAuthClientResponse struct {
+	Client       *OAuthClient
+	ClientSecret string
+}
+
+// OAuthClientRequest mirrors auth.OAuthClientRequest
+type OAuthClientRequest struct {
+	ID string
+}
+
+// OAuthClient mirrors auth.OAuthClient. The client secret is never returned.
+type OAuthClient struct {
+	ID           string
+	ClientID     string
+	Name         string
+	RedirectURIs []string
+	GrantTypes   []string
+	Scopes       []string
+	Confidential bool
+	FirstParty   bool
+	IsActive     bool
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// ListOAuthClients returns every client registered with the authorization server
+func (h *AuthHandler) ListOAuthClients(ctx context.Context, req *ListOAuthClientsRequest) (*ListOAuthClientsResponse, error) {
+	clients, err := h.authBusiness.ListOAuthClients(ctx)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	response := &ListOAuthClientsResponse{Clients: make([]*OAuthClient, 0, len(clients))}
+	for _, client := range clients {
+		response.Clients = append(response.Clients, newOAuthClient(client))
+	}
+	return response, nil
+}
+
+// CreateOAuthClient registers a client with the authorization server
+func (h *AuthHandler) CreateOAuthClient(ctx context.Context, req *CreateOAuthClientRequest) (*CreateOAuthClientResponse, error) {
+	client, secret, err := h.authBusiness.CreateOAuthClient(ctx, business.OAuthClientInput{
+		ClientID:     req.ClientID,
+		Name:         req.Name,
+		RedirectURIs: req.RedirectURIs,
+		GrantTypes:   req.GrantTypes,
+		Scopes:       req.Scopes,
+		Confidential: req.Confidential,
+		FirstParty:   req.FirstParty,
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &CreateOAuthClientResponse{Client: newOAuthClient(client), ClientSecret: secret}, nil
+}
+
+// EnableOAuthClient re-enables a client
+func (h *AuthHandler) EnableOAuthClient(ctx context.Context, req *OAuthClientRequest) (*OAuthClient, error) {
+	client, err := h.authBusiness.EnableOAuthClient(ctx, req.ID)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return newOAuthClient(client), nil
+}
+
+// DisableOAuthClient stops a client from obtaining new tokens
+func (h *AuthHandler) DisableOAuthClient(ctx context.Context, req *OAuthClientRequest) (*OAuthClient, error) {
+	client, err := h.authBusiness.DisableOAuthClient(ctx, req.ID)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return newOAuthClient(client), nil
+}
+
+func newOAuthClient(client *models.OAuthClient) *OAuthClient {
+	return &OAuthClient{
+		ID:           client.UUID,
+		ClientID:     client.ClientID,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectURIs,
+		GrantTypes:   client.GrantTypes,
+		Scopes:       client.Scopes,
+		Confidential: client.IsConfidential(),
+		FirstParty:   client.IsFirstParty,
+		IsActive:     client.IsActive,
+		CreatedAt:    client.CreatedAt,
+		UpdatedAt:    client.UpdatedAt,
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/your-project/services/auth/internal/business"
+)
+
+// BeginOAuthRequest mirrors auth.BeginOAuthRequest
+type BeginOAuthRequest struct {
+	Provider string
+	// UserID starts a flow that links the provider to this signed-in user
+	// through LinkProvider instead of signing in
+	UserID string
+	Client ClientContext
+}
+
+// BeginOAuthResponse mirrors auth.BeginOAuthResponse
+type BeginOAuthResponse struct {
+	AuthorizationURL string
+	State            string
+	ExpiresAt        time.Time
+}
+
+// CompleteOAuthRequest mirrors auth.CompleteOAuthRequest
+type CompleteOAuthRequest struct {
+	Provider string
+	Code     string
+	State    string
+	User     string
+	Client   ClientContext
+}
+
+// BeginOAuth returns the provider URL to redirect the user to
+func (h *AuthHandler) BeginOAuth(ctx context.Context, req *BeginOAuthRequest) (*BeginOAuthResponse, error) {
+	result, err := h.authBusiness.BeginOAuth(ctx, business.BeginOAuthInput{
+		Provider: req.Provider,
+		UserID:   req.UserID,
+		Client:   req.Client.toBusiness(),
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &BeginOAuthResponse{
+		AuthorizationURL: result.AuthorizationURL,
+		State:            result.State,
+		ExpiresAt:        result.ExpiresAt,
+	}, nil
+}
+
+// CompleteOAuth handles the provider callback and signs the user in
+func (h *AuthHandler) CompleteOAuth(ctx context.Context, req *CompleteOAuthRequest) (*LoginResponse, error) {
+	result, err := h.authBusiness.CompleteOAuth(ctx, business.CompleteOAuthInput{
+		Provider: req.Provider,
+		Code:     req.Code,
+		State:    req.State,
+		User:     req.User,
+		Client:   req.Client.toBusiness(),
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return newLoginResponse(result), nil
+}
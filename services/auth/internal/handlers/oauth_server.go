@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/your-project/services/auth/internal/business"
+)
+
+// OAuthServer serves the OAuth 2.1 / OpenID Connect endpoints over HTTP. Paths
+// are relative to OAUTH_ISSUER; a proxy publishing the issuer under a path
+// prefix must strip it.
+type OAuthServer struct {
+	authBusiness *business.AuthBusiness
+	mux          *http.ServeMux
+}
+
+// oauthErrorResponse is the JSON error body of RFC 6749 section 5.2
+type oauthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// NewOAuthServer creates the HTTP handler for the authorization server endpoints
+func NewOAuthServer(authBusiness *business.AuthBusiness) *OAuthServer {
+	s := &OAuthServer{
+		authBusiness: authBusiness,
+		mux:          http.NewServeMux(),
+	}
+	s.mux.HandleFunc(business.OAuthAuthorizePath, s.authorize)
+	s.mux.HandleFunc(business.OAuthTokenPath, s.token)
+	s.mux.HandleFunc(business.OAuthUserinfoPath, s.userinfo)
+	s.mux.HandleFunc(business.OAuthDiscoveryPath, s.discovery)
+	s.mux.HandleFunc(business.OAuthJWKSPath, s.jwks)
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *OAuthServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// authorize starts the authorization code flow (GET or form POST per OpenID Connect)
+func (s *OAuthServer) authorize(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet, http.MethodPost) {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, business.OAuthErrInvalidRequest, "malformed request")
+		return
+	}
+
+	target, err := s.authBusiness.Authorize(r.Context(), business.AuthorizeInput{
+		ResponseType:        r.Form.Get("response_type"),
+		ClientID:            r.Form.Get("client_id"),
+		RedirectURI:         r.Form.Get("redirect_uri"),
+		Scope:               r.Form.Get("scope"),
+		State:               r.Form.Get("state"),
+		Nonce:               r.Form.Get("nonce"),
+		CodeChallenge:       r.Form.Get("code_challenge"),
+		CodeChallengeMethod: r.Form.Get("code_challenge_method"),
+		Prompt:              r.Form.Get("prompt"),
+	})
+	if err != nil {
+		// Without a trusted redirect URI the error can only be shown to the user
+		writeBusinessOAuthError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// token implements the token endpoint (RFC 6749 section 3.2)
+func (s *OAuthServer) token(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, business.OAuthErrInvalidRequest, "malformed request")
+		return
+	}
+
+	clientID, clientSecret := r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	basicID, basicSecret, basic := r.BasicAuth()
+	if basic {
+		if clientSecret != "" {
+			writeOAuthError(w, http.StatusBadRequest, business.OAuthErrInvalidRequest, "use only one client authentication method")
+			return
+		}
+		// Basic credentials are form-urlencoded first (RFC 6749 section 2.3.1)
+		var idErr, secretErr error
+		clientID, idErr = url.QueryUnescape(basicID)
+		clientSecret, secretErr = url.QueryUnescape(basicSecret)
+		if idErr != nil || secretErr != nil {
+			writeOAuthError(w, http.StatusBadRequest, business.OAuthErrInvalidRequest, "malformed client credentials")
+			return
+		}
+	}
+
+	tokens, err := s.authBusiness.ExchangeToken(r.Context(), business.TokenRequest{
+		GrantType:    r.PostForm.Get("grant_type"),
+		Code:         r.PostForm.Get("code"),
+		RedirectURI:  r.PostForm.Get("redirect_uri"),
+		CodeVerifier: r.PostForm.Get("code_verifier"),
+		RefreshToken: r.PostForm.Get("refresh_token"),
+		Scope:        r.PostForm.Get("scope"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Client:       httpClientInfo(r),
+	})
+	if err != nil {
+		var oauthErr *business.OAuthError
+		if errors.As(err, &oauthErr) && oauthErr.Code == business.OAuthErrInvalidClient {
+			if basic {
+				w.Header().Set("WWW-Authenticate", `Basic realm="token"`)
+			}
+			writeOAuthError(w, http.StatusUnauthorized, oauthErr.Code, oauthErr.Description)
+			return
+		}
+		writeBusinessOAuthError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tokens)
+}
+
+// userinfo returns the claims of the user an access token was issued for
+func (s *OAuthServer) userinfo(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet, http.MethodPost) {
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+
+	token, ok := bearerToken(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	info, err := s.authBusiness.UserInfo(r.Context(), token)
+	if err != nil {
+		var oauthErr *business.OAuthError
+		if !errors.As(err, &oauthErr) {
+			writeBusinessOAuthError(w, err)
+			return
+		}
+		status := http.StatusUnauthorized
+		if oauthErr.Code == business.OAuthErrInsufficientScope {
+			status = http.StatusForbidden
+		}
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf("Bearer error=%q, error_description=%q", oauthErr.Code, oauthErr.Description))
+		writeOAuthError(w, status, oauthErr.Code, oauthErr.Description)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, info)
+}
+
+// discovery serves the OpenID provider metadata
+func (s *OAuthServer) discovery(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	writeJSON(w, http.StatusOK, s.authBusiness.Discovery())
+}
+
+// jwks serves the public keys for verifying ID and access tokens
+func (s *OAuthServer) jwks(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	keys, err := s.authBusiness.JWKS()
+	if err != nil {
+		writeBusinessOAuthError(w, err)
+		return
+	}
+	// Short enough that verifiers pick up a rotated key quickly
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	writeJSON(w, http.StatusOK, keys)
+}
+
+// allowMethods rejects other methods with 405 and reports whether to continue
+func allowMethods(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, method := range methods {
+		if r.Method == method {
+			return true
+		}
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	w.WriteHeader(http.StatusMethodNotAllowed)
+	return false
+}
+
+// bearerToken extracts an RFC 6750 bearer token from the Authorization header
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// httpClientInfo describes the caller of an HTTP endpoint. Only the socket
+// address is used; forwarded headers are trivially spoofed.
+func httpClientInfo(r *http.Request) business.ClientInfo {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return business.ClientInfo{IPAddress: host, UserAgent: r.UserAgent()}
+}
+
+// writeBusinessOAuthError sends an *OAuthError as a 400 response, or hides any
+// other error behind server_error
+func writeBusinessOAuthError(w http.ResponseWriter, err error) {
+	var oauthErr *business.OAuthError
+	if errors.As(err, &oauthErr) {
+		status := http.StatusBadRequest
+		if oauthErr.Code == business.OAuthErrServerError {
+			status = http.StatusInternalServerError
+		}
+		writeOAuthError(w, status, oauthErr.Code, oauthErr.Description)
+		return
+	}
+	log.Printf("Unhandled OAuth server error: %v", err)
+	writeOAuthError(w, http.StatusInternalServerError, business.OAuthErrServerError, "internal error")
+}
+
+func writeOAuthError(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, oauthErrorResponse{Error: code, ErrorDescription: description})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Failed to write OAuth response: %v", err)
+	}
+}
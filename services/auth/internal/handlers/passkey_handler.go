@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/your-project/services/auth/internal/business"
+	"github.com/your-project/services/auth/internal/models"
+)
+
+// BeginPasskeyRegistrationRequest mirrors auth.BeginPasskeyRegistrationRequest
+type BeginPasskeyRegistrationRequest struct {
+	UserID    string
+	SessionID string
+}
+
+// BeginPasskeyRegistrationResponse mirrors auth.BeginPasskeyRegistrationResponse
+type BeginPasskeyRegistrationResponse struct {
+	ChallengeID          string
+	Challenge            []byte
+	RPID                 string
+	RPName               string
+	UserHandle           []byte
+	UserName             string
+	Algorithms           []int64
+	ExcludeCredentialIDs [][]byte
+	TimeoutMs            int64
+}
+
+// FinishPasskeyRegistrationRequest mirrors auth.FinishPasskeyRegistrationRequest
+type FinishPasskeyRegistrationRequest struct {
+	UserID            string
+	ChallengeID       string
+	Name              string
+	DeviceID          string
+	ClientDataJSON    []byte
+	AttestationObject []byte
+}
+
+// BeginPasskeyLoginRequest mirrors auth.BeginPasskeyLoginRequest
+type BeginPasskeyLoginRequest struct {
+	Email string
+}
+
+// BeginPasskeyLoginResponse mirrors auth.BeginPasskeyLoginResponse
+type BeginPasskeyLoginResponse struct {
+	ChallengeID        string
+	Challenge          []byte
+	RPID               string
+	AllowCredentialIDs [][]byte
+	TimeoutMs          int64
+}
+
+// FinishPasskeyLoginRequest mirrors auth.FinishPasskeyLoginRequest
+type FinishPasskeyLoginRequest struct {
+	ChallengeID       string
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+	UserHandle        []byte
+	Client            ClientContext
+}
+
+// ListPasskeysRequest mirrors auth.ListPasskeysRequest
+type ListPasskeysRequest struct {
+	UserID string
+}
+
+// ListPasskeysResponse mirrors auth.ListPasskeysResponse
+type ListPasskeysResponse struct {
+	Passkeys []*Passkey
+}
+
+// DeletePasskeyRequest mirrors auth.DeletePasskeyRequest
+type DeletePasskeyRequest struct {
+	UserID    string
+	PasskeyID string
+}
+
+// DeletePasskeyResponse mirrors auth.DeletePasskeyResponse
+type DeletePasskeyResponse struct {
+	Message string
+}
+
+// Passkey mirrors auth.Passkey
+type Passkey struct {
+	ID                string
+	Name              string
+	AAGUID            string
+	AttestationFormat string
+	BackupEligible    bool
+	BackupState       bool
+	LastUsedAt        *time.Time
+	CreatedAt         time.Time
+}
+
+// BeginPasskeyRegistration returns creation options for a new passkey
+func (h *AuthHandler) BeginPasskeyRegistration(ctx context.Context, req *BeginPasskeyRegistrationRequest) (*BeginPasskeyRegistrationResponse, error) {
+	options, err := h.authBusiness.BeginPasskeyRegistration(ctx, req.UserID, req.SessionID)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &BeginPasskeyRegistrationResponse{
+		ChallengeID:          options.ChallengeID,
+		Challenge:            options.Challenge,
+		RPID:                 options.RPID,
+		RPName:               options.RPName,
+		UserHandle:           options.UserHandle,
+		UserName:             options.UserName,
+		Algorithms:           options.Algorithms,
+		ExcludeCredentialIDs: options.ExcludeCredentialIDs,
+		TimeoutMs:            options.Timeout.Milliseconds(),
+	}, nil
+}
+
+// FinishPasskeyRegistration verifies the attestation and stores the passkey
+func (h *AuthHandler) FinishPasskeyRegistration(ctx context.Context, req *FinishPasskeyRegistrationRequest) (*Passkey, error) {
+	credential, err := h.authBusiness.FinishPasskeyRegistration(ctx, business.FinishPasskeyRegistrationInput{
+		UserUUID:          req.UserID,
+		ChallengeID:       req.ChallengeID,
+		Name:              req.Name,
+		DeviceUUID:        req.DeviceID,
+		ClientDataJSON:    req.ClientDataJSON,
+		AttestationObject: req.AttestationObject,
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return newPasskey(credential), nil
+}
+
+// BeginPasskeyLogin returns request options for a passkey sign-in
+func (h *AuthHandler) BeginPasskeyLogin(ctx context.Context, req *BeginPasskeyLoginRequest) (*BeginPasskeyLoginResponse, error) {
+	options, err := h.authBusiness.BeginPasskeyLogin(ctx, req.Email)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &BeginPasskeyLoginResponse{
+		ChallengeID:        options.ChallengeID,
+		Challenge:          options.Challenge,
+		RPID:               options.RPID,
+		AllowCredentialIDs: options.AllowCredentialIDs,
+		TimeoutMs:          options.Timeout.Milliseconds(),
+	}, nil
+}
+
+// FinishPasskeyLogin verifies the assertion and starts a session
+func (h *AuthHandler) FinishPasskeyLogin(ctx context.Context, req *FinishPasskeyLoginRequest) (*LoginResponse, error) {
+	result, err := h.authBusiness.FinishPasskeyLogin(ctx, business.FinishPasskeyLoginInput{
+		ChallengeID:       req.ChallengeID,
+		CredentialID:      req.CredentialID,
+		ClientDataJSON:    req.ClientDataJSON,
+		AuthenticatorData: req.AuthenticatorData,
+		Signature:         req.Signature,
+		UserHandle:        req.UserHandle,
+		Client:            req.Client.toBusiness(),
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return newLoginResponse(result), nil
+}
+
+// ListPasskeys returns the user's passkeys
+func (h *AuthHandler) ListPasskeys(ctx context.Context, req *ListPasskeysRequest) (*ListPasskeysResponse, error) {
+	credentials, err := h.authBusiness.ListPasskeys(ctx, req.UserID)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	response := &ListPasskeysResponse{Passkeys: make([]*Passkey, 0, len(credentials))}
+	for _, credential := range credentials {
+		response.Passkeys = append(response.Passkeys, newPasskey(credential))
+	}
+	return response, nil
+}
+
+// DeletePasskey removes one of the user's passkeys
+func (h *AuthHandler) DeletePasskey(ctx context.Context, req *DeletePasskeyRequest) (*DeletePasskeyResponse, error) {
+	if err := h.authBusiness.DeletePasskey(ctx, req.UserID, req.PasskeyID); err != nil {
+		return nil, toStatusError(err)
+	}
+	return &DeletePasskeyResponse{Message: "passkey deleted"}, nil
+}
+
+func newPasskey(credential *models.WebAuthnCredential) *Passkey {
+	passkey := &Passkey{
+		ID:                credential.UUID,
+		AttestationFormat: credential.AttestationFormat,
+		BackupEligible:    credential.BackupEligible,
+		BackupState:       credential.BackupState,
+		LastUsedAt:        credential.LastUsedAt,
+		CreatedAt:         credential.CreatedAt,
+	}
+	if credential.Name != nil {
+		passkey.Name = *credential.Name
+	}
+	if credential.AAGUID != nil {
+		passkey.AAGUID = *credential.AAGUID
+	}
+	return passkey
+}
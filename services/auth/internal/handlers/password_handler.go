@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/your-project/services/auth/internal/business"
+)
+
+// RequestPasswordResetRequest mirrors auth.RequestPasswordResetRequest
+type RequestPasswordResetRequest struct {
+	Email  string
+	Client ClientContext
+}
+
+// RequestPasswordResetResponse mirrors auth.RequestPasswordResetResponse. It is
+// empty whether or not the email belongs to an account.
+type RequestPasswordResetResponse struct{}
+
+// ResetPasswordRequest mirrors auth.ResetPasswordRequest
+type ResetPasswordRequest struct {
+	Token       string
+	NewPassword string
+	Client      ClientContext
+}
+
+// ChangePasswordRequest mirrors auth.ChangePasswordRequest
+type ChangePasswordRequest struct {
+	UserID string
+	// SessionID is the caller's session, which stays signed in
+	SessionID       string
+	CurrentPassword string
+	NewPassword     string
+	Client          ClientContext
+}
+
+// RequestPasswordReset emails a password reset link
+func (h *AuthHandler) RequestPasswordReset(ctx context.Context, req *RequestPasswordResetRequest) (*RequestPasswordResetResponse, error) {
+	if err := h.authBusiness.RequestPasswordReset(ctx, req.Email, req.Client.toBusiness()); err != nil {
+		return nil, toStatusError(err)
+	}
+	return &RequestPasswordResetResponse{}, nil
+}
+
+// ResetPassword sets a new password with a reset token and signs out every session
+func (h *AuthHandler) ResetPassword(ctx context.Context, req *ResetPasswordRequest) (*RevokeSessionsResponse, error) {
+	count, err := h.authBusiness.ResetPassword(ctx, business.ResetPasswordInput{
+		Token:       req.Token,
+		NewPassword: req.NewPassword,
+		Client:      req.Client.toBusiness(),
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &RevokeSessionsResponse{RevokedCount: int32(count)}, nil
+}
+
+// ChangePassword replaces the password and signs out every other session
+func (h *AuthHandler) ChangePassword(ctx context.Context, req *ChangePasswordRequest) (*RevokeSessionsResponse, error) {
+	count, err := h.authBusiness.ChangePassword(ctx, business.ChangePasswordInput{
+		UserID:          req.UserID,
+		SessionID:       req.SessionID,
+		CurrentPassword: req.CurrentPassword,
+		NewPassword:     req.NewPassword,
+		Client:          req.Client.toBusiness(),
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &RevokeSessionsResponse{RevokedCount: int32(count)}, nil
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/your-project/services/auth/internal/business"
+	"github.com/your-project/services/auth/internal/models"
+)
+
+// ListProvidersRequest mirrors auth.ListProvidersRequest
+type ListProvidersRequest struct{}
+
+// ListProvidersResponse mirrors auth.ListProvidersResponse
+type ListProvidersResponse struct {
+	Providers []*ProviderConfig
+}
+
+// ProviderSettings mirrors auth.ProviderSettings
+type ProviderSettings struct {
+	DisplayName string
+	ClientID    string
+	// ClientSecret is write-only; on update an empty value keeps the stored secret
+	ClientSecret     string
+	AuthorizationURL string
+	TokenURL         string
+	UserinfoURL      string
+	Scopes           []string
+	RedirectURI      string
+	Config           map[string]interface{}
+}
+
+// CreateProviderRequest mirrors auth.CreateProviderRequest
+type CreateProviderRequest struct {
+	Name     string
+	Settings ProviderSettings
+	Enabled  bool
+}
+
+// UpdateProviderRequest mirrors auth.UpdateProviderRequest
+type UpdateProviderRequest struct {
+	ProviderID string
+	Settings   ProviderSettings
+}
+
+// ProviderRequest mirrors auth.ProviderRequest
+type ProviderRequest struct {
+	ProviderID string
+}
+
+// ProviderConfig mirrors auth.ProviderConfig. The client secret is never returned.
+type ProviderConfig struct {
+	ID               string
+	Name             string
+	DisplayName      string
+	ClientID         string
+	HasClientSecret  bool
+	AuthorizationURL string
+	TokenURL         string
+	UserinfoURL      string
+	Scopes           []string
+	RedirectURI      string
+	IsEnabled        bool
+	Config           map[string]interface{}
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+// ListProviders returns every configured provider
+func (h *AuthHandler) ListProviders(ctx context.Context, req *ListProvidersRequest) (*ListProvidersResponse, error) {
+	providers, err := h.authBusiness.ListProviders(ctx)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	response := &ListProvidersResponse{Providers: make([]*ProviderConfig, 0, len(providers))}
+	for _, provider := range providers {
+		response.Providers = append(response.Providers, newProviderConfig(provider))
+	}
+	return response, nil
+}
+
+// CreateProvider adds a provider
+func (h *AuthHandler) CreateProvider(ctx context.Context, req *CreateProviderRequest) (*ProviderConfig, error) {
+	input := req.Settings.toBusiness()
+	input.Name = req.Name
+	input.Enabled = req.Enabled
+
+	provider, err := h.authBusiness.CreateProvider(ctx, input)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return newProviderConfig(provider), nil
+}
+
+// UpdateProvider replaces a provider's settings
+func (h *AuthHandler) UpdateProvider(ctx context.Context, req *UpdateProviderRequest) (*ProviderConfig, error) {
+	provider, err := h.authBusiness.UpdateProvider(ctx, req.ProviderID, req.Settings.toBusiness())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return newProviderConfig(provider), nil
+}
+
+// EnableProvider allows sign-in with a provider
+func (h *AuthHandler) EnableProvider(ctx context.Context, req *ProviderRequest) (*ProviderConfig, error) {
+	provider, err := h.authBusiness.EnableProvider(ctx, req.ProviderID)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return newProviderConfig(provider), nil
+}
+
+// DisableProvider stops sign-in with a provider
+func (h *AuthHandler) DisableProvider(ctx context.Context, req *ProviderRequest) (*ProviderConfig, error) {
+	provider, err := h.authBusiness.DisableProvider(ctx, req.ProviderID)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return newProviderConfig(provider), nil
+}
+
+func (s ProviderSettings) toBusiness() business.ProviderInput {
+	return business.ProviderInput{
+		DisplayName:      s.DisplayName,
+		ClientID:         s.ClientID,
+		ClientSecret:     s.ClientSecret,
+		AuthorizationURL: s.AuthorizationURL,
+		TokenURL:         s.TokenURL,
+		UserinfoURL:      s.UserinfoURL,
+		Scopes:           s.Scopes,
+		RedirectURI:      s.RedirectURI,
+		Config:           s.Config,
+	}
+}
+
+func newProviderConfig(provider *models.Provider) *ProviderConfig {
+	result := &ProviderConfig{
+		ID:               provider.UUID,
+		Name:             provider.Name,
+		DisplayName:      provider.DisplayName,
+		ClientID:         provider.ClientID,
+		HasClientSecret:  provider.ClientSecret != "",
+		AuthorizationURL: provider.AuthorizationURL,
+		TokenURL:         provider.TokenURL,
+		IsEnabled:        provider.IsEnabled,
+		Config:           provider.Config,
+		CreatedAt:        provider.CreatedAt,
+		UpdatedAt:        provider.UpdatedAt,
+	}
+	if provider.UserinfoURL != nil {
+		result.UserinfoURL = *provider.UserinfoURL
+	}
+	if provider.Scopes != nil {
+		result.Scopes = strings.Fields(*provider.Scopes)
+	}
+	if provider.RedirectURI != nil {
+		result.RedirectURI = *provider.RedirectURI
+	}
+	return result
+}
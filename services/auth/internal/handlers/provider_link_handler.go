@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/your-project/services/auth/internal/business"
+)
+
+// LinkProviderRequest mirrors auth.LinkProviderRequest
+type LinkProviderRequest struct {
+	UserID    string
+	SessionID string
+	Provider  string
+	Code      string
+	State     string
+	User      string
+	LinkToken string
+	Client    ClientContext
+}
+
+// UnlinkProviderRequest mirrors auth.UnlinkProviderRequest
+type UnlinkProviderRequest struct {
+	UserID    string
+	SessionID string
+	LinkID    string
+	Client    ClientContext
+}
+
+// SetPrimaryProviderRequest mirrors auth.SetPrimaryProviderRequest
+type SetPrimaryProviderRequest struct {
+	UserID    string
+	SessionID string
+	LinkID    string
+	Client    ClientContext
+}
+
+// ListLinkedProvidersRequest mirrors auth.ListLinkedProvidersRequest
+type ListLinkedProvidersRequest struct {
+	UserID string
+}
+
+// ListLinkedProvidersResponse mirrors auth.ListLinkedProvidersResponse
+type ListLinkedProvidersResponse struct {
+	Providers []*LinkedProvider
+}
+
+// UnlinkProviderResponse mirrors auth.UnlinkProviderResponse
+type UnlinkProviderResponse struct {
+	Success bool
+}
+
+// LinkedProvider mirrors auth.LinkedProvider
+type LinkedProvider struct {
+	ID          string
+	Provider    string
+	DisplayName string
+	Email       string
+	Name        string
+	IsPrimary   bool
+	IsActive    bool
+	LastUsedAt  time.Time
+	CreatedAt   time.Time
+}
+
+// LinkProvider links a provider account to the signed-in user
+func (h *AuthHandler) LinkProvider(ctx context.Context, req *LinkProviderRequest) (*LinkedProvider, error) {
+	linked, err := h.authBusiness.LinkProvider(ctx, business.LinkProviderInput{
+		UserID:    req.UserID,
+		SessionID: req.SessionID,
+		Provider:  req.Provider,
+		Code:      req.Code,
+		State:     req.State,
+		User:      req.User,
+		LinkToken: req.LinkToken,
+		Client:    req.Client.toBusiness(),
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return newLinkedProvider(linked), nil
+}
+
+// UnlinkProvider removes a provider link
+func (h *AuthHandler) UnlinkProvider(ctx context.Context, req *UnlinkProviderRequest) (*UnlinkProviderResponse, error) {
+	if err := h.authBusiness.UnlinkProvider(ctx, req.UserID, req.SessionID, req.LinkID, req.Client.toBusiness()); err != nil {
+		return nil, toStatusError(err)
+	}
+	return &UnlinkProviderResponse{Success: true}, nil
+}
+
+// SetPrimaryProvider makes a link the user's primary provider
+func (h *AuthHandler) SetPrimaryProvider(ctx context.Context, req *SetPrimaryProviderRequest) (*LinkedProvider, error) {
+	linked, err := h.authBusiness.SetPrimaryProvider(ctx, req.UserID, req.SessionID, req.LinkID, req.Client.toBusiness())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return newLinkedProvider(linked), nil
+}
+
+// ListLinkedProviders returns the user's provider links
+func (h *AuthHandler) ListLinkedProviders(ctx context.Context, req *ListLinkedProvidersRequest) (*ListLinkedProvidersResponse, error) {
+	linked, err := h.authBusiness.ListLinkedProviders(ctx, req.UserID)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	response := &ListLinkedProvidersResponse{Providers: make([]*LinkedProvider, 0, len(linked))}
+	for _, item := range linked {
+		response.Providers = append(response.Providers, newLinkedProvider(item))
+	}
+	return response, nil
+}
+
+func newLinkedProvider(linked *business.LinkedProvider) *LinkedProvider {
+	result := &LinkedProvider{
+		ID:          linked.Link.UUID,
+		Provider:    linked.Provider.Name,
+		DisplayName: linked.Provider.DisplayName,
+		IsPrimary:   linked.Link.IsPrimary,
+		IsActive:    linked.Link.IsActive,
+		CreatedAt:   linked.Link.CreatedAt,
+	}
+	result.Email, _ = linked.Link.Meta["email"].(string)
+	result.Name, _ = linked.Link.Meta["name"].(string)
+	if linked.Link.LastUsedAt != nil {
+		result.LastUsedAt = *linked.Link.LastUsedAt
+	}
+	return result
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/your-project/services/auth/internal/business"
+)
+
+// CreateServiceAccountRequest mirrors auth.CreateServiceAccountRequest
+type CreateServiceAccountRequest struct {
+	ClientID string
+	Name     string
+	Scopes   []string
+}
+
+// IssueServiceTokenRequest mirrors auth.IssueServiceTokenRequest
+type IssueServiceTokenRequest struct {
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+// ServiceTokenResponse mirrors auth.ServiceTokenResponse
+type ServiceTokenResponse struct {
+	AccessToken string
+	TokenType   string
+	ExpiresIn   int64
+	Scope       string
+}
+
+// CreateServiceAccount registers a service account; its secret is only returned here
+func (h *AuthHandler) CreateServiceAccount(ctx context.Context, req *CreateServiceAccountRequest) (*CreateOAuthClientResponse, error) {
+	client, secret, err := h.authBusiness.CreateServiceAccount(ctx, business.ServiceAccountInput{
+		ClientID: req.ClientID,
+		Name:     req.Name,
+		Scopes:   req.Scopes,
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &CreateOAuthClientResponse{Client: newOAuthClient(client), ClientSecret: secret}, nil
+}
+
+// IssueServiceToken exchanges service account credentials for a short-lived access token
+func (h *AuthHandler) IssueServiceToken(ctx context.Context, req *IssueServiceTokenRequest) (*ServiceTokenResponse, error) {
+	tokens, err := h.authBusiness.IssueServiceToken(ctx, business.ServiceTokenRequest{
+		ClientID:     req.ClientID,
+		ClientSecret: req.ClientSecret,
+		Scopes:       req.Scopes,
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &ServiceTokenResponse{
+		AccessToken: tokens.AccessToken,
+		TokenType:   tokens.TokenType,
+		ExpiresIn:   tokens.ExpiresIn,
+		Scope:       tokens.Scope,
+	}, nil
+}
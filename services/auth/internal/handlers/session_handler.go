@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/your-project/services/auth/internal/models"
+)
+
+// ValidateAccessTokenRequest mirrors auth.ValidateAccessTokenRequest
+type ValidateAccessTokenRequest struct {
+	AccessToken string
+}
+
+// ValidateAccessTokenResponse mirrors auth.ValidateAccessTokenResponse
+type ValidateAccessTokenResponse struct {
+	UserID    string
+	Email     string
+	SessionID string
+	ExpiresAt time.Time
+	Scope     string
+	// ActorID is the admin's UUID on impersonation tokens
+	ActorID string
+}
+
+// ListSessionsRequest mirrors auth.ListSessionsRequest
+type ListSessionsRequest struct {
+	UserID string
+	// CurrentSessionID marks the caller's own session in the response
+	CurrentSessionID string
+}
+
+// ListSessionsResponse mirrors auth.ListSessionsResponse
+type ListSessionsResponse struct {
+	Sessions []*Session
+}
+
+// RevokeSessionRequest mirrors auth.RevokeSessionRequest
+type RevokeSessionRequest struct {
+	UserID    string
+	SessionID string
+	Client    ClientContext
+}
+
+// RevokeOtherSessionsRequest mirrors auth.RevokeOtherSessionsRequest
+type RevokeOtherSessionsRequest struct {
+	UserID           string
+	CurrentSessionID string
+	Client           ClientContext
+}
+
+// RevokeAllSessionsRequest mirrors auth.RevokeAllSessionsRequest
+type RevokeAllSessionsRequest struct {
+	UserID string
+	Client ClientContext
+}
+
+// RevokeSessionsResponse mirrors auth.RevokeSessionsResponse
+type RevokeSessionsResponse struct {
+	RevokedCount int32
+}
+
+// Session mirrors auth.Session
+type Session struct {
+	ID         string
+	DeviceInfo map[string]interface{}
+	IPAddress  string
+	UserAgent  string
+	Current    bool
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+	// ImpersonatedBy is the admin's UUID on impersonation sessions
+	ImpersonatedBy string
+}
+
+// ValidateAccessToken checks an access token and the session it belongs to
+func (h *AuthHandler) ValidateAccessToken(ctx context.Context, req *ValidateAccessTokenRequest) (*ValidateAccessTokenResponse, error) {
+	claims, err := h.authBusiness.ValidateAccessToken(ctx, req.AccessToken)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	response := &ValidateAccessTokenResponse{
+		UserID:    claims.Subject,
+		Email:     claims.Email,
+		SessionID: claims.SessionID,
+		Scope:     claims.Scope,
+	}
+	if claims.ExpiresAt != nil {
+		response.ExpiresAt = claims.ExpiresAt.Time
+	}
+	if claims.Act != nil {
+		response.ActorID = claims.Act.Subject
+	}
+	return response, nil
+}
+
+// ListSessions returns the user's live sessions
+func (h *AuthHandler) ListSessions(ctx context.Context, req *ListSessionsRequest) (*ListSessionsResponse, error) {
+	sessions, err := h.authBusiness.ListSessions(ctx, req.UserID)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	response := &ListSessionsResponse{Sessions: make([]*Session, 0, len(sessions))}
+	for _, session := range sessions {
+		response.Sessions = append(response.Sessions, newSession(session, req.CurrentSessionID))
+	}
+	return response, nil
+}
+
+// RevokeSession ends a single session
+func (h *AuthHandler) RevokeSession(ctx context.Context, req *RevokeSessionRequest) (*RevokeSessionsResponse, error) {
+	if err := h.authBusiness.RevokeSession(ctx, req.UserID, req.SessionID, req.Client.toBusiness()); err != nil {
+		return nil, toStatusError(err)
+	}
+	return &RevokeSessionsResponse{RevokedCount: 1}, nil
+}
+
+// RevokeOtherSessions ends every session except the caller's
+func (h *AuthHandler) RevokeOtherSessions(ctx context.Context, req *RevokeOtherSessionsRequest) (*RevokeSessionsResponse, error) {
+	count, err := h.authBusiness.RevokeOtherSessions(ctx, req.UserID, req.CurrentSessionID, req.Client.toBusiness())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &RevokeSessionsResponse{RevokedCount: int32(count)}, nil
+}
+
+// RevokeAllSessions ends every session of the user
+func (h *AuthHandler) RevokeAllSessions(ctx context.Context, req *RevokeAllSessionsRequest) (*RevokeSessionsResponse, error) {
+	count, err := h.authBusiness.RevokeAllSessions(ctx, req.UserID, req.Client.toBusiness())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &RevokeSessionsResponse{RevokedCount: int32(count)}, nil
+}
+
+func newSession(session *models.Session, currentSessionID string) *Session {
+	result := &Session{
+		ID:         session.UUID,
+		DeviceInfo: session.DeviceInfo,
+		Current:    currentSessionID != "" && session.UUID == currentSessionID,
+		CreatedAt:  session.CreatedAt,
+		LastUsedAt: session.LastUsedAt,
+		ExpiresAt:  session.ExpiresAt,
+	}
+	if session.IPAddress != nil {
+		result.IPAddress = *session.IPAddress
+	}
+	if session.UserAgent != nil {
+		result.UserAgent = *session.UserAgent
+	}
+	result.ImpersonatedBy, _ = session.Meta["impersonated_by"].(string)
+	return result
+}
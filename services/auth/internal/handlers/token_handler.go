@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/your-project/services/auth/internal/business"
+)
+
+// RefreshTokenRequest mirrors auth.RefreshTokenRequest
+type RefreshTokenRequest struct {
+	RefreshToken string
+	Client       ClientContext
+}
+
+// RefreshTokenResponse mirrors auth.RefreshTokenResponse
+type RefreshTokenResponse struct {
+	Tokens *TokenPair
+}
+
+// RefreshAccessToken rotates the refresh token and issues a new access token.
+// Aborted means a concurrent refresh won the race; the client should use its result.
+func (h *AuthHandler) RefreshAccessToken(ctx context.Context, req *RefreshTokenRequest) (*RefreshTokenResponse, error) {
+	tokens, err := h.authBusiness.RefreshTokens(ctx, business.RefreshTokensInput{
+		RefreshToken: req.RefreshToken,
+		Client:       req.Client.toBusiness(),
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &RefreshTokenResponse{Tokens: newTokenPair(tokens)}, nil
+}
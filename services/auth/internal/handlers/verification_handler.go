@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/your-project/services/auth/internal/business"
+)
+
+// StartVerificationRequest mirrors auth.StartVerificationRequest
+type StartVerificationRequest struct {
+	UserID string
+	// Channel is "email" or "phone"
+	Channel string
+	Client  ClientContext
+}
+
+// StartVerificationResponse mirrors auth.StartVerificationResponse
+type StartVerificationResponse struct {
+	Channel     string
+	Destination string
+	ExpiresAt   time.Time
+	ResendAfter time.Time
+}
+
+// ConfirmVerificationRequest mirrors auth.ConfirmVerificationRequest
+type ConfirmVerificationRequest struct {
+	UserID  string
+	Channel string
+	Code    string
+	Client  ClientContext
+}
+
+// VerificationStatus mirrors auth.VerificationStatus
+type VerificationStatus struct {
+	UserID          string
+	IsVerified      bool
+	EmailVerifiedAt *time.Time
+	PhoneVerifiedAt *time.Time
+}
+
+// StartVerification sends a verification code to the user's email or phone
+func (h *AuthHandler) StartVerification(ctx context.Context, req *StartVerificationRequest) (*StartVerificationResponse, error) {
+	challenge, err := h.authBusiness.StartVerification(ctx, business.StartVerificationInput{
+		UserID:  req.UserID,
+		Channel: req.Channel,
+		Client:  req.Client.toBusiness(),
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &StartVerificationResponse{
+		Channel:     challenge.Channel,
+		Destination: challenge.Destination,
+		ExpiresAt:   challenge.ExpiresAt,
+		ResendAfter: challenge.ResendAfter,
+	}, nil
+}
+
+// ConfirmVerification checks a verification code and marks the channel verified
+func (h *AuthHandler) ConfirmVerification(ctx context.Context, req *ConfirmVerificationRequest) (*VerificationStatus, error) {
+	user, err := h.authBusiness.ConfirmVerification(ctx, business.ConfirmVerificationInput{
+		UserID:  req.UserID,
+		Channel: req.Channel,
+		Code:    req.Code,
+		Client:  req.Client.toBusiness(),
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &VerificationStatus{
+		UserID:          user.UUID,
+		IsVerified:      user.IsVerified,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		PhoneVerifiedAt: user.PhoneVerifiedAt,
+	}, nil
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+// Action token purposes stored in sr_auth.action_tokens.purpose
+const (
+	TokenPurposeMagicLink = "magic_link"
+	// TokenPurposeOAuthLink holds a provider account waiting to be linked after re-authentication
+	TokenPurposeOAuthLink = "oauth_link"
+	// TokenPurposePasswordReset is emailed by RequestPasswordReset
+	TokenPurposePasswordReset = "password_reset"
+	// TokenPurposeAccountRestore is emailed by DeleteAccount and valid for the grace period
+	TokenPurposeAccountRestore = "account_restore"
+)
+
+// ActionToken represents a row in sr_auth.action_tokens
+type ActionToken struct {
+	ID          int
+	UUID        string
+	UserID      int
+	Purpose     string
+	TokenHash   string
+	BindingHash *string
+	DeviceID    *string
+	IPAddress   *string
+	UserAgent   *string
+	ExpiresAt   time.Time
+	UsedAt      *time.Time
+	Meta        map[string]interface{}
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   *time.Time
+}
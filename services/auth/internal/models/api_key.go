@@ -0,0 +1,53 @@
+package models
+
+import (
+	"net"
+	"time"
+)
+
+// APIKey represents a row in sr_auth.api_keys. A personal key belongs to a user
+// (UserID); a machine key belongs to a service account (OAuthClientID).
+type APIKey struct {
+	ID            int
+	UUID          string
+	UserID        *int
+	OAuthClientID *int
+	Name          string
+	// KeyPrefix is the public start of the key, such as "sr_1a2b3c4d5e6f"
+	KeyPrefix string
+	KeyHash   string
+	Scopes    []string
+	// AllowedIPs are CIDR ranges the key may be used from; empty allows any address
+	AllowedIPs  []string
+	ExpiresAt   time.Time
+	LastUsedAt  *time.Time
+	LastUsedIP  *string
+	RevokedAt   *time.Time
+	RotatedToID *int
+	IsActive    bool
+	Meta        map[string]interface{}
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   *time.Time
+}
+
+// IsUsable reports whether the key is neither revoked nor expired at now
+func (k *APIKey) IsUsable(now time.Time) bool {
+	return k.IsActive && k.RevokedAt == nil && now.Before(k.ExpiresAt)
+}
+
+// AllowsIP reports whether ip falls within the key's allow list
+func (k *APIKey) AllowsIP(ip net.IP) bool {
+	if len(k.AllowedIPs) == 0 {
+		return true
+	}
+	if ip == nil {
+		return false
+	}
+	for _, cidr := range k.AllowedIPs {
+		if _, network, err := net.ParseCIDR(cidr); err == nil && network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// Contact change statuses stored in sr_auth.contact_changes.status
+const (
+	ContactChangePending   = "pending"
+	ContactChangeConfirmed = "confirmed"
+	ContactChangeUndone    = "undone"
+	// ContactChangeCancelled is set when a newer change or an undo supersedes the row
+	ContactChangeCancelled = "cancelled"
+)
+
+// ContactChange represents a row in sr_auth.contact_changes: a request to
+// replace the user's email address or phone number
+type ContactChange struct {
+	ID     int
+	UUID   string
+	UserID int
+	// Channel is VerificationChannelEmail or VerificationChannelPhone
+	Channel string
+	// OldValue is nil when a phone number is added rather than replaced
+	OldValue         *string
+	NewValue         string
+	Status           string
+	ConfirmTokenHash string
+	ExpiresAt        time.Time
+	ConfirmedAt      *time.Time
+	UndoTokenHash    *string
+	UndoExpiresAt    *time.Time
+	UndoneAt         *time.Time
+	IPAddress        *string
+	UserAgent        *string
+	Meta             map[string]interface{}
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	DeletedAt        *time.Time
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// Device represents a row in sr_auth.devices
+type Device struct {
+	ID         int
+	UUID       string
+	UserID     int
+	DeviceName *string
+	DeviceType *string
+	DeviceID   *string
+	Browser    *string
+	OS         *string
+	IPAddress  *string
+	IsTrusted  bool
+	IsActive   bool
+	LastUsedAt time.Time
+	TrustedAt  *time.Time
+	Meta       map[string]interface{}
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  *time.Time
+}
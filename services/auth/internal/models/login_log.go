@@ -0,0 +1,101 @@
+package models
+
+import (
+	"time"
+)
+
+// Login log event types stored in sr_auth.login_logs.event_type
+const (
+	EventLoginSuccess    = "login_success"
+	EventLoginFailed     = "login_failed"
+	EventLogout          = "logout"
+	EventSessionExpired  = "session_expired"
+	EventPasswordChanged = "password_changed"
+	EventAccountLocked   = "account_locked"
+	EventMFAChallenge    = "mfa_challenge"
+	EventMFAFailed       = "mfa_failed"
+	EventMFAEnabled      = "mfa_enabled"
+	EventMFADisabled     = "mfa_disabled"
+
+	EventMagicLinkRequested = "magic_link_requested"
+	EventSessionRevoked     = "session_revoked"
+	EventTokenRefreshed     = "token_refreshed"
+	EventRefreshTokenReused = "refresh_token_reused"
+	EventNewDeviceSignIn    = "new_device_sign_in"
+	EventDeviceTrusted      = "device_trusted"
+	EventDeviceUntrusted    = "device_untrusted"
+	EventDeviceRemoved      = "device_removed"
+
+	EventProviderLinked         = "provider_linked"
+	EventProviderUnlinked       = "provider_unlinked"
+	EventPrimaryProviderChanged = "primary_provider_changed"
+
+	EventOAuthAuthorized = "oauth_authorized"
+	EventOAuthCodeReused = "oauth_code_reused"
+
+	EventAPIKeyCreated  = "api_key_created"
+	EventAPIKeyRevoked  = "api_key_revoked"
+	EventAPIKeyRotated  = "api_key_rotated"
+	EventAPIKeyRejected = "api_key_rejected"
+
+	EventVerificationSent      = "verification_sent"
+	EventVerificationSucceeded = "verification_succeeded"
+	EventVerificationFailed    = "verification_failed"
+
+	EventPasswordResetRequested = "password_reset_requested"
+
+	EventContactChangeRequested = "contact_change_requested"
+	EventContactChanged         = "contact_changed"
+	EventContactChangeUndone    = "contact_change_undone"
+
+	EventAccountDeactivated = "account_deactivated"
+	EventAccountReactivated = "account_reactivated"
+	EventAccountDeleted     = "account_deleted"
+	EventAccountRestored    = "account_restored"
+
+	// EventImpersonationStarted is recorded on the impersonated user
+	EventImpersonationStarted = "impersonation_started"
+)
+
+// loginEventTypes is the closed set of event types the service writes
+var loginEventTypes = map[string]bool{
+	EventLoginSuccess: true, EventLoginFailed: true, EventLogout: true, EventSessionExpired: true,
+	EventPasswordChanged: true, EventAccountLocked: true, EventMFAChallenge: true, EventMFAFailed: true,
+	EventMFAEnabled: true, EventMFADisabled: true, EventMagicLinkRequested: true, EventSessionRevoked: true,
+	EventTokenRefreshed: true, EventRefreshTokenReused: true, EventNewDeviceSignIn: true,
+	EventDeviceTrusted: true, EventDeviceUntrusted: true, EventDeviceRemoved: true,
+	EventProviderLinked: true, EventProviderUnlinked: true, EventPrimaryProviderChanged: true,
+	EventOAuthAuthorized: true, EventOAuthCodeReused: true,
+	EventAPIKeyCreated: true, EventAPIKeyRevoked: true, EventAPIKeyRotated: true, EventAPIKeyRejected: true,
+	EventVerificationSent: true, EventVerificationSucceeded: true, EventVerificationFailed: true,
+	EventPasswordResetRequested: true,
+	EventContactChangeRequested: true, EventContactChanged: true, EventContactChangeUndone: true,
+	EventAccountDeactivated: true, EventAccountReactivated: true, EventAccountDeleted: true, EventAccountRestored: true,
+	EventImpersonationStarted: true,
+}
+
+// IsLoginEventType reports whether eventType is one the service writes
+func IsLoginEventType(eventType string) bool {
+	return loginEventTypes[eventType]
+}
+
+// LoginLog represents a row in sr_auth.login_logs
+type LoginLog struct {
+	ID            int
+	UUID          string
+	UserID        *int
+	SessionID     *int
+	EventType     string
+	IPAddress     *string
+	UserAgent     *string
+	DeviceInfo    map[string]interface{}
+	Success       bool
+	FailureReason *string
+	Meta          map[string]interface{}
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     *time.Time
+
+	// UserUUID is populated by queries that join sr_auth.users
+	UserUUID *string
+}
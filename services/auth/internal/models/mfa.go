@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+)
+
+// TOTPCredential represents a row in sr_auth.mfa_totp
+type TOTPCredential struct {
+	ID           int
+	UUID         string
+	UserID       int
+	Secret       string
+	ConfirmedAt  *time.Time
+	LastUsedStep *int64
+	Meta         map[string]interface{}
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    *time.Time
+}
+
+// IsConfirmed reports whether enrollment was completed with a valid code
+func (t *TOTPCredential) IsConfirmed() bool {
+	return t.ConfirmedAt != nil
+}
+
+// RecoveryCode represents a row in sr_auth.mfa_recovery_codes
+type RecoveryCode struct {
+	ID        int
+	UUID      string
+	UserID    int
+	CodeHash  string
+	UsedAt    *time.Time
+	Meta      map[string]interface{}
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt *time.Time
+}
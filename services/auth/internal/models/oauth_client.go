@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+)
+
+// OAuth grant types a client may be registered for
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+	GrantTypeClientCredentials = "client_credentials"
+)
+
+// OAuthClient represents a row in sr_auth.oauth_clients: an application that
+// signs users in through the service's own authorization server
+type OAuthClient struct {
+	ID       int
+	UUID     string
+	ClientID string
+	// ClientSecretHash is empty for public clients, which authenticate with PKCE only
+	ClientSecretHash string
+	Name             string
+	RedirectURIs     []string
+	GrantTypes       []string
+	Scopes           []string
+	IsFirstParty     bool
+	IsActive         bool
+	Meta             map[string]interface{}
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	DeletedAt        *time.Time
+}
+
+// IsConfidential reports whether the client has a secret to authenticate with
+func (c *OAuthClient) IsConfidential() bool {
+	return c.ClientSecretHash != ""
+}
+
+// AllowsGrant reports whether the client is registered for grantType
+func (c *OAuthClient) AllowsGrant(grantType string) bool {
+	for _, allowed := range c.GrantTypes {
+		if allowed == grantType {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRedirectURI reports whether uri exactly matches a registered redirect URI
+func (c *OAuthClient) HasRedirectURI(uri string) bool {
+	for _, registered := range c.RedirectURIs {
+		if registered == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// OAuthAuthorization represents a row in sr_auth.oauth_authorizations: an
+// /authorize request and, once the user approved it, its authorization code
+type OAuthAuthorization struct {
+	ID                  int
+	UUID                string
+	ClientID            int
+	RedirectURI         string
+	Scope               string
+	State               *string
+	Nonce               *string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	UserID              *int
+	SessionID           *int
+	CodeHash            *string
+	CodeExpiresAt       *time.Time
+	TokenSessionID      *int
+	ExpiresAt           time.Time
+	ApprovedAt          *time.Time
+	DeniedAt            *time.Time
+	UsedAt              *time.Time
+	Meta                map[string]interface{}
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	DeletedAt           *time.Time
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+)
+
+// Verification channels a user can prove control of
+const (
+	VerificationChannelEmail = "email"
+	VerificationChannelPhone = "phone"
+)
+
+// OTP use cases stored in sr_auth.otp_tokens.use_case
+const (
+	OTPUseCaseEmailVerification = "email_verification"
+	OTPUseCasePhoneVerification = "phone_verification"
+	// OTPUseCaseTwoFactor rows hold the jti of an mfa_pending token, so failed
+	// second-factor attempts are counted and the token is burned on success
+	OTPUseCaseTwoFactor = "two_factor"
+)
+
+// OTPToken represents a row in sr_auth.otp_tokens
+type OTPToken struct {
+	ID     int
+	UUID   string
+	UserID int
+	// CodeHash is stored in the otp_code column
+	CodeHash string
+	UseCase  string
+	// Destination is the email address or phone number the code was sent to
+	Destination string
+	Attempts    int
+	IPAddress   *string
+	IsUsed      bool
+	ExpiresAt   time.Time
+	UsedAt      *time.Time
+	Meta        map[string]interface{}
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   *time.Time
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+)
+
+// Provider represents a row in sr_auth.providers
+type Provider struct {
+	ID               int
+	UUID             string
+	Name             string
+	DisplayName      string
+	ClientID         string
+	ClientSecret     string
+	AuthorizationURL string
+	TokenURL         string
+	UserinfoURL      *string
+	Scopes           *string
+	RedirectURI      *string
+	IsActive         bool
+	IsEnabled        bool
+	// Config holds provider quirks such as profile field names (see docs/oauth-design-decisions.md)
+	Config    map[string]interface{}
+	Meta      map[string]interface{}
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt *time.Time
+}
+
+// UserProvider represents a row in sr_auth.user_providers
+type UserProvider struct {
+	ID             int
+	UUID           string
+	UserID         int
+	ProviderID     int
+	ProviderUserID string
+	AccessToken    *string
+	RefreshToken   *string
+	TokenType      *string
+	ExpiresAt      *time.Time
+	ProfileData    map[string]interface{}
+	IsPrimary      bool
+	IsActive       bool
+	LastUsedAt     *time.Time
+	Meta           map[string]interface{}
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      *time.Time
+}
+
+// OAuthState represents a row in sr_auth.oauth_states
+type OAuthState struct {
+	ID           int
+	UUID         string
+	ProviderID   int
+	UserID       *int
+	StateHash    string
+	Nonce        string
+	CodeVerifier string
+	RedirectURI  string
+	DeviceID     *string
+	IPAddress    *string
+	UserAgent    *string
+	ExpiresAt    time.Time
+	UsedAt       *time.Time
+	Meta         map[string]interface{}
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    *time.Time
+}
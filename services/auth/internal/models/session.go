@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// Session represents a row in sr_auth.sessions
+type Session struct {
+	ID               int
+	UUID             string
+	UserID           int
+	RefreshTokenHash string
+	DeviceInfo       map[string]interface{}
+	IPAddress        *string
+	UserAgent        *string
+	IsActive         bool
+	ExpiresAt        time.Time
+	LastUsedAt       time.Time
+	RevokedAt        *time.Time
+	Meta             map[string]interface{}
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	DeletedAt        *time.Time
+}
+
+// RefreshTokenHistory represents a row in sr_auth.refresh_token_history: a refresh
+// token hash that has been rotated out of its session
+type RefreshTokenHistory struct {
+	ID        int
+	UUID      string
+	SessionID int
+	TokenHash string
+	RotatedAt time.Time
+	Meta      map[string]interface{}
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt *time.Time
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// AuthMethod mirrors the sr_auth.auth_method enum
+type AuthMethod string
+
+const (
+	AuthMethodPassword AuthMethod = "password"
+	AuthMethodOAuth    AuthMethod = "oauth"
+	AuthMethodBoth     AuthMethod = "both"
+	AuthMethodPasskey  AuthMethod = "passkey"
+)
+
+// User represents a row in sr_auth.users
+type User struct {
+	ID           int
+	UUID         string
+	Email        string
+	Phone        *string
+	PasswordHash string // empty for accounts without a password
+	IsActive     bool
+	// IsVerified is set once any channel is verified; EmailVerifiedAt and
+	// PhoneVerifiedAt record which ones
+	IsVerified        bool
+	EmailVerifiedAt   *time.Time
+	PhoneVerifiedAt   *time.Time
+	AuthMethod        AuthMethod
+	PrimaryProviderID *int
+	// DeactivatedAt is set while the account is deactivated
+	DeactivatedAt *time.Time
+	// PurgeAfter is set on deleted accounts; they can be restored until then
+	PurgeAfter *time.Time
+	Meta       map[string]interface{}
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  *time.Time
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+)
+
+// WebAuthn ceremony names stored in sr_auth.webauthn_challenges.ceremony
+const (
+	CeremonyRegistration   = "registration"
+	CeremonyAuthentication = "authentication"
+)
+
+// WebAuthnCredential represents a row in sr_auth.webauthn_credentials
+type WebAuthnCredential struct {
+	ID                int
+	UUID              string
+	UserID            int
+	DeviceID          *int
+	CredentialID      []byte
+	PublicKey         []byte
+	Algorithm         int64
+	SignCount         uint32
+	AAGUID            *string
+	AttestationFormat string
+	AttestationType   string
+	Name              *string
+	BackupEligible    bool
+	BackupState       bool
+	IsActive          bool
+	CloneDetectedAt   *time.Time
+	LastUsedAt        *time.Time
+	Meta              map[string]interface{}
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	DeletedAt         *time.Time
+}
+
+// WebAuthnChallenge represents a row in sr_auth.webauthn_challenges
+type WebAuthnChallenge struct {
+	ID        int
+	UUID      string
+	UserID    *int
+	Ceremony  string
+	Challenge []byte
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	Meta      map[string]interface{}
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt *time.Time
+}
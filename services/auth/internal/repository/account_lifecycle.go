@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/your-project/services/auth/internal/models"
+)
+
+// purgedUserTables hold per-user rows that are removed when a deleted account
+// is purged. Deleting the user cascades to all of them; anonymizing keeps the
+// user row, so they are deleted explicitly.
+var purgedUserTables = []string{
+	"sessions", "devices", "otp_tokens", "user_providers", "action_tokens", "mfa_totp", "mfa_recovery_codes",
+	"webauthn_credentials", "webauthn_challenges", "oauth_states", "oauth_authorizations", "api_keys", "contact_changes",
+}
+
+// SetUserActive activates or deactivates a non-deleted user, stamping or
+// clearing deactivated_at
+func (r *AuthRepository) SetUserActive(ctx context.Context, userID int, active bool) (*models.User, error) {
+	row := r.conn(ctx).QueryRowContext(ctx, `
+		UPDATE sr_auth.users
+		SET is_active = $2, deactivated_at = CASE WHEN $2 THEN NULL ELSE COALESCE(deactivated_at, get_utc_timestamp()) END
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING `+userColumns,
+		userID, active)
+	return scanUser(row)
+}
+
+// SoftDeleteUser marks the user deleted through soft_delete_record and keeps the
+// account restorable until purgeAfter. The account stops resolving through the
+// GetUser lookups immediately.
+func (r *AuthRepository) SoftDeleteUser(ctx context.Context, userID int, purgeAfter time.Time) (*models.User, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockUser(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE sr_auth.users SET is_active = false, purge_after = $2 WHERE id = $1`, userID, purgeAfter); err != nil {
+		return nil, fmt.Errorf("failed to schedule user purge: %w", err)
+	}
+	var deleted bool
+	if err := tx.QueryRowContext(ctx, `SELECT soft_delete_record('sr_auth.users', $1)`, userID).Scan(&deleted); err != nil {
+		return nil, fmt.Errorf("failed to delete user: %w", err)
+	}
+	if !deleted {
+		return nil, ErrNotFound
+	}
+
+	user, err := scanUser(tx.QueryRowContext(ctx,
+		`SELECT `+userColumns+` FROM sr_auth.users WHERE id = $1`, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit user deletion: %w", err)
+	}
+	return user, nil
+}
+
+// RestoreUser undoes SoftDeleteUser while the grace period lasts. The account
+// comes back active unless it was deactivated before being deleted. It returns
+// ErrNotFound if the user is not deleted or the grace period is over.
+func (r *AuthRepository) RestoreUser(ctx context.Context, userID int) (*models.User, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRowContext(ctx, `
+		SELECT id FROM sr_auth.users
+		WHERE id = $1 AND deleted_at IS NOT NULL AND purge_after > get_utc_timestamp()
+		FOR UPDATE`, userID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock deleted user: %w", err)
+	}
+
+	var restored bool
+	if err := tx.QueryRowContext(ctx, `SELECT restore_deleted_record('sr_auth.users', $1)`, userID).Scan(&restored); err != nil {
+		return nil, fmt.Errorf("failed to restore user: %w", err)
+	}
+	if !restored {
+		return nil, ErrNotFound
+	}
+
+	user, err := scanUser(tx.QueryRowContext(ctx, `
+		UPDATE sr_auth.users SET purge_after = NULL, is_active = (deactivated_at IS NULL)
+		WHERE id = $1
+		RETURNING `+userColumns, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit user restore: %w", err)
+	}
+	return user, nil
+}
+
+// PurgeDeletedUsers removes up to batchSize deleted accounts whose grace period
+// ended before now and returns their UUIDs. Their sessions, devices, codes,
+// provider links and other per-user rows are deleted and their login history
+// loses IP addresses and user agents. With anonymize the user row stays, with
+// its email replaced by a placeholder, so audit entries keep pointing at it;
+// otherwise the row is deleted and audit entries are detached. Rows locked by
+// another purger are skipped.
+func (r *AuthRepository) PurgeDeletedUsers(ctx context.Context, now time.Time, batchSize int, anonymize bool) ([]string, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, uuid FROM sr_auth.users
+		WHERE deleted_at IS NOT NULL AND purge_after <= $1
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED`,
+		now, batchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select users to purge: %w", err)
+	}
+	var (
+		ids   []int64
+		uuids []string
+	)
+	for rows.Next() {
+		var (
+			id   int64
+			uuid string
+		)
+		if err := rows.Scan(&id, &uuid); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan user to purge: %w", err)
+		}
+		ids = append(ids, id)
+		uuids = append(uuids, uuid)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("failed to select users to purge: %w", err)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to select users to purge: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	for _, table := range []string{"login_logs", "login_logs_archive"} {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE sr_auth.`+table+` SET ip_address = NULL, user_agent = NULL, device_info = '{}'
+			WHERE user_id = ANY($1)`, pq.Array(ids)); err != nil {
+			return nil, fmt.Errorf("failed to scrub %s: %w", table, err)
+		}
+	}
+
+	if anonymize {
+		for _, table := range purgedUserTables {
+			if _, err := tx.ExecContext(ctx,
+				`DELETE FROM sr_auth.`+table+` WHERE user_id = ANY($1)`, pq.Array(ids)); err != nil {
+				return nil, fmt.Errorf("failed to purge %s: %w", table, err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE sr_auth.users
+			SET email = 'deleted-' || uuid || '@deleted.invalid', phone = NULL, password_hash = NULL,
+				is_verified = false, email_verified_at = NULL, phone_verified_at = NULL, primary_provider_id = NULL,
+				purge_after = NULL, meta = jsonb_build_object('purged_at', get_utc_timestamp())
+			WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+			return nil, fmt.Errorf("failed to anonymize users: %w", err)
+		}
+	} else {
+		if _, err := tx.ExecContext(ctx,
+			`DELETE FROM sr_auth.users WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+			return nil, fmt.Errorf("failed to delete users: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit user purge: %w", err)
+	}
+	return uuids, nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/your-project/services/auth/internal/models"
+)
+
+// CreateActionToken stores a new token and invalidates the user's other unused
+// tokens for the same purpose, so only the most recent one can be redeemed
+func (r *AuthRepository) CreateActionToken(ctx context.Context, token *models.ActionToken) error {
+	meta, err := encodeJSON(token.Meta)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE sr_auth.action_tokens SET deleted_at = get_utc_timestamp()
+		WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL AND deleted_at IS NULL`,
+		token.UserID, token.Purpose); err != nil {
+		return fmt.Errorf("failed to invalidate action tokens: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO sr_auth.action_tokens (user_id, purpose, token_hash, binding_hash, device_id, ip_address, user_agent, expires_at, meta)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, uuid, created_at, updated_at`,
+		token.UserID, token.Purpose, token.TokenHash, nullableString(token.BindingHash), nullableString(token.DeviceID),
+		nullableString(token.IPAddress), nullableString(token.UserAgent), token.ExpiresAt, meta,
+	).Scan(&token.ID, &token.UUID, &token.CreatedAt, &token.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create action token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit action token: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumeActionToken atomically marks an unexpired, unused token as used and returns it.
+// It returns ErrNotFound for unknown, expired, already used or wrong-purpose tokens.
+func (r *AuthRepository) ConsumeActionToken(ctx context.Context, tokenHash, purpose string) (*models.ActionToken, error) {
+	var (
+		token       models.ActionToken
+		bindingHash sql.NullString
+		deviceID    sql.NullString
+		ipAddress   sql.NullString
+		userAgent   sql.NullString
+		usedAt      sql.NullTime
+		meta        []byte
+	)
+
+	err := r.conn(ctx).QueryRowContext(ctx, `
+		UPDATE sr_auth.action_tokens SET used_at = get_utc_timestamp()
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND deleted_at IS NULL
+			AND expires_at > get_utc_timestamp()
+		RETURNING id, uuid, user_id, purpose, token_hash, binding_hash, device_id, host(ip_address), user_agent,
+			expires_at, used_at, meta, created_at, updated_at`,
+		tokenHash, purpose,
+	).Scan(&token.ID, &token.UUID, &token.UserID, &token.Purpose, &token.TokenHash, &bindingHash, &deviceID,
+		&ipAddress, &userAgent, &token.ExpiresAt, &usedAt, &meta, &token.CreatedAt, &token.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume action token: %w", err)
+	}
+
+	if bindingHash.Valid {
+		token.BindingHash = &bindingHash.String
+	}
+	if deviceID.Valid {
+		token.DeviceID = &deviceID.String
+	}
+	if ipAddress.Valid {
+		token.IPAddress = &ipAddress.String
+	}
+	if userAgent.Valid {
+		token.UserAgent = &userAgent.String
+	}
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+	if token.Meta, err = decodeJSON(meta); err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
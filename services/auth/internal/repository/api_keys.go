@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/your-project/services/auth/internal/models"
+)
+
+const apiKeyColumns = `id, uuid, user_id, oauth_client_id, name, key_prefix, key_hash, scopes, allowed_ips, expires_at,
+	last_used_at, host(last_used_ip), revoked_at, rotated_to_id, is_active, meta, created_at, updated_at, deleted_at`
+
+// CreateAPIKey stores a new key. It returns ErrDuplicate if the prefix is taken.
+func (r *AuthRepository) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	return r.createAPIKey(ctx, r.conn(ctx), key)
+}
+
+// GetAPIKeyByPrefix returns a non-deleted key by its public prefix, including revoked and expired ones
+func (r *AuthRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	row := r.conn(ctx).QueryRowContext(ctx,
+		`SELECT `+apiKeyColumns+` FROM sr_auth.api_keys WHERE key_prefix = $1 AND deleted_at IS NULL`, prefix)
+	return scanAPIKey(row)
+}
+
+// GetOwnedAPIKey returns a non-deleted key by UUID if it belongs to the given
+// user or service account (exactly one of userID and oauthClientID is set)
+func (r *AuthRepository) GetOwnedAPIKey(ctx context.Context, uuid string, userID, oauthClientID *int) (*models.APIKey, error) {
+	row := r.conn(ctx).QueryRowContext(ctx, `
+		SELECT `+apiKeyColumns+` FROM sr_auth.api_keys
+		WHERE uuid = $1 AND user_id IS NOT DISTINCT FROM $2 AND oauth_client_id IS NOT DISTINCT FROM $3
+			AND deleted_at IS NULL`,
+		uuid, userID, oauthClientID)
+	return scanAPIKey(row)
+}
+
+// ListAPIKeys returns the keys of a user or service account, newest first.
+// Revoked keys and keys expired before expiredAfter are left out.
+func (r *AuthRepository) ListAPIKeys(ctx context.Context, userID, oauthClientID *int, expiredAfter time.Time) ([]*models.APIKey, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, `
+		SELECT `+apiKeyColumns+` FROM sr_auth.api_keys
+		WHERE user_id IS NOT DISTINCT FROM $1 AND oauth_client_id IS NOT DISTINCT FROM $2
+			AND revoked_at IS NULL AND expires_at > $3 AND deleted_at IS NULL
+		ORDER BY created_at DESC, id DESC`,
+		userID, oauthClientID, expiredAfter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []*models.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey revokes a key and records the reason in meta. It returns
+// ErrNotFound if the key does not exist or is already revoked.
+func (r *AuthRepository) RevokeAPIKey(ctx context.Context, keyID int, reason string) (*models.APIKey, error) {
+	row := r.conn(ctx).QueryRowContext(ctx, `
+		UPDATE sr_auth.api_keys
+		SET revoked_at = get_utc_timestamp(),
+			meta = COALESCE(meta, '{}'::jsonb) || jsonb_build_object('revoked_reason', $2::text)
+		WHERE id = $1 AND revoked_at IS NULL AND deleted_at IS NULL
+		RETURNING `+apiKeyColumns,
+		keyID, reason)
+	return scanAPIKey(row)
+}
+
+// RotateAPIKey stores replacement and links the old key to it in one
+// transaction. The old key stays valid until retireAt (or its own expiry if
+// sooner). It returns ErrNotFound if the old key was revoked or rotated meanwhile.
+func (r *AuthRepository) RotateAPIKey(ctx context.Context, oldKeyID int, replacement *models.APIKey, retireAt time.Time) (*models.APIKey, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := r.createAPIKey(ctx, tx, replacement); err != nil {
+		return nil, err
+	}
+
+	row := tx.QueryRowContext(ctx, `
+		UPDATE sr_auth.api_keys SET rotated_to_id = $2, expires_at = LEAST(expires_at, $3)
+		WHERE id = $1 AND rotated_to_id IS NULL AND revoked_at IS NULL AND deleted_at IS NULL
+		RETURNING `+apiKeyColumns,
+		oldKeyID, replacement.ID, retireAt)
+	old, err := scanAPIKey(row)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit api key rotation: %w", err)
+	}
+	return old, nil
+}
+
+// TouchAPIKey records use of a key. Writes are skipped while the stored
+// last_used_at is newer than staleBefore, so busy keys do not update on every call.
+func (r *AuthRepository) TouchAPIKey(ctx context.Context, keyID int, ipAddress string, usedAt, staleBefore time.Time) error {
+	_, err := r.conn(ctx).ExecContext(ctx, `
+		UPDATE sr_auth.api_keys SET last_used_at = $2, last_used_ip = $3
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $4)`,
+		keyID, usedAt, nullableString(&ipAddress), staleBefore)
+	if err != nil {
+		return fmt.Errorf("failed to touch api key: %w", err)
+	}
+	return nil
+}
+
+func (r *AuthRepository) createAPIKey(ctx context.Context, q querier, key *models.APIKey) error {
+	meta, err := encodeJSON(key.Meta)
+	if err != nil {
+		return err
+	}
+
+	row := q.QueryRowContext(ctx, `
+		INSERT INTO sr_auth.api_keys (user_id, oauth_client_id, name, key_prefix, key_hash, scopes, allowed_ips,
+			expires_at, meta)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING `+apiKeyColumns,
+		key.UserID, key.OAuthClientID, key.Name, key.KeyPrefix, key.KeyHash, pq.Array(key.Scopes),
+		pq.Array(key.AllowedIPs), key.ExpiresAt, meta)
+	stored, err := scanAPIKey(row)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	*key = *stored
+	return nil
+}
+
+// scanAPIKey reads one api_keys row selected with apiKeyColumns
+func scanAPIKey(row scanner) (*models.APIKey, error) {
+	var (
+		key           models.APIKey
+		userID        sql.NullInt64
+		oauthClientID sql.NullInt64
+		lastUsedAt    sql.NullTime
+		lastUsedIP    sql.NullString
+		revokedAt     sql.NullTime
+		rotatedToID   sql.NullInt64
+		meta          []byte
+		deletedAt     sql.NullTime
+	)
+
+	err := row.Scan(&key.ID, &key.UUID, &userID, &oauthClientID, &key.Name, &key.KeyPrefix, &key.KeyHash,
+		pq.Array(&key.Scopes), pq.Array(&key.AllowedIPs), &key.ExpiresAt, &lastUsedAt, &lastUsedIP, &revokedAt,
+		&rotatedToID, &key.IsActive, &meta, &key.CreatedAt, &key.UpdatedAt, &deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan api key: %w", err)
+	}
+
+	if userID.Valid {
+		id := int(userID.Int64)
+		key.UserID = &id
+	}
+	if oauthClientID.Valid {
+		id := int(oauthClientID.Int64)
+		key.OAuthClientID = &id
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if lastUsedIP.Valid {
+		key.LastUsedIP = &lastUsedIP.String
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	if rotatedToID.Valid {
+		id := int(rotatedToID.Int64)
+		key.RotatedToID = &id
+	}
+	if deletedAt.Valid {
+		key.DeletedAt = &deletedAt.Time
+	}
+	if key.Meta, err = decodeJSON(meta); err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
@@ -2,18 +2,44 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/your-project/pkgs/envelope"
+)
+
+// Repository errors
+var (
+	// ErrNotFound is returned when a requested record does not exist or is soft deleted
+	ErrNotFound = errors.New("record not found")
+	// ErrDuplicate is returned when an insert or update violates a unique constraint
+	ErrDuplicate = errors.New("record already exists")
+	// ErrLastLoginMethod is returned when a change would leave a user without any way to sign in
+	ErrLastLoginMethod = errors.New("user has no other sign-in method")
+	// ErrCodeMismatch is returned when a one-time code is wrong; the attempt has been counted
+	ErrCodeMismatch = errors.New("one-time code does not match")
 )
 
 // AuthRepository handles database operations for authentication
 type AuthRepository struct {
-	db *sql.DB
+	db        *sql.DB
+	encryptor *envelope.Encryptor
+	// tx is set on the copies WithTx hands to its callback
+	tx *txState
 }
 
 // NewAuthRepository creates a new auth repository instance
-func NewAuthRepository(db *sql.DB) *AuthRepository {
-	return &AuthRepository{
+func NewAuthRepository(db *sql.DB, opts ...Option) *AuthRepository {
+	r := &AuthRepository{
 		db: db,
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 // DB returns the database connection (for testing purposes)
@@ -21,10 +47,45 @@ func (r *AuthRepository) DB() *sql.DB {
 	return r.db
 }
 
-// TODO: Implement repository methods for authentication operations
-// Examples:
-// - CreateUser
-// - GetUserByEmail
-// - UpdateUser
-// - DeleteUser
-// - ValidateCredentials
+// scanner is implemented by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// encodeJSON marshals a JSONB column value, defaulting nil maps to '{}'
+func encodeJSON(value map[string]interface{}) ([]byte, error) {
+	if value == nil {
+		return []byte("{}"), nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode json column: %w", err)
+	}
+	return data, nil
+}
+
+// decodeJSON unmarshals a JSONB column value into a map
+func decodeJSON(data []byte) (map[string]interface{}, error) {
+	value := map[string]interface{}{}
+	if len(data) == 0 {
+		return value, nil
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("failed to decode json column: %w", err)
+	}
+	return value, nil
+}
+
+// isUniqueViolation reports whether err is a Postgres unique_violation (23505)
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// nullableString converts an optional string into a value suitable for nullable columns
+func nullableString(value *string) interface{} {
+	if value == nil || *value == "" {
+		return nil
+	}
+	return *value
+}
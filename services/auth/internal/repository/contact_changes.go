@@ -0,0 +1,298 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/your-project/services/auth/internal/models"
+)
+
+const contactChangeColumns = `id, uuid, user_id, channel, old_value, new_value, status, confirm_token_hash,
+	expires_at, confirmed_at, undo_token_hash, undo_expires_at, undone_at, host(ip_address), user_agent,
+	meta, created_at, updated_at, deleted_at`
+
+// CreateContactChange stores a pending change and cancels the user's other
+// pending changes on the same channel, so only the latest link can be confirmed
+func (r *AuthRepository) CreateContactChange(ctx context.Context, change *models.ContactChange) error {
+	meta, err := encodeJSON(change.Meta)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE sr_auth.contact_changes SET status = $3
+		WHERE user_id = $1 AND channel = $2 AND status = $4 AND deleted_at IS NULL`,
+		change.UserID, change.Channel, models.ContactChangeCancelled, models.ContactChangePending); err != nil {
+		return fmt.Errorf("failed to cancel pending contact changes: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO sr_auth.contact_changes (user_id, channel, old_value, new_value, confirm_token_hash, expires_at,
+			ip_address, user_agent, meta)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, uuid, status, created_at, updated_at`,
+		change.UserID, change.Channel, nullableString(change.OldValue), change.NewValue, change.ConfirmTokenHash,
+		change.ExpiresAt, nullableString(change.IPAddress), nullableString(change.UserAgent), meta,
+	).Scan(&change.ID, &change.UUID, &change.Status, &change.CreatedAt, &change.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create contact change: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit contact change: %w", err)
+	}
+	return nil
+}
+
+// IsContactInUse reports whether any account other than exceptUserID holds
+// value on channel. Emails compare case-insensitively. Soft-deleted accounts
+// count, since they still hold the unique column.
+func (r *AuthRepository) IsContactInUse(ctx context.Context, channel, value string, exceptUserID int) (bool, error) {
+	match, err := contactMatch(channel)
+	if err != nil {
+		return false, err
+	}
+
+	var inUse bool
+	err = r.conn(ctx).QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM sr_auth.users WHERE `+match+` AND id <> $2)`, value, exceptUserID).Scan(&inUse)
+	if err != nil {
+		return false, fmt.Errorf("failed to check contact uniqueness: %w", err)
+	}
+	return inUse, nil
+}
+
+// ConfirmContactChange applies the pending change whose confirm token hashes to
+// confirmTokenHash: the new address replaces the old one and counts as verified.
+// undoTokenHash is stored with undoExpiresAt so the old address can revert the
+// change; it is dropped when there was no old address. It returns ErrNotFound for unknown, expired or
+// superseded tokens and ErrDuplicate if another account took the address
+// meanwhile.
+func (r *AuthRepository) ConfirmContactChange(ctx context.Context, confirmTokenHash string, undoTokenHash *string, undoExpiresAt time.Time) (*models.ContactChange, *models.User, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	change, err := scanContactChange(tx.QueryRowContext(ctx, `
+		SELECT `+contactChangeColumns+` FROM sr_auth.contact_changes
+		WHERE confirm_token_hash = $1 AND status = $2 AND expires_at > get_utc_timestamp() AND deleted_at IS NULL
+		FOR UPDATE`,
+		confirmTokenHash, models.ContactChangePending))
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := lockUser(ctx, tx, change.UserID); err != nil {
+		return nil, nil, err
+	}
+	if change.OldValue == nil {
+		undoTokenHash = nil
+	}
+
+	if err := setUserContact(ctx, tx, change.UserID, change.Channel, change.NewValue); err != nil {
+		return nil, nil, err
+	}
+
+	var expires interface{}
+	if undoTokenHash != nil {
+		expires = undoExpiresAt
+	}
+	err = tx.QueryRowContext(ctx, `
+		UPDATE sr_auth.contact_changes
+		SET status = $2, confirmed_at = get_utc_timestamp(), undo_token_hash = $3, undo_expires_at = $4
+		WHERE id = $1
+		RETURNING confirmed_at`,
+		change.ID, models.ContactChangeConfirmed, nullableString(undoTokenHash), expires,
+	).Scan(&change.ConfirmedAt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to confirm contact change: %w", err)
+	}
+	change.Status = models.ContactChangeConfirmed
+	change.UndoTokenHash = undoTokenHash
+	if undoTokenHash != nil {
+		change.UndoExpiresAt = &undoExpiresAt
+	}
+
+	user, err := scanUser(tx.QueryRowContext(ctx,
+		`SELECT `+userColumns+` FROM sr_auth.users WHERE id = $1`, change.UserID))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit contact change: %w", err)
+	}
+	return change, user, nil
+}
+
+// UndoContactChange reverts a confirmed change with the undo token sent to the
+// old address. The old address is restored as verified, and every other pending
+// or confirmed change on the channel is cancelled, so undo links sent to
+// addresses set later stop working. It returns ErrNotFound for unknown, expired
+// or used tokens and ErrDuplicate if the old address now belongs to another
+// account.
+func (r *AuthRepository) UndoContactChange(ctx context.Context, undoTokenHash string) (*models.ContactChange, *models.User, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	change, err := scanContactChange(tx.QueryRowContext(ctx, `
+		SELECT `+contactChangeColumns+` FROM sr_auth.contact_changes
+		WHERE undo_token_hash = $1 AND status = $2 AND undo_expires_at > get_utc_timestamp() AND deleted_at IS NULL
+		FOR UPDATE`,
+		undoTokenHash, models.ContactChangeConfirmed))
+	if err != nil {
+		return nil, nil, err
+	}
+	if change.OldValue == nil {
+		return nil, nil, ErrNotFound
+	}
+	if err := lockUser(ctx, tx, change.UserID); err != nil {
+		return nil, nil, err
+	}
+
+	if err := setUserContact(ctx, tx, change.UserID, change.Channel, *change.OldValue); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.QueryRowContext(ctx, `
+		UPDATE sr_auth.contact_changes SET status = $2, undone_at = get_utc_timestamp()
+		WHERE id = $1
+		RETURNING undone_at`,
+		change.ID, models.ContactChangeUndone).Scan(&change.UndoneAt); err != nil {
+		return nil, nil, fmt.Errorf("failed to undo contact change: %w", err)
+	}
+	change.Status = models.ContactChangeUndone
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE sr_auth.contact_changes SET status = $4
+		WHERE user_id = $1 AND channel = $2 AND id <> $3 AND status IN ($5, $6) AND deleted_at IS NULL`,
+		change.UserID, change.Channel, change.ID, models.ContactChangeCancelled,
+		models.ContactChangePending, models.ContactChangeConfirmed); err != nil {
+		return nil, nil, fmt.Errorf("failed to cancel contact changes: %w", err)
+	}
+
+	user, err := scanUser(tx.QueryRowContext(ctx,
+		`SELECT `+userColumns+` FROM sr_auth.users WHERE id = $1`, change.UserID))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit contact change undo: %w", err)
+	}
+	return change, user, nil
+}
+
+// setUserContact stores value as the user's verified address on channel. The
+// uniqueness check is repeated under the transaction so a clash is reported as
+// ErrDuplicate even for case variants the unique index would let through.
+func setUserContact(ctx context.Context, tx querier, userID int, channel, value string) error {
+	match, err := contactMatch(channel)
+	if err != nil {
+		return err
+	}
+
+	var taken bool
+	if err := tx.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM sr_auth.users WHERE `+match+` AND id <> $2)`, value, userID).Scan(&taken); err != nil {
+		return fmt.Errorf("failed to check contact uniqueness: %w", err)
+	}
+	if taken {
+		return ErrDuplicate
+	}
+
+	column, verifiedColumn := "email", "email_verified_at"
+	if channel == models.VerificationChannelPhone {
+		column, verifiedColumn = "phone", "phone_verified_at"
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE sr_auth.users SET `+column+` = $2, `+verifiedColumn+` = get_utc_timestamp(), is_verified = true
+		WHERE id = $1`, userID, value)
+	if isUniqueViolation(err) {
+		return ErrDuplicate
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %w", column, err)
+	}
+	return nil
+}
+
+// contactMatch returns the users predicate comparing channel's column with $1
+func contactMatch(channel string) (string, error) {
+	switch channel {
+	case models.VerificationChannelEmail:
+		return "LOWER(email) = LOWER($1)", nil
+	case models.VerificationChannelPhone:
+		return "phone = $1", nil
+	default:
+		return "", fmt.Errorf("unknown contact channel %q", channel)
+	}
+}
+
+func scanContactChange(row scanner) (*models.ContactChange, error) {
+	var (
+		change        models.ContactChange
+		oldValue      sql.NullString
+		confirmedAt   sql.NullTime
+		undoTokenHash sql.NullString
+		undoExpiresAt sql.NullTime
+		undoneAt      sql.NullTime
+		ipAddress     sql.NullString
+		userAgent     sql.NullString
+		meta          []byte
+		deletedAt     sql.NullTime
+	)
+
+	err := row.Scan(&change.ID, &change.UUID, &change.UserID, &change.Channel, &oldValue, &change.NewValue,
+		&change.Status, &change.ConfirmTokenHash, &change.ExpiresAt, &confirmedAt, &undoTokenHash, &undoExpiresAt,
+		&undoneAt, &ipAddress, &userAgent, &meta, &change.CreatedAt, &change.UpdatedAt, &deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan contact change: %w", err)
+	}
+
+	if oldValue.Valid {
+		change.OldValue = &oldValue.String
+	}
+	if confirmedAt.Valid {
+		change.ConfirmedAt = &confirmedAt.Time
+	}
+	if undoTokenHash.Valid {
+		change.UndoTokenHash = &undoTokenHash.String
+	}
+	if undoExpiresAt.Valid {
+		change.UndoExpiresAt = &undoExpiresAt.Time
+	}
+	if undoneAt.Valid {
+		change.UndoneAt = &undoneAt.Time
+	}
+	if ipAddress.Valid {
+		change.IPAddress = &ipAddress.String
+	}
+	if userAgent.Valid {
+		change.UserAgent = &userAgent.String
+	}
+	if deletedAt.Valid {
+		change.DeletedAt = &deletedAt.Time
+	}
+	if change.Meta, err = decodeJSON(meta); err != nil {
+		return nil, err
+	}
+
+	return &change, nil
+}
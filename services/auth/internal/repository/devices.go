@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/your-project/services/auth/internal/models"
+)
+
+const deviceColumns = `id, uuid, user_id, device_name, device_type, device_id, browser, os, host(ip_address),
+	is_trusted, is_active, last_used_at, trusted_at, meta, created_at, updated_at, deleted_at`
+
+// UpsertDevice records a sign-in from device.DeviceID, creating the row on first sight.
+// A previously removed device is restored untrusted. It reports whether the device is new
+// to the user, counting restored devices as new. Inside WithTx it runs in a savepoint,
+// since callers treat device tracking as best effort.
+func (r *AuthRepository) UpsertDevice(ctx context.Context, device *models.Device) (bool, error) {
+	var isNew bool
+	err := r.isolate(ctx, func(q querier) error {
+		var err error
+		isNew, err = upsertDevice(ctx, q, device)
+		return err
+	})
+	return isNew, err
+}
+
+// upsertDevice runs UpsertDevice on q
+func upsertDevice(ctx context.Context, q querier, device *models.Device) (bool, error) {
+	meta, err := encodeJSON(device.Meta)
+	if err != nil {
+		return false, err
+	}
+
+	row := q.QueryRowContext(ctx, `
+		INSERT INTO sr_auth.devices (user_id, device_id, device_name, device_type, browser, os, ip_address, last_used_at, meta)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (user_id, device_id) WHERE device_id IS NOT NULL DO NOTHING
+		RETURNING `+deviceColumns,
+		device.UserID, nullableString(device.DeviceID), nullableString(device.DeviceName), nullableString(device.DeviceType),
+		nullableString(device.Browser), nullableString(device.OS), nullableString(device.IPAddress), device.LastUsedAt, meta)
+	stored, err := scanDevice(row)
+	if err == nil {
+		*device = *stored
+		return true, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return false, fmt.Errorf("failed to insert device: %w", err)
+	}
+
+	var restored bool
+	row = q.QueryRowContext(ctx, `
+		WITH previous AS (
+			SELECT id, deleted_at IS NOT NULL AS was_deleted FROM sr_auth.devices
+			WHERE user_id = $1 AND device_id = $2 FOR UPDATE
+		)
+		UPDATE sr_auth.devices d
+		SET device_type = $3, browser = $4, os = $5, ip_address = $6, last_used_at = $7,
+			device_name = CASE WHEN previous.was_deleted THEN $8 ELSE COALESCE(d.device_name, $8) END,
+			is_trusted = CASE WHEN previous.was_deleted THEN false ELSE d.is_trusted END,
+			trusted_at = CASE WHEN previous.was_deleted THEN NULL ELSE d.trusted_at END,
+			is_active = true, deleted_at = NULL
+		FROM previous
+		WHERE d.id = previous.id
+		RETURNING d.id, d.uuid, d.user_id, d.device_name, d.device_type, d.device_id, d.browser, d.os, host(d.ip_address),
+			d.is_trusted, d.is_active, d.last_used_at, d.trusted_at, d.meta, d.created_at, d.updated_at, d.deleted_at,
+			previous.was_deleted`,
+		device.UserID, nullableString(device.DeviceID), nullableString(device.DeviceType), nullableString(device.Browser),
+		nullableString(device.OS), nullableString(device.IPAddress), device.LastUsedAt, nullableString(device.DeviceName))
+	stored, err = scanDevice(row, &restored)
+	if err != nil {
+		return false, fmt.Errorf("failed to update device: %w", err)
+	}
+
+	*device = *stored
+	return restored, nil
+}
+
+// GetDeviceByDeviceID returns one of the user's devices by its client-provided identifier
+func (r *AuthRepository) GetDeviceByDeviceID(ctx context.Context, userID int, deviceID string) (*models.Device, error) {
+	row := r.conn(ctx).QueryRowContext(ctx, `
+		SELECT `+deviceColumns+` FROM sr_auth.devices
+		WHERE user_id = $1 AND device_id = $2 AND deleted_at IS NULL`,
+		userID, deviceID)
+	return scanDevice(row)
+}
+
+// GetDeviceByUUID returns one of the user's devices by UUID
+func (r *AuthRepository) GetDeviceByUUID(ctx context.Context, userID int, uuid string) (*models.Device, error) {
+	row := r.conn(ctx).QueryRowContext(ctx, `
+		SELECT `+deviceColumns+` FROM sr_auth.devices
+		WHERE user_id = $1 AND uuid = $2 AND deleted_at IS NULL`,
+		userID, uuid)
+	return scanDevice(row)
+}
+
+// ListDevices returns the user's devices, most recently used first
+func (r *AuthRepository) ListDevices(ctx context.Context, userID int) ([]*models.Device, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, `
+		SELECT `+deviceColumns+` FROM sr_auth.devices
+		WHERE user_id = $1 AND deleted_at IS NULL
+		ORDER BY last_used_at DESC`,
+		userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list devices: %w", err)
+	}
+	defer rows.Close()
+
+	var devices []*models.Device
+	for rows.Next() {
+		device, err := scanDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list devices: %w", err)
+	}
+
+	return devices, nil
+}
+
+// SetDeviceTrust marks a device trusted from trustedAt, or clears its trust when trustedAt is nil
+func (r *AuthRepository) SetDeviceTrust(ctx context.Context, userID int, uuid string, trustedAt *time.Time) (*models.Device, error) {
+	row := r.conn(ctx).QueryRowContext(ctx, `
+		UPDATE sr_auth.devices SET is_trusted = $3, trusted_at = $4
+		WHERE user_id = $1 AND uuid = $2 AND deleted_at IS NULL
+		RETURNING `+deviceColumns,
+		userID, uuid, trustedAt != nil, trustedAt)
+	return scanDevice(row)
+}
+
+// DeleteDevice soft-deletes one of the user's devices and clears its trust
+func (r *AuthRepository) DeleteDevice(ctx context.Context, userID int, uuid string) error {
+	result, err := r.conn(ctx).ExecContext(ctx, `
+		UPDATE sr_auth.devices
+		SET deleted_at = get_utc_timestamp(), is_active = false, is_trusted = false, trusted_at = NULL
+		WHERE user_id = $1 AND uuid = $2 AND deleted_at IS NULL`,
+		userID, uuid)
+	if err != nil {
+		return fmt.Errorf("failed to delete device: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete device: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// scanDevice reads deviceColumns followed by any extra destinations
+func scanDevice(row scanner, extra ...interface{}) (*models.Device, error) {
+	var (
+		device     models.Device
+		deviceName sql.NullString
+		deviceType sql.NullString
+		deviceID   sql.NullString
+		browser    sql.NullString
+		os         sql.NullString
+		ipAddress  sql.NullString
+		trustedAt  sql.NullTime
+		meta       []byte
+		deletedAt  sql.NullTime
+	)
+
+	dest := []interface{}{&device.ID, &device.UUID, &device.UserID, &deviceName, &deviceType, &deviceID, &browser, &os,
+		&ipAddress, &device.IsTrusted, &device.IsActive, &device.LastUsedAt, &trustedAt, &meta,
+		&device.CreatedAt, &device.UpdatedAt, &deletedAt}
+	err := row.Scan(append(dest, extra...)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan device: %w", err)
+	}
+
+	for _, field := range []struct {
+		src sql.NullString
+		dst **string
+	}{
+		{deviceName, &device.DeviceName}, {deviceType, &device.DeviceType}, {deviceID, &device.DeviceID},
+		{browser, &device.Browser}, {os, &device.OS}, {ipAddress, &device.IPAddress},
+	} {
+		if field.src.Valid {
+			value := field.src.String
+			*field.dst = &value
+		}
+	}
+	if trustedAt.Valid {
+		device.TrustedAt = &trustedAt.Time
+	}
+	if deletedAt.Valid {
+		device.DeletedAt = &deletedAt.Time
+	}
+	if device.Meta, err = decodeJSON(meta); err != nil {
+		return nil, err
+	}
+
+	return &device, nil
+}
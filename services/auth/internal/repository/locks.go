@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+)
+
+// Advisory lock namespaces, the first key of pg_try_advisory_lock(int, int).
+// The second key identifies the locked row or job.
+const (
+	lockNamespaceProviderTokenRefresh int32 = 1001
+	lockNamespaceMaintenance          int32 = 1002
+)
+
+// tryAdvisoryLock takes a session-level advisory lock on a dedicated
+// connection so it can be held across network calls without an open
+// transaction. unlock releases it and returns the connection to the pool.
+func (r *AuthRepository) tryAdvisoryLock(ctx context.Context, namespace, key int32) (func(), bool, error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to acquire connection for advisory lock: %w", err)
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1, $2)`, namespace, key).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, false, fmt.Errorf("failed to take advisory lock: %w", err)
+	}
+	if !locked {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		// The caller's context may already be cancelled; the lock must still be released
+		conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1, $2)`, namespace, key)
+		conn.Close()
+	}
+	return unlock, true, nil
+}
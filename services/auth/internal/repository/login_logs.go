@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/your-project/services/auth/internal/models"
+)
+
+// CreateLoginLog appends an entry to the authentication audit trail
+func (r *AuthRepository) CreateLoginLog(ctx context.Context, entry *models.LoginLog) error {
+	deviceInfo, err := encodeJSON(entry.DeviceInfo)
+	if err != nil {
+		return err
+	}
+	meta, err := encodeJSON(entry.Meta)
+	if err != nil {
+		return err
+	}
+
+	// Audit writes are best effort, so a failed insert must not abort a surrounding WithTx
+	return r.isolate(ctx, func(q querier) error {
+		err := q.QueryRowContext(ctx, `
+			INSERT INTO sr_auth.login_logs (user_id, session_id, event_type, ip_address, user_agent, device_info, success, failure_reason, meta)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			RETURNING id, uuid, created_at, updated_at`,
+			entry.UserID, entry.SessionID, entry.EventType, nullableString(entry.IPAddress), nullableString(entry.UserAgent),
+			deviceInfo, entry.Success, nullableString(entry.FailureReason), meta,
+		).Scan(&entry.ID, &entry.UUID, &entry.CreatedAt, &entry.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create login log: %w", err)
+		}
+		return nil
+	})
+}
+
+// LoginLogCursor is the keyset position of the last row on a page
+type LoginLogCursor struct {
+	CreatedAt time.Time
+	ID        int
+}
+
+// LoginLogFilter selects login_logs rows, newest first. Zero values leave a field unfiltered.
+type LoginLogFilter struct {
+	UserID     *int
+	EventTypes []string
+	Success    *bool
+	// Network is an IP address or CIDR block matched against ip_address
+	Network string
+	From    time.Time
+	To      time.Time
+	After   *LoginLogCursor
+	Limit   int
+}
+
+// ListLoginLogs returns one page of audit entries matching filter
+func (r *AuthRepository) ListLoginLogs(ctx context.Context, filter LoginLogFilter) ([]*models.LoginLog, error) {
+	var (
+		conditions = []string{"l.deleted_at IS NULL"}
+		args       []interface{}
+	)
+	addCondition := func(format string, values ...interface{}) {
+		placeholders := make([]interface{}, len(values))
+		for i, value := range values {
+			args = append(args, value)
+			placeholders[i] = len(args)
+		}
+		conditions = append(conditions, fmt.Sprintf(format, placeholders...))
+	}
+
+	if filter.UserID != nil {
+		addCondition("l.user_id = $%d", *filter.UserID)
+	}
+	if len(filter.EventTypes) > 0 {
+		addCondition("l.event_type = ANY($%d)", pq.Array(filter.EventTypes))
+	}
+	if filter.Success != nil {
+		addCondition("l.success = $%d", *filter.Success)
+	}
+	if filter.Network != "" {
+		addCondition("l.ip_address <<= $%d::inet", filter.Network)
+	}
+	if !filter.From.IsZero() {
+		addCondition("l.created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		addCondition("l.created_at < $%d", filter.To)
+	}
+	if filter.After != nil {
+		addCondition("(l.created_at, l.id) < ($%d, $%d)", filter.After.CreatedAt, filter.After.ID)
+	}
+	args = append(args, filter.Limit)
+
+	rows, err := r.conn(ctx).QueryContext(ctx, `
+		SELECT l.id, l.uuid, l.user_id, u.uuid, l.session_id, l.event_type, host(l.ip_address), l.user_agent,
+			l.device_info, l.success, l.failure_reason, l.meta, l.created_at, l.updated_at
+		FROM sr_auth.login_logs l
+		LEFT JOIN sr_auth.users u ON u.id = l.user_id
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY l.created_at DESC, l.id DESC
+		LIMIT $`+strconv.Itoa(len(args)),
+		args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list login logs: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*models.LoginLog
+	for rows.Next() {
+		var (
+			entry         models.LoginLog
+			userID        sql.NullInt64
+			userUUID      sql.NullString
+			sessionID     sql.NullInt64
+			ipAddress     sql.NullString
+			userAgent     sql.NullString
+			deviceInfo    []byte
+			failureReason sql.NullString
+			meta          []byte
+		)
+		if err := rows.Scan(&entry.ID, &entry.UUID, &userID, &userUUID, &sessionID, &entry.EventType, &ipAddress,
+			&userAgent, &deviceInfo, &entry.Success, &failureReason, &meta, &entry.CreatedAt, &entry.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan login log: %w", err)
+		}
+
+		if userID.Valid {
+			id := int(userID.Int64)
+			entry.UserID = &id
+		}
+		if userUUID.Valid {
+			entry.UserUUID = &userUUID.String
+		}
+		if sessionID.Valid {
+			id := int(sessionID.Int64)
+			entry.SessionID = &id
+		}
+		if ipAddress.Valid {
+			entry.IPAddress = &ipAddress.String
+		}
+		if userAgent.Valid {
+			entry.UserAgent = &userAgent.String
+		}
+		if failureReason.Valid {
+			entry.FailureReason = &failureReason.String
+		}
+		if entry.DeviceInfo, err = decodeJSON(deviceInfo); err != nil {
+			return nil, err
+		}
+		if entry.Meta, err = decodeJSON(meta); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, &entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list login logs: %w", err)
+	}
+
+	return entries, nil
+}
+
+// ArchiveLoginLogs moves up to batchSize rows created before cutoff into
+// login_logs_archive and returns how many were moved. Rows locked by another
+// archiver are skipped, so concurrent runs split the work instead of blocking.
+// The delete and insert are one statement: a row whose id is already archived
+// fails it, so nothing is deleted without being archived.
+func (r *AuthRepository) ArchiveLoginLogs(ctx context.Context, cutoff time.Time, batchSize int) (int64, error) {
+	result, err := r.conn(ctx).ExecContext(ctx, `
+		WITH moved AS (
+			DELETE FROM sr_auth.login_logs
+			WHERE id IN (
+				SELECT id FROM sr_auth.login_logs
+				WHERE created_at < $1
+				ORDER BY id
+				LIMIT $2
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, uuid, user_id, session_id, event_type, ip_address, user_agent, device_info,
+				success, failure_reason, meta, created_at, updated_at, deleted_at
+		)
+		INSERT INTO sr_auth.login_logs_archive (id, uuid, user_id, session_id, event_type, ip_address, user_agent,
+			device_info, success, failure_reason, meta, created_at, updated_at, deleted_at)
+		SELECT id, uuid, user_id, session_id, event_type, ip_address, user_agent, device_info,
+			success, failure_reason, meta, created_at, updated_at, deleted_at
+		FROM moved`,
+		cutoff, batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive login logs: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive login logs: %w", err)
+	}
+	return count, nil
+}
+
+// CountLoginFailures counts the user's failed events of the given types since the given time
+func (r *AuthRepository) CountLoginFailures(ctx context.Context, userID int, eventTypes []string, since time.Time) (int, error) {
+	var count int
+	err := r.conn(ctx).QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM sr_auth.login_logs
+		WHERE user_id = $1 AND success = false AND event_type = ANY($2) AND created_at >= $3 AND deleted_at IS NULL`,
+		userID, pq.Array(eventTypes), since,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count login failures: %w", err)
+	}
+	return count, nil
+}
+
+// ListKnownIPs returns the distinct addresses the user has signed in from since
+// the given time, taken from successful logins and sessions, most recent first
+func (r *AuthRepository) ListKnownIPs(ctx context.Context, userID int, since time.Time, limit int) ([]string, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, `
+		SELECT host(ip_address) FROM (
+			SELECT ip_address, created_at FROM sr_auth.login_logs
+			WHERE user_id = $1 AND event_type = $2 AND success = true AND ip_address IS NOT NULL
+				AND created_at >= $3 AND deleted_at IS NULL
+			UNION ALL
+			SELECT ip_address, created_at FROM sr_auth.sessions
+			WHERE user_id = $1 AND ip_address IS NOT NULL AND created_at >= $3 AND deleted_at IS NULL
+		) seen
+		GROUP BY ip_address
+		ORDER BY MAX(created_at) DESC
+		LIMIT $4`,
+		userID, models.EventLoginSuccess, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list known IPs: %w", err)
+	}
+	defer rows.Close()
+
+	var ips []string
+	for rows.Next() {
+		var ip string
+		if err := rows.Scan(&ip); err != nil {
+			return nil, fmt.Errorf("failed to scan known IP: %w", err)
+		}
+		ips = append(ips, ip)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list known IPs: %w", err)
+	}
+	return ips, nil
+}
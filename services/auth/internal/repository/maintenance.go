@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// MaintenanceTables are the tables PruneExpiredRows cleans up, in the order
+// the maintenance job visits them. user_providers rows are kept; only the
+// sealed provider tokens of dead links are cleared.
+var MaintenanceTables = []string{
+	"otp_tokens",
+	"action_tokens",
+	"webauthn_challenges",
+	"oauth_states",
+	"oauth_authorizations",
+	"contact_changes",
+	"mfa_recovery_codes",
+	"sessions",
+	"user_providers",
+}
+
+// pruneConditions select the rows of each maintenance table that stopped being
+// usable before the cutoff $1. Short-lived tokens are matched on expiry alone,
+// which also covers used and replaced ones, so every condition can use an index
+// (see migration 017). Deleting a session cascades to its refresh token history
+// and the authorizations it approved, and sets login_logs.session_id to NULL:
+// entries written under an impersonation session then no longer lead to the
+// session's impersonated_by, only impersonation_started keeps its UUID in meta.
+// Provider links match once unlinked or deactivated, or once their access
+// token expired, before the cutoff. Active links only lose the expired access
+// token; their refresh token is kept so the refresher can still recover them.
+var pruneConditions = map[string]string{
+	"otp_tokens":           `expires_at < $1`,
+	"action_tokens":        `expires_at < $1`,
+	"webauthn_challenges":  `expires_at < $1`,
+	"oauth_states":         `expires_at < $1`,
+	"oauth_authorizations": `expires_at < $1 AND (code_expires_at IS NULL OR code_expires_at < $1)`,
+	"contact_changes":      `expires_at < $1 AND (undo_expires_at IS NULL OR undo_expires_at < $1)`,
+	"mfa_recovery_codes":   `used_at < $1 OR deleted_at < $1`,
+	"sessions":             `expires_at < $1 OR revoked_at < $1`,
+	"user_providers": `(is_active = false AND updated_at < $1 AND (access_token IS NOT NULL OR refresh_token IS NOT NULL))
+		OR (access_token IS NOT NULL AND expires_at < $1)`,
+}
+
+// pruneActions replace the DELETE for tables whose rows outlive what is pruned
+var pruneActions = map[string]string{
+	"user_providers": `UPDATE sr_auth.user_providers
+		SET access_token = NULL, token_type = NULL, expires_at = NULL,
+			refresh_token = CASE WHEN is_active THEN refresh_token END`,
+}
+
+// TryLockMaintenance takes the advisory lock that lets only one replica run
+// the maintenance job at a time. ok is false if another holds it.
+func (r *AuthRepository) TryLockMaintenance(ctx context.Context) (unlock func(), ok bool, err error) {
+	return r.tryAdvisoryLock(ctx, lockNamespaceMaintenance, 0)
+}
+
+// PruneExpiredRows deletes up to batchSize rows of one of MaintenanceTables that
+// expired, or for sessions and recovery codes were revoked, used or replaced,
+// before cutoff, and returns how many were removed. For user_providers it
+// clears the tokens of up to batchSize dead links, and the expired access
+// tokens of live ones, instead. Each call is its own
+// short statement, and rows locked by a concurrent transaction are skipped
+// rather than waited for.
+func (r *AuthRepository) PruneExpiredRows(ctx context.Context, table string, cutoff time.Time, batchSize int) (int64, error) {
+	condition, ok := pruneConditions[table]
+	if !ok {
+		return 0, fmt.Errorf("table %q is not pruned by maintenance", table)
+	}
+
+	action, ok := pruneActions[table]
+	if !ok {
+		action = `DELETE FROM sr_auth.` + table
+	}
+
+	result, err := r.conn(ctx).ExecContext(ctx, action+`
+		WHERE id IN (
+			SELECT id FROM sr_auth.`+table+`
+			WHERE `+condition+`
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)`,
+		cutoff, batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune %s: %w", table, err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune %s: %w", table, err)
+	}
+	return count, nil
+}
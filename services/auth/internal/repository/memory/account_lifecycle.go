@@ -0,0 +1,154 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// SetUserActive activates or deactivates a non-deleted user, stamping or
+// clearing deactivated_at
+func (s *Store) SetUserActive(ctx context.Context, userID int, active bool) (*models.User, error) {
+	defer s.lock(ctx)()
+
+	user := s.liveUser(userID)
+	if user == nil {
+		return nil, repository.ErrNotFound
+	}
+
+	now := s.now()
+	user.IsActive = active
+	if active {
+		user.DeactivatedAt = nil
+	} else if user.DeactivatedAt == nil {
+		user.DeactivatedAt = timePtr(now)
+	}
+	user.UpdatedAt = now
+	return clone(user), nil
+}
+
+// SoftDeleteUser marks the user deleted and keeps the account restorable until purgeAfter
+func (s *Store) SoftDeleteUser(ctx context.Context, userID int, purgeAfter time.Time) (*models.User, error) {
+	defer s.lock(ctx)()
+
+	user := s.liveUser(userID)
+	if user == nil {
+		return nil, repository.ErrNotFound
+	}
+
+	now := s.now()
+	user.IsActive = false
+	user.PurgeAfter = timePtr(purgeAfter)
+	user.DeletedAt = timePtr(now)
+	user.UpdatedAt = now
+	return clone(user), nil
+}
+
+// RestoreUser undoes SoftDeleteUser while the grace period lasts. The account
+// comes back active unless it was deactivated before being deleted. It returns
+// ErrNotFound if the user is not deleted or the grace period is over.
+func (s *Store) RestoreUser(ctx context.Context, userID int) (*models.User, error) {
+	defer s.lock(ctx)()
+
+	now := s.now()
+	user := s.userByID(userID)
+	if user == nil || user.DeletedAt == nil || user.PurgeAfter == nil || !user.PurgeAfter.After(now) {
+		return nil, repository.ErrNotFound
+	}
+
+	user.DeletedAt = nil
+	user.PurgeAfter = nil
+	user.IsActive = user.DeactivatedAt == nil
+	user.UpdatedAt = now
+	return clone(user), nil
+}
+
+// PurgeDeletedUsers removes up to batchSize deleted accounts whose grace period
+// ended before now and returns their UUIDs, with the same anonymize and delete
+// modes as the SQL repository
+func (s *Store) PurgeDeletedUsers(ctx context.Context, now time.Time, batchSize int, anonymize bool) ([]string, error) {
+	defer s.lock(ctx)()
+
+	due := limit(filter(s.users, func(u *models.User) bool {
+		return u.DeletedAt != nil && u.PurgeAfter != nil && !u.PurgeAfter.After(now)
+	}), batchSize)
+	if len(due) == 0 {
+		return nil, nil
+	}
+
+	purged := map[int]bool{}
+	var uuids []string
+	for _, user := range due {
+		purged[user.ID] = true
+		uuids = append(uuids, user.UUID)
+	}
+
+	for _, logs := range [][]*models.LoginLog{s.loginLogs, s.loginLogsArchive} {
+		for _, entry := range logs {
+			if entry.UserID != nil && purged[*entry.UserID] {
+				entry.IPAddress, entry.UserAgent = nil, nil
+				entry.DeviceInfo = map[string]interface{}{}
+			}
+		}
+	}
+	s.deleteUserRows(purged)
+
+	stamp := s.now()
+	if anonymize {
+		for _, user := range due {
+			user.Email = "deleted-" + user.UUID + "@deleted.invalid"
+			user.Phone, user.PasswordHash = nil, ""
+			user.IsVerified, user.EmailVerifiedAt, user.PhoneVerifiedAt = false, nil, nil
+			user.PrimaryProviderID, user.PurgeAfter = nil, nil
+			user.Meta = map[string]interface{}{"purged_at": stamp.Format(time.RFC3339Nano)}
+			user.UpdatedAt = stamp
+		}
+		return uuids, nil
+	}
+
+	s.users = remove(s.users, func(u *models.User) bool { return purged[u.ID] })
+	for _, logs := range [][]*models.LoginLog{s.loginLogs, s.loginLogsArchive} {
+		for _, entry := range logs {
+			if entry.UserID != nil && purged[*entry.UserID] {
+				entry.UserID = nil
+			}
+		}
+	}
+	return uuids, nil
+}
+
+// deleteUserRows removes the per-user rows listed in purgedUserTables, together
+// with the refresh token history of the removed sessions
+func (s *Store) deleteUserRows(users map[int]bool) {
+	sessions := map[int]bool{}
+	for _, session := range s.sessions {
+		if users[session.UserID] {
+			sessions[session.ID] = true
+		}
+	}
+	s.refreshTokenHistory = remove(s.refreshTokenHistory, func(h *models.RefreshTokenHistory) bool {
+		return sessions[h.SessionID]
+	})
+
+	s.sessions = remove(s.sessions, func(r *models.Session) bool { return users[r.UserID] })
+	s.devices = remove(s.devices, func(r *models.Device) bool { return users[r.UserID] })
+	s.otpTokens = remove(s.otpTokens, func(r *models.OTPToken) bool { return users[r.UserID] })
+	s.userProviders = remove(s.userProviders, func(r *models.UserProvider) bool { return users[r.UserID] })
+	s.actionTokens = remove(s.actionTokens, func(r *models.ActionToken) bool { return users[r.UserID] })
+	s.totpCredentials = remove(s.totpCredentials, func(r *models.TOTPCredential) bool { return users[r.UserID] })
+	s.recoveryCodes = remove(s.recoveryCodes, func(r *models.RecoveryCode) bool { return users[r.UserID] })
+	s.webAuthnCredentials = remove(s.webAuthnCredentials, func(r *models.WebAuthnCredential) bool {
+		return users[r.UserID]
+	})
+	s.webAuthnChallenges = remove(s.webAuthnChallenges, func(r *models.WebAuthnChallenge) bool {
+		return r.UserID != nil && users[*r.UserID]
+	})
+	s.oauthStates = remove(s.oauthStates, func(r *models.OAuthState) bool { return r.UserID != nil && users[*r.UserID] })
+	s.oauthAuthorizations = remove(s.oauthAuthorizations, func(r *models.OAuthAuthorization) bool {
+		return r.UserID != nil && users[*r.UserID]
+	})
+	s.apiKeys = remove(s.apiKeys, func(r *models.APIKey) bool { return r.UserID != nil && users[*r.UserID] })
+	s.contactChanges = remove(s.contactChanges, func(r *models.ContactChange) bool { return users[r.UserID] })
+}
@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// CreateActionToken stores a new token and invalidates the user's other unused
+// tokens for the same purpose
+func (s *Store) CreateActionToken(ctx context.Context, token *models.ActionToken) error {
+	defer s.lock(ctx)()
+
+	meta, err := jsonColumn(token.Meta)
+	if err != nil {
+		return err
+	}
+	if find(s.actionTokens, func(t *models.ActionToken) bool { return t.TokenHash == token.TokenHash }) != nil {
+		return fmt.Errorf("failed to create action token: %w", repository.ErrDuplicate)
+	}
+
+	now := s.now()
+	for _, live := range s.actionTokens {
+		if live.UserID == token.UserID && live.Purpose == token.Purpose && live.UsedAt == nil && live.DeletedAt == nil {
+			live.DeletedAt = timePtr(now)
+			live.UpdatedAt = now
+		}
+	}
+
+	stored := clone(token)
+	stored.ID = s.nextID("action_tokens")
+	stored.UUID = newUUID()
+	stored.BindingHash, stored.DeviceID = optional(token.BindingHash), optional(token.DeviceID)
+	stored.IPAddress, stored.UserAgent = optional(token.IPAddress), optional(token.UserAgent)
+	stored.UsedAt = nil
+	stored.Meta = meta
+	stored.CreatedAt, stored.UpdatedAt, stored.DeletedAt = now, now, nil
+	s.actionTokens = append(s.actionTokens, stored)
+
+	token.ID, token.UUID, token.CreatedAt, token.UpdatedAt = stored.ID, stored.UUID, now, now
+	return nil
+}
+
+// ConsumeActionToken marks an unexpired, unused token as used and returns it.
+// It returns ErrNotFound for unknown, expired, already used or wrong-purpose tokens.
+func (s *Store) ConsumeActionToken(ctx context.Context, tokenHash, purpose string) (*models.ActionToken, error) {
+	defer s.lock(ctx)()
+
+	now := s.now()
+	token := find(s.actionTokens, func(t *models.ActionToken) bool {
+		return t.TokenHash == tokenHash && t.Purpose == purpose && t.UsedAt == nil && t.DeletedAt == nil &&
+			t.ExpiresAt.After(now)
+	})
+	if token == nil {
+		return nil, repository.ErrNotFound
+	}
+
+	token.UsedAt = timePtr(now)
+	token.UpdatedAt = now
+	return clone(token), nil
+}
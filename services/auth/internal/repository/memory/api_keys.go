@@ -0,0 +1,158 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// CreateAPIKey stores a new key. It returns ErrDuplicate if the prefix is taken.
+func (s *Store) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	defer s.lock(ctx)()
+
+	return s.createAPIKey(key)
+}
+
+// GetAPIKeyByPrefix returns a non-deleted key by its public prefix, including revoked and expired ones
+func (s *Store) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	defer s.lock(ctx)()
+
+	return s.getAPIKey(func(k *models.APIKey) bool { return k.KeyPrefix == prefix })
+}
+
+// GetOwnedAPIKey returns a non-deleted key by UUID if it belongs to the given
+// user or service account (exactly one of userID and oauthClientID is set)
+func (s *Store) GetOwnedAPIKey(ctx context.Context, uuid string, userID, oauthClientID *int) (*models.APIKey, error) {
+	defer s.lock(ctx)()
+
+	return s.getAPIKey(func(k *models.APIKey) bool {
+		return k.UUID == uuid && sameID(k.UserID, userID) && sameID(k.OAuthClientID, oauthClientID)
+	})
+}
+
+// ListAPIKeys returns the keys of a user or service account, newest first.
+// Revoked keys and keys expired before expiredAfter are left out.
+func (s *Store) ListAPIKeys(ctx context.Context, userID, oauthClientID *int, expiredAfter time.Time) ([]*models.APIKey, error) {
+	defer s.lock(ctx)()
+
+	keys := filter(s.apiKeys, func(k *models.APIKey) bool {
+		return sameID(k.UserID, userID) && sameID(k.OAuthClientID, oauthClientID) && k.RevokedAt == nil &&
+			k.ExpiresAt.After(expiredAfter) && k.DeletedAt == nil
+	})
+	sortRows(keys, func(a, b *models.APIKey) bool {
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID > b.ID
+	})
+	return cloneAll(keys), nil
+}
+
+// RevokeAPIKey revokes a key and records the reason in meta. It returns
+// ErrNotFound if the key does not exist or is already revoked.
+func (s *Store) RevokeAPIKey(ctx context.Context, keyID int, reason string) (*models.APIKey, error) {
+	defer s.lock(ctx)()
+
+	key := find(s.apiKeys, func(k *models.APIKey) bool {
+		return k.ID == keyID && k.RevokedAt == nil && k.DeletedAt == nil
+	})
+	if key == nil {
+		return nil, repository.ErrNotFound
+	}
+	meta, err := mergeJSON(key.Meta, map[string]interface{}{"revoked_reason": reason})
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	key.RevokedAt = timePtr(now)
+	key.Meta = meta
+	key.UpdatedAt = now
+	return clone(key), nil
+}
+
+// RotateAPIKey stores replacement and links the old key to it. The old key
+// stays valid until retireAt (or its own expiry if sooner). It returns
+// ErrNotFound, without storing replacement, if the old key was revoked or
+// rotated meanwhile.
+func (s *Store) RotateAPIKey(ctx context.Context, oldKeyID int, replacement *models.APIKey, retireAt time.Time) (*models.APIKey, error) {
+	defer s.lock(ctx)()
+
+	old := find(s.apiKeys, func(k *models.APIKey) bool {
+		return k.ID == oldKeyID && k.RotatedToID == nil && k.RevokedAt == nil && k.DeletedAt == nil
+	})
+	if old == nil {
+		return nil, repository.ErrNotFound
+	}
+	if err := s.createAPIKey(replacement); err != nil {
+		return nil, err
+	}
+
+	rotatedToID := replacement.ID
+	old.RotatedToID = &rotatedToID
+	if retireAt.Before(old.ExpiresAt) {
+		old.ExpiresAt = retireAt
+	}
+	old.UpdatedAt = s.now()
+	return clone(old), nil
+}
+
+// TouchAPIKey records use of a key. Writes are skipped while the stored
+// last_used_at is newer than staleBefore, so busy keys do not update on every call.
+func (s *Store) TouchAPIKey(ctx context.Context, keyID int, ipAddress string, usedAt, staleBefore time.Time) error {
+	defer s.lock(ctx)()
+
+	key := find(s.apiKeys, func(k *models.APIKey) bool {
+		return k.ID == keyID && (k.LastUsedAt == nil || k.LastUsedAt.Before(staleBefore))
+	})
+	if key == nil {
+		return nil
+	}
+	key.LastUsedAt = timePtr(usedAt)
+	key.LastUsedIP = optional(&ipAddress)
+	key.UpdatedAt = s.now()
+	return nil
+}
+
+// createAPIKey inserts key and replaces it with the stored row
+func (s *Store) createAPIKey(key *models.APIKey) error {
+	meta, err := jsonColumn(key.Meta)
+	if err != nil {
+		return err
+	}
+	if find(s.apiKeys, func(k *models.APIKey) bool { return k.KeyPrefix == key.KeyPrefix }) != nil {
+		return repository.ErrDuplicate
+	}
+
+	now := s.now()
+	stored := clone(key)
+	stored.ID = s.nextID("api_keys")
+	stored.UUID = newUUID()
+	stored.LastUsedAt, stored.LastUsedIP, stored.RevokedAt, stored.RotatedToID = nil, nil, nil, nil
+	stored.IsActive = true
+	stored.Meta = meta
+	stored.CreatedAt, stored.UpdatedAt, stored.DeletedAt = now, now, nil
+	s.apiKeys = append(s.apiKeys, stored)
+
+	*key = *clone(stored)
+	return nil
+}
+
+// getAPIKey returns a copy of the first non-deleted key matching keep
+func (s *Store) getAPIKey(keep func(*models.APIKey) bool) (*models.APIKey, error) {
+	key := find(s.apiKeys, func(k *models.APIKey) bool { return k.DeletedAt == nil && keep(k) })
+	if key == nil {
+		return nil, repository.ErrNotFound
+	}
+	return clone(key), nil
+}
+
+// sameID compares two nullable IDs like IS NOT DISTINCT FROM
+func sameID(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
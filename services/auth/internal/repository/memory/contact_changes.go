@@ -0,0 +1,170 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// CreateContactChange stores a pending change and cancels the user's other
+// pending changes on the same channel
+func (s *Store) CreateContactChange(ctx context.Context, change *models.ContactChange) error {
+	defer s.lock(ctx)()
+
+	meta, err := jsonColumn(change.Meta)
+	if err != nil {
+		return err
+	}
+	if find(s.contactChanges, func(c *models.ContactChange) bool {
+		return c.ConfirmTokenHash == change.ConfirmTokenHash
+	}) != nil {
+		return fmt.Errorf("failed to create contact change: %w", repository.ErrDuplicate)
+	}
+
+	now := s.now()
+	for _, pending := range s.contactChanges {
+		if pending.UserID == change.UserID && pending.Channel == change.Channel &&
+			pending.Status == models.ContactChangePending && pending.DeletedAt == nil {
+			pending.Status = models.ContactChangeCancelled
+			pending.UpdatedAt = now
+		}
+	}
+
+	stored := clone(change)
+	stored.ID = s.nextID("contact_changes")
+	stored.UUID = newUUID()
+	stored.OldValue = optional(change.OldValue)
+	stored.Status = models.ContactChangePending
+	stored.ConfirmedAt, stored.UndoTokenHash, stored.UndoExpiresAt, stored.UndoneAt = nil, nil, nil, nil
+	stored.IPAddress, stored.UserAgent = optional(change.IPAddress), optional(change.UserAgent)
+	stored.Meta = meta
+	stored.CreatedAt, stored.UpdatedAt, stored.DeletedAt = now, now, nil
+	s.contactChanges = append(s.contactChanges, stored)
+
+	change.ID, change.UUID, change.Status = stored.ID, stored.UUID, stored.Status
+	change.CreatedAt, change.UpdatedAt = now, now
+	return nil
+}
+
+// IsContactInUse reports whether any account other than exceptUserID holds
+// value on channel. Soft-deleted accounts count.
+func (s *Store) IsContactInUse(ctx context.Context, channel, value string, exceptUserID int) (bool, error) {
+	defer s.lock(ctx)()
+
+	if err := checkContactChannel(channel); err != nil {
+		return false, err
+	}
+	return s.contactTaken(channel, value, exceptUserID, true), nil
+}
+
+// ConfirmContactChange applies the pending change whose confirm token hashes
+// to confirmTokenHash and stores the undo token when there was an old address
+func (s *Store) ConfirmContactChange(ctx context.Context, confirmTokenHash string, undoTokenHash *string, undoExpiresAt time.Time) (*models.ContactChange, *models.User, error) {
+	defer s.lock(ctx)()
+
+	now := s.now()
+	change := find(s.contactChanges, func(c *models.ContactChange) bool {
+		return c.ConfirmTokenHash == confirmTokenHash && c.Status == models.ContactChangePending &&
+			c.ExpiresAt.After(now) && c.DeletedAt == nil
+	})
+	if change == nil {
+		return nil, nil, repository.ErrNotFound
+	}
+	user := s.liveUser(change.UserID)
+	if user == nil {
+		return nil, nil, repository.ErrNotFound
+	}
+	if change.OldValue == nil {
+		undoTokenHash = nil
+	}
+	undoTokenHash = optional(undoTokenHash)
+	if undoTokenHash != nil && find(s.contactChanges, func(c *models.ContactChange) bool {
+		return c.UndoTokenHash != nil && *c.UndoTokenHash == *undoTokenHash
+	}) != nil {
+		return nil, nil, fmt.Errorf("failed to confirm contact change: %w", repository.ErrDuplicate)
+	}
+
+	if err := s.setUserContact(user, change.Channel, change.NewValue); err != nil {
+		return nil, nil, err
+	}
+
+	change.Status = models.ContactChangeConfirmed
+	change.ConfirmedAt = timePtr(now)
+	change.UndoTokenHash, change.UndoExpiresAt = undoTokenHash, nil
+	if undoTokenHash != nil {
+		change.UndoExpiresAt = timePtr(undoExpiresAt)
+	}
+	change.UpdatedAt = now
+	return clone(change), clone(user), nil
+}
+
+// UndoContactChange reverts a confirmed change with the undo token sent to the
+// old address and cancels every other pending or confirmed change on the channel
+func (s *Store) UndoContactChange(ctx context.Context, undoTokenHash string) (*models.ContactChange, *models.User, error) {
+	defer s.lock(ctx)()
+
+	now := s.now()
+	change := find(s.contactChanges, func(c *models.ContactChange) bool {
+		return c.UndoTokenHash != nil && *c.UndoTokenHash == undoTokenHash &&
+			c.Status == models.ContactChangeConfirmed && c.UndoExpiresAt != nil && c.UndoExpiresAt.After(now) &&
+			c.DeletedAt == nil
+	})
+	if change == nil || change.OldValue == nil {
+		return nil, nil, repository.ErrNotFound
+	}
+	user := s.liveUser(change.UserID)
+	if user == nil {
+		return nil, nil, repository.ErrNotFound
+	}
+
+	if err := s.setUserContact(user, change.Channel, *change.OldValue); err != nil {
+		return nil, nil, err
+	}
+
+	change.Status = models.ContactChangeUndone
+	change.UndoneAt = timePtr(now)
+	change.UpdatedAt = now
+	for _, other := range s.contactChanges {
+		if other.UserID == change.UserID && other.Channel == change.Channel && other.ID != change.ID &&
+			(other.Status == models.ContactChangePending || other.Status == models.ContactChangeConfirmed) &&
+			other.DeletedAt == nil {
+			other.Status = models.ContactChangeCancelled
+			other.UpdatedAt = now
+		}
+	}
+	return clone(change), clone(user), nil
+}
+
+// setUserContact stores value as the user's verified address on channel,
+// returning ErrDuplicate if another account holds it in any letter case
+func (s *Store) setUserContact(user *models.User, channel, value string) error {
+	if err := checkContactChannel(channel); err != nil {
+		return err
+	}
+	if s.contactTaken(channel, value, user.ID, true) {
+		return repository.ErrDuplicate
+	}
+
+	now := s.now()
+	if channel == models.VerificationChannelEmail {
+		user.Email = value
+		user.EmailVerifiedAt = timePtr(now)
+	} else {
+		user.Phone = &value
+		user.PhoneVerifiedAt = timePtr(now)
+	}
+	user.IsVerified = true
+	user.UpdatedAt = now
+	return nil
+}
+
+// checkContactChannel rejects channels contactMatch does not know
+func checkContactChannel(channel string) error {
+	if channel != models.VerificationChannelEmail && channel != models.VerificationChannelPhone {
+		return fmt.Errorf("unknown contact channel %q", channel)
+	}
+	return nil
+}
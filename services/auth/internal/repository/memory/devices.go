@@ -0,0 +1,147 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// UpsertDevice records a sign-in from device.DeviceID, creating the row on
+// first sight. A previously removed device is restored untrusted. It reports
+// whether the device is new to the user, counting restored devices as new.
+func (s *Store) UpsertDevice(ctx context.Context, device *models.Device) (bool, error) {
+	defer s.lock(ctx)()
+
+	meta, err := jsonColumn(device.Meta)
+	if err != nil {
+		return false, err
+	}
+
+	now := s.now()
+	deviceID := optional(device.DeviceID)
+	var existing *models.Device
+	if deviceID != nil {
+		// idx_devices_user_device covers soft-deleted rows too
+		existing = find(s.devices, func(d *models.Device) bool {
+			return d.UserID == device.UserID && d.DeviceID != nil && *d.DeviceID == *deviceID
+		})
+	}
+
+	if existing == nil {
+		stored := clone(device)
+		stored.ID = s.nextID("devices")
+		stored.UUID = newUUID()
+		stored.DeviceID, stored.DeviceName = deviceID, optional(device.DeviceName)
+		stored.DeviceType, stored.Browser = optional(device.DeviceType), optional(device.Browser)
+		stored.OS, stored.IPAddress = optional(device.OS), optional(device.IPAddress)
+		stored.IsTrusted, stored.IsActive, stored.TrustedAt = false, true, nil
+		stored.Meta = meta
+		stored.CreatedAt, stored.UpdatedAt, stored.DeletedAt = now, now, nil
+		s.devices = append(s.devices, stored)
+
+		*device = *clone(stored)
+		return true, nil
+	}
+
+	restored := existing.DeletedAt != nil
+	existing.DeviceType, existing.Browser = optional(device.DeviceType), optional(device.Browser)
+	existing.OS, existing.IPAddress = optional(device.OS), optional(device.IPAddress)
+	existing.LastUsedAt = device.LastUsedAt
+	if restored || existing.DeviceName == nil {
+		existing.DeviceName = optional(device.DeviceName)
+	}
+	if restored {
+		existing.IsTrusted, existing.TrustedAt = false, nil
+	}
+	existing.IsActive = true
+	existing.DeletedAt = nil
+	existing.UpdatedAt = now
+
+	*device = *clone(existing)
+	return restored, nil
+}
+
+// GetDeviceByDeviceID returns one of the user's devices by its client-provided identifier
+func (s *Store) GetDeviceByDeviceID(ctx context.Context, userID int, deviceID string) (*models.Device, error) {
+	defer s.lock(ctx)()
+
+	device := find(s.devices, func(d *models.Device) bool {
+		return d.UserID == userID && d.DeviceID != nil && *d.DeviceID == deviceID && d.DeletedAt == nil
+	})
+	if device == nil {
+		return nil, repository.ErrNotFound
+	}
+	return clone(device), nil
+}
+
+// GetDeviceByUUID returns one of the user's devices by UUID
+func (s *Store) GetDeviceByUUID(ctx context.Context, userID int, uuid string) (*models.Device, error) {
+	defer s.lock(ctx)()
+
+	device := s.liveDevice(userID, uuid)
+	if device == nil {
+		return nil, repository.ErrNotFound
+	}
+	return clone(device), nil
+}
+
+// GetDeviceIDByUUID resolves one of the user's devices to its primary key
+func (s *Store) GetDeviceIDByUUID(ctx context.Context, userID int, uuid string) (int, error) {
+	defer s.lock(ctx)()
+
+	device := s.liveDevice(userID, uuid)
+	if device == nil {
+		return 0, repository.ErrNotFound
+	}
+	return device.ID, nil
+}
+
+// ListDevices returns the user's devices, most recently used first
+func (s *Store) ListDevices(ctx context.Context, userID int) ([]*models.Device, error) {
+	defer s.lock(ctx)()
+
+	devices := filter(s.devices, func(d *models.Device) bool { return d.UserID == userID && d.DeletedAt == nil })
+	sortRows(devices, func(a, b *models.Device) bool { return a.LastUsedAt.After(b.LastUsedAt) })
+	return cloneAll(devices), nil
+}
+
+// SetDeviceTrust marks a device trusted from trustedAt, or clears its trust when trustedAt is nil
+func (s *Store) SetDeviceTrust(ctx context.Context, userID int, uuid string, trustedAt *time.Time) (*models.Device, error) {
+	defer s.lock(ctx)()
+
+	device := s.liveDevice(userID, uuid)
+	if device == nil {
+		return nil, repository.ErrNotFound
+	}
+	device.IsTrusted = trustedAt != nil
+	device.TrustedAt = nil
+	if trustedAt != nil {
+		device.TrustedAt = timePtr(*trustedAt)
+	}
+	device.UpdatedAt = s.now()
+	return clone(device), nil
+}
+
+// DeleteDevice soft-deletes one of the user's devices and clears its trust
+func (s *Store) DeleteDevice(ctx context.Context, userID int, uuid string) error {
+	defer s.lock(ctx)()
+
+	device := s.liveDevice(userID, uuid)
+	if device == nil {
+		return repository.ErrNotFound
+	}
+	now := s.now()
+	device.DeletedAt = timePtr(now)
+	device.IsActive, device.IsTrusted, device.TrustedAt = false, false, nil
+	device.UpdatedAt = now
+	return nil
+}
+
+// liveDevice returns the user's stored non-deleted device with the given UUID, or nil
+func (s *Store) liveDevice(userID int, uuid string) *models.Device {
+	return find(s.devices, func(d *models.Device) bool {
+		return d.UserID == userID && d.UUID == uuid && d.DeletedAt == nil
+	})
+}
@@ -0,0 +1,215 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// CreateLoginLog appends an entry to the authentication audit trail
+func (s *Store) CreateLoginLog(ctx context.Context, entry *models.LoginLog) error {
+	defer s.lock(ctx)()
+
+	deviceInfo, err := jsonColumn(entry.DeviceInfo)
+	if err != nil {
+		return err
+	}
+	meta, err := jsonColumn(entry.Meta)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	stored := clone(entry)
+	stored.ID = s.nextID("login_logs")
+	stored.UUID = newUUID()
+	stored.IPAddress, stored.UserAgent = optional(entry.IPAddress), optional(entry.UserAgent)
+	stored.FailureReason = optional(entry.FailureReason)
+	stored.DeviceInfo, stored.Meta = deviceInfo, meta
+	stored.CreatedAt, stored.UpdatedAt, stored.DeletedAt = now, now, nil
+	stored.UserUUID = nil
+	s.loginLogs = append(s.loginLogs, stored)
+
+	entry.ID, entry.UUID, entry.CreatedAt, entry.UpdatedAt = stored.ID, stored.UUID, now, now
+	return nil
+}
+
+// ListLoginLogs returns one page of audit entries matching filter, newest first
+func (s *Store) ListLoginLogs(ctx context.Context, f repository.LoginLogFilter) ([]*models.LoginLog, error) {
+	defer s.lock(ctx)()
+
+	var network *net.IPNet
+	if f.Network != "" {
+		var err error
+		if network, err = parseNetwork(f.Network); err != nil {
+			return nil, fmt.Errorf("failed to list login logs: %w", err)
+		}
+	}
+	eventTypes := map[string]bool{}
+	for _, eventType := range f.EventTypes {
+		eventTypes[eventType] = true
+	}
+
+	entries := filter(s.loginLogs, func(l *models.LoginLog) bool {
+		switch {
+		case l.DeletedAt != nil:
+			return false
+		case f.UserID != nil && (l.UserID == nil || *l.UserID != *f.UserID):
+			return false
+		case len(eventTypes) > 0 && !eventTypes[l.EventType]:
+			return false
+		case f.Success != nil && l.Success != *f.Success:
+			return false
+		case network != nil && (l.IPAddress == nil || !network.Contains(net.ParseIP(*l.IPAddress))):
+			return false
+		case !f.From.IsZero() && l.CreatedAt.Before(f.From):
+			return false
+		case !f.To.IsZero() && !l.CreatedAt.Before(f.To):
+			return false
+		case f.After != nil && !newerCursor(f.After, l):
+			return false
+		}
+		return true
+	})
+	sortRows(entries, func(a, b *models.LoginLog) bool {
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID > b.ID
+	})
+
+	page := cloneAll(limit(entries, f.Limit))
+	for _, entry := range page {
+		if entry.UserID == nil {
+			continue
+		}
+		if user := s.userByID(*entry.UserID); user != nil {
+			uuid := user.UUID
+			entry.UserUUID = &uuid
+		}
+	}
+	return page, nil
+}
+
+// ArchiveLoginLogs moves up to batchSize entries created before cutoff into
+// the archive and returns how many were moved. Like the single SQL statement,
+// it moves nothing if one of the entries is already archived.
+func (s *Store) ArchiveLoginLogs(ctx context.Context, cutoff time.Time, batchSize int) (int64, error) {
+	defer s.lock(ctx)()
+
+	entries := limit(filter(s.loginLogs, func(l *models.LoginLog) bool {
+		return l.CreatedAt.Before(cutoff)
+	}), batchSize)
+	moved := map[int]bool{}
+	for _, entry := range entries {
+		if find(s.loginLogsArchive, func(a *models.LoginLog) bool { return a.ID == entry.ID }) != nil {
+			return 0, fmt.Errorf("failed to archive login logs: %w", repository.ErrDuplicate)
+		}
+		moved[entry.ID] = true
+	}
+
+	s.loginLogsArchive = append(s.loginLogsArchive, entries...)
+	s.loginLogs = remove(s.loginLogs, func(l *models.LoginLog) bool { return moved[l.ID] })
+	return int64(len(entries)), nil
+}
+
+// CountLoginFailures counts the user's failed events of the given types since the given time
+func (s *Store) CountLoginFailures(ctx context.Context, userID int, eventTypes []string, since time.Time) (int, error) {
+	defer s.lock(ctx)()
+
+	var count int
+	for _, entry := range s.loginLogs {
+		if entry.UserID != nil && *entry.UserID == userID && !entry.Success && containsString(eventTypes, entry.EventType) &&
+			!entry.CreatedAt.Before(since) && entry.DeletedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// ListKnownIPs returns the distinct addresses the user has signed in from since
+// the given time, taken from successful logins and sessions, most recent first
+func (s *Store) ListKnownIPs(ctx context.Context, userID int, since time.Time, limitTo int) ([]string, error) {
+	defer s.lock(ctx)()
+
+	lastSeen := map[string]time.Time{}
+	seen := func(ip *string, at time.Time) {
+		if ip == nil || at.Before(since) {
+			return
+		}
+		if last, ok := lastSeen[*ip]; !ok || at.After(last) {
+			lastSeen[*ip] = at
+		}
+	}
+	for _, entry := range s.loginLogs {
+		if entry.UserID != nil && *entry.UserID == userID && entry.EventType == models.EventLoginSuccess &&
+			entry.Success && entry.DeletedAt == nil {
+			seen(entry.IPAddress, entry.CreatedAt)
+		}
+	}
+	for _, session := range s.sessions {
+		if session.UserID == userID && session.DeletedAt == nil {
+			seen(session.IPAddress, session.CreatedAt)
+		}
+	}
+
+	var known []string
+	for ip := range lastSeen {
+		known = append(known, ip)
+	}
+	sort.Slice(known, func(i, j int) bool {
+		a, b := lastSeen[known[i]], lastSeen[known[j]]
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return known[i] < known[j]
+	})
+	if limitTo >= 0 && len(known) > limitTo {
+		known = known[:limitTo]
+	}
+	return known, nil
+}
+
+// newerCursor reports whether entry sorts after the cursor, i.e. (created_at, id) < cursor
+func newerCursor(cursor *repository.LoginLogCursor, entry *models.LoginLog) bool {
+	if !entry.CreatedAt.Equal(cursor.CreatedAt) {
+		return entry.CreatedAt.Before(cursor.CreatedAt)
+	}
+	return entry.ID < cursor.ID
+}
+
+// parseNetwork reads an IP address or CIDR block the way the inet cast does
+func parseNetwork(value string) (*net.IPNet, error) {
+	if strings.Contains(value, "/") {
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q", value)
+		}
+		return network, nil
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid network %q", value)
+	}
+	bits := 8 * net.IPv6len
+	if ip.To4() != nil {
+		ip, bits = ip.To4(), 8*net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// containsString reports whether values holds value, like value = ANY(values)
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
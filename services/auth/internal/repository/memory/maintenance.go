@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/your-project/services/auth/internal/models"
+)
+
+// TryLockMaintenance lets only one caller run the maintenance job at a time.
+// ok is false if another caller holds the lock.
+func (s *Store) TryLockMaintenance(ctx context.Context) (unlock func(), ok bool, err error) {
+	s.locksMu.Lock()
+	defer s.locksMu.Unlock()
+
+	if s.maintenanceLocked {
+		return nil, false, nil
+	}
+	s.maintenanceLocked = true
+
+	unlock = func() {
+		s.locksMu.Lock()
+		defer s.locksMu.Unlock()
+		s.maintenanceLocked = false
+	}
+	return unlock, true, nil
+}
+
+// PruneExpiredRows deletes up to batchSize rows of one of MaintenanceTables that
+// expired, or for sessions and recovery codes were revoked, used or replaced,
+// before cutoff, and returns how many were removed. Removed sessions take their
+// refresh token history and the authorizations they approved with them, as the
+// foreign keys do. For user_providers the tokens of dead links, and the expired access
+// tokens of live ones, are cleared.
+func (s *Store) PruneExpiredRows(ctx context.Context, table string, cutoff time.Time, batchSize int) (int64, error) {
+	defer s.lock(ctx)()
+
+	before := func(t *time.Time) bool { return t != nil && t.Before(cutoff) }
+	endedBefore := func(t *time.Time) bool { return t == nil || t.Before(cutoff) }
+
+	var removed int
+	switch table {
+	case "otp_tokens":
+		removed = len(prune(&s.otpTokens, batchSize, func(r *models.OTPToken) bool {
+			return r.ExpiresAt.Before(cutoff)
+		}))
+	case "action_tokens":
+		removed = len(prune(&s.actionTokens, batchSize, func(r *models.ActionToken) bool {
+			return r.ExpiresAt.Before(cutoff)
+		}))
+	case "webauthn_challenges":
+		removed = len(prune(&s.webAuthnChallenges, batchSize, func(r *models.WebAuthnChallenge) bool {
+			return r.ExpiresAt.Before(cutoff)
+		}))
+	case "oauth_states":
+		removed = len(prune(&s.oauthStates, batchSize, func(r *models.OAuthState) bool {
+			return r.ExpiresAt.Before(cutoff)
+		}))
+	case "oauth_authorizations":
+		removed = len(prune(&s.oauthAuthorizations, batchSize, func(r *models.OAuthAuthorization) bool {
+			return r.ExpiresAt.Before(cutoff) && endedBefore(r.CodeExpiresAt)
+		}))
+	case "contact_changes":
+		removed = len(prune(&s.contactChanges, batchSize, func(r *models.ContactChange) bool {
+			return r.ExpiresAt.Before(cutoff) && endedBefore(r.UndoExpiresAt)
+		}))
+	case "mfa_recovery_codes":
+		removed = len(prune(&s.recoveryCodes, batchSize, func(r *models.RecoveryCode) bool {
+			return before(r.UsedAt) || before(r.DeletedAt)
+		}))
+	case "sessions":
+		sessions := prune(&s.sessions, batchSize, func(r *models.Session) bool {
+			return r.ExpiresAt.Before(cutoff) || before(r.RevokedAt)
+		})
+		s.deleteSessionRows(sessions)
+		removed = len(sessions)
+	case "user_providers":
+		links := limit(filter(s.userProviders, func(l *models.UserProvider) bool {
+			hasTokens := l.AccessToken != nil || l.RefreshToken != nil
+			return (!l.IsActive && l.UpdatedAt.Before(cutoff) && hasTokens) || (l.AccessToken != nil && before(l.ExpiresAt))
+		}), batchSize)
+		now := s.now()
+		for _, link := range links {
+			link.AccessToken, link.TokenType, link.ExpiresAt = nil, nil, nil
+			if !link.IsActive {
+				link.RefreshToken = nil
+			}
+			link.UpdatedAt = now
+		}
+		removed = len(links)
+	default:
+		return 0, fmt.Errorf("table %q is not pruned by maintenance", table)
+	}
+	return int64(removed), nil
+}
+
+// deleteSessionRows applies the foreign keys that reference removed sessions,
+// including login_logs.session_id ON DELETE SET NULL
+func (s *Store) deleteSessionRows(sessions []*models.Session) {
+	removed := map[int]bool{}
+	for _, session := range sessions {
+		removed[session.ID] = true
+	}
+	gone := func(id *int) bool { return id != nil && removed[*id] }
+
+	s.refreshTokenHistory = remove(s.refreshTokenHistory, func(h *models.RefreshTokenHistory) bool {
+		return removed[h.SessionID]
+	})
+	s.oauthAuthorizations = remove(s.oauthAuthorizations, func(a *models.OAuthAuthorization) bool {
+		return gone(a.SessionID)
+	})
+	for _, authz := range s.oauthAuthorizations {
+		if gone(authz.TokenSessionID) {
+			authz.TokenSessionID = nil
+		}
+	}
+	for _, entry := range s.loginLogs {
+		if gone(entry.SessionID) {
+			entry.SessionID = nil
+		}
+	}
+}
+
+// prune removes up to n rows matching dead from *rows and returns them
+func prune[T any](rows *[]*T, n int, dead func(*T) bool) []*T {
+	doomed := limit(filter(*rows, dead), n)
+	drop := map[*T]bool{}
+	for _, row := range doomed {
+		drop[row] = true
+	}
+	*rows = remove(*rows, func(row *T) bool { return drop[row] })
+	return doomed
+}
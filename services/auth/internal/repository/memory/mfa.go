@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// GetTOTPCredential returns the user's current (confirmed or pending) TOTP enrollment
+func (s *Store) GetTOTPCredential(ctx context.Context, userID int) (*models.TOTPCredential, error) {
+	defer s.lock(ctx)()
+
+	credential := s.liveTOTPCredential(userID)
+	if credential == nil {
+		return nil, repository.ErrNotFound
+	}
+	return clone(credential), nil
+}
+
+// CreatePendingTOTPCredential replaces any unconfirmed enrollment with a new
+// secret. A confirmed enrollment is left untouched and makes the insert fail,
+// as the unique index on user_id does.
+func (s *Store) CreatePendingTOTPCredential(ctx context.Context, userID int, secret string) (*models.TOTPCredential, error) {
+	defer s.lock(ctx)()
+
+	if confirmed := s.liveTOTPCredential(userID); confirmed != nil && confirmed.ConfirmedAt != nil {
+		return nil, fmt.Errorf("failed to create totp credential: %w", repository.ErrDuplicate)
+	}
+
+	now := s.now()
+	if pending := s.liveTOTPCredential(userID); pending != nil {
+		pending.DeletedAt = timePtr(now)
+		pending.UpdatedAt = now
+	}
+
+	credential := &models.TOTPCredential{
+		ID:        s.nextID("mfa_totp"),
+		UUID:      newUUID(),
+		UserID:    userID,
+		Secret:    secret,
+		Meta:      map[string]interface{}{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	s.totpCredentials = append(s.totpCredentials, credential)
+	return clone(credential), nil
+}
+
+// ConfirmTOTPCredential marks an enrollment confirmed, records the verifying
+// step and replaces the user's recovery codes
+func (s *Store) ConfirmTOTPCredential(ctx context.Context, credentialID int, step int64, recoveryCodeHashes []string) error {
+	defer s.lock(ctx)()
+
+	credential := find(s.totpCredentials, func(c *models.TOTPCredential) bool {
+		return c.ID == credentialID && c.ConfirmedAt == nil && c.DeletedAt == nil
+	})
+	if credential == nil {
+		return repository.ErrNotFound
+	}
+
+	now := s.now()
+	credential.ConfirmedAt = timePtr(now)
+	credential.LastUsedStep = &step
+	credential.UpdatedAt = now
+	s.replaceRecoveryCodes(credential.UserID, recoveryCodeHashes)
+	return nil
+}
+
+// AdvanceTOTPStep records step as used if it is newer than the last accepted
+// step. It returns false when the step was already used.
+func (s *Store) AdvanceTOTPStep(ctx context.Context, credentialID int, step int64) (bool, error) {
+	defer s.lock(ctx)()
+
+	credential := find(s.totpCredentials, func(c *models.TOTPCredential) bool {
+		return c.ID == credentialID && c.DeletedAt == nil && (c.LastUsedStep == nil || *c.LastUsedStep < step)
+	})
+	if credential == nil {
+		return false, nil
+	}
+	credential.LastUsedStep = &step
+	credential.UpdatedAt = s.now()
+	return true, nil
+}
+
+// DeleteTOTPCredential soft deletes the user's enrollment and all recovery codes
+func (s *Store) DeleteTOTPCredential(ctx context.Context, userID int) error {
+	defer s.lock(ctx)()
+
+	now := s.now()
+	for _, credential := range s.totpCredentials {
+		if credential.UserID == userID && credential.DeletedAt == nil {
+			credential.DeletedAt = timePtr(now)
+			credential.UpdatedAt = now
+		}
+	}
+	s.replaceRecoveryCodes(userID, nil)
+	return nil
+}
+
+// ReplaceRecoveryCodes invalidates existing recovery codes and stores the new hashes
+func (s *Store) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	defer s.lock(ctx)()
+
+	s.replaceRecoveryCodes(userID, codeHashes)
+	return nil
+}
+
+// ConsumeRecoveryCode marks a matching unused recovery code as used.
+// It returns false when no unused code matches.
+func (s *Store) ConsumeRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	defer s.lock(ctx)()
+
+	code := find(s.recoveryCodes, func(c *models.RecoveryCode) bool {
+		return c.UserID == userID && c.CodeHash == codeHash && c.UsedAt == nil && c.DeletedAt == nil
+	})
+	if code == nil {
+		return false, nil
+	}
+	now := s.now()
+	code.UsedAt = timePtr(now)
+	code.UpdatedAt = now
+	return true, nil
+}
+
+// CountUnusedRecoveryCodes returns how many recovery codes the user has left
+func (s *Store) CountUnusedRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	defer s.lock(ctx)()
+
+	return len(filter(s.recoveryCodes, func(c *models.RecoveryCode) bool {
+		return c.UserID == userID && c.UsedAt == nil && c.DeletedAt == nil
+	})), nil
+}
+
+// liveTOTPCredential returns the user's stored non-deleted enrollment, or nil
+func (s *Store) liveTOTPCredential(userID int) *models.TOTPCredential {
+	return find(s.totpCredentials, func(c *models.TOTPCredential) bool {
+		return c.UserID == userID && c.DeletedAt == nil
+	})
+}
+
+// replaceRecoveryCodes soft deletes the user's recovery codes and stores codeHashes
+func (s *Store) replaceRecoveryCodes(userID int, codeHashes []string) {
+	now := s.now()
+	for _, code := range s.recoveryCodes {
+		if code.UserID == userID && code.DeletedAt == nil {
+			code.DeletedAt = timePtr(now)
+			code.UpdatedAt = now
+		}
+	}
+	for _, hash := range codeHashes {
+		s.recoveryCodes = append(s.recoveryCodes, &models.RecoveryCode{
+			ID:        s.nextID("mfa_recovery_codes"),
+			UUID:      newUUID(),
+			UserID:    userID,
+			CodeHash:  hash,
+			Meta:      map[string]interface{}{},
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	}
+}
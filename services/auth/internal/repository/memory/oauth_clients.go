@@ -0,0 +1,245 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// GetOAuthClientByClientID returns a non-deleted client by its public client_id, including disabled ones
+func (s *Store) GetOAuthClientByClientID(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	defer s.lock(ctx)()
+
+	return s.getOAuthClient(func(c *models.OAuthClient) bool { return c.ClientID == clientID })
+}
+
+// GetOAuthClientByID returns a non-deleted client by primary key
+func (s *Store) GetOAuthClientByID(ctx context.Context, id int) (*models.OAuthClient, error) {
+	defer s.lock(ctx)()
+
+	return s.getOAuthClient(func(c *models.OAuthClient) bool { return c.ID == id })
+}
+
+// GetOAuthClientByUUID returns a non-deleted client by UUID
+func (s *Store) GetOAuthClientByUUID(ctx context.Context, uuid string) (*models.OAuthClient, error) {
+	defer s.lock(ctx)()
+
+	return s.getOAuthClient(func(c *models.OAuthClient) bool { return c.UUID == uuid })
+}
+
+// ListOAuthClients returns all non-deleted clients ordered by name
+func (s *Store) ListOAuthClients(ctx context.Context) ([]*models.OAuthClient, error) {
+	defer s.lock(ctx)()
+
+	clients := filter(s.oauthClients, func(c *models.OAuthClient) bool { return c.DeletedAt == nil })
+	sortRows(clients, func(a, b *models.OAuthClient) bool {
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ID < b.ID
+	})
+	return cloneAll(clients), nil
+}
+
+// CreateOAuthClient registers a client. It returns ErrDuplicate if the client_id is taken.
+func (s *Store) CreateOAuthClient(ctx context.Context, client *models.OAuthClient) error {
+	defer s.lock(ctx)()
+
+	meta, err := jsonColumn(client.Meta)
+	if err != nil {
+		return err
+	}
+	if find(s.oauthClients, func(c *models.OAuthClient) bool {
+		return c.ClientID == client.ClientID && c.DeletedAt == nil
+	}) != nil {
+		return repository.ErrDuplicate
+	}
+
+	now := s.now()
+	stored := clone(client)
+	stored.ID = s.nextID("oauth_clients")
+	stored.UUID = newUUID()
+	stored.IsActive = true
+	stored.Meta = meta
+	stored.CreatedAt, stored.UpdatedAt, stored.DeletedAt = now, now, nil
+	s.oauthClients = append(s.oauthClients, stored)
+
+	*client = *clone(stored)
+	return nil
+}
+
+// SetOAuthClientActive enables or disables a client by UUID and returns it
+func (s *Store) SetOAuthClientActive(ctx context.Context, uuid string, active bool) (*models.OAuthClient, error) {
+	defer s.lock(ctx)()
+
+	client := find(s.oauthClients, func(c *models.OAuthClient) bool { return c.UUID == uuid && c.DeletedAt == nil })
+	if client == nil {
+		return nil, repository.ErrNotFound
+	}
+	client.IsActive = active
+	client.UpdatedAt = s.now()
+	return clone(client), nil
+}
+
+// SetOAuthClientScopes replaces a client's scopes, which the service only sets
+// at registration, so tests can play an administrator editing the row
+func (s *Store) SetOAuthClientScopes(ctx context.Context, uuid string, scopes []string) error {
+	defer s.lock(ctx)()
+
+	client := find(s.oauthClients, func(c *models.OAuthClient) bool { return c.UUID == uuid && c.DeletedAt == nil })
+	if client == nil {
+		return repository.ErrNotFound
+	}
+	client.Scopes = append([]string(nil), scopes...)
+	client.UpdatedAt = s.now()
+	return nil
+}
+
+// CreateOAuthAuthorization stores an /authorize request waiting for the user's approval
+func (s *Store) CreateOAuthAuthorization(ctx context.Context, authz *models.OAuthAuthorization) error {
+	defer s.lock(ctx)()
+
+	meta, err := jsonColumn(authz.Meta)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	stored := &models.OAuthAuthorization{
+		ID:                  s.nextID("oauth_authorizations"),
+		UUID:                newUUID(),
+		ClientID:            authz.ClientID,
+		RedirectURI:         authz.RedirectURI,
+		Scope:               authz.Scope,
+		State:               optional(authz.State),
+		Nonce:               optional(authz.Nonce),
+		CodeChallenge:       authz.CodeChallenge,
+		CodeChallengeMethod: authz.CodeChallengeMethod,
+		ExpiresAt:           authz.ExpiresAt,
+		Meta:                meta,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+	s.oauthAuthorizations = append(s.oauthAuthorizations, stored)
+
+	authz.ID, authz.UUID, authz.CreatedAt, authz.UpdatedAt = stored.ID, stored.UUID, now, now
+	return nil
+}
+
+// GetPendingOAuthAuthorization returns an unexpired request that has been neither
+// approved nor denied. It returns ErrNotFound otherwise.
+func (s *Store) GetPendingOAuthAuthorization(ctx context.Context, uuid string) (*models.OAuthAuthorization, error) {
+	defer s.lock(ctx)()
+
+	now := s.now()
+	authz := find(s.oauthAuthorizations, func(a *models.OAuthAuthorization) bool {
+		return a.UUID == uuid && pendingAuthorization(a) && a.ExpiresAt.After(now)
+	})
+	if authz == nil {
+		return nil, repository.ErrNotFound
+	}
+	return clone(authz), nil
+}
+
+// ApproveOAuthAuthorization attaches the approving user, session and code to a
+// pending request. It returns ErrNotFound if the request is no longer pending.
+func (s *Store) ApproveOAuthAuthorization(ctx context.Context, id, userID, sessionID int, codeHash string, codeExpiresAt time.Time) error {
+	defer s.lock(ctx)()
+
+	now := s.now()
+	authz := find(s.oauthAuthorizations, func(a *models.OAuthAuthorization) bool {
+		return a.ID == id && pendingAuthorization(a) && a.ExpiresAt.After(now)
+	})
+	if authz == nil {
+		return repository.ErrNotFound
+	}
+	if find(s.oauthAuthorizations, func(a *models.OAuthAuthorization) bool {
+		return a.CodeHash != nil && *a.CodeHash == codeHash
+	}) != nil {
+		return fmt.Errorf("failed to approve oauth authorization: %w", repository.ErrDuplicate)
+	}
+
+	authz.UserID, authz.SessionID = &userID, &sessionID
+	authz.CodeHash, authz.CodeExpiresAt = &codeHash, &codeExpiresAt
+	authz.ApprovedAt = timePtr(now)
+	authz.UpdatedAt = now
+	return nil
+}
+
+// DenyOAuthAuthorization marks a pending request as denied. It returns
+// ErrNotFound if the request is no longer pending.
+func (s *Store) DenyOAuthAuthorization(ctx context.Context, id int) error {
+	defer s.lock(ctx)()
+
+	authz := find(s.oauthAuthorizations, func(a *models.OAuthAuthorization) bool {
+		return a.ID == id && pendingAuthorization(a)
+	})
+	if authz == nil {
+		return repository.ErrNotFound
+	}
+	now := s.now()
+	authz.DeniedAt = timePtr(now)
+	authz.UpdatedAt = now
+	return nil
+}
+
+// ConsumeOAuthAuthorizationCode marks an unexpired, unused code as used and
+// returns its authorization. It returns ErrNotFound for unknown, expired or
+// already used codes.
+func (s *Store) ConsumeOAuthAuthorizationCode(ctx context.Context, codeHash string) (*models.OAuthAuthorization, error) {
+	defer s.lock(ctx)()
+
+	now := s.now()
+	authz := find(s.oauthAuthorizations, func(a *models.OAuthAuthorization) bool {
+		return a.CodeHash != nil && *a.CodeHash == codeHash && a.UsedAt == nil && a.DeletedAt == nil &&
+			a.CodeExpiresAt != nil && a.CodeExpiresAt.After(now)
+	})
+	if authz == nil {
+		return nil, repository.ErrNotFound
+	}
+	authz.UsedAt = timePtr(now)
+	authz.UpdatedAt = now
+	return clone(authz), nil
+}
+
+// GetOAuthAuthorizationByCodeHash returns the authorization a code was issued
+// for, whether or not it has been used
+func (s *Store) GetOAuthAuthorizationByCodeHash(ctx context.Context, codeHash string) (*models.OAuthAuthorization, error) {
+	defer s.lock(ctx)()
+
+	authz := find(s.oauthAuthorizations, func(a *models.OAuthAuthorization) bool {
+		return a.CodeHash != nil && *a.CodeHash == codeHash && a.DeletedAt == nil
+	})
+	if authz == nil {
+		return nil, repository.ErrNotFound
+	}
+	return clone(authz), nil
+}
+
+// SetOAuthAuthorizationTokenSession records the session created by exchanging the code
+func (s *Store) SetOAuthAuthorizationTokenSession(ctx context.Context, id, sessionID int) error {
+	defer s.lock(ctx)()
+
+	if authz := find(s.oauthAuthorizations, func(a *models.OAuthAuthorization) bool { return a.ID == id }); authz != nil {
+		authz.TokenSessionID = &sessionID
+		authz.UpdatedAt = s.now()
+	}
+	return nil
+}
+
+// getOAuthClient returns a copy of the first non-deleted client matching keep
+func (s *Store) getOAuthClient(keep func(*models.OAuthClient) bool) (*models.OAuthClient, error) {
+	client := find(s.oauthClients, func(c *models.OAuthClient) bool { return c.DeletedAt == nil && keep(c) })
+	if client == nil {
+		return nil, repository.ErrNotFound
+	}
+	return clone(client), nil
+}
+
+// pendingAuthorization reports whether a request is live and neither approved nor denied
+func pendingAuthorization(authz *models.OAuthAuthorization) bool {
+	return authz.ApprovedAt == nil && authz.DeniedAt == nil && authz.DeletedAt == nil
+}
@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"context"
+	"crypto/subtle"
+	"time"
+
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// CreateOTP stores a new code and invalidates the user's other live codes for
+// the same use case
+func (s *Store) CreateOTP(ctx context.Context, otp *models.OTPToken) error {
+	defer s.lock(ctx)()
+
+	meta, err := jsonColumn(otp.Meta)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	for _, live := range s.otpTokens {
+		if live.UserID == otp.UserID && live.UseCase == otp.UseCase && !live.IsUsed && live.DeletedAt == nil {
+			live.DeletedAt = timePtr(now)
+			live.UpdatedAt = now
+		}
+	}
+
+	stored := clone(otp)
+	stored.ID = s.nextID("otp_tokens")
+	stored.UUID = newUUID()
+	stored.IPAddress = optional(otp.IPAddress)
+	stored.Attempts, stored.IsUsed, stored.UsedAt = 0, false, nil
+	stored.Meta = meta
+	stored.CreatedAt, stored.UpdatedAt, stored.DeletedAt = now, now, nil
+	s.otpTokens = append(s.otpTokens, stored)
+
+	*otp = *clone(stored)
+	return nil
+}
+
+// OTPSendStats returns how many codes were sent to the user for useCase since
+// the given time and when the latest one was sent (zero if none)
+func (s *Store) OTPSendStats(ctx context.Context, userID int, useCase string, since time.Time) (int, time.Time, error) {
+	defer s.lock(ctx)()
+
+	var (
+		count  int
+		lastAt time.Time
+	)
+	for _, otp := range s.otpTokens {
+		if otp.UserID == userID && otp.UseCase == useCase && otp.CreatedAt.After(since) {
+			count++
+			if otp.CreatedAt.After(lastAt) {
+				lastAt = otp.CreatedAt
+			}
+		}
+	}
+	return count, lastAt, nil
+}
+
+// ConsumeOTP checks codeHash against the user's live code for useCase and marks
+// it used on a match. A wrong code counts an attempt and returns
+// ErrCodeMismatch. It returns ErrNotFound if no code is left to try.
+func (s *Store) ConsumeOTP(ctx context.Context, userID int, useCase, codeHash string, maxAttempts int) (*models.OTPToken, error) {
+	defer s.lock(ctx)()
+
+	now := s.now()
+	var otp *models.OTPToken
+	for _, candidate := range s.otpTokens {
+		if candidate.UserID == userID && candidate.UseCase == useCase && !candidate.IsUsed &&
+			candidate.DeletedAt == nil && candidate.ExpiresAt.After(now) && candidate.Attempts < maxAttempts &&
+			(otp == nil || !candidate.CreatedAt.Before(otp.CreatedAt)) {
+			otp = candidate
+		}
+	}
+	if otp == nil {
+		return nil, repository.ErrNotFound
+	}
+
+	otp.UpdatedAt = now
+	if subtle.ConstantTimeCompare([]byte(otp.CodeHash), []byte(codeHash)) != 1 {
+		otp.Attempts++
+		return nil, repository.ErrCodeMismatch
+	}
+
+	otp.IsUsed = true
+	otp.UsedAt = timePtr(now)
+	return clone(otp), nil
+}
+
+// TakeOTPAttempt counts an attempt against the user's live code for useCase
+// whose hash is codeHash and returns it. It returns ErrNotFound if the code is
+// used, expired, replaced or out of attempts.
+func (s *Store) TakeOTPAttempt(ctx context.Context, userID int, useCase, codeHash string, maxAttempts int) (*models.OTPToken, error) {
+	defer s.lock(ctx)()
+
+	now := s.now()
+	otp := find(s.otpTokens, func(o *models.OTPToken) bool {
+		return o.UserID == userID && o.UseCase == useCase && o.CodeHash == codeHash && !o.IsUsed &&
+			o.DeletedAt == nil && o.ExpiresAt.After(now) && o.Attempts < maxAttempts
+	})
+	if otp == nil {
+		return nil, repository.ErrNotFound
+	}
+
+	otp.Attempts++
+	otp.UpdatedAt = now
+	return clone(otp), nil
+}
+
+// MarkOTPUsed burns a code taken with TakeOTPAttempt. It returns false when
+// the code was already used or replaced.
+func (s *Store) MarkOTPUsed(ctx context.Context, id int) (bool, error) {
+	defer s.lock(ctx)()
+
+	otp := find(s.otpTokens, func(o *models.OTPToken) bool {
+		return o.ID == id && !o.IsUsed && o.DeletedAt == nil
+	})
+	if otp == nil {
+		return false, nil
+	}
+
+	now := s.now()
+	otp.IsUsed = true
+	otp.UsedAt = timePtr(now)
+	otp.UpdatedAt = now
+	return true, nil
+}
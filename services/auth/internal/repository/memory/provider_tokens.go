@@ -0,0 +1,141 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// refreshStateKeys are the user_providers.meta entries kept by the token refresher
+var refreshStateKeys = []string{"refresh_failures", "refresh_retry_at", "refresh_error"}
+
+// ListUserProvidersDueForRefresh returns active links of enabled providers whose
+// access token expires before dueBy and that have a refresh token and are not
+// backing off after a failed refresh, soonest expiry first
+func (s *Store) ListUserProvidersDueForRefresh(ctx context.Context, dueBy, now time.Time, limitTo int) ([]*models.UserProvider, error) {
+	defer s.lock(ctx)()
+
+	var due []*models.UserProvider
+	for _, link := range s.userProviders {
+		if !link.IsActive || link.DeletedAt != nil || link.RefreshToken == nil || link.ExpiresAt == nil ||
+			!link.ExpiresAt.Before(dueBy) {
+			continue
+		}
+		if retryAt, ok := link.Meta["refresh_retry_at"].(string); ok {
+			at, err := time.Parse(time.RFC3339Nano, retryAt)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list user providers due for refresh: %w", err)
+			}
+			if at.After(now) {
+				continue
+			}
+		}
+		if provider := s.liveProvider(link.ProviderID); provider == nil || !provider.IsActive || !provider.IsEnabled {
+			continue
+		}
+		due = append(due, link)
+	}
+
+	sortRows(due, func(a, b *models.UserProvider) bool { return a.ExpiresAt.Before(*b.ExpiresAt) })
+	return cloneAll(limit(due, limitTo)), nil
+}
+
+// GetUserProviderByID returns a non-deleted link by primary key
+func (s *Store) GetUserProviderByID(ctx context.Context, id int) (*models.UserProvider, error) {
+	defer s.lock(ctx)()
+
+	return s.getUserProvider(func(l *models.UserProvider) bool { return l.ID == id })
+}
+
+// TryLockUserProviderRefresh lets only one caller refresh a link's tokens at a
+// time. ok is false if another caller holds the lock.
+func (s *Store) TryLockUserProviderRefresh(ctx context.Context, linkID int) (unlock func(), ok bool, err error) {
+	s.locksMu.Lock()
+	defer s.locksMu.Unlock()
+
+	if s.refreshLocks[linkID] {
+		return nil, false, nil
+	}
+	s.refreshLocks[linkID] = true
+
+	unlock = func() {
+		s.locksMu.Lock()
+		defer s.locksMu.Unlock()
+		delete(s.refreshLocks, linkID)
+	}
+	return unlock, true, nil
+}
+
+// StoreRefreshedUserProviderTokens saves tokens from a refresh and clears the
+// refresher's backoff state. A nil refresh token keeps the stored one.
+func (s *Store) StoreRefreshedUserProviderTokens(ctx context.Context, link *models.UserProvider) error {
+	defer s.lock(ctx)()
+
+	stored := s.liveLink(link.ID)
+	if stored == nil {
+		return repository.ErrNotFound
+	}
+
+	stored.AccessToken = optional(link.AccessToken)
+	if refreshToken := optional(link.RefreshToken); refreshToken != nil {
+		stored.RefreshToken = refreshToken
+	}
+	if tokenType := optional(link.TokenType); tokenType != nil {
+		stored.TokenType = tokenType
+	}
+	stored.ExpiresAt = nil
+	if link.ExpiresAt != nil {
+		stored.ExpiresAt = timePtr(*link.ExpiresAt)
+	}
+	for _, key := range refreshStateKeys {
+		delete(stored.Meta, key)
+	}
+	stored.UpdatedAt = s.now()
+
+	*link = *clone(stored)
+	return nil
+}
+
+// RecordUserProviderRefreshFailure stores the backoff state after a failed refresh
+func (s *Store) RecordUserProviderRefreshFailure(ctx context.Context, linkID, failures int, retryAt time.Time, reason string) error {
+	defer s.lock(ctx)()
+
+	stored := s.liveLink(linkID)
+	if stored == nil {
+		return nil
+	}
+	meta, err := mergeJSON(stored.Meta, map[string]interface{}{
+		"refresh_failures": failures,
+		"refresh_retry_at": retryAt.UTC().Format(time.RFC3339Nano),
+		"refresh_error":    reason,
+	})
+	if err != nil {
+		return err
+	}
+	stored.Meta = meta
+	stored.UpdatedAt = s.now()
+	return nil
+}
+
+// DeactivateUserProvider marks a link inactive after the provider revoked its
+// refresh token and drops the unusable tokens
+func (s *Store) DeactivateUserProvider(ctx context.Context, linkID int, reason string) error {
+	defer s.lock(ctx)()
+
+	stored := s.liveLink(linkID)
+	if stored == nil {
+		return nil
+	}
+	meta, err := mergeJSON(stored.Meta, map[string]interface{}{"deactivated_reason": reason}, refreshStateKeys...)
+	if err != nil {
+		return err
+	}
+	stored.IsActive = false
+	stored.AccessToken, stored.RefreshToken, stored.ExpiresAt = nil, nil, nil
+	stored.Meta = meta
+	stored.UpdatedAt = s.now()
+	return nil
+}
@@ -0,0 +1,188 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// GetProviderByName returns a non-deleted provider by its name ('google', 'github', ...)
+func (s *Store) GetProviderByName(ctx context.Context, name string) (*models.Provider, error) {
+	defer s.lock(ctx)()
+
+	return s.getProvider(func(p *models.Provider) bool { return p.Name == name })
+}
+
+// GetProviderByID returns a non-deleted provider by primary key
+func (s *Store) GetProviderByID(ctx context.Context, id int) (*models.Provider, error) {
+	defer s.lock(ctx)()
+
+	return s.getProvider(func(p *models.Provider) bool { return p.ID == id })
+}
+
+// GetProviderByUUID returns a non-deleted provider by its public ID
+func (s *Store) GetProviderByUUID(ctx context.Context, uuid string) (*models.Provider, error) {
+	defer s.lock(ctx)()
+
+	return s.getProvider(func(p *models.Provider) bool { return p.UUID == uuid })
+}
+
+// ListProviders returns all non-deleted providers ordered by name
+func (s *Store) ListProviders(ctx context.Context) ([]*models.Provider, error) {
+	defer s.lock(ctx)()
+
+	providers := filter(s.providers, func(p *models.Provider) bool { return p.DeletedAt == nil })
+	sortRows(providers, func(a, b *models.Provider) bool { return a.Name < b.Name })
+	return cloneAll(providers), nil
+}
+
+// CreateProvider inserts a provider. It returns ErrDuplicate if the name is
+// taken, by a deleted provider too.
+func (s *Store) CreateProvider(ctx context.Context, provider *models.Provider) error {
+	defer s.lock(ctx)()
+
+	config, err := jsonColumn(provider.Config)
+	if err != nil {
+		return err
+	}
+	meta, err := jsonColumn(provider.Meta)
+	if err != nil {
+		return err
+	}
+	if find(s.providers, func(p *models.Provider) bool { return p.Name == provider.Name }) != nil {
+		return repository.ErrDuplicate
+	}
+
+	now := s.now()
+	stored := clone(provider)
+	stored.ID = s.nextID("providers")
+	stored.UUID = newUUID()
+	stored.UserinfoURL, stored.Scopes = optional(provider.UserinfoURL), optional(provider.Scopes)
+	stored.RedirectURI = optional(provider.RedirectURI)
+	stored.IsActive = true
+	stored.Config, stored.Meta = config, meta
+	stored.CreatedAt, stored.UpdatedAt, stored.DeletedAt = now, now, nil
+	s.providers = append(s.providers, stored)
+
+	*provider = *clone(stored)
+	return nil
+}
+
+// UpdateProvider replaces a provider's settings. The client secret is only
+// replaced when provider.ClientSecret is not empty; name and is_enabled are unchanged.
+func (s *Store) UpdateProvider(ctx context.Context, provider *models.Provider) error {
+	defer s.lock(ctx)()
+
+	config, err := jsonColumn(provider.Config)
+	if err != nil {
+		return err
+	}
+	stored := s.liveProvider(provider.ID)
+	if stored == nil {
+		return repository.ErrNotFound
+	}
+
+	stored.DisplayName, stored.ClientID = provider.DisplayName, provider.ClientID
+	if provider.ClientSecret != "" {
+		stored.ClientSecret = provider.ClientSecret
+	}
+	stored.AuthorizationURL, stored.TokenURL = provider.AuthorizationURL, provider.TokenURL
+	stored.UserinfoURL, stored.Scopes = optional(provider.UserinfoURL), optional(provider.Scopes)
+	stored.RedirectURI = optional(provider.RedirectURI)
+	stored.Config = config
+	stored.UpdatedAt = s.now()
+
+	*provider = *clone(stored)
+	return nil
+}
+
+// SetProviderEnabled enables or disables a provider for sign-in
+func (s *Store) SetProviderEnabled(ctx context.Context, id int, enabled bool) (*models.Provider, error) {
+	defer s.lock(ctx)()
+
+	provider := s.liveProvider(id)
+	if provider == nil {
+		return nil, repository.ErrNotFound
+	}
+	provider.IsEnabled = enabled
+	provider.UpdatedAt = s.now()
+	return clone(provider), nil
+}
+
+// ProvidersVersion returns a fingerprint of the providers that changes
+// whenever a provider is created, updated or deleted
+func (s *Store) ProvidersVersion(ctx context.Context) (string, error) {
+	defer s.lock(ctx)()
+
+	var latest string
+	var newest time.Time
+	for _, provider := range s.providers {
+		if provider.UpdatedAt.After(newest) {
+			newest = provider.UpdatedAt
+			latest = newest.Format(time.RFC3339Nano)
+		}
+	}
+	return strconv.Itoa(len(s.providers)) + ":" + latest, nil
+}
+
+// CreateOAuthState stores authorization request state for a later CompleteOAuth call
+func (s *Store) CreateOAuthState(ctx context.Context, state *models.OAuthState) error {
+	defer s.lock(ctx)()
+
+	meta, err := jsonColumn(state.Meta)
+	if err != nil {
+		return err
+	}
+	if find(s.oauthStates, func(o *models.OAuthState) bool { return o.StateHash == state.StateHash }) != nil {
+		return fmt.Errorf("failed to create oauth state: %w", repository.ErrDuplicate)
+	}
+
+	now := s.now()
+	stored := clone(state)
+	stored.ID = s.nextID("oauth_states")
+	stored.UUID = newUUID()
+	stored.DeviceID, stored.IPAddress = optional(state.DeviceID), optional(state.IPAddress)
+	stored.UserAgent = optional(state.UserAgent)
+	stored.UsedAt = nil
+	stored.Meta = meta
+	stored.CreatedAt, stored.UpdatedAt, stored.DeletedAt = now, now, nil
+	s.oauthStates = append(s.oauthStates, stored)
+
+	state.ID, state.UUID, state.CreatedAt, state.UpdatedAt = stored.ID, stored.UUID, now, now
+	return nil
+}
+
+// ConsumeOAuthState marks an unexpired, unused state as used and returns it.
+// It returns ErrNotFound for unknown, expired or already used states.
+func (s *Store) ConsumeOAuthState(ctx context.Context, stateHash string) (*models.OAuthState, error) {
+	defer s.lock(ctx)()
+
+	now := s.now()
+	state := find(s.oauthStates, func(o *models.OAuthState) bool {
+		return o.StateHash == stateHash && o.UsedAt == nil && o.DeletedAt == nil && o.ExpiresAt.After(now)
+	})
+	if state == nil {
+		return nil, repository.ErrNotFound
+	}
+	state.UsedAt = timePtr(now)
+	state.UpdatedAt = now
+	return clone(state), nil
+}
+
+// getProvider returns a copy of the first non-deleted provider matching keep
+func (s *Store) getProvider(keep func(*models.Provider) bool) (*models.Provider, error) {
+	provider := find(s.providers, func(p *models.Provider) bool { return p.DeletedAt == nil && keep(p) })
+	if provider == nil {
+		return nil, repository.ErrNotFound
+	}
+	return clone(provider), nil
+}
+
+// liveProvider returns the stored non-deleted provider with the given ID, or nil
+func (s *Store) liveProvider(id int) *models.Provider {
+	return find(s.providers, func(p *models.Provider) bool { return p.ID == id && p.DeletedAt == nil })
+}
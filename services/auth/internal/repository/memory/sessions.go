@@ -0,0 +1,192 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// CreateSession inserts a new session and populates its generated fields
+func (s *Store) CreateSession(ctx context.Context, session *models.Session) error {
+	defer s.lock(ctx)()
+
+	deviceInfo, err := jsonColumn(session.DeviceInfo)
+	if err != nil {
+		return err
+	}
+	meta, err := jsonColumn(session.Meta)
+	if err != nil {
+		return err
+	}
+	if s.refreshTokenTaken(session.RefreshTokenHash) {
+		return fmt.Errorf("failed to create session: %w", repository.ErrDuplicate)
+	}
+
+	now := s.now()
+	stored := clone(session)
+	stored.ID = s.nextID("sessions")
+	stored.UUID = newUUID()
+	stored.DeviceInfo, stored.Meta = deviceInfo, meta
+	stored.IPAddress, stored.UserAgent = optional(session.IPAddress), optional(session.UserAgent)
+	stored.IsActive = true
+	stored.LastUsedAt = now
+	stored.RevokedAt = nil
+	stored.CreatedAt, stored.UpdatedAt, stored.DeletedAt = now, now, nil
+	s.sessions = append(s.sessions, stored)
+
+	session.ID, session.UUID, session.IsActive = stored.ID, stored.UUID, stored.IsActive
+	session.LastUsedAt, session.CreatedAt, session.UpdatedAt = now, now, now
+	return nil
+}
+
+// GetSessionByUUID returns a non-deleted session by UUID, including revoked and expired ones
+func (s *Store) GetSessionByUUID(ctx context.Context, uuid string) (*models.Session, error) {
+	defer s.lock(ctx)()
+
+	return s.getSession(func(r *models.Session) bool { return r.UUID == uuid })
+}
+
+// GetSessionByID returns a non-deleted session by primary key
+func (s *Store) GetSessionByID(ctx context.Context, id int) (*models.Session, error) {
+	defer s.lock(ctx)()
+
+	return s.getSession(func(r *models.Session) bool { return r.ID == id })
+}
+
+// GetSessionByRefreshTokenHash returns the session whose current refresh token has the given hash
+func (s *Store) GetSessionByRefreshTokenHash(ctx context.Context, tokenHash string) (*models.Session, error) {
+	defer s.lock(ctx)()
+
+	return s.getSession(func(r *models.Session) bool { return r.RefreshTokenHash == tokenHash })
+}
+
+// RotateRefreshToken swaps a live session's refresh token hash and retires the
+// old one. Of two rotations of the same token only the first succeeds; the
+// other gets ErrNotFound.
+func (s *Store) RotateRefreshToken(ctx context.Context, sessionID int, oldHash, newHash string, usedAt time.Time) error {
+	defer s.lock(ctx)()
+
+	session := find(s.sessions, func(r *models.Session) bool {
+		return r.ID == sessionID && r.RefreshTokenHash == oldHash && r.IsActive && r.RevokedAt == nil &&
+			r.DeletedAt == nil
+	})
+	if session == nil {
+		return repository.ErrNotFound
+	}
+	if s.refreshTokenTaken(newHash) {
+		return fmt.Errorf("failed to rotate refresh token: %w", repository.ErrDuplicate)
+	}
+
+	now := s.now()
+	session.RefreshTokenHash = newHash
+	session.LastUsedAt = usedAt
+	session.UpdatedAt = now
+	s.refreshTokenHistory = append(s.refreshTokenHistory, &models.RefreshTokenHistory{
+		ID:        s.nextID("refresh_token_history"),
+		UUID:      newUUID(),
+		SessionID: sessionID,
+		TokenHash: oldHash,
+		RotatedAt: usedAt,
+		Meta:      map[string]interface{}{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	return nil
+}
+
+// GetRefreshTokenHistory returns the retired refresh token with the given hash
+func (s *Store) GetRefreshTokenHistory(ctx context.Context, tokenHash string) (*models.RefreshTokenHistory, error) {
+	defer s.lock(ctx)()
+
+	entry := find(s.refreshTokenHistory, func(h *models.RefreshTokenHistory) bool {
+		return h.TokenHash == tokenHash && h.DeletedAt == nil
+	})
+	if entry == nil {
+		return nil, repository.ErrNotFound
+	}
+	return clone(entry), nil
+}
+
+// ListActiveSessions returns the user's unrevoked sessions that have neither
+// passed expires_at nor been idle since idleCutoff, most recently used first
+func (s *Store) ListActiveSessions(ctx context.Context, userID int, now, idleCutoff time.Time) ([]*models.Session, error) {
+	defer s.lock(ctx)()
+
+	sessions := filter(s.sessions, func(r *models.Session) bool {
+		return r.UserID == userID && r.IsActive && r.RevokedAt == nil && r.DeletedAt == nil &&
+			r.ExpiresAt.After(now) && r.LastUsedAt.After(idleCutoff)
+	})
+	sortRows(sessions, func(a, b *models.Session) bool { return a.LastUsedAt.After(b.LastUsedAt) })
+	return cloneAll(sessions), nil
+}
+
+// TouchSession records activity on a session unless it was touched after staleBefore
+func (s *Store) TouchSession(ctx context.Context, sessionID int, usedAt, staleBefore time.Time) error {
+	defer s.lock(ctx)()
+
+	if session := find(s.sessions, func(r *models.Session) bool { return r.ID == sessionID }); session != nil &&
+		session.LastUsedAt.Before(staleBefore) {
+		session.LastUsedAt = usedAt
+		session.UpdatedAt = s.now()
+	}
+	return nil
+}
+
+// RevokeSession deactivates one of the user's active sessions and records the
+// reason in meta. It returns ErrNotFound if the session does not exist, belongs
+// to someone else or is already revoked.
+func (s *Store) RevokeSession(ctx context.Context, userID int, sessionUUID, reason string) (*models.Session, error) {
+	defer s.lock(ctx)()
+
+	session := find(s.sessions, func(r *models.Session) bool {
+		return r.UserID == userID && r.UUID == sessionUUID && r.RevokedAt == nil && r.DeletedAt == nil
+	})
+	if session == nil {
+		return nil, repository.ErrNotFound
+	}
+	s.revokeSession(session, reason)
+	return clone(session), nil
+}
+
+// RevokeUserSessions deactivates all of the user's unrevoked sessions except
+// exceptSessionID (0 revokes every session) and returns how many were revoked
+func (s *Store) RevokeUserSessions(ctx context.Context, userID, exceptSessionID int, reason string) (int64, error) {
+	defer s.lock(ctx)()
+
+	var count int64
+	for _, session := range s.sessions {
+		if session.UserID == userID && session.ID != exceptSessionID && session.RevokedAt == nil &&
+			session.DeletedAt == nil {
+			s.revokeSession(session, reason)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// getSession returns a copy of the first non-deleted session matching keep
+func (s *Store) getSession(keep func(*models.Session) bool) (*models.Session, error) {
+	session := find(s.sessions, func(r *models.Session) bool { return r.DeletedAt == nil && keep(r) })
+	if session == nil {
+		return nil, repository.ErrNotFound
+	}
+	return clone(session), nil
+}
+
+// revokeSession stamps revoked_at and adds revoked_reason to meta
+func (s *Store) revokeSession(session *models.Session, reason string) {
+	now := s.now()
+	session.IsActive = false
+	session.RevokedAt = timePtr(now)
+	session.Meta["revoked_reason"] = reason
+	session.UpdatedAt = now
+}
+
+// refreshTokenTaken reports whether a session already holds tokenHash, which
+// the unique index on sessions.refresh_token_hash forbids
+func (s *Store) refreshTokenTaken(tokenHash string) bool {
+	return find(s.sessions, func(r *models.Session) bool { return r.RefreshTokenHash == tokenHash }) != nil
+}
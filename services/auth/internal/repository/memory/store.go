@@ -0,0 +1,300 @@
+// Package memory implements repository.Store in process. It keeps the unique
+// indexes and soft-delete rules of the sr_auth schema, so business logic can be
+// exercised without Postgres. Each method runs under a single lock, which makes
+// it atomic in the way the SQL repository's statements and transactions are.
+// WithTx holds the lock for its whole callback, so transactions are serializable.
+package memory
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+var _ repository.Store = (*Store)(nil)
+
+// Store is a handle on an in-memory database. WithTx hands its callback a
+// second handle on the same database that runs inside the transaction.
+type Store struct {
+	*database
+	// inTx is set on the handle given to WithTx callbacks, which already hold mu
+	inTx bool
+}
+
+// database is the state shared by every handle of a Store
+type database struct {
+	mu    sync.Mutex
+	clock func() time.Time
+	tables
+
+	// refreshLocks and maintenanceLocked stand in for the advisory locks taken by
+	// TryLockUserProviderRefresh and TryLockMaintenance, which are not part of
+	// any transaction
+	locksMu           sync.Mutex
+	refreshLocks      map[int]bool
+	maintenanceLocked bool
+}
+
+// tables holds the sr_auth tables as slices ordered by primary key
+type tables struct {
+	// lastID is the most recent serial value handed out per table
+	lastID map[string]int
+
+	users               []*models.User
+	contactChanges      []*models.ContactChange
+	sessions            []*models.Session
+	refreshTokenHistory []*models.RefreshTokenHistory
+	otpTokens           []*models.OTPToken
+	actionTokens        []*models.ActionToken
+	devices             []*models.Device
+	providers           []*models.Provider
+	oauthStates         []*models.OAuthState
+	userProviders       []*models.UserProvider
+	loginLogs           []*models.LoginLog
+	loginLogsArchive    []*models.LoginLog
+	totpCredentials     []*models.TOTPCredential
+	recoveryCodes       []*models.RecoveryCode
+	webAuthnChallenges  []*models.WebAuthnChallenge
+	webAuthnCredentials []*models.WebAuthnCredential
+	apiKeys             []*models.APIKey
+	oauthClients        []*models.OAuthClient
+	oauthAuthorizations []*models.OAuthAuthorization
+}
+
+// Option configures a Store
+type Option func(*Store)
+
+// WithClock replaces time.Now as the source of get_utc_timestamp(), for tests
+// that move time forward to expire codes, tokens and grace periods
+func WithClock(now func() time.Time) Option {
+	return func(s *Store) {
+		s.clock = now
+	}
+}
+
+// New creates an empty store
+func New(opts ...Option) *Store {
+	s := &Store{database: &database{
+		clock:        time.Now,
+		tables:       tables{lastID: map[string]int{}},
+		refreshLocks: map[int]bool{},
+	}}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// txKey is the context key under which WithTx marks the database it holds locked
+type txKey struct{}
+
+// WithTx runs fn with the store locked and rolls every table back if fn
+// returns an error. Calls on tx, and calls on this store made with the ctx
+// passed to fn, are part of the transaction. A nested WithTx only rolls back
+// its own changes, like a savepoint.
+func (s *Store) WithTx(ctx context.Context, fn func(ctx context.Context, tx repository.Store) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	defer s.lock(ctx)()
+
+	saved := s.tables.snapshot()
+	err := fn(context.WithValue(ctx, txKey{}, s.database), &Store{database: s.database, inTx: true})
+	if err != nil {
+		s.tables = saved
+	}
+	return err
+}
+
+// lock takes the store lock and returns its release, unless the handle or ctx
+// belongs to a WithTx callback that already holds it
+func (s *Store) lock(ctx context.Context) func() {
+	if s.inTx || ctx.Value(txKey{}) == s.database {
+		return func() {}
+	}
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+// snapshot deep-copies the tables so a failed transaction can be undone
+func (t *tables) snapshot() tables {
+	lastID := make(map[string]int, len(t.lastID))
+	for table, id := range t.lastID {
+		lastID[table] = id
+	}
+	return tables{
+		lastID:              lastID,
+		users:               cloneAll(t.users),
+		contactChanges:      cloneAll(t.contactChanges),
+		sessions:            cloneAll(t.sessions),
+		refreshTokenHistory: cloneAll(t.refreshTokenHistory),
+		otpTokens:           cloneAll(t.otpTokens),
+		actionTokens:        cloneAll(t.actionTokens),
+		devices:             cloneAll(t.devices),
+		providers:           cloneAll(t.providers),
+		oauthStates:         cloneAll(t.oauthStates),
+		userProviders:       cloneAll(t.userProviders),
+		loginLogs:           cloneAll(t.loginLogs),
+		loginLogsArchive:    cloneAll(t.loginLogsArchive),
+		totpCredentials:     cloneAll(t.totpCredentials),
+		recoveryCodes:       cloneAll(t.recoveryCodes),
+		webAuthnChallenges:  cloneAll(t.webAuthnChallenges),
+		webAuthnCredentials: cloneAll(t.webAuthnCredentials),
+		apiKeys:             cloneAll(t.apiKeys),
+		oauthClients:        cloneAll(t.oauthClients),
+		oauthAuthorizations: cloneAll(t.oauthAuthorizations),
+	}
+}
+
+// now is the store's get_utc_timestamp()
+func (s *Store) now() time.Time {
+	return s.clock().UTC()
+}
+
+// nextID returns the next serial value for table
+func (s *Store) nextID(table string) int {
+	s.lastID[table]++
+	return s.lastID[table]
+}
+
+// newUUID returns a random version 4 UUID, like generate_uuid()
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("memory: failed to generate uuid: %v", err))
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// jsonColumn normalizes a JSONB value the way a Postgres round trip does: nil
+// becomes an empty object and numbers come back as float64
+func jsonColumn(value map[string]interface{}) (map[string]interface{}, error) {
+	if value == nil {
+		return map[string]interface{}{}, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode json column: %w", err)
+	}
+	decoded := map[string]interface{}{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode json column: %w", err)
+	}
+	return decoded, nil
+}
+
+// mergeJSON returns base with the keys of patch applied and the keys in remove
+// dropped, like (base - remove) || patch
+func mergeJSON(base, patch map[string]interface{}, remove ...string) (map[string]interface{}, error) {
+	merged, err := jsonColumn(base)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range remove {
+		delete(merged, key)
+	}
+	extra, err := jsonColumn(patch)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range extra {
+		merged[key] = value
+	}
+	return merged, nil
+}
+
+// clone deep-copies a row so callers never share memory with the store. Rows
+// only hold JSON columns that went through jsonColumn, so encoding cannot fail.
+func clone[T any](row *T) *T {
+	data, err := json.Marshal(row)
+	if err != nil {
+		panic(fmt.Sprintf("memory: failed to copy %T: %v", row, err))
+	}
+	var copied T
+	if err := json.Unmarshal(data, &copied); err != nil {
+		panic(fmt.Sprintf("memory: failed to copy %T: %v", row, err))
+	}
+	return &copied
+}
+
+// cloneAll copies the rows of a result set
+func cloneAll[T any](rows []*T) []*T {
+	var copied []*T
+	for _, row := range rows {
+		copied = append(copied, clone(row))
+	}
+	return copied
+}
+
+// find returns the first row, in primary key order, matching keep
+func find[T any](rows []*T, keep func(*T) bool) *T {
+	for _, row := range rows {
+		if keep(row) {
+			return row
+		}
+	}
+	return nil
+}
+
+// filter returns the rows matching keep, in primary key order
+func filter[T any](rows []*T, keep func(*T) bool) []*T {
+	var kept []*T
+	for _, row := range rows {
+		if keep(row) {
+			kept = append(kept, row)
+		}
+	}
+	return kept
+}
+
+// remove deletes the rows matching drop and returns the rest
+func remove[T any](rows []*T, drop func(*T) bool) []*T {
+	kept := rows[:0]
+	for _, row := range rows {
+		if !drop(row) {
+			kept = append(kept, row)
+		}
+	}
+	for i := len(kept); i < len(rows); i++ {
+		rows[i] = nil
+	}
+	return kept
+}
+
+// sortRows orders rows by less, keeping primary key order among equal rows
+func sortRows[T any](rows []*T, less func(a, b *T) bool) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		return less(rows[i], rows[j])
+	})
+}
+
+// limit truncates rows to n entries, like LIMIT n
+func limit[T any](rows []*T, n int) []*T {
+	if n >= 0 && len(rows) > n {
+		return rows[:n]
+	}
+	return rows
+}
+
+// optional returns nil for empty strings, like nullableString in the SQL repository
+func optional(value *string) *string {
+	if value == nil || *value == "" {
+		return nil
+	}
+	copied := *value
+	return &copied
+}
+
+// timePtr returns a pointer to a copy of t
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,288 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// GetUserProviderByIdentity returns the link for an account at a provider, active or not
+func (s *Store) GetUserProviderByIdentity(ctx context.Context, providerID int, providerUserID string) (*models.UserProvider, error) {
+	defer s.lock(ctx)()
+
+	return s.getUserProvider(func(l *models.UserProvider) bool {
+		return l.ProviderID == providerID && l.ProviderUserID == providerUserID
+	})
+}
+
+// GetUserProviderByUUID returns one of a user's provider links
+func (s *Store) GetUserProviderByUUID(ctx context.Context, userID int, uuid string) (*models.UserProvider, error) {
+	defer s.lock(ctx)()
+
+	return s.getUserProvider(func(l *models.UserProvider) bool { return l.UserID == userID && l.UUID == uuid })
+}
+
+// ListUserProviders returns a user's provider links, primary first
+func (s *Store) ListUserProviders(ctx context.Context, userID int) ([]*models.UserProvider, error) {
+	defer s.lock(ctx)()
+
+	links := s.liveLinks(userID)
+	sortRows(links, func(a, b *models.UserProvider) bool {
+		if a.IsPrimary != b.IsPrimary {
+			return a.IsPrimary
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+	return cloneAll(links), nil
+}
+
+// RecordUserProviderLogin stores the tokens and profile from a sign-in through
+// the link, reactivating it if it had been marked inactive. link.Meta is merged
+// into the stored meta and the token refresher's backoff state is cleared.
+func (s *Store) RecordUserProviderLogin(ctx context.Context, link *models.UserProvider, usedAt time.Time) error {
+	defer s.lock(ctx)()
+
+	profile, err := jsonColumn(link.ProfileData)
+	if err != nil {
+		return err
+	}
+	stored := s.liveLink(link.ID)
+	if stored == nil {
+		return repository.ErrNotFound
+	}
+	meta, err := mergeJSON(stored.Meta, link.Meta, append([]string{"deactivated_reason"}, refreshStateKeys...)...)
+	if err != nil {
+		return err
+	}
+
+	stored.AccessToken = optional(link.AccessToken)
+	if refreshToken := optional(link.RefreshToken); refreshToken != nil {
+		stored.RefreshToken = refreshToken
+	}
+	stored.TokenType = optional(link.TokenType)
+	stored.ExpiresAt = nil
+	if link.ExpiresAt != nil {
+		stored.ExpiresAt = timePtr(*link.ExpiresAt)
+	}
+	stored.ProfileData = profile
+	stored.LastUsedAt = timePtr(usedAt)
+	stored.IsActive = true
+	stored.Meta = meta
+	stored.UpdatedAt = s.now()
+
+	*link = *clone(stored)
+	return nil
+}
+
+// CreateOAuthUser creates a user without a password together with its first,
+// primary provider link. It returns ErrDuplicate if the email or provider
+// identity is already taken.
+func (s *Store) CreateOAuthUser(ctx context.Context, user *models.User, link *models.UserProvider) error {
+	defer s.lock(ctx)()
+
+	userMeta, err := jsonColumn(user.Meta)
+	if err != nil {
+		return err
+	}
+	storedLink, err := s.newLink(link)
+	if err != nil {
+		return err
+	}
+	if s.contactTaken(models.VerificationChannelEmail, user.Email, 0, false) {
+		return repository.ErrDuplicate
+	}
+
+	now := s.now()
+	primaryProviderID := storedLink.ProviderID
+	stored := &models.User{
+		ID:                s.nextID("users"),
+		UUID:              newUUID(),
+		Email:             user.Email,
+		IsActive:          true,
+		IsVerified:        user.IsVerified,
+		AuthMethod:        models.AuthMethodOAuth,
+		PrimaryProviderID: &primaryProviderID,
+		Meta:              userMeta,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if user.IsVerified {
+		stored.EmailVerifiedAt = timePtr(now)
+	}
+
+	storedLink.UserID = stored.ID
+	storedLink.IsPrimary = true
+	if s.linkTaken(storedLink) {
+		return repository.ErrDuplicate
+	}
+	storedLink.ID = s.nextID("user_providers")
+	s.users = append(s.users, stored)
+	s.userProviders = append(s.userProviders, storedLink)
+
+	*user = *clone(stored)
+	*link = *clone(storedLink)
+	return nil
+}
+
+// LinkUserProvider adds a provider link to an existing user. The first link
+// becomes primary. It returns ErrDuplicate if the provider account is already
+// linked to any user, or the user already has a link to the provider.
+func (s *Store) LinkUserProvider(ctx context.Context, link *models.UserProvider) error {
+	defer s.lock(ctx)()
+
+	user := s.liveUser(link.UserID)
+	if user == nil {
+		return repository.ErrNotFound
+	}
+	stored, err := s.newLink(link)
+	if err != nil {
+		return err
+	}
+	if s.linkTaken(stored) {
+		return repository.ErrDuplicate
+	}
+
+	stored.ID = s.nextID("user_providers")
+	stored.IsPrimary = len(s.liveLinks(user.ID)) == 0
+	s.userProviders = append(s.userProviders, stored)
+	s.syncUserAuthMethod(user)
+	user.UpdatedAt = s.now()
+
+	*link = *clone(stored)
+	return nil
+}
+
+// UnlinkUserProvider soft deletes a provider link. If it was primary, the most
+// recently used remaining link is promoted. It returns ErrLastLoginMethod when
+// the user has no password, passkey or other link to sign in with.
+func (s *Store) UnlinkUserProvider(ctx context.Context, userID int, uuid string) (*models.UserProvider, error) {
+	defer s.lock(ctx)()
+
+	user := s.liveUser(userID)
+	if user == nil {
+		return nil, repository.ErrNotFound
+	}
+	link := find(s.userProviders, func(l *models.UserProvider) bool {
+		return l.UserID == userID && l.UUID == uuid && l.DeletedAt == nil
+	})
+	if link == nil {
+		return nil, repository.ErrNotFound
+	}
+
+	hasOtherMethod := user.PasswordHash != "" ||
+		find(s.webAuthnCredentials, func(c *models.WebAuthnCredential) bool {
+			return c.UserID == userID && c.IsActive && c.DeletedAt == nil
+		}) != nil ||
+		find(s.userProviders, func(l *models.UserProvider) bool {
+			return l.UserID == userID && l.UUID != uuid && l.DeletedAt == nil
+		}) != nil
+	if !hasOtherMethod {
+		return nil, repository.ErrLastLoginMethod
+	}
+
+	now := s.now()
+	link.DeletedAt = timePtr(now)
+	link.IsActive, link.IsPrimary = false, false
+	link.UpdatedAt = now
+
+	remaining := s.liveLinks(userID)
+	if find(remaining, func(l *models.UserProvider) bool { return l.IsPrimary }) == nil && len(remaining) > 0 {
+		sortRows(remaining, func(a, b *models.UserProvider) bool {
+			switch {
+			case a.LastUsedAt == nil || b.LastUsedAt == nil:
+				if (a.LastUsedAt == nil) != (b.LastUsedAt == nil) {
+					return b.LastUsedAt == nil
+				}
+			case !a.LastUsedAt.Equal(*b.LastUsedAt):
+				return a.LastUsedAt.After(*b.LastUsedAt)
+			}
+			return a.CreatedAt.After(b.CreatedAt)
+		})
+		remaining[0].IsPrimary = true
+		remaining[0].UpdatedAt = now
+	}
+
+	s.syncUserAuthMethod(user)
+	user.UpdatedAt = now
+	return clone(link), nil
+}
+
+// SetPrimaryUserProvider makes one of a user's links the primary one
+func (s *Store) SetPrimaryUserProvider(ctx context.Context, userID int, uuid string) (*models.UserProvider, error) {
+	defer s.lock(ctx)()
+
+	user := s.liveUser(userID)
+	if user == nil {
+		return nil, repository.ErrNotFound
+	}
+	link := find(s.userProviders, func(l *models.UserProvider) bool {
+		return l.UserID == userID && l.UUID == uuid && l.DeletedAt == nil
+	})
+	if link == nil {
+		return nil, repository.ErrNotFound
+	}
+
+	now := s.now()
+	for _, other := range s.liveLinks(userID) {
+		if other.IsPrimary != (other.ID == link.ID) {
+			other.IsPrimary = other.ID == link.ID
+			other.UpdatedAt = now
+		}
+	}
+	s.syncUserAuthMethod(user)
+	user.UpdatedAt = now
+	return clone(link), nil
+}
+
+// getUserProvider returns a copy of the first non-deleted link matching keep
+func (s *Store) getUserProvider(keep func(*models.UserProvider) bool) (*models.UserProvider, error) {
+	link := find(s.userProviders, func(l *models.UserProvider) bool { return l.DeletedAt == nil && keep(l) })
+	if link == nil {
+		return nil, repository.ErrNotFound
+	}
+	return clone(link), nil
+}
+
+// liveLink returns the stored non-deleted link with the given ID, or nil
+func (s *Store) liveLink(id int) *models.UserProvider {
+	return find(s.userProviders, func(l *models.UserProvider) bool { return l.ID == id && l.DeletedAt == nil })
+}
+
+// liveLinks returns the user's stored non-deleted links
+func (s *Store) liveLinks(userID int) []*models.UserProvider {
+	return filter(s.userProviders, func(l *models.UserProvider) bool { return l.UserID == userID && l.DeletedAt == nil })
+}
+
+// newLink prepares a user_providers row from link without an ID
+func (s *Store) newLink(link *models.UserProvider) (*models.UserProvider, error) {
+	profile, err := jsonColumn(link.ProfileData)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := jsonColumn(link.Meta)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	stored := clone(link)
+	stored.UUID = newUUID()
+	stored.AccessToken, stored.RefreshToken = optional(link.AccessToken), optional(link.RefreshToken)
+	stored.TokenType = optional(link.TokenType)
+	stored.ProfileData, stored.Meta = profile, meta
+	stored.IsPrimary, stored.IsActive = false, true
+	stored.LastUsedAt = timePtr(now)
+	stored.CreatedAt, stored.UpdatedAt, stored.DeletedAt = now, now, nil
+	return stored, nil
+}
+
+// linkTaken reports whether a live link already uses link's provider identity
+// or ties its user to the same provider, which the partial unique indexes forbid
+func (s *Store) linkTaken(link *models.UserProvider) bool {
+	return find(s.userProviders, func(l *models.UserProvider) bool {
+		return l.DeletedAt == nil && l.ProviderID == link.ProviderID &&
+			(l.ProviderUserID == link.ProviderUserID || l.UserID == link.UserID)
+	}) != nil
+}
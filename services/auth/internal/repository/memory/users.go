@@ -0,0 +1,174 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// AddUser inserts a user, which the service itself only does through
+// CreateOAuthUser, so tests can start from password or passkey accounts. ID,
+// UUID and timestamps are assigned, the account is active unless DeactivatedAt
+// is set, and auth_method is derived from the password hash. It returns
+// repository.ErrDuplicate if the email or phone is taken, soft-deleted
+// accounts included.
+func (s *Store) AddUser(ctx context.Context, user *models.User) error {
+	defer s.lock(ctx)()
+
+	meta, err := jsonColumn(user.Meta)
+	if err != nil {
+		return err
+	}
+	if s.contactTaken(models.VerificationChannelEmail, user.Email, 0, false) ||
+		(user.Phone != nil && s.contactTaken(models.VerificationChannelPhone, *user.Phone, 0, false)) {
+		return repository.ErrDuplicate
+	}
+
+	now := s.now()
+	stored := clone(user)
+	stored.ID = s.nextID("users")
+	stored.UUID = newUUID()
+	stored.Phone = optional(user.Phone)
+	stored.IsActive = user.DeactivatedAt == nil
+	stored.PrimaryProviderID = nil
+	stored.Meta = meta
+	stored.CreatedAt, stored.UpdatedAt, stored.DeletedAt = now, now, nil
+	s.users = append(s.users, stored)
+	s.syncUserAuthMethod(stored)
+
+	*user = *clone(stored)
+	return nil
+}
+
+// GetUserByID returns a non-deleted user by primary key
+func (s *Store) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+	defer s.lock(ctx)()
+
+	return s.getUser(func(u *models.User) bool { return u.ID == id })
+}
+
+// GetUserByUUID returns a non-deleted user by UUID
+func (s *Store) GetUserByUUID(ctx context.Context, uuid string) (*models.User, error) {
+	defer s.lock(ctx)()
+
+	return s.getUser(func(u *models.User) bool { return u.UUID == uuid })
+}
+
+// GetUserByEmail returns a non-deleted user by email (case-insensitive)
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	defer s.lock(ctx)()
+
+	return s.getUser(func(u *models.User) bool { return strings.EqualFold(u.Email, email) })
+}
+
+// MarkUserVerified records that the user proved control of destination on
+// channel and sets is_verified. It returns ErrNotFound if the user's address
+// on that channel is no longer destination.
+func (s *Store) MarkUserVerified(ctx context.Context, userID int, channel, destination string) (*models.User, error) {
+	defer s.lock(ctx)()
+
+	if channel != models.VerificationChannelEmail && channel != models.VerificationChannelPhone {
+		return nil, fmt.Errorf("unknown verification channel %q", channel)
+	}
+	user := s.liveUser(userID)
+	if user == nil || !contactMatches(user, channel, destination) {
+		return nil, repository.ErrNotFound
+	}
+
+	now := s.now()
+	if channel == models.VerificationChannelEmail {
+		user.EmailVerifiedAt = timePtr(now)
+	} else {
+		user.PhoneVerifiedAt = timePtr(now)
+	}
+	user.IsVerified = true
+	user.UpdatedAt = now
+	return clone(user), nil
+}
+
+// SetUserPassword stores a new bcrypt hash and re-derives auth_method
+func (s *Store) SetUserPassword(ctx context.Context, userID int, passwordHash string) (*models.User, error) {
+	defer s.lock(ctx)()
+
+	user := s.liveUser(userID)
+	if user == nil {
+		return nil, repository.ErrNotFound
+	}
+	user.PasswordHash = passwordHash
+	user.UpdatedAt = s.now()
+	s.syncUserAuthMethod(user)
+	return clone(user), nil
+}
+
+// getUser returns a copy of the first non-deleted user matching keep
+func (s *Store) getUser(keep func(*models.User) bool) (*models.User, error) {
+	user := find(s.users, func(u *models.User) bool { return u.DeletedAt == nil && keep(u) })
+	if user == nil {
+		return nil, repository.ErrNotFound
+	}
+	return clone(user), nil
+}
+
+// liveUser returns the stored non-deleted user with the given ID, or nil. It
+// plays the part of lockUser.
+func (s *Store) liveUser(userID int) *models.User {
+	return find(s.users, func(u *models.User) bool { return u.ID == userID && u.DeletedAt == nil })
+}
+
+// userByID returns the stored user with the given ID, deleted or not
+func (s *Store) userByID(userID int) *models.User {
+	return find(s.users, func(u *models.User) bool { return u.ID == userID })
+}
+
+// contactTaken reports whether a user other than exceptUserID holds value on
+// channel. With foldCase emails compare like LOWER(email) = LOWER($1);
+// otherwise they compare like the unique index.
+func (s *Store) contactTaken(channel, value string, exceptUserID int, foldCase bool) bool {
+	return find(s.users, func(u *models.User) bool {
+		if u.ID == exceptUserID {
+			return false
+		}
+		if channel == models.VerificationChannelEmail && !foldCase {
+			return u.Email == value
+		}
+		return contactMatches(u, channel, value)
+	}) != nil
+}
+
+// contactMatches compares the user's address on channel with value the way
+// contactMatch does: emails case-insensitively, phones exactly
+func contactMatches(user *models.User, channel, value string) bool {
+	if channel == models.VerificationChannelEmail {
+		return strings.EqualFold(user.Email, value)
+	}
+	return user.Phone != nil && *user.Phone == value
+}
+
+// syncUserAuthMethod derives auth_method and primary_provider_id from the
+// password hash and the user's remaining provider links
+func (s *Store) syncUserAuthMethod(user *models.User) {
+	links := filter(s.userProviders, func(l *models.UserProvider) bool {
+		return l.UserID == user.ID && l.DeletedAt == nil
+	})
+	hasPassword := user.PasswordHash != ""
+
+	switch {
+	case hasPassword && len(links) > 0:
+		user.AuthMethod = models.AuthMethodBoth
+	case hasPassword:
+		user.AuthMethod = models.AuthMethodPassword
+	case len(links) > 0:
+		user.AuthMethod = models.AuthMethodOAuth
+	default:
+		user.AuthMethod = models.AuthMethodPasskey
+	}
+
+	user.PrimaryProviderID = nil
+	if primary := find(links, func(l *models.UserProvider) bool { return l.IsPrimary }); primary != nil {
+		id := primary.ProviderID
+		user.PrimaryProviderID = &id
+	}
+}
@@ -0,0 +1,151 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/your-project/services/auth/internal/models"
+	"github.com/your-project/services/auth/internal/repository"
+)
+
+// CreateWebAuthnChallenge stores ceremony state for a later Finish call
+func (s *Store) CreateWebAuthnChallenge(ctx context.Context, challenge *models.WebAuthnChallenge) error {
+	defer s.lock(ctx)()
+
+	meta, err := jsonColumn(challenge.Meta)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	stored := clone(challenge)
+	stored.ID = s.nextID("webauthn_challenges")
+	stored.UUID = newUUID()
+	stored.UsedAt = nil
+	stored.Meta = meta
+	stored.CreatedAt, stored.UpdatedAt, stored.DeletedAt = now, now, nil
+	s.webAuthnChallenges = append(s.webAuthnChallenges, stored)
+
+	challenge.ID, challenge.UUID, challenge.CreatedAt, challenge.UpdatedAt = stored.ID, stored.UUID, now, now
+	return nil
+}
+
+// ConsumeWebAuthnChallenge marks an unexpired challenge as used and returns it.
+// A challenge can only be consumed once.
+func (s *Store) ConsumeWebAuthnChallenge(ctx context.Context, uuid, ceremony string) (*models.WebAuthnChallenge, error) {
+	defer s.lock(ctx)()
+
+	now := s.now()
+	challenge := find(s.webAuthnChallenges, func(c *models.WebAuthnChallenge) bool {
+		return c.UUID == uuid && c.Ceremony == ceremony && c.UsedAt == nil && c.DeletedAt == nil && c.ExpiresAt.After(now)
+	})
+	if challenge == nil {
+		return nil, repository.ErrNotFound
+	}
+	challenge.UsedAt = timePtr(now)
+	challenge.UpdatedAt = now
+	return clone(challenge), nil
+}
+
+// CreateWebAuthnCredential stores a newly registered credential.
+// It returns ErrDuplicate when the credential ID is already registered.
+func (s *Store) CreateWebAuthnCredential(ctx context.Context, credential *models.WebAuthnCredential) error {
+	defer s.lock(ctx)()
+
+	meta, err := jsonColumn(credential.Meta)
+	if err != nil {
+		return err
+	}
+	if find(s.webAuthnCredentials, func(c *models.WebAuthnCredential) bool {
+		return bytes.Equal(c.CredentialID, credential.CredentialID) && c.DeletedAt == nil
+	}) != nil {
+		return repository.ErrDuplicate
+	}
+
+	now := s.now()
+	stored := clone(credential)
+	stored.ID = s.nextID("webauthn_credentials")
+	stored.UUID = newUUID()
+	stored.AAGUID, stored.Name = optional(credential.AAGUID), optional(credential.Name)
+	stored.IsActive = true
+	stored.CloneDetectedAt, stored.LastUsedAt = nil, nil
+	stored.Meta = meta
+	stored.CreatedAt, stored.UpdatedAt, stored.DeletedAt = now, now, nil
+	s.webAuthnCredentials = append(s.webAuthnCredentials, stored)
+
+	credential.ID, credential.UUID, credential.IsActive = stored.ID, stored.UUID, true
+	credential.CreatedAt, credential.UpdatedAt = now, now
+	return nil
+}
+
+// GetWebAuthnCredentialByCredentialID looks up an active credential by its authenticator-assigned ID
+func (s *Store) GetWebAuthnCredentialByCredentialID(ctx context.Context, credentialID []byte) (*models.WebAuthnCredential, error) {
+	defer s.lock(ctx)()
+
+	credential := find(s.webAuthnCredentials, func(c *models.WebAuthnCredential) bool {
+		return bytes.Equal(c.CredentialID, credentialID) && c.IsActive && c.DeletedAt == nil
+	})
+	if credential == nil {
+		return nil, repository.ErrNotFound
+	}
+	return clone(credential), nil
+}
+
+// ListWebAuthnCredentials returns the user's active credentials, newest first
+func (s *Store) ListWebAuthnCredentials(ctx context.Context, userID int) ([]*models.WebAuthnCredential, error) {
+	defer s.lock(ctx)()
+
+	credentials := filter(s.webAuthnCredentials, func(c *models.WebAuthnCredential) bool {
+		return c.UserID == userID && c.IsActive && c.DeletedAt == nil
+	})
+	sortRows(credentials, func(a, b *models.WebAuthnCredential) bool { return a.CreatedAt.After(b.CreatedAt) })
+	return cloneAll(credentials), nil
+}
+
+// UpdateWebAuthnCredentialUsage records a successful assertion.
+// The update only applies while the stored counter is still expectedSignCount.
+func (s *Store) UpdateWebAuthnCredentialUsage(ctx context.Context, id int, expectedSignCount, signCount uint32, backupState bool) (bool, error) {
+	defer s.lock(ctx)()
+
+	credential := find(s.webAuthnCredentials, func(c *models.WebAuthnCredential) bool {
+		return c.ID == id && c.SignCount == expectedSignCount && c.IsActive && c.DeletedAt == nil
+	})
+	if credential == nil {
+		return false, nil
+	}
+	now := s.now()
+	credential.SignCount, credential.BackupState = signCount, backupState
+	credential.LastUsedAt = timePtr(now)
+	credential.UpdatedAt = now
+	return true, nil
+}
+
+// MarkWebAuthnCredentialCloned disables a credential whose signature counter went backwards
+func (s *Store) MarkWebAuthnCredentialCloned(ctx context.Context, id int) error {
+	defer s.lock(ctx)()
+
+	if credential := find(s.webAuthnCredentials, func(c *models.WebAuthnCredential) bool { return c.ID == id }); credential != nil {
+		now := s.now()
+		credential.IsActive = false
+		credential.CloneDetectedAt = timePtr(now)
+		credential.UpdatedAt = now
+	}
+	return nil
+}
+
+// DeleteWebAuthnCredential soft deletes one of the user's credentials
+func (s *Store) DeleteWebAuthnCredential(ctx context.Context, userID int, uuid string) error {
+	defer s.lock(ctx)()
+
+	credential := find(s.webAuthnCredentials, func(c *models.WebAuthnCredential) bool {
+		return c.UserID == userID && c.UUID == uuid && c.DeletedAt == nil
+	})
+	if credential == nil {
+		return repository.ErrNotFound
+	}
+	now := s.now()
+	credential.DeletedAt = timePtr(now)
+	credential.IsActive = false
+	credential.UpdatedAt = now
+	return nil
+}
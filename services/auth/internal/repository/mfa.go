@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/your-project/services/auth/internal/models"
+)
+
+// GetTOTPCredential returns the user's current (confirmed or pending) TOTP enrollment
+func (r *AuthRepository) GetTOTPCredential(ctx context.Context, userID int) (*models.TOTPCredential, error) {
+	var (
+		credential   models.TOTPCredential
+		confirmedAt  sql.NullTime
+		lastUsedStep sql.NullInt64
+		meta         []byte
+	)
+
+	err := r.conn(ctx).QueryRowContext(ctx, `
+		SELECT id, uuid, user_id, secret, confirmed_at, last_used_step, meta, created_at, updated_at
+		FROM sr_auth.mfa_totp
+		WHERE user_id = $1 AND deleted_at IS NULL`, userID,
+	).Scan(&credential.ID, &credential.UUID, &credential.UserID, &credential.Secret, &confirmedAt,
+		&lastUsedStep, &meta, &credential.CreatedAt, &credential.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get totp credential: %w", err)
+	}
+
+	if confirmedAt.Valid {
+		credential.ConfirmedAt = &confirmedAt.Time
+	}
+	if lastUsedStep.Valid {
+		credential.LastUsedStep = &lastUsedStep.Int64
+	}
+	if credential.Meta, err = decodeJSON(meta); err != nil {
+		return nil, err
+	}
+
+	return &credential, nil
+}
+
+// CreatePendingTOTPCredential replaces any unconfirmed enrollment with a new secret.
+// Confirmed enrollments are left untouched; the unique index on user_id rejects the insert in that case.
+func (r *AuthRepository) CreatePendingTOTPCredential(ctx context.Context, userID int, secret string) (*models.TOTPCredential, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE sr_auth.mfa_totp SET deleted_at = get_utc_timestamp()
+		WHERE user_id = $1 AND confirmed_at IS NULL AND deleted_at IS NULL`, userID); err != nil {
+		return nil, fmt.Errorf("failed to discard pending totp credential: %w", err)
+	}
+
+	credential := models.TOTPCredential{UserID: userID, Secret: secret, Meta: map[string]interface{}{}}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO sr_auth.mfa_totp (user_id, secret)
+		VALUES ($1, $2)
+		RETURNING id, uuid, created_at, updated_at`, userID, secret,
+	).Scan(&credential.ID, &credential.UUID, &credential.CreatedAt, &credential.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create totp credential: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit totp credential: %w", err)
+	}
+
+	return &credential, nil
+}
+
+// ConfirmTOTPCredential marks an enrollment confirmed, records the verifying step and
+// replaces the user's recovery codes in a single transaction
+func (r *AuthRepository) ConfirmTOTPCredential(ctx context.Context, credentialID int, step int64, recoveryCodeHashes []string) error {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID int
+	err = tx.QueryRowContext(ctx, `
+		UPDATE sr_auth.mfa_totp
+		SET confirmed_at = get_utc_timestamp(), last_used_step = $2
+		WHERE id = $1 AND confirmed_at IS NULL AND deleted_at IS NULL
+		RETURNING user_id`, credentialID, step,
+	).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to confirm totp credential: %w", err)
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit totp confirmation: %w", err)
+	}
+
+	return nil
+}
+
+// AdvanceTOTPStep records step as used if it is newer than the last accepted step.
+// It returns false when the step was already used, which indicates a replayed code.
+func (r *AuthRepository) AdvanceTOTPStep(ctx context.Context, credentialID int, step int64) (bool, error) {
+	result, err := r.conn(ctx).ExecContext(ctx, `
+		UPDATE sr_auth.mfa_totp SET last_used_step = $2
+		WHERE id = $1 AND deleted_at IS NULL AND (last_used_step IS NULL OR last_used_step < $2)`,
+		credentialID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to advance totp step: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to read affected rows: %w", err)
+	}
+
+	return affected == 1, nil
+}
+
+// DeleteTOTPCredential soft deletes the user's enrollment and all recovery codes
+func (r *AuthRepository) DeleteTOTPCredential(ctx context.Context, userID int) error {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE sr_auth.mfa_totp SET deleted_at = get_utc_timestamp()
+		WHERE user_id = $1 AND deleted_at IS NULL`, userID); err != nil {
+		return fmt.Errorf("failed to delete totp credential: %w", err)
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit totp removal: %w", err)
+	}
+
+	return nil
+}
+
+// ReplaceRecoveryCodes invalidates existing recovery codes and stores the new hashes
+func (r *AuthRepository) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit recovery codes: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumeRecoveryCode marks a matching unused recovery code as used.
+// It returns false when no unused code matches.
+func (r *AuthRepository) ConsumeRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	result, err := r.conn(ctx).ExecContext(ctx, `
+		UPDATE sr_auth.mfa_recovery_codes SET used_at = get_utc_timestamp()
+		WHERE id = (
+			SELECT id FROM sr_auth.mfa_recovery_codes
+			WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL AND deleted_at IS NULL
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)`, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to consume recovery code: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to read affected rows: %w", err)
+	}
+
+	return affected == 1, nil
+}
+
+// CountUnusedRecoveryCodes returns how many recovery codes the user has left
+func (r *AuthRepository) CountUnusedRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	var count int
+	err := r.conn(ctx).QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM sr_auth.mfa_recovery_codes
+		WHERE user_id = $1 AND used_at IS NULL AND deleted_at IS NULL`, userID,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return count, nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx querier, userID int, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE sr_auth.mfa_recovery_codes SET deleted_at = get_utc_timestamp()
+		WHERE user_id = $1 AND deleted_at IS NULL`, userID); err != nil {
+		return fmt.Errorf("failed to invalidate recovery codes: %w", err)
+	}
+
+	for _, hash := range codeHashes {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO sr_auth.mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)`,
+			userID, hash); err != nil {
+			return fmt.Errorf("failed to store recovery code: %w", err)
+		}
+	}
+
+	return nil
+}
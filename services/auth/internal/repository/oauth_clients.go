@@ -0,0 +1,305 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/your-project/services/auth/internal/models"
+)
+
+const oauthClientColumns = `id, uuid, client_id, client_secret_hash, name, redirect_uris, grant_types, scopes,
+	is_first_party, is_active, meta, created_at, updated_at, deleted_at`
+
+// GetOAuthClientByClientID returns a non-deleted client by its public client_id, including disabled ones
+func (r *AuthRepository) GetOAuthClientByClientID(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	row := r.conn(ctx).QueryRowContext(ctx,
+		`SELECT `+oauthClientColumns+` FROM sr_auth.oauth_clients WHERE client_id = $1 AND deleted_at IS NULL`, clientID)
+	return scanOAuthClient(row)
+}
+
+// GetOAuthClientByID returns a non-deleted client by primary key
+func (r *AuthRepository) GetOAuthClientByID(ctx context.Context, id int) (*models.OAuthClient, error) {
+	row := r.conn(ctx).QueryRowContext(ctx,
+		`SELECT `+oauthClientColumns+` FROM sr_auth.oauth_clients WHERE id = $1 AND deleted_at IS NULL`, id)
+	return scanOAuthClient(row)
+}
+
+// GetOAuthClientByUUID returns a non-deleted client by UUID
+func (r *AuthRepository) GetOAuthClientByUUID(ctx context.Context, uuid string) (*models.OAuthClient, error) {
+	row := r.conn(ctx).QueryRowContext(ctx,
+		`SELECT `+oauthClientColumns+` FROM sr_auth.oauth_clients WHERE uuid = $1 AND deleted_at IS NULL`, uuid)
+	return scanOAuthClient(row)
+}
+
+// ListOAuthClients returns all non-deleted clients ordered by name
+func (r *AuthRepository) ListOAuthClients(ctx context.Context) ([]*models.OAuthClient, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx,
+		`SELECT `+oauthClientColumns+` FROM sr_auth.oauth_clients WHERE deleted_at IS NULL ORDER BY name, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list oauth clients: %w", err)
+	}
+	defer rows.Close()
+
+	var clients []*models.OAuthClient
+	for rows.Next() {
+		client, err := scanOAuthClient(rows)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list oauth clients: %w", err)
+	}
+	return clients, nil
+}
+
+// CreateOAuthClient registers a client. It returns ErrDuplicate if the client_id is taken.
+func (r *AuthRepository) CreateOAuthClient(ctx context.Context, client *models.OAuthClient) error {
+	meta, err := encodeJSON(client.Meta)
+	if err != nil {
+		return err
+	}
+
+	row := r.conn(ctx).QueryRowContext(ctx, `
+		INSERT INTO sr_auth.oauth_clients (client_id, client_secret_hash, name, redirect_uris, grant_types, scopes,
+			is_first_party, meta)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING `+oauthClientColumns,
+		client.ClientID, nullableString(&client.ClientSecretHash), client.Name, pq.Array(client.RedirectURIs),
+		pq.Array(client.GrantTypes), pq.Array(client.Scopes), client.IsFirstParty, meta)
+	stored, err := scanOAuthClient(row)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("failed to create oauth client: %w", err)
+	}
+
+	*client = *stored
+	return nil
+}
+
+// SetOAuthClientActive enables or disables a client by UUID and returns it
+func (r *AuthRepository) SetOAuthClientActive(ctx context.Context, uuid string, active bool) (*models.OAuthClient, error) {
+	row := r.conn(ctx).QueryRowContext(ctx, `
+		UPDATE sr_auth.oauth_clients SET is_active = $2
+		WHERE uuid = $1 AND deleted_at IS NULL
+		RETURNING `+oauthClientColumns,
+		uuid, active)
+	return scanOAuthClient(row)
+}
+
+// scanOAuthClient reads one oauth_clients row selected with oauthClientColumns
+func scanOAuthClient(row scanner) (*models.OAuthClient, error) {
+	var (
+		client     models.OAuthClient
+		secretHash sql.NullString
+		meta       []byte
+		deletedAt  sql.NullTime
+	)
+
+	err := row.Scan(&client.ID, &client.UUID, &client.ClientID, &secretHash, &client.Name,
+		pq.Array(&client.RedirectURIs), pq.Array(&client.GrantTypes), pq.Array(&client.Scopes),
+		&client.IsFirstParty, &client.IsActive, &meta, &client.CreatedAt, &client.UpdatedAt, &deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan oauth client: %w", err)
+	}
+
+	client.ClientSecretHash = secretHash.String
+	if deletedAt.Valid {
+		client.DeletedAt = &deletedAt.Time
+	}
+	if client.Meta, err = decodeJSON(meta); err != nil {
+		return nil, err
+	}
+
+	return &client, nil
+}
+
+const oauthAuthorizationColumns = `id, uuid, client_id, redirect_uri, scope, state, nonce, code_challenge,
+	code_challenge_method, user_id, session_id, code_hash, code_expires_at, token_session_id, expires_at,
+	approved_at, denied_at, used_at, meta, created_at, updated_at, deleted_at`
+
+// CreateOAuthAuthorization stores an /authorize request waiting for the user's approval
+func (r *AuthRepository) CreateOAuthAuthorization(ctx context.Context, authz *models.OAuthAuthorization) error {
+	meta, err := encodeJSON(authz.Meta)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn(ctx).QueryRowContext(ctx, `
+		INSERT INTO sr_auth.oauth_authorizations (client_id, redirect_uri, scope, state, nonce, code_challenge,
+			code_challenge_method, expires_at, meta)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, uuid, created_at, updated_at`,
+		authz.ClientID, authz.RedirectURI, authz.Scope, nullableString(authz.State), nullableString(authz.Nonce),
+		authz.CodeChallenge, authz.CodeChallengeMethod, authz.ExpiresAt, meta,
+	).Scan(&authz.ID, &authz.UUID, &authz.CreatedAt, &authz.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create oauth authorization: %w", err)
+	}
+
+	return nil
+}
+
+// GetPendingOAuthAuthorization returns an unexpired request that has been neither
+// approved nor denied. It returns ErrNotFound otherwise.
+func (r *AuthRepository) GetPendingOAuthAuthorization(ctx context.Context, uuid string) (*models.OAuthAuthorization, error) {
+	row := r.conn(ctx).QueryRowContext(ctx, `
+		SELECT `+oauthAuthorizationColumns+` FROM sr_auth.oauth_authorizations
+		WHERE uuid = $1 AND approved_at IS NULL AND denied_at IS NULL AND deleted_at IS NULL
+			AND expires_at > get_utc_timestamp()`, uuid)
+	return scanOAuthAuthorization(row)
+}
+
+// ApproveOAuthAuthorization attaches the approving user, session and code to a
+// pending request. It returns ErrNotFound if the request is no longer pending.
+func (r *AuthRepository) ApproveOAuthAuthorization(ctx context.Context, id, userID, sessionID int, codeHash string, codeExpiresAt time.Time) error {
+	result, err := r.conn(ctx).ExecContext(ctx, `
+		UPDATE sr_auth.oauth_authorizations
+		SET user_id = $2, session_id = $3, code_hash = $4, code_expires_at = $5, approved_at = get_utc_timestamp()
+		WHERE id = $1 AND approved_at IS NULL AND denied_at IS NULL AND deleted_at IS NULL
+			AND expires_at > get_utc_timestamp()`,
+		id, userID, sessionID, codeHash, codeExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to approve oauth authorization: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to approve oauth authorization: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DenyOAuthAuthorization marks a pending request as denied. It returns
+// ErrNotFound if the request is no longer pending.
+func (r *AuthRepository) DenyOAuthAuthorization(ctx context.Context, id int) error {
+	result, err := r.conn(ctx).ExecContext(ctx, `
+		UPDATE sr_auth.oauth_authorizations SET denied_at = get_utc_timestamp()
+		WHERE id = $1 AND approved_at IS NULL AND denied_at IS NULL AND deleted_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("failed to deny oauth authorization: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to deny oauth authorization: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ConsumeOAuthAuthorizationCode atomically marks an unexpired, unused code as
+// used and returns its authorization. It returns ErrNotFound for unknown,
+// expired or already used codes.
+func (r *AuthRepository) ConsumeOAuthAuthorizationCode(ctx context.Context, codeHash string) (*models.OAuthAuthorization, error) {
+	row := r.conn(ctx).QueryRowContext(ctx, `
+		UPDATE sr_auth.oauth_authorizations SET used_at = get_utc_timestamp()
+		WHERE code_hash = $1 AND used_at IS NULL AND deleted_at IS NULL AND code_expires_at > get_utc_timestamp()
+		RETURNING `+oauthAuthorizationColumns, codeHash)
+	return scanOAuthAuthorization(row)
+}
+
+// GetOAuthAuthorizationByCodeHash returns the authorization a code was issued
+// for, whether or not it has been used
+func (r *AuthRepository) GetOAuthAuthorizationByCodeHash(ctx context.Context, codeHash string) (*models.OAuthAuthorization, error) {
+	row := r.conn(ctx).QueryRowContext(ctx, `
+		SELECT `+oauthAuthorizationColumns+` FROM sr_auth.oauth_authorizations
+		WHERE code_hash = $1 AND deleted_at IS NULL`, codeHash)
+	return scanOAuthAuthorization(row)
+}
+
+// SetOAuthAuthorizationTokenSession records the session created by exchanging the code
+func (r *AuthRepository) SetOAuthAuthorizationTokenSession(ctx context.Context, id, sessionID int) error {
+	_, err := r.conn(ctx).ExecContext(ctx,
+		`UPDATE sr_auth.oauth_authorizations SET token_session_id = $2 WHERE id = $1`, id, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to record oauth token session: %w", err)
+	}
+	return nil
+}
+
+// scanOAuthAuthorization reads one oauth_authorizations row selected with oauthAuthorizationColumns
+func scanOAuthAuthorization(row scanner) (*models.OAuthAuthorization, error) {
+	var (
+		authz          models.OAuthAuthorization
+		state          sql.NullString
+		nonce          sql.NullString
+		userID         sql.NullInt64
+		sessionID      sql.NullInt64
+		codeHash       sql.NullString
+		codeExpiresAt  sql.NullTime
+		tokenSessionID sql.NullInt64
+		approvedAt     sql.NullTime
+		deniedAt       sql.NullTime
+		usedAt         sql.NullTime
+		meta           []byte
+		deletedAt      sql.NullTime
+	)
+
+	err := row.Scan(&authz.ID, &authz.UUID, &authz.ClientID, &authz.RedirectURI, &authz.Scope, &state, &nonce,
+		&authz.CodeChallenge, &authz.CodeChallengeMethod, &userID, &sessionID, &codeHash, &codeExpiresAt,
+		&tokenSessionID, &authz.ExpiresAt, &approvedAt, &deniedAt, &usedAt, &meta, &authz.CreatedAt,
+		&authz.UpdatedAt, &deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan oauth authorization: %w", err)
+	}
+
+	if state.Valid {
+		authz.State = &state.String
+	}
+	if nonce.Valid {
+		authz.Nonce = &nonce.String
+	}
+	if userID.Valid {
+		id := int(userID.Int64)
+		authz.UserID = &id
+	}
+	if sessionID.Valid {
+		id := int(sessionID.Int64)
+		authz.SessionID = &id
+	}
+	if codeHash.Valid {
+		authz.CodeHash = &codeHash.String
+	}
+	if codeExpiresAt.Valid {
+		authz.CodeExpiresAt = &codeExpiresAt.Time
+	}
+	if tokenSessionID.Valid {
+		id := int(tokenSessionID.Int64)
+		authz.TokenSessionID = &id
+	}
+	if approvedAt.Valid {
+		authz.ApprovedAt = &approvedAt.Time
+	}
+	if deniedAt.Valid {
+		authz.DeniedAt = &deniedAt.Time
+	}
+	if usedAt.Valid {
+		authz.UsedAt = &usedAt.Time
+	}
+	if deletedAt.Valid {
+		authz.DeletedAt = &deletedAt.Time
+	}
+	if authz.Meta, err = decodeJSON(meta); err != nil {
+		return nil, err
+	}
+
+	return &authz, nil
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/your-project/services/auth/internal/models"
+)
+
+const otpTokenColumns = `id, uuid, user_id, otp_code, use_case, destination, attempts, host(ip_address), is_used,
+	expires_at, used_at, meta, created_at, updated_at, deleted_at`
+
+// CreateOTP stores a new code and invalidates the user's other live codes for
+// the same use case, so only the most recent one can be redeemed
+func (r *AuthRepository) CreateOTP(ctx context.Context, otp *models.OTPToken) error {
+	meta, err := encodeJSON(otp.Meta)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE sr_auth.otp_tokens SET deleted_at = get_utc_timestamp()
+		WHERE user_id = $1 AND use_case = $2 AND is_used = false AND deleted_at IS NULL`,
+		otp.UserID, otp.UseCase); err != nil {
+		return fmt.Errorf("failed to invalidate otp tokens: %w", err)
+	}
+
+	row := tx.QueryRowContext(ctx, `
+		INSERT INTO sr_auth.otp_tokens (user_id, otp_code, use_case, destination, ip_address, expires_at, meta)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+otpTokenColumns,
+		otp.UserID, otp.CodeHash, otp.UseCase, otp.Destination, nullableString(otp.IPAddress), otp.ExpiresAt, meta)
+	stored, err := scanOTPToken(row)
+	if err != nil {
+		return fmt.Errorf("failed to create otp token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit otp token: %w", err)
+	}
+
+	*otp = *stored
+	return nil
+}
+
+// OTPSendStats returns how many codes were sent to the user for useCase since
+// the given time and when the latest one was sent (zero if none)
+func (r *AuthRepository) OTPSendStats(ctx context.Context, userID int, useCase string, since time.Time) (int, time.Time, error) {
+	var (
+		count  int
+		lastAt sql.NullTime
+	)
+	err := r.conn(ctx).QueryRowContext(ctx, `
+		SELECT COUNT(*), MAX(created_at) FROM sr_auth.otp_tokens
+		WHERE user_id = $1 AND use_case = $2 AND created_at > $3`,
+		userID, useCase, since,
+	).Scan(&count, &lastAt)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to count otp tokens: %w", err)
+	}
+	return count, lastAt.Time, nil
+}
+
+// ConsumeOTP checks codeHash against the user's live code for useCase and marks
+// it used on a match. A wrong code counts an attempt and returns
+// ErrCodeMismatch; once maxAttempts is reached the code stops being live. It
+// returns ErrNotFound if there is no unexpired, unused code left to try.
+func (r *AuthRepository) ConsumeOTP(ctx context.Context, userID int, useCase, codeHash string, maxAttempts int) (*models.OTPToken, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRowContext(ctx, `
+		SELECT `+otpTokenColumns+` FROM sr_auth.otp_tokens
+		WHERE user_id = $1 AND use_case = $2 AND is_used = false AND deleted_at IS NULL
+			AND expires_at > get_utc_timestamp() AND attempts < $3
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+		FOR UPDATE`,
+		userID, useCase, maxAttempts)
+	otp, err := scanOTPToken(row)
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(otp.CodeHash), []byte(codeHash)) != 1 {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE sr_auth.otp_tokens SET attempts = attempts + 1 WHERE id = $1`, otp.ID); err != nil {
+			return nil, fmt.Errorf("failed to count otp attempt: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit otp attempt: %w", err)
+		}
+		return nil, ErrCodeMismatch
+	}
+
+	row = tx.QueryRowContext(ctx, `
+		UPDATE sr_auth.otp_tokens SET is_used = true, used_at = get_utc_timestamp()
+		WHERE id = $1
+		RETURNING `+otpTokenColumns,
+		otp.ID)
+	if otp, err = scanOTPToken(row); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit otp token: %w", err)
+	}
+	return otp, nil
+}
+
+// TakeOTPAttempt counts an attempt against the user's live code for useCase
+// whose hash is codeHash and returns it. The attempt is taken before the caller
+// checks whatever the code stands in for, so parallel guesses cannot exceed
+// maxAttempts. It returns ErrNotFound if the code is used, expired, replaced or
+// out of attempts.
+func (r *AuthRepository) TakeOTPAttempt(ctx context.Context, userID int, useCase, codeHash string, maxAttempts int) (*models.OTPToken, error) {
+	row := r.conn(ctx).QueryRowContext(ctx, `
+		UPDATE sr_auth.otp_tokens SET attempts = attempts + 1
+		WHERE user_id = $1 AND use_case = $2 AND otp_code = $3 AND is_used = false AND deleted_at IS NULL
+			AND expires_at > get_utc_timestamp() AND attempts < $4
+		RETURNING `+otpTokenColumns,
+		userID, useCase, codeHash, maxAttempts)
+	return scanOTPToken(row)
+}
+
+// MarkOTPUsed burns a code taken with TakeOTPAttempt. It returns false when the
+// code was already used or replaced, which indicates a replay.
+func (r *AuthRepository) MarkOTPUsed(ctx context.Context, id int) (bool, error) {
+	result, err := r.conn(ctx).ExecContext(ctx, `
+		UPDATE sr_auth.otp_tokens SET is_used = true, used_at = get_utc_timestamp()
+		WHERE id = $1 AND is_used = false AND deleted_at IS NULL`,
+		id)
+	if err != nil {
+		return false, fmt.Errorf("failed to use otp token: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to read affected rows: %w", err)
+	}
+
+	return affected == 1, nil
+}
+
+// scanOTPToken reads one otp_tokens row selected with otpTokenColumns
+func scanOTPToken(row scanner) (*models.OTPToken, error) {
+	var (
+		otp         models.OTPToken
+		destination sql.NullString
+		ipAddress   sql.NullString
+		usedAt      sql.NullTime
+		meta        []byte
+		deletedAt   sql.NullTime
+	)
+
+	err := row.Scan(&otp.ID, &otp.UUID, &otp.UserID, &otp.CodeHash, &otp.UseCase, &destination, &otp.Attempts,
+		&ipAddress, &otp.IsUsed, &otp.ExpiresAt, &usedAt, &meta, &otp.CreatedAt, &otp.UpdatedAt, &deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan otp token: %w", err)
+	}
+
+	otp.Destination = destination.String
+	if ipAddress.Valid {
+		otp.IPAddress = &ipAddress.String
+	}
+	if usedAt.Valid {
+		otp.UsedAt = &usedAt.Time
+	}
+	if deletedAt.Valid {
+		otp.DeletedAt = &deletedAt.Time
+	}
+	if otp.Meta, err = decodeJSON(meta); err != nil {
+		return nil, err
+	}
+
+	return &otp, nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/your-project/services/auth/internal/models"
+)
+
+// refreshStateKeys are the user_providers.meta entries kept by the token refresher
+const refreshStateKeys = `'refresh_failures', 'refresh_retry_at', 'refresh_error'`
+
+// ListUserProvidersDueForRefresh returns active links of enabled providers whose
+// access token expires before dueBy and that have a refresh token and are not
+// backing off after a failed refresh, soonest expiry first
+func (r *AuthRepository) ListUserProvidersDueForRefresh(ctx context.Context, dueBy, now time.Time, limit int) ([]*models.UserProvider, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, `
+		SELECT `+userProviderColumns+` FROM sr_auth.user_providers
+		WHERE is_active = true AND deleted_at IS NULL
+			AND refresh_token IS NOT NULL AND expires_at IS NOT NULL AND expires_at < $1
+			AND (meta->>'refresh_retry_at' IS NULL OR (meta->>'refresh_retry_at')::timestamptz <= $2)
+			AND provider_id IN (
+				SELECT id FROM sr_auth.providers WHERE is_active = true AND is_enabled = true AND deleted_at IS NULL
+			)
+		ORDER BY expires_at
+		LIMIT $3`,
+		dueBy, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user providers due for refresh: %w", err)
+	}
+	defer rows.Close()
+
+	var links []*models.UserProvider
+	for rows.Next() {
+		link, err := r.scanUserProvider(ctx, rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list user providers due for refresh: %w", err)
+	}
+	return links, nil
+}
+
+// GetUserProviderByID returns a non-deleted link by primary key
+func (r *AuthRepository) GetUserProviderByID(ctx context.Context, id int) (*models.UserProvider, error) {
+	row := r.conn(ctx).QueryRowContext(ctx, `
+		SELECT `+userProviderColumns+` FROM sr_auth.user_providers WHERE id = $1 AND deleted_at IS NULL`, id)
+	return r.scanUserProvider(ctx, row)
+}
+
+// TryLockUserProviderRefresh takes the advisory lock that lets only one
+// replica refresh a link's tokens at a time. ok is false if another holds it.
+func (r *AuthRepository) TryLockUserProviderRefresh(ctx context.Context, linkID int) (unlock func(), ok bool, err error) {
+	return r.tryAdvisoryLock(ctx, lockNamespaceProviderTokenRefresh, int32(linkID))
+}
+
+// StoreRefreshedUserProviderTokens saves tokens from a refresh and clears the
+// refresher's backoff state. A nil refresh token keeps the stored one.
+func (r *AuthRepository) StoreRefreshedUserProviderTokens(ctx context.Context, link *models.UserProvider) error {
+	accessToken, refreshToken, err := r.sealUserProviderTokens(ctx, link)
+	if err != nil {
+		return err
+	}
+
+	row := r.conn(ctx).QueryRowContext(ctx, `
+		UPDATE sr_auth.user_providers
+		SET access_token = $2, refresh_token = COALESCE($3, refresh_token), token_type = COALESCE($4, token_type),
+			expires_at = $5, meta = meta - ARRAY[`+refreshStateKeys+`]
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING `+userProviderColumns,
+		link.ID, accessToken, refreshToken, nullableString(link.TokenType), link.ExpiresAt)
+	stored, err := r.scanUserProvider(ctx, row)
+	if err != nil {
+		return err
+	}
+
+	*link = *stored
+	return nil
+}
+
+// RecordUserProviderRefreshFailure stores the backoff state after a failed refresh
+func (r *AuthRepository) RecordUserProviderRefreshFailure(ctx context.Context, linkID, failures int, retryAt time.Time, reason string) error {
+	state, err := json.Marshal(map[string]interface{}{
+		"refresh_failures": failures,
+		"refresh_retry_at": retryAt.UTC().Format(time.RFC3339Nano),
+		"refresh_error":    reason,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode refresh state: %w", err)
+	}
+
+	_, err = r.conn(ctx).ExecContext(ctx, `
+		UPDATE sr_auth.user_providers SET meta = meta || $2
+		WHERE id = $1 AND deleted_at IS NULL`,
+		linkID, state)
+	if err != nil {
+		return fmt.Errorf("failed to record refresh failure: %w", err)
+	}
+	return nil
+}
+
+// DeactivateUserProvider marks a link inactive after the provider revoked its
+// refresh token and drops the unusable tokens. Signing in through the link
+// stores new tokens and reactivates it.
+func (r *AuthRepository) DeactivateUserProvider(ctx context.Context, linkID int, reason string) error {
+	state, err := json.Marshal(map[string]interface{}{"deactivated_reason": reason})
+	if err != nil {
+		return fmt.Errorf("failed to encode deactivation reason: %w", err)
+	}
+
+	_, err = r.conn(ctx).ExecContext(ctx, `
+		UPDATE sr_auth.user_providers
+		SET is_active = false, access_token = NULL, refresh_token = NULL, expires_at = NULL,
+			meta = (meta - ARRAY[`+refreshStateKeys+`]) || $2
+		WHERE id = $1 AND deleted_at IS NULL`,
+		linkID, state)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate user provider: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/your-project/services/auth/internal/models"
+)
+
+const providerColumns = `id, uuid, name, display_name, client_id, client_secret, authorization_url, token_url,
+	userinfo_url, scopes, redirect_uri, is_active, is_enabled, config, meta, created_at, updated_at, deleted_at`
+
+// GetProviderByName returns a non-deleted provider by its name ('google', 'github', ...)
+func (r *AuthRepository) GetProviderByName(ctx context.Context, name string) (*models.Provider, error) {
+	row := r.conn(ctx).QueryRowContext(ctx,
+		`SELECT `+providerColumns+` FROM sr_auth.providers WHERE name = $1 AND deleted_at IS NULL`, name)
+	return r.scanProvider(ctx, row)
+}
+
+// GetProviderByID returns a non-deleted provider by primary key
+func (r *AuthRepository) GetProviderByID(ctx context.Context, id int) (*models.Provider, error) {
+	row := r.conn(ctx).QueryRowContext(ctx,
+		`SELECT `+providerColumns+` FROM sr_auth.providers WHERE id = $1 AND deleted_at IS NULL`, id)
+	return r.scanProvider(ctx, row)
+}
+
+// GetProviderByUUID returns a non-deleted provider by its public ID
+func (r *AuthRepository) GetProviderByUUID(ctx context.Context, uuid string) (*models.Provider, error) {
+	row := r.conn(ctx).QueryRowContext(ctx,
+		`SELECT `+providerColumns+` FROM sr_auth.providers WHERE uuid = $1 AND deleted_at IS NULL`, uuid)
+	return r.scanProvider(ctx, row)
+}
+
+// ListProviders returns all non-deleted providers ordered by name
+func (r *AuthRepository) ListProviders(ctx context.Context) ([]*models.Provider, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx,
+		`SELECT `+providerColumns+` FROM sr_auth.providers WHERE deleted_at IS NULL ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list providers: %w", err)
+	}
+	defer rows.Close()
+
+	var providers []*models.Provider
+	for rows.Next() {
+		provider, err := r.scanProvider(ctx, rows)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list providers: %w", err)
+	}
+	return providers, nil
+}
+
+// CreateProvider inserts a provider. It returns ErrDuplicate if the name is taken.
+func (r *AuthRepository) CreateProvider(ctx context.Context, provider *models.Provider) error {
+	secret, err := r.sealSecret(ctx, provider.ClientSecret, aadProviderClientSecret)
+	if err != nil {
+		return err
+	}
+	config, err := encodeJSON(provider.Config)
+	if err != nil {
+		return err
+	}
+	meta, err := encodeJSON(provider.Meta)
+	if err != nil {
+		return err
+	}
+
+	row := r.conn(ctx).QueryRowContext(ctx, `
+		INSERT INTO sr_auth.providers (name, display_name, client_id, client_secret, authorization_url, token_url,
+			userinfo_url, scopes, redirect_uri, is_enabled, config, meta)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING `+providerColumns,
+		provider.Name, provider.DisplayName, provider.ClientID, secret, provider.AuthorizationURL, provider.TokenURL,
+		nullableString(provider.UserinfoURL), nullableString(provider.Scopes), nullableString(provider.RedirectURI),
+		provider.IsEnabled, config, meta)
+	stored, err := r.scanProvider(ctx, row)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("failed to create provider: %w", err)
+	}
+
+	*provider = *stored
+	return nil
+}
+
+// UpdateProvider replaces a provider's settings. The client secret is only
+// replaced when provider.ClientSecret is not empty; name and is_enabled are unchanged.
+func (r *AuthRepository) UpdateProvider(ctx context.Context, provider *models.Provider) error {
+	secret, err := r.sealSecret(ctx, provider.ClientSecret, aadProviderClientSecret)
+	if err != nil {
+		return err
+	}
+	config, err := encodeJSON(provider.Config)
+	if err != nil {
+		return err
+	}
+
+	row := r.conn(ctx).QueryRowContext(ctx, `
+		UPDATE sr_auth.providers
+		SET display_name = $2, client_id = $3, client_secret = COALESCE(NULLIF($4, ''), client_secret),
+			authorization_url = $5, token_url = $6, userinfo_url = $7, scopes = $8, redirect_uri = $9, config = $10
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING `+providerColumns,
+		provider.ID, provider.DisplayName, provider.ClientID, secret, provider.AuthorizationURL, provider.TokenURL,
+		nullableString(provider.UserinfoURL), nullableString(provider.Scopes), nullableString(provider.RedirectURI),
+		config)
+	stored, err := r.scanProvider(ctx, row)
+	if err != nil {
+		return err
+	}
+
+	*provider = *stored
+	return nil
+}
+
+// SetProviderEnabled enables or disables a provider for sign-in
+func (r *AuthRepository) SetProviderEnabled(ctx context.Context, id int, enabled bool) (*models.Provider, error) {
+	row := r.conn(ctx).QueryRowContext(ctx, `
+		UPDATE sr_auth.providers SET is_enabled = $2
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING `+providerColumns,
+		id, enabled)
+	return r.scanProvider(ctx, row)
+}
+
+// ProvidersVersion returns a fingerprint of the providers table that changes
+// whenever a provider is created, updated or deleted
+func (r *AuthRepository) ProvidersVersion(ctx context.Context) (string, error) {
+	var version string
+	err := r.conn(ctx).QueryRowContext(ctx, `
+		SELECT count(*) || ':' || COALESCE(max(updated_at)::text, '') FROM sr_auth.providers`,
+	).Scan(&version)
+	if err != nil {
+		return "", fmt.Errorf("failed to read providers version: %w", err)
+	}
+	return version, nil
+}
+
+func (r *AuthRepository) scanProvider(ctx context.Context, row scanner) (*models.Provider, error) {
+	var (
+		provider    models.Provider
+		userinfoURL sql.NullString
+		scopes      sql.NullString
+		redirectURI sql.NullString
+		config      []byte
+		meta        []byte
+		deletedAt   sql.NullTime
+	)
+
+	err := row.Scan(&provider.ID, &provider.UUID, &provider.Name, &provider.DisplayName, &provider.ClientID,
+		&provider.ClientSecret, &provider.AuthorizationURL, &provider.TokenURL, &userinfoURL, &scopes, &redirectURI,
+		&provider.IsActive, &provider.IsEnabled, &config, &meta, &provider.CreatedAt, &provider.UpdatedAt, &deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan provider: %w", err)
+	}
+
+	if userinfoURL.Valid {
+		provider.UserinfoURL = &userinfoURL.String
+	}
+	if scopes.Valid {
+		provider.Scopes = &scopes.String
+	}
+	if redirectURI.Valid {
+		provider.RedirectURI = &redirectURI.String
+	}
+	if deletedAt.Valid {
+		provider.DeletedAt = &deletedAt.Time
+	}
+	if provider.Config, err = decodeJSON(config); err != nil {
+		return nil, err
+	}
+	if provider.Meta, err = decodeJSON(meta); err != nil {
+		return nil, err
+	}
+
+	return r.openProvider(ctx, &provider)
+}
+
+// CreateOAuthState stores authorization request state for a later CompleteOAuth call
+func (r *AuthRepository) CreateOAuthState(ctx context.Context, state *models.OAuthState) error {
+	meta, err := encodeJSON(state.Meta)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn(ctx).QueryRowContext(ctx, `
+		INSERT INTO sr_auth.oauth_states (provider_id, user_id, state_hash, nonce, code_verifier, redirect_uri,
+			device_id, ip_address, user_agent, expires_at, meta)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, uuid, created_at, updated_at`,
+		state.ProviderID, state.UserID, state.StateHash, state.Nonce, state.CodeVerifier, state.RedirectURI,
+		nullableString(state.DeviceID), nullableString(state.IPAddress), nullableString(state.UserAgent),
+		state.ExpiresAt, meta,
+	).Scan(&state.ID, &state.UUID, &state.CreatedAt, &state.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create oauth state: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumeOAuthState atomically marks an unexpired, unused state as used and returns it.
+// It returns ErrNotFound for unknown, expired or already used states.
+func (r *AuthRepository) ConsumeOAuthState(ctx context.Context, stateHash string) (*models.OAuthState, error) {
+	var (
+		state     models.OAuthState
+		userID    sql.NullInt64
+		deviceID  sql.NullString
+		ipAddress sql.NullString
+		userAgent sql.NullString
+		usedAt    sql.NullTime
+		meta      []byte
+	)
+
+	err := r.conn(ctx).QueryRowContext(ctx, `
+		UPDATE sr_auth.oauth_states SET used_at = get_utc_timestamp()
+		WHERE state_hash = $1 AND used_at IS NULL AND deleted_at IS NULL AND expires_at > get_utc_timestamp()
+		RETURNING id, uuid, provider_id, user_id, state_hash, nonce, code_verifier, redirect_uri, device_id,
+			host(ip_address), user_agent, expires_at, used_at, meta, created_at, updated_at`,
+		stateHash,
+	).Scan(&state.ID, &state.UUID, &state.ProviderID, &userID, &state.StateHash, &state.Nonce, &state.CodeVerifier,
+		&state.RedirectURI, &deviceID, &ipAddress, &userAgent, &state.ExpiresAt, &usedAt, &meta,
+		&state.CreatedAt, &state.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume oauth state: %w", err)
+	}
+
+	if userID.Valid {
+		id := int(userID.Int64)
+		state.UserID = &id
+	}
+	if deviceID.Valid {
+		state.DeviceID = &deviceID.String
+	}
+	if ipAddress.Valid {
+		state.IPAddress = &ipAddress.String
+	}
+	if userAgent.Valid {
+		state.UserAgent = &userAgent.String
+	}
+	if usedAt.Valid {
+		state.UsedAt = &usedAt.Time
+	}
+	if state.Meta, err = decodeJSON(meta); err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
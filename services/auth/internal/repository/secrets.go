@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/your-project/pkgs/envelope"
+	"github.com/your-project/services/auth/internal/models"
+)
+
+// Associated data binding each encrypted value to its column
+const (
+	aadProviderClientSecret = "providers.client_secret"
+	aadAccessToken          = "user_providers.access_token"
+	aadRefreshToken         = "user_providers.refresh_token"
+)
+
+// ErrEncryptorRequired is returned when an encrypted column is read, or a
+// backfill is started, on a repository created without WithEncryptor
+var ErrEncryptorRequired = errors.New("repository has no encryptor configured")
+
+// Option configures an AuthRepository
+type Option func(*AuthRepository)
+
+// WithEncryptor encrypts provider client secrets and OAuth tokens at rest.
+// Without it they are stored as given, which is only meant for tests.
+func WithEncryptor(encryptor *envelope.Encryptor) Option {
+	return func(r *AuthRepository) {
+		r.encryptor = encryptor
+	}
+}
+
+// sealSecret encrypts a value for the column identified by aad. Empty values stay empty.
+func (r *AuthRepository) sealSecret(ctx context.Context, value, aad string) (string, error) {
+	if r.encryptor == nil || value == "" {
+		return value, nil
+	}
+	sealed, err := r.encryptor.EncryptString(ctx, value, aad)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt %s: %w", aad, err)
+	}
+	return sealed, nil
+}
+
+// sealOptionalSecret is sealSecret for nullable columns; nil and empty values are stored as NULL
+func (r *AuthRepository) sealOptionalSecret(ctx context.Context, value *string, aad string) (interface{}, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+	return r.sealSecret(ctx, *value, aad)
+}
+
+// sealUserProviderTokens encrypts a link's tokens for insert or update
+func (r *AuthRepository) sealUserProviderTokens(ctx context.Context, link *models.UserProvider) (interface{}, interface{}, error) {
+	accessToken, err := r.sealOptionalSecret(ctx, link.AccessToken, aadAccessToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	refreshToken, err := r.sealOptionalSecret(ctx, link.RefreshToken, aadRefreshToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	return accessToken, refreshToken, nil
+}
+
+// openSecret decrypts a column value. Plaintext written before encryption was
+// enabled is returned as is until EncryptStoredSecrets has run.
+func (r *AuthRepository) openSecret(ctx context.Context, value, aad string) (string, error) {
+	if !envelope.IsEncrypted(value) {
+		return value, nil
+	}
+	if r.encryptor == nil {
+		return "", ErrEncryptorRequired
+	}
+	plaintext, err := r.encryptor.DecryptString(ctx, value, aad)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt %s: %w", aad, err)
+	}
+	return plaintext, nil
+}
+
+// openOptionalSecret is openSecret for nullable columns
+func (r *AuthRepository) openOptionalSecret(ctx context.Context, value *string, aad string) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	plaintext, err := r.openSecret(ctx, *value, aad)
+	if err != nil {
+		return nil, err
+	}
+	return &plaintext, nil
+}
+
+// openProvider decrypts a scanned provider's client secret
+func (r *AuthRepository) openProvider(ctx context.Context, provider *models.Provider) (*models.Provider, error) {
+	secret, err := r.openSecret(ctx, provider.ClientSecret, aadProviderClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	provider.ClientSecret = secret
+	return provider, nil
+}
+
+// openUserProvider decrypts a scanned link's tokens
+func (r *AuthRepository) openUserProvider(ctx context.Context, link *models.UserProvider) (*models.UserProvider, error) {
+	accessToken, err := r.openOptionalSecret(ctx, link.AccessToken, aadAccessToken)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := r.openOptionalSecret(ctx, link.RefreshToken, aadRefreshToken)
+	if err != nil {
+		return nil, err
+	}
+	link.AccessToken, link.RefreshToken = accessToken, refreshToken
+	return link, nil
+}
+
+// SecretBackfillStats counts the values visited by EncryptStoredSecrets
+type SecretBackfillStats struct {
+	Rows      int
+	Encrypted int
+	Rewrapped int
+}
+
+// EncryptStoredSecrets encrypts plaintext client secrets and OAuth tokens and
+// rewraps values whose master key is not the current one, batchSize rows at a
+// time. A row changed concurrently is left alone; it was written encrypted.
+func (r *AuthRepository) EncryptStoredSecrets(ctx context.Context, batchSize int) (SecretBackfillStats, error) {
+	var stats SecretBackfillStats
+	if r.encryptor == nil {
+		return stats, ErrEncryptorRequired
+	}
+
+	err := r.backfillColumns(ctx, &stats, batchSize, "providers", "client_secret")
+	if err != nil {
+		return stats, err
+	}
+	err = r.backfillColumns(ctx, &stats, batchSize, "user_providers", "access_token", "refresh_token")
+	return stats, err
+}
+
+// backfillColumns walks an sr_auth table by primary key, including soft
+// deleted rows. Values are bound to "table.column" like the aad constants.
+func (r *AuthRepository) backfillColumns(ctx context.Context, stats *SecretBackfillStats, batchSize int, table string, columns ...string) error {
+	aads := make([]string, len(columns))
+	for i, column := range columns {
+		aads[i] = table + "." + column
+	}
+	selectList := "id, " + strings.Join(columns, ", ")
+	table = "sr_auth." + table
+
+	lastID := 0
+	for {
+		rows, err := r.conn(ctx).QueryContext(ctx,
+			`SELECT `+selectList+` FROM `+table+` WHERE id > $1 ORDER BY id LIMIT $2`, lastID, batchSize)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", table, err)
+		}
+
+		type row struct {
+			id     int
+			values []sql.NullString
+		}
+		var batch []row
+		for rows.Next() {
+			item := row{values: make([]sql.NullString, len(columns))}
+			dest := []interface{}{&item.id}
+			for i := range item.values {
+				dest = append(dest, &item.values[i])
+			}
+			if err := rows.Scan(dest...); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan %s: %w", table, err)
+			}
+			batch = append(batch, item)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", table, err)
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		for _, item := range batch {
+			lastID = item.id
+			stats.Rows++
+			for i, value := range item.values {
+				if !value.Valid || value.String == "" {
+					continue
+				}
+				updated, encrypted, err := r.resealSecret(ctx, value.String, aads[i])
+				if err != nil {
+					return fmt.Errorf("failed to reseal %s of row %d: %w", aads[i], item.id, err)
+				}
+				if updated == value.String {
+					continue
+				}
+
+				result, err := r.conn(ctx).ExecContext(ctx,
+					`UPDATE `+table+` SET `+columns[i]+` = $3 WHERE id = $1 AND `+columns[i]+` = $2`,
+					item.id, value.String, updated)
+				if err != nil {
+					return fmt.Errorf("failed to update %s: %w", table, err)
+				}
+				if count, _ := result.RowsAffected(); count == 0 {
+					continue
+				}
+				if encrypted {
+					stats.Encrypted++
+				} else {
+					stats.Rewrapped++
+				}
+			}
+		}
+	}
+}
+
+// resealSecret encrypts a plaintext value or rewraps an encrypted one under
+// the current master key. encrypted reports which of the two happened.
+func (r *AuthRepository) resealSecret(ctx context.Context, value, aad string) (string, bool, error) {
+	if !envelope.IsEncrypted(value) {
+		sealed, err := r.sealSecret(ctx, value, aad)
+		return sealed, true, err
+	}
+	if !r.encryptor.NeedsRewrap(value) {
+		return value, false, nil
+	}
+	rewrapped, err := r.encryptor.Rewrap(ctx, value)
+	return rewrapped, false, err
+}
@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/your-project/services/auth/internal/models"
+)
+
+// CreateSession inserts a new session and populates its generated fields
+func (r *AuthRepository) CreateSession(ctx context.Context, session *models.Session) error {
+	deviceInfo, err := encodeJSON(session.DeviceInfo)
+	if err != nil {
+		return err
+	}
+	meta, err := encodeJSON(session.Meta)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn(ctx).QueryRowContext(ctx, `
+		INSERT INTO sr_auth.sessions (user_id, refresh_token_hash, device_info, ip_address, user_agent, expires_at, meta)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, uuid, is_active, last_used_at, created_at, updated_at`,
+		session.UserID, session.RefreshTokenHash, deviceInfo, nullableString(session.IPAddress),
+		nullableString(session.UserAgent), session.ExpiresAt, meta,
+	).Scan(&session.ID, &session.UUID, &session.IsActive, &session.LastUsedAt, &session.CreatedAt, &session.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return nil
+}
+
+const sessionColumns = `id, uuid, user_id, refresh_token_hash, device_info, host(ip_address), user_agent, is_active,
+	expires_at, last_used_at, revoked_at, meta, created_at, updated_at, deleted_at`
+
+// GetSessionByUUID returns a non-deleted session by UUID, including revoked and expired ones
+func (r *AuthRepository) GetSessionByUUID(ctx context.Context, uuid string) (*models.Session, error) {
+	row := r.conn(ctx).QueryRowContext(ctx,
+		`SELECT `+sessionColumns+` FROM sr_auth.sessions WHERE uuid = $1 AND deleted_at IS NULL`, uuid)
+	return scanSession(row)
+}
+
+// GetSessionByID returns a non-deleted session by primary key
+func (r *AuthRepository) GetSessionByID(ctx context.Context, id int) (*models.Session, error) {
+	row := r.conn(ctx).QueryRowContext(ctx,
+		`SELECT `+sessionColumns+` FROM sr_auth.sessions WHERE id = $1 AND deleted_at IS NULL`, id)
+	return scanSession(row)
+}
+
+// GetSessionByRefreshTokenHash returns the session whose current refresh token has the given hash
+func (r *AuthRepository) GetSessionByRefreshTokenHash(ctx context.Context, tokenHash string) (*models.Session, error) {
+	row := r.conn(ctx).QueryRowContext(ctx,
+		`SELECT `+sessionColumns+` FROM sr_auth.sessions WHERE refresh_token_hash = $1 AND deleted_at IS NULL`, tokenHash)
+	return scanSession(row)
+}
+
+// RotateRefreshToken swaps a live session's refresh token hash and retires the old one.
+// The update is conditional on the old hash, so of two concurrent rotations of the same
+// token exactly one succeeds; the other gets ErrNotFound.
+func (r *AuthRepository) RotateRefreshToken(ctx context.Context, sessionID int, oldHash, newHash string, usedAt time.Time) error {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE sr_auth.sessions SET refresh_token_hash = $3, last_used_at = $4
+		WHERE id = $1 AND refresh_token_hash = $2 AND is_active = true AND revoked_at IS NULL AND deleted_at IS NULL`,
+		sessionID, oldHash, newHash, usedAt)
+	if err != nil {
+		return fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO sr_auth.refresh_token_history (session_id, token_hash, rotated_at)
+		VALUES ($1, $2, $3)`,
+		sessionID, oldHash, usedAt); err != nil {
+		return fmt.Errorf("failed to retire refresh token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit refresh token rotation: %w", err)
+	}
+
+	return nil
+}
+
+// GetRefreshTokenHistory returns the retired refresh token with the given hash
+func (r *AuthRepository) GetRefreshTokenHistory(ctx context.Context, tokenHash string) (*models.RefreshTokenHistory, error) {
+	var (
+		entry     models.RefreshTokenHistory
+		meta      []byte
+		deletedAt sql.NullTime
+	)
+
+	err := r.conn(ctx).QueryRowContext(ctx, `
+		SELECT id, uuid, session_id, token_hash, rotated_at, meta, created_at, updated_at, deleted_at
+		FROM sr_auth.refresh_token_history WHERE token_hash = $1 AND deleted_at IS NULL`,
+		tokenHash,
+	).Scan(&entry.ID, &entry.UUID, &entry.SessionID, &entry.TokenHash, &entry.RotatedAt, &meta,
+		&entry.CreatedAt, &entry.UpdatedAt, &deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token history: %w", err)
+	}
+
+	if deletedAt.Valid {
+		entry.DeletedAt = &deletedAt.Time
+	}
+	if entry.Meta, err = decodeJSON(meta); err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// ListActiveSessions returns the user's unrevoked sessions that have neither passed
+// expires_at nor been idle since idleCutoff, most recently used first
+func (r *AuthRepository) ListActiveSessions(ctx context.Context, userID int, now, idleCutoff time.Time) ([]*models.Session, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, `
+		SELECT `+sessionColumns+` FROM sr_auth.sessions
+		WHERE user_id = $1 AND is_active = true AND revoked_at IS NULL AND deleted_at IS NULL
+			AND expires_at > $2 AND last_used_at > $3
+		ORDER BY last_used_at DESC`,
+		userID, now, idleCutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*models.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// TouchSession records activity on a session. Writes are skipped while the stored
+// last_used_at is newer than staleBefore, to keep hot sessions from updating every request.
+func (r *AuthRepository) TouchSession(ctx context.Context, sessionID int, usedAt, staleBefore time.Time) error {
+	_, err := r.conn(ctx).ExecContext(ctx, `
+		UPDATE sr_auth.sessions SET last_used_at = $2
+		WHERE id = $1 AND last_used_at < $3`,
+		sessionID, usedAt, staleBefore)
+	if err != nil {
+		return fmt.Errorf("failed to touch session: %w", err)
+	}
+	return nil
+}
+
+// RevokeSession deactivates one of the user's active sessions and records the reason in meta.
+// It returns ErrNotFound if the session does not exist, belongs to someone else or is already revoked.
+func (r *AuthRepository) RevokeSession(ctx context.Context, userID int, sessionUUID, reason string) (*models.Session, error) {
+	row := r.conn(ctx).QueryRowContext(ctx, `
+		UPDATE sr_auth.sessions
+		SET is_active = false, revoked_at = get_utc_timestamp(),
+			meta = COALESCE(meta, '{}'::jsonb) || jsonb_build_object('revoked_reason', $3::text)
+		WHERE user_id = $1 AND uuid = $2 AND revoked_at IS NULL AND deleted_at IS NULL
+		RETURNING `+sessionColumns,
+		userID, sessionUUID, reason)
+	return scanSession(row)
+}
+
+// RevokeUserSessions deactivates all of the user's unrevoked sessions except exceptSessionID
+// (pass 0 to revoke every session) and returns how many were revoked
+func (r *AuthRepository) RevokeUserSessions(ctx context.Context, userID, exceptSessionID int, reason string) (int64, error) {
+	result, err := r.conn(ctx).ExecContext(ctx, `
+		UPDATE sr_auth.sessions
+		SET is_active = false, revoked_at = get_utc_timestamp(),
+			meta = COALESCE(meta, '{}'::jsonb) || jsonb_build_object('revoked_reason', $3::text)
+		WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL AND deleted_at IS NULL`,
+		userID, exceptSessionID, reason)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return count, nil
+}
+
+func scanSession(row scanner) (*models.Session, error) {
+	var (
+		session    models.Session
+		deviceInfo []byte
+		ipAddress  sql.NullString
+		userAgent  sql.NullString
+		revokedAt  sql.NullTime
+		meta       []byte
+		deletedAt  sql.NullTime
+	)
+
+	err := row.Scan(&session.ID, &session.UUID, &session.UserID, &session.RefreshTokenHash, &deviceInfo, &ipAddress,
+		&userAgent, &session.IsActive, &session.ExpiresAt, &session.LastUsedAt, &revokedAt, &meta,
+		&session.CreatedAt, &session.UpdatedAt, &deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan session: %w", err)
+	}
+
+	if ipAddress.Valid {
+		session.IPAddress = &ipAddress.String
+	}
+	if userAgent.Valid {
+		session.UserAgent = &userAgent.String
+	}
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+	if deletedAt.Valid {
+		session.DeletedAt = &deletedAt.Time
+	}
+	if session.DeviceInfo, err = decodeJSON(deviceInfo); err != nil {
+		return nil, err
+	}
+	if session.Meta, err = decodeJSON(meta); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/your-project/services/auth/internal/models"
+)
+
+// Users reads and updates sr_auth.users and the account lifecycle
+type Users interface {
+	GetUserByID(ctx context.Context, id int) (*models.User, error)
+	GetUserByUUID(ctx context.Context, uuid string) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	MarkUserVerified(ctx context.Context, userID int, channel, destination string) (*models.User, error)
+	SetUserPassword(ctx context.Context, userID int, passwordHash string) (*models.User, error)
+	SetUserActive(ctx context.Context, userID int, active bool) (*models.User, error)
+	SoftDeleteUser(ctx context.Context, userID int, purgeAfter time.Time) (*models.User, error)
+	RestoreUser(ctx context.Context, userID int) (*models.User, error)
+	PurgeDeletedUsers(ctx context.Context, now time.Time, batchSize int, anonymize bool) ([]string, error)
+}
+
+// ContactChanges stores confirmed and undoable email and phone changes
+type ContactChanges interface {
+	CreateContactChange(ctx context.Context, change *models.ContactChange) error
+	IsContactInUse(ctx context.Context, channel, value string, exceptUserID int) (bool, error)
+	ConfirmContactChange(ctx context.Context, confirmTokenHash string, undoTokenHash *string, undoExpiresAt time.Time) (*models.ContactChange, *models.User, error)
+	UndoContactChange(ctx context.Context, undoTokenHash string) (*models.ContactChange, *models.User, error)
+}
+
+// Sessions stores sessions and their retired refresh tokens
+type Sessions interface {
+	CreateSession(ctx context.Context, session *models.Session) error
+	GetSessionByUUID(ctx context.Context, uuid string) (*models.Session, error)
+	GetSessionByID(ctx context.Context, id int) (*models.Session, error)
+	GetSessionByRefreshTokenHash(ctx context.Context, tokenHash string) (*models.Session, error)
+	RotateRefreshToken(ctx context.Context, sessionID int, oldHash, newHash string, usedAt time.Time) error
+	GetRefreshTokenHistory(ctx context.Context, tokenHash string) (*models.RefreshTokenHistory, error)
+	ListActiveSessions(ctx context.Context, userID int, now, idleCutoff time.Time) ([]*models.Session, error)
+	TouchSession(ctx context.Context, sessionID int, usedAt, staleBefore time.Time) error
+	RevokeSession(ctx context.Context, userID int, sessionUUID, reason string) (*models.Session, error)
+	RevokeUserSessions(ctx context.Context, userID, exceptSessionID int, reason string) (int64, error)
+}
+
+// OTPs stores one-time verification codes
+type OTPs interface {
+	CreateOTP(ctx context.Context, otp *models.OTPToken) error
+	OTPSendStats(ctx context.Context, userID int, useCase string, since time.Time) (int, time.Time, error)
+	ConsumeOTP(ctx context.Context, userID int, useCase, codeHash string, maxAttempts int) (*models.OTPToken, error)
+	TakeOTPAttempt(ctx context.Context, userID int, useCase, codeHash string, maxAttempts int) (*models.OTPToken, error)
+	MarkOTPUsed(ctx context.Context, id int) (bool, error)
+}
+
+// ActionTokens stores single-use tokens sent in links (magic links, resets, restores)
+type ActionTokens interface {
+	CreateActionToken(ctx context.Context, token *models.ActionToken) error
+	ConsumeActionToken(ctx context.Context, tokenHash, purpose string) (*models.ActionToken, error)
+}
+
+// Devices stores the devices users sign in from
+type Devices interface {
+	UpsertDevice(ctx context.Context, device *models.Device) (bool, error)
+	GetDeviceByDeviceID(ctx context.Context, userID int, deviceID string) (*models.Device, error)
+	GetDeviceByUUID(ctx context.Context, userID int, uuid string) (*models.Device, error)
+	GetDeviceIDByUUID(ctx context.Context, userID int, uuid string) (int, error)
+	ListDevices(ctx context.Context, userID int) ([]*models.Device, error)
+	SetDeviceTrust(ctx context.Context, userID int, uuid string, trustedAt *time.Time) (*models.Device, error)
+	DeleteDevice(ctx context.Context, userID int, uuid string) error
+}
+
+// Providers stores the identity provider catalog and pending authorization states
+type Providers interface {
+	GetProviderByName(ctx context.Context, name string) (*models.Provider, error)
+	GetProviderByID(ctx context.Context, id int) (*models.Provider, error)
+	GetProviderByUUID(ctx context.Context, uuid string) (*models.Provider, error)
+	ListProviders(ctx context.Context) ([]*models.Provider, error)
+	CreateProvider(ctx context.Context, provider *models.Provider) error
+	UpdateProvider(ctx context.Context, provider *models.Provider) error
+	SetProviderEnabled(ctx context.Context, id int, enabled bool) (*models.Provider, error)
+	ProvidersVersion(ctx context.Context) (string, error)
+	CreateOAuthState(ctx context.Context, state *models.OAuthState) error
+	ConsumeOAuthState(ctx context.Context, stateHash string) (*models.OAuthState, error)
+}
+
+// UserProviders stores users' links to provider accounts and their tokens
+type UserProviders interface {
+	GetUserProviderByIdentity(ctx context.Context, providerID int, providerUserID string) (*models.UserProvider, error)
+	GetUserProviderByID(ctx context.Context, id int) (*models.UserProvider, error)
+	GetUserProviderByUUID(ctx context.Context, userID int, uuid string) (*models.UserProvider, error)
+	ListUserProviders(ctx context.Context, userID int) ([]*models.UserProvider, error)
+	CreateOAuthUser(ctx context.Context, user *models.User, link *models.UserProvider) error
+	LinkUserProvider(ctx context.Context, link *models.UserProvider) error
+	UnlinkUserProvider(ctx context.Context, userID int, uuid string) (*models.UserProvider, error)
+	SetPrimaryUserProvider(ctx context.Context, userID int, uuid string) (*models.UserProvider, error)
+	RecordUserProviderLogin(ctx context.Context, link *models.UserProvider, usedAt time.Time) error
+	ListUserProvidersDueForRefresh(ctx context.Context, dueBy, now time.Time, limit int) ([]*models.UserProvider, error)
+	TryLockUserProviderRefresh(ctx context.Context, linkID int) (unlock func(), ok bool, err error)
+	StoreRefreshedUserProviderTokens(ctx context.Context, link *models.UserProvider) error
+	RecordUserProviderRefreshFailure(ctx context.Context, linkID, failures int, retryAt time.Time, reason string) error
+	DeactivateUserProvider(ctx context.Context, linkID int, reason string) error
+}
+
+// LoginLogs stores the authentication audit trail
+type LoginLogs interface {
+	CreateLoginLog(ctx context.Context, entry *models.LoginLog) error
+	ListLoginLogs(ctx context.Context, filter LoginLogFilter) ([]*models.LoginLog, error)
+	ArchiveLoginLogs(ctx context.Context, cutoff time.Time, batchSize int) (int64, error)
+	CountLoginFailures(ctx context.Context, userID int, eventTypes []string, since time.Time) (int, error)
+	ListKnownIPs(ctx context.Context, userID int, since time.Time, limit int) ([]string, error)
+}
+
+// MFA stores TOTP enrollments and recovery codes
+type MFA interface {
+	GetTOTPCredential(ctx context.Context, userID int) (*models.TOTPCredential, error)
+	CreatePendingTOTPCredential(ctx context.Context, userID int, secret string) (*models.TOTPCredential, error)
+	ConfirmTOTPCredential(ctx context.Context, credentialID int, step int64, recoveryCodeHashes []string) error
+	AdvanceTOTPStep(ctx context.Context, credentialID int, step int64) (bool, error)
+	DeleteTOTPCredential(ctx context.Context, userID int) error
+	ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error
+	ConsumeRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+	CountUnusedRecoveryCodes(ctx context.Context, userID int) (int, error)
+}
+
+// WebAuthn stores passkey credentials and ceremony challenges
+type WebAuthn interface {
+	CreateWebAuthnChallenge(ctx context.Context, challenge *models.WebAuthnChallenge) error
+	ConsumeWebAuthnChallenge(ctx context.Context, uuid, ceremony string) (*models.WebAuthnChallenge, error)
+	CreateWebAuthnCredential(ctx context.Context, credential *models.WebAuthnCredential) error
+	GetWebAuthnCredentialByCredentialID(ctx context.Context, credentialID []byte) (*models.WebAuthnCredential, error)
+	ListWebAuthnCredentials(ctx context.Context, userID int) ([]*models.WebAuthnCredential, error)
+	UpdateWebAuthnCredentialUsage(ctx context.Context, id int, expectedSignCount, signCount uint32, backupState bool) (bool, error)
+	MarkWebAuthnCredentialCloned(ctx context.Context, id int) error
+	DeleteWebAuthnCredential(ctx context.Context, userID int, uuid string) error
+}
+
+// APIKeys stores personal and service account API keys
+type APIKeys interface {
+	CreateAPIKey(ctx context.Context, key *models.APIKey) error
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	GetOwnedAPIKey(ctx context.Context, uuid string, userID, oauthClientID *int) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context, userID, oauthClientID *int, expiredAfter time.Time) ([]*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID int, reason string) (*models.APIKey, error)
+	RotateAPIKey(ctx context.Context, oldKeyID int, replacement *models.APIKey, retireAt time.Time) (*models.APIKey, error)
+	TouchAPIKey(ctx context.Context, keyID int, ipAddress string, usedAt, staleBefore time.Time) error
+}
+
+// OAuthClients stores the clients of the authorization server and their authorization requests
+type OAuthClients interface {
+	GetOAuthClientByClientID(ctx context.Context, clientID string) (*models.OAuthClient, error)
+	GetOAuthClientByID(ctx context.Context, id int) (*models.OAuthClient, error)
+	GetOAuthClientByUUID(ctx context.Context, uuid string) (*models.OAuthClient, error)
+	ListOAuthClients(ctx context.Context) ([]*models.OAuthClient, error)
+	CreateOAuthClient(ctx context.Context, client *models.OAuthClient) error
+	SetOAuthClientActive(ctx context.Context, uuid string, active bool) (*models.OAuthClient, error)
+	CreateOAuthAuthorization(ctx context.Context, authz *models.OAuthAuthorization) error
+	GetPendingOAuthAuthorization(ctx context.Context, uuid string) (*models.OAuthAuthorization, error)
+	ApproveOAuthAuthorization(ctx context.Context, id, userID, sessionID int, codeHash string, codeExpiresAt time.Time) error
+	DenyOAuthAuthorization(ctx context.Context, id int) error
+	ConsumeOAuthAuthorizationCode(ctx context.Context, codeHash string) (*models.OAuthAuthorization, error)
+	GetOAuthAuthorizationByCodeHash(ctx context.Context, codeHash string) (*models.OAuthAuthorization, error)
+	SetOAuthAuthorizationTokenSession(ctx context.Context, id, sessionID int) error
+}
+
+// Maintenance removes rows that are no longer needed once their retention is over
+type Maintenance interface {
+	TryLockMaintenance(ctx context.Context) (unlock func(), ok bool, err error)
+	PruneExpiredRows(ctx context.Context, table string, cutoff time.Time, batchSize int) (int64, error)
+}
+
+// Transactor runs multi-table operations all-or-nothing
+type Transactor interface {
+	// WithTx runs fn in a transaction that commits if fn returns nil. Calls on
+	// tx, and calls on the Store made with the ctx passed to fn, are part of
+	// it. Nested calls run in savepoints. fn may be run more than once.
+	WithTx(ctx context.Context, fn func(ctx context.Context, tx Store) error) error
+}
+
+// Store is every aggregate the business layer works with. AuthRepository
+// implements it on Postgres; the memory package implements it in process for tests.
+type Store interface {
+	Transactor
+	Users
+	ContactChanges
+	Sessions
+	OTPs
+	ActionTokens
+	Devices
+	Providers
+	UserProviders
+	LoginLogs
+	MFA
+	WebAuthn
+	APIKeys
+	OAuthClients
+	Maintenance
+}
+
+var _ Store = (*AuthRepository)(nil)
@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Retry policy for transactions that lose a serialization conflict or a deadlock
+const (
+	maxTxAttempts  = 3
+	txRetryBackoff = 20 * time.Millisecond
+)
+
+// querier is implemented by *sql.DB, *sql.Tx and *localTx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// txKey is the context key under which WithTx stores the open transaction
+type txKey struct{}
+
+// txState is a transaction opened by WithTx and shared by everything running inside it
+type txState struct {
+	db         *sql.DB
+	tx         *sql.Tx
+	savepoints int
+}
+
+// WithTx runs fn in a transaction and commits it if fn returns nil. tx is bound
+// to the transaction, and so is every call on this repository made with the
+// ctx passed to fn, which lets helpers that hold the repository join it. A
+// nested WithTx runs in a savepoint, so its failure only undoes its own work.
+// The outermost call retries fn on serialization failures and deadlocks, so fn
+// must not have side effects outside the repository. Neither tx nor ctx may be
+// used concurrently or after fn returns.
+func (r *AuthRepository) WithTx(ctx context.Context, fn func(ctx context.Context, tx Store) error) error {
+	if state := r.txState(ctx); state != nil {
+		return r.withSavepoint(ctx, state, fn)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := r.runTx(ctx, fn)
+		if err == nil || !isRetryableTxError(err) || attempt == maxTxAttempts {
+			return err
+		}
+
+		timer := time.NewTimer(time.Duration(attempt) * txRetryBackoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// runTx makes one attempt at running fn in a new transaction
+func (r *AuthRepository) runTx(ctx context.Context, fn func(ctx context.Context, tx Store) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := r.callInTx(ctx, &txState{db: r.db, tx: tx}, fn); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// withSavepoint runs a nested WithTx callback in a savepoint of state
+func (r *AuthRepository) withSavepoint(ctx context.Context, state *txState, fn func(ctx context.Context, tx Store) error) error {
+	savepoint, err := state.savepoint(ctx)
+	if err != nil {
+		return err
+	}
+	defer savepoint.Rollback()
+
+	if err := r.callInTx(ctx, state, fn); err != nil {
+		return err
+	}
+	return savepoint.Commit()
+}
+
+// callInTx calls fn with a copy of the repository and a context bound to state
+func (r *AuthRepository) callInTx(ctx context.Context, state *txState, fn func(ctx context.Context, tx Store) error) error {
+	bound := *r
+	bound.tx = state
+	return fn(context.WithValue(ctx, txKey{}, state), &bound)
+}
+
+// txState returns the WithTx transaction the repository or ctx is bound to, or nil
+func (r *AuthRepository) txState(ctx context.Context) *txState {
+	if r.tx != nil {
+		return r.tx
+	}
+	if state, ok := ctx.Value(txKey{}).(*txState); ok && state.db == r.db {
+		return state
+	}
+	return nil
+}
+
+// conn returns the surrounding WithTx transaction, or the connection pool outside one
+func (r *AuthRepository) conn(ctx context.Context) querier {
+	if state := r.txState(ctx); state != nil {
+		return state.tx
+	}
+	return r.db
+}
+
+// localTx is the transaction a single repository method opens for its own
+// statements. Inside WithTx it is a savepoint of the surrounding transaction.
+type localTx struct {
+	querier
+	commit   func() error
+	rollback func() error
+	done     bool
+}
+
+// begin opens a transaction for one repository method
+func (r *AuthRepository) begin(ctx context.Context) (*localTx, error) {
+	if state := r.txState(ctx); state != nil {
+		return state.savepoint(ctx)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &localTx{querier: tx, commit: tx.Commit, rollback: tx.Rollback}, nil
+}
+
+// Commit commits the transaction or releases the savepoint
+func (t *localTx) Commit() error {
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	return t.commit()
+}
+
+// Rollback undoes the work of the transaction or savepoint. It returns
+// sql.ErrTxDone after Commit, so it can be deferred like (*sql.Tx).Rollback.
+func (t *localTx) Rollback() error {
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	return t.rollback()
+}
+
+// savepoint starts a savepoint in the transaction
+func (s *txState) savepoint(ctx context.Context) (*localTx, error) {
+	s.savepoints++
+	name := fmt.Sprintf("sp_%d", s.savepoints)
+	if _, err := s.tx.ExecContext(ctx, `SAVEPOINT `+name); err != nil {
+		return nil, fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	return &localTx{
+		querier: s.tx,
+		commit: func() error {
+			if _, err := s.tx.ExecContext(ctx, `RELEASE SAVEPOINT `+name); err != nil {
+				return fmt.Errorf("failed to release savepoint: %w", err)
+			}
+			return nil
+		},
+		rollback: func() error {
+			_, err := s.tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT `+name)
+			return err
+		},
+	}, nil
+}
+
+// isolate runs fn in a savepoint when ctx is inside WithTx, so a failing
+// best-effort write leaves the surrounding transaction usable. Outside a
+// transaction fn runs directly on the pool.
+func (r *AuthRepository) isolate(ctx context.Context, fn func(q querier) error) error {
+	if r.txState(ctx) == nil {
+		return fn(r.db)
+	}
+
+	savepoint, err := r.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer savepoint.Rollback()
+
+	if err := fn(savepoint); err != nil {
+		return err
+	}
+	return savepoint.Commit()
+}
+
+// isRetryableTxError reports whether err is a Postgres serialization_failure
+// (40001) or deadlock_detected (40P01), after which the transaction can be retried
+func isRetryableTxError(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && (pqErr.Code == "40001" || pqErr.Code == "40P01")
+}
@@ -0,0 +1,402 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/your-project/services/auth/internal/models"
+)
+
+const userProviderColumns = `id, uuid, user_id, provider_id, provider_user_id, access_token, refresh_token, token_type,
+	expires_at, profile_data, is_primary, is_active, last_used_at, meta, created_at, updated_at, deleted_at`
+
+// GetUserProviderByIdentity returns the link for an account at a provider, active or not
+func (r *AuthRepository) GetUserProviderByIdentity(ctx context.Context, providerID int, providerUserID string) (*models.UserProvider, error) {
+	row := r.conn(ctx).QueryRowContext(ctx, `
+		SELECT `+userProviderColumns+` FROM sr_auth.user_providers
+		WHERE provider_id = $1 AND provider_user_id = $2 AND deleted_at IS NULL`,
+		providerID, providerUserID)
+	return r.scanUserProvider(ctx, row)
+}
+
+// RecordUserProviderLogin stores the tokens and profile from a sign-in through the
+// link, reactivating it if it had been marked inactive. link.Meta is merged into
+// the stored meta and the token refresher's backoff state is cleared.
+func (r *AuthRepository) RecordUserProviderLogin(ctx context.Context, link *models.UserProvider, usedAt time.Time) error {
+	profile, err := encodeJSON(link.ProfileData)
+	if err != nil {
+		return err
+	}
+	meta, err := encodeJSON(link.Meta)
+	if err != nil {
+		return err
+	}
+	accessToken, refreshToken, err := r.sealUserProviderTokens(ctx, link)
+	if err != nil {
+		return err
+	}
+
+	row := r.conn(ctx).QueryRowContext(ctx, `
+		UPDATE sr_auth.user_providers
+		SET access_token = $2, refresh_token = COALESCE($3, refresh_token), token_type = $4, expires_at = $5,
+			profile_data = $6, last_used_at = $7, is_active = true,
+			meta = (meta - ARRAY[`+refreshStateKeys+`, 'deactivated_reason']) || $8
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING `+userProviderColumns,
+		link.ID, accessToken, refreshToken, nullableString(link.TokenType), link.ExpiresAt, profile, usedAt, meta)
+	stored, err := r.scanUserProvider(ctx, row)
+	if err != nil {
+		return err
+	}
+
+	*link = *stored
+	return nil
+}
+
+// CreateOAuthUser creates a user without a password together with its first,
+// primary provider link. It returns ErrDuplicate if the email or provider
+// identity is already taken.
+func (r *AuthRepository) CreateOAuthUser(ctx context.Context, user *models.User, link *models.UserProvider) error {
+	userMeta, err := encodeJSON(user.Meta)
+	if err != nil {
+		return err
+	}
+	profile, err := encodeJSON(link.ProfileData)
+	if err != nil {
+		return err
+	}
+	linkMeta, err := encodeJSON(link.Meta)
+	if err != nil {
+		return err
+	}
+	accessToken, refreshToken, err := r.sealUserProviderTokens(ctx, link)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRowContext(ctx, `
+		INSERT INTO sr_auth.users (email, password_hash, is_verified, email_verified_at, auth_method, primary_provider_id, meta)
+		VALUES ($1, NULL, $2, CASE WHEN $2 THEN get_utc_timestamp() END, $3, $4, $5)
+		RETURNING `+userColumns,
+		user.Email, user.IsVerified, string(models.AuthMethodOAuth), link.ProviderID, userMeta)
+	stored, err := scanUser(row)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	row = tx.QueryRowContext(ctx, `
+		INSERT INTO sr_auth.user_providers (user_id, provider_id, provider_user_id, access_token, refresh_token,
+			token_type, expires_at, profile_data, is_primary, last_used_at, meta)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, true, get_utc_timestamp(), $9)
+		RETURNING `+userProviderColumns,
+		stored.ID, link.ProviderID, link.ProviderUserID, accessToken, refreshToken, nullableString(link.TokenType),
+		link.ExpiresAt, profile, linkMeta)
+	storedLink, err := r.scanUserProvider(ctx, row)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("failed to create user provider: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit oauth user: %w", err)
+	}
+
+	*user = *stored
+	*link = *storedLink
+	return nil
+}
+
+func (r *AuthRepository) scanUserProvider(ctx context.Context, row scanner) (*models.UserProvider, error) {
+	var (
+		link         models.UserProvider
+		accessToken  sql.NullString
+		refreshToken sql.NullString
+		tokenType    sql.NullString
+		expiresAt    sql.NullTime
+		profile      []byte
+		lastUsedAt   sql.NullTime
+		meta         []byte
+		deletedAt    sql.NullTime
+	)
+
+	err := row.Scan(&link.ID, &link.UUID, &link.UserID, &link.ProviderID, &link.ProviderUserID, &accessToken,
+		&refreshToken, &tokenType, &expiresAt, &profile, &link.IsPrimary, &link.IsActive, &lastUsedAt, &meta,
+		&link.CreatedAt, &link.UpdatedAt, &deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan user provider: %w", err)
+	}
+
+	if accessToken.Valid {
+		link.AccessToken = &accessToken.String
+	}
+	if refreshToken.Valid {
+		link.RefreshToken = &refreshToken.String
+	}
+	if tokenType.Valid {
+		link.TokenType = &tokenType.String
+	}
+	if expiresAt.Valid {
+		link.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		link.LastUsedAt = &lastUsedAt.Time
+	}
+	if deletedAt.Valid {
+		link.DeletedAt = &deletedAt.Time
+	}
+	if link.ProfileData, err = decodeJSON(profile); err != nil {
+		return nil, err
+	}
+	if link.Meta, err = decodeJSON(meta); err != nil {
+		return nil, err
+	}
+
+	return r.openUserProvider(ctx, &link)
+}
+
+// GetUserProviderByUUID returns one of a user's provider links
+func (r *AuthRepository) GetUserProviderByUUID(ctx context.Context, userID int, uuid string) (*models.UserProvider, error) {
+	row := r.conn(ctx).QueryRowContext(ctx, `
+		SELECT `+userProviderColumns+` FROM sr_auth.user_providers
+		WHERE user_id = $1 AND uuid = $2 AND deleted_at IS NULL`,
+		userID, uuid)
+	return r.scanUserProvider(ctx, row)
+}
+
+// ListUserProviders returns a user's provider links, primary first
+func (r *AuthRepository) ListUserProviders(ctx context.Context, userID int) ([]*models.UserProvider, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, `
+		SELECT `+userProviderColumns+` FROM sr_auth.user_providers
+		WHERE user_id = $1 AND deleted_at IS NULL
+		ORDER BY is_primary DESC, created_at`,
+		userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user providers: %w", err)
+	}
+	defer rows.Close()
+
+	var links []*models.UserProvider
+	for rows.Next() {
+		link, err := r.scanUserProvider(ctx, rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list user providers: %w", err)
+	}
+	return links, nil
+}
+
+// LinkUserProvider adds a provider link to an existing user. The first link
+// becomes primary. It returns ErrDuplicate if the provider account is already
+// linked to any user.
+func (r *AuthRepository) LinkUserProvider(ctx context.Context, link *models.UserProvider) error {
+	profile, err := encodeJSON(link.ProfileData)
+	if err != nil {
+		return err
+	}
+	meta, err := encodeJSON(link.Meta)
+	if err != nil {
+		return err
+	}
+	accessToken, refreshToken, err := r.sealUserProviderTokens(ctx, link)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockUser(ctx, tx, link.UserID); err != nil {
+		return err
+	}
+
+	row := tx.QueryRowContext(ctx, `
+		INSERT INTO sr_auth.user_providers (user_id, provider_id, provider_user_id, access_token, refresh_token,
+			token_type, expires_at, profile_data, is_primary, meta)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8,
+			NOT EXISTS (SELECT 1 FROM sr_auth.user_providers WHERE user_id = $1 AND deleted_at IS NULL), $9)
+		RETURNING `+userProviderColumns,
+		link.UserID, link.ProviderID, link.ProviderUserID, accessToken, refreshToken, nullableString(link.TokenType),
+		link.ExpiresAt, profile, meta)
+	stored, err := r.scanUserProvider(ctx, row)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("failed to link user provider: %w", err)
+	}
+
+	if err := syncUserAuthMethod(ctx, tx, link.UserID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit provider link: %w", err)
+	}
+
+	*link = *stored
+	return nil
+}
+
+// UnlinkUserProvider soft deletes a provider link. If it was primary, the most
+// recently used remaining link is promoted. It returns ErrLastLoginMethod when
+// the user has no password, passkey or other link to sign in with.
+func (r *AuthRepository) UnlinkUserProvider(ctx context.Context, userID int, uuid string) (*models.UserProvider, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockUser(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	var hasOtherMethod bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM sr_auth.users WHERE id = $1 AND password_hash IS NOT NULL)
+			OR EXISTS (SELECT 1 FROM sr_auth.webauthn_credentials WHERE user_id = $1 AND is_active = true AND deleted_at IS NULL)
+			OR EXISTS (SELECT 1 FROM sr_auth.user_providers WHERE user_id = $1 AND uuid <> $2 AND deleted_at IS NULL)`,
+		userID, uuid).Scan(&hasOtherMethod)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count sign-in methods: %w", err)
+	}
+
+	row := tx.QueryRowContext(ctx, `
+		UPDATE sr_auth.user_providers
+		SET deleted_at = get_utc_timestamp(), is_active = false, is_primary = false
+		WHERE user_id = $1 AND uuid = $2 AND deleted_at IS NULL
+		RETURNING `+userProviderColumns,
+		userID, uuid)
+	link, err := r.scanUserProvider(ctx, row)
+	if err != nil {
+		return nil, err
+	}
+	if !hasOtherMethod {
+		return nil, ErrLastLoginMethod
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE sr_auth.user_providers SET is_primary = true
+		WHERE id = (
+			SELECT id FROM sr_auth.user_providers
+			WHERE user_id = $1 AND deleted_at IS NULL
+			ORDER BY last_used_at DESC NULLS LAST, created_at DESC
+			LIMIT 1
+		) AND NOT EXISTS (
+			SELECT 1 FROM sr_auth.user_providers WHERE user_id = $1 AND is_primary AND deleted_at IS NULL
+		)`,
+		userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to promote primary provider: %w", err)
+	}
+
+	if err := syncUserAuthMethod(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit provider unlink: %w", err)
+	}
+	return link, nil
+}
+
+// SetPrimaryUserProvider makes one of a user's links the primary one
+func (r *AuthRepository) SetPrimaryUserProvider(ctx context.Context, userID int, uuid string) (*models.UserProvider, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockUser(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	row := tx.QueryRowContext(ctx, `
+		UPDATE sr_auth.user_providers SET is_primary = true
+		WHERE user_id = $1 AND uuid = $2 AND deleted_at IS NULL
+		RETURNING `+userProviderColumns,
+		userID, uuid)
+	link, err := r.scanUserProvider(ctx, row)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE sr_auth.user_providers SET is_primary = false
+		WHERE user_id = $1 AND id <> $2 AND is_primary AND deleted_at IS NULL`,
+		userID, link.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clear primary provider: %w", err)
+	}
+
+	if err := syncUserAuthMethod(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit primary provider: %w", err)
+	}
+	return link, nil
+}
+
+// lockUser serializes changes to a user's sign-in methods
+func lockUser(ctx context.Context, tx querier, userID int) error {
+	var id int
+	err := tx.QueryRowContext(ctx,
+		`SELECT id FROM sr_auth.users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, userID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock user: %w", err)
+	}
+	return nil
+}
+
+// syncUserAuthMethod derives users.auth_method and primary_provider_id from the
+// password hash and the user's remaining provider links
+func syncUserAuthMethod(ctx context.Context, tx querier, userID int) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE sr_auth.users u
+		SET auth_method = (CASE
+				WHEN u.password_hash IS NOT NULL AND l.link_count > 0 THEN 'both'
+				WHEN u.password_hash IS NOT NULL THEN 'password'
+				WHEN l.link_count > 0 THEN 'oauth'
+				ELSE 'passkey'
+			END)::sr_auth.auth_method,
+			primary_provider_id = l.primary_provider_id
+		FROM (
+			SELECT count(*) AS link_count,
+				(array_agg(provider_id) FILTER (WHERE is_primary))[1] AS primary_provider_id
+			FROM sr_auth.user_providers
+			WHERE user_id = $1 AND deleted_at IS NULL
+		) l
+		WHERE u.id = $1`,
+		userID)
+	if err != nil {
+		return fmt.Errorf("failed to sync auth method: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/your-project/services/auth/internal/models"
+)
+
+const userColumns = `id, uuid, email, phone, password_hash, is_active, is_verified, email_verified_at,
+	phone_verified_at, auth_method, primary_provider_id, deactivated_at, purge_after, meta, created_at, updated_at,
+	deleted_at`
+
+// GetUserByID returns a non-deleted user by primary key
+func (r *AuthRepository) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+	row := r.conn(ctx).QueryRowContext(ctx,
+		`SELECT `+userColumns+` FROM sr_auth.users WHERE id = $1 AND deleted_at IS NULL`, id)
+	return scanUser(row)
+}
+
+// GetUserByUUID returns a non-deleted user by UUID
+func (r *AuthRepository) GetUserByUUID(ctx context.Context, uuid string) (*models.User, error) {
+	row := r.conn(ctx).QueryRowContext(ctx,
+		`SELECT `+userColumns+` FROM sr_auth.users WHERE uuid = $1 AND deleted_at IS NULL`, uuid)
+	return scanUser(row)
+}
+
+// GetUserByEmail returns a non-deleted user by email (case-insensitive)
+func (r *AuthRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	row := r.conn(ctx).QueryRowContext(ctx,
+		`SELECT `+userColumns+` FROM sr_auth.users WHERE LOWER(email) = LOWER($1) AND deleted_at IS NULL`, email)
+	return scanUser(row)
+}
+
+// MarkUserVerified records that the user proved control of destination on
+// channel ("email" or "phone") and sets is_verified. It returns ErrNotFound if
+// the user's address on that channel is no longer destination.
+func (r *AuthRepository) MarkUserVerified(ctx context.Context, userID int, channel, destination string) (*models.User, error) {
+	var column, match string
+	switch channel {
+	case models.VerificationChannelEmail:
+		column, match = "email_verified_at", "LOWER(email) = LOWER($2)"
+	case models.VerificationChannelPhone:
+		column, match = "phone_verified_at", "phone = $2"
+	default:
+		return nil, fmt.Errorf("unknown verification channel %q", channel)
+	}
+
+	row := r.conn(ctx).QueryRowContext(ctx, `
+		UPDATE sr_auth.users SET `+column+` = get_utc_timestamp(), is_verified = true
+		WHERE id = $1 AND `+match+` AND deleted_at IS NULL
+		RETURNING `+userColumns,
+		userID, destination)
+	return scanUser(row)
+}
+
+// SetUserPassword stores a new bcrypt hash and re-derives auth_method, so an
+// OAuth-only user who adds a password becomes "both"
+func (r *AuthRepository) SetUserPassword(ctx context.Context, userID int, passwordHash string) (*models.User, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockUser(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE sr_auth.users SET password_hash = $2 WHERE id = $1`, userID, passwordHash); err != nil {
+		return nil, fmt.Errorf("failed to set password: %w", err)
+	}
+	if err := syncUserAuthMethod(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	user, err := scanUser(tx.QueryRowContext(ctx,
+		`SELECT `+userColumns+` FROM sr_auth.users WHERE id = $1`, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit password change: %w", err)
+	}
+	return user, nil
+}
+
+func scanUser(row scanner) (*models.User, error) {
+	var (
+		user              models.User
+		phone             sql.NullString
+		passwordHash      sql.NullString
+		emailVerifiedAt   sql.NullTime
+		phoneVerifiedAt   sql.NullTime
+		primaryProviderID sql.NullInt64
+		deactivatedAt     sql.NullTime
+		purgeAfter        sql.NullTime
+		meta              []byte
+		deletedAt         sql.NullTime
+		authMethod        string
+	)
+
+	err := row.Scan(&user.ID, &user.UUID, &user.Email, &phone, &passwordHash, &user.IsActive,
+		&user.IsVerified, &emailVerifiedAt, &phoneVerifiedAt, &authMethod, &primaryProviderID, &deactivatedAt, &purgeAfter, &meta, &user.CreatedAt, &user.UpdatedAt, &deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan user: %w", err)
+	}
+
+	user.AuthMethod = models.AuthMethod(authMethod)
+	user.PasswordHash = passwordHash.String
+	if phone.Valid {
+		user.Phone = &phone.String
+	}
+	if emailVerifiedAt.Valid {
+		user.EmailVerifiedAt = &emailVerifiedAt.Time
+	}
+	if phoneVerifiedAt.Valid {
+		user.PhoneVerifiedAt = &phoneVerifiedAt.Time
+	}
+	if primaryProviderID.Valid {
+		id := int(primaryProviderID.Int64)
+		user.PrimaryProviderID = &id
+	}
+	if deactivatedAt.Valid {
+		user.DeactivatedAt = &deactivatedAt.Time
+	}
+	if purgeAfter.Valid {
+		user.PurgeAfter = &purgeAfter.Time
+	}
+	if deletedAt.Valid {
+		user.DeletedAt = &deletedAt.Time
+	}
+	if user.Meta, err = decodeJSON(meta); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/your-project/services/auth/internal/models"
+)
+
+const webAuthnCredentialColumns = `id, uuid, user_id, device_id, credential_id, public_key, algorithm, sign_count,
+	aaguid, attestation_format, attestation_type, name, backup_eligible, backup_state, is_active,
+	clone_detected_at, last_used_at, meta, created_at, updated_at`
+
+// CreateWebAuthnChallenge stores ceremony state for a later Finish call
+func (r *AuthRepository) CreateWebAuthnChallenge(ctx context.Context, challenge *models.WebAuthnChallenge) error {
+	meta, err := encodeJSON(challenge.Meta)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn(ctx).QueryRowContext(ctx, `
+		INSERT INTO sr_auth.webauthn_challenges (user_id, ceremony, challenge, expires_at, meta)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, uuid, created_at, updated_at`,
+		challenge.UserID, challenge.Ceremony, challenge.Challenge, challenge.ExpiresAt, meta,
+	).Scan(&challenge.ID, &challenge.UUID, &challenge.CreatedAt, &challenge.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webauthn challenge: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumeWebAuthnChallenge atomically marks an unexpired challenge as used and returns it.
+// A challenge can only be consumed once.
+func (r *AuthRepository) ConsumeWebAuthnChallenge(ctx context.Context, uuid, ceremony string) (*models.WebAuthnChallenge, error) {
+	var (
+		challenge models.WebAuthnChallenge
+		userID    sql.NullInt64
+		usedAt    sql.NullTime
+		meta      []byte
+	)
+
+	err := r.conn(ctx).QueryRowContext(ctx, `
+		UPDATE sr_auth.webauthn_challenges SET used_at = get_utc_timestamp()
+		WHERE uuid = $1 AND ceremony = $2 AND used_at IS NULL AND deleted_at IS NULL
+			AND expires_at > get_utc_timestamp()
+		RETURNING id, uuid, user_id, ceremony, challenge, expires_at, used_at, meta, created_at, updated_at`,
+		uuid, ceremony,
+	).Scan(&challenge.ID, &challenge.UUID, &userID, &challenge.Ceremony, &challenge.Challenge,
+		&challenge.ExpiresAt, &usedAt, &meta, &challenge.CreatedAt, &challenge.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume webauthn challenge: %w", err)
+	}
+
+	if userID.Valid {
+		id := int(userID.Int64)
+		challenge.UserID = &id
+	}
+	if usedAt.Valid {
+		challenge.UsedAt = &usedAt.Time
+	}
+	if challenge.Meta, err = decodeJSON(meta); err != nil {
+		return nil, err
+	}
+
+	return &challenge, nil
+}
+
+// CreateWebAuthnCredential stores a newly registered credential.
+// It returns ErrDuplicate when the credential ID is already registered.
+func (r *AuthRepository) CreateWebAuthnCredential(ctx context.Context, credential *models.WebAuthnCredential) error {
+	meta, err := encodeJSON(credential.Meta)
+	if err != nil {
+		return err
+	}
+
+	err = r.conn(ctx).QueryRowContext(ctx, `
+		INSERT INTO sr_auth.webauthn_credentials (user_id, device_id, credential_id, public_key, algorithm, sign_count,
+			aaguid, attestation_format, attestation_type, name, backup_eligible, backup_state, meta)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id, uuid, is_active, created_at, updated_at`,
+		credential.UserID, credential.DeviceID, credential.CredentialID, credential.PublicKey, credential.Algorithm,
+		int64(credential.SignCount), nullableString(credential.AAGUID), credential.AttestationFormat,
+		credential.AttestationType, nullableString(credential.Name), credential.BackupEligible, credential.BackupState, meta,
+	).Scan(&credential.ID, &credential.UUID, &credential.IsActive, &credential.CreatedAt, &credential.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrDuplicate
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create webauthn credential: %w", err)
+	}
+
+	return nil
+}
+
+// GetWebAuthnCredentialByCredentialID looks up an active credential by its authenticator-assigned ID
+func (r *AuthRepository) GetWebAuthnCredentialByCredentialID(ctx context.Context, credentialID []byte) (*models.WebAuthnCredential, error) {
+	row := r.conn(ctx).QueryRowContext(ctx, `
+		SELECT `+webAuthnCredentialColumns+` FROM sr_auth.webauthn_credentials
+		WHERE credential_id = $1 AND is_active = true AND deleted_at IS NULL`, credentialID)
+	return scanWebAuthnCredential(row)
+}
+
+// ListWebAuthnCredentials returns the user's active credentials, newest first
+func (r *AuthRepository) ListWebAuthnCredentials(ctx context.Context, userID int) ([]*models.WebAuthnCredential, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, `
+		SELECT `+webAuthnCredentialColumns+` FROM sr_auth.webauthn_credentials
+		WHERE user_id = $1 AND is_active = true AND deleted_at IS NULL
+		ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webauthn credentials: %w", err)
+	}
+	defer rows.Close()
+
+	var credentials []*models.WebAuthnCredential
+	for rows.Next() {
+		credential, err := scanWebAuthnCredential(rows)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate webauthn credentials: %w", err)
+	}
+
+	return credentials, nil
+}
+
+// UpdateWebAuthnCredentialUsage records a successful assertion.
+// The update only applies while the stored counter is still expectedSignCount,
+// so concurrent assertions with the same counter cannot both succeed.
+func (r *AuthRepository) UpdateWebAuthnCredentialUsage(ctx context.Context, id int, expectedSignCount, signCount uint32, backupState bool) (bool, error) {
+	result, err := r.conn(ctx).ExecContext(ctx, `
+		UPDATE sr_auth.webauthn_credentials
+		SET sign_count = $3, backup_state = $4, last_used_at = get_utc_timestamp()
+		WHERE id = $1 AND sign_count = $2 AND is_active = true AND deleted_at IS NULL`,
+		id, int64(expectedSignCount), int64(signCount), backupState)
+	if err != nil {
+		return false, fmt.Errorf("failed to update webauthn credential usage: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to read affected rows: %w", err)
+	}
+
+	return affected == 1, nil
+}
+
+// MarkWebAuthnCredentialCloned disables a credential whose signature counter went backwards
+func (r *AuthRepository) MarkWebAuthnCredentialCloned(ctx context.Context, id int) error {
+	_, err := r.conn(ctx).ExecContext(ctx, `
+		UPDATE sr_auth.webauthn_credentials
+		SET is_active = false, clone_detected_at = get_utc_timestamp()
+		WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark webauthn credential cloned: %w", err)
+	}
+	return nil
+}
+
+// DeleteWebAuthnCredential soft deletes one of the user's credentials
+func (r *AuthRepository) DeleteWebAuthnCredential(ctx context.Context, userID int, uuid string) error {
+	result, err := r.conn(ctx).ExecContext(ctx, `
+		UPDATE sr_auth.webauthn_credentials SET deleted_at = get_utc_timestamp(), is_active = false
+		WHERE user_id = $1 AND uuid = $2 AND deleted_at IS NULL`, userID, uuid)
+	if err != nil {
+		return fmt.Errorf("failed to delete webauthn credential: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read affected rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// GetDeviceIDByUUID resolves one of the user's devices to its primary key
+func (r *AuthRepository) GetDeviceIDByUUID(ctx context.Context, userID int, uuid string) (int, error) {
+	var id int
+	err := r.conn(ctx).QueryRowContext(ctx, `
+		SELECT id FROM sr_auth.devices WHERE user_id = $1 AND uuid = $2 AND deleted_at IS NULL`,
+		userID, uuid,
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get device: %w", err)
+	}
+	return id, nil
+}
+
+func scanWebAuthnCredential(row scanner) (*models.WebAuthnCredential, error) {
+	var (
+		credential      models.WebAuthnCredential
+		deviceID        sql.NullInt64
+		signCount       int64
+		aaguid          sql.NullString
+		name            sql.NullString
+		cloneDetectedAt sql.NullTime
+		lastUsedAt      sql.NullTime
+		meta            []byte
+	)
+
+	err := row.Scan(&credential.ID, &credential.UUID, &credential.UserID, &deviceID, &credential.CredentialID,
+		&credential.PublicKey, &credential.Algorithm, &signCount, &aaguid, &credential.AttestationFormat,
+		&credential.AttestationType, &name, &credential.BackupEligible, &credential.BackupState, &credential.IsActive,
+		&cloneDetectedAt, &lastUsedAt, &meta, &credential.CreatedAt, &credential.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan webauthn credential: %w", err)
+	}
+
+	credential.SignCount = uint32(signCount)
+	if deviceID.Valid {
+		id := int(deviceID.Int64)
+		credential.DeviceID = &id
+	}
+	if aaguid.Valid {
+		credential.AAGUID = &aaguid.String
+	}
+	if name.Valid {
+		credential.Name = &name.String
+	}
+	if cloneDetectedAt.Valid {
+		credential.CloneDetectedAt = &cloneDetectedAt.Time
+	}
+	if lastUsedAt.Valid {
+		credential.LastUsedAt = &lastUsedAt.Time
+	}
+	if credential.Meta, err = decodeJSON(meta); err != nil {
+		return nil, err
+	}
+
+	return &credential, nil
+}
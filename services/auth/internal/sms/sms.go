@@ -0,0 +1,101 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is a text message to a phone number
+type Message struct {
+	To   string
+	Body string
+}
+
+// Sender delivers transactional text messages
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Sink names accepted by New
+const (
+	SinkLog  = "log"
+	SinkFile = "file"
+)
+
+// New returns the development sink selected by name. Production delivery goes
+// through the notification service and is wired in separately.
+func New(sink, dir string) (Sender, error) {
+	switch sink {
+	case "", SinkLog:
+		return NewLogSender(), nil
+	case SinkFile:
+		return NewFileSender(dir)
+	default:
+		return nil, fmt.Errorf("unknown sms sink %q", sink)
+	}
+}
+
+// LogSender writes messages to the service log
+type LogSender struct{}
+
+// NewLogSender creates a sender that logs every message
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+// Send logs the message
+func (s *LogSender) Send(ctx context.Context, msg Message) error {
+	log.Printf("SMS to=%s\n%s", msg.To, msg.Body)
+	return nil
+}
+
+// FileSender writes each message to its own file in a directory
+type FileSender struct {
+	dir string
+	mu  sync.Mutex
+	seq int
+}
+
+// NewFileSender creates a sender that writes .txt files into dir
+func NewFileSender(dir string) (*FileSender, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("sms directory is required for the file sink")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create sms directory: %w", err)
+	}
+	return &FileSender{dir: dir}, nil
+}
+
+// Send writes the message to a new file
+func (s *FileSender) Send(ctx context.Context, msg Message) error {
+	s.mu.Lock()
+	s.seq++
+	seq := s.seq
+	s.mu.Unlock()
+
+	name := fmt.Sprintf("%s-%04d-%s.txt", time.Now().UTC().Format("20060102T150405"), seq, sanitizeFilename(msg.To))
+	content := fmt.Sprintf("To: %s\r\nDate: %s\r\n\r\n%s\r\n", msg.To, time.Now().UTC().Format(time.RFC1123Z), msg.Body)
+
+	if err := os.WriteFile(filepath.Join(s.dir, name), []byte(content), 0o600); err != nil {
+		return fmt.Errorf("failed to write sms file: %w", err)
+	}
+	return nil
+}
+
+func sanitizeFilename(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_', r == '+':
+			return r
+		default:
+			return '_'
+		}
+	}, value)
+}
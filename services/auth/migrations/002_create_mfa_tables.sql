@@ -0,0 +1,71 @@
+-- Migration: 002_create_mfa_tables
+-- Description: TOTP authenticator enrollment and single-use recovery codes for two-factor authentication
+-- Created: 2026-10-18
+-- Dependencies: 001_create_auth_schema.sql
+
+-- UP Migration
+
+-- Start transaction
+BEGIN;
+
+-- Create TOTP credentials table (one enrollment per user)
+CREATE TABLE sr_auth.mfa_totp (
+    id SERIAL PRIMARY KEY,
+    uuid UUID UNIQUE DEFAULT generate_uuid(),
+    user_id INTEGER NOT NULL REFERENCES sr_auth.users(id) ON DELETE CASCADE,
+    secret VARCHAR(255) NOT NULL, -- base32 shared secret
+    confirmed_at TIMESTAMPTZ DEFAULT NULL, -- NULL until the first code is verified
+    last_used_step BIGINT DEFAULT NULL, -- last accepted time step, for replay protection
+    meta JSONB DEFAULT '{}',
+    created_at TIMESTAMPTZ DEFAULT get_utc_timestamp(),
+    updated_at TIMESTAMPTZ DEFAULT get_utc_timestamp(),
+    deleted_at TIMESTAMPTZ DEFAULT NULL
+);
+
+-- Create recovery codes table
+CREATE TABLE sr_auth.mfa_recovery_codes (
+    id SERIAL PRIMARY KEY,
+    uuid UUID UNIQUE DEFAULT generate_uuid(),
+    user_id INTEGER NOT NULL REFERENCES sr_auth.users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(255) NOT NULL, -- SHA-256 hex of the normalized code
+    used_at TIMESTAMPTZ DEFAULT NULL,
+    meta JSONB DEFAULT '{}',
+    created_at TIMESTAMPTZ DEFAULT get_utc_timestamp(),
+    updated_at TIMESTAMPTZ DEFAULT get_utc_timestamp(),
+    deleted_at TIMESTAMPTZ DEFAULT NULL
+);
+
+-- Add common indexes manually (tables without is_active column)
+CREATE INDEX IF NOT EXISTS idx_mfa_totp_uuid ON sr_auth.mfa_totp(uuid);
+CREATE INDEX IF NOT EXISTS idx_mfa_totp_created_at ON sr_auth.mfa_totp(created_at);
+CREATE INDEX IF NOT EXISTS idx_mfa_totp_updated_at ON sr_auth.mfa_totp(updated_at);
+CREATE INDEX IF NOT EXISTS idx_mfa_totp_deleted_at ON sr_auth.mfa_totp(deleted_at) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_uuid ON sr_auth.mfa_recovery_codes(uuid);
+CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_created_at ON sr_auth.mfa_recovery_codes(created_at);
+CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_updated_at ON sr_auth.mfa_recovery_codes(updated_at);
+CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_deleted_at ON sr_auth.mfa_recovery_codes(deleted_at) WHERE deleted_at IS NULL;
+
+-- Add service-specific indexes
+CREATE UNIQUE INDEX idx_mfa_totp_user ON sr_auth.mfa_totp(user_id) WHERE deleted_at IS NULL;
+
+CREATE INDEX idx_mfa_recovery_codes_user_id ON sr_auth.mfa_recovery_codes(user_id);
+CREATE INDEX idx_mfa_recovery_codes_unused ON sr_auth.mfa_recovery_codes(user_id, code_hash) WHERE used_at IS NULL AND deleted_at IS NULL;
+
+-- Create triggers for auto-updating updated_at
+CREATE TRIGGER update_mfa_totp_updated_at
+    BEFORE UPDATE ON sr_auth.mfa_totp
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_mfa_recovery_codes_updated_at
+    BEFORE UPDATE ON sr_auth.mfa_recovery_codes
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Commit transaction
+COMMIT;
+
+-- DOWN Migration
+-- DROP TRIGGER IF EXISTS update_mfa_recovery_codes_updated_at ON sr_auth.mfa_recovery_codes;
+-- DROP TRIGGER IF EXISTS update_mfa_totp_updated_at ON sr_auth.mfa_totp;
+-- DROP TABLE IF EXISTS sr_auth.mfa_recovery_codes;
+-- DROP TABLE IF EXISTS sr_auth.mfa_totp;
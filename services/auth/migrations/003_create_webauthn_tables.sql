@@ -0,0 +1,93 @@
+-- Migration: 003_create_webauthn_tables
+-- Description: WebAuthn (passkey) credentials and ceremony challenges; passkey-only accounts
+-- Created: 2026-10-18
+-- Dependencies: 001_create_auth_schema.sql
+
+-- UP Migration
+
+-- New enum values cannot be used in the transaction that adds them, so this runs first
+ALTER TYPE sr_auth.auth_method ADD VALUE IF NOT EXISTS 'passkey';
+
+-- Start transaction
+BEGIN;
+
+-- Passkey-only accounts have no password
+ALTER TABLE sr_auth.users ALTER COLUMN password_hash DROP NOT NULL;
+
+-- Create WebAuthn credentials table
+CREATE TABLE sr_auth.webauthn_credentials (
+    id SERIAL PRIMARY KEY,
+    uuid UUID UNIQUE DEFAULT generate_uuid(),
+    user_id INTEGER NOT NULL REFERENCES sr_auth.users(id) ON DELETE CASCADE,
+    device_id INTEGER REFERENCES sr_auth.devices(id) ON DELETE SET NULL,
+    credential_id BYTEA NOT NULL,
+    public_key BYTEA NOT NULL, -- COSE_Key encoding
+    algorithm INTEGER NOT NULL, -- COSE algorithm identifier (-7 ES256, -8 EdDSA, -257 RS256)
+    sign_count BIGINT DEFAULT 0,
+    aaguid UUID,
+    attestation_format VARCHAR(32) NOT NULL, -- 'none', 'packed'
+    attestation_type VARCHAR(32) NOT NULL, -- 'none', 'self', 'basic'
+    name VARCHAR(255),
+    backup_eligible BOOLEAN DEFAULT false,
+    backup_state BOOLEAN DEFAULT false,
+    is_active BOOLEAN DEFAULT true,
+    clone_detected_at TIMESTAMPTZ DEFAULT NULL,
+    last_used_at TIMESTAMPTZ DEFAULT NULL,
+    meta JSONB DEFAULT '{}',
+    created_at TIMESTAMPTZ DEFAULT get_utc_timestamp(),
+    updated_at TIMESTAMPTZ DEFAULT get_utc_timestamp(),
+    deleted_at TIMESTAMPTZ DEFAULT NULL
+);
+
+-- Create WebAuthn challenges table (server-side ceremony state)
+CREATE TABLE sr_auth.webauthn_challenges (
+    id SERIAL PRIMARY KEY,
+    uuid UUID UNIQUE DEFAULT generate_uuid(),
+    user_id INTEGER REFERENCES sr_auth.users(id) ON DELETE CASCADE, -- NULL for discoverable-credential login
+    ceremony VARCHAR(20) NOT NULL, -- 'registration', 'authentication'
+    challenge BYTEA NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ DEFAULT NULL,
+    meta JSONB DEFAULT '{}',
+    created_at TIMESTAMPTZ DEFAULT get_utc_timestamp(),
+    updated_at TIMESTAMPTZ DEFAULT get_utc_timestamp(),
+    deleted_at TIMESTAMPTZ DEFAULT NULL
+);
+
+-- Add common indexes using global utility functions (for tables with is_active column)
+SELECT add_common_indexes('sr_auth', 'webauthn_credentials');
+
+-- Add common indexes manually for tables without is_active column
+CREATE INDEX IF NOT EXISTS idx_webauthn_challenges_uuid ON sr_auth.webauthn_challenges(uuid);
+CREATE INDEX IF NOT EXISTS idx_webauthn_challenges_created_at ON sr_auth.webauthn_challenges(created_at);
+CREATE INDEX IF NOT EXISTS idx_webauthn_challenges_updated_at ON sr_auth.webauthn_challenges(updated_at);
+CREATE INDEX IF NOT EXISTS idx_webauthn_challenges_deleted_at ON sr_auth.webauthn_challenges(deleted_at) WHERE deleted_at IS NULL;
+
+-- Add service-specific indexes
+CREATE UNIQUE INDEX idx_webauthn_credentials_credential_id ON sr_auth.webauthn_credentials(credential_id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_webauthn_credentials_user_id ON sr_auth.webauthn_credentials(user_id);
+CREATE INDEX idx_webauthn_credentials_device_id ON sr_auth.webauthn_credentials(device_id);
+CREATE INDEX idx_webauthn_credentials_active ON sr_auth.webauthn_credentials(is_active) WHERE is_active = true;
+
+CREATE INDEX idx_webauthn_challenges_expires_at ON sr_auth.webauthn_challenges(expires_at);
+
+-- Create triggers for auto-updating updated_at
+CREATE TRIGGER update_webauthn_credentials_updated_at
+    BEFORE UPDATE ON sr_auth.webauthn_credentials
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_webauthn_challenges_updated_at
+    BEFORE UPDATE ON sr_auth.webauthn_challenges
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Commit transaction
+COMMIT;
+
+-- DOWN Migration
+-- DROP TRIGGER IF EXISTS update_webauthn_challenges_updated_at ON sr_auth.webauthn_challenges;
+-- DROP TRIGGER IF EXISTS update_webauthn_credentials_updated_at ON sr_auth.webauthn_credentials;
+-- DROP TABLE IF EXISTS sr_auth.webauthn_challenges;
+-- DROP TABLE IF EXISTS sr_auth.webauthn_credentials;
+-- UPDATE sr_auth.users SET password_hash = '' WHERE password_hash IS NULL;
+-- ALTER TABLE sr_auth.users ALTER COLUMN password_hash SET NOT NULL;
+-- Enum values cannot be dropped; 'passkey' remains in sr_auth.auth_method
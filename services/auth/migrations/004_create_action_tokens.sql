@@ -0,0 +1,51 @@
+-- Migration: 004_create_action_tokens
+-- Description: Single-use, purpose-bound tokens delivered out of band (magic links and similar)
+-- Created: 2026-10-18
+-- Dependencies: 001_create_auth_schema.sql
+
+-- UP Migration
+
+-- Start transaction
+BEGIN;
+
+-- Create action tokens table
+CREATE TABLE sr_auth.action_tokens (
+    id SERIAL PRIMARY KEY,
+    uuid UUID UNIQUE DEFAULT generate_uuid(),
+    user_id INTEGER NOT NULL REFERENCES sr_auth.users(id) ON DELETE CASCADE,
+    purpose VARCHAR(50) NOT NULL, -- 'magic_link'
+    token_hash VARCHAR(255) NOT NULL, -- SHA-256 hex of the token sent to the user
+    binding_hash VARCHAR(255), -- SHA-256 hex of the secret kept by the requesting client
+    device_id VARCHAR(255), -- client-provided device identifier of the requester
+    ip_address INET,
+    user_agent TEXT,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ DEFAULT NULL,
+    meta JSONB DEFAULT '{}',
+    created_at TIMESTAMPTZ DEFAULT get_utc_timestamp(),
+    updated_at TIMESTAMPTZ DEFAULT get_utc_timestamp(),
+    deleted_at TIMESTAMPTZ DEFAULT NULL
+);
+
+-- Add common indexes manually (table without is_active column)
+CREATE INDEX IF NOT EXISTS idx_action_tokens_uuid ON sr_auth.action_tokens(uuid);
+CREATE INDEX IF NOT EXISTS idx_action_tokens_created_at ON sr_auth.action_tokens(created_at);
+CREATE INDEX IF NOT EXISTS idx_action_tokens_updated_at ON sr_auth.action_tokens(updated_at);
+CREATE INDEX IF NOT EXISTS idx_action_tokens_deleted_at ON sr_auth.action_tokens(deleted_at) WHERE deleted_at IS NULL;
+
+-- Add service-specific indexes
+CREATE UNIQUE INDEX idx_action_tokens_token_hash ON sr_auth.action_tokens(token_hash);
+CREATE INDEX idx_action_tokens_user_purpose ON sr_auth.action_tokens(user_id, purpose) WHERE used_at IS NULL AND deleted_at IS NULL;
+CREATE INDEX idx_action_tokens_expires_at ON sr_auth.action_tokens(expires_at);
+
+-- Create trigger for auto-updating updated_at
+CREATE TRIGGER update_action_tokens_updated_at
+    BEFORE UPDATE ON sr_auth.action_tokens
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Commit transaction
+COMMIT;
+
+-- DOWN Migration
+-- DROP TRIGGER IF EXISTS update_action_tokens_updated_at ON sr_auth.action_tokens;
+-- DROP TABLE IF EXISTS sr_auth.action_tokens;
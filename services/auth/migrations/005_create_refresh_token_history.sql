@@ -0,0 +1,50 @@
+-- Migration: 005_create_refresh_token_history
+-- Description: Retired refresh token hashes, used to detect reuse of rotated refresh tokens
+-- Created: 2026-10-18
+-- Dependencies: 001_create_auth_schema.sql
+
+-- UP Migration
+
+-- Start transaction
+BEGIN;
+
+-- Create refresh token history table
+CREATE TABLE sr_auth.refresh_token_history (
+    id SERIAL PRIMARY KEY,
+    uuid UUID UNIQUE DEFAULT generate_uuid(),
+    session_id INTEGER NOT NULL REFERENCES sr_auth.sessions(id) ON DELETE CASCADE,
+    token_hash VARCHAR(255) NOT NULL, -- SHA-256 hex of a refresh token that has been rotated out
+    rotated_at TIMESTAMPTZ NOT NULL DEFAULT get_utc_timestamp(),
+    meta JSONB DEFAULT '{}',
+    created_at TIMESTAMPTZ DEFAULT get_utc_timestamp(),
+    updated_at TIMESTAMPTZ DEFAULT get_utc_timestamp(),
+    deleted_at TIMESTAMPTZ DEFAULT NULL
+);
+
+-- Add common indexes manually (table without is_active column)
+CREATE INDEX IF NOT EXISTS idx_refresh_token_history_uuid ON sr_auth.refresh_token_history(uuid);
+CREATE INDEX IF NOT EXISTS idx_refresh_token_history_created_at ON sr_auth.refresh_token_history(created_at);
+CREATE INDEX IF NOT EXISTS idx_refresh_token_history_updated_at ON sr_auth.refresh_token_history(updated_at);
+CREATE INDEX IF NOT EXISTS idx_refresh_token_history_deleted_at ON sr_auth.refresh_token_history(deleted_at) WHERE deleted_at IS NULL;
+
+-- Add service-specific indexes
+CREATE UNIQUE INDEX idx_refresh_token_history_token_hash ON sr_auth.refresh_token_history(token_hash);
+CREATE INDEX idx_refresh_token_history_session_id ON sr_auth.refresh_token_history(session_id);
+
+-- Refresh tokens are looked up by hash on every refresh; make the current hash unique too
+DROP INDEX IF EXISTS sr_auth.idx_sessions_refresh_token_hash;
+CREATE UNIQUE INDEX idx_sessions_refresh_token_hash ON sr_auth.sessions(refresh_token_hash);
+
+-- Create trigger for auto-updating updated_at
+CREATE TRIGGER update_refresh_token_history_updated_at
+    BEFORE UPDATE ON sr_auth.refresh_token_history
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Commit transaction
+COMMIT;
+
+-- DOWN Migration
+-- DROP INDEX IF EXISTS sr_auth.idx_sessions_refresh_token_hash;
+-- CREATE INDEX idx_sessions_refresh_token_hash ON sr_auth.sessions(refresh_token_hash);
+-- DROP TRIGGER IF EXISTS update_refresh_token_history_updated_at ON sr_auth.refresh_token_history;
+-- DROP TABLE IF EXISTS sr_auth.refresh_token_history;
@@ -0,0 +1,54 @@
+-- Migration: 006_create_login_logs_archive
+-- Description: Archive table for login_logs rows past the retention period, plus a keyset pagination index
+-- Created: 2026-10-18
+-- Dependencies: 001_create_auth_schema.sql
+
+-- UP Migration
+
+-- Start transaction
+BEGIN;
+
+-- Create login logs archive table (same columns as login_logs, without foreign keys)
+CREATE TABLE sr_auth.login_logs_archive (
+    id INTEGER PRIMARY KEY, -- id of the original login_logs row
+    uuid UUID UNIQUE NOT NULL,
+    user_id INTEGER,
+    session_id INTEGER,
+    event_type VARCHAR(50) NOT NULL,
+    ip_address INET,
+    user_agent TEXT,
+    device_info JSONB DEFAULT '{}',
+    success BOOLEAN NOT NULL,
+    failure_reason VARCHAR(255),
+    meta JSONB DEFAULT '{}',
+    archived_at TIMESTAMPTZ NOT NULL DEFAULT get_utc_timestamp(),
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ DEFAULT NULL
+);
+
+-- Add common indexes manually (table without is_active column)
+CREATE INDEX IF NOT EXISTS idx_login_logs_archive_uuid ON sr_auth.login_logs_archive(uuid);
+CREATE INDEX IF NOT EXISTS idx_login_logs_archive_created_at ON sr_auth.login_logs_archive(created_at);
+CREATE INDEX IF NOT EXISTS idx_login_logs_archive_updated_at ON sr_auth.login_logs_archive(updated_at);
+CREATE INDEX IF NOT EXISTS idx_login_logs_archive_deleted_at ON sr_auth.login_logs_archive(deleted_at) WHERE deleted_at IS NULL;
+
+-- Add service-specific indexes
+CREATE INDEX idx_login_logs_archive_user_id ON sr_auth.login_logs_archive(user_id, created_at);
+CREATE INDEX idx_login_logs_archive_archived_at ON sr_auth.login_logs_archive(archived_at);
+
+-- Keyset pagination over login_logs orders by (created_at, id)
+CREATE INDEX idx_login_logs_created_at_id ON sr_auth.login_logs(created_at DESC, id DESC);
+
+-- Create trigger for auto-updating updated_at
+CREATE TRIGGER update_login_logs_archive_updated_at
+    BEFORE UPDATE ON sr_auth.login_logs_archive
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Commit transaction
+COMMIT;
+
+-- DOWN Migration
+-- DROP INDEX IF EXISTS sr_auth.idx_login_logs_created_at_id;
+-- DROP TRIGGER IF EXISTS update_login_logs_archive_updated_at ON sr_auth.login_logs_archive;
+-- DROP TABLE IF EXISTS sr_auth.login_logs_archive;
@@ -0,0 +1,67 @@
+-- Migration: 007_create_oauth_states
+-- Description: Server-side OAuth authorization request state (state, nonce, PKCE verifier) and provider profile mappings
+-- Created: 2026-10-18
+-- Dependencies: 001_create_auth_schema.sql
+
+-- UP Migration
+
+-- Start transaction
+BEGIN;
+
+-- Create OAuth states table (one row per authorization request)
+CREATE TABLE sr_auth.oauth_states (
+    id SERIAL PRIMARY KEY,
+    uuid UUID UNIQUE DEFAULT generate_uuid(),
+    provider_id INTEGER NOT NULL REFERENCES sr_auth.providers(id) ON DELETE CASCADE,
+    user_id INTEGER REFERENCES sr_auth.users(id) ON DELETE CASCADE, -- set when linking to a signed-in account
+    state_hash VARCHAR(255) NOT NULL, -- SHA-256 hex of the state parameter sent to the provider
+    nonce VARCHAR(255) NOT NULL, -- OpenID Connect nonce, echoed in the ID token
+    code_verifier VARCHAR(255) NOT NULL, -- PKCE verifier; only its S256 challenge leaves the service
+    redirect_uri TEXT NOT NULL,
+    device_id VARCHAR(255), -- client-provided device identifier of the requester
+    ip_address INET,
+    user_agent TEXT,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ DEFAULT NULL,
+    meta JSONB DEFAULT '{}',
+    created_at TIMESTAMPTZ DEFAULT get_utc_timestamp(),
+    updated_at TIMESTAMPTZ DEFAULT get_utc_timestamp(),
+    deleted_at TIMESTAMPTZ DEFAULT NULL
+);
+
+-- Add common indexes manually (table without is_active column)
+CREATE INDEX IF NOT EXISTS idx_oauth_states_uuid ON sr_auth.oauth_states(uuid);
+CREATE INDEX IF NOT EXISTS idx_oauth_states_created_at ON sr_auth.oauth_states(created_at);
+CREATE INDEX IF NOT EXISTS idx_oauth_states_updated_at ON sr_auth.oauth_states(updated_at);
+CREATE INDEX IF NOT EXISTS idx_oauth_states_deleted_at ON sr_auth.oauth_states(deleted_at) WHERE deleted_at IS NULL;
+
+-- Add service-specific indexes
+CREATE UNIQUE INDEX idx_oauth_states_state_hash ON sr_auth.oauth_states(state_hash);
+CREATE INDEX idx_oauth_states_expires_at ON sr_auth.oauth_states(expires_at);
+
+-- A provider account can be linked to only one user
+CREATE UNIQUE INDEX idx_user_providers_provider_identity ON sr_auth.user_providers(provider_id, provider_user_id) WHERE deleted_at IS NULL;
+
+-- Create trigger for auto-updating updated_at
+CREATE TRIGGER update_oauth_states_updated_at
+    BEFORE UPDATE ON sr_auth.oauth_states
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Profile mappings for providers whose userinfo response differs from OpenID Connect
+UPDATE sr_auth.providers SET config = config || '{"id_field": "id", "email_verified_field": "verified_email"}'
+WHERE name = 'google';
+UPDATE sr_auth.providers SET config = config || '{"id_field": "id", "email_field": "mail", "name_field": "displayName", "email_verified": true}'
+WHERE name = 'microsoft';
+UPDATE sr_auth.providers SET config = config || '{"id_field": "id", "emails_url": "https://api.github.com/user/emails"}'
+WHERE name = 'github';
+UPDATE sr_auth.providers SET config = config || '{"id_field": "id", "userinfo_params": {"fields": "id,name,email"}, "email_verified": true}'
+WHERE name = 'facebook';
+
+-- Commit transaction
+COMMIT;
+
+-- DOWN Migration
+-- UPDATE sr_auth.providers SET config = config - 'id_field' - 'email_field' - 'email_verified_field' - 'name_field' - 'email_verified' - 'emails_url' - 'userinfo_params';
+-- DROP INDEX IF EXISTS sr_auth.idx_user_providers_provider_identity;
+-- DROP TRIGGER IF EXISTS update_oauth_states_updated_at ON sr_auth.oauth_states;
+-- DROP TABLE IF EXISTS sr_auth.oauth_states;
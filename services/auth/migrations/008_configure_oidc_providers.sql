@@ -0,0 +1,33 @@
+-- Migration: 008_configure_oidc_providers
+-- Description: OpenID Connect settings for Google, Microsoft and Apple so identity is taken from validated ID tokens
+-- Created: 2026-10-18
+-- Dependencies: 007_create_oauth_states.sql
+
+-- UP Migration
+
+-- Start transaction
+BEGIN;
+
+-- Google also issues tokens with the scheme-less issuer
+UPDATE sr_auth.providers
+SET config = config || '{"issuer": "https://accounts.google.com", "issuer_aliases": ["accounts.google.com"]}'
+WHERE name = 'google';
+
+-- The common endpoint serves every tenant; iss is matched against the token's tid.
+-- oid is the Graph object ID, the same identifier the userinfo (Graph /me) call returns.
+UPDATE sr_auth.providers
+SET config = config || '{"issuer": "https://login.microsoftonline.com/{tenantid}/v2.0", "discovery_url": "https://login.microsoftonline.com/common/v2.0/.well-known/openid-configuration", "id_claim": "oid"}'
+WHERE name = 'microsoft';
+
+-- Apple posts the callback as a form and needs a signed ES256 client secret:
+-- store the .p8 key (PEM) in client_secret and fill in team_id and key_id
+UPDATE sr_auth.providers
+SET config = config || '{"issuer": "https://appleid.apple.com", "auth_params": {"response_mode": "form_post"}, "client_secret_jwt": {"team_id": "", "key_id": ""}}'
+WHERE name = 'apple';
+
+-- Commit transaction
+COMMIT;
+
+-- DOWN Migration
+-- UPDATE sr_auth.providers SET config = config - 'issuer' - 'issuer_aliases' - 'discovery_url' - 'id_claim' - 'auth_params' - 'client_secret_jwt'
+-- WHERE name IN ('google', 'microsoft', 'apple');
@@ -0,0 +1,20 @@
+-- Migration: 009_scope_user_provider_links
+-- Description: Allow a provider to be linked again after an unlink by only enforcing one live link per user and provider
+-- Created: 2026-10-18
+-- Dependencies: 001_create_auth_schema.sql
+
+-- UP Migration
+
+-- Start transaction
+BEGIN;
+
+-- Unlinked rows are soft deleted and must not block a new link to the same provider
+DROP INDEX IF EXISTS sr_auth.idx_user_providers_user_provider;
+CREATE UNIQUE INDEX idx_user_providers_user_provider ON sr_auth.user_providers(user_id, provider_id) WHERE deleted_at IS NULL;
+
+-- Commit transaction
+COMMIT;
+
+-- DOWN Migration
+-- DROP INDEX IF EXISTS sr_auth.idx_user_providers_user_provider;
+-- CREATE UNIQUE INDEX idx_user_providers_user_provider ON sr_auth.user_providers(user_id, provider_id);
@@ -0,0 +1,27 @@
+-- Migration: 010_encrypt_provider_secrets
+-- Description: Widen providers.client_secret for envelope-encrypted values; run cmd/encrypt-secrets afterwards to encrypt existing rows
+-- Created: 2026-10-18
+-- Dependencies: 001_create_auth_schema.sql
+
+-- UP Migration
+
+-- Start transaction
+BEGIN;
+
+-- Encrypted values ("enc:v1:<key id>:<wrapped key>:<ciphertext>") are longer than the plaintext,
+-- and PEM private keys used for client secret JWTs do not fit in 500 characters once encrypted
+ALTER TABLE sr_auth.providers ALTER COLUMN client_secret TYPE TEXT;
+
+COMMENT ON COLUMN sr_auth.providers.client_secret IS 'Envelope-encrypted client secret';
+COMMENT ON COLUMN sr_auth.user_providers.access_token IS 'Envelope-encrypted upstream access token';
+COMMENT ON COLUMN sr_auth.user_providers.refresh_token IS 'Envelope-encrypted upstream refresh token';
+
+-- Commit transaction
+COMMIT;
+
+-- DOWN Migration
+-- Decrypt the values first; encrypted secrets may not fit the old column size
+-- COMMENT ON COLUMN sr_auth.user_providers.refresh_token IS NULL;
+-- COMMENT ON COLUMN sr_auth.user_providers.access_token IS NULL;
+-- COMMENT ON COLUMN sr_auth.providers.client_secret IS NULL;
+-- ALTER TABLE sr_auth.providers ALTER COLUMN client_secret TYPE VARCHAR(500);
@@ -0,0 +1,18 @@
+-- Migration: 011_index_provider_token_refresh
+-- Description: Index for the background refresher that finds provider links whose upstream access token is about to expire
+-- Created: 2026-10-18
+-- Dependencies: 001_create_auth_schema.sql
+
+-- UP Migration
+
+-- Start transaction
+BEGIN;
+
+CREATE INDEX idx_user_providers_refresh_due ON sr_auth.user_providers(expires_at)
+    WHERE is_active = true AND refresh_token IS NOT NULL AND deleted_at IS NULL;
+
+-- Commit transaction
+COMMIT;
+
+-- DOWN Migration
+-- DROP INDEX IF EXISTS sr_auth.idx_user_providers_refresh_due;
@@ -0,0 +1,85 @@
+-- Migration: 012_create_oauth_clients
+-- Description: Registered OAuth clients of the auth service's own authorization server and their authorization requests/codes
+-- Created: 2026-10-18
+-- Dependencies: 001_create_auth_schema.sql
+
+-- UP Migration
+
+-- Start transaction
+BEGIN;
+
+-- Create OAuth clients table (applications that sign users in through /authorize)
+CREATE TABLE sr_auth.oauth_clients (
+    id SERIAL PRIMARY KEY,
+    uuid UUID UNIQUE DEFAULT generate_uuid(),
+    client_id VARCHAR(100) NOT NULL, -- public identifier sent as client_id
+    client_secret_hash VARCHAR(255), -- SHA-256 hex of the secret; NULL for public clients (mobile, SPA)
+    name VARCHAR(255) NOT NULL, -- shown on the sign-in and consent pages
+    redirect_uris TEXT[] NOT NULL DEFAULT '{}', -- exact-match allow list
+    grant_types TEXT[] NOT NULL DEFAULT '{authorization_code,refresh_token}',
+    scopes TEXT[] NOT NULL DEFAULT '{openid,email}', -- scopes the client may request
+    is_first_party BOOLEAN DEFAULT TRUE, -- first-party clients skip the consent page
+    is_active BOOLEAN DEFAULT TRUE,
+    meta JSONB DEFAULT '{}',
+    created_at TIMESTAMPTZ DEFAULT get_utc_timestamp(),
+    updated_at TIMESTAMPTZ DEFAULT get_utc_timestamp(),
+    deleted_at TIMESTAMPTZ DEFAULT NULL
+);
+
+-- Create OAuth authorizations table (one row per /authorize request; the code is added on approval)
+CREATE TABLE sr_auth.oauth_authorizations (
+    id SERIAL PRIMARY KEY,
+    uuid UUID UNIQUE DEFAULT generate_uuid(), -- request_id handed to the sign-in page
+    client_id INTEGER NOT NULL REFERENCES sr_auth.oauth_clients(id) ON DELETE CASCADE,
+    redirect_uri TEXT NOT NULL,
+    scope TEXT NOT NULL DEFAULT '',
+    state VARCHAR(500), -- echoed back to the client unchanged
+    nonce VARCHAR(255), -- OpenID Connect nonce, copied into the ID token
+    code_challenge VARCHAR(128) NOT NULL, -- PKCE S256 challenge
+    code_challenge_method VARCHAR(10) NOT NULL DEFAULT 'S256',
+    user_id INTEGER REFERENCES sr_auth.users(id) ON DELETE CASCADE, -- set on approval
+    session_id INTEGER REFERENCES sr_auth.sessions(id) ON DELETE CASCADE, -- sign-in session that approved the request
+    code_hash VARCHAR(255), -- SHA-256 hex of the authorization code
+    code_expires_at TIMESTAMPTZ,
+    token_session_id INTEGER REFERENCES sr_auth.sessions(id) ON DELETE SET NULL, -- session created by the code exchange
+    expires_at TIMESTAMPTZ NOT NULL, -- deadline for approving the request
+    approved_at TIMESTAMPTZ DEFAULT NULL,
+    denied_at TIMESTAMPTZ DEFAULT NULL,
+    used_at TIMESTAMPTZ DEFAULT NULL, -- code exchanged
+    meta JSONB DEFAULT '{}',
+    created_at TIMESTAMPTZ DEFAULT get_utc_timestamp(),
+    updated_at TIMESTAMPTZ DEFAULT get_utc_timestamp(),
+    deleted_at TIMESTAMPTZ DEFAULT NULL
+);
+
+-- Add common indexes
+SELECT add_common_indexes('sr_auth', 'oauth_clients');
+
+-- Add common indexes manually (table without is_active column)
+CREATE INDEX IF NOT EXISTS idx_oauth_authorizations_uuid ON sr_auth.oauth_authorizations(uuid);
+CREATE INDEX IF NOT EXISTS idx_oauth_authorizations_created_at ON sr_auth.oauth_authorizations(created_at);
+CREATE INDEX IF NOT EXISTS idx_oauth_authorizations_updated_at ON sr_auth.oauth_authorizations(updated_at);
+CREATE INDEX IF NOT EXISTS idx_oauth_authorizations_deleted_at ON sr_auth.oauth_authorizations(deleted_at) WHERE deleted_at IS NULL;
+
+-- Add service-specific indexes
+CREATE UNIQUE INDEX idx_oauth_clients_client_id ON sr_auth.oauth_clients(client_id) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX idx_oauth_authorizations_code_hash ON sr_auth.oauth_authorizations(code_hash) WHERE code_hash IS NOT NULL;
+CREATE INDEX idx_oauth_authorizations_expires_at ON sr_auth.oauth_authorizations(expires_at);
+
+-- Create triggers for auto-updating updated_at
+CREATE TRIGGER update_oauth_clients_updated_at
+    BEFORE UPDATE ON sr_auth.oauth_clients
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_oauth_authorizations_updated_at
+    BEFORE UPDATE ON sr_auth.oauth_authorizations
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Commit transaction
+COMMIT;
+
+-- DOWN Migration
+-- DROP TRIGGER IF EXISTS update_oauth_authorizations_updated_at ON sr_auth.oauth_authorizations;
+-- DROP TRIGGER IF EXISTS update_oauth_clients_updated_at ON sr_auth.oauth_clients;
+-- DROP TABLE IF EXISTS sr_auth.oauth_authorizations;
+-- DROP TABLE IF EXISTS sr_auth.oauth_clients;
@@ -0,0 +1,53 @@
+-- Migration: 013_create_api_keys
+-- Description: Personal (user) and machine (service account) API keys for integrations that cannot use OAuth
+-- Created: 2026-10-18
+-- Dependencies: 001_create_auth_schema.sql, 012_create_oauth_clients.sql
+
+-- UP Migration
+
+-- Start transaction
+BEGIN;
+
+-- Create API keys table (keys are shown once; only the hash is stored)
+CREATE TABLE sr_auth.api_keys (
+    id SERIAL PRIMARY KEY,
+    uuid UUID UNIQUE DEFAULT generate_uuid(),
+    user_id INTEGER REFERENCES sr_auth.users(id) ON DELETE CASCADE, -- owner of a personal key
+    oauth_client_id INTEGER REFERENCES sr_auth.oauth_clients(id) ON DELETE CASCADE, -- service account owning a machine key
+    name VARCHAR(255) NOT NULL,
+    key_prefix VARCHAR(32) NOT NULL, -- public part of the key ("sr_<lookup id>"), shown in listings and used for lookup
+    key_hash VARCHAR(255) NOT NULL, -- SHA-256 hex of the full key
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    allowed_ips TEXT[] NOT NULL DEFAULT '{}', -- CIDR allow list; empty allows any address
+    expires_at TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ DEFAULT NULL,
+    last_used_ip INET DEFAULT NULL,
+    revoked_at TIMESTAMPTZ DEFAULT NULL,
+    rotated_to_id INTEGER REFERENCES sr_auth.api_keys(id) ON DELETE SET NULL, -- replacement created by a rotation
+    is_active BOOLEAN DEFAULT TRUE,
+    meta JSONB DEFAULT '{}',
+    created_at TIMESTAMPTZ DEFAULT get_utc_timestamp(),
+    updated_at TIMESTAMPTZ DEFAULT get_utc_timestamp(),
+    deleted_at TIMESTAMPTZ DEFAULT NULL,
+    CONSTRAINT api_keys_single_owner CHECK ((user_id IS NULL) <> (oauth_client_id IS NULL))
+);
+
+-- Add common indexes
+SELECT add_common_indexes('sr_auth', 'api_keys');
+
+-- Add service-specific indexes
+CREATE UNIQUE INDEX idx_api_keys_key_prefix ON sr_auth.api_keys(key_prefix);
+CREATE INDEX idx_api_keys_user_id ON sr_auth.api_keys(user_id) WHERE user_id IS NOT NULL;
+CREATE INDEX idx_api_keys_oauth_client_id ON sr_auth.api_keys(oauth_client_id) WHERE oauth_client_id IS NOT NULL;
+
+-- Create trigger for auto-updating updated_at
+CREATE TRIGGER update_api_keys_updated_at
+    BEFORE UPDATE ON sr_auth.api_keys
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Commit transaction
+COMMIT;
+
+-- DOWN Migration
+-- DROP TRIGGER IF EXISTS update_api_keys_updated_at ON sr_auth.api_keys;
+-- DROP TABLE IF EXISTS sr_auth.api_keys;
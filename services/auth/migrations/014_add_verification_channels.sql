@@ -0,0 +1,43 @@
+-- Migration: 014_add_verification_channels
+-- Description: Per-channel verification timestamps on users and hashed, attempt-limited codes in otp_tokens
+-- Created: 2026-10-18
+-- Dependencies: 001_create_auth_schema.sql
+
+-- UP Migration
+
+-- Start transaction
+BEGIN;
+
+-- is_verified stays as "at least one channel verified"; these record which ones
+ALTER TABLE sr_auth.users
+    ADD COLUMN email_verified_at TIMESTAMPTZ DEFAULT NULL,
+    ADD COLUMN phone_verified_at TIMESTAMPTZ DEFAULT NULL;
+
+-- Until now only OAuth sign-up set is_verified, from the provider's verified email
+UPDATE sr_auth.users SET email_verified_at = created_at WHERE is_verified = true;
+
+-- Codes are stored as SHA-256 hex, bound to the address they were sent to and
+-- burned after too many wrong guesses
+ALTER TABLE sr_auth.otp_tokens
+    ALTER COLUMN otp_code TYPE VARCHAR(255),
+    ADD COLUMN destination VARCHAR(255) DEFAULT NULL,
+    ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN ip_address INET DEFAULT NULL;
+
+COMMENT ON COLUMN sr_auth.otp_tokens.otp_code IS 'SHA-256 hex of the code sent to the user';
+COMMENT ON COLUMN sr_auth.otp_tokens.destination IS 'Email address or phone number the code was sent to';
+
+-- Add service-specific indexes
+CREATE INDEX idx_users_email_verified_at ON sr_auth.users(email_verified_at) WHERE email_verified_at IS NOT NULL;
+CREATE INDEX idx_users_phone_verified_at ON sr_auth.users(phone_verified_at) WHERE phone_verified_at IS NOT NULL;
+CREATE INDEX idx_otp_tokens_user_use_case ON sr_auth.otp_tokens(user_id, use_case, created_at);
+
+-- Commit transaction
+COMMIT;
+
+-- DOWN Migration
+-- DROP INDEX IF EXISTS sr_auth.idx_otp_tokens_user_use_case;
+-- DROP INDEX IF EXISTS sr_auth.idx_users_phone_verified_at;
+-- DROP INDEX IF EXISTS sr_auth.idx_users_email_verified_at;
+-- ALTER TABLE sr_auth.otp_tokens DROP COLUMN ip_address, DROP COLUMN attempts, DROP COLUMN destination;
+-- ALTER TABLE sr_auth.users DROP COLUMN phone_verified_at, DROP COLUMN email_verified_at;
@@ -0,0 +1,52 @@
+-- Migration: 015_create_contact_changes
+-- Description: Pending email and phone changes, confirmed through the new address and undoable from the old one
+-- Created: 2026-10-18
+-- Dependencies: 001_create_auth_schema.sql
+
+-- UP Migration
+
+-- Start transaction
+BEGIN;
+
+-- Create contact changes table (tokens are emailed or texted; only their hashes are stored)
+CREATE TABLE sr_auth.contact_changes (
+    id SERIAL PRIMARY KEY,
+    uuid UUID UNIQUE DEFAULT generate_uuid(),
+    user_id INTEGER NOT NULL REFERENCES sr_auth.users(id) ON DELETE CASCADE,
+    channel VARCHAR(10) NOT NULL CHECK (channel IN ('email', 'phone')),
+    old_value VARCHAR(255) DEFAULT NULL, -- address being replaced; NULL when a phone number is added
+    new_value VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'confirmed', 'undone', 'cancelled')),
+    confirm_token_hash VARCHAR(255) NOT NULL, -- SHA-256 hex of the token sent to new_value
+    expires_at TIMESTAMPTZ NOT NULL, -- deadline for confirming
+    confirmed_at TIMESTAMPTZ DEFAULT NULL,
+    undo_token_hash VARCHAR(255) DEFAULT NULL, -- SHA-256 hex of the token sent to old_value on confirmation
+    undo_expires_at TIMESTAMPTZ DEFAULT NULL,
+    undone_at TIMESTAMPTZ DEFAULT NULL,
+    ip_address INET DEFAULT NULL,
+    user_agent TEXT DEFAULT NULL,
+    meta JSONB DEFAULT '{}',
+    created_at TIMESTAMPTZ DEFAULT get_utc_timestamp(),
+    updated_at TIMESTAMPTZ DEFAULT get_utc_timestamp(),
+    deleted_at TIMESTAMPTZ DEFAULT NULL
+);
+
+-- Add common indexes
+SELECT add_common_indexes('sr_auth', 'contact_changes');
+
+-- Add service-specific indexes
+CREATE UNIQUE INDEX idx_contact_changes_confirm_token ON sr_auth.contact_changes(confirm_token_hash);
+CREATE UNIQUE INDEX idx_contact_changes_undo_token ON sr_auth.contact_changes(undo_token_hash) WHERE undo_token_hash IS NOT NULL;
+CREATE INDEX idx_contact_changes_user_channel ON sr_auth.contact_changes(user_id, channel, status);
+
+-- Create trigger for auto-updating updated_at
+CREATE TRIGGER update_contact_changes_updated_at
+    BEFORE UPDATE ON sr_auth.contact_changes
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Commit transaction
+COMMIT;
+
+-- DOWN Migration
+-- DROP TRIGGER IF EXISTS update_contact_changes_updated_at ON sr_auth.contact_changes;
+-- DROP TABLE IF EXISTS sr_auth.contact_changes;
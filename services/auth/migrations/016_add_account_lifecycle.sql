@@ -0,0 +1,28 @@
+-- Migration: 016_add_account_lifecycle
+-- Description: Deactivation and deletion timestamps on users for the account lifecycle and purge job
+-- Created: 2026-10-18
+-- Dependencies: 001_create_auth_schema.sql, global 004_qualify_soft_delete_functions.sql
+
+-- UP Migration
+
+-- Start transaction
+BEGIN;
+
+-- deleted_at marks a deleted account (set through soft_delete_record); purge_after
+-- is when the grace period ends and the purge job removes its data
+ALTER TABLE sr_auth.users
+    ADD COLUMN deactivated_at TIMESTAMPTZ DEFAULT NULL,
+    ADD COLUMN purge_after TIMESTAMPTZ DEFAULT NULL;
+
+COMMENT ON COLUMN sr_auth.users.deactivated_at IS 'Set while the account is deactivated (is_active = false)';
+COMMENT ON COLUMN sr_auth.users.purge_after IS 'End of the deletion grace period; the account can be restored until then';
+
+-- Add service-specific indexes
+CREATE INDEX idx_users_purge_after ON sr_auth.users(purge_after) WHERE purge_after IS NOT NULL;
+
+-- Commit transaction
+COMMIT;
+
+-- DOWN Migration
+-- DROP INDEX IF EXISTS sr_auth.idx_users_purge_after;
+-- ALTER TABLE sr_auth.users DROP COLUMN IF EXISTS purge_after, DROP COLUMN IF EXISTS deactivated_at;
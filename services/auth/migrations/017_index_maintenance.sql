@@ -0,0 +1,31 @@
+-- Migration: 017_index_maintenance
+-- Description: Indexes for the maintenance job that deletes expired, revoked and used rows in batches
+--              and clears the provider tokens of dead user_providers links
+-- Created: 2026-10-18
+-- Dependencies: 001_create_auth_schema.sql, 002_create_mfa_tables.sql, 015_create_contact_changes.sql
+
+-- UP Migration
+
+-- Start transaction
+BEGIN;
+
+-- Expired rows are already found through the existing expires_at indexes
+CREATE INDEX idx_sessions_revoked_at ON sr_auth.sessions(revoked_at) WHERE revoked_at IS NOT NULL;
+CREATE INDEX idx_contact_changes_expires_at ON sr_auth.contact_changes(expires_at);
+CREATE INDEX idx_mfa_recovery_codes_used_at ON sr_auth.mfa_recovery_codes(used_at) WHERE used_at IS NOT NULL;
+CREATE INDEX idx_mfa_recovery_codes_replaced_at ON sr_auth.mfa_recovery_codes(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_user_providers_dead_tokens ON sr_auth.user_providers(updated_at)
+    WHERE is_active = false AND (access_token IS NOT NULL OR refresh_token IS NOT NULL);
+CREATE INDEX idx_user_providers_expired_tokens ON sr_auth.user_providers(expires_at)
+    WHERE access_token IS NOT NULL;
+
+-- Commit transaction
+COMMIT;
+
+-- DOWN Migration
+-- DROP INDEX IF EXISTS sr_auth.idx_user_providers_expired_tokens;
+-- DROP INDEX IF EXISTS sr_auth.idx_user_providers_dead_tokens;
+-- DROP INDEX IF EXISTS sr_auth.idx_mfa_recovery_codes_replaced_at;
+-- DROP INDEX IF EXISTS sr_auth.idx_mfa_recovery_codes_used_at;
+-- DROP INDEX IF EXISTS sr_auth.idx_contact_changes_expires_at;
+-- DROP INDEX IF EXISTS sr_auth.idx_sessions_revoked_at;
@@ -1,11 +1,38 @@
 package tests
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/your-project/pkgs/geoip"
+	"github.com/your-project/pkgs/geoip/geoiptest"
+	"github.com/your-project/pkgs/jwt"
+	"github.com/your-project/pkgs/oauth2/oauth2test"
+	"github.com/your-project/pkgs/totp"
+	"golang.org/x/crypto/bcrypt"
 
 	"github.com/your-project/services/auth/internal/business"
+	"github.com/your-project/services/auth/internal/config"
+	"github.com/your-project/services/auth/internal/events"
+	"github.com/your-project/services/auth/internal/mailer"
+	"github.com/your-project/services/auth/internal/models"
 	"github.com/your-project/services/auth/internal/repository"
+	"github.com/your-project/services/auth/internal/repository/memory"
+	"github.com/your-project/services/auth/internal/sms"
 )
 
 func TestNewAuthBusiness(t *testing.T) {
@@ -16,16 +43,2765 @@ func TestNewAuthBusiness(t *testing.T) {
 	repo := repository.NewAuthRepository(db)
 
 	// Test business layer creation
-	business := business.NewAuthBusiness(repo)
+	business := business.NewAuthBusiness(repo, NewTestConfig(t))
 
 	if business == nil {
 		t.Error("Expected business layer to be created, got nil")
 	}
 }
 
+func TestLoginRequiresCredentials(t *testing.T) {
+	authBusiness := business.NewAuthBusiness(repository.NewAuthRepository(&sql.DB{}), NewTestConfig(t))
+
+	_, err := authBusiness.Login(context.Background(), business.LoginInput{Email: "  ", Password: "secret"})
+	if !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument, got %v", err)
+	}
+}
+
+func TestMFAPendingTokenIsNotAnAccessToken(t *testing.T) {
+	cfg := NewTestConfig(t)
+	authBusiness := business.NewAuthBusiness(repository.NewAuthRepository(&sql.DB{}), cfg)
+
+	claims := &business.TokenClaims{Type: business.TokenTypeMFAPending}
+	claims.SetSubject("00000000-0000-0000-0000-000000000001")
+	token, err := jwt.NewJWTManager(cfg.JWTSecret).GenerateTokenWithExpiry(claims, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	if _, err := authBusiness.ValidateAccessToken(context.Background(), token); !errors.Is(err, business.ErrInvalidToken) {
+		t.Errorf("Expected mfa_pending token to be rejected as access token, got %v", err)
+	}
+}
+
+func TestVerifyMFARejectsAccessToken(t *testing.T) {
+	cfg := NewTestConfig(t)
+	authBusiness := business.NewAuthBusiness(repository.NewAuthRepository(&sql.DB{}), cfg)
+
+	claims := &business.TokenClaims{Type: business.TokenTypeAccess}
+	claims.SetSubject("00000000-0000-0000-0000-000000000001")
+	token, err := jwt.NewJWTManager(cfg.JWTSecret).GenerateTokenWithExpiry(claims, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	_, err = authBusiness.VerifyMFA(context.Background(), business.VerifyMFAInput{MFAToken: token, Code: "123456"})
+	if !errors.Is(err, business.ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken, got %v", err)
+	}
+}
+
+func TestPasskeysDisabledWithoutRelyingParty(t *testing.T) {
+	cfg := NewTestConfig(t)
+	cfg.WebAuthnRPID = ""
+	authBusiness := business.NewAuthBusiness(repository.NewAuthRepository(&sql.DB{}), cfg)
+
+	if _, err := authBusiness.BeginPasskeyLogin(context.Background(), ""); !errors.Is(err, business.ErrPasskeysDisabled) {
+		t.Errorf("Expected ErrPasskeysDisabled, got %v", err)
+	}
+}
+
+func TestBeginPasskeyLoginHidesUnknownEmails(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+	AddPasswordUser(t, setup, "kate@example.com", "kate password")
+
+	allowed := func(email string) [][]byte {
+		t.Helper()
+		options, err := setup.Business.BeginPasskeyLogin(ctx, email)
+		if err != nil {
+			t.Fatalf("Failed to begin passkey login for %s: %v", email, err)
+		}
+		return options.AllowCredentialIDs
+	}
+
+	unknown := allowed("nobody@example.com")
+	if len(unknown) != 1 || len(unknown[0]) == 0 {
+		t.Fatalf("Expected one credential ID for an unknown email, got %d", len(unknown))
+	}
+	if again := allowed("Nobody@Example.com"); len(again) != 1 || !bytes.Equal(again[0], unknown[0]) {
+		t.Error("Expected the same credential ID on every request for an email")
+	}
+	if other := allowed("someone@example.com"); len(other) != 1 || bytes.Equal(other[0], unknown[0]) {
+		t.Error("Expected a different credential ID for a different email")
+	}
+	if known := allowed("kate@example.com"); len(known) != 1 || len(known[0]) != len(unknown[0]) {
+		t.Error("Expected an account without passkeys to look like an unknown email")
+	}
+	if discoverable := allowed(""); len(discoverable) != 0 {
+		t.Errorf("Expected no credential IDs without an email, got %d", len(discoverable))
+	}
+}
+
+func TestFinishPasskeyLoginRequiresChallenge(t *testing.T) {
+	authBusiness := business.NewAuthBusiness(repository.NewAuthRepository(&sql.DB{}), NewTestConfig(t))
+
+	_, err := authBusiness.FinishPasskeyLogin(context.Background(), business.FinishPasskeyLoginInput{CredentialID: []byte("id")})
+	if !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument, got %v", err)
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	if got := business.NormalizeRecoveryCode(" AB12C-de34F "); got != "ab12cde34f" {
+		t.Errorf("Expected ab12cde34f, got %s", got)
+	}
+}
+
+func TestMagicLinkRequiresEmailAndToken(t *testing.T) {
+	authBusiness := business.NewAuthBusiness(repository.NewAuthRepository(&sql.DB{}), NewTestConfig(t), business.WithMailer(mailer.NewLogMailer()))
+
+	if _, err := authBusiness.RequestMagicLink(context.Background(), business.RequestMagicLinkInput{Email: " "}); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument for empty email, got %v", err)
+	}
+	if _, err := authBusiness.ConsumeMagicLink(context.Background(), business.ConsumeMagicLinkInput{BindingToken: "binding"}); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument for empty token, got %v", err)
+	}
+}
+
+// blockingMailer holds every send until release is closed
+type blockingMailer struct {
+	sent    chan mailer.Message
+	release chan struct{}
+}
+
+func (m *blockingMailer) Send(ctx context.Context, msg mailer.Message) error {
+	<-m.release
+	m.sent <- msg
+	return nil
+}
+
+func TestRequestMagicLinkDoesNotWaitForMail(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+	AddPasswordUser(t, setup, "judy@example.com", "judy password")
+
+	mail := &blockingMailer{sent: make(chan mailer.Message, 1), release: make(chan struct{})}
+	setup.Business = business.NewAuthBusiness(setup.Repo, setup.Config, business.WithMailer(mail))
+
+	// Both calls return while the send for the existing account is still blocked
+	known, err := setup.Business.RequestMagicLink(ctx, business.RequestMagicLinkInput{Email: "judy@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to request magic link: %v", err)
+	}
+	unknown, err := setup.Business.RequestMagicLink(ctx, business.RequestMagicLinkInput{Email: "nobody@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to request magic link for unknown email: %v", err)
+	}
+	if known.BindingToken == "" || unknown.BindingToken == "" {
+		t.Errorf("Expected binding tokens for both requests, got %+v and %+v", known, unknown)
+	}
+
+	close(mail.release)
+	select {
+	case msg := <-mail.sent:
+		if msg.To != "judy@example.com" {
+			t.Errorf("Expected the link to be sent to judy@example.com, got %s", msg.To)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the magic link to be sent")
+	}
+	select {
+	case msg := <-mail.sent:
+		t.Errorf("Expected no email for the unknown address, got one to %s", msg.To)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestOAuthRequiresProviderCodeAndState(t *testing.T) {
+	authBusiness := business.NewAuthBusiness(repository.NewAuthRepository(&sql.DB{}), NewTestConfig(t))
+
+	if _, err := authBusiness.BeginOAuth(context.Background(), business.BeginOAuthInput{Provider: " "}); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument for empty provider, got %v", err)
+	}
+	inputs := []business.CompleteOAuthInput{
+		{Code: "code", State: "state"},
+		{Provider: "google", State: "state"},
+		{Provider: "google", Code: "code"},
+	}
+	for _, input := range inputs {
+		if _, err := authBusiness.CompleteOAuth(context.Background(), input); !errors.Is(err, business.ErrInvalidArgument) {
+			t.Errorf("Expected ErrInvalidArgument for %+v, got %v", input, err)
+		}
+	}
+}
+
+func TestProviderLinkingRequiresUserAndLink(t *testing.T) {
+	authBusiness := business.NewAuthBusiness(repository.NewAuthRepository(&sql.DB{}), NewTestConfig(t))
+	ctx := context.Background()
+
+	if _, err := authBusiness.LinkProvider(ctx, business.LinkProviderInput{LinkToken: "token"}); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument for missing user, got %v", err)
+	}
+	if err := authBusiness.UnlinkProvider(ctx, "", "session", "link", business.ClientInfo{}); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument for missing user, got %v", err)
+	}
+	if _, err := authBusiness.SetPrimaryProvider(ctx, "", "session", "link", business.ClientInfo{}); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument for missing user, got %v", err)
+	}
+	if _, err := authBusiness.ListLinkedProviders(ctx, ""); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument for missing user, got %v", err)
+	}
+}
+
+func TestProviderAdminValidation(t *testing.T) {
+	authBusiness := business.NewAuthBusiness(repository.NewAuthRepository(&sql.DB{}), NewTestConfig(t))
+	ctx := context.Background()
+
+	valid := business.ProviderInput{
+		Name:             "gitlab",
+		DisplayName:      "GitLab",
+		ClientID:         "client",
+		AuthorizationURL: "https://gitlab.com/oauth/authorize",
+		TokenURL:         "https://gitlab.com/oauth/token",
+		Scopes:           []string{"read_user openid"},
+	}
+	invalid := map[string]func(*business.ProviderInput){
+		"name":              func(in *business.ProviderInput) { in.Name = "Git Lab" },
+		"display name":      func(in *business.ProviderInput) { in.DisplayName = " " },
+		"missing token url": func(in *business.ProviderInput) { in.TokenURL = "" },
+		"plain http":        func(in *business.ProviderInput) { in.AuthorizationURL = "http://gitlab.com/oauth/authorize" },
+		"relative url":      func(in *business.ProviderInput) { in.UserinfoURL = "/api/v4/user" },
+		"fragment":          func(in *business.ProviderInput) { in.RedirectURI = "https://example.com/callback#x" },
+		"scope":             func(in *business.ProviderInput) { in.Scopes = []string{`read"user`} },
+		"config issuer":     func(in *business.ProviderInput) { in.Config = map[string]interface{}{"issuer": "gitlab.com"} },
+		"enabled no client": func(in *business.ProviderInput) { in.Enabled, in.ClientID = true, "" },
+	}
+	for name, mutate := range invalid {
+		input := valid
+		mutate(&input)
+		if _, err := authBusiness.CreateProvider(ctx, input); !errors.Is(err, business.ErrInvalidArgument) {
+			t.Errorf("%s: expected ErrInvalidArgument, got %v", name, err)
+		}
+	}
+
+	if _, err := authBusiness.UpdateProvider(ctx, "", valid); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument for missing provider ID, got %v", err)
+	}
+	if _, err := authBusiness.EnableProvider(ctx, " "); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument for missing provider ID, got %v", err)
+	}
+}
+
+func TestOAuthClientValidation(t *testing.T) {
+	authBusiness := business.NewAuthBusiness(repository.NewAuthRepository(&sql.DB{}), NewTestConfig(t))
+	ctx := context.Background()
+
+	valid := business.OAuthClientInput{
+		Name:         "Mobile App",
+		RedirectURIs: []string{"com.example.app:/oauth/callback"},
+	}
+	invalid := map[string]func(*business.OAuthClientInput){
+		"name":                 func(in *business.OAuthClientInput) { in.Name = " " },
+		"client id":            func(in *business.OAuthClientInput) { in.ClientID = "a b" },
+		"grant type":           func(in *business.OAuthClientInput) { in.GrantTypes = []string{"password"} },
+		"public service":       func(in *business.OAuthClientInput) { in.GrantTypes = []string{"client_credentials"} },
+		"no redirect":          func(in *business.OAuthClientInput) { in.RedirectURIs = nil },
+		"plain http redirect":  func(in *business.OAuthClientInput) { in.RedirectURIs = []string{"http://app.example.com/cb"} },
+		"bare custom scheme":   func(in *business.OAuthClientInput) { in.RedirectURIs = []string{"myapp:/cb"} },
+		"javascript redirect":  func(in *business.OAuthClientInput) { in.RedirectURIs = []string{"javascript:alert(1)"} },
+		"fragment in redirect": func(in *business.OAuthClientInput) { in.RedirectURIs = []string{"https://app.example.com/cb#x"} },
+	}
+	for name, mutate := range invalid {
+		input := valid
+		mutate(&input)
+		if _, _, err := authBusiness.CreateOAuthClient(ctx, input); !errors.Is(err, business.ErrInvalidArgument) {
+			t.Errorf("%s: expected ErrInvalidArgument, got %v", name, err)
+		}
+	}
+
+	if _, err := authBusiness.DisableOAuthClient(ctx, ""); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument for missing client ID, got %v", err)
+	}
+}
+
+func TestOAuthServerRequiresKnownClient(t *testing.T) {
+	authBusiness := business.NewAuthBusiness(repository.NewAuthRepository(&sql.DB{}), NewTestConfig(t))
+	ctx := context.Background()
+
+	var oauthErr *business.OAuthError
+	_, err := authBusiness.Authorize(ctx, business.AuthorizeInput{ResponseType: "code"})
+	if !errors.As(err, &oauthErr) || oauthErr.Code != business.OAuthErrInvalidRequest {
+		t.Errorf("Expected invalid_request without client_id, got %v", err)
+	}
+
+	_, err = authBusiness.ExchangeToken(ctx, business.TokenRequest{GrantType: "authorization_code"})
+	if !errors.As(err, &oauthErr) || oauthErr.Code != business.OAuthErrInvalidClient {
+		t.Errorf("Expected invalid_client without client_id, got %v", err)
+	}
+}
+
+func TestServiceAccountValidation(t *testing.T) {
+	authBusiness := business.NewAuthBusiness(repository.NewAuthRepository(&sql.DB{}), NewTestConfig(t))
+	ctx := context.Background()
+
+	invalid := map[string]business.ServiceAccountInput{
+		"no scopes":  {Name: "Billing"},
+		"user scope": {Name: "Billing", Scopes: []string{"users.read", "openid"}},
+		"bad scope":  {Name: "Billing", Scopes: []string{`users"read`}},
+		"no name":    {Scopes: []string{"users.read"}},
+	}
+	for name, input := range invalid {
+		if _, _, err := authBusiness.CreateServiceAccount(ctx, input); !errors.Is(err, business.ErrInvalidArgument) {
+			t.Errorf("%s: expected ErrInvalidArgument, got %v", name, err)
+		}
+	}
+
+	_, err := authBusiness.IssueServiceToken(ctx, business.ServiceTokenRequest{ClientSecret: "secret"})
+	if !errors.Is(err, business.ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials without client_id, got %v", err)
+	}
+}
+
+func TestAPIKeyValidation(t *testing.T) {
+	authBusiness := business.NewAuthBusiness(repository.NewAuthRepository(&sql.DB{}), NewTestConfig(t))
+	ctx := context.Background()
+	user := business.APIKeyOwner{UserID: "user-uuid"}
+
+	invalid := map[string]business.APIKeyInput{
+		"no owner":     {Name: "CI", Scopes: []string{"users.read"}},
+		"two owners":   {Owner: business.APIKeyOwner{UserID: "user-uuid", ServiceAccountID: "client-uuid"}, Name: "CI", Scopes: []string{"users.read"}},
+		"no name":      {Owner: user, Scopes: []string{"users.read"}},
+		"no scopes":    {Owner: user, Name: "CI"},
+		"bad ip":       {Owner: user, Name: "CI", Scopes: []string{"users.read"}, AllowedIPs: []string{"10.0.0.300"}},
+		"too long":     {Owner: user, Name: "CI", Scopes: []string{"users.read"}, ExpiresIn: 2 * 365 * 24 * time.Hour},
+		"negative ttl": {Owner: user, Name: "CI", Scopes: []string{"users.read"}, ExpiresIn: -time.Hour},
+	}
+	for name, input := range invalid {
+		if _, _, err := authBusiness.CreateAPIKey(ctx, input); !errors.Is(err, business.ErrInvalidArgument) {
+			t.Errorf("%s: expected ErrInvalidArgument, got %v", name, err)
+		}
+	}
+
+	if _, err := authBusiness.ListAPIKeys(ctx, business.APIKeyOwner{}); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument without owner, got %v", err)
+	}
+
+	for _, key := range []string{"", "sr_", "sk_0123456789ab_secret", "sr_0123456789ab_", "sr_not-hex-here!_secret", "sr_0123_secret"} {
+		if _, err := authBusiness.ValidateAPIKey(ctx, key, business.ClientInfo{}); !errors.Is(err, business.ErrInvalidAPIKey) {
+			t.Errorf("ValidateAPIKey(%q): expected ErrInvalidAPIKey, got %v", key, err)
+		}
+	}
+}
+
+func TestPersonalAPIKeyScopes(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+	setup.Config.VerificationPolicy = config.VerificationPolicyRestrictScopes
+
+	user := AddPasswordUser(t, setup, "ivan@example.com", "ivan password")
+	tokens := SignIn(t, setup, user.Email, "ivan password")
+	create := func(scopes ...string) (string, error) {
+		_, plaintext, err := setup.Business.CreateAPIKey(ctx, business.APIKeyInput{
+			Owner: business.APIKeyOwner{UserID: user.UUID}, SessionID: tokens.SessionUUID, Name: "CLI", Scopes: scopes,
+		})
+		return plaintext, err
+	}
+
+	if _, err := create("openid", "admin"); !errors.Is(err, business.ErrScopeNotAllowed) {
+		t.Errorf("Expected ErrScopeNotAllowed for a scope outside API_KEY_USER_SCOPES, got %v", err)
+	}
+	if _, err := create("profile"); !errors.Is(err, business.ErrScopeNotAllowed) {
+		t.Errorf("Expected ErrScopeNotAllowed for an unverified user, got %v", err)
+	}
+	if _, err := create("openid"); err != nil {
+		t.Fatalf("Failed to create key with an unverified scope: %v", err)
+	}
+
+	if _, err := setup.Repo.MarkUserVerified(ctx, user.ID, "email", user.Email); err != nil {
+		t.Fatalf("Failed to verify user: %v", err)
+	}
+	plaintext, err := create("openid", "profile")
+	if err != nil {
+		t.Fatalf("Failed to create key as a verified user: %v", err)
+	}
+
+	// Scopes removed from the allow list after the key was created are dropped
+	setup.Config.APIKeyUserScopes = []string{"openid", "email"}
+	token, err := setup.Business.ValidateAPIKey(ctx, plaintext, business.ClientInfo{IPAddress: "203.0.113.7"})
+	if err != nil {
+		t.Fatalf("Failed to validate key: %v", err)
+	}
+	if token.Scope != "openid" {
+		t.Errorf("Expected scope %q, got %q", "openid", token.Scope)
+	}
+}
+
+func TestFileMailerWritesMessage(t *testing.T) {
+	dir := t.TempDir()
+	m, err := mailer.New(mailer.SinkFile, dir)
+	if err != nil {
+		t.Fatalf("Failed to create file mailer: %v", err)
+	}
+
+	if err := m.Send(context.Background(), mailer.Message{To: "user@example.com", Subject: "Hello", Body: "link"}); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected one mail file, got %d (%v)", len(entries), err)
+	}
+	content, _ := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	if !strings.Contains(string(content), "Subject: Hello") {
+		t.Errorf("Expected subject header in mail file, got %q", content)
+	}
+
+	if _, err := mailer.New("smtp", dir); err == nil {
+		t.Error("Expected error for unknown mail sink")
+	}
+}
+
+func TestFileSMSSenderWritesMessage(t *testing.T) {
+	dir := t.TempDir()
+	sender, err := sms.New(sms.SinkFile, dir)
+	if err != nil {
+		t.Fatalf("Failed to create file sms sender: %v", err)
+	}
+
+	if err := sender.Send(context.Background(), sms.Message{To: "+15551234567", Body: "Your code is 123456"}); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected one sms file, got %d (%v)", len(entries), err)
+	}
+	content, _ := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	if !strings.Contains(string(content), "To: +15551234567") || !strings.Contains(string(content), "123456") {
+		t.Errorf("Expected recipient and body in sms file, got %q", content)
+	}
+
+	if _, err := sms.New("twilio", dir); err == nil {
+		t.Error("Expected error for unknown sms sink")
+	}
+}
+
+func TestPasswordValidation(t *testing.T) {
+	authBusiness := business.NewAuthBusiness(repository.NewAuthRepository(&sql.DB{}), NewTestConfig(t))
+	ctx := context.Background()
+
+	if err := authBusiness.RequestPasswordReset(ctx, " ", business.ClientInfo{}); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument for empty email, got %v", err)
+	}
+
+	resets := map[string]business.ResetPasswordInput{
+		"no token":       {NewPassword: "correct horse battery"},
+		"short":          {Token: "token", NewPassword: "short"},
+		"blank":          {Token: "token", NewPassword: "          "},
+		"over 72 bytes":  {Token: "token", NewPassword: strings.Repeat("a", 73)},
+		"multibyte long": {Token: "token", NewPassword: strings.Repeat("é", 37)},
+	}
+	for name, input := range resets {
+		if _, err := authBusiness.ResetPassword(ctx, input); !errors.Is(err, business.ErrInvalidArgument) {
+			t.Errorf("ResetPassword %s: expected ErrInvalidArgument, got %v", name, err)
+		}
+	}
+
+	changes := map[string]business.ChangePasswordInput{
+		"no current": {UserID: "user-uuid", NewPassword: "correct horse battery"},
+		"short new":  {UserID: "user-uuid", CurrentPassword: "old password", NewPassword: "short"},
+		"no user":    {CurrentPassword: "old password", NewPassword: "correct horse battery"},
+	}
+	for name, input := range changes {
+		if _, err := authBusiness.ChangePassword(ctx, input); !errors.Is(err, business.ErrInvalidArgument) {
+			t.Errorf("ChangePassword %s: expected ErrInvalidArgument, got %v", name, err)
+		}
+	}
+}
+
+func TestContactChangeValidation(t *testing.T) {
+	authBusiness := business.NewAuthBusiness(repository.NewAuthRepository(&sql.DB{}), NewTestConfig(t))
+	ctx := context.Background()
+
+	requests := map[string]business.RequestContactChangeInput{
+		"unknown channel":   {UserID: "user-uuid", Channel: "fax", NewValue: "new@example.com"},
+		"empty value":       {UserID: "user-uuid", Channel: "email", NewValue: "  "},
+		"invalid email":     {UserID: "user-uuid", Channel: "email", NewValue: "not an email"},
+		"display name":      {UserID: "user-uuid", Channel: "email", NewValue: "Jane <jane@example.com>"},
+		"local phone":       {UserID: "user-uuid", Channel: "phone", NewValue: "0123 456789"},
+		"phone without +":   {UserID: "user-uuid", Channel: "phone", NewValue: "15551234567"},
+		"valid but no user": {Channel: "email", NewValue: "new@example.com"},
+	}
+	for name, input := range requests {
+		if _, err := authBusiness.RequestContactChange(ctx, input); !errors.Is(err, business.ErrInvalidArgument) {
+			t.Errorf("RequestContactChange %s: expected ErrInvalidArgument, got %v", name, err)
+		}
+	}
+
+	if _, err := authBusiness.ConfirmContactChange(ctx, " ", business.ClientInfo{}); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument for empty confirm token, got %v", err)
+	}
+	if _, err := authBusiness.UndoContactChange(ctx, "", business.ClientInfo{}); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument for empty undo token, got %v", err)
+	}
+}
+
+func TestAccountLifecycleValidation(t *testing.T) {
+	authBusiness := business.NewAuthBusiness(repository.NewAuthRepository(&sql.DB{}), NewTestConfig(t))
+	ctx := context.Background()
+
+	if _, err := authBusiness.DeactivateAccount(ctx, business.DeactivateAccountInput{}); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument for missing user on deactivate, got %v", err)
+	}
+	longReason := business.DeactivateAccountInput{UserID: "user-uuid", Reason: strings.Repeat("x", 501)}
+	if _, err := authBusiness.DeactivateAccount(ctx, longReason); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument for overlong reason, got %v", err)
+	}
+	if _, err := authBusiness.ReactivateAccount(ctx, "", business.ClientInfo{}); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument for missing user on reactivate, got %v", err)
+	}
+	if _, err := authBusiness.DeleteAccount(ctx, business.DeleteAccountInput{}); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument for missing user on delete, got %v", err)
+	}
+	if _, err := authBusiness.RestoreAccount(ctx, "  ", business.ClientInfo{}); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument for empty restore token, got %v", err)
+	}
+}
+
+func TestImpersonationValidation(t *testing.T) {
+	authBusiness := business.NewAuthBusiness(repository.NewAuthRepository(&sql.DB{}), NewTestConfig(t))
+	ctx := context.Background()
+
+	inputs := map[string]business.ImpersonateInput{
+		"no reason":   {AdminToken: "token", TargetUserID: "user-uuid", Reason: "  "},
+		"long reason": {AdminToken: "token", TargetUserID: "user-uuid", Reason: strings.Repeat("x", 501)},
+		"no target":   {AdminToken: "token", Reason: "ticket 1234"},
+	}
+	for name, input := range inputs {
+		if _, err := authBusiness.Impersonate(ctx, input); !errors.Is(err, business.ErrInvalidArgument) {
+			t.Errorf("Impersonate %s: expected ErrInvalidArgument, got %v", name, err)
+		}
+	}
+
+	bad := business.ImpersonateInput{AdminToken: "not-a-jwt", TargetUserID: "user-uuid", Reason: "ticket 1234"}
+	if _, err := authBusiness.Impersonate(ctx, bad); !errors.Is(err, business.ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken for malformed admin token, got %v", err)
+	}
+
+	if _, err := authBusiness.ListImpersonations(ctx, ""); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument for missing user, got %v", err)
+	}
+}
+
+func TestEventSinks(t *testing.T) {
+	publisher, err := events.New(events.SinkLog)
+	if err != nil {
+		t.Fatalf("Failed to create log publisher: %v", err)
+	}
+	if err := publisher.Publish(context.Background(), events.Event{Type: events.TypePasswordChanged, UserID: "user-uuid"}); err != nil {
+		t.Errorf("Expected log publisher to accept event, got %v", err)
+	}
+
+	if _, err := events.New("kafka"); err == nil {
+		t.Error("Expected error for unknown event sink")
+	}
+}
+
+func TestVerificationValidation(t *testing.T) {
+	authBusiness := business.NewAuthBusiness(repository.NewAuthRepository(&sql.DB{}), NewTestConfig(t))
+	ctx := context.Background()
+
+	for _, channel := range []string{"", "fax"} {
+		_, err := authBusiness.StartVerification(ctx, business.StartVerificationInput{UserID: "user-uuid", Channel: channel})
+		if !errors.Is(err, business.ErrInvalidArgument) {
+			t.Errorf("StartVerification(channel %q): expected ErrInvalidArgument, got %v", channel, err)
+		}
+	}
+
+	if _, err := authBusiness.StartVerification(ctx, business.StartVerificationInput{Channel: "email"}); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument without user, got %v", err)
+	}
+
+	_, err := authBusiness.ConfirmVerification(ctx, business.ConfirmVerificationInput{UserID: "user-uuid", Channel: "phone"})
+	if !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument without code, got %v", err)
+	}
+
+	for _, code := range []string{"12345", "1234567", "12a456"} {
+		_, err := authBusiness.ConfirmVerification(ctx, business.ConfirmVerificationInput{UserID: "user-uuid", Channel: "email", Code: code})
+		if !errors.Is(err, business.ErrInvalidVerificationCode) {
+			t.Errorf("ConfirmVerification(%q): expected ErrInvalidVerificationCode, got %v", code, err)
+		}
+	}
+}
+
+func TestAccessTokenRequiresSession(t *testing.T) {
+	cfg := NewTestConfig(t)
+	authBusiness := business.NewAuthBusiness(repository.NewAuthRepository(&sql.DB{}), cfg)
+
+	claims := &business.TokenClaims{Type: business.TokenTypeAccess}
+	claims.SetSubject("00000000-0000-0000-0000-000000000001")
+	token, err := jwt.NewJWTManager(cfg.JWTSecret).GenerateTokenWithExpiry(claims, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	if _, err := authBusiness.ValidateAccessToken(context.Background(), token); !errors.Is(err, business.ErrInvalidToken) {
+		t.Errorf("Expected access token without a session to be rejected, got %v", err)
+	}
+}
+
+func TestRevokeSessionRequiresSessionID(t *testing.T) {
+	authBusiness := business.NewAuthBusiness(repository.NewAuthRepository(&sql.DB{}), NewTestConfig(t))
+
+	if err := authBusiness.RevokeSession(context.Background(), "user", " ", business.ClientInfo{}); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument, got %v", err)
+	}
+	if _, err := authBusiness.RevokeOtherSessions(context.Background(), "user", "", business.ClientInfo{}); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument, got %v", err)
+	}
+}
+
+func TestRefreshTokensRequiresToken(t *testing.T) {
+	authBusiness := business.NewAuthBusiness(repository.NewAuthRepository(&sql.DB{}), NewTestConfig(t))
+
+	if _, err := authBusiness.RefreshTokens(context.Background(), business.RefreshTokensInput{RefreshToken: "  "}); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument, got %v", err)
+	}
+}
+
+func TestDeviceRPCsRequireUser(t *testing.T) {
+	authBusiness := business.NewAuthBusiness(repository.NewAuthRepository(&sql.DB{}), NewTestConfig(t))
+
+	if _, err := authBusiness.TrustDevice(context.Background(), "", "device"); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument, got %v", err)
+	}
+	if err := authBusiness.RemoveDevice(context.Background(), "", "device"); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument, got %v", err)
+	}
+}
+
+func TestLoginEventFilterValidation(t *testing.T) {
+	authBusiness := business.NewAuthBusiness(repository.NewAuthRepository(&sql.DB{}), NewTestConfig(t))
+
+	filters := []business.LoginEventFilter{
+		{EventTypes: []string{"not_an_event"}},
+		{IP: "300.1.1.1"},
+		{From: time.Now(), To: time.Now().Add(-time.Hour)},
+		{PageToken: "!!"},
+	}
+	for _, filter := range filters {
+		if _, err := authBusiness.ListLoginEvents(context.Background(), filter); !errors.Is(err, business.ErrInvalidArgument) {
+			t.Errorf("Expected ErrInvalidArgument for %+v, got %v", filter, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if _, err := authBusiness.ExportLoginEvents(context.Background(), business.LoginEventFilter{}, "xml", &buf); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument, got %v", err)
+	}
+}
+
 // TODO: Add more business layer tests when methods are implemented
 // - TestRegisterUser
-// - TestLoginUser
-// - TestValidateToken
 // - TestRefreshToken
 // - TestLogoutUser
+
+func TestLoginAndRefreshWithMemoryStore(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	user := &models.User{Email: "erin@example.com", PasswordHash: string(hash)}
+	if err := setup.Repo.AddUser(ctx, user); err != nil {
+		t.Fatalf("Failed to add user: %v", err)
+	}
+
+	if _, err := setup.Business.Login(ctx, business.LoginInput{Email: user.Email, Password: "wrong"}); !errors.Is(err, business.ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials, got %v", err)
+	}
+
+	result, err := setup.Business.Login(ctx, business.LoginInput{Email: user.Email, Password: "correct horse"})
+	if err != nil {
+		t.Fatalf("Failed to log in: %v", err)
+	}
+	if result.Tokens == nil {
+		t.Fatalf("Expected tokens, got %+v", result)
+	}
+
+	refreshed, err := setup.Business.RefreshTokens(ctx, business.RefreshTokensInput{RefreshToken: result.Tokens.RefreshToken})
+	if err != nil {
+		t.Fatalf("Failed to refresh tokens: %v", err)
+	}
+	if refreshed.SessionUUID != result.Tokens.SessionUUID || refreshed.RefreshToken == result.Tokens.RefreshToken {
+		t.Errorf("Expected a rotated refresh token for the same session, got %+v", refreshed)
+	}
+
+	sessions, err := setup.Business.ListSessions(ctx, user.UUID)
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("Expected one session, got %d, %v", len(sessions), err)
+	}
+	if err := setup.Business.RevokeSession(ctx, user.UUID, sessions[0].UUID, business.ClientInfo{}); err != nil {
+		t.Fatalf("Failed to revoke session: %v", err)
+	}
+	if _, err := setup.Business.ValidateAccessToken(ctx, refreshed.AccessToken); !errors.Is(err, business.ErrInvalidToken) {
+		t.Errorf("Expected access token of a revoked session to be rejected, got %v", err)
+	}
+}
+
+func TestRunMaintenance(t *testing.T) {
+	ctx := context.Background()
+	clock := time.Now().Add(-60 * 24 * time.Hour)
+	store := memory.New(memory.WithClock(func() time.Time { return clock }))
+
+	user := &models.User{Email: "frank@example.com", PasswordHash: "hash"}
+	if err := store.AddUser(ctx, user); err != nil {
+		t.Fatalf("Failed to add user: %v", err)
+	}
+
+	// Sessions revoked 60 days ago and one still live, all created back then
+	var sessions []*models.Session
+	for _, hash := range []string{"old-1", "old-2", "live"} {
+		session := &models.Session{UserID: user.ID, RefreshTokenHash: hash, ExpiresAt: time.Now().Add(24 * time.Hour)}
+		if err := store.CreateSession(ctx, session); err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err := store.RotateRefreshToken(ctx, sessions[0].ID, "old-1", "old-1b", clock); err != nil {
+		t.Fatalf("Failed to rotate refresh token: %v", err)
+	}
+	for _, session := range sessions[:2] {
+		if _, err := store.RevokeSession(ctx, user.ID, session.UUID, "test"); err != nil {
+			t.Fatalf("Failed to revoke session: %v", err)
+		}
+	}
+
+	// An OTP that expired long ago and one that is still pending
+	if err := store.CreateOTP(ctx, &models.OTPToken{UserID: user.ID, CodeHash: "a", UseCase: "login", ExpiresAt: clock.Add(time.Minute)}); err != nil {
+		t.Fatalf("Failed to create otp: %v", err)
+	}
+	clock = time.Now()
+	if err := store.CreateOTP(ctx, &models.OTPToken{UserID: user.ID, CodeHash: "b", UseCase: "verify", ExpiresAt: clock.Add(time.Minute)}); err != nil {
+		t.Fatalf("Failed to create otp: %v", err)
+	}
+
+	cfg := NewTestConfig(t)
+	cfg.MaintenanceBatchSize = 1
+	cfg.MaintenanceRetention = map[string]time.Duration{"otp_tokens": 24 * time.Hour, "sessions": 30 * 24 * time.Hour}
+	authBusiness := business.NewAuthBusiness(store, cfg)
+
+	unlock, ok, err := store.TryLockMaintenance(ctx)
+	if err != nil || !ok {
+		t.Fatalf("Failed to take maintenance lock: %v", err)
+	}
+	result, err := authBusiness.RunMaintenance(ctx)
+	if err != nil || !result.Skipped {
+		t.Errorf("Expected a skipped run while another replica holds the lock, got %+v, %v", result, err)
+	}
+	unlock()
+
+	result, err = authBusiness.RunMaintenance(ctx)
+	if err != nil {
+		t.Fatalf("Maintenance failed: %v", err)
+	}
+	if result.Skipped || result.Removed["sessions"] != 2 || result.Removed["otp_tokens"] != 1 {
+		t.Errorf("Expected 2 sessions and 1 otp removed, got %+v", result)
+	}
+
+	if _, err := store.GetSessionByID(ctx, sessions[0].ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Expected revoked session to be removed, got %v", err)
+	}
+	if _, err := store.GetRefreshTokenHistory(ctx, "old-1"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Expected refresh token history to go with its session, got %v", err)
+	}
+	if _, err := store.GetSessionByID(ctx, sessions[2].ID); err != nil {
+		t.Errorf("Expected live session to be kept, got %v", err)
+	}
+	if count, _, err := store.OTPSendStats(ctx, user.ID, "verify", clock.Add(-time.Hour)); err != nil || count != 1 {
+		t.Errorf("Expected pending otp to be kept, got %d, %v", count, err)
+	}
+}
+
+func TestImpersonationCannotChangeAccount(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+
+	admin := AddPasswordUser(t, setup, "admin@example.com", "admin password")
+	target := AddPasswordUser(t, setup, "grace@example.com", "grace password")
+	setup.Config.ImpersonationAdmins = []string{admin.UUID}
+
+	adminTokens := SignIn(t, setup, admin.Email, "admin password")
+	impersonation, err := setup.Business.Impersonate(ctx, business.ImpersonateInput{
+		AdminToken:   adminTokens.AccessToken,
+		TargetUserID: target.UUID,
+		Reason:       "ticket 1234",
+	})
+	if err != nil {
+		t.Fatalf("Failed to impersonate: %v", err)
+	}
+	sid := impersonation.SessionUUID
+
+	refused := map[string]func() error{
+		"change password": func() error {
+			_, err := setup.Business.ChangePassword(ctx, business.ChangePasswordInput{
+				UserID: target.UUID, SessionID: sid, CurrentPassword: "grace password", NewPassword: "new grace password",
+			})
+			return err
+		},
+		"deactivate": func() error {
+			_, err := setup.Business.DeactivateAccount(ctx, business.DeactivateAccountInput{UserID: target.UUID, SessionID: sid})
+			return err
+		},
+		"delete": func() error {
+			_, err := setup.Business.DeleteAccount(ctx, business.DeleteAccountInput{
+				UserID: target.UUID, SessionID: sid, CurrentPassword: "grace password",
+			})
+			return err
+		},
+		"create api key": func() error {
+			_, _, err := setup.Business.CreateAPIKey(ctx, business.APIKeyInput{
+				Owner: business.APIKeyOwner{UserID: target.UUID}, SessionID: sid, Name: "CI", Scopes: []string{"openid"},
+			})
+			return err
+		},
+		"enroll totp": func() error {
+			_, err := setup.Business.EnrollTOTP(ctx, target.UUID, sid)
+			return err
+		},
+		"disable totp": func() error {
+			return setup.Business.DisableTOTP(ctx, target.UUID, sid, "123456")
+		},
+		"link provider": func() error {
+			_, err := setup.Business.LinkProvider(ctx, business.LinkProviderInput{
+				UserID: target.UUID, SessionID: sid, Provider: "github", Code: "code", State: "state",
+			})
+			return err
+		},
+		"unlink provider": func() error {
+			return setup.Business.UnlinkProvider(ctx, target.UUID, sid, "link", business.ClientInfo{})
+		},
+		"set primary provider": func() error {
+			_, err := setup.Business.SetPrimaryProvider(ctx, target.UUID, sid, "link", business.ClientInfo{})
+			return err
+		},
+		"change email": func() error {
+			_, err := setup.Business.RequestContactChange(ctx, business.RequestContactChangeInput{
+				UserID: target.UUID, SessionID: sid, Channel: "email", NewValue: "mallory@example.com", CurrentPassword: "grace password",
+			})
+			return err
+		},
+		"register passkey": func() error {
+			_, err := setup.Business.BeginPasskeyRegistration(ctx, target.UUID, sid)
+			return err
+		},
+		"approve oauth authorization": func() error {
+			_, err := setup.Business.ApproveAuthorization(ctx, "request", impersonation.AccessToken)
+			return err
+		},
+	}
+	for name, call := range refused {
+		if err := call(); !errors.Is(err, business.ErrImpersonatedSession) {
+			t.Errorf("%s: expected ErrImpersonatedSession, got %v", name, err)
+		}
+	}
+
+	if _, err := setup.Business.ChangePassword(ctx, business.ChangePasswordInput{
+		UserID: target.UUID, CurrentPassword: "grace password", NewPassword: "new grace password",
+	}); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument for password change without a session, got %v", err)
+	}
+
+	own := SignIn(t, setup, target.Email, "grace password")
+	if _, err := setup.Business.EnrollTOTP(ctx, target.UUID, own.SessionUUID); err != nil {
+		t.Errorf("Expected the user's own session to enroll TOTP, got %v", err)
+	}
+	if _, err := setup.Business.EnrollTOTP(ctx, target.UUID, adminTokens.SessionUUID); !errors.Is(err, business.ErrSessionNotFound) {
+		t.Errorf("Expected another user's session to be refused, got %v", err)
+	}
+}
+
+// enrollTOTP turns on two-factor from one of the user's sessions and returns the recovery codes
+func enrollTOTP(t *testing.T, setup *TestSetup, user *models.User, sessionUUID string) []string {
+	t.Helper()
+	ctx := context.Background()
+	enrollment, err := setup.Business.EnrollTOTP(ctx, user.UUID, sessionUUID)
+	if err != nil {
+		t.Fatalf("Failed to enroll TOTP: %v", err)
+	}
+	code, err := totp.GenerateCode(enrollment.Secret, time.Now(), totp.DefaultOptions())
+	if err != nil {
+		t.Fatalf("Failed to generate TOTP code: %v", err)
+	}
+	recoveryCodes, err := setup.Business.ConfirmTOTP(ctx, user.UUID, code)
+	if err != nil {
+		t.Fatalf("Failed to confirm TOTP: %v", err)
+	}
+	return recoveryCodes
+}
+
+// mfaToken signs in a two-factor user and returns the mfa_pending token
+func mfaToken(t *testing.T, setup *TestSetup, email, password string) string {
+	t.Helper()
+	result, err := setup.Business.Login(context.Background(), business.LoginInput{Email: email, Password: password})
+	if err != nil {
+		t.Fatalf("Failed to log in: %v", err)
+	}
+	if !result.MFARequired {
+		t.Fatalf("Expected an mfa_pending token, got %+v", result)
+	}
+	return result.MFAToken
+}
+
+func TestVerifyMFACapsAttemptsAndBurnsToken(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+	setup.Config.MFAMaxAttempts = 3
+
+	user := AddPasswordUser(t, setup, "heidi@example.com", "heidi password")
+	recoveryCodes := enrollTOTP(t, setup, user, SignIn(t, setup, user.Email, "heidi password").SessionUUID)
+
+	token := mfaToken(t, setup, user.Email, "heidi password")
+	for i := 0; i < setup.Config.MFAMaxAttempts; i++ {
+		_, err := setup.Business.VerifyMFA(ctx, business.VerifyMFAInput{MFAToken: token, Code: "wrong-code"})
+		if !errors.Is(err, business.ErrInvalidMFACode) {
+			t.Fatalf("Attempt %d: expected ErrInvalidMFACode, got %v", i+1, err)
+		}
+	}
+	if _, err := setup.Business.VerifyMFA(ctx, business.VerifyMFAInput{MFAToken: token, Code: recoveryCodes[0]}); !errors.Is(err, business.ErrInvalidToken) {
+		t.Errorf("Expected the token to be burned after %d attempts, got %v", setup.Config.MFAMaxAttempts, err)
+	}
+
+	token = mfaToken(t, setup, user.Email, "heidi password")
+	result, err := setup.Business.VerifyMFA(ctx, business.VerifyMFAInput{MFAToken: token, Code: recoveryCodes[0]})
+	if err != nil || result.Tokens == nil {
+		t.Fatalf("Expected a session for a fresh token, got %+v, %v", result, err)
+	}
+	if _, err := setup.Business.VerifyMFA(ctx, business.VerifyMFAInput{MFAToken: token, Code: recoveryCodes[1]}); !errors.Is(err, business.ErrInvalidToken) {
+		t.Errorf("Expected a used token to be refused, got %v", err)
+	}
+}
+
+func TestVerifyMFALocksUserAfterRepeatedFailures(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+	setup.Config.MFAMaxAttempts = 2
+	setup.Config.MFAMaxFailures = 4
+
+	user := AddPasswordUser(t, setup, "ivan@example.com", "ivan password")
+	session := SignIn(t, setup, user.Email, "ivan password")
+	recoveryCodes := enrollTOTP(t, setup, user, session.SessionUUID)
+
+	// Fresh tokens do not reset the count
+	for failures := 0; failures < setup.Config.MFAMaxFailures; {
+		token := mfaToken(t, setup, user.Email, "ivan password")
+		for i := 0; i < setup.Config.MFAMaxAttempts; i++ {
+			if _, err := setup.Business.VerifyMFA(ctx, business.VerifyMFAInput{MFAToken: token, Code: "wrong-code"}); !errors.Is(err, business.ErrInvalidMFACode) {
+				t.Fatalf("Expected ErrInvalidMFACode, got %v", err)
+			}
+			failures++
+		}
+	}
+
+	token := mfaToken(t, setup, user.Email, "ivan password")
+	if _, err := setup.Business.VerifyMFA(ctx, business.VerifyMFAInput{MFAToken: token, Code: recoveryCodes[0]}); !errors.Is(err, business.ErrMFALocked) {
+		t.Errorf("Expected ErrMFALocked, got %v", err)
+	}
+	if err := setup.Business.DisableTOTP(ctx, user.UUID, session.SessionUUID, recoveryCodes[0]); !errors.Is(err, business.ErrMFALocked) {
+		t.Errorf("Expected DisableTOTP to be locked as well, got %v", err)
+	}
+}
+
+// Addresses in the GeoIP fixtures. sydneyHomeASNIP shares berlinIP's ASN, as
+// with a corporate VPN or a mobile carrier roaming abroad.
+const (
+	berlinIP        = "198.51.100.10"
+	sydneyHomeASNIP = "192.0.2.10"
+	sydneyIP        = "203.0.113.10"
+)
+
+// withGeoIP rebuilds setup.Business with small city and ASN databases
+func withGeoIP(t *testing.T, setup *TestSetup) {
+	t.Helper()
+	cityDB := buildGeoIP(t, "GeoLite2-City",
+		geoiptest.Network{CIDR: "198.51.100.0/24", Record: geoiptest.CityRecord("DE", "Berlin", 52.52, 13.40)},
+		geoiptest.Network{CIDR: "192.0.2.0/24", Record: geoiptest.CityRecord("AU", "Sydney", -33.87, 151.21)},
+		geoiptest.Network{CIDR: "203.0.113.0/24", Record: geoiptest.CityRecord("AU", "Sydney", -33.87, 151.21)},
+	)
+	asnDB := buildGeoIP(t, "GeoLite2-ASN",
+		geoiptest.Network{CIDR: "198.51.100.0/24", Record: geoiptest.ASNRecord(64500, "Example Berlin")},
+		geoiptest.Network{CIDR: "192.0.2.0/24", Record: geoiptest.ASNRecord(64500, "Example Berlin")},
+		geoiptest.Network{CIDR: "203.0.113.0/24", Record: geoiptest.ASNRecord(64501, "Example Sydney")},
+	)
+	setup.Business = business.NewAuthBusiness(setup.Repo, setup.Config, business.WithGeoIP(cityDB, asnDB))
+}
+
+func buildGeoIP(t *testing.T, databaseType string, networks ...geoiptest.Network) *geoip.Reader {
+	t.Helper()
+	data, err := geoiptest.Build(geoiptest.Options{DatabaseType: databaseType}, networks...)
+	if err != nil {
+		t.Fatalf("Failed to build %s fixture: %v", databaseType, err)
+	}
+	reader, err := geoip.FromBytes(data)
+	if err != nil {
+		t.Fatalf("Failed to open %s fixture: %v", databaseType, err)
+	}
+	return reader
+}
+
+// latestRisk returns meta["risk"] of the user's most recent login log of eventType
+func latestRisk(t *testing.T, setup *TestSetup, user *models.User, eventType string) (*models.LoginLog, map[string]interface{}) {
+	t.Helper()
+	logs, err := setup.Repo.ListLoginLogs(context.Background(), repository.LoginLogFilter{
+		UserID: &user.ID, EventTypes: []string{eventType}, Limit: 1,
+	})
+	if err != nil || len(logs) == 0 {
+		t.Fatalf("Expected a %s login log, got %v (err %v)", eventType, logs, err)
+	}
+	risk, ok := logs[0].Meta["risk"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected meta.risk on the %s log, got %v", eventType, logs[0].Meta)
+	}
+	return logs[0], risk
+}
+
+// hasReason checks meta.risk.reasons as decoded from the stored JSON
+func hasReason(risk map[string]interface{}, reason string) bool {
+	reasons, _ := risk["reasons"].([]interface{})
+	for _, r := range reasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLoginRiskBlocksImpossibleTravelFromNewDeviceAndNetwork(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	withGeoIP(t, setup)
+	ctx := context.Background()
+
+	user := AddPasswordUser(t, setup, "judy@example.com", "judy password")
+	login := func(ip, deviceID string) (*business.LoginResult, error) {
+		return setup.Business.Login(ctx, business.LoginInput{
+			Email: user.Email, Password: "judy password",
+			Client: business.ClientInfo{IPAddress: ip, DeviceID: deviceID},
+		})
+	}
+
+	if _, err := login(berlinIP, "laptop"); err != nil {
+		t.Fatalf("First sign-in failed: %v", err)
+	}
+	_, risk := latestRisk(t, setup, user, models.EventLoginSuccess)
+	if risk["decision"] != business.RiskDecisionAllow || risk["country"] != "DE" {
+		t.Errorf("Expected an allowed sign-in from DE without history, got %v", risk)
+	}
+
+	// Minutes later from Sydney, on another network and an unknown device
+	if _, err := login(sydneyIP, "phone"); !errors.Is(err, business.ErrLoginBlocked) {
+		t.Fatalf("Expected ErrLoginBlocked, got %v", err)
+	}
+	entry, risk := latestRisk(t, setup, user, models.EventLoginFailed)
+	if entry.FailureReason == nil || *entry.FailureReason != "risk_blocked" {
+		t.Errorf("Expected failure reason risk_blocked, got %v", entry.FailureReason)
+	}
+	if risk["decision"] != business.RiskDecisionBlock {
+		t.Errorf("Expected decision block, got %v", risk["decision"])
+	}
+	for _, reason := range []string{"impossible_travel", "new_device", "new_asn"} {
+		if !hasReason(risk, reason) {
+			t.Errorf("Expected reason %s, got %v", reason, risk["reasons"])
+		}
+	}
+	if score, _ := risk["score"].(float64); int(score) < setup.Config.RiskBlockScore {
+		t.Errorf("Expected score of at least %d, got %v", setup.Config.RiskBlockScore, risk["score"])
+	}
+}
+
+func TestLoginRiskStepsUpTrustedDeviceToMFA(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	withGeoIP(t, setup)
+	ctx := context.Background()
+
+	user := AddPasswordUser(t, setup, "karl@example.com", "karl password")
+	client := business.ClientInfo{IPAddress: berlinIP, DeviceID: "laptop"}
+	first, err := setup.Business.Login(ctx, business.LoginInput{Email: user.Email, Password: "karl password", Client: client})
+	if err != nil {
+		t.Fatalf("First sign-in failed: %v", err)
+	}
+	enrollTOTP(t, setup, user, first.Tokens.SessionUUID)
+
+	devices, err := setup.Repo.ListDevices(ctx, user.ID)
+	if err != nil || len(devices) != 1 {
+		t.Fatalf("Expected one device, got %v (err %v)", devices, err)
+	}
+	trustedAt := time.Now()
+	if _, err := setup.Repo.SetDeviceTrust(ctx, user.ID, devices[0].UUID, &trustedAt); err != nil {
+		t.Fatalf("Failed to trust device: %v", err)
+	}
+
+	// The trusted device skips the second factor from a familiar place
+	result, err := setup.Business.Login(ctx, business.LoginInput{Email: user.Email, Password: "karl password", Client: client})
+	if err != nil || result.MFARequired {
+		t.Fatalf("Expected the trusted device to skip MFA, got %+v (err %v)", result, err)
+	}
+
+	// The same device on the same network, but too far away too soon
+	client.IPAddress = sydneyHomeASNIP
+	result, err = setup.Business.Login(ctx, business.LoginInput{Email: user.Email, Password: "karl password", Client: client})
+	if err != nil {
+		t.Fatalf("Expected a step-up, got %v", err)
+	}
+	if !result.MFARequired || result.Tokens != nil {
+		t.Errorf("Expected step-up risk to require MFA on a trusted device, got %+v", result)
+	}
+	_, risk := latestRisk(t, setup, user, models.EventMFAChallenge)
+	if risk["decision"] != business.RiskDecisionStepUp || !hasReason(risk, "impossible_travel") {
+		t.Errorf("Expected a step_up for impossible travel, got %v", risk)
+	}
+	if hasReason(risk, "new_device") || hasReason(risk, "new_asn") {
+		t.Errorf("Expected only impossible travel, got %v", risk["reasons"])
+	}
+}
+
+// addOAuthProvider registers a fake provider under name and returns it
+func addOAuthProvider(t *testing.T, setup *TestSetup, name string) *oauth2test.Provider {
+	t.Helper()
+	fake := oauth2test.NewProvider("example-client", "example-secret")
+	t.Cleanup(fake.Close)
+
+	userinfoURL, scopes := fake.UserinfoURL(), "profile email"
+	err := setup.Repo.CreateProvider(context.Background(), &models.Provider{
+		Name:             name,
+		DisplayName:      name,
+		ClientID:         fake.ClientID,
+		ClientSecret:     fake.ClientSecret,
+		AuthorizationURL: fake.AuthURL(),
+		TokenURL:         fake.TokenURL(),
+		UserinfoURL:      &userinfoURL,
+		Scopes:           &scopes,
+		IsActive:         true,
+		IsEnabled:        true,
+		Config:           map[string]interface{}{},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	// The provider was written to the store directly, as another replica would
+	if _, err := setup.Business.RefreshProviders(context.Background()); err != nil {
+		t.Fatalf("Failed to refresh providers: %v", err)
+	}
+	return fake
+}
+
+// oauthCallback runs BeginOAuth with provider and has the fake approve it for profile
+func oauthCallback(t *testing.T, setup *TestSetup, fake *oauth2test.Provider, provider, userUUID string, profile map[string]interface{}) (code, state string) {
+	t.Helper()
+	begin, err := setup.Business.BeginOAuth(context.Background(), business.BeginOAuthInput{Provider: provider, UserID: userUUID})
+	if err != nil {
+		t.Fatalf("Failed to begin OAuth: %v", err)
+	}
+	code, state, err = fake.Authorize(begin.AuthorizationURL, profile)
+	if err != nil {
+		t.Fatalf("Provider refused the authorization request: %v", err)
+	}
+	return code, state
+}
+
+// oauthSignIn completes an OAuth sign-in with the fake registered as "example"
+func oauthSignIn(t *testing.T, setup *TestSetup, fake *oauth2test.Provider, profile map[string]interface{}) (*business.LoginResult, error) {
+	t.Helper()
+	code, state := oauthCallback(t, setup, fake, "example", "", profile)
+	return setup.Business.CompleteOAuth(context.Background(), business.CompleteOAuthInput{Provider: "example", Code: code, State: state})
+}
+
+func TestCompleteOAuthCreatesAndSignsInUser(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+	fake := addOAuthProvider(t, setup, "example")
+	profile := map[string]interface{}{"sub": "ex-1001", "email": "lena@example.com", "email_verified": true, "name": "Lena"}
+
+	result, err := oauthSignIn(t, setup, fake, profile)
+	if err != nil {
+		t.Fatalf("Failed to complete OAuth: %v", err)
+	}
+	if result.Tokens == nil || result.LinkRequired {
+		t.Fatalf("Expected a new user to be signed in, got %+v", result)
+	}
+	user, err := setup.Repo.GetUserByEmail(ctx, "lena@example.com")
+	if err != nil {
+		t.Fatalf("Expected the user to be created: %v", err)
+	}
+	if !user.IsVerified || user.UUID != result.User.UUID {
+		t.Errorf("Expected a verified user %s, got %+v", result.User.UUID, user)
+	}
+
+	links, err := setup.Repo.ListUserProviders(ctx, user.ID)
+	if err != nil || len(links) != 1 {
+		t.Fatalf("Expected one provider link, got %v (err %v)", links, err)
+	}
+	if links[0].ProviderUserID != "ex-1001" || !links[0].IsPrimary || links[0].AccessToken == nil {
+		t.Errorf("Expected a primary link to ex-1001 with the provider tokens, got %+v", links[0])
+	}
+
+	// Signing in again finds the user through the link
+	again, err := oauthSignIn(t, setup, fake, profile)
+	if err != nil {
+		t.Fatalf("Failed to sign in again: %v", err)
+	}
+	if again.Tokens == nil || again.User.UUID != user.UUID {
+		t.Errorf("Expected %s to be signed in again, got %+v", user.UUID, again)
+	}
+	if links, _ := setup.Repo.ListUserProviders(ctx, user.ID); len(links) != 1 {
+		t.Errorf("Expected still one provider link, got %d", len(links))
+	}
+
+	unverified := map[string]interface{}{"sub": "ex-1002", "email": "otto@example.com", "email_verified": false}
+	if _, err := oauthSignIn(t, setup, fake, unverified); !errors.Is(err, business.ErrOAuthEmailUnverified) {
+		t.Errorf("Expected ErrOAuthEmailUnverified, got %v", err)
+	}
+}
+
+func TestCompleteOAuthRequiresLinkForExistingEmail(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+	fake := addOAuthProvider(t, setup, "example")
+	owner := AddPasswordUser(t, setup, "mia@example.com", "mia password")
+
+	result, err := oauthSignIn(t, setup, fake, map[string]interface{}{"sub": "ex-2001", "email": "mia@example.com", "email_verified": true})
+	if err != nil {
+		t.Fatalf("Failed to complete OAuth: %v", err)
+	}
+	if !result.LinkRequired || result.LinkToken == "" || result.Tokens != nil {
+		t.Fatalf("Expected LinkRequired without a session, got %+v", result)
+	}
+	if result.User.UUID != owner.UUID {
+		t.Errorf("Expected the link to target %s, got %s", owner.UUID, result.User.UUID)
+	}
+	if links, _ := setup.Repo.ListUserProviders(ctx, owner.ID); len(links) != 0 {
+		t.Errorf("Expected no link before the owner confirms, got %d", len(links))
+	}
+	logs, _ := setup.Repo.ListLoginLogs(ctx, repository.LoginLogFilter{
+		UserID: &owner.ID, EventTypes: []string{models.EventLoginFailed}, Limit: 10,
+	})
+	if len(logs) != 1 || logs[0].FailureReason == nil || *logs[0].FailureReason != "oauth_link_required" {
+		t.Errorf("Expected an oauth_link_required entry, got %v", logs)
+	}
+}
+
+func TestRefreshTokenReuse(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+	setup.Config.RefreshTokenReuseGrace = time.Minute
+
+	user := AddPasswordUser(t, setup, "nina@example.com", "nina password")
+	first := SignIn(t, setup, user.Email, "nina password")
+	refresh := func(token string) (*business.TokenPair, error) {
+		return setup.Business.RefreshTokens(ctx, business.RefreshTokensInput{RefreshToken: token})
+	}
+
+	second, err := refresh(first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+	// A client retrying a refresh whose response it lost is not an attack
+	if _, err := refresh(first.RefreshToken); !errors.Is(err, business.ErrRefreshConflict) {
+		t.Fatalf("Expected ErrRefreshConflict within the grace period, got %v", err)
+	}
+	third, err := refresh(second.RefreshToken)
+	if err != nil {
+		t.Fatalf("Expected the session to survive a reuse within the grace period: %v", err)
+	}
+
+	// Outside the grace period a rotated token means it was stolen
+	setup.Config.RefreshTokenReuseGrace = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	if _, err := refresh(second.RefreshToken); !errors.Is(err, business.ErrInvalidToken) {
+		t.Fatalf("Expected ErrInvalidToken for a reused token, got %v", err)
+	}
+	session, err := setup.Repo.GetSessionByUUID(ctx, first.SessionUUID)
+	if err != nil {
+		t.Fatalf("Failed to load session: %v", err)
+	}
+	if session.RevokedAt == nil {
+		t.Errorf("Expected the session to be revoked after reuse")
+	}
+	if _, err := refresh(third.RefreshToken); !errors.Is(err, business.ErrInvalidToken) {
+		t.Errorf("Expected the current refresh token to die with the session, got %v", err)
+	}
+	logs, _ := setup.Repo.ListLoginLogs(ctx, repository.LoginLogFilter{
+		UserID: &user.ID, EventTypes: []string{models.EventRefreshTokenReused}, Limit: 10,
+	})
+	if len(logs) != 1 {
+		t.Errorf("Expected one refresh_token_reused entry, got %d", len(logs))
+	}
+}
+
+func TestUnlinkProviderKeepsLastLoginMethod(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+	fake := addOAuthProvider(t, setup, "example")
+
+	result, err := oauthSignIn(t, setup, fake, map[string]interface{}{"sub": "ex-3001", "email": "olga@example.com", "email_verified": true})
+	if err != nil || result.Tokens == nil {
+		t.Fatalf("Failed to sign up with the provider: %+v (err %v)", result, err)
+	}
+	user, sid := result.User, result.Tokens.SessionUUID
+
+	// An account at a second provider, linked from the signed-in session
+	other := addOAuthProvider(t, setup, "other")
+	code, state := oauthCallback(t, setup, other, "other", user.UUID, map[string]interface{}{"sub": "ex-3002", "email": "olga@work.example.com", "email_verified": true})
+	second, err := setup.Business.LinkProvider(ctx, business.LinkProviderInput{
+		UserID: user.UUID, SessionID: sid, Provider: "other", Code: code, State: state,
+	})
+	if err != nil {
+		t.Fatalf("Failed to link a second account: %v", err)
+	}
+
+	if err := setup.Business.UnlinkProvider(ctx, user.UUID, sid, second.Link.UUID, business.ClientInfo{}); err != nil {
+		t.Fatalf("Failed to unlink the second account: %v", err)
+	}
+	links, err := setup.Repo.ListUserProviders(ctx, user.ID)
+	if err != nil || len(links) != 1 {
+		t.Fatalf("Expected one link left, got %v (err %v)", links, err)
+	}
+	if err := setup.Business.UnlinkProvider(ctx, user.UUID, sid, links[0].UUID, business.ClientInfo{}); !errors.Is(err, business.ErrLastLoginMethod) {
+		t.Errorf("Expected ErrLastLoginMethod for the only way to sign in, got %v", err)
+	}
+	if links, _ := setup.Repo.ListUserProviders(ctx, user.ID); len(links) != 1 {
+		t.Errorf("Expected the last link to be kept, got %d", len(links))
+	}
+}
+
+func TestLinkByEmailMatchRequiresFreshSignIn(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+	fake := addOAuthProvider(t, setup, "example")
+	owner := AddPasswordUser(t, setup, "pia@example.com", "pia password")
+	profile := map[string]interface{}{"sub": "ex-4001", "email": owner.Email, "email_verified": true}
+
+	staleSession := SignIn(t, setup, owner.Email, "pia password").SessionUUID
+	pending, err := oauthSignIn(t, setup, fake, profile)
+	if err != nil || !pending.LinkRequired {
+		t.Fatalf("Expected LinkRequired, got %+v (err %v)", pending, err)
+	}
+	link := func(sessionUUID, token string) (*business.LinkedProvider, error) {
+		return setup.Business.LinkProvider(ctx, business.LinkProviderInput{UserID: owner.UUID, SessionID: sessionUUID, LinkToken: token})
+	}
+
+	// A session from before the provider sign-in does not prove the owner is present
+	if _, err := link(staleSession, pending.LinkToken); !errors.Is(err, business.ErrReauthRequired) {
+		t.Fatalf("Expected ErrReauthRequired from an older session, got %v", err)
+	}
+	freshSession := SignIn(t, setup, owner.Email, "pia password").SessionUUID
+	if _, err := link(freshSession, pending.LinkToken); !errors.Is(err, business.ErrInvalidToken) {
+		t.Errorf("Expected the token to be burned by the failed attempt, got %v", err)
+	}
+
+	pending, err = oauthSignIn(t, setup, fake, profile)
+	if err != nil || !pending.LinkRequired {
+		t.Fatalf("Expected LinkRequired, got %+v (err %v)", pending, err)
+	}
+	freshSession = SignIn(t, setup, owner.Email, "pia password").SessionUUID
+	linked, err := link(freshSession, pending.LinkToken)
+	if err != nil {
+		t.Fatalf("Failed to confirm the link from a fresh session: %v", err)
+	}
+	if linked.Link.ProviderUserID != "ex-4001" {
+		t.Errorf("Expected a link to ex-4001, got %+v", linked.Link)
+	}
+	if _, err := link(freshSession, pending.LinkToken); !errors.Is(err, business.ErrInvalidToken) {
+		t.Errorf("Expected the link token to be single use, got %v", err)
+	}
+
+	// The provider account now signs the owner in
+	result, err := oauthSignIn(t, setup, fake, profile)
+	if err != nil || result.Tokens == nil || result.User.UUID != owner.UUID {
+		t.Errorf("Expected the linked account to sign %s in, got %+v (err %v)", owner.UUID, result, err)
+	}
+}
+
+func TestFailedLinkConfirmationKeepsToken(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+	fake := addOAuthProvider(t, setup, "example")
+	owner := AddPasswordUser(t, setup, "quinn@example.com", "quinn password")
+	other := AddPasswordUser(t, setup, "rhea@example.com", "rhea password")
+	profile := map[string]interface{}{"sub": "ex-5001", "email": owner.Email, "email_verified": true}
+
+	pending, err := oauthSignIn(t, setup, fake, profile)
+	if err != nil || !pending.LinkRequired {
+		t.Fatalf("Expected LinkRequired, got %+v (err %v)", pending, err)
+	}
+
+	// Meanwhile the same provider account is linked to someone else
+	otherSession := SignIn(t, setup, other.Email, "rhea password").SessionUUID
+	code, state := oauthCallback(t, setup, fake, "example", other.UUID, profile)
+	if _, err := setup.Business.LinkProvider(ctx, business.LinkProviderInput{
+		UserID: other.UUID, SessionID: otherSession, Provider: "example", Code: code, State: state,
+	}); err != nil {
+		t.Fatalf("Failed to link for the other user: %v", err)
+	}
+
+	session := SignIn(t, setup, owner.Email, "quinn password").SessionUUID
+	_, err = setup.Business.LinkProvider(ctx, business.LinkProviderInput{UserID: owner.UUID, SessionID: session, LinkToken: pending.LinkToken})
+	if !errors.Is(err, business.ErrProviderAlreadyLinked) {
+		t.Fatalf("Expected ErrProviderAlreadyLinked, got %v", err)
+	}
+
+	// The failed insert rolled back the token's consumption with it
+	sum := sha256.Sum256([]byte(pending.LinkToken))
+	if _, err := setup.Repo.ConsumeActionToken(ctx, hex.EncodeToString(sum[:]), models.TokenPurposeOAuthLink); err != nil {
+		t.Errorf("Expected the link token to remain unused, got %v", err)
+	}
+}
+
+var errCommitFailed = errors.New("commit failed")
+
+// commitFailingStore fails every transaction once its callback has run, like a
+// serialization failure reported at COMMIT
+type commitFailingStore struct {
+	*memory.Store
+}
+
+func (s commitFailingStore) WithTx(ctx context.Context, fn func(ctx context.Context, tx repository.Store) error) error {
+	return s.Store.WithTx(ctx, func(ctx context.Context, tx repository.Store) error {
+		if err := fn(ctx, tx); err != nil {
+			return err
+		}
+		return errCommitFailed
+	})
+}
+
+func TestFailedLoginTransactionLeavesNoSessionOrDevice(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+	user := AddPasswordUser(t, setup, "sara@example.com", "sara password")
+	input := business.LoginInput{
+		Email: user.Email, Password: "sara password",
+		Client: business.ClientInfo{IPAddress: "198.51.100.20", DeviceID: "laptop"},
+	}
+
+	failing := business.NewAuthBusiness(commitFailingStore{setup.Repo}, setup.Config)
+	if _, err := failing.Login(ctx, input); !errors.Is(err, errCommitFailed) {
+		t.Fatalf("Expected the commit failure, got %v", err)
+	}
+	if sessions, _ := setup.Repo.ListActiveSessions(ctx, user.ID, time.Now(), time.Time{}); len(sessions) != 0 {
+		t.Errorf("Expected the session to be rolled back, got %d", len(sessions))
+	}
+	if devices, _ := setup.Repo.ListDevices(ctx, user.ID); len(devices) != 0 {
+		t.Errorf("Expected the device to be rolled back, got %d", len(devices))
+	}
+	success := true
+	logs, _ := setup.Repo.ListLoginLogs(ctx, repository.LoginLogFilter{UserID: &user.ID, Success: &success, Limit: 10})
+	if len(logs) != 0 {
+		t.Errorf("Expected no login_success entry, got %d", len(logs))
+	}
+
+	// The same sign-in commits all three together
+	if _, err := setup.Business.Login(ctx, input); err != nil {
+		t.Fatalf("Failed to log in: %v", err)
+	}
+	sessions, _ := setup.Repo.ListActiveSessions(ctx, user.ID, time.Now(), time.Time{})
+	devices, _ := setup.Repo.ListDevices(ctx, user.ID)
+	logs, _ = setup.Repo.ListLoginLogs(ctx, repository.LoginLogFilter{UserID: &user.ID, Success: &success, Limit: 10})
+	if len(sessions) != 1 || len(devices) != 1 || len(logs) != 1 {
+		t.Errorf("Expected one session, device and login_success entry, got %d, %d and %d", len(sessions), len(devices), len(logs))
+	}
+}
+
+func TestRefreshProviderTokens(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+
+	// The token endpoint answers by refresh token: a new pair, a revoked grant or an outage
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "refresh_token" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.PostForm.Get("refresh_token") {
+		case "good":
+			w.Write([]byte(`{"access_token":"fresh-access","refresh_token":"fresh-refresh","token_type":"Bearer","expires_in":3600}`))
+		case "revoked":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":"temporarily_unavailable"}`))
+		}
+	}))
+	defer endpoint.Close()
+
+	provider := &models.Provider{Name: "example", DisplayName: "Example", ClientID: "client", ClientSecret: "secret",
+		AuthorizationURL: endpoint.URL + "/authorize", TokenURL: endpoint.URL + "/token",
+		IsActive: true, IsEnabled: true, Config: map[string]interface{}{}}
+	if err := setup.Repo.CreateProvider(ctx, provider); err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	if _, err := setup.Business.RefreshProviders(ctx); err != nil {
+		t.Fatalf("Failed to refresh providers: %v", err)
+	}
+
+	expiresAt := time.Now().Add(time.Minute)
+	link := func(email, refreshToken string) *models.UserProvider {
+		user := AddPasswordUser(t, setup, email, "password")
+		access := "stale-access"
+		l := &models.UserProvider{UserID: user.ID, ProviderID: provider.ID, ProviderUserID: email, IsActive: true,
+			AccessToken: &access, RefreshToken: &refreshToken, ExpiresAt: &expiresAt}
+		if err := setup.Repo.LinkUserProvider(ctx, l); err != nil {
+			t.Fatalf("Failed to link %s: %v", email, err)
+		}
+		return l
+	}
+	refreshed := link("lena@example.com", "good")
+	revoked := link("mike@example.com", "revoked")
+	failing := link("nina@example.com", "outage")
+	busy := link("omar@example.com", "good")
+
+	// Another replica is refreshing this link
+	unlock, ok, err := setup.Repo.TryLockUserProviderRefresh(ctx, busy.ID)
+	if err != nil || !ok {
+		t.Fatalf("Failed to lock link: %v", err)
+	}
+	defer unlock()
+
+	result, err := setup.Business.RefreshProviderTokens(ctx)
+	if err != nil {
+		t.Fatalf("Token refresh failed: %v", err)
+	}
+	if *result != (business.TokenRefreshResult{Refreshed: 1, Revoked: 1, Failed: 1, Skipped: 1}) {
+		t.Errorf("Expected one link in each outcome, got %+v", result)
+	}
+
+	stored, err := setup.Repo.GetUserProviderByID(ctx, refreshed.ID)
+	if err != nil {
+		t.Fatalf("Failed to load refreshed link: %v", err)
+	}
+	if stored.AccessToken == nil || *stored.AccessToken != "fresh-access" || stored.RefreshToken == nil || *stored.RefreshToken != "fresh-refresh" {
+		t.Errorf("Expected the new tokens to be stored, got %v and %v", stored.AccessToken, stored.RefreshToken)
+	}
+	if stored.ExpiresAt == nil || !stored.ExpiresAt.After(time.Now().Add(50*time.Minute)) {
+		t.Errorf("Expected the expiry to move an hour out, got %v", stored.ExpiresAt)
+	}
+
+	stored, err = setup.Repo.GetUserProviderByID(ctx, revoked.ID)
+	if err != nil || stored.IsActive || stored.RefreshToken != nil || stored.Meta["deactivated_reason"] != "refresh_token_revoked" {
+		t.Errorf("Expected the revoked link to be deactivated, got %+v, %v", stored, err)
+	}
+
+	stored, err = setup.Repo.GetUserProviderByID(ctx, failing.ID)
+	if err != nil {
+		t.Fatalf("Failed to load failing link: %v", err)
+	}
+	if failures, _ := stored.Meta["refresh_failures"].(float64); failures != 1 || !stored.IsActive {
+		t.Errorf("Expected one recorded failure on a still active link, got %+v", stored.Meta)
+	}
+	retryAt, _ := stored.Meta["refresh_retry_at"].(string)
+	if at, err := time.Parse(time.RFC3339Nano, retryAt); err != nil || !at.After(time.Now()) {
+		t.Errorf("Expected a retry time in the future, got %q", retryAt)
+	}
+
+	// The failing link backs off and the busy one is still locked, so nothing is due
+	result, err = setup.Business.RefreshProviderTokens(ctx)
+	if err != nil || *result != (business.TokenRefreshResult{Skipped: 1}) {
+		t.Errorf("Expected only the locked link to be tried again, got %+v, %v", result, err)
+	}
+}
+
+// addLoginLog stores an audit entry as the memory store's clock currently reads
+func addLoginLog(t *testing.T, store *memory.Store, user *models.User, eventType string, success bool, ip, userAgent string) *models.LoginLog {
+	t.Helper()
+	entry := &models.LoginLog{UserID: &user.ID, EventType: eventType, Success: success, IPAddress: &ip, UserAgent: &userAgent}
+	if err := store.CreateLoginLog(context.Background(), entry); err != nil {
+		t.Fatalf("Failed to create login log: %v", err)
+	}
+	return entry
+}
+
+func TestListLoginEvents(t *testing.T) {
+	ctx := context.Background()
+	clock := time.Now().Add(-time.Hour)
+	store := memory.New(memory.WithClock(func() time.Time { return clock }))
+	authBusiness := business.NewAuthBusiness(store, NewTestConfig(t))
+
+	user := &models.User{Email: "quinn@example.com", PasswordHash: "hash"}
+	if err := store.AddUser(ctx, user); err != nil {
+		t.Fatalf("Failed to add user: %v", err)
+	}
+	var entries []*models.LoginLog
+	for _, e := range []struct {
+		eventType string
+		success   bool
+		ip        string
+	}{
+		{models.EventLoginSuccess, true, "10.1.0.5"},
+		{models.EventLoginFailed, false, "10.1.0.6"},
+		{models.EventLoginFailed, false, "192.168.1.9"},
+		{models.EventLogout, true, "10.2.0.1"},
+		{models.EventLoginSuccess, true, "10.1.200.1"},
+	} {
+		clock = clock.Add(time.Minute)
+		entries = append(entries, addLoginLog(t, store, user, e.eventType, e.success, e.ip, "test-agent"))
+	}
+
+	ids := func(filter business.LoginEventFilter) []int {
+		t.Helper()
+		page, err := authBusiness.ListLoginEvents(ctx, filter)
+		if err != nil {
+			t.Fatalf("Failed to list login events for %+v: %v", filter, err)
+		}
+		var got []int
+		for _, event := range page.Events {
+			got = append(got, event.ID)
+		}
+		return got
+	}
+	failed, succeeded := false, true
+	cases := map[string]struct {
+		filter business.LoginEventFilter
+		want   []*models.LoginLog
+	}{
+		"event type": {business.LoginEventFilter{EventTypes: []string{models.EventLoginFailed}}, []*models.LoginLog{entries[2], entries[1]}},
+		"cidr":       {business.LoginEventFilter{IP: "10.1.0.0/16"}, []*models.LoginLog{entries[4], entries[1], entries[0]}},
+		"single ip":  {business.LoginEventFilter{IP: "10.2.0.1"}, []*models.LoginLog{entries[3]}},
+		"failures":   {business.LoginEventFilter{Success: &failed}, []*models.LoginLog{entries[2], entries[1]}},
+		"successes in cidr": {business.LoginEventFilter{Success: &succeeded, IP: "10.0.0.0/8", EventTypes: []string{models.EventLoginSuccess}},
+			[]*models.LoginLog{entries[4], entries[0]}},
+	}
+	for name, tc := range cases {
+		got := ids(tc.filter)
+		var want []int
+		for _, entry := range tc.want {
+			want = append(want, entry.ID)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+
+	// Pages of two walk every entry newest first without repeats
+	var (
+		walked []int
+		token  string
+	)
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("Expected paging to end after three pages")
+		}
+		page, err := authBusiness.ListLoginEvents(ctx, business.LoginEventFilter{PageSize: 2, PageToken: token})
+		if err != nil {
+			t.Fatalf("Failed to list page %d: %v", pages, err)
+		}
+		for _, event := range page.Events {
+			walked = append(walked, event.ID)
+		}
+		if token = page.NextPageToken; token == "" {
+			break
+		}
+	}
+	if want := []int{entries[4].ID, entries[3].ID, entries[2].ID, entries[1].ID, entries[0].ID}; fmt.Sprint(walked) != fmt.Sprint(want) {
+		t.Errorf("Expected pages to walk %v, got %v", want, walked)
+	}
+}
+
+func TestExportLoginEventsNeutralizesFormulas(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	user := AddPasswordUser(t, setup, "rita@example.com", "rita password")
+	addLoginLog(t, setup.Repo, user, models.EventLoginFailed, false, "10.0.0.1", `=HYPERLINK("https://evil.example","x")`)
+	addLoginLog(t, setup.Repo, user, models.EventLoginSuccess, true, "10.0.0.2", "Mozilla/5.0")
+
+	var buf bytes.Buffer
+	result, err := setup.Business.ExportLoginEvents(context.Background(), business.LoginEventFilter{}, business.ExportFormatCSV, &buf)
+	if err != nil || result.Rows != 2 || result.Truncated {
+		t.Fatalf("Expected 2 exported rows, got %+v, %v", result, err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil || len(rows) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got %d, %v", len(rows), err)
+	}
+	agents := map[string]bool{}
+	for _, row := range rows[1:] {
+		agents[row[7]] = true
+	}
+	if !agents[`'=HYPERLINK("https://evil.example","x")`] || !agents["Mozilla/5.0"] {
+		t.Errorf("Expected the formula to be prefixed and the plain agent untouched, got %v", agents)
+	}
+}
+
+func TestArchiveLoginEvents(t *testing.T) {
+	ctx := context.Background()
+	clock := time.Now().Add(-100 * 24 * time.Hour)
+	store := memory.New(memory.WithClock(func() time.Time { return clock }))
+	cfg := NewTestConfig(t)
+	cfg.LoginLogRetention = 90 * 24 * time.Hour
+	cfg.LoginLogArchiveBatchSize = 2
+	authBusiness := business.NewAuthBusiness(store, cfg)
+
+	user := &models.User{Email: "sam@example.com", PasswordHash: "hash"}
+	if err := store.AddUser(ctx, user); err != nil {
+		t.Fatalf("Failed to add user: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		addLoginLog(t, store, user, models.EventLoginSuccess, true, "10.0.0.1", "old")
+	}
+	clock = time.Now()
+	recent := addLoginLog(t, store, user, models.EventLoginSuccess, true, "10.0.0.1", "recent")
+
+	// Three old entries take a full batch, a short one and an empty one
+	moved, err := authBusiness.ArchiveLoginEvents(ctx)
+	if err != nil || moved != 3 {
+		t.Fatalf("Expected 3 entries archived, got %d, %v", moved, err)
+	}
+	page, err := authBusiness.ListLoginEvents(ctx, business.LoginEventFilter{})
+	if err != nil || len(page.Events) != 1 || page.Events[0].ID != recent.ID {
+		t.Errorf("Expected only the recent entry to remain, got %+v, %v", page, err)
+	}
+	if moved, err := authBusiness.ArchiveLoginEvents(ctx); err != nil || moved != 0 {
+		t.Errorf("Expected nothing left to archive, got %d, %v", moved, err)
+	}
+}
+
+func TestValidateAPIKeyLifecycle(t *testing.T) {
+	now := time.Now()
+	setup := SetupTestEnvironmentAt(t, &now)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+
+	user := AddPasswordUser(t, setup, "tina@example.com", "tina password")
+	tokens := SignIn(t, setup, "tina@example.com", "tina password")
+	owner := business.APIKeyOwner{UserID: user.UUID}
+	key, plaintext, err := setup.Business.CreateAPIKey(ctx, business.APIKeyInput{
+		Owner: owner, SessionID: tokens.SessionUUID, Name: "ci", Scopes: []string{"openid"}, AllowedIPs: []string{"10.0.0.0/8"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create api key: %v", err)
+	}
+	office := business.ClientInfo{IPAddress: "10.1.2.3"}
+
+	token, err := setup.Business.ValidateAPIKey(ctx, plaintext, office)
+	if err != nil || token.Subject != user.UUID || token.Scope != "openid" || token.APIKeyID != key.UUID {
+		t.Fatalf("Expected a token for tina's key, got %+v, %v", token, err)
+	}
+
+	tampered := plaintext[:len(plaintext)-1] + "x"
+	if tampered == plaintext {
+		tampered = plaintext[:len(plaintext)-1] + "y"
+	}
+	if _, err := setup.Business.ValidateAPIKey(ctx, tampered, office); !errors.Is(err, business.ErrInvalidAPIKey) {
+		t.Errorf("Expected ErrInvalidAPIKey for a tampered secret, got %v", err)
+	}
+
+	if _, err := setup.Business.ValidateAPIKey(ctx, plaintext, business.ClientInfo{IPAddress: "192.0.2.1"}); !errors.Is(err, business.ErrAPIKeyIPNotAllowed) {
+		t.Errorf("Expected ErrAPIKeyIPNotAllowed outside the allow list, got %v", err)
+	}
+	rejections, err := setup.Repo.ListLoginLogs(ctx, repository.LoginLogFilter{EventTypes: []string{models.EventAPIKeyRejected}, Limit: 10})
+	if err != nil || len(rejections) != 1 || rejections[0].FailureReason == nil || *rejections[0].FailureReason != "ip_not_allowed" {
+		t.Errorf("Expected one ip_not_allowed rejection event, got %d, %v", len(rejections), err)
+	}
+
+	// The old key keeps working for the rotation grace, then only the new one does
+	replacement, rotated, err := setup.Business.RotateAPIKey(ctx, owner, tokens.SessionUUID, key.UUID, business.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to rotate api key: %v", err)
+	}
+	if _, err := setup.Business.ValidateAPIKey(ctx, plaintext, office); err != nil {
+		t.Errorf("Expected the old key to work during the grace period, got %v", err)
+	}
+	now = now.Add(setup.Config.APIKeyRotationGrace + time.Minute)
+	if _, err := setup.Business.ValidateAPIKey(ctx, plaintext, office); !errors.Is(err, business.ErrInvalidAPIKey) {
+		t.Errorf("Expected the old key to stop working after the grace period, got %v", err)
+	}
+	if _, err := setup.Business.ValidateAPIKey(ctx, rotated, office); err != nil {
+		t.Errorf("Expected the replacement key to work, got %v", err)
+	}
+
+	if _, err := setup.Business.RevokeAPIKey(ctx, owner, replacement.UUID, business.ClientInfo{}); err != nil {
+		t.Fatalf("Failed to revoke api key: %v", err)
+	}
+	if _, err := setup.Business.ValidateAPIKey(ctx, rotated, office); !errors.Is(err, business.ErrInvalidAPIKey) {
+		t.Errorf("Expected a revoked key to be rejected, got %v", err)
+	}
+}
+
+func TestValidateAPIKeyDropsRemovedServiceAccountScopes(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+
+	account, _, err := setup.Business.CreateServiceAccount(ctx, business.ServiceAccountInput{
+		ClientID: "reporter", Name: "Reporter", Scopes: []string{"reports.read", "reports.write"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create service account: %v", err)
+	}
+	_, plaintext, err := setup.Business.CreateAPIKey(ctx, business.APIKeyInput{
+		Owner: business.APIKeyOwner{ServiceAccountID: account.UUID}, Name: "cron", Scopes: []string{"reports.read", "reports.write"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create api key: %v", err)
+	}
+
+	token, err := setup.Business.ValidateAPIKey(ctx, plaintext, business.ClientInfo{})
+	if err != nil || token.Scope != "reports.read reports.write" || token.Subject != "client:reporter" {
+		t.Fatalf("Expected both scopes for the service account, got %+v, %v", token, err)
+	}
+
+	if err := setup.Repo.SetOAuthClientScopes(ctx, account.UUID, []string{"reports.read"}); err != nil {
+		t.Fatalf("Failed to change scopes: %v", err)
+	}
+	token, err = setup.Business.ValidateAPIKey(ctx, plaintext, business.ClientInfo{})
+	if err != nil || token.Scope != "reports.read" {
+		t.Errorf("Expected the removed scope to be dropped, got %+v, %v", token, err)
+	}
+
+	if err := setup.Repo.SetOAuthClientScopes(ctx, account.UUID, []string{"audit.read"}); err != nil {
+		t.Fatalf("Failed to change scopes: %v", err)
+	}
+	if _, err := setup.Business.ValidateAPIKey(ctx, plaintext, business.ClientInfo{}); !errors.Is(err, business.ErrInvalidAPIKey) {
+		t.Errorf("Expected a key left without scopes to be rejected, got %v", err)
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+
+	user := AddPasswordUser(t, setup, "nora@example.com", "nora password")
+	phone := SignIn(t, setup, user.Email, "nora password")
+	laptop := SignIn(t, setup, user.Email, "nora password")
+
+	if err := setup.Business.RequestPasswordReset(ctx, user.Email, business.ClientInfo{}); err != nil {
+		t.Fatalf("Failed to request a reset: %v", err)
+	}
+	token := setup.Mail.LinkToken(t, user.Email)
+	input := business.ResetPasswordInput{Token: token, NewPassword: "new nora password"}
+
+	// A reset that fails to commit leaves the token usable
+	failing := business.NewAuthBusiness(commitFailingStore{setup.Repo}, setup.Config)
+	if _, err := failing.ResetPassword(ctx, input); !errors.Is(err, errCommitFailed) {
+		t.Fatalf("Expected the commit failure, got %v", err)
+	}
+
+	revoked, err := setup.Business.ResetPassword(ctx, input)
+	if err != nil {
+		t.Fatalf("Failed to reset the password: %v", err)
+	}
+	if revoked != 2 {
+		t.Errorf("Expected both sessions to be revoked, got %d", revoked)
+	}
+	for _, tokens := range []*business.TokenPair{phone, laptop} {
+		if _, err := setup.Business.ValidateAccessToken(ctx, tokens.AccessToken); err == nil {
+			t.Errorf("Expected session %s to be signed out", tokens.SessionUUID)
+		}
+	}
+
+	if _, err := setup.Business.ResetPassword(ctx, business.ResetPasswordInput{Token: token, NewPassword: "third nora password"}); !errors.Is(err, business.ErrInvalidToken) {
+		t.Errorf("Expected a used token to be refused, got %v", err)
+	}
+	if _, err := setup.Business.Login(ctx, business.LoginInput{Email: user.Email, Password: "nora password"}); !errors.Is(err, business.ErrInvalidCredentials) {
+		t.Errorf("Expected the old password to be refused, got %v", err)
+	}
+	SignIn(t, setup, user.Email, "new nora password")
+}
+
+func TestResetPasswordAddsPasswordToOAuthAccount(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+	fake := addOAuthProvider(t, setup, "example")
+
+	profile := map[string]interface{}{"sub": "ex-2001", "email": "omar@example.com", "email_verified": true}
+	if _, err := oauthSignIn(t, setup, fake, profile); err != nil {
+		t.Fatalf("Failed to complete OAuth: %v", err)
+	}
+	if user, _ := setup.Repo.GetUserByEmail(ctx, "omar@example.com"); user.AuthMethod != models.AuthMethodOAuth {
+		t.Fatalf("Expected an OAuth-only account, got %q", user.AuthMethod)
+	}
+
+	if err := setup.Business.RequestPasswordReset(ctx, "omar@example.com", business.ClientInfo{}); err != nil {
+		t.Fatalf("Failed to request a reset: %v", err)
+	}
+	token := setup.Mail.LinkToken(t, "omar@example.com")
+	if _, err := setup.Business.ResetPassword(ctx, business.ResetPasswordInput{Token: token, NewPassword: "omar password"}); err != nil {
+		t.Fatalf("Failed to set a password: %v", err)
+	}
+
+	user, _ := setup.Repo.GetUserByEmail(ctx, "omar@example.com")
+	if user.AuthMethod != models.AuthMethodBoth {
+		t.Errorf("Expected auth_method %q, got %q", models.AuthMethodBoth, user.AuthMethod)
+	}
+	SignIn(t, setup, user.Email, "omar password")
+}
+
+func TestChangePassword(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+
+	user := AddPasswordUser(t, setup, "paul@example.com", "paul password")
+	current := SignIn(t, setup, user.Email, "paul password")
+	others := []*business.TokenPair{
+		SignIn(t, setup, user.Email, "paul password"),
+		SignIn(t, setup, user.Email, "paul password"),
+	}
+
+	_, err := setup.Business.ChangePassword(ctx, business.ChangePasswordInput{
+		UserID: user.UUID, SessionID: current.SessionUUID, CurrentPassword: "wrong password", NewPassword: "new paul password",
+	})
+	if !errors.Is(err, business.ErrInvalidCredentials) {
+		t.Fatalf("Expected a wrong current password to be refused, got %v", err)
+	}
+	failed := false
+	logs, _ := setup.Repo.ListLoginLogs(ctx, repository.LoginLogFilter{
+		UserID: &user.ID, EventTypes: []string{models.EventPasswordChanged}, Success: &failed, Limit: 10,
+	})
+	if len(logs) != 1 || logs[0].FailureReason == nil || *logs[0].FailureReason != "invalid_password" {
+		t.Errorf("Expected one invalid_password entry, got %+v", logs)
+	}
+
+	revoked, err := setup.Business.ChangePassword(ctx, business.ChangePasswordInput{
+		UserID: user.UUID, SessionID: current.SessionUUID, CurrentPassword: "paul password", NewPassword: "new paul password",
+	})
+	if err != nil {
+		t.Fatalf("Failed to change the password: %v", err)
+	}
+	if revoked != len(others) {
+		t.Errorf("Expected %d sessions to be revoked, got %d", len(others), revoked)
+	}
+	if _, err := setup.Business.ValidateAccessToken(ctx, current.AccessToken); err != nil {
+		t.Errorf("Expected the caller's session to stay signed in: %v", err)
+	}
+	for _, tokens := range others {
+		if _, err := setup.Business.ValidateAccessToken(ctx, tokens.AccessToken); err == nil {
+			t.Errorf("Expected session %s to be signed out", tokens.SessionUUID)
+		}
+	}
+	SignIn(t, setup, user.Email, "new paul password")
+}
+
+func TestChangePasswordRefusesImpersonation(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+
+	admin := AddPasswordUser(t, setup, "admin@example.com", "admin password")
+	target := AddPasswordUser(t, setup, "rita@example.com", "rita password")
+	setup.Config.ImpersonationAdmins = []string{admin.UUID}
+
+	adminTokens := SignIn(t, setup, admin.Email, "admin password")
+	impersonation, err := setup.Business.Impersonate(ctx, business.ImpersonateInput{
+		AdminToken: adminTokens.AccessToken, TargetUserID: target.UUID, Reason: "ticket 4321",
+	})
+	if err != nil {
+		t.Fatalf("Failed to impersonate: %v", err)
+	}
+
+	_, err = setup.Business.ChangePassword(ctx, business.ChangePasswordInput{
+		UserID: target.UUID, SessionID: impersonation.SessionUUID, CurrentPassword: "rita password", NewPassword: "new rita password",
+	})
+	if !errors.Is(err, business.ErrImpersonatedSession) {
+		t.Fatalf("Expected the impersonation session to be refused, got %v", err)
+	}
+	SignIn(t, setup, target.Email, "rita password")
+}
+
+// requestContactChange asks for user's address on channel to become value and
+// returns the confirmation token sent there
+func requestContactChange(t *testing.T, setup *TestSetup, user *models.User, password string, tokens *business.TokenPair, channel, value string) string {
+	t.Helper()
+	_, err := setup.Business.RequestContactChange(context.Background(), business.RequestContactChangeInput{
+		UserID: user.UUID, SessionID: tokens.SessionUUID, Channel: channel, NewValue: value, CurrentPassword: password,
+	})
+	if err != nil {
+		t.Fatalf("Failed to request a %s change: %v", channel, err)
+	}
+	return setup.Mail.LinkToken(t, value)
+}
+
+func TestContactChangeConfirmAndUndo(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+
+	user := AddPasswordUser(t, setup, "sam@example.com", "sam password")
+	tokens := SignIn(t, setup, user.Email, "sam password")
+
+	confirm := requestContactChange(t, setup, user, "sam password", tokens, models.VerificationChannelEmail, "sam@example.org")
+	if current, _ := setup.Repo.GetUserByID(ctx, user.ID); current.Email != "sam@example.com" {
+		t.Fatalf("Expected the email to stay until confirmed, got %s", current.Email)
+	}
+	changed, err := setup.Business.ConfirmContactChange(ctx, confirm, business.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to confirm the change: %v", err)
+	}
+	if changed.Email != "sam@example.org" || changed.EmailVerifiedAt == nil {
+		t.Errorf("Expected the new email to be set and verified, got %+v", changed)
+	}
+	if _, err := setup.Business.ConfirmContactChange(ctx, confirm, business.ClientInfo{}); !errors.Is(err, business.ErrInvalidToken) {
+		t.Errorf("Expected a used confirmation token to be refused, got %v", err)
+	}
+
+	// The old address is told about the change and can undo it
+	undo := setup.Mail.LinkToken(t, "sam@example.com")
+	revoked, err := setup.Business.UndoContactChange(ctx, undo, business.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to undo the change: %v", err)
+	}
+	if revoked != 1 {
+		t.Errorf("Expected the session to be revoked, got %d", revoked)
+	}
+	if current, _ := setup.Repo.GetUserByID(ctx, user.ID); current.Email != "sam@example.com" {
+		t.Errorf("Expected the old email to be restored, got %s", current.Email)
+	}
+	if _, err := setup.Business.ValidateAccessToken(ctx, tokens.AccessToken); err == nil {
+		t.Error("Expected the undo to sign the user out")
+	}
+	if _, err := setup.Business.UndoContactChange(ctx, undo, business.ClientInfo{}); !errors.Is(err, business.ErrInvalidToken) {
+		t.Errorf("Expected a used undo token to be refused, got %v", err)
+	}
+}
+
+func TestContactChangeSetsPhone(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+
+	user := AddPasswordUser(t, setup, "tara@example.com", "tara password")
+	tokens := SignIn(t, setup, user.Email, "tara password")
+
+	confirm := requestContactChange(t, setup, user, "tara password", tokens, models.VerificationChannelPhone, "+15550100200")
+	changed, err := setup.Business.ConfirmContactChange(ctx, confirm, business.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to confirm the change: %v", err)
+	}
+	if changed.Phone == nil || *changed.Phone != "+15550100200" || changed.PhoneVerifiedAt == nil {
+		t.Errorf("Expected the new phone to be set and verified, got %+v", changed)
+	}
+	if changed.Email != user.Email {
+		t.Errorf("Expected the email to be untouched, got %s", changed.Email)
+	}
+}
+
+func TestConfirmContactChangeRechecksUniqueness(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+
+	first := AddPasswordUser(t, setup, "uma@example.com", "uma password")
+	second := AddPasswordUser(t, setup, "vic@example.com", "vic password")
+	firstTokens := SignIn(t, setup, first.Email, "uma password")
+	secondTokens := SignIn(t, setup, second.Email, "vic password")
+
+	// Both ask for the same address while it is free; the second to confirm loses
+	firstConfirm := requestContactChange(t, setup, first, "uma password", firstTokens, models.VerificationChannelEmail, "shared@example.com")
+	secondConfirm := requestContactChange(t, setup, second, "vic password", secondTokens, models.VerificationChannelEmail, "shared@example.com")
+	if _, err := setup.Business.ConfirmContactChange(ctx, secondConfirm, business.ClientInfo{}); err != nil {
+		t.Fatalf("Failed to confirm the change: %v", err)
+	}
+
+	if _, err := setup.Business.ConfirmContactChange(ctx, firstConfirm, business.ClientInfo{}); !errors.Is(err, business.ErrContactInUse) {
+		t.Fatalf("Expected the taken address to be refused, got %v", err)
+	}
+	if current, _ := setup.Repo.GetUserByID(ctx, first.ID); current.Email != "uma@example.com" {
+		t.Errorf("Expected the email to be unchanged, got %s", current.Email)
+	}
+}
+
+func TestConfirmContactChangeExpires(t *testing.T) {
+	now := time.Now()
+	setup := SetupTestEnvironmentAt(t, &now)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+
+	user := AddPasswordUser(t, setup, "wes@example.com", "wes password")
+	tokens := SignIn(t, setup, user.Email, "wes password")
+	confirm := requestContactChange(t, setup, user, "wes password", tokens, models.VerificationChannelEmail, "wes@example.org")
+
+	now = now.Add(setup.Config.ContactChangeExpiry + time.Second)
+	if _, err := setup.Business.ConfirmContactChange(ctx, confirm, business.ClientInfo{}); !errors.Is(err, business.ErrInvalidToken) {
+		t.Fatalf("Expected an expired token to be refused, got %v", err)
+	}
+	if current, _ := setup.Repo.GetUserByID(ctx, user.ID); current.Email != "wes@example.com" {
+		t.Errorf("Expected the email to be unchanged, got %s", current.Email)
+	}
+}
+
+func TestDeactivateAccount(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+
+	user := AddPasswordUser(t, setup, "xena@example.com", "xena password")
+	current := SignIn(t, setup, user.Email, "xena password")
+	other := SignIn(t, setup, user.Email, "xena password")
+
+	revoked, err := setup.Business.DeactivateAccount(ctx, business.DeactivateAccountInput{
+		UserID: user.UUID, SessionID: current.SessionUUID, Reason: "taking a break",
+	})
+	if err != nil {
+		t.Fatalf("Failed to deactivate the account: %v", err)
+	}
+	if revoked != 2 {
+		t.Errorf("Expected every session to be revoked, got %d", revoked)
+	}
+	for _, tokens := range []*business.TokenPair{current, other} {
+		if _, err := setup.Business.ValidateAccessToken(ctx, tokens.AccessToken); err == nil {
+			t.Errorf("Expected session %s to be signed out", tokens.SessionUUID)
+		}
+	}
+	login := business.LoginInput{Email: user.Email, Password: "xena password"}
+	if _, err := setup.Business.Login(ctx, login); !errors.Is(err, business.ErrAccountDisabled) {
+		t.Fatalf("Expected sign-in to be refused, got %v", err)
+	}
+
+	if _, err := setup.Business.ReactivateAccount(ctx, user.UUID, business.ClientInfo{}); err != nil {
+		t.Fatalf("Failed to reactivate the account: %v", err)
+	}
+	SignIn(t, setup, user.Email, "xena password")
+}
+
+func TestDeleteAndRestoreAccount(t *testing.T) {
+	now := time.Now()
+	setup := SetupTestEnvironmentAt(t, &now)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+
+	user := AddPasswordUser(t, setup, "yara@example.com", "yara password")
+	tokens := SignIn(t, setup, user.Email, "yara password")
+
+	deletion, err := setup.Business.DeleteAccount(ctx, business.DeleteAccountInput{
+		UserID: user.UUID, SessionID: tokens.SessionUUID, CurrentPassword: "yara password",
+	})
+	if err != nil {
+		t.Fatalf("Failed to delete the account: %v", err)
+	}
+	if want := now.Add(setup.Config.AccountDeletionGrace); !deletion.PurgeAfter.Equal(want) {
+		t.Errorf("Expected purge after %v, got %v", want, deletion.PurgeAfter)
+	}
+	if deletion.SessionsRevoked != 1 {
+		t.Errorf("Expected the session to be revoked, got %d", deletion.SessionsRevoked)
+	}
+	if _, err := setup.Business.Login(ctx, business.LoginInput{Email: user.Email, Password: "yara password"}); err == nil {
+		t.Fatal("Expected a deleted account not to sign in")
+	}
+
+	// Restoring works until the last moment of the grace period
+	now = deletion.PurgeAfter.Add(-time.Minute)
+	restored, err := setup.Business.RestoreAccount(ctx, setup.Mail.LinkToken(t, user.Email), business.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to restore the account: %v", err)
+	}
+	if restored.UUID != user.UUID || restored.DeletedAt != nil || restored.PurgeAfter != nil {
+		t.Errorf("Expected the account to be restored, got %+v", restored)
+	}
+	SignIn(t, setup, user.Email, "yara password")
+}
+
+func TestRestoreAccountAfterGracePeriod(t *testing.T) {
+	now := time.Now()
+	setup := SetupTestEnvironmentAt(t, &now)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+
+	user := AddPasswordUser(t, setup, "zoe@example.com", "zoe password")
+	tokens := SignIn(t, setup, user.Email, "zoe password")
+	deletion, err := setup.Business.DeleteAccount(ctx, business.DeleteAccountInput{
+		UserID: user.UUID, SessionID: tokens.SessionUUID, CurrentPassword: "zoe password",
+	})
+	if err != nil {
+		t.Fatalf("Failed to delete the account: %v", err)
+	}
+
+	now = deletion.PurgeAfter.Add(time.Minute)
+	if _, err := setup.Business.RestoreAccount(ctx, setup.Mail.LinkToken(t, user.Email), business.ClientInfo{}); !errors.Is(err, business.ErrInvalidToken) {
+		t.Errorf("Expected the restore link to have expired, got %v", err)
+	}
+}
+
+func TestPurgeDeletedAccountsWaitsForGracePeriod(t *testing.T) {
+	now := time.Now()
+	setup := SetupTestEnvironmentAt(t, &now)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+
+	user := AddPasswordUser(t, setup, "abe@example.com", "abe password")
+	kept := AddPasswordUser(t, setup, "bea@example.com", "bea password")
+	for _, u := range []*models.User{user, kept} {
+		_, err := setup.Business.Login(ctx, business.LoginInput{
+			Email: u.Email, Password: strings.TrimSuffix(u.Email, "@example.com") + " password",
+			Client: business.ClientInfo{IPAddress: "203.0.113.9"},
+		})
+		if err != nil {
+			t.Fatalf("Failed to log in as %s: %v", u.Email, err)
+		}
+	}
+	sessions, _ := setup.Repo.ListActiveSessions(ctx, user.ID, now, time.Time{})
+	deletion, err := setup.Business.DeleteAccount(ctx, business.DeleteAccountInput{
+		UserID: user.UUID, SessionID: sessions[0].UUID, CurrentPassword: "abe password",
+	})
+	if err != nil {
+		t.Fatalf("Failed to delete the account: %v", err)
+	}
+
+	loggedIPs := func(u *models.User) int {
+		logs, _ := setup.Repo.ListLoginLogs(ctx, repository.LoginLogFilter{UserID: &u.ID, Limit: 50})
+		count := 0
+		for _, entry := range logs {
+			if entry.IPAddress != nil {
+				count++
+			}
+		}
+		return count
+	}
+
+	now = deletion.PurgeAfter.Add(-time.Minute)
+	if purged, err := setup.Business.PurgeDeletedAccounts(ctx); err != nil || purged != 0 {
+		t.Fatalf("Expected nothing to be purged within the grace period, got %d, %v", purged, err)
+	}
+	if loggedIPs(user) == 0 {
+		t.Error("Expected the audit trail to be kept within the grace period")
+	}
+	if err := setup.Repo.AddUser(ctx, &models.User{Email: user.Email}); !errors.Is(err, repository.ErrDuplicate) {
+		t.Errorf("Expected the address to stay reserved within the grace period, got %v", err)
+	}
+
+	now = deletion.PurgeAfter
+	if purged, err := setup.Business.PurgeDeletedAccounts(ctx); err != nil || purged != 1 {
+		t.Fatalf("Expected the account to be purged, got %d, %v", purged, err)
+	}
+	if n := loggedIPs(user); n != 0 {
+		t.Errorf("Expected IP addresses to be stripped from the audit trail, %d left", n)
+	}
+	if loggedIPs(kept) == 0 {
+		t.Error("Expected other users' audit trail to be untouched")
+	}
+	if err := setup.Repo.AddUser(ctx, &models.User{Email: user.Email}); err != nil {
+		t.Errorf("Expected the purged address to be free: %v", err)
+	}
+	SignIn(t, setup, kept.Email, "bea password")
+}
+
+// verificationCode starts verifying user's email address and returns the code sent there
+func verificationCode(t *testing.T, setup *TestSetup, user *models.User) string {
+	t.Helper()
+	_, err := setup.Business.StartVerification(context.Background(), business.StartVerificationInput{
+		UserID: user.UUID, Channel: models.VerificationChannelEmail,
+	})
+	if err != nil {
+		t.Fatalf("Failed to start verification: %v", err)
+	}
+	code := verificationCodePattern.FindString(setup.Mail.LastMessage(t, user.Email).Body)
+	if code == "" {
+		t.Fatal("Expected a verification code in the email")
+	}
+	return code
+}
+
+var verificationCodePattern = regexp.MustCompile(`\b[0-9]{6}\b`)
+
+// confirmEmail verifies user's email address with the latest code
+func confirmEmail(t *testing.T, setup *TestSetup, user *models.User, code string) *models.User {
+	t.Helper()
+	verified, err := setup.Business.ConfirmVerification(context.Background(), business.ConfirmVerificationInput{
+		UserID: user.UUID, Channel: models.VerificationChannelEmail, Code: code,
+	})
+	if err != nil {
+		t.Fatalf("Failed to confirm verification: %v", err)
+	}
+	return verified
+}
+
+func TestVerifyEmail(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+
+	user := AddPasswordUser(t, setup, "cleo@example.com", "cleo password")
+	code := verificationCode(t, setup, user)
+
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	_, err := setup.Business.ConfirmVerification(ctx, business.ConfirmVerificationInput{
+		UserID: user.UUID, Channel: models.VerificationChannelEmail, Code: wrong,
+	})
+	if !errors.Is(err, business.ErrInvalidVerificationCode) {
+		t.Fatalf("Expected a wrong code to be refused, got %v", err)
+	}
+
+	verified := confirmEmail(t, setup, user, code)
+	if !verified.IsVerified || verified.EmailVerifiedAt == nil {
+		t.Errorf("Expected the email to be verified, got %+v", verified)
+	}
+	_, err = setup.Business.StartVerification(ctx, business.StartVerificationInput{UserID: user.UUID, Channel: models.VerificationChannelEmail})
+	if !errors.Is(err, business.ErrAlreadyVerified) {
+		t.Errorf("Expected a verified address to need no code, got %v", err)
+	}
+}
+
+func TestStartVerificationCooldown(t *testing.T) {
+	now := time.Now()
+	setup := SetupTestEnvironmentAt(t, &now)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+
+	user := AddPasswordUser(t, setup, "dan@example.com", "dan password")
+	first := verificationCode(t, setup, user)
+
+	now = now.Add(setup.Config.VerificationResendCooldown - time.Second)
+	_, err := setup.Business.StartVerification(ctx, business.StartVerificationInput{UserID: user.UUID, Channel: models.VerificationChannelEmail})
+	if !errors.Is(err, business.ErrVerificationCooldown) {
+		t.Fatalf("Expected a resend inside the cooldown to be refused, got %v", err)
+	}
+
+	now = now.Add(time.Second)
+	second := verificationCode(t, setup, user)
+	if first != second {
+		// Only the latest code counts
+		_, err = setup.Business.ConfirmVerification(ctx, business.ConfirmVerificationInput{
+			UserID: user.UUID, Channel: models.VerificationChannelEmail, Code: first,
+		})
+		if !errors.Is(err, business.ErrInvalidVerificationCode) {
+			t.Errorf("Expected the replaced code to be refused, got %v", err)
+		}
+	}
+	confirmEmail(t, setup, user, second)
+}
+
+func TestVerificationPolicyBlockLogin(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+	setup.Config.VerificationPolicy = config.VerificationPolicyBlockLogin
+
+	user := AddPasswordUser(t, setup, "eli@example.com", "eli password")
+	result, err := setup.Business.Login(ctx, business.LoginInput{Email: user.Email, Password: "eli password"})
+	if err != nil {
+		t.Fatalf("Failed to log in: %v", err)
+	}
+	if result.Tokens != nil || !result.VerificationRequired ||
+		len(result.UnverifiedChannels) != 1 || result.UnverifiedChannels[0] != models.VerificationChannelEmail {
+		t.Fatalf("Expected sign-in to wait for email verification, got %+v", result)
+	}
+
+	confirmEmail(t, setup, user, verificationCode(t, setup, user))
+	SignIn(t, setup, user.Email, "eli password")
+}
+
+func TestVerificationPolicyRestrictScopes(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+	setup.Config.VerificationPolicy = config.VerificationPolicyRestrictScopes
+
+	user := AddPasswordUser(t, setup, "fay@example.com", "fay password")
+	claims, err := setup.Business.ValidateAccessToken(ctx, SignIn(t, setup, user.Email, "fay password").AccessToken)
+	if err != nil {
+		t.Fatalf("Failed to validate the token: %v", err)
+	}
+	if want := strings.Join(setup.Config.VerificationUnverifiedScopes, " "); claims.Scope != want {
+		t.Errorf("Expected an unverified user to get %q, got %q", want, claims.Scope)
+	}
+
+	confirmEmail(t, setup, user, verificationCode(t, setup, user))
+	claims, err = setup.Business.ValidateAccessToken(ctx, SignIn(t, setup, user.Email, "fay password").AccessToken)
+	if err != nil {
+		t.Fatalf("Failed to validate the token: %v", err)
+	}
+	if claims.Scope != "" {
+		t.Errorf("Expected a verified user's first-party token to be unrestricted, got %q", claims.Scope)
+	}
+}
+
+func TestValidateAccessTokenRevokedSession(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+
+	user := AddPasswordUser(t, setup, "gus@example.com", "gus password")
+	tokens := SignIn(t, setup, user.Email, "gus password")
+	if _, err := setup.Business.ValidateAccessToken(ctx, tokens.AccessToken); err != nil {
+		t.Fatalf("Failed to validate the token: %v", err)
+	}
+
+	if err := setup.Business.RevokeSession(ctx, user.UUID, tokens.SessionUUID, business.ClientInfo{}); err != nil {
+		t.Fatalf("Failed to revoke the session: %v", err)
+	}
+	if _, err := setup.Business.ValidateAccessToken(ctx, tokens.AccessToken); !errors.Is(err, business.ErrInvalidToken) {
+		t.Errorf("Expected the token of a revoked session to be refused, got %v", err)
+	}
+}
+
+func TestValidateAccessTokenIdleTimeout(t *testing.T) {
+	now := time.Now()
+	setup := SetupTestEnvironmentAt(t, &now)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+	// Well inside the absolute timeout, which would otherwise end the session first
+	idle := time.Hour
+	setup.Config.SessionIdleTimeout = idle
+
+	user := AddPasswordUser(t, setup, "hal@example.com", "hal password")
+	tokens := SignIn(t, setup, user.Email, "hal password")
+
+	// Activity inside the idle timeout keeps the session going past it
+	for i := 0; i < 2; i++ {
+		now = now.Add(idle - time.Minute)
+		if _, err := setup.Business.ValidateAccessToken(ctx, tokens.AccessToken); err != nil {
+			t.Fatalf("Expected an active session to stay live: %v", err)
+		}
+	}
+
+	now = now.Add(idle)
+	if _, err := setup.Business.ValidateAccessToken(ctx, tokens.AccessToken); !errors.Is(err, business.ErrInvalidToken) {
+		t.Fatalf("Expected an idle session to be refused, got %v", err)
+	}
+	session, _ := setup.Repo.GetSessionByUUID(ctx, tokens.SessionUUID)
+	if session.IsActive {
+		t.Error("Expected the idle session to be ended")
+	}
+	logs, _ := setup.Repo.ListLoginLogs(ctx, repository.LoginLogFilter{
+		UserID: &user.ID, EventTypes: []string{models.EventSessionExpired}, Limit: 10,
+	})
+	if len(logs) != 1 || logs[0].Meta["reason"] != "idle_timeout" {
+		t.Errorf("Expected one idle_timeout entry, got %+v", logs)
+	}
+}
+
+func TestValidateAccessTokenAbsoluteTimeout(t *testing.T) {
+	now := time.Now()
+	setup := SetupTestEnvironmentAt(t, &now)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+	setup.Config.SessionIdleTimeout = 0
+
+	user := AddPasswordUser(t, setup, "ivy@example.com", "ivy password")
+	tokens := SignIn(t, setup, user.Email, "ivy password")
+
+	now = now.Add(setup.Config.RefreshTokenExpiry - time.Minute)
+	if _, err := setup.Business.ValidateAccessToken(ctx, tokens.AccessToken); err != nil {
+		t.Fatalf("Expected the session to be live until its absolute timeout: %v", err)
+	}
+	now = now.Add(time.Minute)
+	if _, err := setup.Business.ValidateAccessToken(ctx, tokens.AccessToken); !errors.Is(err, business.ErrInvalidToken) {
+		t.Fatalf("Expected a session past its absolute timeout to be refused, got %v", err)
+	}
+	logs, _ := setup.Repo.ListLoginLogs(ctx, repository.LoginLogFilter{
+		UserID: &user.ID, EventTypes: []string{models.EventSessionExpired}, Limit: 10,
+	})
+	if len(logs) != 1 || logs[0].Meta["reason"] != "absolute_timeout" {
+		t.Errorf("Expected one absolute_timeout entry, got %+v", logs)
+	}
+}
+
+func TestValidateAccessTokenTouchesSessionOncePerMinute(t *testing.T) {
+	now := time.Now()
+	setup := SetupTestEnvironmentAt(t, &now)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+
+	user := AddPasswordUser(t, setup, "jon@example.com", "jon password")
+	tokens := SignIn(t, setup, user.Email, "jon password")
+	lastUsed := func() time.Time {
+		session, err := setup.Repo.GetSessionByUUID(ctx, tokens.SessionUUID)
+		if err != nil {
+			t.Fatalf("Failed to load the session: %v", err)
+		}
+		return session.LastUsedAt
+	}
+	validate := func() {
+		if _, err := setup.Business.ValidateAccessToken(ctx, tokens.AccessToken); err != nil {
+			t.Fatalf("Failed to validate the token: %v", err)
+		}
+	}
+	signedIn := lastUsed()
+
+	now = now.Add(30 * time.Second)
+	validate()
+	if got := lastUsed(); !got.Equal(signedIn) {
+		t.Errorf("Expected no write within a minute of the last one, last_active moved to %v", got)
+	}
+
+	now = signedIn.Add(time.Minute + time.Second)
+	validate()
+	if got := lastUsed(); !got.Equal(now) {
+		t.Errorf("Expected last_active to be %v once a minute has passed, got %v", now, got)
+	}
+	touched := now
+
+	now = now.Add(59 * time.Second)
+	validate()
+	if got := lastUsed(); !got.Equal(touched) {
+		t.Errorf("Expected no second write within the minute, last_active moved to %v", got)
+	}
+}
+
+// signInFrom logs in from the given device and returns the result, which may ask for a second factor
+func signInFrom(t *testing.T, setup *TestSetup, email, password string, client business.ClientInfo) *business.LoginResult {
+	t.Helper()
+	result, err := setup.Business.Login(context.Background(), business.LoginInput{Email: email, Password: password, Client: client})
+	if err != nil {
+		t.Fatalf("Failed to log in as %s: %v", email, err)
+	}
+	return result
+}
+
+// deviceByID returns the user's device with the given client identifier, or nil
+func deviceByID(t *testing.T, setup *TestSetup, user *models.User, deviceID string) *models.Device {
+	t.Helper()
+	devices, err := setup.Business.ListDevices(context.Background(), user.UUID)
+	if err != nil {
+		t.Fatalf("Failed to list devices: %v", err)
+	}
+	for _, device := range devices {
+		if device.DeviceID != nil && *device.DeviceID == deviceID {
+			return device
+		}
+	}
+	return nil
+}
+
+func TestNewDeviceAlertSkipsFirstDevice(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+
+	user := AddPasswordUser(t, setup, "kim@example.com", "kim password")
+	alerts := func() int {
+		logs, _ := setup.Repo.ListLoginLogs(ctx, repository.LoginLogFilter{
+			UserID: &user.ID, EventTypes: []string{models.EventNewDeviceSignIn}, Limit: 10,
+		})
+		return len(logs)
+	}
+
+	signInFrom(t, setup, user.Email, "kim password", business.ClientInfo{DeviceID: "laptop"})
+	if n := alerts(); n != 0 {
+		t.Fatalf("Expected no alert for the first device, got %d", n)
+	}
+	signInFrom(t, setup, user.Email, "kim password", business.ClientInfo{DeviceID: "laptop"})
+	signInFrom(t, setup, user.Email, "kim password", business.ClientInfo{DeviceID: "phone"})
+	if n := alerts(); n != 1 {
+		t.Fatalf("Expected one alert for the second device, got %d", n)
+	}
+	if msg := setup.Mail.LastMessage(t, user.Email); msg.Subject != "New sign-in to your account" {
+		t.Errorf("Expected a new sign-in email, got %q", msg.Subject)
+	}
+	signInFrom(t, setup, user.Email, "kim password", business.ClientInfo{DeviceID: "phone"})
+	if n := alerts(); n != 1 {
+		t.Errorf("Expected a known device not to raise another alert, got %d", n)
+	}
+}
+
+func TestTrustDeviceRefusesDerivedIDs(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+
+	user := AddPasswordUser(t, setup, "lou@example.com", "lou password")
+	signInFrom(t, setup, user.Email, "lou password", business.ClientInfo{UserAgent: "Mozilla/5.0 (X11; Linux x86_64) Firefox/128.0"})
+	// A client may not claim the reserved prefix for itself
+	signInFrom(t, setup, user.Email, "lou password", business.ClientInfo{DeviceID: "ua:forged"})
+
+	devices, err := setup.Business.ListDevices(ctx, user.UUID)
+	if err != nil {
+		t.Fatalf("Failed to list devices: %v", err)
+	}
+	if len(devices) != 1 || devices[0].DeviceID == nil || !strings.HasPrefix(*devices[0].DeviceID, "ua:") {
+		t.Fatalf("Expected only the device derived from the user agent, got %+v", devices)
+	}
+	if _, err := setup.Business.TrustDevice(ctx, user.UUID, devices[0].UUID); !errors.Is(err, business.ErrDeviceNotTrustable) {
+		t.Errorf("Expected a derived device to be refused, got %v", err)
+	}
+}
+
+func TestTrustedDeviceSkipsMFA(t *testing.T) {
+	now := time.Now()
+	setup := SetupTestEnvironmentAt(t, &now)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+	phone := business.ClientInfo{DeviceID: "phone"}
+
+	user := AddPasswordUser(t, setup, "max@example.com", "max password")
+	recoveryCodes := enrollTOTP(t, setup, user, SignIn(t, setup, user.Email, "max password").SessionUUID)
+
+	result := signInFrom(t, setup, user.Email, "max password", phone)
+	if !result.MFARequired {
+		t.Fatalf("Expected an unknown device to need the second factor, got %+v", result)
+	}
+	_, err := setup.Business.VerifyMFA(ctx, business.VerifyMFAInput{
+		MFAToken: result.MFAToken, Code: recoveryCodes[0], Client: phone, TrustDevice: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to verify MFA: %v", err)
+	}
+	if device := deviceByID(t, setup, user, "phone"); device == nil || !device.IsTrusted {
+		t.Fatalf("Expected the device to be trusted, got %+v", device)
+	}
+
+	now = now.Add(setup.Config.DeviceTrustDuration - time.Minute)
+	if result := signInFrom(t, setup, user.Email, "max password", phone); result.MFARequired || result.Tokens == nil {
+		t.Errorf("Expected the trusted device to skip the second factor, got %+v", result)
+	}
+	if result := signInFrom(t, setup, user.Email, "max password", business.ClientInfo{DeviceID: "laptop"}); !result.MFARequired {
+		t.Errorf("Expected another device to need the second factor, got %+v", result)
+	}
+
+	now = now.Add(time.Minute)
+	if result := signInFrom(t, setup, user.Email, "max password", phone); !result.MFARequired {
+		t.Errorf("Expected the second factor once the trust period ends, got %+v", result)
+	}
+}
+
+func TestRemovedDeviceReturnsUntrusted(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+	phone := business.ClientInfo{DeviceID: "phone"}
+
+	user := AddPasswordUser(t, setup, "ned@example.com", "ned password")
+	laptop := signInFrom(t, setup, user.Email, "ned password", business.ClientInfo{DeviceID: "laptop"})
+	recoveryCodes := enrollTOTP(t, setup, user, laptop.Tokens.SessionUUID)
+	result := signInFrom(t, setup, user.Email, "ned password", phone)
+	_, err := setup.Business.VerifyMFA(ctx, business.VerifyMFAInput{
+		MFAToken: result.MFAToken, Code: recoveryCodes[0], Client: phone, TrustDevice: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to verify MFA: %v", err)
+	}
+
+	device := deviceByID(t, setup, user, "phone")
+	if err := setup.Business.RemoveDevice(ctx, user.UUID, device.UUID); err != nil {
+		t.Fatalf("Failed to remove the device: %v", err)
+	}
+	result = signInFrom(t, setup, user.Email, "ned password", phone)
+	if !result.MFARequired {
+		t.Fatalf("Expected a removed device to need the second factor, got %+v", result)
+	}
+	if _, err := setup.Business.VerifyMFA(ctx, business.VerifyMFAInput{MFAToken: result.MFAToken, Code: recoveryCodes[1], Client: phone}); err != nil {
+		t.Fatalf("Failed to verify MFA: %v", err)
+	}
+	if again := deviceByID(t, setup, user, "phone"); again == nil || again.IsTrusted || again.TrustedAt != nil {
+		t.Errorf("Expected the device to come back untrusted, got %+v", again)
+	}
+	// The phone's first sign-in raised the first alert
+	logs, _ := setup.Repo.ListLoginLogs(ctx, repository.LoginLogFilter{
+		UserID: &user.ID, EventTypes: []string{models.EventNewDeviceSignIn}, Limit: 10,
+	})
+	if len(logs) != 2 {
+		t.Errorf("Expected the restored device to count as new, got %d alerts", len(logs))
+	}
+}
+
+// providerInput describes fake as the "example" provider
+func providerInput(fake *oauth2test.Provider) business.ProviderInput {
+	return business.ProviderInput{
+		Name:             "example",
+		DisplayName:      "Example",
+		ClientID:         fake.ClientID,
+		ClientSecret:     fake.ClientSecret,
+		AuthorizationURL: fake.AuthURL(),
+		TokenURL:         fake.TokenURL(),
+		UserinfoURL:      fake.UserinfoURL(),
+		Scopes:           []string{"profile", "email"},
+		Enabled:          true,
+	}
+}
+
+func TestProviderAdministration(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+	first := oauth2test.NewProvider("first-client", "first-secret")
+	defer first.Close()
+	second := oauth2test.NewProvider("second-client", "second-secret")
+	defer second.Close()
+
+	provider, err := setup.Business.CreateProvider(ctx, providerInput(first))
+	if err != nil {
+		t.Fatalf("Failed to create the provider: %v", err)
+	}
+	if _, err := setup.Business.CreateProvider(ctx, providerInput(first)); !errors.Is(err, business.ErrProviderExists) {
+		t.Errorf("Expected a second provider named example to be refused, got %v", err)
+	}
+	invalid := providerInput(first)
+	invalid.Name = "Not a name!"
+	if _, err := setup.Business.CreateProvider(ctx, invalid); !errors.Is(err, business.ErrInvalidArgument) {
+		t.Errorf("Expected an invalid name to be refused, got %v", err)
+	}
+	if _, err := oauthSignIn(t, setup, first, map[string]interface{}{"sub": "ex-1", "email": "olga@example.com", "email_verified": true}); err != nil {
+		t.Fatalf("Expected sign-in with the new provider: %v", err)
+	}
+
+	// The update takes effect on this replica without waiting for a refresh
+	if _, err := setup.Business.UpdateProvider(ctx, provider.UUID, providerInput(second)); err != nil {
+		t.Fatalf("Failed to update the provider: %v", err)
+	}
+	if _, err := oauthSignIn(t, setup, second, map[string]interface{}{"sub": "ex-2", "email": "pia@example.com", "email_verified": true}); err != nil {
+		t.Fatalf("Expected sign-in through the updated endpoints: %v", err)
+	}
+
+	code, state := oauthCallback(t, setup, second, "example", "", map[string]interface{}{"sub": "ex-3", "email": "quin@example.com", "email_verified": true})
+	if _, err := setup.Business.DisableProvider(ctx, provider.UUID); err != nil {
+		t.Fatalf("Failed to disable the provider: %v", err)
+	}
+	if _, err := setup.Business.BeginOAuth(ctx, business.BeginOAuthInput{Provider: "example"}); !errors.Is(err, business.ErrProviderNotFound) {
+		t.Errorf("Expected a disabled provider not to start sign-in, got %v", err)
+	}
+	_, err = setup.Business.CompleteOAuth(ctx, business.CompleteOAuthInput{Provider: "example", Code: code, State: state})
+	if !errors.Is(err, business.ErrProviderNotFound) {
+		t.Errorf("Expected a sign-in started before the provider was disabled to be refused, got %v", err)
+	}
+	providers, _ := setup.Business.ListProviders(ctx)
+	if len(providers) != 1 || providers[0].IsEnabled || providers[0].ClientID != "second-client" {
+		t.Errorf("Expected the disabled provider to stay listed for administration, got %+v", providers)
+	}
+}
+
+func TestRefreshProvidersPicksUpOtherReplicas(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+	fake := addOAuthProvider(t, setup, "example")
+	provider, err := setup.Repo.GetProviderByName(ctx, "example")
+	if err != nil {
+		t.Fatalf("Failed to load the provider: %v", err)
+	}
+
+	if reloaded, err := setup.Business.RefreshProviders(ctx); err != nil || reloaded {
+		t.Fatalf("Expected nothing to reload, got %v, %v", reloaded, err)
+	}
+	code, state := oauthCallback(t, setup, fake, "example", "", map[string]interface{}{"sub": "ex-4", "email": "ray@example.com", "email_verified": true})
+
+	// Another replica disables the provider; this one only notices on refresh
+	if _, err := setup.Repo.SetProviderEnabled(ctx, provider.ID, false); err != nil {
+		t.Fatalf("Failed to disable the provider: %v", err)
+	}
+	if reloaded, err := setup.Business.RefreshProviders(ctx); err != nil || !reloaded {
+		t.Fatalf("Expected the change to reload the cache, got %v, %v", reloaded, err)
+	}
+	_, err = setup.Business.CompleteOAuth(ctx, business.CompleteOAuthInput{Provider: "example", Code: code, State: state})
+	if !errors.Is(err, business.ErrProviderNotFound) {
+		t.Errorf("Expected the reloaded cache to refuse the disabled provider, got %v", err)
+	}
+	if reloaded, _ := setup.Business.RefreshProviders(ctx); reloaded {
+		t.Error("Expected no reload without a further change")
+	}
+}
@@ -1,9 +1,20 @@
 package tests
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
 	"github.com/your-project/services/auth/internal/business"
 	"github.com/your-project/services/auth/internal/handlers"
 	"github.com/your-project/services/auth/internal/repository"
@@ -17,7 +28,7 @@ func TestNewAuthHandler(t *testing.T) {
 	repo := repository.NewAuthRepository(db)
 
 	// Create business layer
-	business := business.NewAuthBusiness(repo)
+	business := business.NewAuthBusiness(repo, NewTestConfig(t))
 
 	// Test handler creation
 	handler := handlers.NewAuthHandler(business)
@@ -27,9 +38,258 @@ func TestNewAuthHandler(t *testing.T) {
 	}
 }
 
+func TestLoginInvalidArgument(t *testing.T) {
+	repo := repository.NewAuthRepository(&sql.DB{})
+	handler := handlers.NewAuthHandler(business.NewAuthBusiness(repo, NewTestConfig(t)))
+
+	_, err := handler.Login(context.Background(), &handlers.LoginRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestOAuthServerMetadata(t *testing.T) {
+	cfg := NewTestConfig(t)
+	server := handlers.NewOAuthServer(business.NewAuthBusiness(repository.NewAuthRepository(&sql.DB{}), cfg))
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, business.OAuthDiscoveryPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 from discovery, got %d", rec.Code)
+	}
+	var discovery business.DiscoveryDocument
+	if err := json.NewDecoder(rec.Body).Decode(&discovery); err != nil {
+		t.Fatalf("Failed to decode discovery document: %v", err)
+	}
+	if discovery.Issuer != cfg.OAuthIssuer || discovery.JWKSURI != cfg.OAuthIssuer+business.OAuthJWKSPath {
+		t.Errorf("Unexpected discovery endpoints: %+v", discovery)
+	}
+
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, business.OAuthJWKSPath, nil))
+	var jwks struct {
+		Keys []struct {
+			KeyID     string `json:"kid"`
+			Algorithm string `json:"alg"`
+			D         string `json:"d"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&jwks); err != nil {
+		t.Fatalf("Failed to decode JWKS: %v", err)
+	}
+	if len(jwks.Keys) != 1 || jwks.Keys[0].KeyID == "" || jwks.Keys[0].Algorithm != discovery.IDTokenSigningAlgValuesSupported[0] {
+		t.Errorf("Unexpected JWKS: %+v", jwks)
+	}
+	if jwks.Keys[0].D != "" {
+		t.Error("JWKS must not publish private key material")
+	}
+}
+
+func TestOAuthServerErrors(t *testing.T) {
+	server := handlers.NewOAuthServer(business.NewAuthBusiness(repository.NewAuthRepository(&sql.DB{}), NewTestConfig(t)))
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, business.OAuthTokenPath, nil))
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != http.MethodPost {
+		t.Errorf("Expected 405 for GET /token, got %d", rec.Code)
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	req := httptest.NewRequest(http.MethodPost, business.OAuthTokenPath, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	var body struct {
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode token error: %v", err)
+	}
+	if rec.Code != http.StatusUnauthorized || body.Error != business.OAuthErrInvalidClient {
+		t.Errorf("Expected 401 invalid_client, got %d %q", rec.Code, body.Error)
+	}
+	if rec.Header().Get("Cache-Control") != "no-store" {
+		t.Error("Token responses must not be cached")
+	}
+
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, business.OAuthUserinfoPath, nil))
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") != "Bearer" {
+		t.Errorf("Expected 401 Bearer challenge from userinfo, got %d", rec.Code)
+	}
+}
+
+// tokenRequest posts form to the token endpoint as the client and decodes the JSON response
+func tokenRequest(t *testing.T, server *handlers.OAuthServer, clientID, clientSecret string, form url.Values) (int, map[string]interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, business.OAuthTokenPath, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode token response: %v", err)
+	}
+	return rec.Code, body
+}
+
+func TestOAuthServerAuthorizationCodeFlow(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	ctx := context.Background()
+	server := handlers.NewOAuthServer(setup.Business)
+
+	const redirectURI = "https://app.example.com/callback"
+	client, secret, err := setup.Business.CreateOAuthClient(ctx, business.OAuthClientInput{
+		ClientID:     "reports-app",
+		Name:         "Reports",
+		RedirectURIs: []string{redirectURI},
+		GrantTypes:   []string{"authorization_code", "refresh_token", "client_credentials"},
+		Scopes:       []string{"openid email reports.read"},
+		Confidential: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	user := AddPasswordUser(t, setup, "paul@example.com", "paul password")
+	signIn := SignIn(t, setup, "paul@example.com", "paul password")
+
+	// authorize runs /authorize with an S256 challenge for verifier and approves it as paul
+	authorize := func(verifier string) string {
+		t.Helper()
+		sum := sha256.Sum256([]byte(verifier))
+		query := url.Values{
+			"response_type":         {"code"},
+			"client_id":             {client.ClientID},
+			"redirect_uri":          {redirectURI},
+			"scope":                 {"openid email"},
+			"state":                 {"xyz"},
+			"code_challenge":        {base64.RawURLEncoding.EncodeToString(sum[:])},
+			"code_challenge_method": {"S256"},
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, business.OAuthAuthorizePath+"?"+query.Encode(), nil))
+		if rec.Code != http.StatusFound {
+			t.Fatalf("Expected a redirect to the sign-in page, got %d: %s", rec.Code, rec.Body)
+		}
+		login, err := url.Parse(rec.Header().Get("Location"))
+		if err != nil || login.Query().Get("request_id") == "" {
+			t.Fatalf("Expected a request_id on the sign-in redirect, got %q", rec.Header().Get("Location"))
+		}
+
+		target, err := setup.Business.ApproveAuthorization(ctx, login.Query().Get("request_id"), signIn.AccessToken)
+		if err != nil {
+			t.Fatalf("Failed to approve authorization: %v", err)
+		}
+		callback, err := url.Parse(target)
+		if err != nil || callback.Query().Get("code") == "" || callback.Query().Get("state") != "xyz" {
+			t.Fatalf("Expected a code and the state on the callback, got %q", target)
+		}
+		return callback.Query().Get("code")
+	}
+	exchange := func(code, verifier string) (int, map[string]interface{}) {
+		t.Helper()
+		return tokenRequest(t, server, client.ClientID, secret, url.Values{
+			"grant_type":    {"authorization_code"},
+			"code":          {code},
+			"redirect_uri":  {redirectURI},
+			"code_verifier": {verifier},
+		})
+	}
+
+	const verifier = "a-verifier-that-is-long-enough-for-pkce-0123456789"
+	status, body := exchange(authorize(verifier), "a-different-verifier-that-is-also-long-enough-012")
+	if status != http.StatusBadRequest || body["error"] != business.OAuthErrInvalidGrant {
+		t.Errorf("Expected invalid_grant for a wrong code_verifier, got %d %v", status, body)
+	}
+
+	code := authorize(verifier)
+	status, tokens := exchange(code, verifier)
+	if status != http.StatusOK || tokens["access_token"] == nil || tokens["refresh_token"] == nil || tokens["id_token"] == nil {
+		t.Fatalf("Expected access, refresh and ID tokens, got %d %v", status, tokens)
+	}
+	if tokens["scope"] != "openid email" {
+		t.Errorf("Expected the requested scope to be granted, got %v", tokens["scope"])
+	}
+
+	// userinfo answers for the issued access token
+	req := httptest.NewRequest(http.MethodGet, business.OAuthUserinfoPath, nil)
+	req.Header.Set("Authorization", "Bearer "+tokens["access_token"].(string))
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	var info map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&info); err != nil {
+		t.Fatalf("Failed to decode userinfo: %v", err)
+	}
+	if rec.Code != http.StatusOK || info["sub"] != user.UUID || info["email"] != "paul@example.com" {
+		t.Errorf("Expected paul's claims from userinfo, got %d %v", rec.Code, info)
+	}
+
+	status, refreshed := tokenRequest(t, server, client.ClientID, secret, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {tokens["refresh_token"].(string)},
+	})
+	if status != http.StatusOK || refreshed["access_token"] == nil || refreshed["refresh_token"] == tokens["refresh_token"] {
+		t.Errorf("Expected a new access token and a rotated refresh token, got %d %v", status, refreshed)
+	}
+
+	status, body = exchange(code, verifier)
+	if status != http.StatusBadRequest || body["error"] != business.OAuthErrInvalidGrant {
+		t.Errorf("Expected invalid_grant for a code redeemed twice, got %d %v", status, body)
+	}
+}
+
+func TestOAuthServerClientCredentialsScopes(t *testing.T) {
+	setup := SetupTestEnvironment(t)
+	defer CleanupTestEnvironment(t, setup)
+	server := handlers.NewOAuthServer(setup.Business)
+
+	client, secret, err := setup.Business.CreateOAuthClient(context.Background(), business.OAuthClientInput{
+		ClientID:     "billing-job",
+		Name:         "Billing",
+		GrantTypes:   []string{"client_credentials"},
+		Scopes:       []string{"openid reports.read invoices.write"},
+		Confidential: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	grant := func(scope string) (int, map[string]interface{}) {
+		t.Helper()
+		return tokenRequest(t, server, client.ClientID, secret, url.Values{"grant_type": {"client_credentials"}, "scope": {scope}})
+	}
+
+	// Without a scope the client gets every registered scope that does not need a user
+	status, body := grant("")
+	if status != http.StatusOK || body["scope"] != "reports.read invoices.write" || body["refresh_token"] != nil {
+		t.Errorf("Expected the client's own scopes and no refresh token, got %d %v", status, body)
+	}
+	status, body = grant("reports.read")
+	if status != http.StatusOK || body["scope"] != "reports.read" {
+		t.Errorf("Expected the token to be limited to reports.read, got %d %v", status, body)
+	}
+
+	for _, scope := range []string{"admin", "openid"} {
+		if status, body := grant(scope); status != http.StatusBadRequest || body["error"] != business.OAuthErrInvalidScope {
+			t.Errorf("Expected invalid_scope for %q, got %d %v", scope, status, body)
+		}
+	}
+
+	// A client token has no user to describe
+	_, body = grant("reports.read")
+	req := httptest.NewRequest(http.MethodGet, business.OAuthUserinfoPath, nil)
+	req.Header.Set("Authorization", "Bearer "+body["access_token"].(string))
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 from userinfo for a client token, got %d", rec.Code)
+	}
+}
+
 // TODO: Add more handler tests when gRPC methods are implemented
 // - TestRegister
-// - TestLogin
 // - TestValidateToken
 // - TestRefreshToken
 // - TestLogout
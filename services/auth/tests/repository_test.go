@@ -1,10 +1,16 @@
 package tests
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/your-project/services/auth/internal/models"
 	"github.com/your-project/services/auth/internal/repository"
+	"github.com/your-project/services/auth/internal/repository/memory"
 )
 
 func TestNewAuthRepository(t *testing.T) {
@@ -23,9 +29,303 @@ func TestNewAuthRepository(t *testing.T) {
 	}
 }
 
-// TODO: Add more repository tests when methods are implemented
-// - TestCreateUser
-// - TestGetUserByEmail
-// - TestUpdateUser
-// - TestDeleteUser
-// - TestValidateCredentials
+func TestMemoryStoreUserUniqueness(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+
+	user := &models.User{Email: "alice@example.com", PasswordHash: "hash"}
+	if err := store.AddUser(ctx, user); err != nil {
+		t.Fatalf("Failed to add user: %v", err)
+	}
+	if user.ID == 0 || user.UUID == "" || !user.IsActive || user.AuthMethod != models.AuthMethodPassword {
+		t.Errorf("Expected stored defaults, got %+v", user)
+	}
+
+	if err := store.AddUser(ctx, &models.User{Email: "alice@example.com"}); !errors.Is(err, repository.ErrDuplicate) {
+		t.Errorf("Expected ErrDuplicate for a taken email, got %v", err)
+	}
+
+	found, err := store.GetUserByEmail(ctx, "ALICE@example.com")
+	if err != nil || found.ID != user.ID {
+		t.Fatalf("Expected email lookup to ignore case, got %v, %v", found, err)
+	}
+	inUse, err := store.IsContactInUse(ctx, models.VerificationChannelEmail, "Alice@Example.com", 0)
+	if err != nil || !inUse {
+		t.Errorf("Expected email to be in use, got %v, %v", inUse, err)
+	}
+}
+
+func TestMemoryStoreSoftDelete(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := memory.New(memory.WithClock(func() time.Time { return now }))
+
+	user := &models.User{Email: "bob@example.com", PasswordHash: "hash"}
+	if err := store.AddUser(ctx, user); err != nil {
+		t.Fatalf("Failed to add user: %v", err)
+	}
+	if _, err := store.SoftDeleteUser(ctx, user.ID, now.Add(time.Hour)); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+
+	if _, err := store.GetUserByUUID(ctx, user.UUID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Expected deleted user to be hidden, got %v", err)
+	}
+	// The unique index on email covers deleted rows
+	if err := store.AddUser(ctx, &models.User{Email: "bob@example.com"}); !errors.Is(err, repository.ErrDuplicate) {
+		t.Errorf("Expected ErrDuplicate for the email of a deleted user, got %v", err)
+	}
+
+	if _, err := store.RestoreUser(ctx, user.ID); err != nil {
+		t.Fatalf("Failed to restore user: %v", err)
+	}
+	if _, err := store.GetUserByUUID(ctx, user.UUID); err != nil {
+		t.Errorf("Expected restored user to be found, got %v", err)
+	}
+}
+
+func TestMemoryStoreProviderLinks(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+
+	provider := &models.Provider{Name: "google", DisplayName: "Google"}
+	if err := store.CreateProvider(ctx, provider); err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	if err := store.CreateProvider(ctx, &models.Provider{Name: "google"}); !errors.Is(err, repository.ErrDuplicate) {
+		t.Errorf("Expected ErrDuplicate for a taken provider name, got %v", err)
+	}
+
+	user := &models.User{Email: "carol@example.com"}
+	link := &models.UserProvider{ProviderID: provider.ID, ProviderUserID: "google-1"}
+	if err := store.CreateOAuthUser(ctx, user, link); err != nil {
+		t.Fatalf("Failed to create oauth user: %v", err)
+	}
+	if !link.IsPrimary || user.PrimaryProviderID == nil || *user.PrimaryProviderID != provider.ID {
+		t.Errorf("Expected the first link to be primary, got link %+v user %+v", link, user)
+	}
+
+	other := &models.User{Email: "dave@example.com", PasswordHash: "hash"}
+	if err := store.AddUser(ctx, other); err != nil {
+		t.Fatalf("Failed to add user: %v", err)
+	}
+	taken := &models.UserProvider{UserID: other.ID, ProviderID: provider.ID, ProviderUserID: "google-1"}
+	if err := store.LinkUserProvider(ctx, taken); !errors.Is(err, repository.ErrDuplicate) {
+		t.Errorf("Expected ErrDuplicate for a linked provider account, got %v", err)
+	}
+
+	if _, err := store.UnlinkUserProvider(ctx, user.ID, link.UUID); !errors.Is(err, repository.ErrLastLoginMethod) {
+		t.Errorf("Expected ErrLastLoginMethod, got %v", err)
+	}
+}
+
+func TestMemoryStoreUpsertDevice(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	deviceID := "device-1"
+
+	first := &models.Device{UserID: 1, DeviceID: &deviceID}
+	isNew, err := store.UpsertDevice(ctx, first)
+	if err != nil || !isNew {
+		t.Fatalf("Expected a new device, got %v, %v", isNew, err)
+	}
+	again := &models.Device{UserID: 1, DeviceID: &deviceID}
+	isNew, err = store.UpsertDevice(ctx, again)
+	if err != nil || isNew || again.ID != first.ID {
+		t.Errorf("Expected the same device row, got %v, %v, id %d", isNew, err, again.ID)
+	}
+
+	if err := store.DeleteDevice(ctx, 1, first.UUID); err != nil {
+		t.Fatalf("Failed to delete device: %v", err)
+	}
+	restored := &models.Device{UserID: 1, DeviceID: &deviceID}
+	isNew, err = store.UpsertDevice(ctx, restored)
+	if err != nil || !isNew || restored.ID != first.ID || restored.IsTrusted {
+		t.Errorf("Expected the deleted device to be restored untrusted, got %v, %v, %+v", isNew, err, restored)
+	}
+}
+
+func TestMemoryStoreConsumeActionTokenOnce(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+
+	token := &models.ActionToken{UserID: 1, Purpose: "password_reset", TokenHash: "hash", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := store.CreateActionToken(ctx, token); err != nil {
+		t.Fatalf("Failed to create action token: %v", err)
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		successes int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := store.ConsumeActionToken(ctx, "hash", "password_reset"); err == nil {
+				mu.Lock()
+				successes++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if successes != 1 {
+		t.Errorf("Expected the token to be consumed exactly once, got %d", successes)
+	}
+}
+
+func TestMemoryStoreWithTx(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	errAbort := errors.New("abort")
+
+	err := store.WithTx(ctx, func(ctx context.Context, tx repository.Store) error {
+		if err := tx.CreateOAuthClient(ctx, &models.OAuthClient{ClientID: "committed", Name: "Committed"}); err != nil {
+			return err
+		}
+		// Calls on the outer store with the transaction's ctx join it
+		return store.CreateOAuthClient(ctx, &models.OAuthClient{ClientID: "joined", Name: "Joined"})
+	})
+	if err != nil {
+		t.Fatalf("Failed to commit transaction: %v", err)
+	}
+
+	err = store.WithTx(ctx, func(ctx context.Context, tx repository.Store) error {
+		if err := tx.CreateOAuthClient(ctx, &models.OAuthClient{ClientID: "rolled-back", Name: "Rolled back"}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("Expected the callback error, got %v", err)
+	}
+
+	for clientID, want := range map[string]error{
+		"committed":   nil,
+		"joined":      nil,
+		"rolled-back": repository.ErrNotFound,
+	} {
+		if _, err := store.GetOAuthClientByClientID(ctx, clientID); !errors.Is(err, want) {
+			t.Errorf("Expected %v looking up %s, got %v", want, clientID, err)
+		}
+	}
+}
+
+func TestMemoryStoreNestedWithTx(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+
+	err := store.WithTx(ctx, func(ctx context.Context, tx repository.Store) error {
+		if err := tx.CreateOAuthClient(ctx, &models.OAuthClient{ClientID: "outer", Name: "Outer"}); err != nil {
+			return err
+		}
+		nested := tx.WithTx(ctx, func(ctx context.Context, tx repository.Store) error {
+			if err := tx.CreateOAuthClient(ctx, &models.OAuthClient{ClientID: "inner", Name: "Inner"}); err != nil {
+				return err
+			}
+			return tx.CreateOAuthClient(ctx, &models.OAuthClient{ClientID: "outer", Name: "Outer again"})
+		})
+		if !errors.Is(nested, repository.ErrDuplicate) {
+			t.Errorf("Expected ErrDuplicate from the nested transaction, got %v", nested)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to commit transaction: %v", err)
+	}
+
+	if _, err := store.GetOAuthClientByClientID(ctx, "outer"); err != nil {
+		t.Errorf("Expected the outer insert to survive, got %v", err)
+	}
+	if _, err := store.GetOAuthClientByClientID(ctx, "inner"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Expected the nested insert to be rolled back, got %v", err)
+	}
+}
+
+func TestMemoryStorePruneProviderTokens(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := memory.New(memory.WithClock(func() time.Time { return now }))
+
+	provider := &models.Provider{Name: "google", DisplayName: "Google"}
+	if err := store.CreateProvider(ctx, provider); err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	link := func(email string, expiresAt time.Time) (*models.User, *models.UserProvider) {
+		user := &models.User{Email: email, PasswordHash: "hash"}
+		if err := store.AddUser(ctx, user); err != nil {
+			t.Fatalf("Failed to add user: %v", err)
+		}
+		access, refresh := "sealed-access", "sealed-refresh"
+		l := &models.UserProvider{UserID: user.ID, ProviderID: provider.ID, ProviderUserID: email,
+			AccessToken: &access, RefreshToken: &refresh, ExpiresAt: &expiresAt}
+		if err := store.LinkUserProvider(ctx, l); err != nil {
+			t.Fatalf("Failed to link: %v", err)
+		}
+		return user, l
+	}
+
+	unlinkedUser, unlinked := link("erin@example.com", now.Add(time.Hour))
+	if _, err := store.UnlinkUserProvider(ctx, unlinkedUser.ID, unlinked.UUID); err != nil {
+		t.Fatalf("Failed to unlink: %v", err)
+	}
+	_, expired := link("frank@example.com", now.Add(-time.Hour))
+	now = now.Add(60 * 24 * time.Hour)
+	liveUser, live := link("gina@example.com", now.Add(time.Hour))
+
+	removed, err := store.PruneExpiredRows(ctx, "user_providers", now.Add(-30*24*time.Hour), 10)
+	if err != nil || removed != 2 {
+		t.Fatalf("Expected the tokens of 2 links to be cleared, got %d, %v", removed, err)
+	}
+	// The unlinked row is hidden from lookups; the expired one keeps its refresh token
+	stored, err := store.GetUserProviderByID(ctx, expired.ID)
+	if err != nil || stored.AccessToken != nil || stored.ExpiresAt != nil {
+		t.Errorf("Expected the expired link to lose its access token, got %+v, %v", stored, err)
+	}
+	if stored != nil && (stored.RefreshToken == nil || *stored.RefreshToken != "sealed-refresh") {
+		t.Errorf("Expected the active expired link to keep its refresh token, got %v", stored.RefreshToken)
+	}
+	stored, err = store.GetUserProviderByUUID(ctx, liveUser.ID, live.UUID)
+	if err != nil || stored.AccessToken == nil || stored.RefreshToken == nil {
+		t.Errorf("Expected the live link to keep its tokens, got %+v, %v", stored, err)
+	}
+
+	if removed, _ := store.PruneExpiredRows(ctx, "user_providers", now.Add(-30*24*time.Hour), 10); removed != 0 {
+		t.Errorf("Expected nothing left to clear, got %d", removed)
+	}
+}
+
+func TestMemoryStorePruneSessionDetachesLoginLogs(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := memory.New(memory.WithClock(func() time.Time { return now }))
+
+	user := &models.User{Email: "hank@example.com", PasswordHash: "hash"}
+	if err := store.AddUser(ctx, user); err != nil {
+		t.Fatalf("Failed to add user: %v", err)
+	}
+	session := &models.Session{UserID: user.ID, RefreshTokenHash: "refresh", ExpiresAt: now.Add(time.Hour),
+		Meta: map[string]interface{}{"impersonated_by": "admin-uuid"}}
+	if err := store.CreateSession(ctx, session); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	entry := &models.LoginLog{UserID: &user.ID, SessionID: &session.ID, EventType: models.EventImpersonationStarted, Success: true}
+	if err := store.CreateLoginLog(ctx, entry); err != nil {
+		t.Fatalf("Failed to create login log: %v", err)
+	}
+
+	now = now.Add(60 * 24 * time.Hour)
+	if removed, err := store.PruneExpiredRows(ctx, "sessions", now.Add(-30*24*time.Hour), 10); err != nil || removed != 1 {
+		t.Fatalf("Expected the session to be pruned, got %d, %v", removed, err)
+	}
+	logs, err := store.ListLoginLogs(ctx, repository.LoginLogFilter{UserID: &user.ID, Limit: 10})
+	if err != nil || len(logs) != 1 {
+		t.Fatalf("Expected the login log to be kept, got %v, %v", logs, err)
+	}
+	if logs[0].SessionID != nil {
+		t.Errorf("Expected session_id to be set to NULL, got %d", *logs[0].SessionID)
+	}
+}